@@ -2,6 +2,7 @@ package account
 
 import (
 	"database/sql/driver"
+	"strings"
 	"time"
 
 	"github.com/almighty/almighty-core/app"
@@ -65,6 +66,10 @@ type Identity struct {
 	Username string
 	// Whether username has been updated.
 	RegistrationCompleted bool `gorm:"column:registration_completed"`
+	// When the identity was deactivated, nil if the identity is active
+	DeactivatedAt *time.Time `gorm:"column:deactivated_at"`
+	// When the identity last logged in, nil if it never did
+	LastLoginAt *time.Time `gorm:"column:last_login_at"`
 	// ProviderType The type of provider, such as "keycloak", "github", "oso", etc
 	ProviderType string `gorm:"column:provider_type"`
 	// the URL of the profile on the remote work item service
@@ -80,6 +85,25 @@ func (m Identity) TableName() string {
 	return "identities"
 }
 
+// DisplayName returns the name to display for the given identity and its (optional) user:
+// the full name of the user if set, otherwise the username of the identity,
+// otherwise the local part of the email address of the user.
+func DisplayName(identity Identity, user *User) string {
+	if user != nil && strings.TrimSpace(user.FullName) != "" {
+		return strings.TrimSpace(user.FullName)
+	}
+	if strings.TrimSpace(identity.Username) != "" {
+		return strings.TrimSpace(identity.Username)
+	}
+	if user != nil && user.Email != "" {
+		if i := strings.Index(user.Email, "@"); i >= 0 {
+			return user.Email[:i]
+		}
+		return user.Email
+	}
+	return ""
+}
+
 // TODO: Remove. Data layer should not know about the REST layer. Moved to /users.go
 // ConvertIdentityFromModel convert identity from model to app representation
 func (m Identity) ConvertIdentityFromModel() *app.Identity {
@@ -118,6 +142,8 @@ type IdentityRepository interface {
 	Query(funcs ...func(*gorm.DB) *gorm.DB) ([]*Identity, error)
 	List(ctx context.Context) (*app.IdentityArray, error)
 	IsValid(context.Context, uuid.UUID) bool
+	Search(ctx context.Context, q string, among []uuid.UUID, start int, limit int) ([]*Identity, int, error)
+	CompleteRegistration(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
 // TableName overrides the table name settings in Gorm to force a specific table name
@@ -283,6 +309,86 @@ func (m *GormIdentityRepository) First(funcs ...func(*gorm.DB) *gorm.DB) (*Ident
 	return nil, nil
 }
 
+// Search returns the active Keycloak identities, with their user, whose username or whose user's full name or email
+// contains the given term, regardless of the case. Exact matches come first, then the matches starting with the term,
+// then the others. When 'among' is not nil, only the identities with one of the given IDs are searched.
+// The total number of matching identities is returned along with the requested page.
+func (m *GormIdentityRepository) Search(ctx context.Context, q string, among []uuid.UUID, start int, limit int) ([]*Identity, int, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "identity", "search"}, time.Now())
+	if among != nil && len(among) == 0 {
+		return []*Identity{}, 0, nil
+	}
+
+	q = strings.ToLower(q)
+	prefix := likeEscaper.Replace(q) + "%"
+	substring := "%" + prefix
+	amongClause := ""
+	args := []interface{}{KeycloakIDP, substring, substring, substring}
+	if among != nil {
+		amongClause = "AND i.id IN (?)"
+		args = append(args, among)
+	}
+	args = append(args, q, q, q, prefix, prefix, prefix, limit, start)
+	rows, err := m.db.Raw(`SELECT i.id, count(*) OVER () FROM identities i JOIN users u ON u.id = i.user_id
+		WHERE i.provider_type = ? AND i.deactivated_at IS NULL AND i.deleted_at IS NULL AND u.deleted_at IS NULL
+		AND (lower(i.username) LIKE ? OR lower(u.full_name) LIKE ? OR lower(u.email) LIKE ?) `+amongClause+`
+		ORDER BY CASE
+			WHEN lower(i.username) = ? OR lower(u.full_name) = ? OR lower(u.email) = ? THEN 0
+			WHEN lower(i.username) LIKE ? OR lower(u.full_name) LIKE ? OR lower(u.email) LIKE ? THEN 1
+			ELSE 2
+		END, i.username, i.id
+		LIMIT ? OFFSET ?`, args...).Rows()
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"q":   q,
+			"err": err,
+		}, "unable to search the identities")
+		return nil, 0, errors.WithStack(err)
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	count := 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, 0, errors.WithStack(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	if len(ids) == 0 {
+		if start > 0 {
+			// the requested page is after the last match, count the matches without paging
+			_, count, err = m.Search(ctx, q, among, 0, 1)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		return []*Identity{}, count, nil
+	}
+
+	// load the identities of the page and restore the ranking order
+	found, err := m.Query(IdentityWithUser(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN (?)", ids)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]*Identity, len(found))
+	for _, identity := range found {
+		byID[identity.ID] = identity
+	}
+	result := make([]*Identity, 0, len(ids))
+	for _, id := range ids {
+		if identity, ok := byID[id]; ok {
+			result = append(result, identity)
+		}
+	}
+	return result, count, nil
+}
+
 // IdentityFilterByUserID is a gorm filter for a Belongs To relationship.
 func IdentityFilterByUserID(userID uuid.UUID) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -297,6 +403,29 @@ func IdentityFilterByUsername(username string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// IdentityFilterByUsernameContaining is a gorm filter for the usernames containing the given term, regardless of the case
+func IdentityFilterByUsernameContaining(term string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("username ILIKE ?", containsPattern(term))
+	}
+}
+
+// IdentityFilterByQuery is a gorm filter for the identities whose username or whose user's full name or email
+// contains the given term, regardless of the case
+func IdentityFilterByQuery(q string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := containsPattern(q)
+		return db.Where(`username ILIKE ? OR user_id IN (SELECT id FROM users WHERE deleted_at IS NULL AND (full_name ILIKE ? OR email ILIKE ?))`, pattern, pattern, pattern)
+	}
+}
+
+// IdentityFilterByUsernames is a gorm filter by any of the given 'username's
+func IdentityFilterByUsernames(usernames []string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("username IN (?)", usernames)
+	}
+}
+
 // IdentityFilterByProfileURL is a gorm filter by 'profile_url'
 func IdentityFilterByProfileURL(profileURL string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -311,6 +440,28 @@ func IdentityFilterByID(identityID uuid.UUID) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// IdentityFilterByIDs is a gorm filter by any of the given Identity IDs
+func IdentityFilterByIDs(identityIDs []uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN (?)", identityIDs)
+	}
+}
+
+// IdentityFilterByLastLoginBefore is a gorm filter for active identities which last logged in before the given time.
+func IdentityFilterByLastLoginBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("last_login_at < ? AND deactivated_at IS NULL", t)
+	}
+}
+
+// likeEscaper escapes the wildcards of the LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// containsPattern returns the LIKE pattern matching the values containing the given term
+func containsPattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
 // IdentityWithUser is a gorm filter for preloading the User relationship.
 func IdentityWithUser() func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -355,6 +506,28 @@ func (m *GormIdentityRepository) List(ctx context.Context) (*app.IdentityArray,
 	return &res, nil
 }
 
+// CompleteRegistration marks the registration of the given identity as completed, in a single statement so that
+// concurrent calls can't both complete it. It returns false if the registration was already completed.
+func (m *GormIdentityRepository) CompleteRegistration(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "identity", "complete_registration"}, time.Now())
+	db := m.db.Model(&Identity{}).Where("id = ? AND registration_completed = ?", id, false).Updates(map[string]interface{}{
+		"registration_completed": true,
+		"updated_at":             time.Now(),
+	})
+	if db.Error != nil {
+		log.Error(ctx, map[string]interface{}{
+			"identity_id": id,
+			"err":         db.Error,
+		}, "unable to complete the registration of the identity")
+		return false, errors.WithStack(db.Error)
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"identity_id": id,
+		"completed":   db.RowsAffected > 0,
+	}, "Identity registration completed!")
+	return db.RowsAffected > 0, nil
+}
+
 // IsValid returns true if the identity exists
 func (m *GormIdentityRepository) IsValid(ctx context.Context, id uuid.UUID) bool {
 	_, err := m.Load(ctx, id)
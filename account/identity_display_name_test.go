@@ -0,0 +1,35 @@
+package account_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayName(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	identity := account.Identity{Username: "jdoe"}
+
+	t.Run("full name", func(t *testing.T) {
+		user := account.User{FullName: "John Doe", Email: "john.doe@example.com"}
+		assert.Equal(t, "John Doe", account.DisplayName(identity, &user))
+	})
+
+	t.Run("fallback to username", func(t *testing.T) {
+		user := account.User{FullName: "  ", Email: "john.doe@example.com"}
+		assert.Equal(t, "jdoe", account.DisplayName(identity, &user))
+		assert.Equal(t, "jdoe", account.DisplayName(identity, nil))
+	})
+
+	t.Run("fallback to email local part", func(t *testing.T) {
+		user := account.User{Email: "john.doe@example.com"}
+		assert.Equal(t, "john.doe", account.DisplayName(account.Identity{}, &user))
+	})
+
+	t.Run("nothing to display", func(t *testing.T) {
+		assert.Equal(t, "", account.DisplayName(account.Identity{}, nil))
+	})
+}
@@ -0,0 +1,79 @@
+package account
+
+import (
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// Impersonation records a token issued to an administrator to act as another identity
+type Impersonation struct {
+	gormsupport.Lifecycle
+	ID uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	// The administrator who asked for the token
+	ImpersonatorID uuid.UUID `sql:"type:uuid"`
+	// The identity the token acts as
+	IdentityID uuid.UUID `sql:"type:uuid"`
+	Reason     string
+	ExpiresAt  time.Time
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (i Impersonation) TableName() string {
+	return "impersonations"
+}
+
+// ImpersonationRepository encapsulates storage & retrieval of the impersonation audit log
+type ImpersonationRepository interface {
+	Create(ctx context.Context, impersonation *Impersonation) error
+	List(ctx context.Context, identityID uuid.UUID) ([]Impersonation, error)
+}
+
+// NewImpersonationRepository creates a new impersonation repository
+func NewImpersonationRepository(db *gorm.DB) ImpersonationRepository {
+	return &GormImpersonationRepository{db: db}
+}
+
+// GormImpersonationRepository implements ImpersonationRepository using gorm
+type GormImpersonationRepository struct {
+	db *gorm.DB
+}
+
+// Create records a new impersonation
+// returns InternalError
+func (m *GormImpersonationRepository) Create(ctx context.Context, impersonation *Impersonation) error {
+	defer goa.MeasureSince([]string{"goa", "db", "impersonation", "create"}, time.Now())
+	if impersonation.ID == uuid.Nil {
+		impersonation.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(impersonation).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	log.Info(ctx, map[string]interface{}{
+		"impersonation_id": impersonation.ID,
+		"impersonator_id":  impersonation.ImpersonatorID,
+		"identity_id":      impersonation.IdentityID,
+		"expires_at":       impersonation.ExpiresAt,
+	}, "impersonation recorded")
+	return nil
+}
+
+// List returns the impersonations of the given identity, the oldest first
+// returns InternalError
+func (m *GormImpersonationRepository) List(ctx context.Context, identityID uuid.UUID) ([]Impersonation, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "impersonation", "list"}, time.Now())
+	var res []Impersonation
+	err := m.db.Where("identity_id = ?", identityID).Order("created_at ASC").Find(&res).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package account_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type impersonationBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.ImpersonationRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunImpersonationBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &impersonationBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *impersonationBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewImpersonationRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *impersonationBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *impersonationBlackBoxTest) TestCreateAndListOK() {
+	// given
+	admin, err := testsupport.CreateTestIdentity(s.DB, "TestImpersonation"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestImpersonation"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestImpersonation"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	first := account.Impersonation{ImpersonatorID: admin.ID, IdentityID: identity.ID, Reason: "first", ExpiresAt: time.Now().Add(time.Minute)}
+	second := account.Impersonation{ImpersonatorID: admin.ID, IdentityID: identity.ID, Reason: "second", ExpiresAt: time.Now().Add(time.Minute)}
+	// when
+	require.Nil(s.T(), s.repo.Create(s.ctx, &first))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &second))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &account.Impersonation{ImpersonatorID: admin.ID, IdentityID: other.ID, Reason: "other", ExpiresAt: time.Now()}))
+	// then
+	impersonations, err := s.repo.List(s.ctx, identity.ID)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), impersonations, 2)
+	assert.Equal(s.T(), first.ID, impersonations[0].ID)
+	assert.Equal(s.T(), second.ID, impersonations[1].ID)
+	assert.Equal(s.T(), admin.ID, impersonations[0].ImpersonatorID)
+	assert.Equal(s.T(), "first", impersonations[0].Reason)
+}
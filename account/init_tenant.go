@@ -2,44 +2,152 @@ package account
 
 import (
 	"net/http"
+	"time"
 
 	"golang.org/x/net/context"
 
 	"net/url"
 
 	"github.com/almighty/almighty-core/account/tenant"
+	errs "github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/goasupport"
+	jwt "github.com/dgrijalva/jwt-go"
 	goaclient "github.com/goadesign/goa/client"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	"github.com/pkg/errors"
 )
 
 type tenantConfig interface {
 	GetTenantServiceURL() string
+	GetTenantInitConcurrency() int
+	GetTenantServiceTimeout() time.Duration
+	GetTenantServiceMaxAttempts() int
+	GetTenantServiceRetryBackoff() time.Duration
 }
 
-// NewInitTenant creates a new tenant service in oso
+// NewInitTenant creates a new tenant service in oso. No more than the configured number
+// of tenants are initialized at the same time, additional calls wait for their turn.
 func NewInitTenant(config tenantConfig) func(context.Context) error {
-	return func(ctx context.Context) error {
+	return ThrottleInitTenant(config.GetTenantInitConcurrency(), func(ctx context.Context) error {
 		return InitTenant(ctx, config)
+	})
+}
+
+// ThrottleInitTenant wraps the given init function so that at most `limit` calls run concurrently,
+// across all callers. Calls beyond the limit are queued until a running call completes or their context is done.
+// A limit lower than 1 is treated as 1.
+func ThrottleInitTenant(limit int, initTenant func(context.Context) error) func(context.Context) error {
+	if limit < 1 {
+		limit = 1
+	}
+	slots := make(chan struct{}, limit)
+	return func(ctx context.Context) error {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-slots }()
+		return initTenant(ctx)
 	}
 }
 
-// InitTenant creates a new tenant service in oso
+// InitTenant creates a new tenant service in oso. The call is retried up to the configured number of attempts
+// when the tenant service is unreachable or responds with a 5xx status, unless the given context is done.
 func InitTenant(ctx context.Context, config tenantConfig) error {
-
-	u, err := url.Parse(config.GetTenantServiceURL())
+	c, err := newTenantClient(ctx, config)
 	if err != nil {
 		return err
 	}
 
-	c := tenant.New(goaclient.HTTPClientDoer(http.DefaultClient))
-	c.Host = u.Host
-	c.Scheme = u.Scheme
-	c.SetJWTSigner(goasupport.NewForwardSigner(ctx))
+	maxAttempts := config.GetTenantServiceMaxAttempts()
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		retry, err := setupTenant(ctx, c)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == maxAttempts {
+			return errors.Wrapf(err, "unable to initialize the tenant after %d attempt(s)", attempt)
+		}
+		select {
+		case <-time.After(time.Duration(attempt) * config.GetTenantServiceRetryBackoff()):
+		case <-ctx.Done():
+			return errors.Wrapf(err, "unable to initialize the tenant after %d attempt(s)", attempt)
+		}
+	}
+}
 
-	// Ignore response for now
-	_, err = c.SetupTenant(ctx, tenant.SetupTenantPath())
+// setupTenant calls the tenant service once and returns whether the call can be retried in case of error
+func setupTenant(ctx context.Context, c *tenant.Client) (retry bool, err error) {
+	res, err := c.SetupTenant(ctx, tenant.SetupTenantPath())
+	if err != nil {
+		// the call must not be retried once the context is done
+		return ctx.Err() == nil, errors.Wrap(err, "unable to reach the tenant service")
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode >= 500, errors.Errorf("the tenant service responded with status %d", res.StatusCode)
+	}
+	return false, nil
+}
+
+// NewCleanupTenant removes the tenant service of the user in oso.
+func NewCleanupTenant(config tenantConfig) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return CleanupTenant(ctx, config)
+	}
+}
+
+// CleanupTenant removes the tenant service of the user in oso. A NotFoundError is returned if the
+// tenant service does not know about the tenant, so that callers can treat an already removed tenant as a success.
+func CleanupTenant(ctx context.Context, config tenantConfig) error {
+	c, err := newTenantClient(ctx, config)
 	if err != nil {
 		return err
 	}
+
+	res, err := c.CleanTenant(ctx, tenant.CleanTenantPath())
+	if err != nil {
+		return errors.Wrap(err, "unable to reach the tenant service")
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		// the tenant is the one of the identity of the forwarded token
+		return errs.NewNotFoundError("tenant", contextSubject(ctx))
+	case res.StatusCode < 200 || res.StatusCode >= 300:
+		return errors.Errorf("unable to remove the tenant: the tenant service responded with status %d", res.StatusCode)
+	}
 	return nil
 }
+
+// contextSubject returns the subject of the token in the given context, or an empty ID if the token has none
+func contextSubject(ctx context.Context) string {
+	token := goajwt.ContextJWT(ctx)
+	if token == nil {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// newTenantClient creates a client of the tenant service which forwards the token of the current request
+func newTenantClient(ctx context.Context, config tenantConfig) (*tenant.Client, error) {
+	u, err := url.Parse(config.GetTenantServiceURL())
+	if err != nil {
+		return nil, err
+	}
+
+	c := tenant.New(goaclient.HTTPClientDoer(&http.Client{Timeout: config.GetTenantServiceTimeout()}))
+	c.Host = u.Host
+	c.Scheme = u.Scheme
+	c.SetJWTSigner(goasupport.NewForwardSigner(ctx))
+	return c, nil
+}
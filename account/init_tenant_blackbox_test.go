@@ -0,0 +1,282 @@
+package account_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestThrottleInitTenant(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("calls beyond the limit are queued", func(t *testing.T) {
+		// given
+		var running, started int32
+		release := make(chan struct{})
+		initTenant := account.ThrottleInitTenant(2, func(ctx context.Context) error {
+			atomic.AddInt32(&running, 1)
+			atomic.AddInt32(&started, 1)
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		// when
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				initTenant(context.Background())
+			}()
+		}
+		// then only 2 calls are fired, the others wait for their turn
+		require.True(t, waitFor(func() bool { return atomic.LoadInt32(&started) == 2 }))
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&started))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&running))
+		// when the running calls complete, the queued ones are fired
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(5), atomic.LoadInt32(&started))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&running))
+	})
+
+	t.Run("queued call gives up when its context is done", func(t *testing.T) {
+		// given
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		initTenant := account.ThrottleInitTenant(1, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+		go initTenant(context.Background())
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		// when
+		err := initTenant(ctx)
+		// then
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+// waitFor polls the given condition for up to a second
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// testTenantConfig points to a fake tenant service
+type testTenantConfig struct {
+	url         string
+	timeout     time.Duration
+	maxAttempts int
+}
+
+func (c testTenantConfig) GetTenantServiceURL() string {
+	return c.url
+}
+
+func (c testTenantConfig) GetTenantInitConcurrency() int {
+	return 1
+}
+
+func (c testTenantConfig) GetTenantServiceTimeout() time.Duration {
+	return c.timeout
+}
+
+func (c testTenantConfig) GetTenantServiceMaxAttempts() int {
+	return c.maxAttempts
+}
+
+func (c testTenantConfig) GetTenantServiceRetryBackoff() time.Duration {
+	return 10 * time.Millisecond
+}
+
+func TestInitTenant(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	ctx := goajwt.WithJWT(context.Background(), &jwt.Token{Raw: "sometoken", Claims: jwt.MapClaims{"sub": uuid.NewV4().String()}})
+
+	// newTenantService starts a fake tenant service responding with the given statuses, one per call,
+	// then with the last one. The number of calls is counted.
+	newTenantService := func(calls *int32, statuses ...int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer sometoken", r.Header.Get("Authorization"))
+			i := int(atomic.AddInt32(calls, 1)) - 1
+			if i >= len(statuses) {
+				i = len(statuses) - 1
+			}
+			w.WriteHeader(statuses[i])
+		}))
+	}
+
+	t.Run("tenant initialized after transient failures", func(t *testing.T) {
+		// given
+		var calls int32
+		service := newTenantService(&calls, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusAccepted)
+		defer service.Close()
+		// when
+		err := account.InitTenant(ctx, testTenantConfig{url: service.URL, maxAttempts: 3})
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("tenant service keeps failing", func(t *testing.T) {
+		// given
+		var calls int32
+		service := newTenantService(&calls, http.StatusBadGateway)
+		defer service.Close()
+		// when
+		err := account.InitTenant(ctx, testTenantConfig{url: service.URL, maxAttempts: 3})
+		// then
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "after 3 attempt(s)")
+		assert.Contains(t, err.Error(), "502")
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		// given
+		var calls int32
+		service := newTenantService(&calls, http.StatusForbidden)
+		defer service.Close()
+		// when
+		err := account.InitTenant(ctx, testTenantConfig{url: service.URL, maxAttempts: 3})
+		// then
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "after 1 attempt(s)")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("tenant service times out", func(t *testing.T) {
+		// given
+		var calls int32
+		release := make(chan struct{})
+		service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		}))
+		defer service.Close()
+		defer close(release)
+		// when
+		start := time.Now()
+		err := account.InitTenant(ctx, testTenantConfig{url: service.URL, timeout: 50 * time.Millisecond, maxAttempts: 2})
+		// then
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "after 2 attempt(s)")
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.True(t, time.Since(start) < 5*time.Second)
+	})
+
+	t.Run("no retry once the context is done", func(t *testing.T) {
+		// given
+		var calls int32
+		service := newTenantService(&calls, http.StatusBadGateway)
+		defer service.Close()
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		// when
+		err := account.InitTenant(cancelledCtx, testTenantConfig{url: service.URL, maxAttempts: 3})
+		// then
+		require.NotNil(t, err)
+		assert.Contains(t, err.Error(), "after 1 attempt(s)")
+	})
+}
+
+func TestCleanupTenant(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	identityID := uuid.NewV4().String()
+	ctx := goajwt.WithJWT(context.Background(), &jwt.Token{Raw: "sometoken", Claims: jwt.MapClaims{"sub": identityID}})
+
+	// newTenantService starts a fake tenant service responding with the given status
+	newTenantService := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer sometoken", r.Header.Get("Authorization"))
+			w.WriteHeader(status)
+		}))
+	}
+
+	t.Run("tenant removed", func(t *testing.T) {
+		// given
+		service := newTenantService(http.StatusNoContent)
+		defer service.Close()
+		// when
+		err := account.NewCleanupTenant(testTenantConfig{url: service.URL})(ctx)
+		// then
+		assert.Nil(t, err)
+	})
+
+	t.Run("tenant already removed", func(t *testing.T) {
+		// given
+		service := newTenantService(http.StatusNotFound)
+		defer service.Close()
+		// when
+		err := account.CleanupTenant(ctx, testTenantConfig{url: service.URL})
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errs.NotFoundError{}, errors.Cause(err))
+		assert.Contains(t, err.Error(), identityID)
+	})
+
+	t.Run("tenant already removed, with a token without subject", func(t *testing.T) {
+		// given
+		service := newTenantService(http.StatusNotFound)
+		defer service.Close()
+		for _, claims := range []jwt.Claims{jwt.MapClaims{}, &jwt.StandardClaims{}} {
+			noSubjectCtx := goajwt.WithJWT(context.Background(), &jwt.Token{Raw: "sometoken", Claims: claims})
+			// when
+			err := account.CleanupTenant(noSubjectCtx, testTenantConfig{url: service.URL})
+			// then
+			require.NotNil(t, err)
+			assert.IsType(t, errs.NotFoundError{}, errors.Cause(err))
+		}
+	})
+
+	t.Run("tenant service failure", func(t *testing.T) {
+		// given
+		service := newTenantService(http.StatusInternalServerError)
+		defer service.Close()
+		// when
+		err := account.CleanupTenant(ctx, testTenantConfig{url: service.URL})
+		// then
+		require.NotNil(t, err)
+		_, notFound := errors.Cause(err).(errs.NotFoundError)
+		assert.False(t, notFound)
+		assert.Contains(t, err.Error(), "500")
+	})
+
+	t.Run("tenant service unreachable", func(t *testing.T) {
+		// given
+		service := newTenantService(http.StatusNoContent)
+		service.Close()
+		// when
+		err := account.CleanupTenant(ctx, testTenantConfig{url: service.URL})
+		// then
+		require.NotNil(t, err)
+		_, notFound := errors.Cause(err).(errs.NotFoundError)
+		assert.False(t, notFound)
+	})
+}
@@ -0,0 +1,171 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// PersonalAccessTokenPrefix is the prefix of the personal access tokens, which tells them apart from the JWTs
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is a revocable token created by an identity to call the API on its own behalf, e.g. from
+// scripts. Only the hash of the token is stored: the token itself is returned once, when it is created.
+type PersonalAccessToken struct {
+	gormsupport.Lifecycle
+	ID         uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	IdentityID uuid.UUID `sql:"type:uuid"`
+	// The name given by the identity to remember what the token is used for
+	Name      string
+	TokenHash string
+	// The scopes granted to the token, separated by spaces as in the "scopes" claim of the JWTs
+	Scopes     string
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (t PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList returns the scopes granted to the token
+func (t PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, " ")
+}
+
+// IsActive returns true if the token was neither revoked nor expired at the given time
+func (t PersonalAccessToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// GeneratePersonalAccessToken returns a new random personal access token along with its hash
+func GeneratePersonalAccessToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", errs.NewInternalError(err.Error())
+	}
+	token := PersonalAccessTokenPrefix + hex.EncodeToString(b)
+	return token, HashPersonalAccessToken(token), nil
+}
+
+// HashPersonalAccessToken returns the hash of the given personal access token, as it is stored
+func HashPersonalAccessToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsPersonalAccessToken returns true if the given bearer token is a personal access token rather than a JWT
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// PersonalAccessTokenRepository encapsulates storage & retrieval of the personal access tokens
+type PersonalAccessTokenRepository interface {
+	Load(ctx context.Context, ID uuid.UUID) (*PersonalAccessToken, error)
+	LoadByTokenHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	Create(ctx context.Context, token *PersonalAccessToken) error
+	Save(ctx context.Context, token *PersonalAccessToken) error
+	List(ctx context.Context, identityID uuid.UUID) ([]PersonalAccessToken, error)
+}
+
+// NewPersonalAccessTokenRepository creates a new personal access token repository
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &GormPersonalAccessTokenRepository{db: db}
+}
+
+// GormPersonalAccessTokenRepository implements PersonalAccessTokenRepository using gorm
+type GormPersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// Load returns the personal access token with the given ID
+// returns NotFoundError or InternalError
+func (m *GormPersonalAccessTokenRepository) Load(ctx context.Context, id uuid.UUID) (*PersonalAccessToken, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "personal_access_token", "load"}, time.Now())
+	var native PersonalAccessToken
+	tx := m.db.Where("id = ?", id).First(&native)
+	if tx.RecordNotFound() {
+		return nil, errs.NewNotFoundError("personal access token", id.String())
+	}
+	if tx.Error != nil {
+		return nil, errs.NewInternalError(tx.Error.Error())
+	}
+	return &native, nil
+}
+
+// LoadByTokenHash returns the personal access token with the given hash, whether it is active or not
+// returns NotFoundError or InternalError
+func (m *GormPersonalAccessTokenRepository) LoadByTokenHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "personal_access_token", "load_by_token_hash"}, time.Now())
+	var native PersonalAccessToken
+	tx := m.db.Where("token_hash = ?", tokenHash).First(&native)
+	if tx.RecordNotFound() {
+		// the hash is not given in the error, since it could be used to authenticate with a leaked database
+		return nil, errs.NewNotFoundError("personal access token", "")
+	}
+	if tx.Error != nil {
+		return nil, errs.NewInternalError(tx.Error.Error())
+	}
+	return &native, nil
+}
+
+// Create creates a new personal access token
+// returns InternalError
+func (m *GormPersonalAccessTokenRepository) Create(ctx context.Context, token *PersonalAccessToken) error {
+	defer goa.MeasureSince([]string{"goa", "db", "personal_access_token", "create"}, time.Now())
+	if token.ID == uuid.Nil {
+		token.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(token).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"identity_id": token.IdentityID,
+			"err":         err,
+		}, "unable to create the personal access token")
+		return errs.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"identity_id": token.IdentityID,
+		"token_id":    token.ID,
+	}, "Personal access token created!")
+	return nil
+}
+
+// Save modifies a personal access token
+// returns InternalError
+func (m *GormPersonalAccessTokenRepository) Save(ctx context.Context, token *PersonalAccessToken) error {
+	defer goa.MeasureSince([]string{"goa", "db", "personal_access_token", "save"}, time.Now())
+	if err := m.db.Save(token).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// List returns the personal access tokens of the given identity, including the revoked and expired ones,
+// the most recent first
+// returns InternalError
+func (m *GormPersonalAccessTokenRepository) List(ctx context.Context, identityID uuid.UUID) ([]PersonalAccessToken, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "personal_access_token", "list"}, time.Now())
+	var res []PersonalAccessToken
+	err := m.db.Where("identity_id = ?", identityID).Order("created_at DESC").Find(&res).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
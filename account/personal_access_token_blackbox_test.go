@@ -0,0 +1,108 @@
+package account_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type personalAccessTokenBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.PersonalAccessTokenRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunPersonalAccessTokenBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &personalAccessTokenBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *personalAccessTokenBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewPersonalAccessTokenRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *personalAccessTokenBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *personalAccessTokenBlackBoxTest) TestCreateAndLoadByTokenHashOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestPersonalAccessToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	token, tokenHash, err := account.GeneratePersonalAccessToken()
+	require.Nil(s.T(), err)
+	pat := account.PersonalAccessToken{IdentityID: identity.ID, Name: "ci", TokenHash: tokenHash, Scopes: "admin:users"}
+	// when
+	require.Nil(s.T(), s.repo.Create(s.ctx, &pat))
+	// then
+	loaded, err := s.repo.LoadByTokenHash(s.ctx, account.HashPersonalAccessToken(token))
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), pat.ID, loaded.ID)
+	assert.Equal(s.T(), []string{"admin:users"}, loaded.ScopeList())
+	assert.True(s.T(), loaded.IsActive(time.Now()))
+	_, err = s.repo.LoadByTokenHash(s.ctx, account.HashPersonalAccessToken(token+"0"))
+	require.NotNil(s.T(), err)
+}
+
+func (s *personalAccessTokenBlackBoxTest) TestListOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestPersonalAccessToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestPersonalAccessToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	first := account.PersonalAccessToken{IdentityID: identity.ID, Name: "first", TokenHash: uuid.NewV4().String()}
+	second := account.PersonalAccessToken{IdentityID: identity.ID, Name: "second", TokenHash: uuid.NewV4().String()}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &first))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &second))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &account.PersonalAccessToken{IdentityID: other.ID, Name: "other", TokenHash: uuid.NewV4().String()}))
+	now := time.Now()
+	first.RevokedAt = &now
+	require.Nil(s.T(), s.repo.Save(s.ctx, &first))
+	// when
+	tokens, err := s.repo.List(s.ctx, identity.ID)
+	// then the revoked token is still listed, the most recent token first
+	require.Nil(s.T(), err)
+	require.Len(s.T(), tokens, 2)
+	assert.Equal(s.T(), second.ID, tokens[0].ID)
+	assert.Equal(s.T(), first.ID, tokens[1].ID)
+	assert.False(s.T(), tokens[1].IsActive(time.Now()))
+}
+
+func TestPersonalAccessTokenIsActive(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+	assert.True(t, account.PersonalAccessToken{}.IsActive(now))
+	assert.True(t, account.PersonalAccessToken{ExpiresAt: &later}.IsActive(now))
+	assert.False(t, account.PersonalAccessToken{ExpiresAt: &earlier}.IsActive(now))
+	assert.False(t, account.PersonalAccessToken{RevokedAt: &earlier}.IsActive(now))
+}
+
+func TestGeneratePersonalAccessToken(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	token, tokenHash, err := account.GeneratePersonalAccessToken()
+	require.Nil(t, err)
+	assert.True(t, account.IsPersonalAccessToken(token))
+	assert.NotContains(t, tokenHash, token)
+	assert.Equal(t, account.HashPersonalAccessToken(token), tokenHash)
+	other, _, err := account.GeneratePersonalAccessToken()
+	require.Nil(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -0,0 +1,95 @@
+package account
+
+import (
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// The fields of the profile whose changes are recorded
+const (
+	ProfileFieldFullName           = "fullName"
+	ProfileFieldEmail              = "email"
+	ProfileFieldCompany            = "company"
+	ProfileFieldContextInformation = "contextInformation"
+)
+
+// ProfileEvent records a change of a field of the profile of a user. The events are never updated once created.
+type ProfileEvent struct {
+	gormsupport.Lifecycle
+	ID         uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	IdentityID uuid.UUID `sql:"type:uuid"`
+	// The name of the field, suffixed with the key for the context information, e.g. 'contextInformation.space'
+	Field string
+	// The values before and after the change, nil when the field (or the key) was not set
+	OldValue *string
+	NewValue *string
+	// The identity which changed the field: the identity itself, or an administrator
+	ChangedBy uuid.UUID `sql:"type:uuid"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (e ProfileEvent) TableName() string {
+	return "profile_events"
+}
+
+// ProfileEventRepository encapsulates storage & retrieval of the changes of the profiles
+type ProfileEventRepository interface {
+	Create(ctx context.Context, event *ProfileEvent) error
+	List(ctx context.Context, identityID uuid.UUID, start int, limit int) ([]ProfileEvent, int, error)
+}
+
+// NewProfileEventRepository creates a new profile event repository
+func NewProfileEventRepository(db *gorm.DB) ProfileEventRepository {
+	return &GormProfileEventRepository{db: db}
+}
+
+// GormProfileEventRepository implements ProfileEventRepository using gorm
+type GormProfileEventRepository struct {
+	db *gorm.DB
+}
+
+// Create records a new change of profile
+// returns InternalError
+func (m *GormProfileEventRepository) Create(ctx context.Context, event *ProfileEvent) error {
+	defer goa.MeasureSince([]string{"goa", "db", "profile_event", "create"}, time.Now())
+	if event.ID == uuid.Nil {
+		event.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(event).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"identity_id": event.IdentityID,
+		"field":       event.Field,
+		"changed_by":  event.ChangedBy,
+	}, "profile change recorded")
+	return nil
+}
+
+// List returns the given page of the changes of the profile of the given identity, the most recent first,
+// along with the total count of changes
+// returns InternalError
+func (m *GormProfileEventRepository) List(ctx context.Context, identityID uuid.UUID, start int, limit int) ([]ProfileEvent, int, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "profile_event", "list"}, time.Now())
+	db := m.db.Model(&ProfileEvent{}).Where("identity_id = ?", identityID)
+	var count int
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, errs.NewInternalError(err.Error())
+	}
+	var res []ProfileEvent
+	// the ID breaks the ties between the changes recorded in the same update
+	err := db.Order("created_at DESC, id").Offset(start).Limit(limit).Find(&res).Error
+	if err != nil {
+		return nil, 0, errs.NewInternalError(err.Error())
+	}
+	return res, count, nil
+}
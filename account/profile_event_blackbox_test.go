@@ -0,0 +1,72 @@
+package account_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type profileEventBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.ProfileEventRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunProfileEventBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &profileEventBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *profileEventBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewProfileEventRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *profileEventBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *profileEventBlackBoxTest) TestCreateAndListOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestProfileEvent"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestProfileEvent"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	oldValue := "old"
+	newValue := "new"
+	first := account.ProfileEvent{IdentityID: identity.ID, Field: account.ProfileFieldFullName, OldValue: &oldValue, NewValue: &newValue, ChangedBy: identity.ID}
+	second := account.ProfileEvent{IdentityID: identity.ID, Field: account.ProfileFieldCompany, NewValue: &newValue, ChangedBy: identity.ID}
+	// when
+	require.Nil(s.T(), s.repo.Create(s.ctx, &first))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &second))
+	require.Nil(s.T(), s.repo.Create(s.ctx, &account.ProfileEvent{IdentityID: other.ID, Field: account.ProfileFieldEmail, ChangedBy: other.ID}))
+	// then the most recent event comes first
+	events, count, err := s.repo.List(s.ctx, identity.ID, 0, 10)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, count)
+	require.Len(s.T(), events, 2)
+	assert.Equal(s.T(), second.ID, events[0].ID)
+	assert.Nil(s.T(), events[0].OldValue)
+	assert.Equal(s.T(), first.ID, events[1].ID)
+	assert.Equal(s.T(), "old", *events[1].OldValue)
+	assert.Equal(s.T(), "new", *events[1].NewValue)
+	// when
+	events, count, err = s.repo.List(s.ctx, identity.ID, 1, 10)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, count)
+	require.Len(s.T(), events, 1)
+	assert.Equal(s.T(), first.ID, events[0].ID)
+}
@@ -0,0 +1,331 @@
+package account
+
+import (
+	"strings"
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// Team is a named group of identities. A team can be added as a whole to the collaborators of a space, so
+// that the changes of its members apply to all the spaces of the team.
+type Team struct {
+	gormsupport.Lifecycle
+	ID          uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	Name        string
+	Description *string
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (t Team) TableName() string {
+	return "teams"
+}
+
+// TeamMember is the membership of an identity in a team
+type TeamMember struct {
+	CreatedAt  time.Time
+	TeamID     uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	IdentityID uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m TeamMember) TableName() string {
+	return "team_members"
+}
+
+// SpaceTeam is a team among the collaborators of a space
+type SpaceTeam struct {
+	CreatedAt time.Time
+	SpaceID   uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	TeamID    uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (s SpaceTeam) TableName() string {
+	return "space_teams"
+}
+
+// SpaceTeamMember is an identity which collaborates on a space through one of the teams of the space
+type SpaceTeamMember struct {
+	IdentityID uuid.UUID
+	TeamID     uuid.UUID
+	TeamName   string
+	// AddedAt is when the identity started to collaborate on the space through the team, that is when it joined
+	// the team or when the team was added to the space, whichever happened last
+	AddedAt time.Time
+}
+
+// TeamRepository encapsulates storage & retrieval of the teams, of their members and of the spaces they
+// collaborate on
+type TeamRepository interface {
+	Load(ctx context.Context, ID uuid.UUID) (*Team, error)
+	Create(ctx context.Context, team *Team) error
+	Save(ctx context.Context, team *Team) error
+	List(ctx context.Context) ([]Team, error)
+	AddMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) error
+	RemoveMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) error
+	ListMembers(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error)
+	IsMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) (bool, error)
+	AddToSpace(ctx context.Context, spaceID uuid.UUID, teamID uuid.UUID) error
+	RemoveFromSpace(ctx context.Context, spaceID uuid.UUID, teamID uuid.UUID) error
+	ListBySpace(ctx context.Context, spaceID uuid.UUID) ([]Team, error)
+	ListSpaceMembers(ctx context.Context, spaceID uuid.UUID) ([]SpaceTeamMember, error)
+	ListSpacesByMember(ctx context.Context, identityID uuid.UUID) ([]uuid.UUID, error)
+	IsSpaceMember(ctx context.Context, spaceID uuid.UUID, identityID uuid.UUID) (bool, error)
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &GormTeamRepository{db: db}
+}
+
+// GormTeamRepository implements TeamRepository using gorm
+type GormTeamRepository struct {
+	db *gorm.DB
+}
+
+// Load returns the team with the given ID
+// returns NotFoundError or InternalError
+func (m *GormTeamRepository) Load(ctx context.Context, id uuid.UUID) (*Team, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "load"}, time.Now())
+	var native Team
+	tx := m.db.Where("id = ?", id).First(&native)
+	if tx.RecordNotFound() {
+		return nil, errs.NewNotFoundError("team", id.String())
+	}
+	if tx.Error != nil {
+		return nil, errs.NewInternalError(tx.Error.Error())
+	}
+	return &native, nil
+}
+
+// Create creates a new team
+// returns BadParameterError or InternalError
+func (m *GormTeamRepository) Create(ctx context.Context, team *Team) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "create"}, time.Now())
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return errs.NewBadParameterError("name", team.Name).Expected("non-empty name")
+	}
+	if team.ID == uuid.Nil {
+		team.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(team).Error; err != nil {
+		if gormsupport.IsUniqueViolation(err, "teams_name_idx") {
+			return errs.NewBadParameterError("name", team.Name).Expected("unique")
+		}
+		log.Error(ctx, map[string]interface{}{
+			"team_name": team.Name,
+			"err":       err,
+		}, "unable to create the team")
+		return errs.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"team_id": team.ID,
+	}, "Team created!")
+	return nil
+}
+
+// Save modifies a team. It is also used to record that the members of the team changed.
+// returns InternalError
+func (m *GormTeamRepository) Save(ctx context.Context, team *Team) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "save"}, time.Now())
+	if err := m.db.Save(team).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// List returns all the teams, ordered by name
+// returns InternalError
+func (m *GormTeamRepository) List(ctx context.Context) ([]Team, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "list"}, time.Now())
+	var res []Team
+	if err := m.db.Order("name").Find(&res).Error; err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
+
+// AddMember adds the given identity to the members of the given team. It does nothing if the identity is
+// already a member of the team.
+// returns InternalError
+func (m *GormTeamRepository) AddMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "add_member"}, time.Now())
+	isMember, err := m.IsMember(ctx, teamID, identityID)
+	if err != nil || isMember {
+		return err
+	}
+	if err := m.db.Create(&TeamMember{TeamID: teamID, IdentityID: identityID}).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"team_id":     teamID,
+			"identity_id": identityID,
+			"err":         err,
+		}, "unable to add the member to the team")
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// RemoveMember removes the given identity from the members of the given team
+// returns NotFoundError or InternalError
+func (m *GormTeamRepository) RemoveMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "remove_member"}, time.Now())
+	tx := m.db.Where("team_id = ? AND identity_id = ?", teamID, identityID).Delete(&TeamMember{})
+	if tx.Error != nil {
+		return errs.NewInternalError(tx.Error.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NewNotFoundError("team member", identityID.String())
+	}
+	return nil
+}
+
+// ListMembers returns the IDs of the members of the given team, in the order they joined the team
+// returns InternalError
+func (m *GormTeamRepository) ListMembers(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "list_members"}, time.Now())
+	var members []TeamMember
+	if err := m.db.Where("team_id = ?", teamID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	res := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		res[i] = member.IdentityID
+	}
+	return res, nil
+}
+
+// IsMember returns true if the given identity is a member of the given team
+// returns InternalError
+func (m *GormTeamRepository) IsMember(ctx context.Context, teamID uuid.UUID, identityID uuid.UUID) (bool, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "is_member"}, time.Now())
+	var count int
+	err := m.db.Model(&TeamMember{}).Where("team_id = ? AND identity_id = ?", teamID, identityID).Count(&count).Error
+	if err != nil {
+		return false, errs.NewInternalError(err.Error())
+	}
+	return count > 0, nil
+}
+
+// AddToSpace adds the given team to the collaborators of the given space. It does nothing if the team
+// already collaborates on the space.
+// returns InternalError
+func (m *GormTeamRepository) AddToSpace(ctx context.Context, spaceID uuid.UUID, teamID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "add_to_space"}, time.Now())
+	var count int
+	err := m.db.Model(&SpaceTeam{}).Where("space_id = ? AND team_id = ?", spaceID, teamID).Count(&count).Error
+	if err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := m.db.Create(&SpaceTeam{SpaceID: spaceID, TeamID: teamID}).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id": spaceID,
+			"team_id":  teamID,
+			"err":      err,
+		}, "unable to add the team to the space collaborators")
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// RemoveFromSpace removes the given team from the collaborators of the given space
+// returns NotFoundError or InternalError
+func (m *GormTeamRepository) RemoveFromSpace(ctx context.Context, spaceID uuid.UUID, teamID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "remove_from_space"}, time.Now())
+	tx := m.db.Where("space_id = ? AND team_id = ?", spaceID, teamID).Delete(&SpaceTeam{})
+	if tx.Error != nil {
+		return errs.NewInternalError(tx.Error.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NewNotFoundError("space team", teamID.String())
+	}
+	return nil
+}
+
+// ListBySpace returns the teams which collaborate on the given space, ordered by name
+// returns InternalError
+func (m *GormTeamRepository) ListBySpace(ctx context.Context, spaceID uuid.UUID) ([]Team, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "list_by_space"}, time.Now())
+	var res []Team
+	err := m.db.Joins("JOIN space_teams ON space_teams.team_id = teams.id").
+		Where("space_teams.space_id = ?", spaceID).Order("teams.name").Find(&res).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
+
+// ListSpaceMembers returns the members of the teams which collaborate on the given space, ordered by team
+// name then in the order they joined their team. An identity is listed once per team it belongs to.
+// returns InternalError
+func (m *GormTeamRepository) ListSpaceMembers(ctx context.Context, spaceID uuid.UUID) ([]SpaceTeamMember, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "list_space_members"}, time.Now())
+	rows, err := m.db.Table("team_members").
+		Select("team_members.identity_id, teams.id, teams.name, GREATEST(team_members.created_at, space_teams.created_at)").
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Joins("JOIN space_teams ON space_teams.team_id = teams.id").
+		Where("space_teams.space_id = ?", spaceID).
+		Order("teams.name, team_members.created_at").Rows()
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	defer rows.Close()
+	res := []SpaceTeamMember{}
+	for rows.Next() {
+		var member SpaceTeamMember
+		if err := rows.Scan(&member.IdentityID, &member.TeamID, &member.TeamName, &member.AddedAt); err != nil {
+			return nil, errs.NewInternalError(err.Error())
+		}
+		res = append(res, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
+
+// ListSpacesByMember returns the IDs of the spaces which the given identity collaborates on through one of its teams
+func (m *GormTeamRepository) ListSpacesByMember(ctx context.Context, identityID uuid.UUID) ([]uuid.UUID, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "list_spaces_by_member"}, time.Now())
+	var spaceIDs []uuid.UUID
+	err := m.db.Table("space_teams").
+		Joins("JOIN teams ON teams.id = space_teams.team_id AND teams.deleted_at IS NULL").
+		Joins("JOIN team_members ON team_members.team_id = teams.id").
+		Where("team_members.identity_id = ?", identityID).
+		Pluck("DISTINCT space_teams.space_id", &spaceIDs).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return spaceIDs, nil
+}
+
+// IsSpaceMember returns true if the given identity is a member of one of the teams which collaborate on the
+// given space
+// returns InternalError
+func (m *GormTeamRepository) IsSpaceMember(ctx context.Context, spaceID uuid.UUID, identityID uuid.UUID) (bool, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "team", "is_space_member"}, time.Now())
+	var count int
+	err := m.db.Model(&TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Joins("JOIN space_teams ON space_teams.team_id = teams.id").
+		Where("space_teams.space_id = ? AND team_members.identity_id = ?", spaceID, identityID).
+		Count(&count).Error
+	if err != nil {
+		return false, errs.NewInternalError(err.Error())
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,165 @@
+package account_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type teamBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.TeamRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunTeamBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &teamBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *teamBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewTeamRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *teamBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *teamBlackBoxTest) createTeam() account.Team {
+	team := account.Team{Name: "TestTeam-" + uuid.NewV4().String()}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &team))
+	return team
+}
+
+func (s *teamBlackBoxTest) TestCreateAndLoadOK() {
+	// given
+	description := "description"
+	team := account.Team{Name: " TestTeam-" + uuid.NewV4().String() + " ", Description: &description}
+	// when
+	require.Nil(s.T(), s.repo.Create(s.ctx, &team))
+	// then
+	loaded, err := s.repo.Load(s.ctx, team.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), team.Name, loaded.Name)
+	assert.NotContains(s.T(), loaded.Name, " ")
+	require.NotNil(s.T(), loaded.Description)
+	assert.Equal(s.T(), description, *loaded.Description)
+}
+
+func (s *teamBlackBoxTest) TestCreateWithSameNameFails() {
+	// given
+	team := s.createTeam()
+	// when
+	err := s.repo.Create(s.ctx, &account.Team{Name: team.Name})
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, err)
+}
+
+func (s *teamBlackBoxTest) TestLoadUnknownTeamNotFound() {
+	_, err := s.repo.Load(s.ctx, uuid.NewV4())
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *teamBlackBoxTest) TestAddAndRemoveMembersOK() {
+	// given
+	team := s.createTeam()
+	first, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	second, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	// when the first member is added twice
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, team.ID, first.ID))
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, team.ID, second.ID))
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, team.ID, first.ID))
+	// then
+	members, err := s.repo.ListMembers(s.ctx, team.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []uuid.UUID{first.ID, second.ID}, members)
+	// when
+	require.Nil(s.T(), s.repo.RemoveMember(s.ctx, team.ID, first.ID))
+	// then
+	isMember, err := s.repo.IsMember(s.ctx, team.ID, first.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), isMember)
+	isMember, err = s.repo.IsMember(s.ctx, team.ID, second.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), isMember)
+	err = s.repo.RemoveMember(s.ctx, team.ID, first.ID)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *teamBlackBoxTest) TestSpaceMembersOK() {
+	// given two teams of a space which share a member
+	owner, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	sp, err := space.NewRepository(s.DB).Create(s.ctx, &space.Space{Name: "TestTeam-" + uuid.NewV4().String(), OwnerId: owner.ID})
+	require.Nil(s.T(), err)
+	teamA := account.Team{Name: "TestTeam-A-" + uuid.NewV4().String()}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &teamA))
+	teamB := account.Team{Name: "TestTeam-B-" + uuid.NewV4().String()}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &teamB))
+	shared, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	outsider, err := testsupport.CreateTestIdentity(s.DB, "TestTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, teamA.ID, shared.ID))
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, teamB.ID, other.ID))
+	require.Nil(s.T(), s.repo.AddMember(s.ctx, teamB.ID, shared.ID))
+	// when
+	require.Nil(s.T(), s.repo.AddToSpace(s.ctx, sp.ID, teamB.ID))
+	require.Nil(s.T(), s.repo.AddToSpace(s.ctx, sp.ID, teamA.ID))
+	require.Nil(s.T(), s.repo.AddToSpace(s.ctx, sp.ID, teamA.ID))
+	// then
+	teams, err := s.repo.ListBySpace(s.ctx, sp.ID)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), teams, 2)
+	assert.Equal(s.T(), teamA.ID, teams[0].ID)
+	assert.Equal(s.T(), teamB.ID, teams[1].ID)
+	members, err := s.repo.ListSpaceMembers(s.ctx, sp.ID)
+	require.Nil(s.T(), err)
+	expected := []account.SpaceTeamMember{
+		{IdentityID: shared.ID, TeamID: teamA.ID, TeamName: teamA.Name},
+		{IdentityID: other.ID, TeamID: teamB.ID, TeamName: teamB.Name},
+		{IdentityID: shared.ID, TeamID: teamB.ID, TeamName: teamB.Name},
+	}
+	require.Len(s.T(), members, len(expected))
+	for i, member := range members {
+		assert.False(s.T(), member.AddedAt.IsZero())
+		member.AddedAt = time.Time{}
+		assert.Equal(s.T(), expected[i], member)
+	}
+	isMember, err := s.repo.IsSpaceMember(s.ctx, sp.ID, other.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), isMember)
+	isMember, err = s.repo.IsSpaceMember(s.ctx, sp.ID, outsider.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), isMember)
+	// when
+	require.Nil(s.T(), s.repo.RemoveFromSpace(s.ctx, sp.ID, teamB.ID))
+	// then
+	isMember, err = s.repo.IsSpaceMember(s.ctx, sp.ID, other.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), isMember)
+	err = s.repo.RemoveFromSpace(s.ctx, sp.ID, teamB.ID)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
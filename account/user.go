@@ -19,6 +19,15 @@ import (
 // Hence. keeping the map as a string->interface and not string->string.
 // At the moment, FieldDefinitions could be an overkill, so keeping it out.
 
+const (
+	// UserStateActive is the state of the users allowed to use the API
+	UserStateActive = "active"
+	// UserStateDeactivated is the state of the users deactivated by an administrator, by themselves or for inactivity
+	UserStateDeactivated = "deactivated"
+	// UserStateBanned is the state of the users banned by an administrator
+	UserStateBanned = "banned"
+)
+
 // User describes a User account. A few identities can be assosiated with one user account
 type User struct {
 	gormsupport.Lifecycle
@@ -31,6 +40,13 @@ type User struct {
 	Company            string          // The (optional) Company of the User
 	Identities         []Identity      // has many Identities from different IDPs
 	ContextInformation workitem.Fields `sql:"type:jsonb"` // context information of the user activity
+	// The email the user asked to switch to, which replaces the email once verified
+	PendingEmail *string
+	// The token sent to the pending email to verify it, and when it expires
+	EmailVerificationToken     *string
+	EmailVerificationExpiresAt *time.Time
+	// The state of the user: active, deactivated or banned. Only the active users are allowed to use the API
+	State string
 }
 
 // TableName overrides the table name settings in Gorm to force a specific table name
@@ -57,6 +73,7 @@ type UserRepository interface {
 	List(ctx context.Context) ([]*User, error)
 	Delete(ctx context.Context, ID uuid.UUID) error
 	Query(funcs ...func(*gorm.DB) *gorm.DB) ([]*User, error)
+	ListCompanies(ctx context.Context, prefix string, limit int) ([]string, error)
 }
 
 // TableName overrides the table name settings in Gorm to force a specific table name
@@ -87,6 +104,9 @@ func (m *GormUserRepository) Create(ctx context.Context, u *User) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.NewV4()
 	}
+	if u.State == "" {
+		u.State = UserStateActive
+	}
 	err := m.db.Create(u).Error
 	if err != nil {
 		log.Error(ctx, map[string]interface{}{
@@ -117,6 +137,17 @@ func (m *GormUserRepository) Save(ctx context.Context, model *User) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	// Updating with a struct skips the blank fields, hence the optional profile fields
+	// are updated explicitly so that they can be cleared.
+	err = m.db.Model(obj).Updates(map[string]interface{}{
+		"bio":       model.Bio,
+		"company":   model.Company,
+		"image_url": model.ImageURL,
+		"url":       model.URL,
+	}).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
 	log.Debug(ctx, map[string]interface{}{
 		"user_id": model.ID,
@@ -176,6 +207,21 @@ func (m *GormUserRepository) Query(funcs ...func(*gorm.DB) *gorm.DB) ([]*User, e
 	return objs, nil
 }
 
+// ListCompanies returns the distinct companies of the users starting with the given prefix, regardless of the case,
+// in alphabetical order and up to the given limit
+func (m *GormUserRepository) ListCompanies(ctx context.Context, prefix string, limit int) ([]string, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "user", "list_companies"}, time.Now())
+	var companies []string
+	err := m.db.Model(&User{}).
+		Where("company <> '' AND company ILIKE ?", likeEscaper.Replace(prefix)+"%").
+		Order("company").Limit(limit).
+		Pluck("DISTINCT company", &companies).Error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return companies, nil
+}
+
 // UserFilterByID is a gorm filter for User ID.
 func UserFilterByID(userID uuid.UUID) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -189,3 +235,38 @@ func UserFilterByEmail(email string) func(db *gorm.DB) *gorm.DB {
 		return db.Where("email = ?", email)
 	}
 }
+
+// UserFilterByEmailContaining is a gorm filter for the emails containing the given term, regardless of the case.
+func UserFilterByEmailContaining(term string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("email ILIKE ?", containsPattern(term))
+	}
+}
+
+// UserFilterByCompany is a gorm filter for the company of the users, regardless of the case.
+func UserFilterByCompany(company string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("company ILIKE ?", likeEscaper.Replace(company))
+	}
+}
+
+// UserFilterByState is a gorm filter for the state of the users.
+func UserFilterByState(state string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("state = ?", state)
+	}
+}
+
+// UserFilterByEmailVerificationToken is a gorm filter by 'email_verification_token'
+func UserFilterByEmailVerificationToken(token string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("email_verification_token = ?", token)
+	}
+}
+
+// UserFilterByEmailIgnoringCase is a gorm filter for User email, regardless of the case of the email.
+func UserFilterByEmailIgnoringCase(email string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("lower(email) = lower(?)", email)
+	}
+}
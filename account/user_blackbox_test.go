@@ -1,6 +1,7 @@
 package account_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/almighty/almighty-core/account"
@@ -95,6 +96,103 @@ func (s *userBlackBoxTest) TestOKToSave() {
 
 }
 
+func (s *userBlackBoxTest) TestOKToSaveClearedProfileFields() {
+	t := s.T()
+	resource.Require(t, resource.Database)
+	// given
+	user := createAndLoadUser(s)
+	user.Company = "somecompany"
+	err := s.repo.Save(s.ctx, user)
+	require.Nil(t, err, "Could not update user")
+	// when
+	user.Bio = ""
+	user.Company = ""
+	user.ImageURL = ""
+	user.URL = ""
+	err = s.repo.Save(s.ctx, user)
+	// then
+	require.Nil(t, err, "Could not update user")
+	updatedUser, err := s.repo.Load(s.ctx, user.ID)
+	require.Nil(t, err, "Could not load user")
+	assert.Equal(t, "", updatedUser.Bio)
+	assert.Equal(t, "", updatedUser.Company)
+	assert.Equal(t, "", updatedUser.ImageURL)
+	assert.Equal(t, "", updatedUser.URL)
+	assert.Equal(t, user.FullName, updatedUser.FullName)
+	assert.Equal(t, user.Email, updatedUser.Email)
+}
+
+func (s *userBlackBoxTest) TestCreateActiveUserByDefault() {
+	t := s.T()
+	resource.Require(t, resource.Database)
+	// when
+	user := createAndLoadUser(s)
+	// then
+	assert.Equal(t, account.UserStateActive, user.State)
+}
+
+func (s *userBlackBoxTest) TestQueryUsersByState() {
+	t := s.T()
+	resource.Require(t, resource.Database)
+	// given
+	user := createAndLoadUser(s)
+	banned := createAndLoadUser(s)
+	banned.State = account.UserStateBanned
+	require.Nil(t, s.repo.Save(s.ctx, banned))
+	// when
+	users, err := s.repo.Query(account.UserFilterByState(account.UserStateBanned))
+	// then
+	require.Nil(t, err)
+	ids := make([]uuid.UUID, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	assert.Contains(t, ids, banned.ID)
+	assert.NotContains(t, ids, user.ID)
+}
+
+func (s *userBlackBoxTest) TestQueryUsersByEmailContaining() {
+	t := s.T()
+	resource.Require(t, resource.Database)
+	// given
+	user := createAndLoadUser(s)
+	other := createAndLoadUser(s)
+	// when
+	term := strings.ToUpper(strings.TrimPrefix(user.Email, "someuser@"))
+	users, err := s.repo.Query(account.UserFilterByEmailContaining(term))
+	// then
+	require.Nil(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+	assert.NotEqual(t, other.ID, users[0].ID)
+	// the wildcards are matched literally
+	users, err = s.repo.Query(account.UserFilterByEmailContaining("some%user"))
+	require.Nil(t, err)
+	assert.Empty(t, users)
+}
+
+func (s *userBlackBoxTest) TestListCompanies() {
+	t := s.T()
+	resource.Require(t, resource.Database)
+	// given
+	prefix := uuid.NewV4().String()
+	user := createAndLoadUser(s)
+	user.Company = prefix + "_Company"
+	require.Nil(t, s.repo.Save(s.ctx, user))
+	other := createAndLoadUser(s)
+	other.Company = prefix + "1Company"
+	require.Nil(t, s.repo.Save(s.ctx, other))
+	// when
+	companies, err := s.repo.ListCompanies(s.ctx, strings.ToUpper(prefix), 10)
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, []string{other.Company, user.Company}, companies)
+	// the wildcards are matched literally
+	companies, err = s.repo.ListCompanies(s.ctx, prefix+"_", 10)
+	require.Nil(t, err)
+	assert.Equal(t, []string{user.Company}, companies)
+}
+
 func createAndLoadUser(s *userBlackBoxTest) *account.User {
 	user := &account.User{
 		ID:       uuid.NewV4(),
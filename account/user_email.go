@@ -0,0 +1,163 @@
+package account
+
+import (
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// UserEmail is an email address of a user. The primary email of a user is also the email of the User itself.
+type UserEmail struct {
+	gormsupport.Lifecycle
+	ID       uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	UserID   uuid.UUID `sql:"type:uuid"`
+	Email    string
+	Primary  bool `gorm:"column:is_primary"`
+	Verified bool
+	// The token sent to the email to verify it, and when it expires
+	VerificationToken     *string
+	VerificationExpiresAt *time.Time
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (e UserEmail) TableName() string {
+	return "user_emails"
+}
+
+// UserEmailRepository encapsulates storage & retrieval of the email addresses of the users
+type UserEmailRepository interface {
+	Load(ctx context.Context, ID uuid.UUID) (*UserEmail, error)
+	Create(ctx context.Context, email *UserEmail) error
+	Save(ctx context.Context, email *UserEmail) error
+	Delete(ctx context.Context, ID uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+	List(ctx context.Context, userID uuid.UUID) ([]UserEmail, error)
+	Query(funcs ...func(*gorm.DB) *gorm.DB) ([]UserEmail, error)
+}
+
+// NewUserEmailRepository creates a new user email repository
+func NewUserEmailRepository(db *gorm.DB) UserEmailRepository {
+	return &GormUserEmailRepository{db: db}
+}
+
+// GormUserEmailRepository implements UserEmailRepository using gorm
+type GormUserEmailRepository struct {
+	db *gorm.DB
+}
+
+// Load returns the email with the given ID
+// returns NotFoundError or InternalError
+func (m *GormUserEmailRepository) Load(ctx context.Context, id uuid.UUID) (*UserEmail, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "load"}, time.Now())
+	var native UserEmail
+	tx := m.db.Where("id = ?", id).First(&native)
+	if tx.RecordNotFound() {
+		return nil, errs.NewNotFoundError("email", id.String())
+	}
+	if tx.Error != nil {
+		return nil, errs.NewInternalError(tx.Error.Error())
+	}
+	return &native, nil
+}
+
+// Create creates a new email
+// returns InternalError
+func (m *GormUserEmailRepository) Create(ctx context.Context, email *UserEmail) error {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "create"}, time.Now())
+	if email.ID == uuid.Nil {
+		email.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(email).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"user_id": email.UserID,
+			"err":     err,
+		}, "unable to create the user email")
+		return errs.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"user_id":  email.UserID,
+		"email_id": email.ID,
+	}, "User email created!")
+	return nil
+}
+
+// Save modifies an email
+// returns InternalError
+func (m *GormUserEmailRepository) Save(ctx context.Context, email *UserEmail) error {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "save"}, time.Now())
+	// saving the whole struct, so that the flags and the verification token can be cleared
+	if err := m.db.Save(email).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// Delete removes the email with the given ID
+// returns NotFoundError or InternalError
+func (m *GormUserEmailRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "delete"}, time.Now())
+	tx := m.db.Delete(&UserEmail{ID: id})
+	if err := tx.Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errs.NewNotFoundError("email", id.String())
+	}
+	return nil
+}
+
+// DeleteByUser removes all the emails of the given user
+// returns InternalError
+func (m *GormUserEmailRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "delete_by_user"}, time.Now())
+	if err := m.db.Where("user_id = ?", userID).Delete(&UserEmail{}).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// List returns the emails of the given user, the primary email first
+// returns InternalError
+func (m *GormUserEmailRepository) List(ctx context.Context, userID uuid.UUID) ([]UserEmail, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "list"}, time.Now())
+	var res []UserEmail
+	err := m.db.Where("user_id = ?", userID).Order("is_primary DESC, created_at ASC").Find(&res).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
+
+// Query expose an open ended Query model
+func (m *GormUserEmailRepository) Query(funcs ...func(*gorm.DB) *gorm.DB) ([]UserEmail, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "user_email", "query"}, time.Now())
+	var objs []UserEmail
+	err := m.db.Scopes(funcs...).Table(UserEmail{}.TableName()).Find(&objs).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, errors.WithStack(err)
+	}
+	return objs, nil
+}
+
+// UserEmailFilterByEmailIgnoringCase is a gorm filter for the email addresses, regardless of their case.
+func UserEmailFilterByEmailIgnoringCase(email string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("lower(email) = lower(?)", email)
+	}
+}
+
+// UserEmailFilterByVerificationToken is a gorm filter by 'verification_token'.
+func UserEmailFilterByVerificationToken(token string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("verification_token = ?", token)
+	}
+}
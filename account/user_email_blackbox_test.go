@@ -0,0 +1,173 @@
+package account_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type userEmailBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.UserEmailRepository
+	users account.UserRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunUserEmailBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &userEmailBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *userEmailBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewUserEmailRepository(s.DB)
+	s.users = account.NewUserRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *userEmailBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *userEmailBlackBoxTest) createUser() account.User {
+	user := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: "TestUserEmail",
+	}
+	require.Nil(s.T(), s.users.Create(s.ctx, &user))
+	return user
+}
+
+func (s *userEmailBlackBoxTest) createEmail(user account.User, primary bool) account.UserEmail {
+	email := account.UserEmail{
+		UserID:  user.ID,
+		Email:   uuid.NewV4().String() + "@example.com",
+		Primary: primary,
+	}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &email))
+	return email
+}
+
+func (s *userEmailBlackBoxTest) TestCreateAndLoadOK() {
+	// given
+	user := s.createUser()
+	token := uuid.NewV4().String()
+	email := account.UserEmail{
+		UserID:            user.ID,
+		Email:             uuid.NewV4().String() + "@example.com",
+		VerificationToken: &token,
+	}
+	// when
+	err := s.repo.Create(s.ctx, &email)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := s.repo.Load(s.ctx, email.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), email.Email, loaded.Email)
+	assert.False(s.T(), loaded.Primary)
+	assert.False(s.T(), loaded.Verified)
+	require.NotNil(s.T(), loaded.VerificationToken)
+	assert.Equal(s.T(), token, *loaded.VerificationToken)
+}
+
+func (s *userEmailBlackBoxTest) TestLoadUnknownNotFound() {
+	// when
+	_, err := s.repo.Load(s.ctx, uuid.NewV4())
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *userEmailBlackBoxTest) TestSaveClearsVerificationToken() {
+	// given
+	user := s.createUser()
+	token := uuid.NewV4().String()
+	email := account.UserEmail{
+		UserID:            user.ID,
+		Email:             uuid.NewV4().String() + "@example.com",
+		VerificationToken: &token,
+	}
+	require.Nil(s.T(), s.repo.Create(s.ctx, &email))
+	email.Verified = true
+	email.VerificationToken = nil
+	// when
+	err := s.repo.Save(s.ctx, &email)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := s.repo.Load(s.ctx, email.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), loaded.Verified)
+	assert.Nil(s.T(), loaded.VerificationToken)
+}
+
+func (s *userEmailBlackBoxTest) TestListPrimaryFirst() {
+	// given
+	user := s.createUser()
+	secondary := s.createEmail(user, false)
+	primary := s.createEmail(user, true)
+	s.createEmail(s.createUser(), true)
+	// when
+	emails, err := s.repo.List(s.ctx, user.ID)
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), emails, 2)
+	assert.Equal(s.T(), primary.ID, emails[0].ID)
+	assert.Equal(s.T(), secondary.ID, emails[1].ID)
+}
+
+func (s *userEmailBlackBoxTest) TestDeleteOK() {
+	// given
+	user := s.createUser()
+	email := s.createEmail(user, false)
+	// when
+	err := s.repo.Delete(s.ctx, email.ID)
+	// then
+	require.Nil(s.T(), err)
+	_, err = s.repo.Load(s.ctx, email.ID)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+	err = s.repo.Delete(s.ctx, email.ID)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *userEmailBlackBoxTest) TestDeleteByUserOK() {
+	// given
+	user := s.createUser()
+	s.createEmail(user, true)
+	s.createEmail(user, false)
+	other := s.createUser()
+	s.createEmail(other, true)
+	// when
+	err := s.repo.DeleteByUser(s.ctx, user.ID)
+	// then
+	require.Nil(s.T(), err)
+	emails, err := s.repo.List(s.ctx, user.ID)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), emails)
+	emails, err = s.repo.List(s.ctx, other.ID)
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), emails, 1)
+}
+
+func (s *userEmailBlackBoxTest) TestQueryByEmailIgnoringCase() {
+	// given
+	user := s.createUser()
+	email := s.createEmail(user, false)
+	// when
+	emails, err := s.repo.Query(account.UserEmailFilterByEmailIgnoringCase(strings.ToUpper(email.Email)))
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), emails, 1)
+	assert.Equal(s.T(), email.ID, emails[0].ID)
+}
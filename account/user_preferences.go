@@ -0,0 +1,111 @@
+package account
+
+import (
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultLandingPage is the page a user lands on after login, until they choose another one
+	DefaultLandingPage = "/_home"
+	// DefaultTimezone is the timezone of the users who did not choose one
+	DefaultTimezone = "UTC"
+	// DefaultLocale is the locale of the users who did not choose one
+	DefaultLocale = "en"
+)
+
+// UserPreferences holds the preferences of a user account
+type UserPreferences struct {
+	gormsupport.Lifecycle
+	UserID      uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	LandingPage string    // The path of the page the user lands on after login
+	// Whether the user is notified by email when a work item is assigned to them,
+	// when a work item they follow is commented and when they are mentioned
+	EmailNotifyAssigned  bool
+	EmailNotifyCommented bool
+	EmailNotifyMentioned bool
+	Timezone             string // The IANA name of the timezone of the user, e.g. 'Europe/Paris'
+	Locale               string // The language tag of the locale of the user, e.g. 'en' or 'pt-BR'
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (p UserPreferences) TableName() string {
+	return "user_preferences"
+}
+
+// DefaultUserPreferences returns the preferences of the given user who has not saved any preference yet
+func DefaultUserPreferences(userID uuid.UUID) *UserPreferences {
+	return &UserPreferences{
+		UserID:               userID,
+		LandingPage:          DefaultLandingPage,
+		EmailNotifyAssigned:  true,
+		EmailNotifyCommented: true,
+		EmailNotifyMentioned: true,
+		Timezone:             DefaultTimezone,
+		Locale:               DefaultLocale,
+	}
+}
+
+// UserPreferencesRepository encapsulates storage & retrieval of the preferences of the users
+type UserPreferencesRepository interface {
+	Load(ctx context.Context, userID uuid.UUID) (*UserPreferences, error)
+	Save(ctx context.Context, preferences *UserPreferences) error
+}
+
+// NewUserPreferencesRepository creates a new user preferences repository
+func NewUserPreferencesRepository(db *gorm.DB) UserPreferencesRepository {
+	return &GormUserPreferencesRepository{db: db}
+}
+
+// GormUserPreferencesRepository implements UserPreferencesRepository using gorm
+type GormUserPreferencesRepository struct {
+	db *gorm.DB
+}
+
+// Load returns the preferences of the given user
+// returns NotFoundError if the user has not saved any preference yet
+func (m *GormUserPreferencesRepository) Load(ctx context.Context, userID uuid.UUID) (*UserPreferences, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "user_preferences", "load"}, time.Now())
+	var native UserPreferences
+	tx := m.db.Where("user_id = ?", userID).First(&native)
+	if tx.RecordNotFound() {
+		return nil, errs.NewNotFoundError("user preferences", userID.String())
+	}
+	if tx.Error != nil {
+		return nil, errors.WithStack(tx.Error)
+	}
+	return &native, nil
+}
+
+// Save creates or replaces the preferences of a user
+func (m *GormUserPreferencesRepository) Save(ctx context.Context, preferences *UserPreferences) error {
+	defer goa.MeasureSince([]string{"goa", "db", "user_preferences", "save"}, time.Now())
+	_, err := m.Load(ctx, preferences.UserID)
+	if _, notFound := err.(errs.NotFoundError); notFound {
+		err = m.db.Create(preferences).Error
+	} else if err == nil {
+		// saving the whole struct, so that the notifications can be turned off
+		err = m.db.Save(preferences).Error
+	}
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"user_id": preferences.UserID,
+			"err":     err,
+		}, "unable to save the user preferences")
+		return errors.WithStack(err)
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"user_id": preferences.UserID,
+	}, "User preferences saved!")
+	return nil
+}
@@ -0,0 +1,94 @@
+package account_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type userPreferencesBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo  account.UserPreferencesRepository
+	users account.UserRepository
+	clean func()
+	ctx   context.Context
+}
+
+func TestRunUserPreferencesBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &userPreferencesBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *userPreferencesBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = account.NewUserPreferencesRepository(s.DB)
+	s.users = account.NewUserRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *userPreferencesBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *userPreferencesBlackBoxTest) createUser() account.User {
+	user := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: "TestUserPreferences",
+	}
+	require.Nil(s.T(), s.users.Create(s.ctx, &user))
+	return user
+}
+
+func (s *userPreferencesBlackBoxTest) TestLoadNotSavedNotFound() {
+	// given
+	user := s.createUser()
+	// when
+	_, err := s.repo.Load(s.ctx, user.ID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *userPreferencesBlackBoxTest) TestSaveAndLoadOK() {
+	// given
+	user := s.createUser()
+	preferences := account.DefaultUserPreferences(user.ID)
+	preferences.Timezone = "Europe/Paris"
+	// when
+	err := s.repo.Save(s.ctx, preferences)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := s.repo.Load(s.ctx, user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "Europe/Paris", loaded.Timezone)
+	assert.Equal(s.T(), account.DefaultLandingPage, loaded.LandingPage)
+	assert.True(s.T(), loaded.EmailNotifyMentioned)
+}
+
+func (s *userPreferencesBlackBoxTest) TestSaveReplacesPreferences() {
+	// given
+	user := s.createUser()
+	require.Nil(s.T(), s.repo.Save(s.ctx, account.DefaultUserPreferences(user.ID)))
+	preferences := account.DefaultUserPreferences(user.ID)
+	preferences.EmailNotifyCommented = false
+	preferences.Locale = "pt-BR"
+	// when
+	err := s.repo.Save(s.ctx, preferences)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := s.repo.Load(s.ctx, user.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), loaded.EmailNotifyCommented)
+	assert.True(s.T(), loaded.EmailNotifyAssigned)
+	assert.Equal(s.T(), "pt-BR", loaded.Locale)
+}
@@ -0,0 +1,78 @@
+package account
+
+import (
+	"time"
+
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// UsernameChange records a change of the username of an identity
+type UsernameChange struct {
+	gormsupport.Lifecycle
+	ID          uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	IdentityID  uuid.UUID `sql:"type:uuid"`
+	OldUsername string
+	NewUsername string
+	// The identity which changed the username: the identity itself, or an administrator
+	ChangedBy uuid.UUID `sql:"type:uuid"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (c UsernameChange) TableName() string {
+	return "username_history"
+}
+
+// UsernameHistoryRepository encapsulates storage & retrieval of the changes of the usernames
+type UsernameHistoryRepository interface {
+	Create(ctx context.Context, change *UsernameChange) error
+	List(ctx context.Context, identityID uuid.UUID) ([]UsernameChange, error)
+}
+
+// NewUsernameHistoryRepository creates a new username history repository
+func NewUsernameHistoryRepository(db *gorm.DB) UsernameHistoryRepository {
+	return &GormUsernameHistoryRepository{db: db}
+}
+
+// GormUsernameHistoryRepository implements UsernameHistoryRepository using gorm
+type GormUsernameHistoryRepository struct {
+	db *gorm.DB
+}
+
+// Create records a new change of username
+// returns InternalError
+func (m *GormUsernameHistoryRepository) Create(ctx context.Context, change *UsernameChange) error {
+	defer goa.MeasureSince([]string{"goa", "db", "username_history", "create"}, time.Now())
+	if change.ID == uuid.Nil {
+		change.ID = uuid.NewV4()
+	}
+	if err := m.db.Create(change).Error; err != nil {
+		return errs.NewInternalError(err.Error())
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id":  change.IdentityID,
+		"old_username": change.OldUsername,
+		"new_username": change.NewUsername,
+		"changed_by":   change.ChangedBy,
+	}, "username change recorded")
+	return nil
+}
+
+// List returns the changes of the username of the given identity, the oldest first
+// returns InternalError
+func (m *GormUsernameHistoryRepository) List(ctx context.Context, identityID uuid.UUID) ([]UsernameChange, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "username_history", "list"}, time.Now())
+	var res []UsernameChange
+	err := m.db.Where("identity_id = ?", identityID).Order("created_at ASC").Find(&res).Error
+	if err != nil {
+		return nil, errs.NewInternalError(err.Error())
+	}
+	return res, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/notification"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/workitem"
 	"github.com/almighty/almighty-core/workitem/link"
@@ -17,6 +18,7 @@ import (
 type Application interface {
 	WorkItems() workitem.WorkItemRepository
 	WorkItemTypes() workitem.WorkItemTypeRepository
+	WorkItemRevisions() workitem.RevisionRepository
 	Trackers() TrackerRepository
 	TrackerQueries() TrackerQueryRepository
 	SearchItems() SearchRepository
@@ -27,10 +29,21 @@ type Application interface {
 	Comments() comment.Repository
 	Spaces() space.Repository
 	SpaceResources() space.ResourceRepository
+	Invitations() space.InvitationRepository
+	SpaceCollaborators() space.CollaboratorRepository
 	Iterations() iteration.Repository
 	Users() account.UserRepository
+	UserPreferences() account.UserPreferencesRepository
+	UsernameHistory() account.UsernameHistoryRepository
+	UserEmails() account.UserEmailRepository
+	Impersonations() account.ImpersonationRepository
+	ProfileEvents() account.ProfileEventRepository
+	PersonalAccessTokens() account.PersonalAccessTokenRepository
+	Teams() account.TeamRepository
 	Areas() area.Repository
 	OauthStates() auth.OauthStateReferenceRepository
+	Sessions() auth.SessionRepository
+	Notifications() notification.Repository
 	Codebases() codebase.Repository
 }
 
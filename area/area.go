@@ -52,6 +52,7 @@ type Repository interface {
 	Load(ctx context.Context, id uuid.UUID) (*Area, error)
 	LoadMultiple(ctx context.Context, ids []uuid.UUID) ([]Area, error)
 	ListChildren(ctx context.Context, parentArea *Area) ([]Area, error)
+	ListDescendants(ctx context.Context, parentArea *Area) ([]Area, error)
 	Query(funcs ...func(*gorm.DB) *gorm.DB) ([]Area, error)
 	Root(ctx context.Context, spaceID uuid.UUID) (*Area, error)
 }
@@ -142,6 +143,18 @@ func (m *GormAreaRepository) ListChildren(ctx context.Context, parentArea *Area)
 	return objs, nil
 }
 
+// ListDescendants fetches all Areas below a parent, at any depth - select * from areas where path <@ 'parent_path.parent_id';
+func (m *GormAreaRepository) ListDescendants(ctx context.Context, parentArea *Area) ([]Area, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "Area", "querydescendants"}, time.Now())
+	var objs []Area
+
+	tx := m.db.Where("path <@ ?", path.ToExpression(parentArea.Path, parentArea.ID)).Find(&objs)
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	return objs, nil
+}
+
 // Root fetches the Root Areas inside a space.
 func (m *GormAreaRepository) Root(ctx context.Context, spaceID uuid.UUID) (*Area, error) {
 	defer goa.MeasureSince([]string{"goa", "db", "Area", "root"}, time.Now())
@@ -14,6 +14,8 @@ import (
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/rest"
+
+	errs "github.com/pkg/errors"
 )
 
 const (
@@ -27,6 +29,14 @@ const (
 	PolicyDecisionStrategyUnanimous = "UNANIMOUS"
 )
 
+// Roles of the users listed in a space policy. The users without a role are contributors.
+// Keycloak only knows about the users of the policy: the roles are enforced by the API.
+const (
+	PolicyRoleAdmin       = "admin"
+	PolicyRoleContributor = "contributor"
+	PolicyRoleViewer      = "viewer"
+)
+
 // KeycloakResource represents a keyclaok resource payload
 type KeycloakResource struct {
 	Name   string    `json:"name"`
@@ -63,6 +73,8 @@ type KeycloakPolicy struct {
 type PolicyConfigData struct {
 	//"users":"[\"<ID>\",\"<ID>\"]"
 	UserIDs string `json:"users"`
+	//"roles":"{\"<ID>\":\"admin\"}"
+	Roles string `json:"roles,omitempty"`
 }
 
 // Token represents a Keycloak token response
@@ -75,44 +87,162 @@ type Token struct {
 	TokenType        *string `json:"token_type,omitempty"`
 }
 
-// AddUserToPolicy adds the user ID to the policy
+// AddUserToPolicy adds the user ID to the policy, removing the duplicated user IDs if any.
+// Returns true if the policy was modified
 func (p *KeycloakPolicy) AddUserToPolicy(userID string) bool {
-	currentUsers := p.Config.UserIDs
-	if strings.Contains(currentUsers, userID) {
+	deduplicated := p.RemoveDuplicateUsers()
+	if p.HasUser(userID) {
+		return deduplicated
+	}
+	p.setUserIDs(append(p.userIDs(), userID))
+	return true
+}
+
+// HasUser returns true if the user ID is listed in the policy
+func (p *KeycloakPolicy) HasUser(userID string) bool {
+	for _, id := range p.userIDs() {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveUserFromPolicy removes the user ID from the policy, removing the duplicated user IDs if any.
+// Returns true if the policy was modified
+func (p *KeycloakPolicy) RemoveUserFromPolicy(userID string) bool {
+	ids := p.userIDs()
+	remaining := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != userID {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == len(ids) {
+		return p.RemoveDuplicateUsers()
+	}
+	p.setUserIDs(remaining)
+	p.RemoveDuplicateUsers()
+	roles := p.userRoles()
+	if _, ok := roles[userID]; ok {
+		delete(roles, userID)
+		p.setUserRoles(roles)
+	}
+	return true
+}
+
+// UserRole returns the role of the user in the policy, or an empty string if the user is not listed in the policy
+func (p *KeycloakPolicy) UserRole(userID string) string {
+	if !p.HasUser(userID) {
+		return ""
+	}
+	if role, ok := p.userRoles()[userID]; ok {
+		return role
+	}
+	return PolicyRoleContributor
+}
+
+// SetUserRole sets the role of a user listed in the policy.
+// Returns true if the policy was modified
+func (p *KeycloakPolicy) SetUserRole(userID string, role string) bool {
+	if p.UserRole(userID) == role || !p.HasUser(userID) {
 		return false
 	}
-	s := strings.Split(currentUsers, "]")
-	if len(s) > 1 {
-		p.Config.UserIDs = fmt.Sprintf("%s,\"%s\"]", s[0], userID)
+	roles := p.userRoles()
+	if role == PolicyRoleContributor {
+		// the contributors are not stored, since it is the role by default
+		delete(roles, userID)
 	} else {
-		p.Config.UserIDs = fmt.Sprintf("[\"%s\"]", userID)
+		roles[userID] = role
 	}
+	p.setUserRoles(roles)
 	return true
 }
 
-// RemoveUserFromPolicy removes the user ID from the policy
-func (p *KeycloakPolicy) RemoveUserFromPolicy(userID string) bool {
-	currentUsers := p.Config.UserIDs
-	s := strings.Split(currentUsers, ",")
-	var found bool
-	var i int
-	newUsers := make([]string, len(s))
-	for _, id := range s {
-		newUsers[i] = strings.Trim(id, "[]")
-		if strings.Trim(newUsers[i], "\"") == userID {
-			found = true
-		} else {
-			i++
+// RemoveDuplicateUsers removes the user IDs which are listed more than once in the policy,
+// keeping their first occurrence. Returns true if the policy contained duplicates
+func (p *KeycloakPolicy) RemoveDuplicateUsers() bool {
+	ids := p.userIDs()
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
 		}
 	}
-	if !found {
+	if len(unique) == len(ids) {
 		return false
 	}
-	newUsers = newUsers[0 : len(newUsers)-1]
-	p.Config.UserIDs = fmt.Sprintf("[%s]", strings.Join(newUsers, ","))
+	p.setUserIDs(unique)
 	return true
 }
 
+// UserIDs returns the user IDs listed in the policy, in order, or an error if the users of the policy can't be parsed
+func (p *KeycloakPolicy) UserIDs() ([]string, error) {
+	return ParsePolicyUserIDs(p.Config.UserIDs)
+}
+
+// ParsePolicyUserIDs parses the users of a policy, stored as a JSON array of IDs (`["<ID>","<ID>"]`)
+// which may itself be encoded as a JSON string. An empty value means that the policy has no users.
+func ParsePolicyUserIDs(userIDs string) ([]string, error) {
+	userIDs = strings.TrimSpace(userIDs)
+	if userIDs == "" {
+		return []string{}, nil
+	}
+	var encoded string
+	if err := json.Unmarshal([]byte(userIDs), &encoded); err == nil {
+		return ParsePolicyUserIDs(encoded)
+	}
+	ids := []string{}
+	if err := json.Unmarshal([]byte(userIDs), &ids); err != nil {
+		return nil, errs.Wrapf(err, "invalid users in the policy: %s", userIDs)
+	}
+	return ids, nil
+}
+
+// userIDs returns the user IDs listed in the policy, in order. The users of a policy which can't be parsed
+// are split on commas, so that the IDs it contains are still neither lost nor granted access by mistake.
+func (p *KeycloakPolicy) userIDs() []string {
+	if ids, err := p.UserIDs(); err == nil {
+		return ids
+	}
+	ids := []string{}
+	for _, id := range strings.Split(p.Config.UserIDs, ",") {
+		id = strings.Trim(strings.TrimSpace(id), "[]\"\\")
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// setUserIDs stores the given user IDs in the policy (`["<ID>","<ID>"]`)
+func (p *KeycloakPolicy) setUserIDs(ids []string) {
+	b, _ := json.Marshal(ids)
+	p.Config.UserIDs = string(b)
+}
+
+// userRoles returns the roles of the users listed in the policy, except for the contributors
+func (p *KeycloakPolicy) userRoles() map[string]string {
+	roles := map[string]string{}
+	if p.Config.Roles != "" {
+		// the roles are only set by the API, hence an invalid value is ignored
+		json.Unmarshal([]byte(p.Config.Roles), &roles)
+	}
+	return roles
+}
+
+// setUserRoles stores the given roles in the policy (`{"<ID>":"admin"}`)
+func (p *KeycloakPolicy) setUserRoles(roles map[string]string) {
+	if len(roles) == 0 {
+		p.Config.Roles = ""
+		return
+	}
+	b, _ := json.Marshal(roles)
+	p.Config.Roles = string(b)
+}
+
 // KeycloakPermission represents a keyclaok permission payload
 type KeycloakPermission struct {
 	ID               *string              `json:"id,omitempty"`
@@ -265,6 +265,184 @@ func (s *TestAuthSuite) TestReadTokenOK() {
 	assert.Equal(s.T(), "refToken", *token.RefreshToken)
 }
 
+func TestAddSameUserToPolicyTwice(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	policy := auth.KeycloakPolicy{}
+	userID := uuid.NewV4().String()
+	// when
+	added := policy.AddUserToPolicy(userID)
+	addedAgain := policy.AddUserToPolicy(userID)
+	// then
+	assert.True(t, added)
+	assert.False(t, addedAgain)
+	assert.Equal(t, fmt.Sprintf("[\"%s\"]", userID), policy.Config.UserIDs)
+}
+
+func TestAddUserToPolicyWithDuplicatedUsers(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	userID1 := uuid.NewV4().String()
+	userID2 := uuid.NewV4().String()
+	policy := auth.KeycloakPolicy{
+		Config: auth.PolicyConfigData{UserIDs: fmt.Sprintf("[\"%s\",\"%s\",\"%s\"]", userID1, userID2, userID1)},
+	}
+	// when
+	added := policy.AddUserToPolicy(userID2)
+	// then the policy is modified since the duplicates are removed
+	assert.True(t, added)
+	assert.Equal(t, fmt.Sprintf("[\"%s\",\"%s\"]", userID1, userID2), policy.Config.UserIDs)
+}
+
+func TestRemoveDuplicatedUserFromPolicy(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	userID1 := uuid.NewV4().String()
+	userID2 := uuid.NewV4().String()
+	policy := auth.KeycloakPolicy{
+		Config: auth.PolicyConfigData{UserIDs: fmt.Sprintf("[\"%s\",\"%s\",\"%s\",\"%s\"]", userID1, userID2, userID1, userID2)},
+	}
+	// when
+	removed := policy.RemoveUserFromPolicy(userID1)
+	// then
+	assert.True(t, removed)
+	assert.False(t, policy.HasUser(userID1))
+	assert.Equal(t, fmt.Sprintf("[\"%s\"]", userID2), policy.Config.UserIDs)
+}
+
+func TestRemoveDuplicateUsers(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	userID1 := uuid.NewV4().String()
+	userID2 := uuid.NewV4().String()
+	policy := auth.KeycloakPolicy{
+		Config: auth.PolicyConfigData{UserIDs: fmt.Sprintf("[\"%s\",\"%s\",\"%s\"]", userID1, userID1, userID2)},
+	}
+	// when/then
+	assert.True(t, policy.RemoveDuplicateUsers())
+	assert.Equal(t, fmt.Sprintf("[\"%s\",\"%s\"]", userID1, userID2), policy.Config.UserIDs)
+	assert.False(t, policy.RemoveDuplicateUsers())
+}
+
+func TestSetUserRoleInPolicy(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	userID1 := uuid.NewV4().String()
+	userID2 := uuid.NewV4().String()
+	policy := auth.KeycloakPolicy{}
+	policy.AddUserToPolicy(userID1)
+	policy.AddUserToPolicy(userID2)
+	// when/then the users are contributors by default
+	assert.Equal(t, auth.PolicyRoleContributor, policy.UserRole(userID1))
+	assert.Equal(t, "", policy.Config.Roles)
+	assert.True(t, policy.SetUserRole(userID1, auth.PolicyRoleAdmin))
+	assert.False(t, policy.SetUserRole(userID1, auth.PolicyRoleAdmin))
+	assert.True(t, policy.SetUserRole(userID2, auth.PolicyRoleViewer))
+	assert.Equal(t, auth.PolicyRoleAdmin, policy.UserRole(userID1))
+	assert.Equal(t, auth.PolicyRoleViewer, policy.UserRole(userID2))
+	// back to contributor, which is not stored
+	assert.True(t, policy.SetUserRole(userID2, auth.PolicyRoleContributor))
+	assert.Equal(t, fmt.Sprintf("{\"%s\":\"admin\"}", userID1), policy.Config.Roles)
+	// the users which are not listed have no role
+	assert.False(t, policy.SetUserRole(uuid.NewV4().String(), auth.PolicyRoleAdmin))
+	assert.Equal(t, "", policy.UserRole(uuid.NewV4().String()))
+}
+
+func TestRemoveUserWithRoleFromPolicy(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	userID := uuid.NewV4().String()
+	policy := auth.KeycloakPolicy{}
+	policy.AddUserToPolicy(userID)
+	policy.SetUserRole(userID, auth.PolicyRoleAdmin)
+	// when
+	removed := policy.RemoveUserFromPolicy(userID)
+	// then the role is removed along with the user
+	assert.True(t, removed)
+	assert.Equal(t, "", policy.Config.Roles)
+	policy.AddUserToPolicy(userID)
+	assert.Equal(t, auth.PolicyRoleContributor, policy.UserRole(userID))
+}
+
+func TestParsePolicyUserIDs(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("empty policy", func(t *testing.T) {
+		for _, userIDs := range []string{"", "  ", "[]", "[ ]", `"[]"`} {
+			ids, err := auth.ParsePolicyUserIDs(userIDs)
+			require.Nil(t, err, userIDs)
+			assert.Empty(t, ids, userIDs)
+		}
+	})
+
+	t.Run("single user", func(t *testing.T) {
+		ids, err := auth.ParsePolicyUserIDs(`["6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a"]`)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a"}, ids)
+	})
+
+	t.Run("several users with whitespace", func(t *testing.T) {
+		ids, err := auth.ParsePolicyUserIDs(` [ "6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a" ,
+			"1d0e3c7b-8f5a-4e2b-9c6d-7a8b9c0d1e2f" ] `)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a", "1d0e3c7b-8f5a-4e2b-9c6d-7a8b9c0d1e2f"}, ids)
+	})
+
+	t.Run("double encoded", func(t *testing.T) {
+		ids, err := auth.ParsePolicyUserIDs(`"[\"6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a\",\"1d0e3c7b-8f5a-4e2b-9c6d-7a8b9c0d1e2f\"]"`)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a", "1d0e3c7b-8f5a-4e2b-9c6d-7a8b9c0d1e2f"}, ids)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, userIDs := range []string{`["6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a"`, `6c9b4f5a-5a3e-4d0b-a3b0-2f4f2d3c1e7a`, `[1, 2]`, `{"users":[]}`, `"not an array"`} {
+			_, err := auth.ParsePolicyUserIDs(userIDs)
+			assert.NotNil(t, err, userIDs)
+		}
+	})
+}
+
+func TestDoubleEncodedPolicyUsers(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	userID1 := uuid.NewV4().String()
+	userID2 := uuid.NewV4().String()
+	newDoubleEncodedPolicy := func() auth.KeycloakPolicy {
+		encoded, err := json.Marshal(fmt.Sprintf(`["%s","%s"]`, userID1, userID2))
+		require.Nil(t, err)
+		return auth.KeycloakPolicy{Config: auth.PolicyConfigData{UserIDs: string(encoded)}}
+	}
+
+	t.Run("has user", func(t *testing.T) {
+		policy := newDoubleEncodedPolicy()
+		assert.True(t, policy.HasUser(userID1))
+		assert.True(t, policy.HasUser(userID2))
+		assert.False(t, policy.HasUser(uuid.NewV4().String()))
+		assert.Equal(t, auth.PolicyRoleContributor, policy.UserRole(userID2))
+	})
+
+	t.Run("add user", func(t *testing.T) {
+		policy := newDoubleEncodedPolicy()
+		userID3 := uuid.NewV4().String()
+		assert.False(t, policy.AddUserToPolicy(userID1))
+		assert.True(t, policy.AddUserToPolicy(userID3))
+		// the users are stored again as a plain JSON array
+		assert.Equal(t, fmt.Sprintf(`["%s","%s","%s"]`, userID1, userID2, userID3), policy.Config.UserIDs)
+	})
+
+	t.Run("remove user", func(t *testing.T) {
+		policy := newDoubleEncodedPolicy()
+		assert.True(t, policy.RemoveUserFromPolicy(userID1))
+		assert.Equal(t, fmt.Sprintf(`["%s"]`, userID2), policy.Config.UserIDs)
+	})
+
+	t.Run("set role", func(t *testing.T) {
+		policy := newDoubleEncodedPolicy()
+		assert.True(t, policy.SetUserRole(userID2, auth.PolicyRoleViewer))
+		assert.Equal(t, auth.PolicyRoleViewer, policy.UserRole(userID2))
+	})
+}
+
 func (s *TestAuthSuite) TestUpdateUserToPolicyOK() {
 	policy := auth.KeycloakPolicy{
 		Name:             "test-" + uuid.NewV4().String(),
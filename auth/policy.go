@@ -9,6 +9,7 @@ import (
 // AuthzPolicyManager represents a space collaborators policy manager
 type AuthzPolicyManager interface {
 	GetPolicy(ctx context.Context, request *goa.RequestData, policyID string) (*KeycloakPolicy, *string, error)
+	GetPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string) ([]*KeycloakPolicy, error)
 	UpdatePolicy(ctx context.Context, request *goa.RequestData, policy KeycloakPolicy, pat string) error
 	AddUserToPolicy(p *KeycloakPolicy, userID string) bool
 	RemoveUserFromPolicy(p *KeycloakPolicy, userID string) bool
@@ -58,6 +59,33 @@ func (m *KeycloakPolicyManager) GetPolicy(ctx context.Context, request *goa.Requ
 	return policy, &pat, nil
 }
 
+// GetPolicies obtains the space collaborators policies with the given IDs, in the same order.
+// The protection API token and the client ID are obtained once for all the policies.
+func (m *KeycloakPolicyManager) GetPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string) ([]*KeycloakPolicy, error) {
+	clientsEndpoint, err := m.configuration.GetKeycloakEndpointClients(request)
+	if err != nil {
+		return nil, err
+	}
+	pat, err := getPat(request, m.configuration)
+	if err != nil {
+		return nil, err
+	}
+	publicClientID := m.configuration.GetKeycloakClientID()
+	clientID, err := GetClientID(context.Background(), clientsEndpoint, publicClientID, pat)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*KeycloakPolicy, len(policyIDs))
+	for i, policyID := range policyIDs {
+		policies[i], err = GetPolicy(ctx, clientsEndpoint, clientID, policyID, pat)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
 // UpdatePolicy updates the space collaborators policy
 func (m *KeycloakPolicyManager) UpdatePolicy(ctx context.Context, request *goa.RequestData, policy KeycloakPolicy, pat string) error {
 	clientsEndpoint, err := m.configuration.GetKeycloakEndpointClients(request)
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+const (
+	sessionTableName = "sessions"
+
+	// SessionLimitPolicyRevokeOldest revokes the oldest active sessions of an identity to make room for a new one
+	SessionLimitPolicyRevokeOldest = "revoke-oldest"
+	// SessionLimitPolicyReject rejects a new session when an identity already reached its max number of active sessions
+	SessionLimitPolicyReject = "reject"
+)
+
+// Session represents a login session of an identity, as identified by the
+// 'session_state' claim of the tokens issued by Keycloak
+type Session struct {
+	gormsupport.Lifecycle
+	ID           uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	IdentityID   uuid.UUID `sql:"type:uuid"`
+	SessionState string
+	RevokedAt    *time.Time
+	// ExpiresAt is the time after which the tokens of the session cannot be refreshed anymore, if known
+	ExpiresAt *time.Time
+}
+
+// TableName implements gorm.tabler
+func (s Session) TableName() string {
+	return sessionTableName
+}
+
+// SessionRepository encapsulate storage & retrieval of sessions
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) (*Session, error)
+	LoadBySessionState(ctx context.Context, sessionState string) (*Session, error)
+	ListActive(ctx context.Context, identityID uuid.UUID) ([]Session, error)
+	Revoke(ctx context.Context, ID uuid.UUID) error
+	Extend(ctx context.Context, ID uuid.UUID, expiresAt time.Time) error
+}
+
+// NewSessionRepository creates a new session repo
+func NewSessionRepository(db *gorm.DB) *GormSessionRepository {
+	return &GormSessionRepository{db}
+}
+
+// GormSessionRepository implements SessionRepository using gorm
+type GormSessionRepository struct {
+	db *gorm.DB
+}
+
+// Create creates a new session in the DB
+// returns InternalError
+func (r *GormSessionRepository) Create(ctx context.Context, session *Session) (*Session, error) {
+	if session.ID == uuid.Nil {
+		session.ID = uuid.NewV4()
+	}
+	tx := r.db.Create(session)
+	if err := tx.Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	log.Info(ctx, map[string]interface{}{
+		"session_id":  session.ID,
+		"identity_id": session.IdentityID,
+	}, "Session created successfully")
+	return session, nil
+}
+
+// LoadBySessionState loads the session with the given session state, be it active or revoked
+// returns NotFoundError or InternalError
+func (r *GormSessionRepository) LoadBySessionState(ctx context.Context, sessionState string) (*Session, error) {
+	res := Session{}
+	tx := r.db.Where("session_state=?", sessionState).First(&res)
+	if tx.RecordNotFound() {
+		return nil, errors.NewNotFoundError("session", sessionState)
+	}
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	return &res, nil
+}
+
+// ListActive returns the sessions of the given identity which have neither been revoked nor expired, the oldest first
+// returns InternalError
+func (r *GormSessionRepository) ListActive(ctx context.Context, identityID uuid.UUID) ([]Session, error) {
+	var res []Session
+	err := r.db.Where("identity_id=? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", identityID, time.Now()).Order("created_at ASC").Find(&res).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return res, nil
+}
+
+// Revoke marks the session with the given id as revoked
+// returns NotFoundError or InternalError
+func (r *GormSessionRepository) Revoke(ctx context.Context, ID uuid.UUID) error {
+	tx := r.db.Model(&Session{}).Where("id=? AND revoked_at IS NULL", ID).Update("revoked_at", time.Now())
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errors.NewNotFoundError("session", ID.String())
+	}
+	return nil
+}
+
+// Extend postpones the expiry of the session with the given id, e.g. when its tokens are refreshed
+// returns NotFoundError or InternalError
+func (r *GormSessionRepository) Extend(ctx context.Context, ID uuid.UUID, expiresAt time.Time) error {
+	tx := r.db.Model(&Session{}).Where("id=? AND revoked_at IS NULL", ID).Update("expires_at", expiresAt)
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errors.NewNotFoundError("session", ID.String())
+	}
+	return nil
+}
+
+// RevokeBySessionState revokes the session with the given session state, if it is known and still active
+// returns InternalError
+func RevokeBySessionState(ctx context.Context, repo SessionRepository, sessionState string) error {
+	session, err := repo.LoadBySessionState(ctx, sessionState)
+	if err != nil {
+		if _, notFound := err.(errors.NotFoundError); notFound {
+			return nil
+		}
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	if err := repo.Revoke(ctx, session.ID); err != nil {
+		if _, notFound := err.(errors.NotFoundError); notFound {
+			return nil
+		}
+		return err
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": session.IdentityID,
+		"session_id":  session.ID,
+	}, "session revoked")
+	return nil
+}
+
+// RegisterSession records the given new session of an identity, enforcing the max number of active sessions
+// per identity with the given policy. A maxActive value lower than 1 means that no limit applies.
+// Registering a session which is already known only extends its expiry.
+// returns UnauthorizedError if the session was revoked or rejected by the policy, or InternalError
+func RegisterSession(ctx context.Context, repo SessionRepository, session *Session, maxActive int, policy string) error {
+	existing, err := repo.LoadBySessionState(ctx, session.SessionState)
+	if err == nil {
+		if existing.RevokedAt != nil {
+			return errors.NewUnauthorizedError("session has been revoked")
+		}
+		if session.ExpiresAt != nil {
+			return repo.Extend(ctx, existing.ID, *session.ExpiresAt)
+		}
+		return nil
+	}
+	if _, ok := err.(errors.NotFoundError); !ok {
+		return err
+	}
+	if maxActive > 0 {
+		active, err := repo.ListActive(ctx, session.IdentityID)
+		if err != nil {
+			return err
+		}
+		if exceeding := len(active) - maxActive + 1; exceeding > 0 {
+			if policy == SessionLimitPolicyReject {
+				log.Warn(ctx, map[string]interface{}{
+					"identity_id":     session.IdentityID,
+					"active_sessions": len(active),
+				}, "new session rejected: max number of active sessions reached")
+				return errors.NewUnauthorizedError(fmt.Sprintf("max number of active sessions (%d) reached", maxActive))
+			}
+			for _, s := range active[:exceeding] {
+				if err := repo.Revoke(ctx, s.ID); err != nil {
+					return err
+				}
+				log.Info(ctx, map[string]interface{}{
+					"identity_id": session.IdentityID,
+					"session_id":  s.ID,
+				}, "oldest session revoked: max number of active sessions reached")
+			}
+		}
+	}
+	_, err = repo.Create(ctx, session)
+	return err
+}
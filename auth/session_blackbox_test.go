@@ -0,0 +1,143 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/migration"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type sessionBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo     auth.SessionRepository
+	identity account.Identity
+	clean    func()
+	ctx      context.Context
+}
+
+func TestRunSessionBlackBoxTest(t *testing.T) {
+	suite.Run(t, &sessionBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *sessionBlackBoxTest) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.ctx = migration.NewMigrationContext(context.Background())
+	s.DBTestSuite.PopulateDBTestSuite(s.ctx)
+}
+
+func (s *sessionBlackBoxTest) SetupTest() {
+	s.repo = auth.NewSessionRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	identity, err := testsupport.CreateTestIdentity(s.DB, "session-test-"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	s.identity = identity
+}
+
+func (s *sessionBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+// registerSessions registers as many new sessions as requested for the test identity and returns them
+func (s *sessionBlackBoxTest) registerSessions(count, maxActive int, policy string) []*auth.Session {
+	sessions := make([]*auth.Session, count)
+	for i := range sessions {
+		sessions[i] = &auth.Session{IdentityID: s.identity.ID, SessionState: uuid.NewV4().String()}
+		err := auth.RegisterSession(s.ctx, s.repo, sessions[i], maxActive, policy)
+		require.Nil(s.T(), err)
+	}
+	return sessions
+}
+
+func (s *sessionBlackBoxTest) activeSessionIDs() []uuid.UUID {
+	active, err := s.repo.ListActive(s.ctx, s.identity.ID)
+	require.Nil(s.T(), err)
+	ids := make([]uuid.UUID, len(active))
+	for i, session := range active {
+		ids[i] = session.ID
+	}
+	return ids
+}
+
+func (s *sessionBlackBoxTest) TestRegisterSessionUnlimited() {
+	// when
+	s.registerSessions(5, 0, auth.SessionLimitPolicyReject)
+	// then
+	assert.Len(s.T(), s.activeSessionIDs(), 5)
+}
+
+func (s *sessionBlackBoxTest) TestRegisterSessionRevokeOldest() {
+	// given
+	sessions := s.registerSessions(2, 2, auth.SessionLimitPolicyRevokeOldest)
+	// when exceeding the limit
+	newSession := &auth.Session{IdentityID: s.identity.ID, SessionState: uuid.NewV4().String()}
+	err := auth.RegisterSession(s.ctx, s.repo, newSession, 2, auth.SessionLimitPolicyRevokeOldest)
+	// then the oldest session is revoked
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []uuid.UUID{sessions[1].ID, newSession.ID}, s.activeSessionIDs())
+	revoked, err := s.repo.LoadBySessionState(s.ctx, sessions[0].SessionState)
+	require.Nil(s.T(), err)
+	assert.NotNil(s.T(), revoked.RevokedAt)
+	// and logging in again with the revoked session is not allowed
+	err = auth.RegisterSession(s.ctx, s.repo, &auth.Session{IdentityID: s.identity.ID, SessionState: sessions[0].SessionState}, 2, auth.SessionLimitPolicyRevokeOldest)
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.UnauthorizedError{}, err)
+}
+
+func (s *sessionBlackBoxTest) TestRegisterSessionReject() {
+	// given
+	sessions := s.registerSessions(2, 2, auth.SessionLimitPolicyReject)
+	// when exceeding the limit
+	err := auth.RegisterSession(s.ctx, s.repo, &auth.Session{IdentityID: s.identity.ID, SessionState: uuid.NewV4().String()}, 2, auth.SessionLimitPolicyReject)
+	// then the new session is rejected and the existing ones are kept
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.UnauthorizedError{}, err)
+	assert.Equal(s.T(), []uuid.UUID{sessions[0].ID, sessions[1].ID}, s.activeSessionIDs())
+}
+
+func (s *sessionBlackBoxTest) TestRegisterExistingSession() {
+	// given
+	sessions := s.registerSessions(2, 2, auth.SessionLimitPolicyReject)
+	// when logging in again with an active session
+	err := auth.RegisterSession(s.ctx, s.repo, &auth.Session{IdentityID: s.identity.ID, SessionState: sessions[1].SessionState}, 2, auth.SessionLimitPolicyReject)
+	// then nothing changes
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []uuid.UUID{sessions[0].ID, sessions[1].ID}, s.activeSessionIDs())
+}
+
+func (s *sessionBlackBoxTest) TestListActiveIgnoresExpiredSessions() {
+	// given
+	expired := time.Now().Add(-time.Minute)
+	err := auth.RegisterSession(s.ctx, s.repo, &auth.Session{IdentityID: s.identity.ID, SessionState: uuid.NewV4().String(), ExpiresAt: &expired}, 2, auth.SessionLimitPolicyReject)
+	require.Nil(s.T(), err)
+	sessions := s.registerSessions(2, 2, auth.SessionLimitPolicyReject)
+	// then the expired session neither is active nor counts towards the limit
+	assert.Equal(s.T(), []uuid.UUID{sessions[0].ID, sessions[1].ID}, s.activeSessionIDs())
+}
+
+func (s *sessionBlackBoxTest) TestRegisterExistingSessionExtendsExpiry() {
+	// given
+	expiresAt := time.Now().Add(time.Minute)
+	session := &auth.Session{IdentityID: s.identity.ID, SessionState: uuid.NewV4().String(), ExpiresAt: &expiresAt}
+	require.Nil(s.T(), auth.RegisterSession(s.ctx, s.repo, session, 2, auth.SessionLimitPolicyReject))
+	// when logging in again with the session
+	extended := time.Now().Add(time.Hour)
+	err := auth.RegisterSession(s.ctx, s.repo, &auth.Session{IdentityID: s.identity.ID, SessionState: session.SessionState, ExpiresAt: &extended}, 2, auth.SessionLimitPolicyReject)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := s.repo.LoadBySessionState(s.ctx, session.SessionState)
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), loaded.ExpiresAt)
+	assert.Equal(s.T(), extended.Unix(), loaded.ExpiresAt.Unix())
+}
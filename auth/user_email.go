@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/goadesign/goa"
+)
+
+// UserEmailManager represents a manager of the emails of the users in the identity provider
+type UserEmailManager interface {
+	UpdateEmail(ctx context.Context, request *goa.RequestData, userID string, email string) error
+}
+
+// KeycloakUserEmailManager implements UserEmailManager interface
+type KeycloakUserEmailManager struct {
+	configuration KeycloakConfiguration
+}
+
+// NewKeycloakUserEmailManager constructs KeycloakUserEmailManager
+func NewKeycloakUserEmailManager(config KeycloakConfiguration) *KeycloakUserEmailManager {
+	return &KeycloakUserEmailManager{config}
+}
+
+// UpdateEmail replaces the email of the given user in Keycloak by the given verified email
+func (m *KeycloakUserEmailManager) UpdateEmail(ctx context.Context, request *goa.RequestData, userID string, email string) error {
+	pat, err := getPat(request, m.configuration)
+	if err != nil {
+		return err
+	}
+	adminEndpoint, err := m.configuration.GetKeycloakEndpointAdmin(request)
+	if err != nil {
+		return err
+	}
+	return UpdateKeycloakUserEmail(ctx, adminEndpoint, userID, email, pat)
+}
+
+type keycloakUserEmailPayload struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+}
+
+// UpdateKeycloakUserEmail replaces the email of the given Keycloak user by the given email, marked as verified
+func UpdateKeycloakUserEmail(ctx context.Context, adminEndpoint string, userID, email, protectionAPIToken string) error {
+	b, err := json.Marshal(keycloakUserEmailPayload{Email: email, EmailVerified: true})
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	req, err := http.NewRequest("PUT", adminEndpoint+"/users/"+userID, bytes.NewReader(b))
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err.Error(),
+		}, "Unable to create http request")
+		return errors.NewInternalError("unable to create http request " + err.Error())
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+protectionAPIToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"user_id": userID,
+			"err":     err.Error(),
+		}, "Unable to update the email of the Keycloak user")
+		return errors.NewInternalError("Unable to update the email of the Keycloak user " + err.Error())
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		log.Debug(ctx, map[string]interface{}{
+			"user_id": userID,
+		}, "Keycloak user email updated")
+		return nil
+	case http.StatusNotFound:
+		return errors.NewNotFoundError("keycloak user", userID)
+	case http.StatusConflict:
+		return errors.NewBadParameterError("email", email).Expected("an email which is not used by another Keycloak user")
+	default:
+		body := rest.ReadBody(res.Body)
+		log.Error(ctx, map[string]interface{}{
+			"user_id":         userID,
+			"response_status": res.Status,
+			"response_body":   body,
+		}, "Unable to update the email of the Keycloak user")
+		return errors.NewInternalError("Unable to update the email of the Keycloak user. Response status: " + res.Status + ". Response body: " + body)
+	}
+}
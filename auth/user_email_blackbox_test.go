@@ -0,0 +1,53 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateKeycloakUserEmailOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	var method, path, authorization string
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, authorization = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	// when
+	err := auth.UpdateKeycloakUserEmail(context.Background(), server.URL, "some-user", "john@example.com", "some-pat")
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "/users/some-user", path)
+	assert.Equal(t, "Bearer some-pat", authorization)
+	assert.Equal(t, map[string]interface{}{"email": "john@example.com", "emailVerified": true}, payload)
+}
+
+func TestUpdateKeycloakUserEmailConflict(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+	// when
+	err := auth.UpdateKeycloakUserEmail(context.Background(), server.URL, "some-user", "john@example.com", "some-pat")
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, err)
+}
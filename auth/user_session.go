@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/goadesign/goa"
+)
+
+// UserSessionManager represents a manager of the sessions of the users
+type UserSessionManager interface {
+	RevokeSessions(ctx context.Context, request *goa.RequestData, userID string) error
+}
+
+// KeycloakUserSessionManager implements UserSessionManager interface
+type KeycloakUserSessionManager struct {
+	configuration KeycloakConfiguration
+}
+
+// NewKeycloakUserSessionManager constructs KeycloakUserSessionManager
+func NewKeycloakUserSessionManager(config KeycloakConfiguration) *KeycloakUserSessionManager {
+	return &KeycloakUserSessionManager{config}
+}
+
+// RevokeSessions revokes all the Keycloak sessions of the given user
+func (m *KeycloakUserSessionManager) RevokeSessions(ctx context.Context, request *goa.RequestData, userID string) error {
+	pat, err := getPat(request, m.configuration)
+	if err != nil {
+		return err
+	}
+	adminEndpoint, err := m.configuration.GetKeycloakEndpointAdmin(request)
+	if err != nil {
+		return err
+	}
+	return LogoutKeycloakUser(ctx, adminEndpoint, userID, pat)
+}
+
+// LogoutKeycloakUser removes all the Keycloak sessions of the given user.
+// A user unknown to Keycloak has no session to remove, hence it is not an error.
+func LogoutKeycloakUser(ctx context.Context, adminEndpoint string, userID, protectionAPIToken string) error {
+	req, err := http.NewRequest("POST", adminEndpoint+"/users/"+userID+"/logout", nil)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err.Error(),
+		}, "Unable to create http request")
+		return errors.NewInternalError("unable to create http request " + err.Error())
+	}
+	req.Header.Add("Authorization", "Bearer "+protectionAPIToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"user_id": userID,
+			"err":     err.Error(),
+		}, "Unable to logout the Keycloak user")
+		return errors.NewInternalError("Unable to logout the Keycloak user " + err.Error())
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		log.Debug(ctx, map[string]interface{}{
+			"user_id": userID,
+		}, "Keycloak user sessions removed")
+		return nil
+	case http.StatusNotFound:
+		log.Warn(ctx, map[string]interface{}{
+			"user_id": userID,
+		}, "User not found in Keycloak, no session to remove")
+		return nil
+	default:
+		body := rest.ReadBody(res.Body)
+		log.Error(ctx, map[string]interface{}{
+			"user_id":         userID,
+			"response_status": res.Status,
+			"response_body":   body,
+		}, "Unable to logout the Keycloak user")
+		return errors.NewInternalError("Unable to logout the Keycloak user. Response status: " + res.Status + ". Response body: " + body)
+	}
+}
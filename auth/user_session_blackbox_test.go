@@ -0,0 +1,61 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeycloakAdminServer(status int, requests *[]*http.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		w.WriteHeader(status)
+	}))
+}
+
+func TestLogoutKeycloakUserOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	var requests []*http.Request
+	server := newKeycloakAdminServer(http.StatusNoContent, &requests)
+	defer server.Close()
+	// when
+	err := auth.LogoutKeycloakUser(context.Background(), server.URL, "some-user", "some-pat")
+	// then
+	require.Nil(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "POST", requests[0].Method)
+	assert.Equal(t, "/users/some-user/logout", requests[0].URL.Path)
+	assert.Equal(t, "Bearer some-pat", requests[0].Header.Get("Authorization"))
+}
+
+func TestLogoutUnknownKeycloakUserOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	var requests []*http.Request
+	server := newKeycloakAdminServer(http.StatusNotFound, &requests)
+	defer server.Close()
+	// when
+	err := auth.LogoutKeycloakUser(context.Background(), server.URL, "some-user", "some-pat")
+	// then
+	assert.Nil(t, err)
+}
+
+func TestLogoutKeycloakUserFailure(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	var requests []*http.Request
+	server := newKeycloakAdminServer(http.StatusForbidden, &requests)
+	defer server.Close()
+	// when
+	err := auth.LogoutKeycloakUser(context.Background(), server.URL, "some-user", "some-pat")
+	// then
+	assert.NotNil(t, err)
+}
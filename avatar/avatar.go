@@ -0,0 +1,122 @@
+package avatar
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	// register the decoders of the supported upload formats
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/almighty/almighty-core/errors"
+
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// Sizes are the widths and heights in pixels of the square variants generated for each uploaded avatar
+var Sizes = []int{32, 64, 128, 256}
+
+const (
+	// DefaultSize is the size of the variant served when no size is requested
+	DefaultSize = 256
+	// ContentType is the content type of all the stored variants
+	ContentType = "image/png"
+
+	// maxDimension is the maximum width and height of an uploaded image, so that
+	// small but highly compressed images can't exhaust the memory once decoded
+	maxDimension = 4096
+)
+
+// VariantName returns the name under which the variant of the given size of the avatar
+// of the given identity is stored
+func VariantName(identityID uuid.UUID, size int) string {
+	return fmt.Sprintf("%s/%d.png", identityID, size)
+}
+
+// Variants decodes the given PNG, JPEG or GIF image and returns its PNG encoded variants, indexed by size.
+// Non-square images are cropped around their center.
+// returns BadParameterError if the content is not a supported image, or InternalError
+func Variants(content []byte) (map[int][]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.NewBadParameterError("file", "").Expected("a PNG, JPEG or GIF image")
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, errors.NewBadParameterError("file", fmt.Sprintf("%dx%d", config.Width, config.Height)).Expected(fmt.Sprintf("an image of at most %dx%d pixels", maxDimension, maxDimension))
+	}
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.NewBadParameterError("file", "").Expected("a PNG, JPEG or GIF image")
+	}
+	variants := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, Resize(img, size)); err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		variants[size] = buf.Bytes()
+	}
+	return variants, nil
+}
+
+// Save generates the variants of the given image and stores them as the avatar of the given identity
+// returns BadParameterError if the content is not a supported image, or InternalError
+func Save(ctx context.Context, storage Storage, identityID uuid.UUID, content []byte) error {
+	variants, err := Variants(content)
+	if err != nil {
+		return err
+	}
+	for size, variant := range variants {
+		if err := storage.Save(ctx, VariantName(identityID, size), variant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resize returns a square image of the given size, obtained by cropping the given image around its center
+// and by averaging the pixels of the cropped image covered by each pixel of the result.
+func Resize(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	left := bounds.Min.X + (bounds.Dx()-side)/2
+	top := bounds.Min.Y + (bounds.Dy()-side)/2
+	result := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if side == 0 {
+		return result
+	}
+	for y := 0; y < size; y++ {
+		y0, y1 := coveredRange(top, side, size, y)
+		for x := 0; x < size; x++ {
+			x0, x1 := coveredRange(left, side, size, x)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			result.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return result
+}
+
+// coveredRange returns the range of the source pixels covered by the given pixel of the result,
+// which always contains at least one source pixel
+func coveredRange(start, side, size, i int) (int, int) {
+	from := start + i*side/size
+	to := start + (i+1)*side/size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
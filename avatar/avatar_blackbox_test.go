@@ -0,0 +1,108 @@
+package avatar_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/almighty/almighty-core/avatar"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImage returns an image of the given size, whose left half is red and right half is blue
+func newImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestResizeDownscale(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	result := avatar.Resize(newImage(100, 100), 10)
+	// then
+	assert.Equal(t, image.Rect(0, 0, 10, 10), result.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, result.NRGBAAt(0, 5))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, result.NRGBAAt(9, 5))
+}
+
+func TestResizeUpscale(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	result := avatar.Resize(newImage(4, 4), 32)
+	// then
+	assert.Equal(t, image.Rect(0, 0, 32, 32), result.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, result.NRGBAAt(15, 0))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, result.NRGBAAt(16, 31))
+}
+
+func TestResizeCropsAroundCenter(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given a wide image whose center is covered by a green square
+	img := newImage(300, 100)
+	for y := 0; y < 100; y++ {
+		for x := 100; x < 200; x++ {
+			img.Set(x, y, color.NRGBA{G: 255, A: 255})
+		}
+	}
+	// when
+	result := avatar.Resize(img, 10)
+	// then only the green square remains
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			assert.Equal(t, color.NRGBA{G: 255, A: 255}, result.NRGBAAt(x, y))
+		}
+	}
+}
+
+func TestVariantsOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	variants, err := avatar.Variants(encodePNG(t, newImage(500, 400)))
+	// then
+	require.Nil(t, err)
+	require.Len(t, variants, len(avatar.Sizes))
+	for _, size := range avatar.Sizes {
+		img, err := png.Decode(bytes.NewReader(variants[size]))
+		require.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+}
+
+func TestVariantsNotAnImage(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	_, err := avatar.Variants([]byte("not an image"))
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, err)
+}
+
+func TestVariantsImageTooLarge(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	_, err := avatar.Variants(encodePNG(t, image.NewGray(image.Rect(0, 0, 5000, 10))))
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, err)
+}
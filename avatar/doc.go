@@ -0,0 +1,3 @@
+// Package avatar contains the functions used to store the avatar images uploaded
+// by the users and to generate their resized variants.
+package avatar
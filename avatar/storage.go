@@ -0,0 +1,89 @@
+package avatar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/almighty/almighty-core/errors"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// StorageBackendFileSystem is the name of the backend storing the avatars in a directory of the local filesystem
+	StorageBackendFileSystem = "filesystem"
+)
+
+// Storage encapsulates the storage & retrieval of the avatar images
+type Storage interface {
+	// Save stores the given content under the given name, replacing any previous content
+	Save(ctx context.Context, name string, content []byte) error
+	// Load returns the content stored under the given name
+	Load(ctx context.Context, name string) ([]byte, error)
+}
+
+// StorageConfiguration the configuration of the avatar storage
+type StorageConfiguration interface {
+	GetAvatarStorageBackend() string
+	GetAvatarStorageDir() string
+}
+
+// NewStorage creates the storage of the configured backend
+func NewStorage(config StorageConfiguration) (Storage, error) {
+	switch backend := config.GetAvatarStorageBackend(); backend {
+	case StorageBackendFileSystem:
+		return NewFileSystemStorage(config.GetAvatarStorageDir()), nil
+	default:
+		return nil, errors.NewBadParameterError("avatar.storage.backend", backend).Expected(StorageBackendFileSystem)
+	}
+}
+
+// FileSystemStorage implements Storage by storing each content in a file of the given directory
+type FileSystemStorage struct {
+	dir string
+}
+
+// NewFileSystemStorage creates a storage of the files in the given directory
+func NewFileSystemStorage(dir string) *FileSystemStorage {
+	return &FileSystemStorage{dir: dir}
+}
+
+// Save writes the given content in a temporary file first, so that a concurrent
+// load never sees a partially written content
+// returns InternalError
+func (s *FileSystemStorage) Save(ctx context.Context, name string, content []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// Load reads the file with the given name
+// returns NotFoundError or InternalError
+func (s *FileSystemStorage) Load(ctx context.Context, name string) ([]byte, error) {
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil, errors.NewNotFoundError("avatar", name)
+	}
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return content, nil
+}
@@ -0,0 +1,65 @@
+package avatar_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/almighty/almighty-core/avatar"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func newFileSystemStorage(t *testing.T) (*avatar.FileSystemStorage, func()) {
+	dir, err := ioutil.TempDir("", "avatars")
+	require.Nil(t, err)
+	return avatar.NewFileSystemStorage(dir), func() { os.RemoveAll(dir) }
+}
+
+func TestFileSystemStorageSaveAndLoad(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	storage, clean := newFileSystemStorage(t)
+	defer clean()
+	name := avatar.VariantName(uuid.NewV4(), 32)
+	require.Nil(t, storage.Save(context.Background(), name, []byte("first")))
+	// when
+	require.Nil(t, storage.Save(context.Background(), name, []byte("second")))
+	content, err := storage.Load(context.Background(), name)
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, []byte("second"), content)
+}
+
+func TestFileSystemStorageLoadUnknown(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	storage, clean := newFileSystemStorage(t)
+	defer clean()
+	// when
+	_, err := storage.Load(context.Background(), avatar.VariantName(uuid.NewV4(), 32))
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.NotFoundError{}, err)
+}
+
+func TestSaveStoresAllVariants(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	storage, clean := newFileSystemStorage(t)
+	defer clean()
+	identityID := uuid.NewV4()
+	// when
+	err := avatar.Save(context.Background(), storage, identityID, encodePNG(t, newImage(64, 64)))
+	// then
+	require.Nil(t, err)
+	for _, size := range avatar.Sizes {
+		_, err := storage.Load(context.Background(), avatar.VariantName(identityID, size))
+		assert.Nil(t, err)
+	}
+}
@@ -22,6 +22,7 @@ type Repository interface {
 	List(ctx context.Context, parent string, start *int, limit *int) ([]Comment, uint64, error)
 	Load(ctx context.Context, id uuid.UUID) (*Comment, error)
 	Count(ctx context.Context, parent string) (int, error)
+	ReplaceCreator(ctx context.Context, oldCreatorID uuid.UUID, newCreatorID uuid.UUID) error
 }
 
 // NewRepository creates a new storage type.
@@ -211,6 +212,22 @@ func (m *GormCommentRepository) Count(ctx context.Context, parent string) (int,
 	return count, nil
 }
 
+// ReplaceCreator replaces the given creator by the new one in all the comments, including the deleted ones
+// returns InternalError
+func (m *GormCommentRepository) ReplaceCreator(ctx context.Context, oldCreatorID uuid.UUID, newCreatorID uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "comment", "replace_creator"}, time.Now())
+	tx := m.db.Unscoped().Model(&Comment{}).Where("created_by = ?", oldCreatorID).UpdateColumn("created_by", newCreatorID)
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"old_creator_id": oldCreatorID,
+		"new_creator_id": newCreatorID,
+		"comments":       tx.RowsAffected,
+	}, "comments creator replaced")
+	return nil
+}
+
 // Load a single comment regardless of parent
 func (m *GormCommentRepository) Load(ctx context.Context, id uuid.UUID) (*Comment, error) {
 	defer goa.MeasureSince([]string{"goa", "db", "comment", "get"}, time.Now())
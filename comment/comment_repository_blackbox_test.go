@@ -222,3 +222,24 @@ func (s *TestCommentRepository) TestLoadComment() {
 	assert.Equal(s.T(), comment.ID, loadedComment.ID)
 	assert.Equal(s.T(), comment.Body, loadedComment.Body)
 }
+
+func (s *TestCommentRepository) TestReplaceCreator() {
+	// given
+	otherIdentity, err := testsupport.CreateTestIdentity(s.DB, "jdoe-"+uuid.NewV4().String(), "test")
+	require.Nil(s.T(), err)
+	comment1 := newComment("A", "Test A", rendering.SystemMarkupMarkdown)
+	comment1.CreatedBy = s.testIdentity.ID
+	comment2 := newComment("A", "Test B", rendering.SystemMarkupMarkdown)
+	comment2.CreatedBy = otherIdentity.ID
+	s.createComment(comment1, s.testIdentity.ID)
+	s.createComment(comment2, otherIdentity.ID)
+	// when
+	err = s.repo.ReplaceCreator(s.ctx, otherIdentity.ID, s.testIdentity.ID)
+	// then
+	require.Nil(s.T(), err)
+	for _, c := range []*comment.Comment{comment1, comment2} {
+		loadedComment, err := s.repo.Load(s.ctx, c.ID)
+		require.Nil(s.T(), err)
+		assert.Equal(s.T(), s.testIdentity.ID, loadedComment.CreatedBy)
+	}
+}
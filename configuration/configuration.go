@@ -3,6 +3,7 @@ package configuration
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -45,6 +46,7 @@ const (
 	varPostgresConnectionMaxOpen        = "postgres.connection.maxopen"
 	varPopulateCommonTypes              = "populate.commontypes"
 	varHTTPAddress                      = "http.address"
+	varHTTPRequestTimeout               = "http.request.timeout"
 	varDeveloperModeEnabled             = "developer.mode.enabled"
 	varGithubAuthToken                  = "github.auth.token"
 	varKeycloakSecret                   = "keycloak.secret"
@@ -86,6 +88,44 @@ const (
 	varValidRedirectURLs                = "redirect.valid"
 	varLogLevel                         = "log.level"
 	varTenantServiceURL                 = "tenant.serviceurl"
+	varRemoteItemImportConcurrency      = "remoteworkitem.import.concurrency"
+	varCommentMaxLength                 = "comment.maxlength"
+	varWorkItemRestoreWindow            = "workitem.restore.window"
+	varWorkItemBulkUpdateBatchSize      = "workitem.bulkupdate.batchsize"
+	varWorkItemAssigneeCollaborator     = "workitem.assignee.collaboratorrequired"
+	varWorkItemAssigneeRemoved          = "workitem.assignee.collaboratorremoved"
+	varWorkItemStrictPayload            = "workitem.payload.strict"
+	varWorkItemClosedIteration          = "workitem.iteration.closed"
+	varWorkItemDuplicateAutoClose       = "workitem.duplicate.autoclose"
+	varRenderImageAllowedHosts          = "render.images.allowedhosts"
+	varRenderImageAllowedMIMETypes      = "render.images.allowedmimetypes"
+	varTenantInitConcurrency            = "tenant.init.concurrency"
+	varTenantServiceTimeout             = "tenant.timeout"
+	varTenantServiceMaxAttempts         = "tenant.retry.maxattempts"
+	varTenantServiceRetryBackoff        = "tenant.retry.backoff"
+	varSessionMaxActive                 = "session.max.active"
+	varSessionLimitPolicy               = "session.limit.policy"
+	varUserCompanyRequired              = "user.company.required"
+	varUsernameReuseGracePeriod         = "user.username.reuse.graceperiod"
+	varUserContextInformationKeys       = "user.contextinformation.allowedkeys"
+	varUserInactivityThreshold          = "user.inactivity.threshold"
+	varUserInactivityCheckInterval      = "user.inactivity.checkinterval"
+	varUserInactivityDryRun             = "user.inactivity.dryrun"
+	varUserEmailVerificationExpiry      = "user.email.verification.expiry"
+	varUserImpersonationTokenExpiry     = "user.impersonation.token.expiry"
+	varMailerSMTPHost                   = "mailer.smtp.host"
+	varMailerSMTPPort                   = "mailer.smtp.port"
+	varMailerSMTPUser                   = "mailer.smtp.user"
+	varMailerSMTPPassword               = "mailer.smtp.password"
+	varMailerFrom                       = "mailer.from"
+	varAvatarStorageBackend             = "avatar.storage.backend"
+	varAvatarStorageDir                 = "avatar.storage.dir"
+	varAvatarMaxSize                    = "avatar.maxsize"
+	varAreaMaxDepth                     = "area.maxdepth"
+	varIterationMaxDepth                = "iteration.maxdepth"
+	varSearchCoalesceQueries            = "search.coalesce.queries"
+	varCollaboratorsCacheTTL            = "collaborators.cache.ttl"
+	varCollaboratorsReconcileInterval   = "collaborators.reconciliation.interval"
 )
 
 // ConfigurationData encapsulates the Viper configuration object which stores the configuration data in-memory.
@@ -158,7 +198,9 @@ func (c *ConfigurationData) setConfigDefaults() {
 	// HTTP
 	//-----
 	c.v.SetDefault(varHTTPAddress, "0.0.0.0:8080")
+	c.v.SetDefault(varHTTPRequestTimeout, defaultHTTPRequestTimeout)
 	c.v.SetDefault(varHeaderMaxLength, defaultHeaderMaxLength)
+	c.v.SetDefault(varCommentMaxLength, defaultCommentMaxLength)
 
 	//-----
 	// Misc
@@ -200,6 +242,60 @@ func (c *ConfigurationData) setConfigDefaults() {
 	c.v.SetDefault(varKeycloakTesUser2Secret, defaultKeycloakTesUser2Secret)
 	c.v.SetDefault(varOpenshiftTenantMasterURL, defaultOpenshiftTenantMasterURL)
 	c.v.SetDefault(varCheStarterURL, defaultCheStarterURL)
+
+	//---------------
+	// Remote imports
+	//---------------
+	c.v.SetDefault(varRemoteItemImportConcurrency, defaultRemoteItemImportConcurrency)
+
+	//-----------
+	// Work items
+	//-----------
+	c.v.SetDefault(varWorkItemRestoreWindow, defaultWorkItemRestoreWindow)
+	c.v.SetDefault(varWorkItemBulkUpdateBatchSize, defaultWorkItemBulkUpdateBatchSize)
+	c.v.SetDefault(varWorkItemAssigneeCollaborator, false)
+	c.v.SetDefault(varWorkItemAssigneeRemoved, "keep")
+	c.v.SetDefault(varWorkItemStrictPayload, false)
+	c.v.SetDefault(varWorkItemClosedIteration, "allow")
+	c.v.SetDefault(varWorkItemDuplicateAutoClose, false)
+	c.v.SetDefault(varRenderImageAllowedHosts, []string{})
+	c.v.SetDefault(varRenderImageAllowedMIMETypes, defaultRenderImageAllowedMIMETypes)
+	c.v.SetDefault(varAreaMaxDepth, defaultAreaMaxDepth)
+	c.v.SetDefault(varIterationMaxDepth, defaultIterationMaxDepth)
+	c.v.SetDefault(varSearchCoalesceQueries, false)
+
+	//-------
+	// Tenant
+	//-------
+	c.v.SetDefault(varTenantInitConcurrency, defaultTenantInitConcurrency)
+	c.v.SetDefault(varTenantServiceTimeout, defaultTenantServiceTimeout)
+	c.v.SetDefault(varTenantServiceMaxAttempts, defaultTenantServiceMaxAttempts)
+	c.v.SetDefault(varTenantServiceRetryBackoff, defaultTenantServiceRetryBackoff)
+
+	//------
+	// Users
+	//------
+	c.v.SetDefault(varSessionMaxActive, defaultSessionMaxActive)
+	c.v.SetDefault(varSessionLimitPolicy, defaultSessionLimitPolicy)
+	c.v.SetDefault(varUserCompanyRequired, false)
+	c.v.SetDefault(varUsernameReuseGracePeriod, defaultUsernameReuseGracePeriod)
+	c.v.SetDefault(varUserContextInformationKeys, []string{})
+	c.v.SetDefault(varUserInactivityThreshold, 0)
+	c.v.SetDefault(varUserInactivityCheckInterval, defaultUserInactivityCheckInterval)
+	c.v.SetDefault(varUserInactivityDryRun, false)
+	c.v.SetDefault(varUserEmailVerificationExpiry, defaultUserEmailVerificationExpiry)
+	c.v.SetDefault(varUserImpersonationTokenExpiry, defaultUserImpersonationTokenExpiry)
+	c.v.SetDefault(varMailerSMTPPort, defaultMailerSMTPPort)
+	c.v.SetDefault(varMailerFrom, defaultMailerFrom)
+	c.v.SetDefault(varAvatarStorageBackend, "filesystem")
+	c.v.SetDefault(varAvatarStorageDir, defaultAvatarStorageDir)
+	c.v.SetDefault(varAvatarMaxSize, defaultAvatarMaxSize)
+
+	//--------------
+	// Collaborators
+	//--------------
+	c.v.SetDefault(varCollaboratorsCacheTTL, defaultCollaboratorsCacheTTL)
+	c.v.SetDefault(varCollaboratorsReconcileInterval, 0)
 }
 
 // GetPostgresHost returns the postgres host as set via default, config file, or environment variable
@@ -280,12 +376,24 @@ func (c *ConfigurationData) GetHTTPAddress() string {
 	return c.v.GetString(varHTTPAddress)
 }
 
+// GetHTTPRequestTimeout returns the max duration allowed to process an incoming HTTP request
+// (as set via default, config file, or environment variable). Zero means no timeout.
+func (c *ConfigurationData) GetHTTPRequestTimeout() time.Duration {
+	return c.v.GetDuration(varHTTPRequestTimeout)
+}
+
 // GetHeaderMaxLength returns the max length of HTTP headers allowed in the system
 // For example it can be used to limit the size of bearer tokens returned by the api service
 func (c *ConfigurationData) GetHeaderMaxLength() int64 {
 	return c.v.GetInt64(varHeaderMaxLength)
 }
 
+// GetCommentMaxLength returns the max number of characters allowed in the body of a comment.
+// A value lower than 1 means that the length of comments is not limited.
+func (c *ConfigurationData) GetCommentMaxLength() int {
+	return c.v.GetInt(varCommentMaxLength)
+}
+
 // IsPostgresDeveloperModeEnabled returns if development related features (as set via default, config file, or environment variable),
 // e.g. token generation endpoint are enabled
 func (c *ConfigurationData) IsPostgresDeveloperModeEnabled() bool {
@@ -608,9 +716,280 @@ func (c *ConfigurationData) GetTenantServiceURL() string {
 	return c.v.GetString(varTenantServiceURL)
 }
 
+// GetTenantInitConcurrency returns the max number of tenants initialized at the same time across the platform
+// (as set via default, config file, or environment variable). Additional initializations are queued.
+// Values lower than 1 are treated as 1.
+func (c *ConfigurationData) GetTenantInitConcurrency() int {
+	return c.v.GetInt(varTenantInitConcurrency)
+}
+
+// GetTenantServiceTimeout returns the max duration of a request to the Tenant service
+// (as set via default, config file, or environment variable). Zero means no timeout.
+func (c *ConfigurationData) GetTenantServiceTimeout() time.Duration {
+	return c.v.GetDuration(varTenantServiceTimeout)
+}
+
+// GetTenantServiceMaxAttempts returns the max number of attempts to initialize a tenant when the Tenant service
+// is unreachable or fails (as set via default, config file, or environment variable). Values lower than 1 are treated as 1.
+func (c *ConfigurationData) GetTenantServiceMaxAttempts() int {
+	return c.v.GetInt(varTenantServiceMaxAttempts)
+}
+
+// GetTenantServiceRetryBackoff returns the duration to wait before the second attempt to initialize a tenant
+// (as set via default, config file, or environment variable). The duration grows linearly with the next attempts.
+func (c *ConfigurationData) GetTenantServiceRetryBackoff() time.Duration {
+	return c.v.GetDuration(varTenantServiceRetryBackoff)
+}
+
+// GetRemoteItemImportConcurrency returns the max number of remote tracker items that are
+// imported concurrently (as set via default, config file, or environment variable).
+// Values lower than 1 are treated as 1.
+func (c *ConfigurationData) GetRemoteItemImportConcurrency() int {
+	concurrency := c.v.GetInt(varRemoteItemImportConcurrency)
+	if concurrency < 1 {
+		return 1
+	}
+	return concurrency
+}
+
+// GetWorkItemRestoreWindow returns the duration (as set via default, config file, or environment variable)
+// during which a deleted work item can still be restored. Once it has elapsed, the work item is considered purged.
+func (c *ConfigurationData) GetWorkItemRestoreWindow() time.Duration {
+	return c.v.GetDuration(varWorkItemRestoreWindow)
+}
+
+// GetWorkItemBulkUpdateBatchSize returns the max number of work items updated within a single transaction
+// by a bulk update (as set via default, config file, or environment variable).
+// Values lower than 1 are treated as 1.
+func (c *ConfigurationData) GetWorkItemBulkUpdateBatchSize() int {
+	return c.v.GetInt(varWorkItemBulkUpdateBatchSize)
+}
+
+// IsWorkItemAssigneeCollaboratorRequired returns true if work items can only be assigned to the collaborators
+// of their space (as set via default, config file, or environment variable).
+func (c *ConfigurationData) IsWorkItemAssigneeCollaboratorRequired() bool {
+	return c.v.GetBool(varWorkItemAssigneeCollaborator)
+}
+
+// IsWorkItemPayloadStrict returns true if work item payloads containing attributes unknown to the work item type
+// must be rejected instead of being ignored (as set via default, config file, or environment variable).
+func (c *ConfigurationData) IsWorkItemPayloadStrict() bool {
+	return c.v.GetBool(varWorkItemStrictPayload)
+}
+
+// GetWorkItemRemovedCollaboratorAssignments returns what happens to the work items assigned to a collaborator
+// who is removed from their space: "keep" the assignments, "unassign" the collaborator or reassign the work items
+// to the space "owner" (as set via default, config file, or environment variable).
+func (c *ConfigurationData) GetWorkItemRemovedCollaboratorAssignments() string {
+	return c.v.GetString(varWorkItemAssigneeRemoved)
+}
+
+// GetWorkItemClosedIterationAssignment returns how the assignment of work items to an iteration which is closed
+// or whose end date has passed is handled: "allow", "warn" or "reject" (as set via default, config file, or
+// environment variable).
+func (c *ConfigurationData) GetWorkItemClosedIterationAssignment() string {
+	return c.v.GetString(varWorkItemClosedIteration)
+}
+
+// IsWorkItemDuplicateAutoClosed returns true if the work items marked as the duplicate of another work item
+// are closed along with the creation of the "duplicate of" link (as set via default, config file, or environment variable).
+func (c *ConfigurationData) IsWorkItemDuplicateAutoClosed() bool {
+	return c.v.GetBool(varWorkItemDuplicateAutoClose)
+}
+
+// GetRenderImageAllowedHosts returns the hosts from which images can be embedded in rendered markup content
+// (as set via default, config file, or environment variable). An empty list means that images from any host are allowed.
+func (c *ConfigurationData) GetRenderImageAllowedHosts() []string {
+	return c.v.GetStringSlice(varRenderImageAllowedHosts)
+}
+
+// GetRenderImageAllowedMIMETypes returns the MIME types of the images which can be embedded in rendered markup content
+// (as set via default, config file, or environment variable). An empty list means that images of any type are allowed.
+func (c *ConfigurationData) GetRenderImageAllowedMIMETypes() []string {
+	return c.v.GetStringSlice(varRenderImageAllowedMIMETypes)
+}
+
+// GetSessionMaxActive returns the max number of simultaneous active sessions per identity
+// (as set via default, config file, or environment variable). Values lower than 1 mean that no limit applies.
+func (c *ConfigurationData) GetSessionMaxActive() int {
+	return c.v.GetInt(varSessionMaxActive)
+}
+
+// GetSessionLimitPolicy returns what happens when a user logs in while having already reached the max number
+// of active sessions (as set via default, config file, or environment variable):
+// either "revoke-oldest" to revoke the oldest sessions or "reject" to reject the new session.
+func (c *ConfigurationData) GetSessionLimitPolicy() string {
+	return c.v.GetString(varSessionLimitPolicy)
+}
+
+// IsUserCompanyRequired returns true if users must provide a non-empty company to complete
+// their registration and when updating their profile (as set via default, config file, or environment variable)
+func (c *ConfigurationData) IsUserCompanyRequired() bool {
+	return c.v.GetBool(varUserCompanyRequired)
+}
+
+// GetUsernameReuseGracePeriod returns the duration (as set via default, config file, or environment variable)
+// during which the username of a deactivated identity remains reserved. Once it has elapsed, another account can take it.
+func (c *ConfigurationData) GetUsernameReuseGracePeriod() time.Duration {
+	return c.v.GetDuration(varUsernameReuseGracePeriod)
+}
+
+// GetUserContextInformationAllowedKeys returns the keys which can be set in the context information of a user
+// (as set via default, config file, or environment variable). An empty list means that any key is allowed.
+func (c *ConfigurationData) GetUserContextInformationAllowedKeys() []string {
+	return c.v.GetStringSlice(varUserContextInformationKeys)
+}
+
+// GetUserInactivityThreshold returns the duration (as set via default, config file, or environment variable)
+// after which an identity which did not log in is deactivated. Zero disables the deactivation of inactive identities.
+func (c *ConfigurationData) GetUserInactivityThreshold() time.Duration {
+	return c.v.GetDuration(varUserInactivityThreshold)
+}
+
+// GetUserInactivityCheckInterval returns the interval between two checks for inactive identities
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetUserInactivityCheckInterval() time.Duration {
+	return c.v.GetDuration(varUserInactivityCheckInterval)
+}
+
+// IsUserInactivityDryRunEnabled returns true if inactive identities are only logged instead of being deactivated
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) IsUserInactivityDryRunEnabled() bool {
+	return c.v.GetBool(varUserInactivityDryRun)
+}
+
+// GetEmailVerificationExpiry returns how long the token sent to verify the new email of a user remains valid
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetEmailVerificationExpiry() time.Duration {
+	return c.v.GetDuration(varUserEmailVerificationExpiry)
+}
+
+// GetImpersonationTokenExpiry returns how long the tokens issued to the administrators to act as another identity
+// remain valid (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetImpersonationTokenExpiry() time.Duration {
+	return c.v.GetDuration(varUserImpersonationTokenExpiry)
+}
+
+// GetMailerSMTPHost returns the host of the SMTP server sending the emails to the users. No email is sent
+// if the host is empty (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetMailerSMTPHost() string {
+	return c.v.GetString(varMailerSMTPHost)
+}
+
+// GetMailerSMTPPort returns the port of the SMTP server (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetMailerSMTPPort() int {
+	return c.v.GetInt(varMailerSMTPPort)
+}
+
+// GetMailerSMTPUser returns the user authenticating to the SMTP server, if any
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetMailerSMTPUser() string {
+	return c.v.GetString(varMailerSMTPUser)
+}
+
+// GetMailerSMTPPassword returns the password of the user authenticating to the SMTP server
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetMailerSMTPPassword() string {
+	return c.v.GetString(varMailerSMTPPassword)
+}
+
+// GetMailerFrom returns the sender address of the emails sent to the users
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetMailerFrom() string {
+	return c.v.GetString(varMailerFrom)
+}
+
+// GetAvatarStorageBackend returns the name of the backend storing the avatars uploaded by the users
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetAvatarStorageBackend() string {
+	return c.v.GetString(varAvatarStorageBackend)
+}
+
+// GetAvatarStorageDir returns the directory in which the 'filesystem' backend stores the avatars
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetAvatarStorageDir() string {
+	return c.v.GetString(varAvatarStorageDir)
+}
+
+// GetAvatarMaxSize returns the maximum size in bytes of an uploaded avatar image
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetAvatarMaxSize() int64 {
+	return c.v.GetInt64(varAvatarMaxSize)
+}
+
+// GetAreaMaxDepth returns the maximum depth of an area below the root area of its space
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetAreaMaxDepth() int {
+	return c.v.GetInt(varAreaMaxDepth)
+}
+
+// GetIterationMaxDepth returns the maximum depth of an iteration below the root iteration of its space
+// (as set via default, config file, or environment variable)
+func (c *ConfigurationData) GetIterationMaxDepth() int {
+	return c.v.GetInt(varIterationMaxDepth)
+}
+
+// IsSearchQueryCoalescingEnabled returns true if identical concurrent searches are executed only once,
+// the concurrent callers sharing the same result (as set via default, config file, or environment variable)
+func (c *ConfigurationData) IsSearchQueryCoalescingEnabled() bool {
+	return c.v.GetBool(varSearchCoalesceQueries)
+}
+
+// GetCollaboratorsCacheTTL returns how long the collaborators of a space cached in the database are listed
+// without loading the space policy from Keycloak again (as set via default, config file, or environment variable).
+// Zero disables the cache.
+func (c *ConfigurationData) GetCollaboratorsCacheTTL() time.Duration {
+	return c.v.GetDuration(varCollaboratorsCacheTTL)
+}
+
+// GetCollaboratorsReconciliationInterval returns the interval between two synchronizations of the cached
+// collaborators of the spaces with Keycloak (as set via default, config file, or environment variable).
+// Zero disables the reconciliation.
+func (c *ConfigurationData) GetCollaboratorsReconciliationInterval() time.Duration {
+	return c.v.GetDuration(varCollaboratorsReconcileInterval)
+}
+
 const (
 	defaultHeaderMaxLength = 5000 // bytes
 
+	defaultCommentMaxLength = 10000 // characters
+
+	defaultRemoteItemImportConcurrency = 4
+
+	defaultHTTPRequestTimeout = 60 * time.Second
+
+	defaultWorkItemRestoreWindow = 30 * 24 * time.Hour
+
+	defaultWorkItemBulkUpdateBatchSize = 100
+
+	defaultTenantInitConcurrency = 10
+
+	defaultTenantServiceTimeout      = 30 * time.Second
+	defaultTenantServiceMaxAttempts  = 3
+	defaultTenantServiceRetryBackoff = time.Second
+
+	defaultSessionMaxActive = 0 // unlimited
+
+	defaultSessionLimitPolicy = "revoke-oldest"
+
+	defaultUsernameReuseGracePeriod = 90 * 24 * time.Hour
+
+	defaultUserInactivityCheckInterval = 24 * time.Hour
+
+	defaultUserEmailVerificationExpiry = 24 * time.Hour
+
+	defaultUserImpersonationTokenExpiry = 15 * time.Minute
+
+	defaultMailerSMTPPort = 25
+	defaultMailerFrom     = "noreply@openshift.io"
+
+	defaultAvatarMaxSize = 1024 * 1024 // bytes
+
+	defaultAreaMaxDepth      = 10
+	defaultIterationMaxDepth = 10
+
+	defaultCollaboratorsCacheTTL = 10 * time.Minute
+
 	// Auth-related defaults
 
 	// RSAPrivateKey for signing JWT Tokens
@@ -691,3 +1070,9 @@ vwIDAQAB
 
 // ActualToken is actual OAuth access token of github
 var defaultActualToken = strings.Split(camouflagedAccessToken, "-AccessToken-")[0] + strings.Split(camouflagedAccessToken, "-AccessToken-")[1]
+
+// defaultAvatarStorageDir is the directory in which the avatars are stored by default
+var defaultAvatarStorageDir = filepath.Join(os.TempDir(), "almighty-avatars")
+
+// defaultRenderImageAllowedMIMETypes are the MIME types of the images which can be embedded in rendered content by default
+var defaultRenderImageAllowedMIMETypes = []string{"image/png", "image/jpeg", "image/gif"}
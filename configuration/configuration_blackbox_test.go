@@ -285,6 +285,24 @@ func TestGetMaxHeaderSizeSetByEnvVaribaleOK(t *testing.T) {
 	assert.Equal(t, envValue, viperValue)
 }
 
+func TestGetRemoteItemImportConcurrencyClampedOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	envName := "ALMIGHTY_REMOTEWORKITEM_IMPORT_CONCURRENCY"
+	env := os.Getenv(envName)
+	defer func() {
+		os.Setenv(envName, env)
+		resetConfiguration(defaultValuesConfigFilePath)
+	}()
+
+	os.Setenv(envName, "0")
+	resetConfiguration(defaultValuesConfigFilePath)
+	assert.Equal(t, 1, config.GetRemoteItemImportConcurrency())
+
+	os.Setenv(envName, "8")
+	resetConfiguration(defaultValuesConfigFilePath)
+	assert.Equal(t, 8, config.GetRemoteItemImportConcurrency())
+}
+
 func generateEnvKey(yamlKey string) string {
 	return "ALMIGHTY_" + strings.ToUpper(strings.Replace(yamlKey, ".", "_", -1))
 }
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+)
+
+// AdminControllerConfiguration the configuration for the AdminController
+type AdminControllerConfiguration interface {
+	GetTokenPrivateKey() []byte
+	GetImpersonationTokenExpiry() time.Duration
+}
+
+// AdminController implements the admin resource.
+type AdminController struct {
+	*goa.Controller
+	db            application.DB
+	configuration AdminControllerConfiguration
+}
+
+// NewAdminController creates an admin controller.
+func NewAdminController(service *goa.Service, db application.DB, configuration AdminControllerConfiguration) *AdminController {
+	return &AdminController{Controller: service.NewController("AdminController"), db: db, configuration: configuration}
+}
+
+// Impersonate runs the impersonate action: it issues a short-lived token acting as the given identity and records
+// the impersonation. Only the tokens granted the admin scope are allowed to impersonate, and the impersonation tokens
+// themselves are not, since they are granted no scope.
+func (c *AdminController) Impersonate(ctx *app.ImpersonateAdminContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to impersonate other identities", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	reason := strings.TrimSpace(ctx.Payload.Reason)
+	if reason == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("reason", ctx.Payload.Reason).Expected("the reason of the impersonation"))
+	}
+	privateKey, err := token.ParsePrivateKey(c.configuration.GetTokenPrivateKey())
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err,
+		}, "unable to parse the private key of the tokens")
+		return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+	}
+	expiresIn := c.configuration.GetImpersonationTokenExpiry()
+	var tokenString string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err := appl.Identities().Load(ctx, ctx.IdentityID)
+		if err != nil {
+			return errors.NewNotFoundError("identity", ctx.IdentityID.String())
+		}
+		impersonation := &account.Impersonation{
+			ImpersonatorID: *currentIdentityID,
+			IdentityID:     identity.ID,
+			Reason:         reason,
+			ExpiresAt:      time.Now().Add(expiresIn),
+		}
+		if err := appl.Impersonations().Create(ctx, impersonation); err != nil {
+			return err
+		}
+		// the token is only issued once the impersonation is recorded, and is not if the record is rolled back
+		tokenString, err = token.GenerateImpersonationToken(privateKey, *identity, *currentIdentityID, expiresIn)
+		if err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Warn(ctx, map[string]interface{}{
+		"identity_id":     ctx.IdentityID,
+		"impersonator_id": *currentIdentityID,
+		"reason":          reason,
+	}, "impersonation token issued")
+	expiresInSeconds := int(expiresIn.Seconds())
+	tokenType := "bearer"
+	return ctx.OK(&app.AuthToken{Token: &app.TokenData{
+		AccessToken: &tokenString,
+		ExpiresIn:   &expiresInSeconds,
+		TokenType:   &tokenType,
+	}})
+}
@@ -0,0 +1,135 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestAdmin(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestAdminSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestAdminSuite struct {
+	gormtestsupport.DBTestSuite
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func (s *TestAdminSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *TestAdminSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *TestAdminSuite) TearDownTest() {
+	s.clean()
+}
+
+func (s *TestAdminSuite) SecuredControllerWithScopes(identity account.Identity, scopes ...string) (*goa.Service, *AdminController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUserWithScopes("Admin-Service", almtoken.NewManager(pub), identity, scopes...)
+	return svc, NewAdminController(svc, s.db, s.Configuration)
+}
+
+func (s *TestAdminSuite) TestImpersonateOK() {
+	// given
+	admin, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonate"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	_, result := test.ImpersonateAdminOK(s.T(), svc.Context, svc, ctrl, identity.ID, &app.Impersonation{Reason: "ticket #1234"})
+	// then
+	require.NotNil(s.T(), result.Token.AccessToken)
+	assert.Equal(s.T(), int(s.Configuration.GetImpersonationTokenExpiry().Seconds()), *result.Token.ExpiresIn)
+	privateKey, err := almtoken.ParsePrivateKey(s.Configuration.GetTokenPrivateKey())
+	require.Nil(s.T(), err)
+	extracted, err := almtoken.NewManagerWithPrivateKey(privateKey).Extract(*result.Token.AccessToken)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), identity.ID, extracted.ID)
+	// the impersonation is recorded
+	impersonations, err := s.db.Impersonations().List(context.Background(), identity.ID)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), impersonations, 1)
+	assert.Equal(s.T(), admin.ID, impersonations[0].ImpersonatorID)
+	assert.Equal(s.T(), "ticket #1234", impersonations[0].Reason)
+}
+
+func (s *TestAdminSuite) TestImpersonateWithoutAdminScopeForbidden() {
+	// given
+	user, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateWithoutAdminScope"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateWithoutAdminScopeTarget"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredControllerWithScopes(user)
+	// when
+	test.ImpersonateAdminForbidden(s.T(), svc.Context, svc, ctrl, identity.ID, &app.Impersonation{Reason: "ticket #1234"})
+	// then
+	impersonations, err := s.db.Impersonations().List(context.Background(), identity.ID)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), impersonations)
+}
+
+func (s *TestAdminSuite) TestImpersonateWithImpersonationTokenForbidden() {
+	// given
+	admin, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateWithImpersonationTokenAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	impersonated, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateWithImpersonationToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateWithImpersonationTokenTarget"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredControllerWithScopes(impersonated, almtoken.ScopeAdminUsers)
+	goajwt.ContextJWT(svc.Context).Claims.(jwt.MapClaims)[almtoken.ImpersonatorClaim] = admin.ID.String()
+	// when/then
+	test.ImpersonateAdminForbidden(s.T(), svc.Context, svc, ctrl, identity.ID, &app.Impersonation{Reason: "ticket #1234"})
+}
+
+// impersonatedBy makes the token of the given service act as if it was issued to the given administrator
+// to impersonate the identity of the token
+func impersonatedBy(svc *goa.Service, impersonator account.Identity) {
+	goajwt.ContextJWT(svc.Context).Claims.(jwt.MapClaims)[almtoken.ImpersonatorClaim] = impersonator.ID.String()
+}
+
+func (s *TestAdminSuite) TestImpersonateUnknownIdentityNotFound() {
+	// given
+	admin, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateUnknownIdentityAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when/then
+	test.ImpersonateAdminNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4(), &app.Impersonation{Reason: "ticket #1234"})
+}
+
+func (s *TestAdminSuite) TestImpersonateBlankReasonBadRequest() {
+	// given
+	admin, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateBlankReasonAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestImpersonateBlankReason"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when/then
+	test.ImpersonateAdminBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID, &app.Impersonation{Reason: "  "})
+}
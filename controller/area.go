@@ -29,6 +29,7 @@ type AreaController struct {
 // AreaControllerConfiguration the configuration for the AreaController
 type AreaControllerConfiguration interface {
 	GetCacheControlAreas() string
+	GetAreaMaxDepth() int
 }
 
 // NewAreaController creates a area controller.
@@ -85,6 +86,9 @@ func (c *AreaController) CreateChild(ctx *app.CreateChildAreaContext) error {
 		}
 
 		childPath := append(parent.Path, parent.ID)
+		if maxDepth := c.config.GetAreaMaxDepth(); len(childPath) > maxDepth {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", ctx.ID).Expected(fmt.Sprintf("an area less than %d levels below the root area", maxDepth)))
+		}
 		newArea := area.Area{
 			SpaceID: parent.SpaceID,
 			Path:    childPath,
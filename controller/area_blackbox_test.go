@@ -11,6 +11,7 @@ import (
 	"github.com/almighty/almighty-core/app/test"
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/area"
+	config "github.com/almighty/almighty-core/configuration"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/gormapplication"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
@@ -107,6 +108,39 @@ func (rest *TestAreaREST) TestSuccessCreateMultiChildArea() {
 	assert.Contains(rest.T(), *created.Data.Relationships.Children.Links.Self, "children")
 }
 
+// maxDepthConfiguration overrides the configuration to limit the depth of the areas and iterations
+type maxDepthConfiguration struct {
+	*config.ConfigurationData
+	maxDepth int
+}
+
+func (c maxDepthConfiguration) GetAreaMaxDepth() int {
+	return c.maxDepth
+}
+
+func (c maxDepthConfiguration) GetIterationMaxDepth() int {
+	return c.maxDepth
+}
+
+func (rest *TestAreaREST) TestCreateChildAreaUpToMaxDepth() {
+	// given
+	_, rootArea := createSpaceAndArea(rest.T(), rest.db)
+	svc, _ := rest.SecuredController()
+	ctrl := NewAreaController(svc, rest.db, maxDepthConfiguration{ConfigurationData: rest.Configuration, maxDepth: 2})
+	parentID := rootArea.ID.String()
+	for i := 1; i <= 2; i++ {
+		name := fmt.Sprintf("TestCreateChildAreaUpToMaxDepth-%d", i)
+		// when
+		_, created := test.CreateChildAreaCreated(rest.T(), svc.Context, svc, ctrl, parentID, getCreateChildAreaPayload(&name))
+		// then
+		require.NotNil(rest.T(), created)
+		parentID = *created.Data.ID
+	}
+	// when/then
+	name := "TestCreateChildAreaUpToMaxDepth-3"
+	test.CreateChildAreaBadRequest(rest.T(), svc.Context, svc, ctrl, parentID, getCreateChildAreaPayload(&name))
+}
+
 func (rest *TestAreaREST) TestFailCreateChildAreaMissingName() {
 	// given
 	_, parentArea := createSpaceAndArea(rest.T(), rest.db)
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/goadesign/goa"
+)
+
+// CollaboratorPoliciesController implements the collaborator-policies resource.
+type CollaboratorPoliciesController struct {
+	*goa.Controller
+	db            application.DB
+	policyManager auth.AuthzPolicyManager
+}
+
+// NewCollaboratorPoliciesController creates a collaborator-policies controller.
+func NewCollaboratorPoliciesController(service *goa.Service, db application.DB, policyManager auth.AuthzPolicyManager) *CollaboratorPoliciesController {
+	return &CollaboratorPoliciesController{Controller: service.NewController("CollaboratorPoliciesController"), db: db, policyManager: policyManager}
+}
+
+// Deduplicate removes the duplicated users from the collaborators policies of all the spaces.
+// Only the tokens granted the admin scope are allowed to run it.
+func (c *CollaboratorPoliciesController) Deduplicate(ctx *app.DeduplicateCollaboratorPoliciesContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to deduplicate the collaborators policies", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	var policyIDs []string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		resources, err := appl.SpaceResources().List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			policyIDs = append(policyIDs, r.PolicyID)
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+	}
+	deduplicated := 0
+	for _, policyID := range policyIDs {
+		policy, pat, err := c.policyManager.GetPolicy(ctx, ctx.RequestData, policyID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+		}
+		if !policy.RemoveDuplicateUsers() {
+			continue
+		}
+		if err := c.policyManager.UpdatePolicy(ctx, ctx.RequestData, *policy, *pat); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"policy_id":    policyID,
+				"deduplicated": deduplicated,
+				"err":          err,
+			}, "deduplication of the collaborators policies aborted")
+			return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+		}
+		log.Info(ctx, map[string]interface{}{
+			"policy_id": policyID,
+		}, "removed the duplicated users from the collaborators policy")
+		deduplicated++
+	}
+	return ctx.OK(&app.CollaboratorPoliciesDeduplication{Deduplicated: deduplicated})
+}
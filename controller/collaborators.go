@@ -3,19 +3,52 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/almighty/almighty-core/account"
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/httpsupport"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/space/authz"
+	"github.com/almighty/almighty-core/workitem"
 	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 )
 
+// Roles of a user in a space: the owner of the space, the role of a collaborator listed in the space policy, or none.
+const (
+	SpaceRoleOwner       = "owner"
+	SpaceRoleAdmin       = auth.PolicyRoleAdmin
+	SpaceRoleContributor = auth.PolicyRoleContributor
+	SpaceRoleViewer      = auth.PolicyRoleViewer
+	SpaceRoleNone        = "none"
+)
+
+// What happens to the work items assigned to a collaborator who is removed from their space
+const (
+	RemovedCollaboratorKeepAssignments = "keep"
+	RemovedCollaboratorUnassign        = "unassign"
+	RemovedCollaboratorReassignToOwner = "owner"
+)
+
+// Results of the addition of a user to the space collaborators
+const (
+	CollaboratorAdded          = "added"
+	CollaboratorAlreadyPresent = "already-present"
+	CollaboratorNotFound       = "not-found"
+)
+
 // CollaboratorsController implements the collaborators resource.
 type CollaboratorsController struct {
 	*goa.Controller
@@ -26,6 +59,8 @@ type CollaboratorsController struct {
 
 type collaboratorsConfiguration interface {
 	GetKeycloakEndpointEntitlement(*goa.RequestData) (string, error)
+	GetWorkItemRemovedCollaboratorAssignments() string
+	GetCollaboratorsCacheTTL() time.Duration
 }
 
 type collaboratorContext interface {
@@ -38,88 +73,577 @@ func NewCollaboratorsController(service *goa.Service, db application.DB, config
 	return &CollaboratorsController{Controller: service.NewController("CollaboratorsController"), db: db, config: config, policyManager: policyManager}
 }
 
-// List collaborators for the given space ID.
+// List collaborators for the given space ID, along with their role in the meta of each collaborator.
+// The members of the teams of the space are listed after the collaborators of the space policy, once,
+// with the name of the team they collaborate through in the meta.
+// The collaborators of the space policy are served from the database while their cache is fresh.
 func (c *CollaboratorsController) List(ctx *app.ListCollaboratorsContext) error {
-	policy, _, err := c.getPolicy(ctx, ctx.RequestData, ctx.ID)
+	collaborators, err := loadSpaceCollaborators(ctx, c.db, c.policyManager, ctx.RequestData, ctx.ID, c.config.GetCollaboratorsCacheTTL())
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	userIDs := policy.Config.UserIDs
-	//UsersIDs format : "[\"<ID>\",\"<ID>\"]"
-	s := strings.Split(userIDs, ",")
+	s := make([]string, len(collaborators))
+	roles := make(map[string]string, len(collaborators))
+	for i, collaborator := range collaborators {
+		s[i] = collaborator.IdentityID.String()
+		roles[s[i]] = collaborator.Role
+	}
+
+	// the ETag changes whenever the collaborators of the space are updated, or the members of its teams
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	var resource *space.Resource
+	var ownerID uuid.UUID
+	var teams []account.Team
+	var teamMembers []account.SpaceTeamMember
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		resource, err = appl.SpaceResources().LoadBySpace(ctx, &spaceID)
+		if err != nil {
+			return err
+		}
+		s, err := appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			return err
+		}
+		ownerID = s.OwnerId
+		teams, err = appl.Teams().ListBySpace(ctx, spaceID)
+		if err != nil {
+			return err
+		}
+		teamMembers, err = appl.Teams().ListSpaceMembers(ctx, spaceID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+	via := make(map[string]string, len(teamMembers))
+	teamAddedAt := make(map[string]time.Time, len(teamMembers))
+	listed := make(map[string]bool, len(s)+len(teamMembers))
+	for _, id := range s {
+		listed[id] = true
+	}
+	for _, member := range teamMembers {
+		id := member.IdentityID.String()
+		if !listed[id] {
+			listed[id] = true
+			via[id] = member.TeamName
+			s = append(s, id)
+		}
+		// the members of several teams were added along with the first of them
+		if addedAt, ok := teamAddedAt[id]; via[id] != "" && (!ok || member.AddedAt.Before(addedAt)) {
+			teamAddedAt[id] = member.AddedAt
+		}
+	}
+	var additionalQuery []string
+	if ctx.FilterUsername != nil {
+		additionalQuery = append(additionalQuery, "filter[username]="+url.QueryEscape(*ctx.FilterUsername))
+	}
+	if ctx.FilterEmail != nil {
+		additionalQuery = append(additionalQuery, "filter[email]="+url.QueryEscape(*ctx.FilterEmail))
+	}
+	if ctx.Sort != nil {
+		additionalQuery = append(additionalQuery, "sort="+*ctx.Sort)
+	}
+	s, err = filterAndSortCollaborators(ctx, c.db, s, teamAddedAt, ctx.FilterUsername, ctx.FilterEmail, ctx.Sort)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+	}
 	count := len(s)
 
 	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
-	if offset > len(s) {
-		offset = len(s)
+	if offset > count {
+		offset = count
 	}
-	if offset+limit > len(s) {
-		limit = len(s)
+	end := offset + limit
+	if end > count {
+		end = count
 	}
-	page := s[offset : offset+limit]
+	page := s[offset:end]
 
-	data := make([]*app.IdentityData, len(s))
-	for i, id := range page {
-		id = strings.Trim(id, "[]\"")
-		uID, err := uuid.FromString(id)
-		if err != nil {
-			log.Error(ctx, map[string]interface{}{
-				"identity_id": id,
-				"users-ids":   userIDs,
-			}, "unable to convert the identity ID to uuid v4")
-			return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+	entity := httpsupport.ConditionalEntity{ETagData: []interface{}{resource.ID, resource.UpdatedAt, count}}
+	for _, team := range teams {
+		entity.ETagData = append(entity.ETagData, team.ID, team.UpdatedAt)
+	}
+	for _, id := range page {
+		entity.ETagData = append(entity.ETagData, id)
+	}
+
+	return httpsupport.ConditionalRequest(ctx, ctx.RequestData, ctx.ResponseData, entity, func() error {
+		identityIDs := make([]uuid.UUID, len(page))
+		for i, id := range page {
+			identityIDs[i], err = uuid.FromString(id)
+			if err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"identity_id": id,
+				}, "unable to convert the identity ID to uuid v4")
+				return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+			}
 		}
+		data := make([]*app.IdentityData, 0, len(page))
 		err = application.Transactional(c.db, func(appl application.Application) error {
-			identities, err := appl.Identities().Query(account.IdentityFilterByID(uID), account.IdentityWithUser())
+			if len(identityIDs) == 0 {
+				return nil
+			}
+			// all the identities of the page are loaded at once
+			identities, err := appl.Identities().Query(account.IdentityFilterByIDs(identityIDs), account.IdentityWithUser())
 			if err != nil {
 				log.Error(ctx, map[string]interface{}{
-					"identity_id": id,
-					"err":         err,
-				}, "unable to find the identity listed in the space policy")
+					"identity_ids": page,
+					"err":          err,
+				}, "unable to find the identities listed in the space policy")
 				return err
 			}
-			if len(identities) == 0 {
-				log.Error(ctx, map[string]interface{}{
-					"identity_id": id,
-				}, "unable to find the identity listed in the space policy")
-				return errors.New("Identity listed in the space policy not found")
+			identitiesByID := make(map[uuid.UUID]*account.Identity, len(identities))
+			for _, identity := range identities {
+				identitiesByID[identity.ID] = identity
+			}
+			// keep the order of the space policy
+			for _, id := range identityIDs {
+				identity, ok := identitiesByID[id]
+				if !ok {
+					// an identity which was deleted without being removed from the policy is not listed
+					log.Warn(ctx, map[string]interface{}{
+						"identity_id": id,
+					}, "unable to find the identity listed in the space policy")
+					continue
+				}
+				appIdentity := ConvertUser(ctx.RequestData, identity, &identity.User).Data
+				if team, ok := via[id.String()]; ok {
+					appIdentity.Meta = map[string]interface{}{"role": SpaceRoleContributor, "via": team}
+					data = append(data, appIdentity)
+					continue
+				}
+				role := roles[id.String()]
+				if uuid.Equal(id, ownerID) {
+					role = SpaceRoleOwner
+				}
+				appIdentity.Meta = map[string]interface{}{"role": role}
+				data = append(data, appIdentity)
 			}
-			appIdentity := ConvertUser(ctx.RequestData, identities[0], &identities[0].User)
-			data[i] = appIdentity.Data
 			return nil
 		})
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
 		}
+
+		response := app.UserList{
+			Links: &app.PagingLinks{},
+			Meta:  &app.UserListMeta{TotalCount: count},
+			Data:  data,
+		}
+		setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(page), offset, limit, count, additionalQuery...)
+		return ctx.OK(&response)
+	})
+}
+
+// filterAndSortCollaborators returns the collaborators with the given IDs whose username and email contain the given
+// terms, regardless of the case, sorted by `username` or by `added_at`. The collaborators of the space policy are
+// added in the order of the given IDs, while the members of the teams, which are listed after them, were added at
+// the given times. The identities of the collaborators are only loaded to filter them or to sort them by username,
+// and the identities which don't exist anymore are skipped.
+func filterAndSortCollaborators(ctx context.Context, db application.DB, ids []string, teamAddedAt map[string]time.Time, username, email, sortParam *string) ([]string, error) {
+	result := ids
+	if sortParam != nil && strings.TrimPrefix(*sortParam, "-") == "added_at" {
+		result = sortCollaboratorsByAddition(ids, teamAddedAt, *sortParam == "-added_at")
+	}
+	byUsername := sortParam != nil && strings.TrimPrefix(*sortParam, "-") == "username"
+	if (username == nil && email == nil && !byUsername) || len(ids) == 0 {
+		return result, nil
+	}
+	identityIDs := make([]uuid.UUID, len(result))
+	for i, id := range result {
+		identityID, err := uuid.FromString(id)
+		if err != nil {
+			return nil, errs.Wrapf(err, "unable to convert the identity ID '%s' to uuid v4", id)
+		}
+		identityIDs[i] = identityID
+	}
+	identitiesByID := make(map[uuid.UUID]*account.Identity, len(identityIDs))
+	err := application.Transactional(db, func(appl application.Application) error {
+		identities, err := appl.Identities().Query(account.IdentityFilterByIDs(identityIDs), account.IdentityWithUser())
+		if err != nil {
+			return err
+		}
+		for _, identity := range identities {
+			identitiesByID[identity.ID] = identity
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]*account.Identity, 0, len(identityIDs))
+	for _, id := range identityIDs {
+		identity, ok := identitiesByID[id]
+		if !ok {
+			log.Warn(ctx, map[string]interface{}{
+				"identity_id": id,
+			}, "unable to find the identity listed in the space policy")
+			continue
+		}
+		if username != nil && !strings.Contains(strings.ToLower(identity.Username), strings.ToLower(*username)) {
+			continue
+		}
+		if email != nil && !strings.Contains(strings.ToLower(identity.User.Email), strings.ToLower(*email)) {
+			continue
+		}
+		filtered = append(filtered, identity)
 	}
+	if byUsername {
+		// the collaborators with the same username remain in the order they were added
+		var byAttribute sort.Interface = collaboratorsByUsername(filtered)
+		if strings.HasPrefix(*sortParam, "-") {
+			byAttribute = sort.Reverse(byAttribute)
+		}
+		sort.Stable(byAttribute)
+	}
+	result = make([]string, len(filtered))
+	for i, identity := range filtered {
+		result[i] = identity.ID.String()
+	}
+	return result, nil
+}
 
-	response := app.UserList{
-		Links: &app.PagingLinks{},
-		Meta:  &app.UserListMeta{TotalCount: count},
-		Data:  data,
+// sortCollaboratorsByAddition sorts the given collaborators by the time they were added to the space: the collaborators
+// of the space policy are kept in the order of the policy, and the members of the teams are sorted by their own addition
+// time. The members of the teams remain listed after the collaborators of the policy, whose addition time is unknown.
+func sortCollaboratorsByAddition(ids []string, teamAddedAt map[string]time.Time, descending bool) []string {
+	policyIDs := make([]string, 0, len(ids))
+	teamIDs := make([]string, 0, len(teamAddedAt))
+	for _, id := range ids {
+		if _, ok := teamAddedAt[id]; ok {
+			teamIDs = append(teamIDs, id)
+		} else {
+			policyIDs = append(policyIDs, id)
+		}
 	}
-	setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(page), offset, limit, count)
-	return ctx.OK(&response)
+	var byAddition sort.Interface = teamMembersByAddition{ids: teamIDs, addedAt: teamAddedAt}
+	if descending {
+		byAddition = sort.Reverse(byAddition)
+	}
+	sort.Stable(byAddition)
+	if descending {
+		for i, j := 0, len(policyIDs)-1; i < j; i, j = i+1, j-1 {
+			policyIDs[i], policyIDs[j] = policyIDs[j], policyIDs[i]
+		}
+	}
+	return append(policyIDs, teamIDs...)
+}
+
+type teamMembersByAddition struct {
+	ids     []string
+	addedAt map[string]time.Time
+}
+
+func (m teamMembersByAddition) Len() int      { return len(m.ids) }
+func (m teamMembersByAddition) Swap(i, j int) { m.ids[i], m.ids[j] = m.ids[j], m.ids[i] }
+func (m teamMembersByAddition) Less(i, j int) bool {
+	return m.addedAt[m.ids[i]].Before(m.addedAt[m.ids[j]])
+}
+
+type collaboratorsByUsername []*account.Identity
+
+func (c collaboratorsByUsername) Len() int           { return len(c) }
+func (c collaboratorsByUsername) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c collaboratorsByUsername) Less(i, j int) bool { return c[i].Username < c[j].Username }
+
+// ListActivity lists the collaborators of the given space ID along with the last time they created,
+// updated or commented a work item of the space, so that the inactive collaborators can be spotted.
+func (c *CollaboratorsController) ListActivity(ctx *app.ListActivityCollaboratorsContext) error {
+	authorized, err := authz.Authorize(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized("User not among space collaborators"))
+	}
+	collaborators, err := loadSpaceCollaborators(ctx, c.db, c.policyManager, ctx.RequestData, ctx.ID, c.config.GetCollaboratorsCacheTTL())
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	identityIDs := make([]uuid.UUID, len(collaborators))
+	for i, collaborator := range collaborators {
+		identityIDs[i] = collaborator.IdentityID
+	}
+	// the space ID was validated when loading its collaborators
+	spaceID, _ := uuid.FromString(ctx.ID)
+	data := make([]*app.CollaboratorActivity, 0, len(identityIDs))
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if len(identityIDs) == 0 {
+			return nil
+		}
+		identities, err := appl.Identities().Query(account.IdentityFilterByIDs(identityIDs))
+		if err != nil {
+			return err
+		}
+		usernames := make(map[uuid.UUID]string, len(identities))
+		for _, identity := range identities {
+			usernames[identity.ID] = identity.Username
+		}
+		lastActivities, err := appl.WorkItemRevisions().LastActivities(ctx, spaceID, identityIDs)
+		if err != nil {
+			return err
+		}
+		// keep the order of the space policy, like the list of collaborators
+		for _, id := range identityIDs {
+			username, ok := usernames[id]
+			if !ok {
+				log.Error(ctx, map[string]interface{}{
+					"identity_id": id,
+				}, "unable to find the identity listed in the space policy")
+				return errors.New("Identity listed in the space policy not found")
+			}
+			activity := &app.CollaboratorActivity{IdentityID: id, Username: username}
+			if lastActivity, ok := lastActivities[id]; ok {
+				activity.LastActivity = &lastActivity
+			}
+			data = append(data, activity)
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+	}
+	return ctx.OK(&app.CollaboratorActivityList{Data: data})
 }
 
 // Add user's identity to the list of space collaborators.
 func (c *CollaboratorsController) Add(ctx *app.AddCollaboratorsContext) error {
 	identityIDs := []*app.UpdateUserID{{ID: ctx.IdentityID}}
-	err := c.updatePolicy(ctx, ctx.RequestData, ctx.ID, identityIDs, c.policyManager.AddUserToPolicy)
+	additions, err := c.addCollaborators(ctx, ctx.RequestData, ctx.ID, identityIDs)
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	return ctx.OK([]byte{})
+	switch additions[0].Attributes.Status {
+	case CollaboratorNotFound:
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(fmt.Sprintf("identity %s not found", ctx.IdentityID)))
+	case CollaboratorAlreadyPresent:
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("identity %s already collaborates on space %s", ctx.IdentityID, ctx.ID)))
+		return ctx.Conflict(jerrors)
+	}
+	return ctx.OK(&app.CollaboratorAdditionList{Data: additions})
 }
 
 // AddMany adds user's identities to the list of space collaborators.
 func (c *CollaboratorsController) AddMany(ctx *app.AddManyCollaboratorsContext) error {
-	if ctx.Payload != nil && ctx.Payload.Data != nil {
-		err := c.updatePolicy(ctx, ctx.RequestData, ctx.ID, ctx.Payload.Data, c.policyManager.AddUserToPolicy)
+	var identityIDs []*app.UpdateUserID
+	if ctx.Payload != nil {
+		identityIDs = ctx.Payload.Data
+	}
+	additions, err := c.addCollaborators(ctx, ctx.RequestData, ctx.ID, identityIDs)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.CollaboratorAdditionList{Data: additions})
+}
+
+// Role returns the role of the current user in the given space.
+func (c *CollaboratorsController) Role(ctx *app.RoleCollaboratorsContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	role, err := c.spaceRole(ctx, ctx.RequestData, spaceID, *currentIdentityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.SpaceRole{
+		SpaceID:    spaceID,
+		IdentityID: *currentIdentityID,
+		Role:       role,
+	})
+}
+
+// Permissions returns the operations which the current user is allowed to perform in the given space,
+// as granted by the role of the user in that space.
+func (c *CollaboratorsController) Permissions(ctx *app.PermissionsCollaboratorsContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	role, err := c.spaceRole(ctx, ctx.RequestData, spaceID, *currentIdentityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	isOwner := role == SpaceRoleOwner
+	isAdmin := isOwner || role == SpaceRoleAdmin
+	isCollaborator := isAdmin || role == SpaceRoleContributor
+	return ctx.OK(&app.SpacePermissions{
+		SpaceID:                spaceID,
+		IdentityID:             *currentIdentityID,
+		Role:                   role,
+		CanCreateItem:          isCollaborator,
+		CanEditItems:           isCollaborator,
+		CanManageCollaborators: isCollaborator,
+		CanRemoveAdmins:        isAdmin,
+		CanUpdateSpace:         isAdmin,
+		CanDeleteSpace:         isOwner,
+	})
+}
+
+// spaceRole returns the role of the given identity in the given space.
+func (c *CollaboratorsController) spaceRole(ctx collaboratorContext, req *goa.RequestData, spaceID uuid.UUID, identityID uuid.UUID) (string, error) {
+	return loadSpaceRole(ctx, c.db, c.policyManager, req, spaceID, identityID)
+}
+
+// loadSpaceRole returns the role of the given identity in the given space: the owner of the space,
+// the role of a collaborator listed in the space policy, contributor for the members of the teams
+// of the space, or none.
+func loadSpaceRole(ctx context.Context, db application.DB, policyManager auth.AuthzPolicyManager, req *goa.RequestData, spaceID uuid.UUID, identityID uuid.UUID) (string, error) {
+	var ownerID uuid.UUID
+	var isTeamMember bool
+	err := application.Transactional(db, func(appl application.Application) error {
+		space, err := appl.Spaces().Load(ctx, spaceID)
 		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, err)
+			return err
+		}
+		ownerID = space.OwnerId
+		isTeamMember, err = appl.Teams().IsSpaceMember(ctx, spaceID, identityID)
+		return err
+	})
+	if err != nil {
+		return "", goa.ErrNotFound(err.Error())
+	}
+	if uuid.Equal(ownerID, identityID) {
+		return SpaceRoleOwner, nil
+	}
+	policy, _, err := loadSpacePolicy(ctx, db, policyManager, req, spaceID.String())
+	if err != nil {
+		return "", err
+	}
+	if role := policy.UserRole(identityID.String()); role != "" {
+		return role, nil
+	}
+	if isTeamMember {
+		return SpaceRoleContributor, nil
+	}
+	return SpaceRoleNone, nil
+}
+
+// Update sets the role of a collaborator of the given space. Only the owner and the admins of the space
+// are allowed to set the roles.
+func (c *CollaboratorsController) Update(ctx *app.UpdateCollaboratorsContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	identityID, err := uuid.FromString(ctx.IdentityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	if ctx.Payload == nil || ctx.Payload.Data == nil || ctx.Payload.Data.Attributes == nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest("missing role of the collaborator"))
+	}
+	role := ctx.Payload.Data.Attributes.Role
+	currentRole, err := c.spaceRole(ctx, ctx.RequestData, spaceID, *currentIdentityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if currentRole != SpaceRoleOwner && currentRole != SpaceRoleAdmin {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to set the roles of the collaborators of space %s", *currentIdentityID, spaceID)))
+		return ctx.Forbidden(jerrors)
+	}
+	collaboratorRole, err := c.spaceRole(ctx, ctx.RequestData, spaceID, identityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	switch collaboratorRole {
+	case SpaceRoleOwner:
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest("the role of the space owner can't be changed"))
+	case SpaceRoleNone:
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(fmt.Sprintf("identity %s is not a collaborator of space %s", identityID, spaceID)))
+	}
+	policy, _, err := c.getPolicy(ctx, ctx.RequestData, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !policy.HasUser(identityID.String()) {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(fmt.Sprintf("identity %s collaborates on space %s through a team: it must be added to the collaborators before its role is changed", identityID, spaceID)))
+	}
+	identityIDs := []*app.UpdateUserID{{ID: identityID.String()}}
+	err = c.updatePolicy(ctx, ctx.RequestData, ctx.ID, identityIDs, func(policy *auth.KeycloakPolicy, identityID string) bool {
+		return policy.SetUserRole(identityID, role)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id":    spaceID,
+		"identity_id": identityID,
+		"role":        role,
+	}, "collaborator role updated")
+	return ctx.OK(&app.SpaceRole{
+		SpaceID:    spaceID,
+		IdentityID: identityID,
+		Role:       role,
+	})
+}
+
+// AddTeam adds a team to the collaborators of the given space. The team is not added to the space policy:
+// its members are authorized against the team, so that the changes of its members apply immediately.
+func (c *CollaboratorsController) AddTeam(ctx *app.AddTeamCollaboratorsContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	if _, _, err := c.authorizeCollaboratorsUpdate(ctx, ctx.RequestData, ctx.ID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Teams().Load(ctx, ctx.TeamID); err != nil {
+			return err
 		}
+		return appl.Teams().AddToSpace(ctx, spaceID, ctx.TeamID)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
+	if err := touchSpaceResource(ctx, c.db, spaceID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id": spaceID,
+		"team_id":  ctx.TeamID,
+	}, "team added to the space collaborators")
+	return ctx.OK([]byte{})
+}
+
+// RemoveTeam removes a team from the collaborators of the given space.
+func (c *CollaboratorsController) RemoveTeam(ctx *app.RemoveTeamCollaboratorsContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+	}
+	if _, _, err := c.authorizeCollaboratorsUpdate(ctx, ctx.RequestData, ctx.ID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		return appl.Teams().RemoveFromSpace(ctx, spaceID, ctx.TeamID)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if err := touchSpaceResource(ctx, c.db, spaceID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id": spaceID,
+		"team_id":  ctx.TeamID,
+	}, "team removed from the space collaborators")
 	return ctx.OK([]byte{})
 }
 
@@ -137,12 +661,20 @@ func (c *CollaboratorsController) Remove(ctx *app.RemoveCollaboratorsContext) er
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
+	err = c.authorizeCollaboratorsRemoval(ctx, ctx.RequestData, spaceID, []string{ctx.IdentityID})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 
 	identityIDs := []*app.UpdateUserID{{ID: ctx.IdentityID}}
 	err = c.updatePolicy(ctx, ctx.RequestData, ctx.ID, identityIDs, c.policyManager.RemoveUserFromPolicy)
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
+	err = c.releaseAssignments(ctx, spaceID, []string{ctx.IdentityID})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 	return ctx.OK([]byte{})
 }
 
@@ -157,23 +689,88 @@ func (c *CollaboratorsController) RemoveMany(ctx *app.RemoveManyCollaboratorsCon
 			}, "unable to convert the space ID to uuid v4")
 			return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
 		}
+		var removedIDs []string
 		for _, idn := range ctx.Payload.Data {
 			if idn != nil {
 				err := c.checkSpaceOwner(ctx, spaceID, idn.ID)
 				if err != nil {
 					return jsonapi.JSONErrorResponse(ctx, err)
 				}
+				removedIDs = append(removedIDs, idn.ID)
 			}
 		}
+		err = c.authorizeCollaboratorsRemoval(ctx, ctx.RequestData, spaceID, removedIDs)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 		err = c.updatePolicy(ctx, ctx.RequestData, ctx.ID, ctx.Payload.Data, c.policyManager.RemoveUserFromPolicy)
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, err)
 		}
+		err = c.releaseAssignments(ctx, spaceID, removedIDs)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 	}
 
 	return ctx.OK([]byte{})
 }
 
+// releaseAssignments unassigns the given removed collaborators from the work items of the space,
+// or reassigns these work items to the space owner, depending on the configuration.
+func (c *CollaboratorsController) releaseAssignments(ctx context.Context, spaceID uuid.UUID, identityIDs []string) error {
+	mode := c.config.GetWorkItemRemovedCollaboratorAssignments()
+	if mode != RemovedCollaboratorUnassign && mode != RemovedCollaboratorReassignToOwner {
+		return nil
+	}
+	modifierID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return goa.ErrUnauthorized(err.Error())
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		s, err := appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			return goa.ErrNotFound(err.Error())
+		}
+		ownerID := s.OwnerId.String()
+		for _, identityID := range identityIDs {
+			exp := criteria.Equals(criteria.Field(workitem.SystemAssignees), criteria.Literal([]string{identityID}))
+			workitems, _, err := appl.WorkItems().List(ctx, spaceID, exp, nil, nil, nil)
+			if err != nil {
+				return goa.ErrInternal(err.Error())
+			}
+			for _, wi := range workitems {
+				assignees := []string{}
+				for _, id := range assigneeIDs(wi.Fields[workitem.SystemAssignees]) {
+					// the space owner is appended last when the work item is reassigned to them
+					if id == identityID || (mode == RemovedCollaboratorReassignToOwner && id == ownerID) {
+						continue
+					}
+					assignees = append(assignees, id)
+				}
+				if mode == RemovedCollaboratorReassignToOwner {
+					assignees = append(assignees, ownerID)
+				}
+				if len(assignees) == 0 {
+					delete(wi.Fields, workitem.SystemAssignees)
+				} else {
+					wi.Fields[workitem.SystemAssignees] = assignees
+				}
+				if _, err := appl.WorkItems().Save(ctx, spaceID, wi, *modifierID); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"space_id":    spaceID,
+						"wi_id":       wi.ID,
+						"identity_id": identityID,
+						"err":         err,
+					}, "unable to release the assignment of the removed collaborator")
+					return goa.ErrInternal(err.Error())
+				}
+			}
+		}
+		return nil
+	})
+}
+
 func (c *CollaboratorsController) checkSpaceOwner(ctx context.Context, spaceID uuid.UUID, identityID string) error {
 	var ownerID string
 	err := application.Transactional(c.db, func(appl application.Application) error {
@@ -198,17 +795,7 @@ func (c *CollaboratorsController) checkSpaceOwner(ctx context.Context, spaceID u
 }
 
 func (c *CollaboratorsController) updatePolicy(ctx collaboratorContext, req *goa.RequestData, spaceID string, identityIDs []*app.UpdateUserID, update func(policy *auth.KeycloakPolicy, identityID string) bool) error {
-	// Authorize current user
-	authorized, err := authz.Authorize(ctx, spaceID)
-	if err != nil {
-		return goa.ErrUnauthorized(err.Error())
-	}
-	if !authorized {
-		return goa.ErrUnauthorized("User not among space collaborators")
-	}
-
-	// Update policy
-	policy, pat, err := c.getPolicy(ctx, req, spaceID)
+	policy, pat, err := c.authorizeCollaboratorsUpdate(ctx, req, spaceID)
 	if err != nil {
 		return err
 	}
@@ -245,32 +832,178 @@ func (c *CollaboratorsController) updatePolicy(ctx collaboratorContext, req *goa
 			if err != nil {
 				return goa.ErrNotFound(err.Error())
 			}
-			updated = updated || update(policy, identityID)
+			if update(policy, identityID) {
+				updated = true
+			}
 		}
 	}
 	if !updated {
 		// Nothing changed. No need to update
 		return nil
 	}
+	return c.savePolicy(ctx, req, spaceID, policy, pat)
+}
+
+// addCollaborators adds the given identities to the space policy and returns the result of the addition of each identity,
+// listed once in the order of the given IDs. The identities which don't exist are reported rather than failing the whole
+// addition, and the policy is only updated if it changed.
+func (c *CollaboratorsController) addCollaborators(ctx collaboratorContext, req *goa.RequestData, spaceID string, identityIDs []*app.UpdateUserID) ([]*app.CollaboratorAddition, error) {
+	policy, pat, err := c.authorizeCollaboratorsUpdate(ctx, req, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	additions := []*app.CollaboratorAddition{}
+	listed := map[string]bool{}
+	updated := false
+	for _, identityIDData := range identityIDs {
+		if identityIDData == nil {
+			continue
+		}
+		identityUUID, err := uuid.FromString(identityIDData.ID)
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"identity_id": identityIDData.ID,
+			}, "unable to convert the identity ID to uuid v4")
+			return nil, goa.ErrBadRequest(err.Error())
+		}
+		identityID := identityUUID.String()
+		if listed[identityID] {
+			continue
+		}
+		listed[identityID] = true
+		var found bool
+		err = application.Transactional(c.db, func(appl application.Application) error {
+			found = appl.Identities().IsValid(ctx, identityUUID)
+			return nil
+		})
+		if err != nil {
+			return nil, goa.ErrInternal(err.Error())
+		}
+		// the policy is left untouched for the users who are already listed, so that a conflict never changes it
+		status := CollaboratorAdded
+		switch {
+		case !found:
+			status = CollaboratorNotFound
+		case policy.HasUser(identityID):
+			status = CollaboratorAlreadyPresent
+		default:
+			c.policyManager.AddUserToPolicy(policy, identityID)
+			updated = true
+		}
+		additions = append(additions, &app.CollaboratorAddition{
+			ID:         identityID,
+			Type:       "identities",
+			Attributes: &app.CollaboratorAdditionAttributes{Status: status},
+		})
+	}
+	if updated {
+		if err := c.savePolicy(ctx, req, spaceID, policy, pat); err != nil {
+			return nil, err
+		}
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id":  spaceID,
+		"additions": len(additions),
+		"updated":   updated,
+	}, "collaborators added to the space")
+	return additions, nil
+}
 
-	err = c.policyManager.UpdatePolicy(ctx, req, *policy, *pat)
+// savePolicy updates the given space policy in Keycloak along with the cached collaborators of the space
+func (c *CollaboratorsController) savePolicy(ctx collaboratorContext, req *goa.RequestData, spaceID string, policy *auth.KeycloakPolicy, pat *string) error {
+	err := c.policyManager.UpdatePolicy(ctx, req, *policy, *pat)
 	if err != nil {
 		return goa.ErrInternal(err.Error())
 	}
 
-	// TODO	We will need to update the resource when implementing http cache
-	// _, err = appl.SpaceResources().Save(ctx, resource)
+	spaceUUID, err := uuid.FromString(spaceID)
+	if err != nil {
+		return goa.ErrBadRequest(err.Error())
+	}
+	// the cached collaborators are updated along with the policy, so that they can still be listed from the database
+	if err := cachePolicyCollaborators(ctx, c.db, spaceUUID, policy); err != nil {
+		return err
+	}
+	return touchSpaceResource(ctx, c.db, spaceUUID)
+}
+
+// authorizeCollaboratorsUpdate checks that the current user is allowed to manage the collaborators of the given space,
+// and returns the space policy along with the token to update it
+func (c *CollaboratorsController) authorizeCollaboratorsUpdate(ctx collaboratorContext, req *goa.RequestData, spaceID string) (*auth.KeycloakPolicy, *string, error) {
+	// Authorize current user
+	authorized, err := authz.Authorize(ctx, spaceID)
+	if err != nil {
+		return nil, nil, goa.ErrUnauthorized(err.Error())
+	}
+	if !authorized {
+		return nil, nil, goa.ErrUnauthorized("User not among space collaborators")
+	}
+	policy, pat, err := c.getPolicy(ctx, req, spaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the viewers are listed in the policy, but they are not allowed to manage the collaborators
+	if currentIdentityID, err := login.ContextIdentity(ctx); err == nil && policy.UserRole(currentIdentityID.String()) == SpaceRoleViewer {
+		return nil, nil, goa.ErrUnauthorized("User not allowed to manage the space collaborators")
+	}
+	return policy, pat, nil
+}
 
+// authorizeCollaboratorsRemoval checks that the current user is allowed to remove the given collaborators from the given
+// space: the contributors are only allowed to remove the contributors and the viewers, while the admins can only be removed
+// by the owner and the other admins of the space
+func (c *CollaboratorsController) authorizeCollaboratorsRemoval(ctx collaboratorContext, req *goa.RequestData, spaceID uuid.UUID, identityIDs []string) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return goa.ErrUnauthorized(err.Error())
+	}
+	role, err := c.spaceRole(ctx, req, spaceID, *currentIdentityID)
+	if err != nil {
+		return err
+	}
+	if role == SpaceRoleOwner || role == SpaceRoleAdmin {
+		return nil
+	}
+	policy, _, err := c.getPolicy(ctx, req, spaceID.String())
+	if err != nil {
+		return err
+	}
+	for _, identityID := range identityIDs {
+		if policy.UserRole(identityID) == SpaceRoleAdmin {
+			return goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to remove the admin %s from the collaborators of space %s", *currentIdentityID, identityID, spaceID))
+		}
+	}
+	return nil
+}
+
+// touchSpaceResource updates the space resource, so that the ETag of the collaborators list changes
+func touchSpaceResource(ctx context.Context, db application.DB, spaceID uuid.UUID) error {
+	err := application.Transactional(db, func(appl application.Application) error {
+		resource, err := appl.SpaceResources().LoadBySpace(ctx, &spaceID)
+		if err != nil {
+			return err
+		}
+		_, err = appl.SpaceResources().Save(ctx, resource)
+		return err
+	})
+	if err != nil {
+		return goa.ErrInternal(err.Error())
+	}
 	return nil
 }
 
 func (c *CollaboratorsController) getPolicy(ctx collaboratorContext, req *goa.RequestData, spaceID string) (*auth.KeycloakPolicy, *string, error) {
+	return loadSpacePolicy(ctx, c.db, c.policyManager, req, spaceID)
+}
+
+// loadSpacePolicy returns the collaborators policy of the given space along with the token to update it
+func loadSpacePolicy(ctx context.Context, db application.DB, policyManager auth.AuthzPolicyManager, req *goa.RequestData, spaceID string) (*auth.KeycloakPolicy, *string, error) {
 	spaceUUID, err := uuid.FromString(spaceID)
 	if err != nil {
 		return nil, nil, goa.ErrBadRequest(err.Error())
 	}
 	var policyID string
-	err = application.Transactional(c.db, func(appl application.Application) error {
+	err = application.Transactional(db, func(appl application.Application) error {
 		// Load associated space resource
 		resource, err := appl.SpaceResources().LoadBySpace(ctx, &spaceUUID)
 		if err != nil {
@@ -283,7 +1016,7 @@ func (c *CollaboratorsController) getPolicy(ctx collaboratorContext, req *goa.Re
 	if err != nil {
 		return nil, nil, goa.ErrNotFound(err.Error())
 	}
-	policy, pat, err := c.policyManager.GetPolicy(ctx, req, policyID)
+	policy, pat, err := policyManager.GetPolicy(ctx, req, policyID)
 	if err != nil {
 		return nil, nil, goa.ErrInternal(err.Error())
 	}
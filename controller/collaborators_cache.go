@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/space"
+
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// CollaboratorsCacheConfiguration the configuration of the cache of the space collaborators
+type CollaboratorsCacheConfiguration interface {
+	GetCollaboratorsCacheTTL() time.Duration
+	GetCollaboratorsReconciliationInterval() time.Duration
+}
+
+// loadSpaceCollaborators returns the collaborators of the given space policy, in the order of the policy.
+// They are served from the database as long as they were cached for less than the given TTL, otherwise
+// the policy is loaded from Keycloak and cached again. A zero TTL disables the cache.
+func loadSpaceCollaborators(ctx context.Context, db application.DB, policyManager auth.AuthzPolicyManager, req *goa.RequestData, spaceID string, ttl time.Duration) ([]space.Collaborator, error) {
+	spaceUUID, err := uuid.FromString(spaceID)
+	if err != nil {
+		return nil, goa.ErrBadRequest(err.Error())
+	}
+	if ttl > 0 {
+		var collaborators []space.Collaborator
+		var syncedAt *time.Time
+		err = application.Transactional(db, func(appl application.Application) error {
+			collaborators, syncedAt, err = appl.SpaceCollaborators().List(ctx, spaceUUID)
+			return err
+		})
+		if err != nil {
+			return nil, goa.ErrNotFound(err.Error())
+		}
+		if syncedAt != nil && time.Since(*syncedAt) < ttl {
+			return collaborators, nil
+		}
+	}
+	policy, _, err := loadSpacePolicy(ctx, db, policyManager, req, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	collaborators, err := policyCollaborators(policy)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id":  spaceID,
+			"users-ids": policy.Config.UserIDs,
+			"err":       err,
+		}, "unable to parse the users of the space policy")
+		return nil, goa.ErrInternal(err.Error())
+	}
+	if ttl > 0 {
+		if err := cacheSpaceCollaborators(ctx, db, spaceUUID, collaborators); err != nil {
+			return nil, err
+		}
+	}
+	return collaborators, nil
+}
+
+// policyCollaborators returns the users of the given space policy along with their role, in the order of the policy
+func policyCollaborators(policy *auth.KeycloakPolicy) ([]space.Collaborator, error) {
+	userIDs, err := policy.UserIDs()
+	if err != nil {
+		return nil, err
+	}
+	collaborators := make([]space.Collaborator, 0, len(userIDs))
+	listed := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		identityID, err := uuid.FromString(id)
+		if err != nil {
+			return nil, errs.Wrapf(err, "unable to convert the identity ID '%s' to uuid v4", id)
+		}
+		// the duplicated users of the policies which were not deduplicated yet are cached once
+		if listed[identityID] {
+			continue
+		}
+		listed[identityID] = true
+		collaborators = append(collaborators, space.Collaborator{IdentityID: identityID, Role: policy.UserRole(id)})
+	}
+	return collaborators, nil
+}
+
+// cacheSpaceCollaborators replaces the cached collaborators of the given space
+func cacheSpaceCollaborators(ctx context.Context, db application.DB, spaceID uuid.UUID, collaborators []space.Collaborator) error {
+	err := application.Transactional(db, func(appl application.Application) error {
+		return appl.SpaceCollaborators().Replace(ctx, spaceID, collaborators)
+	})
+	if err != nil {
+		return goa.ErrInternal(err.Error())
+	}
+	return nil
+}
+
+// cachePolicyCollaborators replaces the cached collaborators of the given space by the users of its updated policy
+func cachePolicyCollaborators(ctx context.Context, db application.DB, spaceID uuid.UUID, policy *auth.KeycloakPolicy) error {
+	collaborators, err := policyCollaborators(policy)
+	if err != nil {
+		return goa.ErrInternal(err.Error())
+	}
+	return cacheSpaceCollaborators(ctx, db, spaceID, collaborators)
+}
+
+// invalidateCachedCollaborators makes the cached collaborators of the space with the given policy reloaded from
+// the policy when they are listed next
+func invalidateCachedCollaborators(ctx context.Context, db application.DB, policyID string) error {
+	return application.Transactional(db, func(appl application.Application) error {
+		return appl.SpaceCollaborators().InvalidateByPolicy(ctx, policyID)
+	})
+}
+
+// collaborationPolicyIDs returns the IDs of the collaborators policies which may list the given identities: the policies
+// of the spaces whose cached collaborators include them, along with the policies of the spaces whose collaborators
+// are not cached, or were invalidated, since they can't be told apart without loading them from Keycloak.
+func collaborationPolicyIDs(ctx context.Context, appl application.Application, identities []*account.Identity) ([]string, error) {
+	spaceIDs, err := appl.SpaceCollaborators().ListStale(ctx, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range identities {
+		ids, err := appl.SpaceCollaborators().ListSpaces(ctx, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		spaceIDs = append(spaceIDs, ids...)
+	}
+	var policyIDs []string
+	found := map[uuid.UUID]bool{}
+	for _, spaceID := range spaceIDs {
+		if found[spaceID] {
+			continue
+		}
+		found[spaceID] = true
+		resource, err := appl.SpaceResources().LoadBySpace(ctx, &spaceID)
+		if err != nil {
+			if _, notFound := errs.Cause(err).(errors.NotFoundError); notFound {
+				continue
+			}
+			return nil, err
+		}
+		policyIDs = append(policyIDs, resource.PolicyID)
+	}
+	return policyIDs, nil
+}
+
+// ReconcileSpaceCollaborators synchronizes the cached collaborators of the spaces which were cached before the given time,
+// or never, with their policy in Keycloak, and returns the number of synchronized spaces. The spaces whose policy can't be
+// loaded are logged and skipped, so that they are synchronized again on the next reconciliation.
+func ReconcileSpaceCollaborators(ctx context.Context, db application.DB, policyManager auth.AuthzPolicyManager, req *goa.RequestData, syncedBefore time.Time) (int, error) {
+	var spaceIDs []uuid.UUID
+	err := application.Transactional(db, func(appl application.Application) error {
+		var err error
+		spaceIDs, err = appl.SpaceCollaborators().ListStale(ctx, syncedBefore)
+		return err
+	})
+	if err != nil {
+		return 0, errs.Wrap(err, "unable to list the spaces whose cached collaborators are stale")
+	}
+	synced := 0
+	for _, spaceID := range spaceIDs {
+		policy, _, err := loadSpacePolicy(ctx, db, policyManager, req, spaceID.String())
+		if err == nil {
+			err = cachePolicyCollaborators(ctx, db, spaceID, policy)
+		}
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"space_id": spaceID,
+				"err":      err,
+			}, "unable to synchronize the cached collaborators of the space")
+			continue
+		}
+		synced++
+	}
+	log.Info(ctx, map[string]interface{}{
+		"stale":  len(spaceIDs),
+		"synced": synced,
+	}, "cached space collaborators reconciled")
+	return synced, nil
+}
+
+// ScheduleSpaceCollaboratorsReconciliation synchronizes the cached collaborators older than their TTL with Keycloak
+// at the configured interval, until the returned function is called. Nothing is scheduled if no interval is configured,
+// or if the cache is disabled. Since there is no request to compute the Keycloak endpoints from, they must be configured.
+func ScheduleSpaceCollaboratorsReconciliation(ctx context.Context, db application.DB, policyManager auth.AuthzPolicyManager, config CollaboratorsCacheConfiguration) (stop func()) {
+	interval := config.GetCollaboratorsReconciliationInterval()
+	ttl := config.GetCollaboratorsCacheTTL()
+	if interval <= 0 || ttl <= 0 {
+		return func() {}
+	}
+	req := &goa.RequestData{Request: &http.Request{Header: http.Header{}}}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := ReconcileSpaceCollaborators(ctx, db, policyManager, req, time.Now().Add(-ttl)); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"err": err,
+					}, "unable to reconcile the cached space collaborators")
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
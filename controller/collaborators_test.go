@@ -1,8 +1,10 @@
 package controller_test
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"context"
 
@@ -11,19 +13,24 @@ import (
 	"github.com/almighty/almighty-core/app/test"
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/comment"
+	config "github.com/almighty/almighty-core/configuration"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormapplication"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space/authz"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
+	"github.com/almighty/almighty-core/workitem"
 	token "github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
 	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -53,11 +60,23 @@ func (s *DummySpaceAuthzService) Configuration() authz.AuthzConfiguration {
 	return nil
 }
 
+func (s *DummySpaceAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	return false, nil
+}
+
 func (m *DummyPolicyManager) GetPolicy(ctx context.Context, request *goa.RequestData, policyID string) (*auth.KeycloakPolicy, *string, error) {
 	pat := ""
 	return m.rest.policy, &pat, nil
 }
 
+func (m *DummyPolicyManager) GetPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string) ([]*auth.KeycloakPolicy, error) {
+	policies := make([]*auth.KeycloakPolicy, len(policyIDs))
+	for i := range policyIDs {
+		policies[i] = m.rest.policy
+	}
+	return policies, nil
+}
+
 func (m *DummyPolicyManager) UpdatePolicy(ctx context.Context, request *goa.RequestData, policy auth.KeycloakPolicy, pat string) error {
 	return nil
 }
@@ -117,6 +136,13 @@ func (rest *TestCollaboratorsREST) SecuredController() (*goa.Service, *Collabora
 	return svc, NewCollaboratorsController(svc, rest.db, rest.Configuration, &DummyPolicyManager{rest: rest})
 }
 
+func (rest *TestCollaboratorsREST) SecuredControllerAs(identity account.Identity) (*goa.Service, *CollaboratorsController) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+
+	svc := testsupport.ServiceAsSpaceUser("Collaborators-Service", almtoken.NewManagerWithPrivateKey(priv), identity, &DummySpaceAuthzService{rest})
+	return svc, NewCollaboratorsController(svc, rest.db, rest.Configuration, &DummyPolicyManager{rest: rest})
+}
+
 func (rest *TestCollaboratorsREST) UnSecuredController() (*goa.Service, *CollaboratorsController) {
 	svc := goa.New("Collaborators-Service")
 	return svc, NewCollaboratorsController(svc, rest.db, rest.Configuration, &DummyPolicyManager{rest: rest})
@@ -124,12 +150,12 @@ func (rest *TestCollaboratorsREST) UnSecuredController() (*goa.Service, *Collabo
 
 func (rest *TestCollaboratorsREST) TestListCollaboratorsWithRandomSpaceIDNotFound() {
 	svc, ctrl := rest.UnSecuredController()
-	test.ListCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String(), nil, nil)
+	test.ListCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String(), nil, nil, nil, nil, nil, nil)
 }
 
 func (rest *TestCollaboratorsREST) TestListCollaboratorsWithWrongSpaceIDFormatReturnsBadRequest() {
 	svc, ctrl := rest.UnSecuredController()
-	test.ListCollaboratorsBadRequest(rest.T(), svc.Context, svc, ctrl, "wrongFormatID", nil, nil)
+	test.ListCollaboratorsBadRequest(rest.T(), svc.Context, svc, ctrl, "wrongFormatID", nil, nil, nil, nil, nil, nil)
 }
 
 func (rest *TestCollaboratorsREST) TestListCollaboratorsOk() {
@@ -137,10 +163,126 @@ func (rest *TestCollaboratorsREST) TestListCollaboratorsOk() {
 	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
 
+	// the collaborators removed in Keycloak are listed until the cached collaborators are reconciled
 	rest.policy.RemoveUserFromPolicy(rest.testIdentity2.ID.String())
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+	_, err := ReconcileSpaceCollaborators(context.Background(), rest.db, &DummyPolicyManager{rest: rest}, nil, time.Now())
+	require.Nil(rest.T(), err)
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
 }
 
+func (rest *TestCollaboratorsREST) TestListCollaboratorsEmptyPolicyOk() {
+	svc, ctrl := rest.UnSecuredController()
+	for _, userIDs := range []string{"", "[]"} {
+		// given
+		rest.policy.Config.UserIDs = userIDs
+		// when
+		_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+		// then
+		require.NotNil(rest.T(), users)
+		assert.Empty(rest.T(), users.Data)
+		assert.Equal(rest.T(), 0, users.Meta.TotalCount)
+	}
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsKeepsPolicyOrderOk() {
+	// given identities added to the policy in the reverse order of their creation
+	var identities []account.Identity
+	for i := 0; i < 3; i++ {
+		identity, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+		require.Nil(rest.T(), err)
+		identities = append(identities, identity)
+	}
+	var userIDs []string
+	for i := len(identities) - 1; i >= 0; i-- {
+		rest.policy.AddUserToPolicy(identities[i].ID.String())
+		userIDs = append(userIDs, identities[i].ID.String())
+	}
+	// when/then
+	rest.checkCollaborators(userIDs)
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsPagedOk() {
+	// given
+	var userIDs []string
+	for i := 0; i < 5; i++ {
+		identity, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+		require.Nil(rest.T(), err)
+		rest.policy.AddUserToPolicy(identity.ID.String())
+		userIDs = append(userIDs, identity.ID.String())
+	}
+	svc, ctrl := rest.UnSecuredController()
+	limit := 3
+	// when
+	firstOffset := "0"
+	_, firstPage := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, &limit, &firstOffset, nil, nil)
+	secondOffset := "3"
+	_, secondPage := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, &limit, &secondOffset, nil, nil)
+	// then
+	require.Len(rest.T(), firstPage.Data, 3)
+	require.Len(rest.T(), secondPage.Data, 2)
+	for i, data := range append(firstPage.Data, secondPage.Data...) {
+		require.NotNil(rest.T(), data)
+		assert.Equal(rest.T(), userIDs[i], *data.ID)
+	}
+	assert.Equal(rest.T(), 5, firstPage.Meta.TotalCount)
+	assert.Equal(rest.T(), 5, secondPage.Meta.TotalCount)
+	require.NotNil(rest.T(), firstPage.Links.Next)
+	assert.Contains(rest.T(), *firstPage.Links.Next, "page[offset]=3")
+	assert.Nil(rest.T(), secondPage.Links.Next)
+	require.NotNil(rest.T(), secondPage.Links.Prev)
+	assert.Contains(rest.T(), *secondPage.Links.Prev, "page[offset]=0")
+}
+
+func (rest *TestCollaboratorsREST) TestListActivityOk() {
+	// given
+	identity3, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	for _, identity := range []account.Identity{rest.testIdentity1, rest.testIdentity2, identity3} {
+		rest.policy.AddUserToPolicy(identity.ID.String())
+	}
+	ctx := context.Background()
+	spaceID, err := uuid.FromString(rest.spaceID)
+	require.Nil(rest.T(), err)
+	// the first collaborator creates a work item which the second collaborator comments
+	wi, err := workitem.NewWorkItemRepository(rest.DB).Create(ctx, spaceID, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "TestListActivity",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, rest.testIdentity1.ID)
+	require.Nil(rest.T(), err)
+	c := comment.Comment{ParentID: wi.ID, Body: "TestListActivity", Markup: rendering.SystemMarkupPlainText}
+	require.Nil(rest.T(), comment.NewRepository(rest.DB).Create(ctx, &c, rest.testIdentity2.ID))
+	wiRevisions, err := workitem.NewRevisionRepository(rest.DB).List(ctx, wi.ID)
+	require.Nil(rest.T(), err)
+	require.Len(rest.T(), wiRevisions, 1)
+	commentRevisions, err := comment.NewRevisionRepository(rest.DB).List(ctx, c.ID)
+	require.Nil(rest.T(), err)
+	require.Len(rest.T(), commentRevisions, 1)
+	svc, ctrl := rest.SecuredController()
+	// when
+	_, result := test.ListActivityCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	// then
+	require.Len(rest.T(), result.Data, 3)
+	assert.Equal(rest.T(), rest.testIdentity1.ID, result.Data[0].IdentityID)
+	assert.Equal(rest.T(), rest.testIdentity1.Username, result.Data[0].Username)
+	require.NotNil(rest.T(), result.Data[0].LastActivity)
+	assert.True(rest.T(), wiRevisions[0].Time.Equal(*result.Data[0].LastActivity))
+	assert.Equal(rest.T(), rest.testIdentity2.ID, result.Data[1].IdentityID)
+	require.NotNil(rest.T(), result.Data[1].LastActivity)
+	assert.True(rest.T(), commentRevisions[0].Time.Equal(*result.Data[1].LastActivity))
+	// the third collaborator has no activity in the space
+	assert.Equal(rest.T(), identity3.ID, result.Data[2].IdentityID)
+	assert.Nil(rest.T(), result.Data[2].LastActivity)
+}
+
+func (rest *TestCollaboratorsREST) TestListActivityUnauthorizedIfCurrentUserIsNotCollaborator() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc, ctrl := rest.SecuredController()
+	// when/then
+	test.ListActivityCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+}
+
 func (rest *TestCollaboratorsREST) TestAddCollaboratorsWithRandomSpaceIDNotFound() {
 	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
 	svc, ctrl := rest.SecuredController()
@@ -173,12 +315,48 @@ func (rest *TestCollaboratorsREST) TestAddCollaboratorsOk() {
 	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
 
-	test.AddCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+	_, additions := test.AddCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+	require.Len(rest.T(), additions.Data, 1)
+	assert.Equal(rest.T(), rest.testIdentity2.ID.String(), additions.Data[0].ID)
+	assert.Equal(rest.T(), CollaboratorAdded, additions.Data[0].Attributes.Status)
 	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
 	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
 }
 
+func (rest *TestCollaboratorsREST) TestAddCollaboratorsWithUnknownIdentityNotFound() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredController()
+	test.AddCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, rest.spaceID, uuid.NewV4().String())
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsNotModifiedUsingIfNoneMatchHeader() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.UnSecuredController()
+	res, _ := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	require.NotEmpty(rest.T(), eTag)
+	// when/then
+	test.ListCollaboratorsNotModified(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, &eTag)
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsETagChangesWhenCollaboratorAdded() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.UnSecuredController()
+	res, _ := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	// when
+	secureSvc, secureCtrl := rest.SecuredController()
+	test.AddCollaboratorsOK(rest.T(), secureSvc.Context, secureSvc, secureCtrl, rest.spaceID, rest.testIdentity2.ID.String())
+	// then
+	res, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, &eTag)
+	require.Len(rest.T(), users.Data, 2)
+	assert.NotEqual(rest.T(), eTag, res.Header().Get(app.ETag))
+}
+
 func (rest *TestCollaboratorsREST) TestAddManyCollaboratorsOk() {
 	svc, ctrl := rest.SecuredController()
 
@@ -186,12 +364,126 @@ func (rest *TestCollaboratorsREST) TestAddManyCollaboratorsOk() {
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
 
 	payload := &app.AddManyCollaboratorsPayload{Data: []*app.UpdateUserID{{ID: rest.testIdentity1.ID.String(), Type: idnType}, {ID: rest.testIdentity2.ID.String(), Type: idnType}}}
-	test.AddManyCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
+	_, additions := test.AddManyCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
+	require.Len(rest.T(), additions.Data, 2)
+	assert.Equal(rest.T(), CollaboratorAlreadyPresent, additions.Data[0].Attributes.Status)
+	assert.Equal(rest.T(), CollaboratorAdded, additions.Data[1].Attributes.Status)
 	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
 	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
 	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
 }
 
+func (rest *TestCollaboratorsREST) TestAddSameCollaboratorTwiceConflict() {
+	// given
+	svc, ctrl := rest.SecuredController()
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	test.AddCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+	// when
+	_, jerrors := test.AddCollaboratorsConflict(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+	// then
+	require.NotEmpty(rest.T(), jerrors.Errors)
+	assert.Equal(rest.T(), fmt.Sprintf(`["%s","%s"]`, rest.testIdentity1.ID, rest.testIdentity2.ID), rest.policy.Config.UserIDs)
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+}
+
+func (rest *TestCollaboratorsREST) TestAddCollaboratorListedTwiceConflictLeavesPolicyUnchanged() {
+	// given
+	svc, ctrl := rest.SecuredController()
+	id1 := rest.testIdentity1.ID.String()
+	id2 := rest.testIdentity2.ID.String()
+	rest.policy.Config.UserIDs = fmt.Sprintf(`["%s","%s","%s"]`, id1, id2, id2)
+	// when
+	test.AddCollaboratorsConflict(rest.T(), svc.Context, svc, ctrl, rest.spaceID, id2)
+	// then the duplicated users are not removed by a conflicting request
+	assert.Equal(rest.T(), fmt.Sprintf(`["%s","%s","%s"]`, id1, id2, id2), rest.policy.Config.UserIDs)
+}
+
+func (rest *TestCollaboratorsREST) TestAddManyCollaboratorsWithSameUserTwiceOk() {
+	// given
+	svc, ctrl := rest.SecuredController()
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	identity, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	payload := &app.AddManyCollaboratorsPayload{Data: []*app.UpdateUserID{
+		{ID: rest.testIdentity2.ID.String(), Type: idnType},
+		{ID: rest.testIdentity2.ID.String(), Type: idnType},
+		{ID: identity.ID.String(), Type: idnType},
+	}}
+	// when
+	_, additions := test.AddManyCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
+	// then the duplicated user is reported once
+	require.Len(rest.T(), additions.Data, 2)
+	assert.Equal(rest.T(), rest.testIdentity2.ID.String(), additions.Data[0].ID)
+	assert.Equal(rest.T(), CollaboratorAdded, additions.Data[0].Attributes.Status)
+	assert.Equal(rest.T(), identity.ID.String(), additions.Data[1].ID)
+	assert.Equal(rest.T(), CollaboratorAdded, additions.Data[1].Attributes.Status)
+	assert.Equal(rest.T(), fmt.Sprintf(`["%s","%s","%s"]`, rest.testIdentity1.ID, rest.testIdentity2.ID, identity.ID), rest.policy.Config.UserIDs)
+}
+
+func (rest *TestCollaboratorsREST) TestAddManyCollaboratorsWithUnknownIdentityOk() {
+	// given
+	svc, ctrl := rest.SecuredController()
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	unknownID := uuid.NewV4().String()
+	payload := &app.AddManyCollaboratorsPayload{Data: []*app.UpdateUserID{
+		{ID: rest.testIdentity1.ID.String(), Type: idnType},
+		{ID: unknownID, Type: idnType},
+		{ID: rest.testIdentity2.ID.String(), Type: idnType},
+	}}
+	// when
+	_, additions := test.AddManyCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
+	// then the result of each user is reported, and the known users are added
+	require.Len(rest.T(), additions.Data, 3)
+	assert.Equal(rest.T(), CollaboratorAlreadyPresent, additions.Data[0].Attributes.Status)
+	assert.Equal(rest.T(), unknownID, additions.Data[1].ID)
+	assert.Equal(rest.T(), CollaboratorNotFound, additions.Data[1].Attributes.Status)
+	assert.Equal(rest.T(), CollaboratorAdded, additions.Data[2].Attributes.Status)
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+}
+
+func (rest *TestCollaboratorsREST) TestDeduplicateCollaboratorPoliciesOK() {
+	// given
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsUserWithScopes("CollaboratorPolicies-Service", almtoken.NewManagerWithPrivateKey(priv), rest.testIdentity1, almtoken.ScopeAdminUsers)
+	ctrl := NewCollaboratorPoliciesController(svc, rest.db, &DummyPolicyManager{rest: rest})
+	id1 := rest.testIdentity1.ID.String()
+	id2 := rest.testIdentity2.ID.String()
+	rest.policy.Config.UserIDs = fmt.Sprintf(`["%s","%s","%s","%s"]`, id1, id2, id2, id1)
+	// when
+	_, result := test.DeduplicateCollaboratorPoliciesOK(rest.T(), svc.Context, svc, ctrl)
+	// then all the spaces share the same dummy policy, which is deduplicated once
+	assert.Equal(rest.T(), 1, result.Deduplicated)
+	assert.Equal(rest.T(), fmt.Sprintf(`["%s","%s"]`, id1, id2), rest.policy.Config.UserIDs)
+	rest.checkCollaborators([]string{id1, id2})
+}
+
+func (rest *TestCollaboratorsREST) TestDeduplicateCollaboratorPoliciesForbidden() {
+	// given
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsUser("CollaboratorPolicies-Service", almtoken.NewManagerWithPrivateKey(priv), rest.testIdentity2)
+	ctrl := NewCollaboratorPoliciesController(svc, rest.db, &DummyPolicyManager{rest: rest})
+	duplicated := fmt.Sprintf(`["%s","%s"]`, rest.testIdentity1.ID, rest.testIdentity1.ID)
+	rest.policy.Config.UserIDs = duplicated
+	// when
+	test.DeduplicateCollaboratorPoliciesForbidden(rest.T(), svc.Context, svc, ctrl)
+	// then
+	assert.Equal(rest.T(), duplicated, rest.policy.Config.UserIDs)
+}
+
+func (rest *TestCollaboratorsREST) TestDeduplicateCollaboratorPoliciesByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsUserWithScopes("CollaboratorPolicies-Service", almtoken.NewManagerWithPrivateKey(priv), rest.testIdentity1, almtoken.ScopeAdminUsers)
+	impersonatedBy(svc, rest.testIdentity2)
+	ctrl := NewCollaboratorPoliciesController(svc, rest.db, &DummyPolicyManager{rest: rest})
+	duplicated := fmt.Sprintf(`["%s","%s"]`, rest.testIdentity1.ID, rest.testIdentity1.ID)
+	rest.policy.Config.UserIDs = duplicated
+	// when
+	test.DeduplicateCollaboratorPoliciesForbidden(rest.T(), svc.Context, svc, ctrl)
+	// then
+	assert.Equal(rest.T(), duplicated, rest.policy.Config.UserIDs)
+}
+
 func (rest *TestCollaboratorsREST) TestAddCollaboratorsUnauthorizedIfNoToken() {
 	svc, ctrl := rest.UnSecuredController()
 	test.AddCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
@@ -308,7 +600,7 @@ func (rest *TestCollaboratorsREST) TestRemoveManyCollaboratorsWithWrongUserIDFor
 func (rest *TestCollaboratorsREST) checkCollaborators(userIDs []string) {
 	svc, ctrl := rest.UnSecuredController()
 
-	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil)
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
 	require.NotNil(rest.T(), users)
 	require.Equal(rest.T(), len(userIDs), len(users.Data))
 	for i, id := range userIDs {
@@ -327,6 +619,85 @@ func (rest *TestCollaboratorsREST) TestRemoveCollaboratorsOk() {
 	test.RemoveCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
 }
 
+type removedCollaboratorConfiguration struct {
+	*config.ConfigurationData
+	mode string
+}
+
+func (c removedCollaboratorConfiguration) GetWorkItemRemovedCollaboratorAssignments() string {
+	return c.mode
+}
+
+// createAssignedWorkItems creates a work item assigned to the second test identity only,
+// and another one assigned to both test identities, and returns their IDs
+func (rest *TestCollaboratorsREST) createAssignedWorkItems() (string, string) {
+	spaceID, err := uuid.FromString(rest.spaceID)
+	require.Nil(rest.T(), err)
+	repo := workitem.NewWorkItemRepository(rest.DB)
+	var ids []string
+	for _, assignees := range [][]string{
+		{rest.testIdentity2.ID.String()},
+		{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()},
+	} {
+		wi, err := repo.Create(context.Background(), spaceID, workitem.SystemBug, map[string]interface{}{
+			workitem.SystemTitle:     "TestRemoveCollaborator",
+			workitem.SystemState:     workitem.SystemStateNew,
+			workitem.SystemAssignees: assignees,
+		}, rest.testIdentity1.ID)
+		require.Nil(rest.T(), err)
+		ids = append(ids, wi.ID)
+	}
+	return ids[0], ids[1]
+}
+
+func (rest *TestCollaboratorsREST) loadAssignees(wiID string) interface{} {
+	spaceID, err := uuid.FromString(rest.spaceID)
+	require.Nil(rest.T(), err)
+	wi, err := workitem.NewWorkItemRepository(rest.DB).Load(context.Background(), spaceID, wiID)
+	require.Nil(rest.T(), err)
+	return wi.Fields[workitem.SystemAssignees]
+}
+
+func (rest *TestCollaboratorsREST) removeCollaboratorWithAssignments(mode string) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsSpaceUser("Collaborators-Service", almtoken.NewManagerWithPrivateKey(priv), rest.testIdentity1, &DummySpaceAuthzService{rest})
+	ctrl := NewCollaboratorsController(svc, rest.db, removedCollaboratorConfiguration{ConfigurationData: rest.Configuration, mode: mode}, &DummyPolicyManager{rest: rest})
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	test.RemoveCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveCollaboratorKeepsAssignments() {
+	// given
+	wiID1, wiID2 := rest.createAssignedWorkItems()
+	// when
+	rest.removeCollaboratorWithAssignments(RemovedCollaboratorKeepAssignments)
+	// then
+	assert.Equal(rest.T(), []interface{}{rest.testIdentity2.ID.String()}, rest.loadAssignees(wiID1))
+	assert.Equal(rest.T(), []interface{}{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()}, rest.loadAssignees(wiID2))
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveCollaboratorUnassignsItems() {
+	// given
+	wiID1, wiID2 := rest.createAssignedWorkItems()
+	// when
+	rest.removeCollaboratorWithAssignments(RemovedCollaboratorUnassign)
+	// then
+	assert.Empty(rest.T(), rest.loadAssignees(wiID1))
+	assert.Equal(rest.T(), []interface{}{rest.testIdentity1.ID.String()}, rest.loadAssignees(wiID2))
+	assert.False(rest.T(), rest.policy.HasUser(rest.testIdentity2.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveCollaboratorReassignsItemsToOwner() {
+	// given the first test identity, which created the space, is its owner
+	wiID1, wiID2 := rest.createAssignedWorkItems()
+	// when
+	rest.removeCollaboratorWithAssignments(RemovedCollaboratorReassignToOwner)
+	// then
+	assert.Equal(rest.T(), []interface{}{rest.testIdentity1.ID.String()}, rest.loadAssignees(wiID1))
+	assert.Equal(rest.T(), []interface{}{rest.testIdentity1.ID.String()}, rest.loadAssignees(wiID2))
+}
+
 func (rest *TestCollaboratorsREST) TestRemoveManyCollaboratorsOk() {
 	svc, ctrl := rest.SecuredController()
 
@@ -338,6 +709,519 @@ func (rest *TestCollaboratorsREST) TestRemoveManyCollaboratorsOk() {
 	test.RemoveManyCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
 }
 
+// addSpaceAdmin adds a new identity to the collaborators of the space, as an admin
+func (rest *TestCollaboratorsREST) addSpaceAdmin() account.Identity {
+	admin, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	rest.policy.AddUserToPolicy(admin.ID.String())
+	rest.policy.SetUserRole(admin.ID.String(), auth.PolicyRoleAdmin)
+	return admin
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveAdminUnauthorizedForContributor() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	admin := rest.addSpaceAdmin()
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+	// when/then
+	test.RemoveCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID, admin.ID.String())
+	assert.Equal(rest.T(), SpaceRoleAdmin, rest.policy.UserRole(admin.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveManyWithAdminUnauthorizedForContributor() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	contributor, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	rest.policy.AddUserToPolicy(contributor.ID.String())
+	admin := rest.addSpaceAdmin()
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+	payload := &app.RemoveManyCollaboratorsPayload{Data: []*app.UpdateUserID{{ID: contributor.ID.String(), Type: idnType}, {ID: admin.ID.String(), Type: idnType}}}
+	// when/then none of the collaborators is removed
+	test.RemoveManyCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID, payload)
+	assert.True(rest.T(), rest.policy.HasUser(contributor.ID.String()))
+	assert.Equal(rest.T(), SpaceRoleAdmin, rest.policy.UserRole(admin.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveAdminByAdminOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleAdmin)
+	admin := rest.addSpaceAdmin()
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+	// when
+	test.RemoveCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, admin.ID.String())
+	// then
+	assert.False(rest.T(), rest.policy.HasUser(admin.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestRoleOfSpaceOwner() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredController()
+
+	_, role := test.RoleCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), role)
+	assert.Equal(rest.T(), SpaceRoleOwner, role.Role)
+	assert.Equal(rest.T(), rest.testIdentity1.ID, role.IdentityID)
+	assert.Equal(rest.T(), rest.spaceID, role.SpaceID.String())
+}
+
+func (rest *TestCollaboratorsREST) TestRoleOfSpaceCollaborator() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, role := test.RoleCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), role)
+	assert.Equal(rest.T(), SpaceRoleContributor, role.Role)
+	assert.Equal(rest.T(), rest.testIdentity2.ID, role.IdentityID)
+}
+
+func (rest *TestCollaboratorsREST) TestRoleOfNonCollaborator() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, role := test.RoleCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), role)
+	assert.Equal(rest.T(), SpaceRoleNone, role.Role)
+}
+
+func (rest *TestCollaboratorsREST) TestRoleWithRandomSpaceIDNotFound() {
+	svc, ctrl := rest.SecuredController()
+	test.RoleCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+func (rest *TestCollaboratorsREST) TestRoleUnauthorizedIfNoToken() {
+	svc, ctrl := rest.UnSecuredController()
+	test.RoleCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsOfSpaceOwner() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredController()
+
+	_, permissions := test.PermissionsCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), permissions)
+	assert.Equal(rest.T(), SpaceRoleOwner, permissions.Role)
+	assert.Equal(rest.T(), rest.testIdentity1.ID, permissions.IdentityID)
+	assert.True(rest.T(), permissions.CanCreateItem)
+	assert.True(rest.T(), permissions.CanEditItems)
+	assert.True(rest.T(), permissions.CanManageCollaborators)
+	assert.True(rest.T(), permissions.CanRemoveAdmins)
+	assert.True(rest.T(), permissions.CanUpdateSpace)
+	assert.True(rest.T(), permissions.CanDeleteSpace)
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsOfSpaceCollaborator() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, permissions := test.PermissionsCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), permissions)
+	assert.Equal(rest.T(), SpaceRoleContributor, permissions.Role)
+	assert.True(rest.T(), permissions.CanCreateItem)
+	assert.True(rest.T(), permissions.CanEditItems)
+	assert.True(rest.T(), permissions.CanManageCollaborators)
+	assert.False(rest.T(), permissions.CanRemoveAdmins)
+	assert.False(rest.T(), permissions.CanUpdateSpace)
+	assert.False(rest.T(), permissions.CanDeleteSpace)
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsOfViewer() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, permissions := test.PermissionsCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), permissions)
+	assert.Equal(rest.T(), SpaceRoleNone, permissions.Role)
+	assert.Equal(rest.T(), rest.testIdentity2.ID, permissions.IdentityID)
+	assert.False(rest.T(), permissions.CanCreateItem)
+	assert.False(rest.T(), permissions.CanEditItems)
+	assert.False(rest.T(), permissions.CanManageCollaborators)
+	assert.False(rest.T(), permissions.CanRemoveAdmins)
+	assert.False(rest.T(), permissions.CanUpdateSpace)
+	assert.False(rest.T(), permissions.CanDeleteSpace)
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsOfSpaceAdmin() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleAdmin)
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, permissions := test.PermissionsCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), permissions)
+	assert.Equal(rest.T(), SpaceRoleAdmin, permissions.Role)
+	assert.True(rest.T(), permissions.CanCreateItem)
+	assert.True(rest.T(), permissions.CanEditItems)
+	assert.True(rest.T(), permissions.CanManageCollaborators)
+	assert.True(rest.T(), permissions.CanRemoveAdmins)
+	assert.True(rest.T(), permissions.CanUpdateSpace)
+	assert.False(rest.T(), permissions.CanDeleteSpace)
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsOfSpaceViewer() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleViewer)
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, permissions := test.PermissionsCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), permissions)
+	assert.Equal(rest.T(), SpaceRoleViewer, permissions.Role)
+	assert.False(rest.T(), permissions.CanCreateItem)
+	assert.False(rest.T(), permissions.CanEditItems)
+	assert.False(rest.T(), permissions.CanManageCollaborators)
+	assert.False(rest.T(), permissions.CanRemoveAdmins)
+	assert.False(rest.T(), permissions.CanUpdateSpace)
+	assert.False(rest.T(), permissions.CanDeleteSpace)
+}
+
+func newUpdateCollaboratorsPayload(role string) *app.UpdateCollaboratorsPayload {
+	return &app.UpdateCollaboratorsPayload{
+		Data: &app.Collaborator{
+			Type:       idnType,
+			Attributes: &app.CollaboratorAttributes{Role: role},
+		},
+	}
+}
+
+func (rest *TestCollaboratorsREST) TestUpdateCollaboratorRoleOK() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc, ctrl := rest.SecuredController()
+	// when
+	_, role := test.UpdateCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleAdmin))
+	// then
+	require.NotNil(rest.T(), role)
+	assert.Equal(rest.T(), SpaceRoleAdmin, role.Role)
+	assert.Equal(rest.T(), rest.testIdentity2.ID, role.IdentityID)
+	assert.Equal(rest.T(), SpaceRoleAdmin, rest.policy.UserRole(rest.testIdentity2.ID.String()))
+	// and the roles are listed with the collaborators
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	require.Len(rest.T(), users.Data, 2)
+	assert.Equal(rest.T(), SpaceRoleOwner, users.Data[0].Meta["role"])
+	assert.Equal(rest.T(), SpaceRoleAdmin, users.Data[1].Meta["role"])
+}
+
+func (rest *TestCollaboratorsREST) TestUpdateCollaboratorRoleForbiddenForContributor() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.AddUserToPolicy(identity.ID.String())
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+	// when
+	test.UpdateCollaboratorsForbidden(rest.T(), svc.Context, svc, ctrl, rest.spaceID, identity.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleViewer))
+	// then
+	assert.Equal(rest.T(), SpaceRoleContributor, rest.policy.UserRole(identity.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestUpdateRoleOfSpaceOwnerBadRequest() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleAdmin)
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	test.UpdateCollaboratorsBadRequest(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity1.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleViewer))
+}
+
+func (rest *TestCollaboratorsREST) TestUpdateRoleOfNonCollaboratorNotFound() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredController()
+
+	test.UpdateCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleAdmin))
+}
+
+func (rest *TestCollaboratorsREST) TestAddCollaboratorsUnauthorizedIfCurrentUserIsViewer() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleViewer)
+	identity, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	test.AddCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID, identity.ID.String())
+	assert.False(rest.T(), rest.policy.HasUser(identity.ID.String()))
+}
+
+// createTeam creates a team with the given members
+func (rest *TestCollaboratorsREST) createTeam(members ...account.Identity) account.Team {
+	ctx := context.Background()
+	teams := account.NewTeamRepository(rest.DB)
+	team := account.Team{Name: "TestCollaborators-team-" + uuid.NewV4().String()}
+	require.Nil(rest.T(), teams.Create(ctx, &team))
+	for _, member := range members {
+		require.Nil(rest.T(), teams.AddMember(ctx, team.ID, member.ID))
+	}
+	return team
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsWithTeamOk() {
+	// given a team whose first member is already a collaborator of the space
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	identity3, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+	require.Nil(rest.T(), err)
+	team := rest.createTeam(rest.testIdentity1, rest.testIdentity2, identity3)
+	svc, ctrl := rest.SecuredController()
+	test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+	// when
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	// then the members of the team are listed once, after the collaborators of the policy
+	require.Len(rest.T(), users.Data, 3)
+	assert.Equal(rest.T(), 3, users.Meta.TotalCount)
+	assert.Equal(rest.T(), rest.testIdentity1.ID.String(), *users.Data[0].ID)
+	assert.Equal(rest.T(), SpaceRoleOwner, users.Data[0].Meta["role"])
+	assert.Nil(rest.T(), users.Data[0].Meta["via"])
+	for i, identity := range []account.Identity{rest.testIdentity2, identity3} {
+		assert.Equal(rest.T(), identity.ID.String(), *users.Data[i+1].ID)
+		assert.Equal(rest.T(), SpaceRoleContributor, users.Data[i+1].Meta["role"])
+		assert.Equal(rest.T(), team.Name, users.Data[i+1].Meta["via"])
+	}
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsFollowsTeamMembersOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	team := rest.createTeam(rest.testIdentity1)
+	svc, ctrl := rest.SecuredController()
+	test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+	// when a member joins the team
+	teams := account.NewTeamRepository(rest.DB)
+	require.Nil(rest.T(), teams.AddMember(context.Background(), team.ID, rest.testIdentity2.ID))
+	// then
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+	// when the member leaves the team
+	require.Nil(rest.T(), teams.RemoveMember(context.Background(), team.ID, rest.testIdentity2.ID))
+	// then
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+}
+
+func (rest *TestCollaboratorsREST) TestRoleOfTeamMember() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	team := rest.createTeam(rest.testIdentity2)
+	svc, ctrl := rest.SecuredController()
+	test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+	svc, ctrl = rest.SecuredControllerAs(rest.testIdentity2)
+
+	_, role := test.RoleCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.NotNil(rest.T(), role)
+	assert.Equal(rest.T(), SpaceRoleContributor, role.Role)
+}
+
+func (rest *TestCollaboratorsREST) TestUpdateRoleOfTeamMemberBadRequest() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	team := rest.createTeam(rest.testIdentity2)
+	svc, ctrl := rest.SecuredController()
+	test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+
+	test.UpdateCollaboratorsBadRequest(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleAdmin))
+}
+
+func (rest *TestCollaboratorsREST) TestRemoveTeamCollaboratorsOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	team := rest.createTeam(rest.testIdentity2)
+	svc, ctrl := rest.SecuredController()
+	test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+	// when
+	test.RemoveTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+	// then
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+	test.RemoveTeamCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+}
+
+func (rest *TestCollaboratorsREST) TestAddTeamCollaboratorsUnauthorizedIfNotCollaborator() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	team := rest.createTeam(rest.testIdentity2)
+	svc, ctrl := rest.SecuredControllerAs(rest.testIdentity2)
+
+	test.AddTeamCollaboratorsUnauthorized(rest.T(), svc.Context, svc, ctrl, rest.spaceID, team.ID)
+}
+
+func (rest *TestCollaboratorsREST) TestAddUnknownTeamCollaboratorsNotFound() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl := rest.SecuredController()
+
+	test.AddTeamCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, rest.spaceID, uuid.NewV4())
+}
+
+func (rest *TestCollaboratorsREST) TestPermissionsWithRandomSpaceIDNotFound() {
+	svc, ctrl := rest.SecuredController()
+	test.PermissionsCollaboratorsNotFound(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+// createNamedCollaborators adds identities with the given username prefixes to the collaborators, in the given order
+func (rest *TestCollaboratorsREST) createNamedCollaborators(prefixes ...string) []account.Identity {
+	var identities []account.Identity
+	for _, prefix := range prefixes {
+		identity, err := testsupport.CreateTestIdentity(rest.DB, prefix+"-TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+		require.Nil(rest.T(), err)
+		rest.policy.AddUserToPolicy(identity.ID.String())
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsFilteredByUsernameOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	identities := rest.createNamedCollaborators("alice", "bob", "Malice")
+	svc, ctrl := rest.UnSecuredController()
+	filter := "ALICE"
+	limit := 1
+	// when
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, &filter, &limit, nil, nil, nil)
+	// then
+	require.Len(rest.T(), users.Data, 1)
+	assert.Equal(rest.T(), identities[0].ID.String(), *users.Data[0].ID)
+	assert.Equal(rest.T(), 2, users.Meta.TotalCount)
+	require.NotNil(rest.T(), users.Links.Next)
+	assert.Contains(rest.T(), *users.Links.Next, "filter[username]=ALICE")
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsFilteredByEmailOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	identity := rest.createIdentityWithEmail("TestCollaborators-" + uuid.NewV4().String() + "@Example.com")
+	rest.policy.AddUserToPolicy(identity.ID.String())
+	svc, ctrl := rest.UnSecuredController()
+	filter := "example.COM"
+	// when
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, &filter, nil, nil, nil, nil, nil)
+	// then
+	require.Len(rest.T(), users.Data, 1)
+	assert.Equal(rest.T(), identity.ID.String(), *users.Data[0].ID)
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsSortedOk() {
+	// given
+	identities := rest.createNamedCollaborators("carol", "alice", "bob")
+	svc, ctrl := rest.UnSecuredController()
+	for sortParam, expected := range map[string][]account.Identity{
+		"username":  {identities[1], identities[2], identities[0]},
+		"-username": {identities[0], identities[2], identities[1]},
+		"added_at":  {identities[0], identities[1], identities[2]},
+		"-added_at": {identities[2], identities[1], identities[0]},
+	} {
+		sortParam := sortParam
+		// when
+		_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, &sortParam, nil)
+		// then
+		require.Len(rest.T(), users.Data, len(expected), sortParam)
+		for i, identity := range expected {
+			assert.Equal(rest.T(), identity.ID.String(), *users.Data[i].ID, sortParam)
+		}
+	}
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsWithTeamsSortedByAdditionOk() {
+	// given two collaborators of the policy, and the members of two teams added one after the other
+	identities := rest.createNamedCollaborators("alice", "bob")
+	members := make([]account.Identity, 2)
+	svc, ctrl := rest.SecuredController()
+	for i := range members {
+		member, err := testsupport.CreateTestIdentity(rest.DB, "TestCollaborators-"+uuid.NewV4().String(), "TestCollaborators")
+		require.Nil(rest.T(), err)
+		members[i] = member
+		test.AddTeamCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.createTeam(member).ID)
+	}
+	for sortParam, expected := range map[string][]account.Identity{
+		"added_at":  {identities[0], identities[1], members[0], members[1]},
+		"-added_at": {identities[1], identities[0], members[1], members[0]},
+	} {
+		sortParam := sortParam
+		// when
+		_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, &sortParam, nil)
+		// then the members of the teams remain listed after the collaborators of the policy
+		require.Len(rest.T(), users.Data, len(expected), sortParam)
+		for i, identity := range expected {
+			assert.Equal(rest.T(), identity.ID.String(), *users.Data[i].ID, sortParam)
+		}
+	}
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsSkipsUnknownIdentityOk() {
+	// given a collaborator of the policy whose identity doesn't exist
+	identities := rest.createNamedCollaborators("alice")
+	rest.policy.AddUserToPolicy(uuid.NewV4().String())
+	svc, ctrl := rest.UnSecuredController()
+	sortParam := "username"
+	// when
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, &sortParam, nil)
+	// then
+	require.Len(rest.T(), users.Data, 1)
+	assert.Equal(rest.T(), identities[0].ID.String(), *users.Data[0].ID)
+}
+
+type collaboratorsCacheConfiguration struct {
+	*config.ConfigurationData
+	ttl time.Duration
+}
+
+func (c collaboratorsCacheConfiguration) GetCollaboratorsCacheTTL() time.Duration {
+	return c.ttl
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsFromCacheOk() {
+	// given the collaborators listed once
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	rest.policy.SetUserRole(rest.testIdentity2.ID.String(), auth.PolicyRoleViewer)
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String(), rest.testIdentity2.ID.String()})
+	// when the policy is changed in Keycloak
+	rest.policy.RemoveUserFromPolicy(rest.testIdentity1.ID.String())
+	// then the cached collaborators are listed along with their role
+	svc, ctrl := rest.UnSecuredController()
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	require.Len(rest.T(), users.Data, 2)
+	assert.Equal(rest.T(), SpaceRoleOwner, users.Data[0].Meta["role"])
+	assert.Equal(rest.T(), SpaceRoleViewer, users.Data[1].Meta["role"])
+	// unless the cache is disabled
+	ctrl = NewCollaboratorsController(svc, rest.db, collaboratorsCacheConfiguration{ConfigurationData: rest.Configuration}, &DummyPolicyManager{rest: rest})
+	_, users = test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	require.Len(rest.T(), users.Data, 1)
+	assert.Equal(rest.T(), rest.testIdentity2.ID.String(), *users.Data[0].ID)
+}
+
+func (rest *TestCollaboratorsREST) TestListCollaboratorsReloadedWhenCacheExpiredOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+	// when
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc := goa.New("Collaborators-Service")
+	ctrl := NewCollaboratorsController(svc, rest.db, collaboratorsCacheConfiguration{ConfigurationData: rest.Configuration, ttl: time.Nanosecond}, &DummyPolicyManager{rest: rest})
+	// then
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	require.Len(rest.T(), users.Data, 2)
+}
+
+func (rest *TestCollaboratorsREST) TestCachedCollaboratorsUpdatedWithPolicyOk() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.checkCollaborators([]string{rest.testIdentity1.ID.String()})
+	svc, ctrl := rest.SecuredController()
+	// when
+	test.AddCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String())
+	test.UpdateCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, rest.testIdentity2.ID.String(), newUpdateCollaboratorsPayload(SpaceRoleAdmin))
+	// then the cached collaborators are listed without loading the policy again
+	rest.policy = &auth.KeycloakPolicy{}
+	_, users := test.ListCollaboratorsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, nil, nil, nil, nil, nil, nil)
+	require.Len(rest.T(), users.Data, 2)
+	assert.Equal(rest.T(), rest.testIdentity2.ID.String(), *users.Data[1].ID)
+	assert.Equal(rest.T(), SpaceRoleAdmin, users.Data[1].Meta["role"])
+}
+
 func (rest *TestCollaboratorsREST) createSpace() app.Space {
 	svc, _ := rest.SecuredController()
 	spaceCtrl := NewSpaceController(svc, rest.db, rest.Configuration, &DummyResourceManager{})
@@ -376,6 +1260,10 @@ func (s *TestSpaceAuthzService) Configuration() authz.AuthzConfiguration {
 	return nil
 }
 
+func (s *TestSpaceAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	return false, nil
+}
+
 func CreateSecuredSpace(t *testing.T, db application.DB, config SpaceConfiguration, owner account.Identity) app.Space {
 	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	svc := testsupport.ServiceAsSpaceUser("Collaborators-Service", almtoken.NewManagerWithPrivateKey(priv), owner, &TestSpaceAuthzService{owner})
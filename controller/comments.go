@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"html"
+	"unicode/utf8"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
@@ -13,7 +14,9 @@ import (
 	"github.com/almighty/almighty-core/login"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space/authz"
 	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
 )
 
 // CommentsController implements the comments resource.
@@ -26,6 +29,7 @@ type CommentsController struct {
 // CommentsControllerConfiguration the configuration for CommentsController
 type CommentsControllerConfiguration interface {
 	GetCacheControlComments() string
+	GetCommentMaxLength() int
 }
 
 // NewCommentsController creates a comments controller.
@@ -80,6 +84,9 @@ func (c *CommentsController) Update(ctx *app.UpdateCommentsContext) error {
 			return jsonapi.JSONErrorResponse(ctx, goa.NewErrorClass("forbidden", 403)("User is not the comment author"))
 		}
 
+		if err := validateCommentBody(*ctx.Payload.Data.Attributes.Body, c.config.GetCommentMaxLength()); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 		cm.Body = *ctx.Payload.Data.Attributes.Body
 		cm.Markup = rendering.NilSafeGetMarkup(ctx.Payload.Data.Attributes.Markup)
 		err = appl.Comments().Save(ctx.Context, cm, *identityID)
@@ -126,6 +133,88 @@ func (c *CommentsController) Delete(ctx *app.DeleteCommentsContext) error {
 	})
 }
 
+// Bulk comment statuses, as reported for each work item by the bulk-create action
+const (
+	BulkCommentCreated   = "created"
+	BulkCommentForbidden = "forbidden"
+	BulkCommentNotFound  = "not_found"
+)
+
+// BulkCreate posts the same comment on each of the given work items, in a single transaction, and reports
+// the result for each work item. Only the tokens granted the admin scope are allowed to post
+// bulk comments, and the work items of the spaces they have no access to are skipped.
+func (c *CommentsController) BulkCreate(ctx *app.BulkCreateCommentsContext) error {
+	identityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		// need to use the goa.NewErrorClass() func as there is no native support for 403 in goa
+		// and it is not planned to be supported yet: https://github.com/goadesign/goa/pull/1030
+		return jsonapi.JSONErrorResponse(ctx, goa.NewErrorClass("forbidden", 403)("User is not allowed to post bulk comments"))
+	}
+	attributes := ctx.Payload.Data.Attributes
+	if err := validateCommentBody(attributes.Body, c.config.GetCommentMaxLength()); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	markup := rendering.NilSafeGetMarkup(attributes.Markup)
+	results := make([]*app.BulkCommentResult, len(ctx.Payload.Data.WorkItems))
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		for i, wiID := range ctx.Payload.Data.WorkItems {
+			results[i] = &app.BulkCommentResult{WorkItemID: wiID}
+			wi, err := appl.WorkItems().LoadByID(ctx, wiID)
+			if err != nil {
+				if _, ok := errs.Cause(err).(errors.NotFoundError); ok {
+					results[i].Status = BulkCommentNotFound
+					continue
+				}
+				return err
+			}
+			authorized, err := authz.Authorize(ctx, wi.SpaceID.String())
+			if err != nil {
+				return errors.NewUnauthorizedError(err.Error())
+			}
+			if !authorized {
+				results[i].Status = BulkCommentForbidden
+				continue
+			}
+			viewer, err := authz.IsViewer(ctx, wi.SpaceID.String())
+			if err != nil {
+				return err
+			}
+			if viewer {
+				results[i].Status = BulkCommentForbidden
+				continue
+			}
+			newComment := comment.Comment{
+				ParentID:  wiID,
+				Body:      attributes.Body,
+				Markup:    markup,
+				CreatedBy: *identityID,
+			}
+			if err := appl.Comments().Create(ctx, &newComment, *identityID); err != nil {
+				return err
+			}
+			results[i].Status = BulkCommentCreated
+			results[i].Comment = ConvertComment(ctx.RequestData, newComment)
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.BulkCommentResults{Data: results})
+}
+
+// validateCommentBody returns a BadParameterError if the given comment body is
+// longer than maxLength characters. A maxLength lower than 1 disables the check.
+func validateCommentBody(body string, maxLength int) error {
+	if maxLength > 0 && utf8.RuneCountInString(body) > maxLength {
+		return errors.NewBadParameterError("data.attributes.body", fmt.Sprintf("%d characters", utf8.RuneCountInString(body))).Expected(fmt.Sprintf("at most %d characters", maxLength))
+	}
+	return nil
+}
+
 // CommentConvertFunc is a open ended function to add additional links/data/relations to a Comment during
 // conversion from internal to API
 type CommentConvertFunc func(*goa.RequestData, *comment.Comment, *app.Comment)
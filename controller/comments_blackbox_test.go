@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"net/http"
@@ -21,11 +22,13 @@ import (
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/space/authz"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
 	"github.com/almighty/almighty-core/workitem"
 
 	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -76,7 +79,7 @@ func (s *CommentsSuite) unsecuredController() (*goa.Service, *CommentsController
 func (s *CommentsSuite) securedControllers(identity account.Identity) (*goa.Service, *WorkitemController, *WorkItemCommentsController, *CommentsController) {
 	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	svc := testsupport.ServiceAsUser("Comment-Service", almtoken.NewManagerWithPrivateKey(priv), identity)
-	workitemCtrl := NewWorkitemController(svc, s.db, s.Configuration)
+	workitemCtrl := NewWorkitemController(svc, s.db, s.Configuration, nil)
 	workitemCommentsCtrl := NewWorkItemCommentsController(svc, s.db, s.Configuration)
 	commentsCtrl := NewCommentsController(svc, s.db, s.Configuration)
 	return svc, workitemCtrl, workitemCommentsCtrl, commentsCtrl
@@ -326,6 +329,28 @@ func (s *CommentsSuite) TestUpdateCommentWithOtherUser() {
 	test.UpdateCommentsForbidden(s.T(), userSvc.Context, userSvc, commentsCtrl, *c.Data.ID, updateCommentPayload)
 }
 
+func (s *CommentsSuite) TestCreateCommentTooLong() {
+	// given
+	wID := s.createWorkItem(s.testIdentity)
+	body := strings.Repeat("a", s.Configuration.GetCommentMaxLength()+1)
+	createWorkItemCommentPayload := s.newCreateWorkItemCommentsPayload(body, &plaintextMarkup)
+	// when/then
+	userSvc, _, workitemCommentsCtrl, _ := s.securedControllers(s.testIdentity)
+	test.CreateWorkItemCommentsBadRequest(s.T(), userSvc.Context, userSvc, workitemCommentsCtrl, space.SystemSpace.String(), wID, createWorkItemCommentPayload)
+}
+
+func (s *CommentsSuite) TestUpdateCommentTooLong() {
+	// given
+	wID := s.createWorkItem(s.testIdentity)
+	c := s.createWorkItemComment(s.testIdentity, wID, "body", &plaintextMarkup)
+	// when
+	body := strings.Repeat("a", s.Configuration.GetCommentMaxLength()+1)
+	updateCommentPayload := s.newUpdateCommentsPayload(body, &plaintextMarkup)
+	// when/then
+	userSvc, _, _, commentsCtrl := s.securedControllers(s.testIdentity)
+	test.UpdateCommentsBadRequest(s.T(), userSvc.Context, userSvc, commentsCtrl, *c.Data.ID, updateCommentPayload)
+}
+
 func (s *CommentsSuite) TestDeleteCommentWithSameAuthenticatedUser() {
 	// given
 	wID := s.createWorkItem(s.testIdentity)
@@ -349,3 +374,108 @@ func (s *CommentsSuite) TestDeleteCommentWithOtherAuthenticatedUser() {
 	userSvc, _, _, commentsCtrl := s.securedControllers(s.testIdentity2)
 	test.DeleteCommentsForbidden(s.T(), userSvc.Context, userSvc, commentsCtrl, *c.Data.ID)
 }
+
+// systemSpaceAuthzService only authorizes the operations in the system space
+type systemSpaceAuthzService struct{}
+
+func (s *systemSpaceAuthzService) Authorize(ctx context.Context, endpoint string, spaceID string) (bool, error) {
+	return spaceID == space.SystemSpace.String(), nil
+}
+
+func (s *systemSpaceAuthzService) Configuration() authz.AuthzConfiguration {
+	return nil
+}
+
+func (s *systemSpaceAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	return false, nil
+}
+
+func (s *CommentsSuite) securedBulkController(identity account.Identity, admins ...account.Identity) (*goa.Service, *CommentsController) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsSpaceUser("Comment-Service", almtoken.NewManagerWithPrivateKey(priv), identity, &systemSpaceAuthzService{})
+	for _, admin := range admins {
+		if uuid.Equal(admin.ID, identity.ID) {
+			svc.Context = testsupport.WithScopes(svc.Context, identity, almtoken.ScopeAdminUsers)
+		}
+	}
+	return svc, NewCommentsController(svc, s.db, s.Configuration)
+}
+
+func newBulkCreateCommentsPayload(body string, workItemIDs ...string) *app.BulkCreateCommentsPayload {
+	return &app.BulkCreateCommentsPayload{
+		Data: &app.BulkCreateCommentsData{
+			Attributes: &app.CreateCommentAttributes{
+				Body:   body,
+				Markup: &markdownMarkup,
+			},
+			WorkItems: workItemIDs,
+		},
+	}
+}
+
+func (s *CommentsSuite) TestBulkCreateCommentsOK() {
+	// given two work items in the system space and one in a space the admin has no access to
+	wID1 := s.createWorkItem(s.testIdentity)
+	wID2 := s.createWorkItem(s.testIdentity)
+	otherSpace, err := s.db.Spaces().Create(context.Background(), &space.Space{
+		Name:    "TestBulkCreateComments-" + uuid.NewV4().String(),
+		OwnerId: s.testIdentity2.ID,
+	})
+	require.Nil(s.T(), err)
+	forbiddenWI, err := s.db.WorkItems().Create(context.Background(), otherSpace.ID, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "work item title",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.testIdentity2.ID)
+	require.Nil(s.T(), err)
+	forbiddenID := forbiddenWI.ID
+	unknownID := "999999999"
+	svc, ctrl := s.securedBulkController(s.testIdentity, s.testIdentity)
+	// when
+	payload := newBulkCreateCommentsPayload("release notes", wID1, forbiddenID, unknownID, wID2)
+	_, result := test.BulkCreateCommentsOK(s.T(), svc.Context, svc, ctrl, payload)
+	// then
+	require.Len(s.T(), result.Data, 4)
+	for i, wID := range []string{wID1, forbiddenID, unknownID, wID2} {
+		assert.Equal(s.T(), wID, result.Data[i].WorkItemID)
+	}
+	assert.Equal(s.T(), BulkCommentCreated, result.Data[0].Status)
+	assertComment(s.T(), result.Data[0].Comment, s.testIdentity, "release notes", markdownMarkup)
+	assert.Equal(s.T(), BulkCommentForbidden, result.Data[1].Status)
+	assert.Nil(s.T(), result.Data[1].Comment)
+	assert.Equal(s.T(), BulkCommentNotFound, result.Data[2].Status)
+	assert.Nil(s.T(), result.Data[2].Comment)
+	assert.Equal(s.T(), BulkCommentCreated, result.Data[3].Status)
+	assertComment(s.T(), result.Data[3].Comment, s.testIdentity, "release notes", markdownMarkup)
+	// the work item of the forbidden space is left untouched
+	count, err := s.db.Comments().Count(context.Background(), forbiddenWI.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, count)
+	count, err = s.db.Comments().Count(context.Background(), wID2)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *CommentsSuite) TestBulkCreateCommentsNotAdminForbidden() {
+	// given
+	wID := s.createWorkItem(s.testIdentity)
+	svc, ctrl := s.securedBulkController(s.testIdentity2, s.testIdentity)
+	// when/then
+	test.BulkCreateCommentsForbidden(s.T(), svc.Context, svc, ctrl, newBulkCreateCommentsPayload("release notes", wID))
+}
+
+func (s *CommentsSuite) TestBulkCreateCommentsByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	wID := s.createWorkItem(s.testIdentity)
+	svc, ctrl := s.securedBulkController(s.testIdentity, s.testIdentity)
+	impersonatedBy(svc, s.testIdentity2)
+	// when/then
+	test.BulkCreateCommentsForbidden(s.T(), svc.Context, svc, ctrl, newBulkCreateCommentsPayload("release notes", wID))
+}
+
+func (s *CommentsSuite) TestBulkCreateCommentsWithoutAuth() {
+	// given
+	wID := s.createWorkItem(s.testIdentity)
+	svc, ctrl := s.unsecuredController()
+	// when/then
+	test.BulkCreateCommentsUnauthorized(s.T(), svc.Context, svc, ctrl, newBulkCreateCommentsPayload("release notes", wID))
+}
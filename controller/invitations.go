@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/space"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// InvitationsController implements the invitations resource.
+type InvitationsController struct {
+	*goa.Controller
+	db            application.DB
+	policyManager auth.AuthzPolicyManager
+}
+
+// NewInvitationsController creates an invitations controller.
+func NewInvitationsController(service *goa.Service, db application.DB, policyManager auth.AuthzPolicyManager) *InvitationsController {
+	return &InvitationsController{Controller: service.NewController("InvitationsController"), db: db, policyManager: policyManager}
+}
+
+// Accept runs the accept action: the invitee is added to the space policy with the role of the invitation
+func (c *InvitationsController) Accept(ctx *app.AcceptInvitationsContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	var invitation *space.Invitation
+	var currentEmail string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		invitation, err = appl.Invitations().Load(ctx, ctx.InvitationID)
+		if err != nil {
+			return err
+		}
+		identities, err := appl.Identities().Query(account.IdentityFilterByID(*currentIdentityID), account.IdentityWithUser())
+		if err != nil {
+			return err
+		}
+		if len(identities) == 0 {
+			return errors.NewNotFoundError("identity", currentIdentityID.String())
+		}
+		currentEmail = identities[0].User.Email
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if invitation.State != space.InvitationStatePending {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("invitationID", ctx.InvitationID).Expected("a pending invitation"))
+	}
+	invited := invitation.InviteeID.Valid && uuid.Equal(invitation.InviteeID.UUID, *currentIdentityID)
+	if !invitation.InviteeID.Valid && invitation.InviteeEmail != nil && currentEmail != "" {
+		invited = strings.EqualFold(*invitation.InviteeEmail, currentEmail)
+	}
+	if !invited {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not the invitee of invitation %s", *currentIdentityID, invitation.ID)))
+		return ctx.Forbidden(jerrors)
+	}
+	policy, pat, err := loadSpacePolicy(ctx, c.db, c.policyManager, ctx.RequestData, invitation.SpaceID.String())
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	identityID := currentIdentityID.String()
+	updated := policy.AddUserToPolicy(identityID)
+	if policy.SetUserRole(identityID, invitation.Role) {
+		updated = true
+	}
+	if updated {
+		if err := c.policyManager.UpdatePolicy(ctx, ctx.RequestData, *policy, *pat); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+		}
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		invitation.State = space.InvitationStateAccepted
+		invitation.InviteeID = account.NullUUID{UUID: *currentIdentityID, Valid: true}
+		return appl.Invitations().Save(ctx, invitation)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if updated {
+		if err := cachePolicyCollaborators(ctx, c.db, invitation.SpaceID, policy); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+	}
+	if err := touchSpaceResource(ctx, c.db, invitation.SpaceID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id":      invitation.SpaceID,
+		"invitation_id": invitation.ID,
+		"identity_id":   currentIdentityID,
+		"role":          invitation.Role,
+	}, "invitation accepted")
+	return ctx.OK(&app.SpaceRole{
+		SpaceID:    invitation.SpaceID,
+		IdentityID: *currentIdentityID,
+		Role:       invitation.Role,
+	})
+}
@@ -0,0 +1,186 @@
+package controller_test
+
+import (
+	"context"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (rest *TestCollaboratorsREST) SecuredInvitationsControllers(identity account.Identity, mailer *testMailer) (*goa.Service, *SpaceInvitationsController, *InvitationsController) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+
+	svc := testsupport.ServiceAsSpaceUser("Invitations-Service", almtoken.NewManagerWithPrivateKey(priv), identity, &DummySpaceAuthzService{rest})
+	policyManager := &DummyPolicyManager{rest: rest}
+	return svc, NewSpaceInvitationsController(svc, rest.db, policyManager, mailer), NewInvitationsController(svc, rest.db, policyManager)
+}
+
+func newCreateSpaceInvitationsPayload(identityID *uuid.UUID, email *string, role *string) *app.CreateSpaceInvitationsPayload {
+	return &app.CreateSpaceInvitationsPayload{
+		Data: &app.Invitation{
+			Type: "invitations",
+			Attributes: &app.InvitationAttributes{
+				IdentityID: identityID,
+				Email:      email,
+				Role:       role,
+			},
+		},
+	}
+}
+
+// createIdentityWithEmail creates an identity along with its user, which has the given email address
+func (rest *TestCollaboratorsREST) createIdentityWithEmail(email string) account.Identity {
+	user := account.User{Email: email, FullName: "Test Invitee"}
+	require.Nil(rest.T(), account.NewUserRepository(rest.DB).Create(context.Background(), &user))
+	identity := account.Identity{
+		Username:     "TestInvitations-" + uuid.NewV4().String(),
+		ProviderType: account.KeycloakIDP,
+		UserID:       account.NullUUID{UUID: user.ID, Valid: true},
+	}
+	require.Nil(rest.T(), account.NewIdentityRepository(rest.DB).Create(context.Background(), &identity))
+	return identity
+}
+
+func (rest *TestCollaboratorsREST) TestInviteIdentityAndAcceptOK() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	mailer := &testMailer{}
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, mailer)
+	role := SpaceRoleAdmin
+	// when
+	_, created := test.CreateSpaceInvitationsCreated(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(&rest.testIdentity2.ID, nil, &role))
+	// then the invitee is notified, but not yet a collaborator
+	require.NotNil(rest.T(), created.Data.ID)
+	assert.Equal(rest.T(), "pending", *created.Data.Attributes.State)
+	assert.Equal(rest.T(), rest.testIdentity1.ID, *created.Data.Attributes.InviterID)
+	assert.False(rest.T(), rest.policy.HasUser(rest.testIdentity2.ID.String()))
+	unread, err := rest.db.Notifications().CountUnread(context.Background(), rest.testIdentity2.ID)
+	require.Nil(rest.T(), err)
+	assert.Equal(rest.T(), 1, unread)
+	_, invitations := test.ListSpaceInvitationsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.Len(rest.T(), invitations.Data, 1)
+	// when
+	svc, _, acceptCtrl := rest.SecuredInvitationsControllers(rest.testIdentity2, mailer)
+	_, spaceRole := test.AcceptInvitationsOK(rest.T(), svc.Context, svc, acceptCtrl, *created.Data.ID)
+	// then
+	assert.Equal(rest.T(), SpaceRoleAdmin, spaceRole.Role)
+	assert.Equal(rest.T(), rest.testIdentity2.ID, spaceRole.IdentityID)
+	assert.Equal(rest.T(), SpaceRoleAdmin, rest.policy.UserRole(rest.testIdentity2.ID.String()))
+	svc, ctrl, _ = rest.SecuredInvitationsControllers(rest.testIdentity1, mailer)
+	_, invitations = test.ListSpaceInvitationsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	assert.Empty(rest.T(), invitations.Data)
+	// and the invitation can't be accepted twice
+	svc, _, acceptCtrl = rest.SecuredInvitationsControllers(rest.testIdentity2, mailer)
+	test.AcceptInvitationsBadRequest(rest.T(), svc.Context, svc, acceptCtrl, *created.Data.ID)
+}
+
+func (rest *TestCollaboratorsREST) TestInviteEmailAndAcceptOK() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	email := "TestInvitations-" + uuid.NewV4().String() + "@example.com"
+	mailer := &testMailer{}
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, mailer)
+	// when
+	_, created := test.CreateSpaceInvitationsCreated(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, &email, nil))
+	// then
+	assert.Equal(rest.T(), SpaceRoleContributor, *created.Data.Attributes.Role)
+	require.Len(rest.T(), mailer.sent, 1)
+	assert.Contains(rest.T(), mailer.sent[0].body, created.Data.ID.String())
+	// when another user accepts the invitation
+	svc, _, acceptCtrl := rest.SecuredInvitationsControllers(rest.testIdentity2, mailer)
+	test.AcceptInvitationsForbidden(rest.T(), svc.Context, svc, acceptCtrl, *created.Data.ID)
+	// then
+	assert.False(rest.T(), rest.policy.HasUser(rest.testIdentity2.ID.String()))
+	// when the user with the invited email address accepts the invitation
+	invitee := rest.createIdentityWithEmail(email)
+	svc, _, acceptCtrl = rest.SecuredInvitationsControllers(invitee, mailer)
+	_, spaceRole := test.AcceptInvitationsOK(rest.T(), svc.Context, svc, acceptCtrl, *created.Data.ID)
+	// then
+	assert.Equal(rest.T(), SpaceRoleContributor, spaceRole.Role)
+	assert.True(rest.T(), rest.policy.HasUser(invitee.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestInviteSameEmailTwiceConflict() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	email := "TestInvitations-" + uuid.NewV4().String() + "@example.com"
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+	test.CreateSpaceInvitationsCreated(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, &email, nil))
+
+	test.CreateSpaceInvitationsConflict(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, &email, nil))
+}
+
+func (rest *TestCollaboratorsREST) TestInviteCollaboratorConflict() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+
+	test.CreateSpaceInvitationsConflict(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(&rest.testIdentity2.ID, nil, nil))
+}
+
+func (rest *TestCollaboratorsREST) TestInviteWithoutInviteeBadRequest() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+
+	test.CreateSpaceInvitationsBadRequest(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, nil, nil))
+}
+
+func (rest *TestCollaboratorsREST) TestInviteForbiddenForContributor() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	email := "TestInvitations-" + uuid.NewV4().String() + "@example.com"
+	mailer := &testMailer{}
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity2, mailer)
+	// when
+	test.CreateSpaceInvitationsForbidden(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, &email, nil))
+	// then
+	assert.Empty(rest.T(), mailer.sent)
+	test.ListSpaceInvitationsForbidden(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+}
+
+func (rest *TestCollaboratorsREST) TestRevokeInvitationOK() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+	_, created := test.CreateSpaceInvitationsCreated(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(&rest.testIdentity2.ID, nil, nil))
+	// when
+	test.RevokeSpaceInvitationsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID, *created.Data.ID)
+	// then the revoked invitation can't be accepted
+	_, invitations := test.ListSpaceInvitationsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	assert.Empty(rest.T(), invitations.Data)
+	test.RevokeSpaceInvitationsBadRequest(rest.T(), svc.Context, svc, ctrl, rest.spaceID, *created.Data.ID)
+	svc, _, acceptCtrl := rest.SecuredInvitationsControllers(rest.testIdentity2, &testMailer{})
+	test.AcceptInvitationsBadRequest(rest.T(), svc.Context, svc, acceptCtrl, *created.Data.ID)
+	assert.False(rest.T(), rest.policy.HasUser(rest.testIdentity2.ID.String()))
+}
+
+func (rest *TestCollaboratorsREST) TestRevokeInvitationForbiddenForContributor() {
+	// given
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	rest.policy.AddUserToPolicy(rest.testIdentity2.ID.String())
+	email := "TestInvitations-" + uuid.NewV4().String() + "@example.com"
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+	_, created := test.CreateSpaceInvitationsCreated(rest.T(), svc.Context, svc, ctrl, rest.spaceID, newCreateSpaceInvitationsPayload(nil, &email, nil))
+	contributorSvc, contributorCtrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity2, &testMailer{})
+	// when
+	test.RevokeSpaceInvitationsForbidden(rest.T(), contributorSvc.Context, contributorSvc, contributorCtrl, rest.spaceID, *created.Data.ID)
+	// then the invitation is still pending
+	_, invitations := test.ListSpaceInvitationsOK(rest.T(), svc.Context, svc, ctrl, rest.spaceID)
+	require.Len(rest.T(), invitations.Data, 1)
+}
+
+func (rest *TestCollaboratorsREST) TestRevokeUnknownInvitationNotFound() {
+	rest.policy.AddUserToPolicy(rest.testIdentity1.ID.String())
+	svc, ctrl, _ := rest.SecuredInvitationsControllers(rest.testIdentity1, &testMailer{})
+
+	test.RevokeSpaceInvitationsNotFound(rest.T(), svc.Context, svc, ctrl, rest.spaceID, uuid.NewV4())
+}
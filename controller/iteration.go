@@ -28,6 +28,7 @@ type IterationController struct {
 
 type IterationControllerConfiguration interface {
 	GetCacheControlIterations() string
+	GetIterationMaxDepth() int
 }
 
 // NewIterationController creates a iteration controller.
@@ -59,6 +60,9 @@ func (c *IterationController) CreateChild(ctx *app.CreateChildIterationContext)
 		}
 
 		childPath := append(parent.Path, parent.ID)
+		if maxDepth := c.config.GetIterationMaxDepth(); len(childPath) > maxDepth {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("iterationID", ctx.IterationID).Expected(fmt.Sprintf("an iteration less than %d levels below the root iteration", maxDepth)))
+		}
 
 		newItr := iteration.Iteration{
 			SpaceID: parent.SpaceID,
@@ -87,6 +87,21 @@ func (rest *TestIterationREST) TestSuccessCreateChildIteration() {
 	assert.Equal(rest.T(), 0, created.Data.Relationships.Workitems.Meta["closed"])
 }
 
+func (rest *TestIterationREST) TestCreateChildIterationUpToMaxDepth() {
+	// given an iteration right below the root iteration
+	parent := createSpaceAndIteration(rest.T(), rest.db)
+	svc, _ := rest.SecuredController()
+	ctrl := NewIterationController(svc, rest.db, maxDepthConfiguration{ConfigurationData: rest.Configuration, maxDepth: 2})
+	name := "TestCreateChildIterationUpToMaxDepth-2"
+	// when
+	_, created := test.CreateChildIterationCreated(rest.T(), svc.Context, svc, ctrl, parent.ID.String(), getChildIterationPayload(&name))
+	// then
+	require.NotNil(rest.T(), created)
+	// when/then
+	name = "TestCreateChildIterationUpToMaxDepth-3"
+	test.CreateChildIterationBadRequest(rest.T(), svc.Context, svc, ctrl, *created.Data.ID, getChildIterationPayload(&name))
+}
+
 func (rest *TestIterationREST) TestFailCreateChildIterationMissingName() {
 	// given
 	parentID := createSpaceAndIteration(rest.T(), rest.db).ID
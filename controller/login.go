@@ -148,6 +148,12 @@ func (c *LoginController) Refresh(ctx *app.RefreshLoginContext) error {
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
+	if err := c.auth.RefreshSession(ctx, *token); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err,
+		}, "failed to refresh the session")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 
 	entitlementEndpoint, err := c.configuration.GetKeycloakEndpointEntitlement(ctx.RequestData)
 	if err != nil {
@@ -10,6 +10,8 @@ import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/app/test"
 	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	config "github.com/almighty/almighty-core/configuration"
 	"github.com/almighty/almighty-core/gormapplication"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/gormtestsupport"
@@ -60,14 +62,14 @@ func (rest *TestLoginREST) SecuredController() (*goa.Service, *LoginController)
 	return svc, NewLoginController(svc, loginService, loginService.TokenManager, rest.Configuration)
 }
 
-func newTestKeycloakOAuthProvider(db application.DB, configuration loginConfiguration) *login.KeycloakOAuthProvider {
+func newTestKeycloakOAuthProvider(db application.DB, configuration *config.ConfigurationData) *login.KeycloakOAuthProvider {
 	publicKey, err := token.ParsePublicKey([]byte(token.RSAPublicKey))
 	if err != nil {
 		panic(err)
 	}
 
 	tokenManager := token.NewManager(publicKey)
-	return login.NewKeycloakOAuthProvider(db.Identities(), db.Users(), tokenManager, db)
+	return login.NewKeycloakOAuthProvider(db.Identities(), db.Users(), tokenManager, db, configuration)
 }
 
 func (rest *TestLoginREST) TestAuthorizeLoginOK() {
@@ -180,3 +182,7 @@ func (t TestLoginService) LinkSession(ctx *app.LinksessionLoginContext, brokerEn
 func (t TestLoginService) LinkCallback(ctx *app.LinkcallbackLoginContext, brokerEndpoint string, clientID string) error {
 	return ctx.TemporaryRedirect()
 }
+
+func (t TestLoginService) RefreshSession(ctx context.Context, token auth.Token) error {
+	return nil
+}
@@ -1,11 +1,15 @@
 package controller
 
 import (
+	"strings"
+
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/token"
 	"github.com/goadesign/goa"
 )
 
@@ -18,16 +22,19 @@ type logoutConfiguration interface {
 type LogoutController struct {
 	*goa.Controller
 	logoutService login.LogoutService
+	db            application.DB
+	tokenManager  token.Manager
 	configuration logoutConfiguration
 }
 
 // NewLogoutController creates a logout controller.
-func NewLogoutController(service *goa.Service, logoutService *login.KeycloakLogoutService, configuration logoutConfiguration) *LogoutController {
-	return &LogoutController{Controller: service.NewController("LogoutController"), logoutService: logoutService, configuration: configuration}
+func NewLogoutController(service *goa.Service, logoutService *login.KeycloakLogoutService, db application.DB, tokenManager token.Manager, configuration logoutConfiguration) *LogoutController {
+	return &LogoutController{Controller: service.NewController("LogoutController"), logoutService: logoutService, db: db, tokenManager: tokenManager, configuration: configuration}
 }
 
-// Logout runs the logout action.
+// Logout runs the logout action. The session of the bearer token of the request, if any, is revoked.
 func (c *LogoutController) Logout(ctx *app.LogoutLogoutContext) error {
+	c.revokeSession(ctx)
 	logoutEndpoint, err := c.configuration.GetKeycloakEndpointLogout(ctx.RequestData)
 	if err != nil {
 		log.Error(ctx, map[string]interface{}{
@@ -41,3 +48,18 @@ func (c *LogoutController) Logout(ctx *app.LogoutLogoutContext) error {
 	}
 	return c.logoutService.Logout(ctx, logoutEndpoint, whitelist)
 }
+
+// revokeSession revokes the session of the bearer token of the request, if any. The logout goes on
+// when the session cannot be revoked, since the Keycloak session is ended anyway.
+func (c *LogoutController) revokeSession(ctx *app.LogoutLogoutContext) {
+	authorization := ctx.RequestData.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return
+	}
+	err := login.RevokeSession(ctx, c.db, c.tokenManager.PublicKey(), strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err,
+		}, "unable to revoke the session")
+	}
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/login"
+
+	"github.com/goadesign/goa"
+)
+
+// NotificationController implements the notification resource.
+type NotificationController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewNotificationController creates a notification controller.
+func NewNotificationController(service *goa.Service, db application.DB) *NotificationController {
+	return &NotificationController{Controller: service.NewController("NotificationController"), db: db}
+}
+
+// UnreadCount returns the number of unread notifications of the authenticated user
+func (c *NotificationController) UnreadCount(ctx *app.UnreadCountNotificationContext) error {
+	identityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		count, err := appl.Notifications().CountUnread(ctx, *identityID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.NotificationUnreadCount{Unread: count})
+	})
+}
+
+// MarkRead marks a notification of the authenticated user as read and returns the remaining number of unread notifications
+func (c *NotificationController) MarkRead(ctx *app.MarkReadNotificationContext) error {
+	identityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		err := appl.Notifications().MarkRead(ctx, *identityID, ctx.NotificationID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		count, err := appl.Notifications().CountUnread(ctx, *identityID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.NotificationUnreadCount{Unread: count})
+	})
+}
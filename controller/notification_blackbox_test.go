@@ -0,0 +1,114 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/notification"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestRunNotificationREST(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestNotificationREST{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestNotificationREST struct {
+	gormtestsupport.DBTestSuite
+	db       *gormapplication.GormDB
+	identity account.Identity
+	clean    func()
+}
+
+func (rest *TestNotificationREST) SetupTest() {
+	rest.db = gormapplication.NewGormDB(rest.DB)
+	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
+	identity, err := testsupport.CreateTestIdentity(rest.DB, "TestNotificationREST-"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(rest.T(), err)
+	rest.identity = identity
+}
+
+func (rest *TestNotificationREST) TearDownTest() {
+	rest.clean()
+}
+
+func (rest *TestNotificationREST) SecuredController(identity account.Identity) (*goa.Service, *NotificationController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Notification-Service", almtoken.NewManager(pub), identity)
+	return svc, NewNotificationController(svc, rest.db)
+}
+
+func (rest *TestNotificationREST) UnSecuredController() (*goa.Service, *NotificationController) {
+	svc := goa.New("Notification-Service")
+	return svc, NewNotificationController(svc, rest.db)
+}
+
+func (rest *TestNotificationREST) createNotification(identityID uuid.UUID) *notification.Notification {
+	n, err := rest.db.Notifications().Create(context.Background(), &notification.Notification{IdentityID: identityID, Message: "something happened"})
+	require.Nil(rest.T(), err)
+	return n
+}
+
+func (rest *TestNotificationREST) TestUnreadCountOK() {
+	// given
+	rest.createNotification(rest.identity.ID)
+	rest.createNotification(rest.identity.ID)
+	otherIdentity, err := testsupport.CreateTestIdentity(rest.DB, "TestNotificationREST-other-"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(rest.T(), err)
+	rest.createNotification(otherIdentity.ID)
+	svc, ctrl := rest.SecuredController(rest.identity)
+	// when
+	_, result := test.UnreadCountNotificationOK(rest.T(), svc.Context, svc, ctrl)
+	// then
+	assert.Equal(rest.T(), 2, result.Unread)
+}
+
+func (rest *TestNotificationREST) TestMarkReadDecrementsUnreadCount() {
+	// given
+	n1 := rest.createNotification(rest.identity.ID)
+	rest.createNotification(rest.identity.ID)
+	svc, ctrl := rest.SecuredController(rest.identity)
+	// when
+	_, result := test.MarkReadNotificationOK(rest.T(), svc.Context, svc, ctrl, n1.ID)
+	// then
+	assert.Equal(rest.T(), 1, result.Unread)
+	_, result = test.UnreadCountNotificationOK(rest.T(), svc.Context, svc, ctrl)
+	assert.Equal(rest.T(), 1, result.Unread)
+	// marking the same notification again has no effect
+	_, result = test.MarkReadNotificationOK(rest.T(), svc.Context, svc, ctrl, n1.ID)
+	assert.Equal(rest.T(), 1, result.Unread)
+}
+
+func (rest *TestNotificationREST) TestMarkReadOfOtherIdentityNotFound() {
+	// given
+	otherIdentity, err := testsupport.CreateTestIdentity(rest.DB, "TestNotificationREST-other-"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(rest.T(), err)
+	n := rest.createNotification(otherIdentity.ID)
+	svc, ctrl := rest.SecuredController(rest.identity)
+	// when/then
+	test.MarkReadNotificationNotFound(rest.T(), svc.Context, svc, ctrl, n.ID)
+	otherSvc, otherCtrl := rest.SecuredController(otherIdentity)
+	_, result := test.UnreadCountNotificationOK(rest.T(), otherSvc.Context, otherSvc, otherCtrl)
+	assert.Equal(rest.T(), 1, result.Unread)
+}
+
+func (rest *TestNotificationREST) TestUnreadCountUnauthorized() {
+	// given
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.UnreadCountNotificationUnauthorized(rest.T(), svc.Context, svc, ctrl)
+}
@@ -36,9 +36,12 @@ func (s *MarkupRenderingSuite) TearDownSuite() {
 func (s *MarkupRenderingSuite) SetupTest() {
 	s.svc = goa.New("Rendering-service-test")
 	s.controller = NewRenderController(s.svc)
+	// allows embedding PNG images from a single host
+	rendering.SetImagePolicy(rendering.NewImagePolicy([]string{"images.example.com"}, []string{"image/png"}))
 }
 
 func (s *MarkupRenderingSuite) TearDownTest() {
+	rendering.SetImagePolicy(rendering.NewImagePolicy(nil, nil))
 }
 
 func (s *MarkupRenderingSuite) TestRenderPlainText() {
@@ -86,3 +89,20 @@ func (s *MarkupRenderingSuite) TestRenderUnsupportedMarkup() {
 	// when/then
 	test.RenderRenderBadRequest(s.T(), s.svc.Context, s.svc, s.controller, &payload)
 }
+
+func (s *MarkupRenderingSuite) TestRenderMarkdownWithImages() {
+	// given
+	payload := app.MarkupRenderingPayload{Data: &app.MarkupRenderingPayloadData{
+		Type: RenderingType,
+		Attributes: &app.MarkupRenderingPayloadDataAttributes{
+			Content: "![allowed](https://images.example.com/foo.png) ![disallowed](https://evil.example.com/foo.png)",
+			Markup:  rendering.SystemMarkupMarkdown,
+		}}}
+	// when
+	_, result := test.RenderRenderOK(s.T(), s.svc.Context, s.svc, s.controller, &payload)
+	// then
+	require.NotNil(s.T(), result)
+	require.NotNil(s.T(), result.Data)
+	assert.Contains(s.T(), result.Data.Attributes.RenderedContent, "https://images.example.com/foo.png")
+	assert.NotContains(s.T(), result.Data.Attributes.RenderedContent, "evil.example.com")
+}
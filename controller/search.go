@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
@@ -10,12 +11,15 @@ import (
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/workitem"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
+	"golang.org/x/net/context"
 )
 
 type searchConfiguration interface {
 	GetHTTPAddress() string
+	IsSearchQueryCoalescingEnabled() bool
 }
 
 // SearchController implements the search resource.
@@ -23,6 +27,7 @@ type SearchController struct {
 	*goa.Controller
 	db            application.DB
 	configuration searchConfiguration
+	queries       *search.QueryGroup
 }
 
 // NewSearchController creates a search controller.
@@ -30,7 +35,11 @@ func NewSearchController(service *goa.Service, db application.DB, configuration
 	if db == nil {
 		panic("db must not be nil")
 	}
-	return &SearchController{Controller: service.NewController("SearchController"), db: db, configuration: configuration}
+	ctrl := &SearchController{Controller: service.NewController("SearchController"), db: db, configuration: configuration}
+	if configuration.IsSearchQueryCoalescingEnabled() {
+		ctrl.queries = search.NewQueryGroup()
+	}
+	return ctrl
 }
 
 // Show runs the show action.
@@ -52,8 +61,8 @@ func (c *SearchController) Show(ctx *app.ShowSearchContext) error {
 
 	return application.Transactional(c.db, func(appl application.Application) error {
 		//return transaction.Do(c.ts, func() error {
-		result, c, err := appl.SearchItems().SearchFullText(ctx.Context, ctx.Q, &offset, &limit, ctx.SpaceID)
-		count := int(c)
+		result, totalCount, err := c.searchFullText(ctx, appl, offset, limit)
+		count := int(totalCount)
 		if err != nil {
 			cause := errs.Cause(err)
 			switch cause.(type) {
@@ -80,6 +89,41 @@ func (c *SearchController) Show(ctx *app.ShowSearchContext) error {
 	})
 }
 
+// searchFullText runs the full text search of the given request. When query coalescing is enabled,
+// identical concurrent searches are executed only once and share the same result: the shared search then
+// runs in its own transaction and is not cancelled along with the request which started it, since the
+// other requests waiting for its result would fail as well.
+func (c *SearchController) searchFullText(ctx *app.ShowSearchContext, appl application.Application, offset, limit int) ([]workitem.WorkItem, uint64, error) {
+	if c.queries == nil {
+		return appl.SearchItems().SearchFullText(ctx.Context, ctx.Q, &offset, &limit, ctx.SpaceID)
+	}
+	spaceID := ""
+	if ctx.SpaceID != nil {
+		spaceID = *ctx.SpaceID
+	}
+	key := fmt.Sprintf("%s|%d|%d|%s", ctx.Q, offset, limit, spaceID)
+	return c.queries.Do(key, func() ([]workitem.WorkItem, uint64, error) {
+		var result []workitem.WorkItem
+		var count uint64
+		err := application.Transactional(c.db, func(appl application.Application) error {
+			var err error
+			result, count, err = appl.SearchItems().SearchFullText(detachedContext{ctx.Context}, ctx.Q, &offset, &limit, ctx.SpaceID)
+			return err
+		})
+		return result, count, err
+	})
+}
+
+// detachedContext keeps the values of its parent context, such as the request ID logged along with the errors,
+// but is never cancelled nor expires
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
 // Spaces runs the space search action.
 func (c *SearchController) Spaces(ctx *app.SpacesSearchContext) error {
 	q := ctx.Q
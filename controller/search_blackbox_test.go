@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 
 	"github.com/almighty/almighty-core/account"
@@ -339,7 +340,7 @@ func (s *searchBlackBoxTest) verifySearchByKnownURLs(wi *app.WorkItemSingle, hos
 // Uses helper functions verifySearchByKnownURLs, searchByURL, getWICreatePayload
 func (s *searchBlackBoxTest) TestAutoRegisterHostURL() {
 	// service := getServiceAsUser(s.testIdentity)
-	wiCtrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	wiCtrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 	// create a WI, search by `list view URL` of newly created item
 	newWI := s.getWICreatePayload()
 	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, wiCtrl, newWI.Data.Relationships.Space.Data.ID.String(), newWI)
@@ -502,3 +503,55 @@ func (s *searchBlackBoxTest) TestSearchWorkItemsWithoutSpaceContext() {
 	require.NotEmpty(s.T(), sr.Data)
 	assert.Len(s.T(), sr.Data, 15)
 }
+
+// coalescingSearchConfiguration overrides the configuration to coalesce the identical concurrent searches
+type coalescingSearchConfiguration struct {
+	*config.ConfigurationData
+}
+
+func (c coalescingSearchConfiguration) IsSearchQueryCoalescingEnabled() bool {
+	return true
+}
+
+func (s *searchBlackBoxTest) TestSearchWorkItemsCoalescedWithCancelledRequest() {
+	// given
+	_, err := s.wiRepo.Create(
+		s.ctx,
+		space.SystemSpace,
+		workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:       "specialwordforcoalescedsearch",
+			workitem.SystemDescription: nil,
+			workitem.SystemCreator:     "baijum",
+			workitem.SystemState:       workitem.SystemStateClosed,
+		},
+		s.testIdentity.ID)
+	require.Nil(s.T(), err)
+	ctrl := NewSearchController(s.svc, s.db, coalescingSearchConfiguration{s.spaceBlackBoxTestConfiguration})
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// when the same search is run concurrently, by a request cancelled by its client among others
+	q := "specialwordforcoalescedsearch"
+	spaceIDStr := space.SystemSpace.String()
+	const requests = 5
+	results := make([]*app.SearchWorkItemList, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := context.Background()
+			if i == 0 {
+				ctx = cancelledCtx
+			}
+			_, results[i] = test.ShowSearchOK(s.T(), ctx, nil, ctrl, nil, nil, q, &spaceIDStr)
+		}(i)
+	}
+	wg.Wait()
+	// then all the requests get the result of the search
+	for _, sr := range results {
+		require.NotNil(s.T(), sr)
+		require.Len(s.T(), sr.Data, 1)
+		assert.Equal(s.T(), "specialwordforcoalescedsearch", sr.Data[0].Attributes[workitem.SystemTitle])
+	}
+}
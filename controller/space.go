@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
@@ -15,6 +16,7 @@ import (
 	"github.com/almighty/almighty-core/login"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/space/authz"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -37,6 +39,14 @@ type SpaceConfiguration interface {
 	GetKeycloakClientID() string
 	GetKeycloakSecret() string
 	GetCacheControlSpaces() string
+	GetCommentMaxLength() int
+	GetWorkItemRestoreWindow() time.Duration
+	GetWorkItemBulkUpdateBatchSize() int
+	IsWorkItemAssigneeCollaboratorRequired() bool
+	IsWorkItemPayloadStrict() bool
+	GetWorkItemRemovedCollaboratorAssignments() string
+	GetWorkItemClosedIterationAssignment() string
+	IsWorkItemDuplicateAutoClosed() bool
 }
 
 // SpaceController implements the space resource.
@@ -78,6 +88,13 @@ func (c *SpaceController) Create(ctx *app.CreateSpaceContext) error {
 		if reqSpace.Attributes.Description != nil {
 			newSpace.Description = *reqSpace.Attributes.Description
 		}
+		applySpaceTheme(reqSpace.Attributes.Theme, &newSpace)
+		if reqSpace.Attributes.UniqueTitlesPerIteration != nil {
+			newSpace.UniqueTitlesPerIteration = *reqSpace.Attributes.UniqueTitlesPerIteration
+		}
+		if err := newSpace.ValidateTheme(); err != nil {
+			return err
+		}
 
 		rSpace, err = appl.Spaces().Create(ctx, &newSpace)
 		if err != nil {
@@ -227,6 +244,41 @@ func (c *SpaceController) List(ctx *app.ListSpaceContext) error {
 	return ctx.OK(&response)
 }
 
+// ListOwned runs the list-owned action.
+func (c *SpaceController) ListOwned(ctx *app.ListOwnedSpaceContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
+
+	var response app.SpaceList
+	txnErr := application.Transactional(c.db, func(appl application.Application) error {
+		spaces, cnt, err := appl.Spaces().LoadByOwner(ctx.Context, currentUser, &offset, &limit)
+		if err != nil {
+			return err
+		}
+		return ctx.ConditionalEntities(spaces, c.config.GetCacheControlSpaces, func() error {
+			count := int(cnt)
+			spaceData, err := ConvertSpacesFromModel(ctx.Context, c.db, ctx.RequestData, spaces)
+			if err != nil {
+				return err
+			}
+			response = app.SpaceList{
+				Links: &app.PagingLinks{},
+				Meta:  &app.SpaceListMeta{TotalCount: count},
+				Data:  spaceData,
+			}
+			setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(spaces), offset, limit, count)
+			return nil
+		})
+	})
+	if txnErr != nil {
+		return jsonapi.JSONErrorResponse(ctx, txnErr)
+	}
+	return ctx.OK(&response)
+}
+
 // Show runs the show action.
 func (c *SpaceController) Show(ctx *app.ShowSpaceContext) error {
 	id, err := uuid.FromString(ctx.ID)
@@ -262,6 +314,79 @@ func (c *SpaceController) Show(ctx *app.ShowSpaceContext) error {
 	return ctx.OK(&result)
 }
 
+// Statistics runs the statistics action.
+func (c *SpaceController) Statistics(ctx *app.StatisticsSpaceContext) error {
+	id, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+	var stats *space.Statistics
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		stats, err = appl.Spaces().LoadStatistics(ctx.Context, id)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.SpaceStatistics{
+		SpaceID:   id,
+		WorkItems: stats.WorkItems,
+		Comments:  stats.Comments,
+	})
+}
+
+// Settings runs the settings action.
+func (c *SpaceController) Settings(ctx *app.SettingsSpaceContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	id, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+	var s *space.Space
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		s, err = appl.Spaces().Load(ctx.Context, id)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	// the owner is always allowed, the other users must be collaborators of the space
+	if !uuid.Equal(*currentUser, s.OwnerId) {
+		authorized, err := authz.Authorize(ctx, id.String())
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+		}
+		if !authorized {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized("user is not a collaborator of the space"))
+			return ctx.Forbidden(jerrors)
+		}
+	}
+	return ctx.OK(&app.SpaceSettings{
+		SpaceID: id,
+		Theme: &app.SpaceTheme{
+			PrimaryColor: &s.ThemePrimaryColor,
+			AccentColor:  &s.ThemeAccentColor,
+			LogoURL:      &s.ThemeLogoURL,
+		},
+		UniqueTitlesPerIteration: s.UniqueTitlesPerIteration,
+		WorkItems: &app.SpaceWorkItemSettings{
+			AssigneeCollaboratorRequired:   c.config.IsWorkItemAssigneeCollaboratorRequired(),
+			RemovedCollaboratorAssignments: c.config.GetWorkItemRemovedCollaboratorAssignments(),
+			ClosedIterationAssignment:      c.config.GetWorkItemClosedIterationAssignment(),
+			StrictPayload:                  c.config.IsWorkItemPayloadStrict(),
+			DuplicateAutoClose:             c.config.IsWorkItemDuplicateAutoClosed(),
+			RestoreWindow:                  c.config.GetWorkItemRestoreWindow().String(),
+		},
+		Limits: &app.SpaceLimits{
+			CommentMaxLength:    c.config.GetCommentMaxLength(),
+			BulkUpdateBatchSize: c.config.GetWorkItemBulkUpdateBatchSize(),
+		},
+	})
+}
+
 // Update runs the update action.
 func (c *SpaceController) Update(ctx *app.UpdateSpaceContext) error {
 	currentUser, err := login.ContextIdentity(ctx)
@@ -297,6 +422,13 @@ func (c *SpaceController) Update(ctx *app.UpdateSpaceContext) error {
 		if ctx.Payload.Data.Attributes.Description != nil {
 			s.Description = *ctx.Payload.Data.Attributes.Description
 		}
+		applySpaceTheme(ctx.Payload.Data.Attributes.Theme, s)
+		if ctx.Payload.Data.Attributes.UniqueTitlesPerIteration != nil {
+			s.UniqueTitlesPerIteration = *ctx.Payload.Data.Attributes.UniqueTitlesPerIteration
+		}
+		if err := s.ValidateTheme(); err != nil {
+			return err
+		}
 
 		s, err = appl.Spaces().Save(ctx.Context, s)
 		if err != nil {
@@ -348,6 +480,23 @@ func validateUpdateSpace(ctx *app.UpdateSpaceContext) error {
 	return nil
 }
 
+// applySpaceTheme copies the theme values which are set in the given payload onto the given space.
+// An empty value resets the corresponding theme value to its default.
+func applySpaceTheme(theme *app.SpaceTheme, s *space.Space) {
+	if theme == nil {
+		return
+	}
+	if theme.PrimaryColor != nil {
+		s.ThemePrimaryColor = *theme.PrimaryColor
+	}
+	if theme.AccentColor != nil {
+		s.ThemeAccentColor = *theme.AccentColor
+	}
+	if theme.LogoURL != nil {
+		s.ThemeLogoURL = *theme.LogoURL
+	}
+}
+
 // ConvertSpaceToModel converts an `app.Space` to a `space.Space`
 func ConvertSpaceToModel(appSpace app.Space) space.Space {
 	modelSpace := space.Space{}
@@ -371,6 +520,10 @@ func ConvertSpaceToModel(appSpace app.Space) space.Space {
 		if appSpace.Attributes.Description != nil {
 			modelSpace.Description = *appSpace.Attributes.Description
 		}
+		applySpaceTheme(appSpace.Attributes.Theme, &modelSpace)
+		if appSpace.Attributes.UniqueTitlesPerIteration != nil {
+			modelSpace.UniqueTitlesPerIteration = *appSpace.Attributes.UniqueTitlesPerIteration
+		}
 	}
 	if appSpace.Relationships != nil && appSpace.Relationships.OwnedBy != nil &&
 		appSpace.Relationships.OwnedBy.Data != nil && appSpace.Relationships.OwnedBy.Data.ID != nil {
@@ -425,6 +578,12 @@ func ConvertSpaceFromModel(ctx context.Context, db application.DB, request *goa.
 			CreatedAt:   &sp.CreatedAt,
 			UpdatedAt:   &sp.UpdatedAt,
 			Version:     &sp.Version,
+			Theme: &app.SpaceTheme{
+				PrimaryColor: &sp.ThemePrimaryColor,
+				AccentColor:  &sp.ThemeAccentColor,
+				LogoURL:      &sp.ThemeLogoURL,
+			},
+			UniqueTitlesPerIteration: &sp.UniqueTitlesPerIteration,
 		},
 		Links: &app.GenericLinksForSpace{
 			Self: &selfURL,
@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/mailer"
+	"github.com/almighty/almighty-core/notification"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// SpaceInvitationsController implements the space_invitations resource.
+type SpaceInvitationsController struct {
+	*goa.Controller
+	db            application.DB
+	policyManager auth.AuthzPolicyManager
+	mailer        mailer.Mailer
+}
+
+// NewSpaceInvitationsController creates a space_invitations controller.
+func NewSpaceInvitationsController(service *goa.Service, db application.DB, policyManager auth.AuthzPolicyManager, mailer mailer.Mailer) *SpaceInvitationsController {
+	return &SpaceInvitationsController{Controller: service.NewController("SpaceInvitationsController"), db: db, policyManager: policyManager, mailer: mailer}
+}
+
+// List runs the list action: it lists the pending invitations of the given space, the oldest first
+func (c *SpaceInvitationsController) List(ctx *app.ListSpaceInvitationsContext) error {
+	spaceID, _, err := c.authorizeSpaceInvitations(ctx, ctx.RequestData, ctx.ID)
+	if err != nil {
+		if _, forbidden := err.(spaceInvitationsForbiddenError); forbidden {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
+			return ctx.Forbidden(jerrors)
+		}
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var invitations []space.Invitation
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		invitations, err = appl.Invitations().ListPending(ctx, spaceID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	data := make([]*app.Invitation, len(invitations))
+	for i := range invitations {
+		data[i] = convertInvitation(ctx.RequestData, invitations[i])
+	}
+	return ctx.OK(&app.InvitationList{Data: data})
+}
+
+// Create runs the create action: it creates a pending invitation to collaborate on the given space and notifies
+// the invitee. The space policy is only updated when the invitee accepts the invitation.
+func (c *SpaceInvitationsController) Create(ctx *app.CreateSpaceInvitationsContext) error {
+	spaceID, currentIdentityID, err := c.authorizeSpaceInvitations(ctx, ctx.RequestData, ctx.ID)
+	if err != nil {
+		if _, forbidden := err.(spaceInvitationsForbiddenError); forbidden {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
+			return ctx.Forbidden(jerrors)
+		}
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attributes := ctx.Payload.Data.Attributes
+	if attributes == nil || (attributes.IdentityID == nil) == (attributes.Email == nil) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("attributes", nil).Expected("either the identity or the email address of the invitee"))
+	}
+	invitation := space.Invitation{
+		SpaceID:   spaceID,
+		InviterID: currentIdentityID,
+		Role:      SpaceRoleContributor,
+	}
+	if attributes.Role != nil {
+		invitation.Role = *attributes.Role
+	}
+	var inviteeEmail, inviteeName string
+	if attributes.IdentityID != nil {
+		invitation.InviteeID = account.NullUUID{UUID: *attributes.IdentityID, Valid: true}
+	} else {
+		inviteeEmail, err = normalizeEmail(*attributes.Email)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("email", *attributes.Email).Expected("email address"))
+		}
+		invitation.InviteeEmail = &inviteeEmail
+	}
+	if invitation.InviteeID.Valid {
+		// the identities which already collaborate on the space are not invited
+		role, err := loadSpaceRole(ctx, c.db, c.policyManager, ctx.RequestData, spaceID, invitation.InviteeID.UUID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		if role != SpaceRoleNone {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("identity %s already collaborates on space %s", invitation.InviteeID.UUID, spaceID)))
+			return ctx.Conflict(jerrors)
+		}
+	}
+	alreadyInvited := false
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		s, err := appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			return err
+		}
+		inviterName, err := identityDisplayName(appl, currentIdentityID)
+		if err != nil {
+			return err
+		}
+		if invitation.InviteeID.Valid {
+			invitees, err := appl.Identities().Query(account.IdentityFilterByID(invitation.InviteeID.UUID), account.IdentityWithUser())
+			if err != nil {
+				return err
+			}
+			if len(invitees) == 0 {
+				return errors.NewNotFoundError("identity", invitation.InviteeID.UUID.String())
+			}
+			inviteeEmail = invitees[0].User.Email
+			inviteeName = invitees[0].User.FullName
+		}
+		pending, err := appl.Invitations().ListPending(ctx, spaceID)
+		if err != nil {
+			return err
+		}
+		for _, p := range pending {
+			if (invitation.InviteeID.Valid && uuid.Equal(p.InviteeID.UUID, invitation.InviteeID.UUID)) || (p.InviteeEmail != nil && *p.InviteeEmail == inviteeEmail) {
+				alreadyInvited = true
+				return nil
+			}
+		}
+		if err := appl.Invitations().Create(ctx, &invitation); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("%s invited you to collaborate on the space %s as %s", inviterName, s.Name, invitation.Role)
+		if invitation.InviteeID.Valid {
+			if _, err := appl.Notifications().Create(ctx, &notification.Notification{IdentityID: invitation.InviteeID.UUID, Message: message}); err != nil {
+				return err
+			}
+		}
+		if inviteeEmail == "" {
+			// the invited identity has no email address: it is only notified in the application
+			return nil
+		}
+		// the invitation is not created if the invitee can't be notified by email
+		return sendInvitation(ctx, c.mailer, ctx.RequestData, inviteeName, inviteeEmail, message, invitation.ID)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if alreadyInvited {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("the invitee was already invited to collaborate on space %s", spaceID)))
+		return ctx.Conflict(jerrors)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id":      spaceID,
+		"invitation_id": invitation.ID,
+		"inviter_id":    currentIdentityID,
+	}, "invitation created")
+	result := &app.InvitationSingle{Data: convertInvitation(ctx.RequestData, invitation)}
+	ctx.ResponseData.Header().Set("Location", *result.Data.Links.Self)
+	return ctx.Created(result)
+}
+
+// Revoke runs the revoke action: the revoked invitation can no longer be accepted
+func (c *SpaceInvitationsController) Revoke(ctx *app.RevokeSpaceInvitationsContext) error {
+	spaceID, currentIdentityID, err := c.authorizeSpaceInvitations(ctx, ctx.RequestData, ctx.ID)
+	if err != nil {
+		if _, forbidden := err.(spaceInvitationsForbiddenError); forbidden {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
+			return ctx.Forbidden(jerrors)
+		}
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		invitation, err := appl.Invitations().Load(ctx, ctx.InvitationID)
+		if err != nil {
+			return err
+		}
+		if !uuid.Equal(invitation.SpaceID, spaceID) {
+			return errors.NewNotFoundError("invitation", ctx.InvitationID.String())
+		}
+		if invitation.State != space.InvitationStatePending {
+			return errors.NewBadParameterError("invitationID", ctx.InvitationID).Expected("a pending invitation")
+		}
+		invitation.State = space.InvitationStateRevoked
+		return appl.Invitations().Save(ctx, invitation)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"space_id":      spaceID,
+		"invitation_id": ctx.InvitationID,
+		"identity_id":   currentIdentityID,
+	}, "invitation revoked")
+	return ctx.OK([]byte{})
+}
+
+// spaceInvitationsForbiddenError is returned when the authenticated identity is not allowed to manage the invitations
+// of a space
+type spaceInvitationsForbiddenError struct {
+	identityID uuid.UUID
+	spaceID    uuid.UUID
+}
+
+func (e spaceInvitationsForbiddenError) Error() string {
+	return fmt.Sprintf("identity %s is not allowed to manage the invitations of space %s", e.identityID, e.spaceID)
+}
+
+// authorizeSpaceInvitations returns the ID of the given space and of the authenticated identity if the identity is
+// the owner or an admin of the space, which are the only ones allowed to manage the invitations of the space.
+// A spaceInvitationsForbiddenError is returned otherwise.
+func (c *SpaceInvitationsController) authorizeSpaceInvitations(ctx context.Context, req *goa.RequestData, id string) (uuid.UUID, uuid.UUID, error) {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, goa.ErrUnauthorized(err.Error())
+	}
+	spaceID, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.NewBadParameterError("id", id).Expected("space ID")
+	}
+	role, err := loadSpaceRole(ctx, c.db, c.policyManager, req, spaceID, *currentIdentityID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if role != SpaceRoleOwner && role != SpaceRoleAdmin {
+		return uuid.Nil, uuid.Nil, spaceInvitationsForbiddenError{identityID: *currentIdentityID, spaceID: spaceID}
+	}
+	return spaceID, *currentIdentityID, nil
+}
+
+// identityDisplayName returns the full name of the user of the given identity, or its username if the user has none
+func identityDisplayName(appl application.Application, identityID uuid.UUID) (string, error) {
+	identities, err := appl.Identities().Query(account.IdentityFilterByID(identityID), account.IdentityWithUser())
+	if err != nil {
+		return "", err
+	}
+	if len(identities) == 0 {
+		return "", errors.NewNotFoundError("identity", identityID.String())
+	}
+	if name := strings.TrimSpace(identities[0].User.FullName); name != "" {
+		return name, nil
+	}
+	return identities[0].Username, nil
+}
+
+// sendInvitation sends the invitation with the given message to the email address of the invitee, along with the
+// link to accept it
+func sendInvitation(ctx context.Context, m mailer.Mailer, request *goa.RequestData, fullName, email, message string, invitationID uuid.UUID) error {
+	link := rest.AbsoluteURL(request, fmt.Sprintf("%s/accept", app.InvitationsHref(invitationID)))
+	if fullName == "" {
+		fullName = email
+	}
+	body := fmt.Sprintf(`Hello %s,
+
+%s.
+
+Once signed in, you can accept the invitation with the following link:
+
+%s
+
+If you don't expect this invitation, you can ignore this email.
+`, fullName, message, link)
+	return m.Send(ctx, email, "Invitation to collaborate on a space", body)
+}
+
+func convertInvitation(request *goa.RequestData, invitation space.Invitation) *app.Invitation {
+	id := invitation.ID
+	spaceID := invitation.SpaceID
+	inviterID := invitation.InviterID
+	role := invitation.Role
+	state := invitation.State
+	selfURL := rest.AbsoluteURL(request, fmt.Sprintf("%s/invitations/%s", app.SpaceHref(invitation.SpaceID), invitation.ID))
+	result := &app.Invitation{
+		Type: "invitations",
+		ID:   &id,
+		Attributes: &app.InvitationAttributes{
+			Email:     invitation.InviteeEmail,
+			Role:      &role,
+			State:     &state,
+			InviterID: &inviterID,
+			SpaceID:   &spaceID,
+			CreatedAt: &invitation.CreatedAt,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+	if invitation.InviteeID.Valid {
+		identityID := invitation.InviteeID.UUID
+		result.Attributes.IdentityID = &identityID
+	}
+	return result
+}
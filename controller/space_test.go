@@ -10,7 +10,9 @@ import (
 	"github.com/almighty/almighty-core/account"
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/app/test"
+	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/configuration"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/gormapplication"
@@ -20,6 +22,7 @@ import (
 	"github.com/almighty/almighty-core/resource"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
+	"github.com/almighty/almighty-core/workitem"
 	"github.com/goadesign/goa"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
@@ -217,6 +220,204 @@ func (rest *TestSpaceREST) TestSuccessUpdateSpace() {
 	assert.Equal(rest.T(), newDescription, *updated.Data.Attributes.Description)
 }
 
+func (rest *TestSpaceREST) TestSuccessCreateSpaceWithTheme() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestSuccessCreateSpaceWithTheme-")
+	primaryColor := "#00aaff"
+	accentColor := "#f50"
+	logoURL := "https://example.com/logo.png"
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	p.Data.Attributes.Theme = &app.SpaceTheme{
+		PrimaryColor: &primaryColor,
+		AccentColor:  &accentColor,
+		LogoURL:      &logoURL,
+	}
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	// when
+	_, fetched := test.ShowSpaceOK(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String(), nil, nil)
+	// then
+	require.NotNil(rest.T(), fetched.Data.Attributes.Theme)
+	assert.Equal(rest.T(), primaryColor, *fetched.Data.Attributes.Theme.PrimaryColor)
+	assert.Equal(rest.T(), accentColor, *fetched.Data.Attributes.Theme.AccentColor)
+	assert.Equal(rest.T(), logoURL, *fetched.Data.Attributes.Theme.LogoURL)
+}
+
+func (rest *TestSpaceREST) TestFailCreateSpaceInvalidTheme() {
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	invalidColor := "blue"
+	invalidURL := "javascript:alert(1)"
+	for _, theme := range []*app.SpaceTheme{
+		{PrimaryColor: &invalidColor},
+		{AccentColor: &invalidColor},
+		{LogoURL: &invalidURL},
+	} {
+		// given
+		name := testsupport.CreateRandomValidTestName("TestFailCreateSpaceInvalidTheme-")
+		p := minimumRequiredCreateSpace()
+		p.Data.Attributes.Name = &name
+		p.Data.Attributes.Theme = theme
+		// when/then
+		test.CreateSpaceBadRequest(rest.T(), svc.Context, svc, ctrl, p)
+	}
+}
+
+func (rest *TestSpaceREST) TestSuccessUpdateSpaceTheme() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestSuccessUpdateSpaceTheme-")
+	primaryColor := "#00aaff"
+	logoURL := "https://example.com/logo.png"
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	p.Data.Attributes.Theme = &app.SpaceTheme{
+		PrimaryColor: &primaryColor,
+		LogoURL:      &logoURL,
+	}
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	newPrimaryColor := "#123ABC"
+	resetLogoURL := ""
+	u := minimumRequiredUpdateSpace()
+	u.Data.ID = created.Data.ID
+	u.Data.Attributes.Version = created.Data.Attributes.Version
+	u.Data.Attributes.Name = &name
+	u.Data.Attributes.Theme = &app.SpaceTheme{
+		PrimaryColor: &newPrimaryColor,
+		LogoURL:      &resetLogoURL,
+	}
+	// when
+	_, updated := test.UpdateSpaceOK(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String(), u)
+	// then
+	require.NotNil(rest.T(), updated.Data.Attributes.Theme)
+	assert.Equal(rest.T(), newPrimaryColor, *updated.Data.Attributes.Theme.PrimaryColor)
+	assert.Equal(rest.T(), "", *updated.Data.Attributes.Theme.AccentColor)
+	assert.Equal(rest.T(), "", *updated.Data.Attributes.Theme.LogoURL)
+}
+
+func (rest *TestSpaceREST) TestFailUpdateSpaceInvalidTheme() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestFailUpdateSpaceInvalidTheme-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	invalidURL := "ftp://example.com/logo.png"
+	u := minimumRequiredUpdateSpace()
+	u.Data.ID = created.Data.ID
+	u.Data.Attributes.Version = created.Data.Attributes.Version
+	u.Data.Attributes.Name = &name
+	u.Data.Attributes.Theme = &app.SpaceTheme{LogoURL: &invalidURL}
+	// when/then
+	test.UpdateSpaceBadRequest(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String(), u)
+}
+
+// spaceSettingsConfiguration overrides some of the service-wide settings inherited by the spaces
+type spaceSettingsConfiguration struct {
+	*configuration.ConfigurationData
+}
+
+func (c *spaceSettingsConfiguration) GetCommentMaxLength() int {
+	return 512
+}
+
+func (c *spaceSettingsConfiguration) GetWorkItemClosedIterationAssignment() string {
+	return ClosedIterationAssignmentReject
+}
+
+func (c *spaceSettingsConfiguration) IsWorkItemDuplicateAutoClosed() bool {
+	return true
+}
+
+func (rest *TestSpaceREST) TestSuccessShowSpaceSettings() {
+	// given a space whose settings are set individually
+	name := testsupport.CreateRandomValidTestName("TestSuccessShowSpaceSettings-")
+	primaryColor := "#00aaff"
+	logoURL := "https://example.com/logo.png"
+	uniqueTitles := true
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	p.Data.Attributes.Theme = &app.SpaceTheme{
+		PrimaryColor: &primaryColor,
+	}
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsUser("Space-Service", almtoken.NewManagerWithPrivateKey(priv), testsupport.TestIdentity)
+	config := &spaceSettingsConfiguration{spaceConfiguration}
+	ctrl := NewSpaceController(svc, rest.db, config, &DummyResourceManager{})
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	u := minimumRequiredUpdateSpace()
+	u.Data.ID = created.Data.ID
+	u.Data.Attributes.Version = created.Data.Attributes.Version
+	u.Data.Attributes.Name = &name
+	u.Data.Attributes.Theme = &app.SpaceTheme{
+		LogoURL: &logoURL,
+	}
+	u.Data.Attributes.UniqueTitlesPerIteration = &uniqueTitles
+	test.UpdateSpaceOK(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String(), u)
+	// when
+	_, settings := test.SettingsSpaceOK(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String())
+	// then
+	require.NotNil(rest.T(), settings)
+	assert.Equal(rest.T(), *created.Data.ID, settings.SpaceID)
+	require.NotNil(rest.T(), settings.Theme)
+	assert.Equal(rest.T(), primaryColor, *settings.Theme.PrimaryColor)
+	assert.Equal(rest.T(), "", *settings.Theme.AccentColor)
+	assert.Equal(rest.T(), logoURL, *settings.Theme.LogoURL)
+	assert.True(rest.T(), settings.UniqueTitlesPerIteration)
+	require.NotNil(rest.T(), settings.WorkItems)
+	assert.Equal(rest.T(), ClosedIterationAssignmentReject, settings.WorkItems.ClosedIterationAssignment)
+	assert.True(rest.T(), settings.WorkItems.DuplicateAutoClose)
+	assert.Equal(rest.T(), config.IsWorkItemAssigneeCollaboratorRequired(), settings.WorkItems.AssigneeCollaboratorRequired)
+	assert.Equal(rest.T(), config.GetWorkItemRemovedCollaboratorAssignments(), settings.WorkItems.RemovedCollaboratorAssignments)
+	assert.Equal(rest.T(), config.IsWorkItemPayloadStrict(), settings.WorkItems.StrictPayload)
+	assert.Equal(rest.T(), config.GetWorkItemRestoreWindow().String(), settings.WorkItems.RestoreWindow)
+	require.NotNil(rest.T(), settings.Limits)
+	assert.Equal(rest.T(), 512, settings.Limits.CommentMaxLength)
+	assert.Equal(rest.T(), config.GetWorkItemBulkUpdateBatchSize(), settings.Limits.BulkUpdateBatchSize)
+}
+
+func (rest *TestSpaceREST) TestSuccessShowSpaceSettingsAsCollaborator() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestSuccessShowSpaceSettingsAsCollaborator-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	collaborator, err := testsupport.CreateTestIdentity(rest.DB, "TestSuccessShowSpaceSettingsAsCollaborator-"+uuid.NewV4().String(), "test provider")
+	require.Nil(rest.T(), err)
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	collaboratorSvc := testsupport.ServiceAsSpaceUser("Space-Service", almtoken.NewManagerWithPrivateKey(priv), collaborator, &TestSpaceAuthzService{collaborator})
+	collaboratorCtrl := NewSpaceController(collaboratorSvc, rest.db, spaceConfiguration, &DummyResourceManager{})
+	// when
+	_, settings := test.SettingsSpaceOK(rest.T(), collaboratorSvc.Context, collaboratorSvc, collaboratorCtrl, created.Data.ID.String())
+	// then
+	require.NotNil(rest.T(), settings)
+	assert.Equal(rest.T(), *created.Data.ID, settings.SpaceID)
+}
+
+func (rest *TestSpaceREST) TestFailShowSpaceSettingsNotCollaborator() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestFailShowSpaceSettingsNotCollaborator-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	other, err := testsupport.CreateTestIdentity(rest.DB, "TestFailShowSpaceSettingsNotCollaborator-"+uuid.NewV4().String(), "test provider")
+	require.Nil(rest.T(), err)
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	otherSvc := testsupport.ServiceAsSpaceUser("Space-Service", almtoken.NewManagerWithPrivateKey(priv), other, &TestSpaceAuthzService{testsupport.TestIdentity})
+	otherCtrl := NewSpaceController(otherSvc, rest.db, spaceConfiguration, &DummyResourceManager{})
+	// when/then
+	test.SettingsSpaceForbidden(rest.T(), otherSvc.Context, otherSvc, otherCtrl, created.Data.ID.String())
+}
+
+func (rest *TestSpaceREST) TestFailShowSpaceSettingsUnsecure() {
+	// given
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.SettingsSpaceUnauthorized(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
 func (rest *TestSpaceREST) TestFailUpdateSpaceNameLength() {
 	// given
 	name := testsupport.CreateRandomValidTestName("TestFailUpdateSpaceNameLength-")
@@ -473,6 +674,69 @@ func (rest *TestSpaceREST) TestFailShowSpaceNotFoundBadID() {
 	test.ShowSpaceNotFound(rest.T(), svc.Context, svc, ctrl, "asfasfsaf", nil, nil)
 }
 
+func (rest *TestSpaceREST) TestSuccessShowSpaceStatistics() {
+	// given a space with 3 work items, one of them deleted, and 4 comments, one of them deleted
+	testIdentity, err := testsupport.CreateTestIdentity(rest.DB, "TestSuccessShowSpaceStatistics user", "test provider")
+	require.Nil(rest.T(), err)
+	name := testsupport.CreateRandomValidTestName("TestSuccessShowSpaceStatistics-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	svc, ctrl := rest.SecuredController(testIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	spaceID := *created.Data.ID
+	err = application.Transactional(rest.db, func(appl application.Application) error {
+		var workItems []*workitem.WorkItem
+		for i := 0; i < 3; i++ {
+			wi, err := appl.WorkItems().Create(context.Background(), spaceID, workitem.SystemBug,
+				map[string]interface{}{
+					workitem.SystemTitle: fmt.Sprintf("TestSuccessShowSpaceStatistics #%d", i),
+					workitem.SystemState: workitem.SystemStateNew,
+				}, testIdentity.ID)
+			require.Nil(rest.T(), err)
+			workItems = append(workItems, wi)
+		}
+		var comments []*comment.Comment
+		for _, wi := range []*workitem.WorkItem{workItems[0], workItems[0], workItems[1], workItems[1]} {
+			c := comment.Comment{ParentID: wi.ID, Body: "TestSuccessShowSpaceStatistics"}
+			require.Nil(rest.T(), appl.Comments().Create(context.Background(), &c, testIdentity.ID))
+			comments = append(comments, &c)
+		}
+		require.Nil(rest.T(), appl.Comments().Delete(context.Background(), comments[3].ID, testIdentity.ID))
+		return appl.WorkItems().Delete(context.Background(), spaceID, workItems[2].ID, testIdentity.ID)
+	})
+	require.Nil(rest.T(), err)
+	// when
+	_, stats := test.StatisticsSpaceOK(rest.T(), svc.Context, svc, ctrl, spaceID.String())
+	// then
+	require.NotNil(rest.T(), stats)
+	assert.Equal(rest.T(), spaceID, stats.SpaceID)
+	assert.Equal(rest.T(), 2, stats.WorkItems)
+	assert.Equal(rest.T(), 3, stats.Comments)
+}
+
+func (rest *TestSpaceREST) TestSuccessShowEmptySpaceStatistics() {
+	// given
+	name := testsupport.CreateRandomValidTestName("TestSuccessShowEmptySpaceStatistics-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	// when
+	_, stats := test.StatisticsSpaceOK(rest.T(), svc.Context, svc, ctrl, created.Data.ID.String())
+	// then
+	require.NotNil(rest.T(), stats)
+	assert.Equal(rest.T(), 0, stats.WorkItems)
+	assert.Equal(rest.T(), 0, stats.Comments)
+}
+
+func (rest *TestSpaceREST) TestFailShowSpaceStatisticsNotFound() {
+	// given
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.StatisticsSpaceNotFound(rest.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+	test.StatisticsSpaceNotFound(rest.T(), svc.Context, svc, ctrl, "asfasfsaf")
+}
+
 func (rest *TestSpaceREST) TestListSpacesOK() {
 	// given
 	name := testsupport.CreateRandomValidTestName("TestListSpacesOK-")
@@ -487,6 +751,41 @@ func (rest *TestSpaceREST) TestListSpacesOK() {
 	require.NotEmpty(rest.T(), list.Data)
 }
 
+func (rest *TestSpaceREST) TestListOwnedSpacesOK() {
+	// given a space owned by the current user and another one owned by someone else
+	ownedName := testsupport.CreateRandomValidTestName("TestListOwnedSpacesOK-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &ownedName
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, owned := test.CreateSpaceCreated(rest.T(), svc.Context, svc, ctrl, p)
+	otherName := testsupport.CreateRandomValidTestName("TestListOwnedSpacesOK-")
+	p = minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &otherName
+	svc2, ctrl2 := rest.SecuredController(testsupport.TestIdentity2)
+	_, other := test.CreateSpaceCreated(rest.T(), svc2.Context, svc2, ctrl2, p)
+	// when
+	pageLimit := 100
+	_, list := test.ListOwnedSpaceOK(rest.T(), svc.Context, svc, ctrl, &pageLimit, nil, nil, nil)
+	// then
+	require.NotNil(rest.T(), list)
+	require.NotEmpty(rest.T(), list.Data)
+	ids := make([]uuid.UUID, len(list.Data))
+	for i, s := range list.Data {
+		ids[i] = *s.ID
+		assert.Equal(rest.T(), testsupport.TestIdentity.ID, *s.Relationships.OwnedBy.Data.ID)
+	}
+	assert.Contains(rest.T(), ids, *owned.Data.ID)
+	assert.NotContains(rest.T(), ids, *other.Data.ID)
+	assert.Equal(rest.T(), len(list.Data), list.Meta.TotalCount)
+}
+
+func (rest *TestSpaceREST) TestFailListOwnedSpacesUnsecure() {
+	// given
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.ListOwnedSpaceUnauthorized(rest.T(), svc.Context, svc, ctrl, nil, nil, nil, nil)
+}
+
 func (rest *TestSpaceREST) TestListSpacesOKUsingExpiredIfModifiedSinceHeader() {
 	// given
 	name := testsupport.CreateRandomValidTestName("TestListSpacesOKUsingExpiredIfModifiedSinceHeader-")
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/rest"
+
+	"github.com/goadesign/goa"
+)
+
+// TeamsController implements the teams resource.
+type TeamsController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewTeamsController creates a teams controller.
+func NewTeamsController(service *goa.Service, db application.DB) *TeamsController {
+	return &TeamsController{Controller: service.NewController("TeamsController"), db: db}
+}
+
+// List runs the list action: it lists all the teams, ordered by name
+func (c *TeamsController) List(ctx *app.ListTeamsContext) error {
+	var teams []account.Team
+	err := application.Transactional(c.db, func(appl application.Application) error {
+		var err error
+		teams, err = appl.Teams().List(ctx)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	data := make([]*app.Team, len(teams))
+	for i := range teams {
+		data[i] = convertTeam(ctx.RequestData, teams[i])
+	}
+	return ctx.OK(&app.TeamList{Data: data})
+}
+
+// Show runs the show action.
+func (c *TeamsController) Show(ctx *app.ShowTeamsContext) error {
+	var team *account.Team
+	err := application.Transactional(c.db, func(appl application.Application) error {
+		var err error
+		team, err = appl.Teams().Load(ctx, ctx.TeamID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.TeamSingle{Data: convertTeam(ctx.RequestData, *team)})
+}
+
+// Create runs the create action: it creates a team whose first member is the authenticated identity, so that
+// it can add the other members.
+func (c *TeamsController) Create(ctx *app.CreateTeamsContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	attributes := ctx.Payload.Data.Attributes
+	if attributes == nil || attributes.Name == nil || strings.TrimSpace(*attributes.Name) == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("name", nil).Expected("the name of the team"))
+	}
+	team := account.Team{
+		Name:        *attributes.Name,
+		Description: attributes.Description,
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if err := appl.Teams().Create(ctx, &team); err != nil {
+			return err
+		}
+		return appl.Teams().AddMember(ctx, team.ID, *currentIdentityID)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": *currentIdentityID,
+		"team_id":     team.ID,
+	}, "team created")
+	result := &app.TeamSingle{Data: convertTeam(ctx.RequestData, team)}
+	ctx.ResponseData.Header().Set("Location", *result.Data.Links.Self)
+	return ctx.Created(result)
+}
+
+func convertTeam(request *goa.RequestData, team account.Team) *app.Team {
+	id := team.ID
+	name := team.Name
+	selfURL := rest.AbsoluteURL(request, app.TeamsHref(team.ID))
+	return &app.Team{
+		Type: "teams",
+		ID:   &id,
+		Attributes: &app.TeamAttributes{
+			Name:        &name,
+			Description: team.Description,
+			CreatedAt:   &team.CreatedAt,
+			UpdatedAt:   &team.UpdatedAt,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+}
@@ -0,0 +1,154 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTeams(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestTeamsSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestTeamsSuite struct {
+	gormtestsupport.DBTestSuite
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func (s *TestTeamsSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *TestTeamsSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *TestTeamsSuite) TearDownTest() {
+	s.clean()
+}
+
+func (s *TestTeamsSuite) SecuredControllers(identity account.Identity) (*goa.Service, *TeamsController, *TeamsMembersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Teams-Service", almtoken.NewManager(pub), identity)
+	return svc, NewTeamsController(svc, s.db), NewTeamsMembersController(svc, s.db)
+}
+
+func newCreateTeamsPayload(name string) *app.CreateTeamsPayload {
+	return &app.CreateTeamsPayload{
+		Data: &app.Team{
+			Type: "teams",
+			Attributes: &app.TeamAttributes{
+				Name: &name,
+			},
+		},
+	}
+}
+
+// createTeam creates a team as the given identity, which becomes its first member
+func (s *TestTeamsSuite) createTeam(identity account.Identity) *app.Team {
+	svc, ctrl, _ := s.SecuredControllers(identity)
+	name := "TestTeams-" + uuid.NewV4().String()
+	_, created := test.CreateTeamsCreated(s.T(), svc.Context, svc, ctrl, newCreateTeamsPayload(name))
+	require.NotNil(s.T(), created.Data.ID)
+	assert.Equal(s.T(), name, *created.Data.Attributes.Name)
+	return created.Data
+}
+
+func (s *TestTeamsSuite) TestCreateAndShowTeamOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	// when
+	team := s.createTeam(identity)
+	// then
+	svc, ctrl, membersCtrl := s.SecuredControllers(identity)
+	_, shown := test.ShowTeamsOK(s.T(), svc.Context, svc, ctrl, *team.ID)
+	assert.Equal(s.T(), *team.Attributes.Name, *shown.Data.Attributes.Name)
+	_, members := test.ListTeamsMembersOK(s.T(), svc.Context, svc, membersCtrl, *team.ID)
+	require.Len(s.T(), members.Data, 1)
+	assert.Equal(s.T(), identity.ID.String(), *members.Data[0].ID)
+}
+
+func (s *TestTeamsSuite) TestCreateTeamWithSameNameBadRequest() {
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	team := s.createTeam(identity)
+	svc, ctrl, _ := s.SecuredControllers(identity)
+
+	test.CreateTeamsBadRequest(s.T(), svc.Context, svc, ctrl, newCreateTeamsPayload(*team.Attributes.Name))
+}
+
+func (s *TestTeamsSuite) TestShowUnknownTeamNotFound() {
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestShowTeam"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl, _ := s.SecuredControllers(identity)
+
+	test.ShowTeamsNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4())
+}
+
+func (s *TestTeamsSuite) TestAddAndRemoveMembersOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestTeamMembers"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestTeamMembers"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	team := s.createTeam(identity)
+	svc, _, ctrl := s.SecuredControllers(identity)
+	// when
+	test.AddTeamsMembersOK(s.T(), svc.Context, svc, ctrl, *team.ID, other.ID)
+	// then
+	_, members := test.ListTeamsMembersOK(s.T(), svc.Context, svc, ctrl, *team.ID)
+	require.Len(s.T(), members.Data, 2)
+	assert.Equal(s.T(), identity.ID.String(), *members.Data[0].ID)
+	assert.Equal(s.T(), other.ID.String(), *members.Data[1].ID)
+	// when
+	test.RemoveTeamsMembersOK(s.T(), svc.Context, svc, ctrl, *team.ID, identity.ID)
+	// then the last member can't leave the team
+	_, members = test.ListTeamsMembersOK(s.T(), svc.Context, svc, ctrl, *team.ID)
+	require.Len(s.T(), members.Data, 1)
+	assert.Equal(s.T(), other.ID.String(), *members.Data[0].ID)
+	svc, _, ctrl = s.SecuredControllers(other)
+	test.RemoveTeamsMembersBadRequest(s.T(), svc.Context, svc, ctrl, *team.ID, other.ID)
+}
+
+func (s *TestTeamsSuite) TestAddMemberForbiddenForNonMember() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestTeamMembers"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestTeamMembers"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	team := s.createTeam(identity)
+	svc, _, ctrl := s.SecuredControllers(other)
+	// when
+	test.AddTeamsMembersForbidden(s.T(), svc.Context, svc, ctrl, *team.ID, other.ID)
+	// then
+	_, members := test.ListTeamsMembersOK(s.T(), svc.Context, svc, ctrl, *team.ID)
+	require.Len(s.T(), members.Data, 1)
+}
+
+func (s *TestTeamsSuite) TestAddUnknownIdentityNotFound() {
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestTeamMembers"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	team := s.createTeam(identity)
+	svc, _, ctrl := s.SecuredControllers(identity)
+
+	test.AddTeamsMembersNotFound(s.T(), svc.Context, svc, ctrl, *team.ID, uuid.NewV4())
+}
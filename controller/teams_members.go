@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// TeamsMembersController implements the teams_members resource.
+type TeamsMembersController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewTeamsMembersController creates a teams_members controller.
+func NewTeamsMembersController(service *goa.Service, db application.DB) *TeamsMembersController {
+	return &TeamsMembersController{Controller: service.NewController("TeamsMembersController"), db: db}
+}
+
+// List runs the list action: it lists the members of the given team, in the order they joined the team
+func (c *TeamsMembersController) List(ctx *app.ListTeamsMembersContext) error {
+	var data []*app.IdentityData
+	err := application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Teams().Load(ctx, ctx.TeamID); err != nil {
+			return err
+		}
+		memberIDs, err := appl.Teams().ListMembers(ctx, ctx.TeamID)
+		if err != nil {
+			return err
+		}
+		data = make([]*app.IdentityData, 0, len(memberIDs))
+		if len(memberIDs) == 0 {
+			return nil
+		}
+		identities, err := appl.Identities().Query(account.IdentityFilterByIDs(memberIDs), account.IdentityWithUser())
+		if err != nil {
+			return err
+		}
+		identitiesByID := make(map[uuid.UUID]*account.Identity, len(identities))
+		for _, identity := range identities {
+			identitiesByID[identity.ID] = identity
+		}
+		for _, id := range memberIDs {
+			if identity, ok := identitiesByID[id]; ok {
+				data = append(data, ConvertUser(ctx.RequestData, identity, &identity.User).Data)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.UserArray{Data: data})
+}
+
+// Add runs the add action: it adds the given identity to the members of the team, which makes it a collaborator
+// of all the spaces of the team
+func (c *TeamsMembersController) Add(ctx *app.AddTeamsMembersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		team, err := authorizeTeamMembers(ctx, appl, ctx.TeamID, *currentIdentityID)
+		if err != nil {
+			return err
+		}
+		if _, err := appl.Identities().Load(ctx, ctx.IdentityID); err != nil {
+			return errors.NewNotFoundError("identity", ctx.IdentityID.String())
+		}
+		if err := appl.Teams().AddMember(ctx, ctx.TeamID, ctx.IdentityID); err != nil {
+			return err
+		}
+		// the team is saved so that the collaborators of its spaces are known to have changed
+		return appl.Teams().Save(ctx, team)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"team_id":     ctx.TeamID,
+		"identity_id": ctx.IdentityID,
+	}, "team member added")
+	return ctx.OK([]byte{})
+}
+
+// Remove runs the remove action: it removes the given identity from the members of the team. The last member
+// can't be removed, since nobody would be left to manage the team.
+func (c *TeamsMembersController) Remove(ctx *app.RemoveTeamsMembersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		team, err := authorizeTeamMembers(ctx, appl, ctx.TeamID, *currentIdentityID)
+		if err != nil {
+			return err
+		}
+		memberIDs, err := appl.Teams().ListMembers(ctx, ctx.TeamID)
+		if err != nil {
+			return err
+		}
+		if len(memberIDs) == 1 && uuid.Equal(memberIDs[0], ctx.IdentityID) {
+			return errors.NewBadParameterError("identityID", ctx.IdentityID).Expected("not the last member of the team")
+		}
+		if err := appl.Teams().RemoveMember(ctx, ctx.TeamID, ctx.IdentityID); err != nil {
+			return err
+		}
+		return appl.Teams().Save(ctx, team)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"team_id":     ctx.TeamID,
+		"identity_id": ctx.IdentityID,
+	}, "team member removed")
+	return ctx.OK([]byte{})
+}
+
+// authorizeTeamMembers returns the given team if the given identity is one of its members, which are the only ones
+// allowed to add and remove the members of the team
+func authorizeTeamMembers(ctx context.Context, appl application.Application, teamID uuid.UUID, identityID uuid.UUID) (*account.Team, error) {
+	team, err := appl.Teams().Load(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	isMember, err := appl.Teams().IsMember(ctx, teamID, identityID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, goa.NewErrorClass("forbidden", 403)(fmt.Sprintf("identity %s is not allowed to manage the members of team %s", identityID, teamID))
+	}
+	return team, nil
+}
@@ -38,7 +38,7 @@ func TestRunTrackerREST(t *testing.T) {
 }
 
 func (rest *TestTrackerREST) SetupTest() {
-	rest.RwiScheduler = remoteworkitem.NewScheduler(rest.DB)
+	rest.RwiScheduler = remoteworkitem.NewScheduler(rest.DB, rest.Configuration)
 	rest.db = gormapplication.NewGormDB(rest.DB)
 	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
 }
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
@@ -135,6 +136,42 @@ func (c *TrackerqueryController) Delete(ctx *app.DeleteTrackerqueryContext) erro
 	return result
 }
 
+// Status runs the status action.
+func (c *TrackerqueryController) Status(ctx *app.StatusTrackerqueryContext) error {
+	err := application.Transactional(c.db, func(appl application.Application) error {
+		_, err := appl.TrackerQueries().Load(ctx.Context, ctx.ID)
+		return err
+	})
+	if err != nil {
+		cause := errs.Cause(err)
+		switch cause.(type) {
+		case remoteworkitem.NotFoundError:
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrNotFound(err.Error()))
+			return ctx.NotFound(jerrors)
+		default:
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInternal(err.Error()))
+			return ctx.InternalServerError(jerrors)
+		}
+	}
+	id, err := strconv.ParseUint(ctx.ID, 10, 64)
+	if err != nil {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrBadRequest(err.Error()))
+		return ctx.BadRequest(jerrors)
+	}
+	status, _ := c.scheduler.ImportStatus(id)
+	result := &app.TrackerQueryImportStatus{
+		ID:         ctx.ID,
+		Running:    status.Running,
+		Processed:  status.Processed,
+		Failed:     status.Failed,
+		FinishedAt: status.FinishedAt,
+	}
+	if !status.StartedAt.IsZero() {
+		result.StartedAt = &status.StartedAt
+	}
+	return ctx.OK(result)
+}
+
 // List runs the list action.
 func (c *TrackerqueryController) List(ctx *app.ListTrackerqueryContext) error {
 	return application.Transactional(c.db, func(appl application.Application) error {
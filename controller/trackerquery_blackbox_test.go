@@ -41,7 +41,7 @@ func TestRunTrackerQueryREST(t *testing.T) {
 }
 
 func (rest *TestTrackerQueryREST) SetupTest() {
-	rest.RwiScheduler = remoteworkitem.NewScheduler(rest.DB)
+	rest.RwiScheduler = remoteworkitem.NewScheduler(rest.DB, rest.Configuration)
 	rest.db = gormapplication.NewGormDB(rest.DB)
 	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
 }
@@ -10,9 +10,11 @@ import (
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/token"
 	"github.com/goadesign/goa"
 	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 )
 
 // UserController implements the user resource.
@@ -62,3 +64,52 @@ func (c *UserController) Show(ctx *app.ShowUserContext) error {
 		return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
 	})
 }
+
+// ListRecentSpaces lists the spaces recently visited by the authorized user, the most recently visited first.
+// The spaces which no longer exist are skipped.
+func (c *UserController) ListRecentSpaces(ctx *app.ListRecentSpacesUserContext) error {
+	id, err := c.tokenManager.Locate(ctx)
+	if err != nil {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrBadRequest(err.Error()))
+		return ctx.BadRequest(jerrors)
+	}
+
+	return application.Transactional(c.db, func(appl application.Application) error {
+		identity, err := appl.Identities().Load(ctx, id)
+		if err != nil || identity == nil {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("Auth token contains id %s of unknown Identity\n", id)))
+			return ctx.Unauthorized(jerrors)
+		}
+		var spaces []space.Space
+		if identity.UserID.Valid {
+			user, err := appl.Users().Load(ctx.Context, identity.UserID.UUID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, fmt.Sprintf("Can't load user with id %s", identity.UserID.UUID)))
+			}
+			for _, recent := range recentSpaces(user.ContextInformation) {
+				spaceID, err := uuid.FromString(recent.SpaceID)
+				if err != nil {
+					continue
+				}
+				s, err := appl.Spaces().Load(ctx.Context, spaceID)
+				if err != nil {
+					log.Warn(ctx, map[string]interface{}{
+						"space_id": spaceID,
+						"err":      err,
+					}, "unable to load a recently visited space")
+					continue
+				}
+				spaces = append(spaces, *s)
+			}
+		}
+		spaceData, err := ConvertSpacesFromModel(ctx.Context, c.db, ctx.RequestData, spaces)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.SpaceList{
+			Links: &app.PagingLinks{},
+			Meta:  &app.SpaceListMeta{TotalCount: len(spaceData)},
+			Data:  spaceData,
+		})
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/almighty/almighty-core/comment"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/notification"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space"
 	almtoken "github.com/almighty/almighty-core/token"
@@ -81,6 +82,7 @@ func TestCurrentAuthorizedOK(t *testing.T) {
 	assert.NotNil(t, identity)
 
 	assert.Equal(t, usr.FullName, *identity.Data.Attributes.FullName)
+	assert.Equal(t, usr.FullName, *identity.Data.Attributes.DisplayName)
 	assert.Equal(t, ident.Username, *identity.Data.Attributes.Username)
 	assert.Equal(t, usr.ImageURL, *identity.Data.Attributes.ImageURL)
 	assert.Equal(t, usr.Email, *identity.Data.Attributes.Email)
@@ -142,6 +144,16 @@ func (m TestIdentityRepository) IsValid(ctx context.Context, id uuid.UUID) bool
 	return true
 }
 
+func (m TestIdentityRepository) Search(ctx context.Context, q string, among []uuid.UUID, start int, limit int) ([]*account.Identity, int, error) {
+	return []*account.Identity{m.Identity}, 1, nil
+}
+
+func (m TestIdentityRepository) CompleteRegistration(ctx context.Context, id uuid.UUID) (bool, error) {
+	completed := !m.Identity.RegistrationCompleted
+	m.Identity.RegistrationCompleted = true
+	return completed, nil
+}
+
 type TestUserRepository struct {
 	User *account.User
 }
@@ -180,6 +192,11 @@ func (m TestUserRepository) Query(funcs ...func(*gorm.DB) *gorm.DB) ([]*account.
 	return []*account.User{m.User}, nil
 }
 
+// ListCompanies returns the company of the user
+func (m TestUserRepository) ListCompanies(ctx context.Context, prefix string, limit int) ([]string, error) {
+	return []string{m.User.Company}, nil
+}
+
 type GormTestBase struct {
 	IdentityRepository account.IdentityRepository
 	UserRepository     account.UserRepository
@@ -193,6 +210,18 @@ func (g *GormTestBase) WorkItemTypes() workitem.WorkItemTypeRepository {
 	return nil
 }
 
+func (g *GormTestBase) Sessions() auth.SessionRepository {
+	return nil
+}
+
+func (g *GormTestBase) Notifications() notification.Repository {
+	return nil
+}
+
+func (g *GormTestBase) WorkItemRevisions() workitem.RevisionRepository {
+	return nil
+}
+
 func (g *GormTestBase) Spaces() space.Repository {
 	return nil
 }
@@ -222,6 +251,51 @@ func (g *GormTestBase) Users() account.UserRepository {
 	return g.UserRepository
 }
 
+// UserPreferences creates new user preferences repository
+func (g *GormTestBase) UserPreferences() account.UserPreferencesRepository {
+	return nil
+}
+
+// UsernameHistory creates new username history repository
+func (g *GormTestBase) UsernameHistory() account.UsernameHistoryRepository {
+	return nil
+}
+
+// UserEmails creates new user email repository
+func (g *GormTestBase) UserEmails() account.UserEmailRepository {
+	return nil
+}
+
+// Impersonations creates new impersonation repository
+func (g *GormTestBase) Impersonations() account.ImpersonationRepository {
+	return nil
+}
+
+// ProfileEvents creates new profile event repository
+func (g *GormTestBase) ProfileEvents() account.ProfileEventRepository {
+	return nil
+}
+
+// PersonalAccessTokens creates new personal access token repository
+func (g *GormTestBase) PersonalAccessTokens() account.PersonalAccessTokenRepository {
+	return nil
+}
+
+// Teams creates new team repository
+func (g *GormTestBase) Teams() account.TeamRepository {
+	return nil
+}
+
+// Invitations creates new invitation repository
+func (g *GormTestBase) Invitations() space.InvitationRepository {
+	return nil
+}
+
+// SpaceCollaborators creates new cached space collaborators repository
+func (g *GormTestBase) SpaceCollaborators() space.CollaboratorRepository {
+	return nil
+}
+
 // WorkItemLinkCategories returns a work item link category repository
 func (g *GormTestBase) WorkItemLinkCategories() link.WorkItemLinkCategoryRepository {
 	return nil
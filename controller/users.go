@@ -2,17 +2,30 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/almighty/almighty-core/account"
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
 	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/httpsupport"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/mailer"
 	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/token"
 	"github.com/almighty/almighty-core/workitem"
 	"github.com/goadesign/goa"
 	goajwt "github.com/goadesign/goa/middleware/security/jwt"
@@ -25,6 +38,15 @@ import (
 type usersConfiguration interface {
 	// add configuration specific to keycloak user profile api url
 	GetKeycloakAccountEndpoint(*goa.RequestData) (string, error)
+	// add configuration specific to the keycloak admin api, used to provision the imported users
+	GetKeycloakEndpointAdmin(*goa.RequestData) (string, error)
+	GetKeycloakEndpointToken(*goa.RequestData) (string, error)
+	GetKeycloakClientID() string
+	GetKeycloakSecret() string
+	IsUserCompanyRequired() bool
+	GetUsernameReuseGracePeriod() time.Duration
+	GetUserContextInformationAllowedKeys() []string
+	GetEmailVerificationExpiry() time.Duration
 }
 
 // UsersController implements the users resource.
@@ -33,15 +55,24 @@ type UsersController struct {
 	db                 application.DB
 	configuration      usersConfiguration
 	userProfileService login.UserProfileService
+	policyManager      auth.AuthzPolicyManager
+	sessionManager     auth.UserSessionManager
+	mailer             mailer.Mailer
+	emailManager       auth.UserEmailManager
+	InitTenant         func(context.Context) error
 }
 
 // NewUsersController creates a users controller.
-func NewUsersController(service *goa.Service, db application.DB, configuration usersConfiguration, userProfileService login.UserProfileService) *UsersController {
-	return &UsersController{Controller: service.NewController("UsersController"), db: db, configuration: configuration, userProfileService: userProfileService}
+func NewUsersController(service *goa.Service, db application.DB, configuration usersConfiguration, userProfileService login.UserProfileService, policyManager auth.AuthzPolicyManager, sessionManager auth.UserSessionManager, mailer mailer.Mailer, emailManager auth.UserEmailManager) *UsersController {
+	return &UsersController{Controller: service.NewController("UsersController"), db: db, configuration: configuration, userProfileService: userProfileService, policyManager: policyManager, sessionManager: sessionManager, mailer: mailer, emailManager: emailManager}
 }
 
 // Show runs the show action.
 func (c *UsersController) Show(ctx *app.ShowUsersContext) error {
+	fieldset, err := jsonapi.NewSparseFieldset("identities", ctx.FieldsIdentities, app.IdentityDataAttributes{})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 	return application.Transactional(c.db, func(appl application.Application) error {
 		id, err := uuid.FromString(ctx.ID)
 		if err != nil {
@@ -54,14 +85,66 @@ func (c *UsersController) Show(ctx *app.ShowUsersContext) error {
 			return ctx.ResponseData.Service.Send(ctx.Context, httpStatusCode, jerrors)
 		}
 		var user *account.User
+		var preferences *account.UserPreferences
 		userID := identity.UserID
+		// the ETag and the last modification time change whenever the identity, its user or the preferences of the user are updated
+		entity := httpsupport.ConditionalEntity{
+			ETagData:     []interface{}{identity.ID, identity.UpdatedAt},
+			LastModified: identity.UpdatedAt,
+		}
 		if userID.Valid {
 			user, err = appl.Users().Load(ctx.Context, userID.UUID)
 			if err != nil {
 				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, fmt.Sprintf("User ID %s not valid", userID.UUID)))
 			}
+			preferences, err = loadUserPreferences(ctx, appl, userID.UUID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			entity.ETagData = append(entity.ETagData, user.UpdatedAt, preferences.UpdatedAt)
+			entity.LastModified = latest(entity.LastModified, user.UpdatedAt, preferences.UpdatedAt)
 		}
-		return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+		ownedSpaces, _, err := appl.Spaces().LoadByOwner(ctx, &identity.ID, nil, nil)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		var spaces []space.Space
+		includeSpaces := ctx.Include != nil && *ctx.Include == "spaces"
+		if includeSpaces {
+			spaces, err = c.loadCollaboratingSpaces(ctx, appl, identity.ID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+		}
+		userSpaces := append(ownedSpaces, spaces...)
+		// they also change whenever a space of the user is updated
+		for _, s := range userSpaces {
+			entity.ETagData = append(entity.ETagData, s.ID, s.UpdatedAt)
+			entity.LastModified = latest(entity.LastModified, s.UpdatedAt)
+		}
+		return httpsupport.ConditionalRequest(ctx, ctx.RequestData, ctx.ResponseData, entity, func() error {
+			result := ConvertUser(ctx.RequestData, identity, user)
+			fieldset.Apply(result.Data.Attributes)
+			result.Data.Relationships = &app.IdentityRelationships{
+				OwnedSpaces: spacesRelationship(ownedSpaces),
+			}
+			if preferences != nil {
+				// the preferences are included in the response
+				result.Data.Relationships.Preferences = userPreferencesRelationship(ctx.RequestData, identity.ID)
+				result.Included = append(result.Included, convertUserPreferencesData(ctx.RequestData, identity.ID, preferences))
+			}
+			if includeSpaces {
+				result.Data.Relationships.Spaces = spacesRelationship(spaces)
+				spaceData, err := ConvertSpacesFromModel(ctx.Context, c.db, ctx.RequestData, userSpaces)
+				if err != nil {
+					return jsonapi.JSONErrorResponse(ctx, err)
+				}
+				for _, s := range spaceData {
+					result.Included = append(result.Included, s)
+				}
+			}
+			return ctx.OK(result)
+		})
 	})
 }
 
@@ -89,6 +172,15 @@ func copyExistingKeycloakUserProfileInfo(existingProfile *login.KeycloakUserProf
 	return keycloakUserProfile
 }
 
+// setKeycloakProfileAttribute sets the given attribute in the Keycloak user profile, or removes it if the value is empty
+func setKeycloakProfileAttribute(keycloakUserProfile *login.KeycloakUserProfile, name, value string) {
+	if value == "" {
+		delete(*keycloakUserProfile.Attributes, name)
+		return
+	}
+	(*keycloakUserProfile.Attributes)[name] = []string{value}
+}
+
 // Update updates the authorized user based on the provided Token
 func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 
@@ -96,6 +188,11 @@ func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
 	}
+	forceUsernameChange := ctx.ForceUsernameChange != nil && *ctx.ForceUsernameChange
+	if forceUsernameChange && !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to force the change of its username", *id)))
+		return ctx.Forbidden(jerrors)
+	}
 
 	return application.Transactional(c.db, func(appl application.Application) error {
 		identity, err := appl.Identities().Load(ctx, *id)
@@ -134,27 +231,43 @@ func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 		// to have everything - whatever we are updating, and whatever are not.
 		keycloakUserProfile := copyExistingKeycloakUserProfileInfo(keycloakUserExistingInfo)
 
+		// a new email is only pending until it is verified with the token sent to it
+		var emailVerificationToken string
 		updatedEmail := ctx.Payload.Data.Attributes.Email
 		if updatedEmail != nil {
-			isUnique, err := isEmailUnique(appl, *updatedEmail, *user)
+			email, err := normalizeEmail(*updatedEmail)
+			if err != nil {
+				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrBadRequest(err.Error()))
+				return ctx.BadRequest(jerrors)
+			}
+			isUnique, err := isEmailUnique(appl, email, *user)
 			if err != nil {
 				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, fmt.Sprintf("error updating idenitity with id %s and user with id %s", identity.ID, identity.UserID.UUID)))
 			}
 			if !isUnique {
-				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("email address: %s is already in use", *updatedEmail)))
+				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("email address: %s is already in use", email)))
 				return ctx.Conflict(jerrors)
 			}
-			user.Email = *updatedEmail
-			keycloakUserProfile.Email = updatedEmail
+			if email != user.Email {
+				emailVerificationToken, err = generateEmailVerificationToken()
+				if err != nil {
+					return jsonapi.JSONErrorResponse(ctx, err)
+				}
+				expiresAt := time.Now().Add(c.configuration.GetEmailVerificationExpiry())
+				user.PendingEmail = &email
+				user.EmailVerificationToken = &emailVerificationToken
+				user.EmailVerificationExpiresAt = &expiresAt
+			}
 		}
 
+		var usernameChange *account.UsernameChange
 		updatedUserName := ctx.Payload.Data.Attributes.Username
 		if updatedUserName != nil && *updatedUserName != identity.Username {
-			if identity.RegistrationCompleted {
+			if identity.RegistrationCompleted && !forceUsernameChange {
 				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("username cannot be updated more than once for idenitity id %s ", *id)))
 				return ctx.Forbidden(jerrors)
 			}
-			isUnique, err := isUsernameUnique(appl, *updatedUserName, *identity)
+			isUnique, err := isUsernameUnique(appl, *updatedUserName, *identity, c.configuration.GetUsernameReuseGracePeriod())
 			if err != nil {
 				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, fmt.Sprintf("error updating idenitity with id %s and user with id %s", identity.ID, identity.UserID.UUID)))
 			}
@@ -162,15 +275,26 @@ func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("username : %s is already in use", *updatedUserName)))
 				return ctx.Conflict(jerrors)
 			}
+			usernameChange = &account.UsernameChange{
+				IdentityID:  identity.ID,
+				OldUsername: identity.Username,
+				NewUsername: *updatedUserName,
+				ChangedBy:   *id,
+			}
 			identity.Username = *updatedUserName
 			identity.RegistrationCompleted = true
 			keycloakUserProfile.Username = updatedUserName
 		}
 
+		// the changes of the full name, the company and the context information are recorded as profile events
+		profileChanges := newProfileChanges(identity.ID, *id, *user)
+
+		// For the optional profile attributes below, a nil value leaves the attribute unchanged
+		// while an empty string clears it.
 		updatedBio := ctx.Payload.Data.Attributes.Bio
 		if updatedBio != nil {
 			user.Bio = *updatedBio
-			(*keycloakUserProfile.Attributes)[login.BioAttributeName] = []string{*updatedBio}
+			setKeycloakProfileAttribute(keycloakUserProfile, login.BioAttributeName, *updatedBio)
 		}
 		updatedFullName := ctx.Payload.Data.Attributes.FullName
 		if updatedFullName != nil {
@@ -191,40 +315,51 @@ func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 		updatedImageURL := ctx.Payload.Data.Attributes.ImageURL
 		if updatedImageURL != nil {
 			user.ImageURL = *updatedImageURL
-			(*keycloakUserProfile.Attributes)[login.ImageURLAttributeName] = []string{*updatedImageURL}
-
+			setKeycloakProfileAttribute(keycloakUserProfile, login.ImageURLAttributeName, *updatedImageURL)
 		}
 		updateURL := ctx.Payload.Data.Attributes.URL
 		if updateURL != nil {
 			user.URL = *updateURL
-			(*keycloakUserProfile.Attributes)[login.URLAttributeName] = []string{*updateURL}
+			setKeycloakProfileAttribute(keycloakUserProfile, login.URLAttributeName, *updateURL)
 		}
 
 		updatedCompany := ctx.Payload.Data.Attributes.Company
 		if updatedCompany != nil {
 			user.Company = *updatedCompany
-			(*keycloakUserProfile.Attributes)[login.CompanyAttributeName] = []string{*updatedCompany}
+			setKeycloakProfileAttribute(keycloakUserProfile, login.CompanyAttributeName, *updatedCompany)
 		}
 
 		// If none of the 'extra' attributes were present, we better make that section nil
 		// so that the Attributes section is omitted in the payload sent to KC
 
-		if updatedBio == nil && updatedImageURL == nil && updateURL == nil {
+		if updatedBio == nil && updatedImageURL == nil && updateURL == nil && updatedCompany == nil {
 			keycloakUserProfile.Attributes = nil
 		}
 
+		// when required, the company must be provided to complete the registration and cannot be cleared afterwards
+		if c.configuration.IsUserCompanyRequired() && (updatedCompany != nil || identity.RegistrationCompleted) && strings.TrimSpace(user.Company) == "" {
+			return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("company", user.Company).Expected("non-empty company"))
+		}
+
 		updatedContextInformation := ctx.Payload.Data.Attributes.ContextInformation
 		if updatedContextInformation != nil {
 			// if user.ContextInformation , we get to PATCH the ContextInformation field,
 			// instead of over-writing it altogether. Note: The PATCH-ing is only for the
 			// 1st level of JSON.
+			if err := validateContextInformationKeys(updatedContextInformation, c.configuration.GetUserContextInformationAllowedKeys()); err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
 			if user.ContextInformation == nil {
 				user.ContextInformation = workitem.Fields{}
 			}
 			for fieldName, fieldValue := range updatedContextInformation {
+				profileChanges.setContextInformation(fieldName, user.ContextInformation[fieldName], fieldValue)
 				// Save it as is, for short-term.
 				user.ContextInformation[fieldName] = fieldValue
 			}
+			if spaceID, ok := updatedContextInformation[contextInformationSpace].(string); ok && spaceID != "" {
+				recordRecentSpace(user.ContextInformation, spaceID, time.Now())
+			}
 		}
 
 		// The update of the keycloak needs to be attempted first because if that fails,
@@ -262,171 +397,1547 @@ func (c *UsersController) Update(ctx *app.UpdateUsersContext) error {
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, err)
 		}
+		if usernameChange != nil {
+			if err := appl.UsernameHistory().Create(ctx, usernameChange); err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+		}
+		if err := profileChanges.record(ctx, appl, *user); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 
 		c.userProfileService.Update(keycloakUserProfile, tokenString, accountAPIEndpoint)
+
+		if emailVerificationToken != "" {
+			if err := sendEmailVerification(ctx, c.mailer, ctx.RequestData, user.FullName, *user.PendingEmail, emailVerificationToken, c.configuration.GetEmailVerificationExpiry()); err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+		}
 		return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
 	})
 }
 
-func isUsernameUnique(appl application.Application, username string, identity account.Identity) (bool, error) {
-	usersWithSameUserName, err := appl.Identities().Query(account.IdentityFilterByUsername(username), account.IdentityFilterByProviderType(account.KeycloakIDP))
+// ListUsernameHistory lists the changes of the username of the given identity, the oldest first.
+// Only the identity itself and the tokens granted the admin scope are allowed to list them.
+func (c *UsersController) ListUsernameHistory(ctx *app.ListUsernameHistoryUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
 	if err != nil {
-		log.Error(context.Background(), map[string]interface{}{
-			"user_name": username,
-			"err":       err,
-		}, "error fetching users with username filter")
-		return false, err
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
 	}
-	for _, u := range usersWithSameUserName {
-		if u.UserID.UUID != identity.UserID.UUID {
-			return false, nil
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) && !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to list the username history of identity %s", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	var changes []account.UsernameChange
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Identities().Load(ctx, identityID); err != nil {
+			return errs.NewNotFoundError("identity", ctx.ID)
 		}
+		changes, err = appl.UsernameHistory().List(ctx, identityID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	return true, nil
+	data := make([]*app.UsernameChange, len(changes))
+	for i, change := range changes {
+		changeID := change.ID
+		data[i] = &app.UsernameChange{
+			Type: "usernamechanges",
+			ID:   &changeID,
+			Attributes: &app.UsernameChangeAttributes{
+				OldUsername: change.OldUsername,
+				NewUsername: change.NewUsername,
+				ChangedBy:   change.ChangedBy,
+				ChangedAt:   change.CreatedAt,
+			},
+		}
+	}
+	return ctx.OK(&app.UsernameChangeList{Data: data})
 }
 
-func isEmailUnique(appl application.Application, email string, user account.User) (bool, error) {
-	usersWithSameEmail, err := appl.Users().Query(account.UserFilterByEmail(email))
+// ListEvents lists the changes of the profile of the given identity, the most recent first.
+// Only the identity itself and the tokens granted the admin scope are allowed to list them.
+func (c *UsersController) ListEvents(ctx *app.ListEventsUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
 	if err != nil {
-		log.Error(context.Background(), map[string]interface{}{
-			"email": email,
-			"err":   err,
-		}, "error fetching identities with email filter")
-		return false, err
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
 	}
-	for _, u := range usersWithSameEmail {
-		if u.ID != user.ID {
-			return false, nil
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) && !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to list the profile events of identity %s", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
+	var events []account.ProfileEvent
+	var count int
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Identities().Load(ctx, identityID); err != nil {
+			return errs.NewNotFoundError("identity", ctx.ID)
 		}
+		events, count, err = appl.ProfileEvents().List(ctx, identityID, offset, limit)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	return true, nil
+	data := make([]*app.ProfileEvent, len(events))
+	for i, event := range events {
+		eventID := event.ID
+		data[i] = &app.ProfileEvent{
+			Type: "profileevents",
+			ID:   &eventID,
+			Attributes: &app.ProfileEventAttributes{
+				Field:     event.Field,
+				OldValue:  event.OldValue,
+				NewValue:  event.NewValue,
+				ChangedBy: event.ChangedBy,
+				ChangedAt: event.CreatedAt,
+			},
+		}
+	}
+	response := app.ProfileEventList{
+		Links: &app.PagingLinks{},
+		Meta:  &app.ProfileEventListMeta{TotalCount: count},
+		Data:  data,
+	}
+	setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(data), offset, limit, count)
+	return ctx.OK(&response)
 }
 
-// List runs the list action.
-func (c *UsersController) List(ctx *app.ListUsersContext) error {
-	return application.Transactional(c.db, func(appl application.Application) error {
-		var err error
-		var identities []*account.Identity
-		var users []*account.User
-		var result *app.UserArray
-		identityFilters := []func(*gorm.DB) *gorm.DB{}
-		userFilters := []func(*gorm.DB) *gorm.DB{}
+// profileChanges collects the changes of the profile of a user, to record them as profile events
+type profileChanges struct {
+	identityID uuid.UUID
+	changedBy  uuid.UUID
+	// the user before the changes
+	old                account.User
+	contextInformation []account.ProfileEvent
+}
 
-		var appIdentities []*app.IdentityData
+// newProfileChanges starts collecting the changes made by the given identity to the profile of the given user
+func newProfileChanges(identityID, changedBy uuid.UUID, user account.User) *profileChanges {
+	return &profileChanges{identityID: identityID, changedBy: changedBy, old: user}
+}
 
-		/*
-			There are 2 database tables we fetch the data from : identities , users
-			First, we filter on the attributes of identities table - providerType , username
-			After that we use the above result to cummulatively filter on users  - email , company
-		*/
+// setContextInformation collects the change of the value of the given key of the context information
+func (c *profileChanges) setContextInformation(key string, oldValue, newValue interface{}) {
+	c.add(&c.contextInformation, account.ProfileFieldContextInformation+"."+key, profileEventValue(oldValue), profileEventValue(newValue))
+}
 
-		/*** Start filtering on Identities table ****/
+// events returns the events recording the collected changes along with the changes from the user before the changes
+// to the given user, if any
+func (c *profileChanges) events(user account.User) []account.ProfileEvent {
+	var events []account.ProfileEvent
+	c.add(&events, account.ProfileFieldFullName, &c.old.FullName, &user.FullName)
+	c.add(&events, account.ProfileFieldEmail, &c.old.Email, &user.Email)
+	c.add(&events, account.ProfileFieldCompany, &c.old.Company, &user.Company)
+	return append(events, c.contextInformation...)
+}
 
-		if ctx.FilterUsername != nil {
-			identityFilters = append(identityFilters, account.IdentityFilterByUsername(*ctx.FilterUsername))
-		}
-		if ctx.FilterRegistrationCompleted != nil {
-			identityFilters = append(identityFilters, account.IdentityFilterByRegistrationCompleted(*ctx.FilterRegistrationCompleted))
+// record records the events returned for the given user
+func (c *profileChanges) record(ctx context.Context, appl application.Application, user account.User) error {
+	for _, event := range c.events(user) {
+		if err := appl.ProfileEvents().Create(ctx, &event); err != nil {
+			return err
 		}
-		// Add more filters when needed , here. ..
+	}
+	return nil
+}
 
-		if len(identityFilters) != 0 {
-			identityFilters = append(identityFilters, account.IdentityFilterByProviderType(account.KeycloakIDP))
-			identityFilters = append(identityFilters, account.IdentityWithUser())
+func (c *profileChanges) add(events *[]account.ProfileEvent, field string, oldValue, newValue *string) {
+	if oldValue == newValue || (oldValue != nil && newValue != nil && *oldValue == *newValue) {
+		return
+	}
+	*events = append(*events, account.ProfileEvent{
+		IdentityID: c.identityID,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		ChangedBy:  c.changedBy,
+	})
+}
 
-			// From a data model perspective, we are querying by identity ( and not user )
-			identities, err = appl.Identities().Query(identityFilters...)
+// profileEventValue returns the value to record in a profile event for the given value of the context information:
+// the strings are recorded as is, and the other values as JSON
+func profileEventValue(value interface{}) *string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return &v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s := fmt.Sprint(v)
+			return &s
+		}
+		s := string(b)
+		return &s
+	}
+}
 
-			if err != nil {
-				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching identities with filter(s)"))
-			}
+// generateEmailVerificationToken returns a new random token to verify an email
+func generateEmailVerificationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errs.NewInternalError(err.Error())
+	}
+	return hex.EncodeToString(b), nil
+}
 
-			// cumulatively filter out those not matcing the user-based filters.
-			for _, identity := range identities {
-				// this is where you keep trying all other filters one by one for 'user' fields like email.
-				if ctx.FilterEmail == nil || identity.User.Email == *ctx.FilterEmail {
+// sendEmailVerification sends the link to verify the given email, with the given token, to that email
+func sendEmailVerification(ctx context.Context, m mailer.Mailer, request *goa.RequestData, fullName, email, token string, expiry time.Duration) error {
+	link := rest.AbsoluteURL(request, "/api/users/verifyemail?token="+url.QueryEscape(token))
+	body := fmt.Sprintf(`Hello %s,
 
-					// if one or more 'User' filters are present, check if it's satified, if Not, proceed with ConvertUser
+Please verify your new email address by opening the following link:
 
-					appIdentity := ConvertUser(ctx.RequestData, identity, &identity.User)
-					appIdentities = append(appIdentities, appIdentity.Data)
-				}
-			}
-			result = &app.UserArray{Data: appIdentities}
+%s
 
-		} else {
+This link expires in %s. If you did not ask to add this email address, you can ignore this email.
+`, fullName, link, expiry)
+	return m.Send(ctx, email, "Verify your new email address", body)
+}
 
-			/*** Start filtering on Users table ****/
+// VerifyEmail runs the verify-email action: the email of the user to whom the given token was sent is replaced by
+// the email the token was sent to, in Keycloak first and then in the platform db. If the token was sent to
+// a secondary email of the user instead, that email is marked as verified.
+func (c *UsersController) VerifyEmail(ctx *app.VerifyEmailUsersContext) error {
+	return application.Transactional(c.db, func(appl application.Application) error {
+		users, err := appl.Users().Query(account.UserFilterByEmailVerificationToken(ctx.Token))
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching users by email verification token"))
+		}
+		if len(users) == 0 || users[0].PendingEmail == nil {
+			return c.verifySecondaryEmail(ctx, appl)
+		}
+		user := users[0]
+		if user.EmailVerificationExpiresAt == nil || user.EmailVerificationExpiresAt.Before(time.Now()) {
+			return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("token", ctx.Token).Expected("an email verification token which has not expired"))
+		}
+		email := *user.PendingEmail
+		// the email may have been taken by another user since it was requested
+		isUnique, err := isEmailUnique(appl, email, *user)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, fmt.Sprintf("error verifying the email of user with id %s", user.ID)))
+		}
+		if !isUnique {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("email address: %s is already in use", email)))
+			return ctx.Conflict(jerrors)
+		}
+		identity, err := loadKeyCloakIdentity(appl, user)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errs.NewNotFoundError("identity", user.ID.String()))
+		}
+		// the email must be updated in Keycloak as well, otherwise it would be restored at the next login of the user
+		if err := c.emailManager.UpdateEmail(ctx, ctx.RequestData, identity.ID.String(), email); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		// the change is recorded as made by the user, who received the token
+		profileChanges := newProfileChanges(identity.ID, identity.ID, *user)
+		user.Email = email
+		user.PendingEmail = nil
+		user.EmailVerificationToken = nil
+		user.EmailVerificationExpiresAt = nil
+		if err := appl.Users().Save(ctx, user); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		if err := profileChanges.record(ctx, appl, *user); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		log.Info(ctx, map[string]interface{}{
+			"user_id": user.ID,
+		}, "email verified")
+		return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+	})
+}
 
-			if ctx.FilterEmail != nil {
-				userFilters = append(userFilters, account.UserFilterByEmail(*ctx.FilterEmail))
-			}
-			// .. Add other filters in future when needed into the userFilters slice in the above manner.
+// verifySecondaryEmail marks the secondary email to which the token of the given verify-email action was sent as verified
+func (c *UsersController) verifySecondaryEmail(ctx *app.VerifyEmailUsersContext, appl application.Application) error {
+	emails, err := appl.UserEmails().Query(account.UserEmailFilterByVerificationToken(ctx.Token))
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching user emails by verification token"))
+	}
+	if len(emails) == 0 {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewNotFoundError("email verification token", ctx.Token))
+	}
+	userEmail := emails[0]
+	if userEmail.VerificationExpiresAt == nil || userEmail.VerificationExpiresAt.Before(time.Now()) {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("token", ctx.Token).Expected("an email verification token which has not expired"))
+	}
+	user, err := appl.Users().Load(ctx, userEmail.UserID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewNotFoundError("user", userEmail.UserID.String()))
+	}
+	identity, err := loadKeyCloakIdentity(appl, user)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewNotFoundError("identity", user.ID.String()))
+	}
+	userEmail.Verified = true
+	userEmail.VerificationToken = nil
+	userEmail.VerificationExpiresAt = nil
+	if err := appl.UserEmails().Save(ctx, &userEmail); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"user_id":  user.ID,
+		"email_id": userEmail.ID,
+	}, "secondary email verified")
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+}
 
-			if len(userFilters) != 0 {
-				users, err = appl.Users().Query(userFilters...)
-			} else {
-				// Not breaking the existing API - If no filters were passed, we fall back on the good old 'list everything'.
-				// FIXME We should remove this when fabric8io/fabric8-planner#1538 is fixed
-				users, err = appl.Users().List(ctx.Context)
+// Deactivate deactivates the authenticated user, whose API calls are then rejected by the JWT middleware. The ownership
+// of the spaces of the user is transferred to the new owner given in the payload, who must be a collaborator of each of
+// these spaces.
+func (c *UsersController) Deactivate(ctx *app.DeactivateUsersContext) error {
+	id, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	var identity *account.Identity
+	var user *account.User
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err = appl.Identities().Load(ctx, *id)
+		if err != nil || identity == nil {
+			log.Error(ctx, map[string]interface{}{
+				"identity_id": id,
+			}, "auth token contains id %s of unknown Identity", *id)
+			return errs.NewUnauthorizedError(fmt.Sprintf("auth token contains id %s of unknown Identity", *id))
+		}
+		ownedSpaces, _, err := appl.Spaces().LoadByOwner(ctx, id, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(ownedSpaces) > 0 {
+			newOwnerID := ctx.Payload.NewOwner
+			if newOwnerID == nil || uuid.Equal(*newOwnerID, *id) {
+				return errs.NewBadParameterError("newOwner", newOwnerID).Expected(fmt.Sprintf("the ID of another collaborator to become the owner of the %d space(s) of the user", len(ownedSpaces)))
 			}
-
-			if err != nil {
-				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching users"))
+			if _, err := appl.Identities().Load(ctx, *newOwnerID); err != nil {
+				return errs.NewNotFoundError("identity", newOwnerID.String())
 			}
-			result, err = LoadKeyCloakIdentities(appl, ctx.RequestData, users)
-			if err != nil {
-				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching keycloak identities"))
+			for _, s := range ownedSpaces {
+				resource, err := appl.SpaceResources().LoadBySpace(ctx, &s.ID)
+				if err != nil {
+					return err
+				}
+				policy, _, err := c.policyManager.GetPolicy(ctx, ctx.RequestData, resource.PolicyID)
+				if err != nil {
+					return errs.NewInternalError(err.Error())
+				}
+				if !policy.HasUser(newOwnerID.String()) {
+					return errs.NewBadParameterError("newOwner", *newOwnerID).Expected("a collaborator of the space " + s.Name)
+				}
+				s.OwnerId = *newOwnerID
+				if _, err := appl.Spaces().Save(ctx, &s); err != nil {
+					return err
+				}
+				log.Info(ctx, map[string]interface{}{
+					"space_id":     s.ID,
+					"identity_id":  id,
+					"new_owner_id": newOwnerID,
+				}, "space ownership transferred on owner deactivation")
 			}
 		}
-		if result == nil {
-			result = &app.UserArray{Data: make([]*app.IdentityData, 0)}
+		if identity.DeactivatedAt == nil {
+			now := time.Now()
+			identity.DeactivatedAt = &now
+			if err := appl.Identities().Save(ctx, identity); err != nil {
+				return err
+			}
+		}
+		if identity.UserID.Valid {
+			user, err = appl.Users().Load(ctx, identity.UserID.UUID)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Can't load user with id %s", identity.UserID.UUID))
+			}
+			// the user is deactivated along with the identity, so that the administrators find it among the
+			// deactivated users and can reactivate it
+			if user.State != account.UserStateDeactivated && user.State != account.UserStateBanned {
+				user.State = account.UserStateDeactivated
+				if err := appl.Users().Save(ctx, user); err != nil {
+					return err
+				}
+			}
 		}
-		return ctx.OK(result)
+		return nil
 	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
 }
 
-// LoadKeyCloakIdentities loads keycloak identies for the users and converts the users into REST representation
-func LoadKeyCloakIdentities(appl application.Application, request *goa.RequestData, users []*account.User) (*app.UserArray, error) {
-	data := make([]*app.IdentityData, len(users))
-	for i, user := range users {
-		identity, err := loadKeyCloakIdentity(appl, user)
+// Delete deprovisions the user with the given identity ID: the user and all their identities are soft-deleted,
+// removed from the collaborators policies of the spaces and their sessions are revoked, both locally and in Keycloak.
+// Only the tokens granted the admin scope are allowed to delete users, and the users still owning spaces
+// must transfer their ownership first.
+func (c *UsersController) Delete(ctx *app.DeleteUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to delete users", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	var identity *account.Identity
+	var identities []*account.Identity
+	var policyIDs []string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err = appl.Identities().Load(ctx, identityID)
 		if err != nil {
-			return nil, err
+			return errs.NewNotFoundError("identity", ctx.ID)
+		}
+		identities = []*account.Identity{identity}
+		if identity.UserID.Valid {
+			identities, err = appl.Identities().Query(account.IdentityFilterByUserID(identity.UserID.UUID))
+			if err != nil {
+				return err
+			}
+		}
+		for _, i := range identities {
+			ownedSpaces, _, err := appl.Spaces().LoadByOwner(ctx, &i.ID, nil, nil)
+			if err != nil {
+				return err
+			}
+			if len(ownedSpaces) > 0 {
+				return errs.NewBadParameterError("id", ctx.ID).Expected(fmt.Sprintf("a user owning no space, the ownership of the %d space(s) of the user must be transferred first", len(ownedSpaces)))
+			}
+		}
+		policyIDs, err = collaborationPolicyIDs(ctx, appl, identities)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	// the identities are removed from the policies first, and added back if they can't be deleted locally afterwards,
+	// so that a failure never leaves a user who can still log in without their collaborations or the other way around
+	removed, err := c.removeFromPolicies(ctx, ctx.RequestData, policyIDs, identities)
+	if err != nil {
+		c.restoreInPolicies(ctx, ctx.RequestData, removed)
+		return jsonapi.JSONErrorResponse(ctx, errs.NewInternalError(err.Error()))
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		for _, i := range identities {
+			sessions, err := appl.Sessions().ListActive(ctx, i.ID)
+			if err != nil {
+				return err
+			}
+			for _, s := range sessions {
+				if err := appl.Sessions().Revoke(ctx, s.ID); err != nil {
+					return err
+				}
+			}
+			if err := appl.Identities().Delete(ctx, i.ID); err != nil {
+				return err
+			}
+		}
+		if identity.UserID.Valid {
+			if err := appl.UserEmails().DeleteByUser(ctx, identity.UserID.UUID); err != nil {
+				return err
+			}
+			if err := appl.Users().Delete(ctx, identity.UserID.UUID); err != nil {
+				return err
+			}
 		}
-		appIdentity := ConvertUser(request, identity, user)
-		data[i] = appIdentity.Data
+		// the Keycloak sessions are revoked last, before the deletion is committed: should the commit fail,
+		// the user only has to log in again
+		for _, i := range identities {
+			if i.ProviderType != account.KeycloakIDP {
+				continue
+			}
+			if err := c.sessionManager.RevokeSessions(ctx, ctx.RequestData, i.ID.String()); err != nil {
+				return errs.NewInternalError(err.Error())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.restoreInPolicies(ctx, ctx.RequestData, removed)
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	return &app.UserArray{Data: data}, nil
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"deleted_by":  *currentIdentityID,
+	}, "user deprovisioned")
+	return ctx.OK([]byte{})
 }
 
-func loadKeyCloakIdentity(appl application.Application, user *account.User) (*account.Identity, error) {
-	identities, err := appl.Identities().Query(account.IdentityFilterByUserID(user.ID))
+// removeFromPolicies removes the given identities from the collaborators policies with the given IDs, and returns
+// the role of the removed identities in each updated policy, even if an error occurred after some policies were updated
+func (c *UsersController) removeFromPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string, identities []*account.Identity) (map[string]map[string]string, error) {
+	removed := map[string]map[string]string{}
+	// all the policies are obtained at once, only the ones to update are loaded again along with their PAT
+	policies, err := c.policyManager.GetPolicies(ctx, request, policyIDs)
 	if err != nil {
-		return nil, err
+		return removed, err
 	}
-	for _, identity := range identities {
-		if identity.ProviderType == account.KeycloakIDP {
-			return identity, nil
+	for i, policy := range policies {
+		member := false
+		for _, identity := range identities {
+			member = member || policy.HasUser(identity.ID.String())
+		}
+		if !member {
+			continue
+		}
+		policy, pat, err := c.policyManager.GetPolicy(ctx, request, policyIDs[i])
+		if err != nil {
+			return removed, err
+		}
+		roles := map[string]string{}
+		for _, identity := range identities {
+			if role := policy.UserRole(identity.ID.String()); role != "" {
+				roles[identity.ID.String()] = role
+			}
+			policy.RemoveUserFromPolicy(identity.ID.String())
+		}
+		if err := c.policyManager.UpdatePolicy(ctx, request, *policy, *pat); err != nil {
+			return removed, err
 		}
+		removed[policyIDs[i]] = roles
+		if err := invalidateCachedCollaborators(ctx, c.db, policyIDs[i]); err != nil {
+			return removed, err
+		}
+		log.Info(ctx, map[string]interface{}{
+			"policy_id": policyIDs[i],
+		}, "deprovisioned user removed from the collaborators policy")
 	}
-	return nil, fmt.Errorf("Can't find Keycloak Identity for user %s", user.Email)
+	return removed, nil
 }
 
-// ConvertUser converts a complete Identity object into REST representation
-func ConvertUser(request *goa.RequestData, identity *account.Identity, user *account.User) *app.Identity {
-	uuid := identity.ID
-	id := uuid.String()
+// restoreInPolicies adds the identities removed by removeFromPolicies back to the collaborators policies, with their role.
+// The policies which can't be restored are logged, since the error which caused the restoration is the one reported.
+func (c *UsersController) restoreInPolicies(ctx context.Context, request *goa.RequestData, removed map[string]map[string]string) {
+	for policyID, roles := range removed {
+		policy, pat, err := c.policyManager.GetPolicy(ctx, request, policyID)
+		if err == nil {
+			for identityID, role := range roles {
+				policy.AddUserToPolicy(identityID)
+				policy.SetUserRole(identityID, role)
+			}
+			err = c.policyManager.UpdatePolicy(ctx, request, *policy, *pat)
+		}
+		if err == nil {
+			err = invalidateCachedCollaborators(ctx, c.db, policyID)
+		}
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"policy_id": policyID,
+				"err":       err,
+			}, "unable to restore the collaborators policy of a user who could not be deprovisioned")
+			continue
+		}
+		log.Info(ctx, map[string]interface{}{
+			"policy_id": policyID,
+		}, "collaborators policy restored after a failed deprovisioning")
+	}
+}
+
+// Merge runs the merge action: the duplicate user of the source identity is merged into the user of the given identity.
+// The identities of the duplicate user are moved to the user, and the work items, comments and collaborations of the
+// spaces of these identities are transferred to the given identity before the duplicate user is deleted.
+// Only the tokens granted the admin scope are allowed to merge users.
+func (c *UsersController) Merge(ctx *app.MergeUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to merge users", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	sourceID := ctx.Payload.Source
+	var identity *account.Identity
+	var user *account.User
+	var sourceIdentities []*account.Identity
+	var policyIDs []string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, user, err = loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		_, sourceUser, err := loadIdentityAndUser(ctx, appl, sourceID)
+		if err != nil {
+			return err
+		}
+		if uuid.Equal(sourceUser.ID, user.ID) {
+			return errs.NewBadParameterError("source", sourceID).Expected("an identity of another user")
+		}
+		sourceIdentities, err = appl.Identities().Query(account.IdentityFilterByUserID(sourceUser.ID))
+		if err != nil {
+			return err
+		}
+		policyIDs, err = collaborationPolicyIDs(ctx, appl, sourceIdentities)
+		if err != nil {
+			return err
+		}
+		for _, i := range sourceIdentities {
+			if err := appl.WorkItems().ReplaceIdentity(ctx, i.ID, identity.ID); err != nil {
+				return err
+			}
+			if err := appl.Comments().ReplaceCreator(ctx, i.ID, identity.ID); err != nil {
+				return err
+			}
+			i.UserID = account.NullUUID{UUID: user.ID, Valid: true}
+			if err := appl.Identities().Save(ctx, i); err != nil {
+				return err
+			}
+		}
+		if err := appl.UserEmails().DeleteByUser(ctx, sourceUser.ID); err != nil {
+			return err
+		}
+		if err := appl.Users().Delete(ctx, sourceUser.ID); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"source_id":   sourceID,
+		"merged_by":   *currentIdentityID,
+	}, "users merged")
+	// the collaborators policies can only be updated once the merge is committed, since Keycloak can't be rolled back
+	// along with the database: each policy is retried on its own, and the ones which still can't be updated are reported
+	if err := c.replaceInPolicies(ctx, ctx.RequestData, policyIDs, sourceIdentities, *identity); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewInternalError(err.Error()))
+	}
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+}
+
+// mergePolicyUpdateAttempts the number of times the update of a collaborators policy is attempted when merging users
+const mergePolicyUpdateAttempts = 3
+
+// replaceInPolicies replaces the given identities by the new collaborator in the collaborators policies with the given IDs.
+// The new collaborator is given the role of the first replaced identity, unless already listed. The policies which can't
+// be updated after a few attempts are skipped, and reported in the returned error once the others are updated.
+func (c *UsersController) replaceInPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string, identities []*account.Identity, collaborator account.Identity) error {
+	// all the policies are obtained at once, only the ones to update are loaded again along with their PAT
+	policies, err := c.policyManager.GetPolicies(ctx, request, policyIDs)
+	if err != nil {
+		return errors.Wrap(err, "the users were merged but their collaborators policies could not be loaded")
+	}
+	var failed []string
+	for i, policy := range policies {
+		member := false
+		for _, identity := range identities {
+			member = member || policy.HasUser(identity.ID.String())
+		}
+		if !member {
+			continue
+		}
+		for attempt := 1; ; attempt++ {
+			err = c.replaceInPolicy(ctx, request, policyIDs[i], identities, collaborator)
+			if err == nil || attempt == mergePolicyUpdateAttempts {
+				break
+			}
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"policy_id":       policyIDs[i],
+				"collaborator_id": collaborator.ID,
+				"err":             err,
+			}, "unable to replace the merged user in the collaborators policy")
+			failed = append(failed, policyIDs[i])
+			continue
+		}
+		log.Info(ctx, map[string]interface{}{
+			"policy_id": policyIDs[i],
+		}, "merged user replaced in the collaborators policy")
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("the users were merged but the collaborators policies %s could not be updated", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// replaceInPolicy replaces the given identities by the new collaborator in the collaborators policy with the given ID
+func (c *UsersController) replaceInPolicy(ctx context.Context, request *goa.RequestData, policyID string, identities []*account.Identity, collaborator account.Identity) error {
+	policy, pat, err := c.policyManager.GetPolicy(ctx, request, policyID)
+	if err != nil {
+		return err
+	}
+	role := policy.UserRole(collaborator.ID.String())
+	for _, identity := range identities {
+		if role == "" {
+			role = policy.UserRole(identity.ID.String())
+		}
+		policy.RemoveUserFromPolicy(identity.ID.String())
+	}
+	policy.AddUserToPolicy(collaborator.ID.String())
+	policy.SetUserRole(collaborator.ID.String(), role)
+	if err := c.policyManager.UpdatePolicy(ctx, request, *policy, *pat); err != nil {
+		return err
+	}
+	return invalidateCachedCollaborators(ctx, c.db, policyID)
+}
+
+// CompleteRegistration runs the complete-registration action: the registration of the authenticated user is completed
+// once the user has a username and an email verified by Keycloak, along with a company when the company is required.
+// The tenant of the user is then initialized. The registration can only be completed once.
+func (c *UsersController) CompleteRegistration(ctx *app.CompleteRegistrationUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to complete the registration of identity %s", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	accountAPIEndpoint, err := c.configuration.GetKeycloakAccountEndpoint(ctx.RequestData)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	keycloakUserProfile, err := c.userProfileService.Get(goajwt.ContextJWT(ctx).Raw, accountAPIEndpoint)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"identity_id": identityID,
+			"err":         err,
+		}, "failed to get the keycloak account")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var identity *account.Identity
+	var user *account.User
+	completed := false
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, user, err = loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		if identity.RegistrationCompleted {
+			return nil
+		}
+		if strings.TrimSpace(identity.Username) == "" {
+			return errs.NewBadParameterError("username", identity.Username).Expected("non-empty username")
+		}
+		if user.Email == "" || keycloakUserProfile.EmailVerified == nil || !*keycloakUserProfile.EmailVerified {
+			return errs.NewBadParameterError("email", user.Email).Expected("verified email")
+		}
+		if c.configuration.IsUserCompanyRequired() && strings.TrimSpace(user.Company) == "" {
+			return errs.NewBadParameterError("company", user.Company).Expected("non-empty company")
+		}
+		// the registration may have been completed concurrently since the identity was loaded
+		completed, err = appl.Identities().CompleteRegistration(ctx, identityID)
+		identity.RegistrationCompleted = true
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !completed {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("the registration of identity %s is already completed", identityID)))
+		return ctx.Conflict(jerrors)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+	}, "registration completed")
+	if c.InitTenant != nil {
+		go func(ctx context.Context) {
+			if err := c.InitTenant(ctx); err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"identity_id": identityID,
+					"err":         err,
+				}, "unable to initialize the tenant after the registration")
+			}
+		}(ctx)
+	}
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+}
+
+// UpdateState sets the state of the user of the given identity. The API calls of the deactivated and banned users
+// are rejected by the JWT middleware, and the identities of the users set active again are reactivated as well.
+// Only the tokens granted the admin scope are allowed to set the state, as for the deletion of the users.
+func (c *UsersController) UpdateState(ctx *app.UpdateStateUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to set the state of the users", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	var identity *account.Identity
+	var user *account.User
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err = appl.Identities().Load(ctx, identityID)
+		if err != nil || !identity.UserID.Valid {
+			return errs.NewNotFoundError("identity", ctx.ID)
+		}
+		user, err = appl.Users().Load(ctx, identity.UserID.UUID)
+		if err != nil {
+			return errs.NewNotFoundError("user", identity.UserID.UUID.String())
+		}
+		user.State = ctx.Payload.State
+		if err := appl.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		if user.State != account.UserStateActive {
+			return nil
+		}
+		// reactivating the user reactivates its identities, which may have been deactivated on their own
+		identities, err := appl.Identities().Query(account.IdentityFilterByUserID(user.ID))
+		if err != nil {
+			return err
+		}
+		for _, i := range identities {
+			if i.DeactivatedAt == nil {
+				continue
+			}
+			i.DeactivatedAt = nil
+			if err := appl.Identities().Save(ctx, i); err != nil {
+				return err
+			}
+			if uuid.Equal(i.ID, identity.ID) {
+				identity.DeactivatedAt = nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"state":       user.State,
+		"admin_id":    currentIdentityID,
+	}, "user state updated")
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+}
+
+// ListMemberships lists all the spaces which the given identity belongs to, along with its role in each space.
+// Only the tokens granted the admin scope are allowed to list the memberships.
+func (c *UsersController) ListMemberships(ctx *app.ListMembershipsUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to list the memberships of other identities", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	memberships := []*app.SpaceMembership{}
+	// the spaces which are not owned by the identity, indexed by their policy ID
+	spacesByPolicy := map[string]space.Space{}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Identities().Load(ctx, identityID); err != nil {
+			return errs.NewNotFoundError("identity", ctx.ID)
+		}
+		spaces, _, err := appl.Spaces().List(ctx, nil, nil)
+		if err != nil {
+			return err
+		}
+		resources, err := appl.SpaceResources().List(ctx)
+		if err != nil {
+			return err
+		}
+		policyIDs := make(map[uuid.UUID]string, len(resources))
+		for _, r := range resources {
+			policyIDs[r.SpaceID] = r.PolicyID
+		}
+		for _, s := range spaces {
+			if uuid.Equal(s.OwnerId, identityID) {
+				memberships = append(memberships, &app.SpaceMembership{SpaceID: s.ID, SpaceName: s.Name, Role: SpaceRoleOwner})
+			} else if policyID, ok := policyIDs[s.ID]; ok {
+				spacesByPolicy[policyID] = s
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	// all the policies are obtained at once, outside of the transaction
+	policyIDs := make([]string, 0, len(spacesByPolicy))
+	for policyID := range spacesByPolicy {
+		policyIDs = append(policyIDs, policyID)
+	}
+	policies, err := c.policyManager.GetPolicies(ctx, ctx.RequestData, policyIDs)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewInternalError(err.Error()))
+	}
+	for i, policy := range policies {
+		if role := policy.UserRole(identityID.String()); role != "" {
+			s := spacesByPolicy[policyIDs[i]]
+			memberships = append(memberships, &app.SpaceMembership{SpaceID: s.ID, SpaceName: s.Name, Role: role})
+		}
+	}
+	sort.Sort(spaceMembershipsByName(memberships))
+	return ctx.OK(&app.SpaceMembershipArray{
+		IdentityID: identityID,
+		Data:       memberships,
+	})
+}
+
+// loadCollaboratingSpaces returns the spaces which the given identity collaborates on without owning them,
+// sorted by name. The collaborators of the space policies are read from their cache, which the reconciliation
+// keeps in sync with Keycloak, along with the spaces of the teams of the identity.
+func (c *UsersController) loadCollaboratingSpaces(ctx context.Context, appl application.Application, identityID uuid.UUID) ([]space.Space, error) {
+	spaceIDs, err := appl.SpaceCollaborators().ListSpaces(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+	teamSpaceIDs, err := appl.Teams().ListSpacesByMember(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[uuid.UUID]bool, len(spaceIDs)+len(teamSpaceIDs))
+	result := []space.Space{}
+	for _, spaceID := range append(spaceIDs, teamSpaceIDs...) {
+		if listed[spaceID] {
+			continue
+		}
+		listed[spaceID] = true
+		s, err := appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			if _, ok := err.(errs.NotFoundError); ok {
+				continue
+			}
+			return nil, err
+		}
+		if !uuid.Equal(s.OwnerId, identityID) {
+			result = append(result, *s)
+		}
+	}
+	sort.Sort(spacesByName(result))
+	return result, nil
+}
+
+// spacesRelationship returns the relationship to the given spaces
+func spacesRelationship(spaces []space.Space) *app.RelationGenericList {
+	data := make([]*app.GenericData, len(spaces))
+	for i, s := range spaces {
+		t := APIStringTypeSpace
+		id := s.ID.String()
+		data[i] = &app.GenericData{
+			Type: &t,
+			ID:   &id,
+		}
+	}
+	return &app.RelationGenericList{Data: data}
+}
+
+// latest returns the latest of the given times
+func latest(times ...time.Time) time.Time {
+	var result time.Time
+	for _, t := range times {
+		if t.After(result) {
+			result = t
+		}
+	}
+	return result
+}
+
+type spacesByName []space.Space
+
+func (s spacesByName) Len() int           { return len(s) }
+func (s spacesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s spacesByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// isAdminIdentity returns true if the token in the given context was granted the admin scope, which is required by
+// all the administrative actions. The personal access tokens are only granted the scopes they were created with,
+// and the impersonation tokens are granted none, so that impersonating an administrator grants none of their privileges.
+func isAdminIdentity(ctx context.Context) bool {
+	return token.ContextHasScope(ctx, token.ScopeAdminUsers) && token.ContextImpersonator(ctx) == nil
+}
+
+type spaceMembershipsByName []*app.SpaceMembership
+
+func (m spaceMembershipsByName) Len() int           { return len(m) }
+func (m spaceMembershipsByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m spaceMembershipsByName) Less(i, j int) bool { return m[i].SpaceName < m[j].SpaceName }
+
+// validateContextInformationKeys returns a BadParameterError if a key of the given context information
+// is not in the given list of allowed keys. An empty list allows any key. Unsetting a key (with a nil value)
+// is always allowed, so that keys which were stored before being disallowed can be cleaned up.
+func validateContextInformationKeys(contextInformation map[string]interface{}, allowedKeys []string) error {
+	if len(allowedKeys) == 0 {
+		return nil
+	}
+	allowed := make(map[string]bool, len(allowedKeys))
+	for _, k := range allowedKeys {
+		allowed[k] = true
+	}
+	for k, v := range contextInformation {
+		if v != nil && !allowed[k] {
+			return errs.NewBadParameterError("contextInformation", k).Expected("one of " + strings.Join(allowedKeys, ", "))
+		}
+	}
+	return nil
+}
+
+// Keys of the context information of a user. The recent spaces are maintained by the server: each time the
+// current space is updated, it is moved at the beginning of the recent spaces along with the time of the visit.
+const (
+	contextInformationSpace        = "space"
+	contextInformationRecentSpaces = "recentSpaces"
+	recentSpaceID                  = "space"
+	recentSpaceVisitedAt           = "visited_at"
+	maxRecentSpaces                = 10
+)
+
+// recentSpace is a space visited by a user, as stored in the context information of the user
+type recentSpace struct {
+	SpaceID   string
+	VisitedAt time.Time
+}
+
+// recentSpaces returns the recent spaces stored in the given context information, the most recently visited first.
+// Malformed entries are skipped.
+func recentSpaces(contextInformation workitem.Fields) []recentSpace {
+	entries, _ := contextInformation[contextInformationRecentSpaces].([]interface{})
+	result := make([]recentSpace, 0, len(entries))
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		spaceID, _ := fields[recentSpaceID].(string)
+		visitedAt, _ := fields[recentSpaceVisitedAt].(string)
+		t, err := time.Parse(time.RFC3339Nano, visitedAt)
+		if spaceID == "" || err != nil {
+			continue
+		}
+		result = append(result, recentSpace{SpaceID: spaceID, VisitedAt: t})
+	}
+	sort.Stable(recentSpacesByVisit(result))
+	return result
+}
+
+// recentSpacesByVisit sorts recent spaces by visit time, the most recent first
+type recentSpacesByVisit []recentSpace
+
+func (r recentSpacesByVisit) Len() int           { return len(r) }
+func (r recentSpacesByVisit) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r recentSpacesByVisit) Less(i, j int) bool { return r[i].VisitedAt.After(r[j].VisitedAt) }
+
+// recordRecentSpace records the visit of the given space at the given time in the recent spaces of the given
+// context information, keeping only the latest visit of each space and the maxRecentSpaces most recent spaces.
+func recordRecentSpace(contextInformation workitem.Fields, spaceID string, visitedAt time.Time) {
+	entries := []interface{}{
+		map[string]interface{}{
+			recentSpaceID:        spaceID,
+			recentSpaceVisitedAt: visitedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	for _, s := range recentSpaces(contextInformation) {
+		if len(entries) == maxRecentSpaces {
+			break
+		}
+		if s.SpaceID != spaceID {
+			entries = append(entries, map[string]interface{}{
+				recentSpaceID:        s.SpaceID,
+				recentSpaceVisitedAt: s.VisitedAt.UTC().Format(time.RFC3339Nano),
+			})
+		}
+	}
+	contextInformation[contextInformationRecentSpaces] = entries
+}
+
+// isUsernameUnique returns false if the username is used by another user. The username of a
+// deactivated identity remains reserved until the given grace period has elapsed since its deactivation.
+func isUsernameUnique(appl application.Application, username string, identity account.Identity, gracePeriod time.Duration) (bool, error) {
+	// all identities with the username are needed, as a deactivated identity may share it with the identity which reused it
+	usersWithSameUserName, err := appl.Identities().Query(account.IdentityFilterByUsernames([]string{username}), account.IdentityFilterByProviderType(account.KeycloakIDP))
+	if err != nil {
+		log.Error(context.Background(), map[string]interface{}{
+			"user_name": username,
+			"err":       err,
+		}, "error fetching users with username filter")
+		return false, err
+	}
+	for _, u := range usersWithSameUserName {
+		if u.DeactivatedAt != nil && time.Since(*u.DeactivatedAt) >= gracePeriod {
+			continue
+		}
+		if u.UserID.UUID != identity.UserID.UUID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// normalizeEmail trims the given email address and converts it to lower case. An error is returned
+// if it is not a bare email address, such as "john@example.com".
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", errors.Errorf("invalid email address: %s", email)
+	}
+	return strings.ToLower(email), nil
+}
+
+// isEmailUnique returns false if the email address is used by another user, either as their email
+// or as one of their secondary emails, regardless of its case.
+func isEmailUnique(appl application.Application, email string, user account.User) (bool, error) {
+	usersWithSameEmail, err := appl.Users().Query(account.UserFilterByEmailIgnoringCase(email))
+	if err != nil {
+		log.Error(context.Background(), map[string]interface{}{
+			"email": email,
+			"err":   err,
+		}, "error fetching identities with email filter")
+		return false, err
+	}
+	for _, u := range usersWithSameEmail {
+		if u.ID != user.ID {
+			return false, nil
+		}
+	}
+	emailsWithSameAddress, err := appl.UserEmails().Query(account.UserEmailFilterByEmailIgnoringCase(email))
+	if err != nil {
+		log.Error(context.Background(), map[string]interface{}{
+			"email": email,
+			"err":   err,
+		}, "error fetching user emails with email filter")
+		return false, err
+	}
+	for _, e := range emailsWithSameAddress {
+		if e.UserID != user.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Resolve runs the resolve action: it lists the users with the given usernames, skipping unknown usernames.
+// When a username is shared by a deactivated identity and by the identity which reused it, the latter is returned.
+func (c *UsersController) Resolve(ctx *app.ResolveUsersContext) error {
+	return application.Transactional(c.db, func(appl application.Application) error {
+		identities, err := appl.Identities().Query(
+			account.IdentityFilterByUsernames(ctx.Username),
+			account.IdentityFilterByProviderType(account.KeycloakIDP),
+			account.IdentityWithUser())
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching identities by usernames"))
+		}
+		identitiesByUsername := map[string]*account.Identity{}
+		for _, identity := range identities {
+			if other, ok := identitiesByUsername[identity.Username]; ok && other.DeactivatedAt == nil {
+				continue
+			}
+			identitiesByUsername[identity.Username] = identity
+		}
+		appIdentities := []*app.IdentityData{}
+		for _, username := range ctx.Username {
+			identity, ok := identitiesByUsername[username]
+			if !ok {
+				continue
+			}
+			// list each identity once, even if its username was given several times
+			delete(identitiesByUsername, username)
+			appIdentity := ConvertUser(ctx.RequestData, identity, &identity.User)
+			appIdentities = append(appIdentities, appIdentity.Data)
+		}
+		return ctx.OK(&app.UserArray{Data: appIdentities})
+	})
+}
+
+// List runs the list action.
+func (c *UsersController) List(ctx *app.ListUsersContext) error {
+	fieldset, err := jsonapi.NewSparseFieldset("identities", ctx.FieldsIdentities, app.IdentityDataAttributes{})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		var err error
+		var identities []*account.Identity
+		var users []*account.User
+		identityFilters := []func(*gorm.DB) *gorm.DB{}
+		userFilters := []func(*gorm.DB) *gorm.DB{}
+
+		var appIdentities []*app.IdentityData
+		// the last update of the identities and of their users, indexed by identity ID
+		lastUpdates := map[string]time.Time{}
+
+		/*
+			There are 2 database tables we fetch the data from : identities , users
+			First, we filter on the attributes of identities table - providerType , username , q
+			After that we use the above result to cummulatively filter on users  - email , company
+		*/
+
+		/*** Start filtering on Identities table ****/
+
+		if ctx.FilterUsername != nil {
+			identityFilters = append(identityFilters, account.IdentityFilterByUsername(*ctx.FilterUsername))
+		}
+		if ctx.FilterUsernameContains != nil {
+			identityFilters = append(identityFilters, account.IdentityFilterByUsernameContaining(*ctx.FilterUsernameContains))
+		}
+		if ctx.FilterQ != nil {
+			identityFilters = append(identityFilters, account.IdentityFilterByQuery(*ctx.FilterQ))
+		}
+		if ctx.FilterRegistrationCompleted != nil {
+			identityFilters = append(identityFilters, account.IdentityFilterByRegistrationCompleted(*ctx.FilterRegistrationCompleted))
+		}
+		if ctx.FilterID != nil {
+			identityIDs, err := parseIdentityIDs(*ctx.FilterID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			identityFilters = append(identityFilters, account.IdentityFilterByIDs(identityIDs))
+		}
+		// Add more filters when needed , here. ..
+
+		if len(identityFilters) != 0 {
+			identityFilters = append(identityFilters, account.IdentityFilterByProviderType(account.KeycloakIDP))
+			identityFilters = append(identityFilters, account.IdentityWithUser())
+
+			// From a data model perspective, we are querying by identity ( and not user )
+			identities, err = appl.Identities().Query(identityFilters...)
+
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching identities with filter(s)"))
+			}
+
+			// cumulatively filter out those not matcing the user-based filters.
+			for _, identity := range identities {
+				// this is where you keep trying all other filters one by one for 'user' fields like email.
+				if (ctx.FilterEmail == nil || identity.User.Email == *ctx.FilterEmail) &&
+					(ctx.FilterEmailContains == nil || strings.Contains(strings.ToLower(identity.User.Email), strings.ToLower(*ctx.FilterEmailContains))) &&
+					(ctx.FilterCompany == nil || strings.EqualFold(identity.User.Company, *ctx.FilterCompany)) &&
+					(ctx.FilterState == nil || identity.User.State == *ctx.FilterState) {
+
+					// if one or more 'User' filters are present, check if it's satified, if Not, proceed with ConvertUser
+
+					appIdentity := ConvertUser(ctx.RequestData, identity, &identity.User)
+					appIdentities = append(appIdentities, appIdentity.Data)
+					lastUpdates[*appIdentity.Data.ID] = latest(identity.UpdatedAt, identity.User.UpdatedAt)
+				}
+			}
+
+		} else {
+
+			/*** Start filtering on Users table ****/
+
+			if ctx.FilterEmail != nil {
+				userFilters = append(userFilters, account.UserFilterByEmail(*ctx.FilterEmail))
+			}
+			if ctx.FilterEmailContains != nil {
+				userFilters = append(userFilters, account.UserFilterByEmailContaining(*ctx.FilterEmailContains))
+			}
+			if ctx.FilterCompany != nil {
+				userFilters = append(userFilters, account.UserFilterByCompany(*ctx.FilterCompany))
+			}
+			if ctx.FilterState != nil {
+				userFilters = append(userFilters, account.UserFilterByState(*ctx.FilterState))
+			}
+			// .. Add other filters in future when needed into the userFilters slice in the above manner.
+
+			if len(userFilters) != 0 {
+				users, err = appl.Users().Query(userFilters...)
+			} else {
+				// Not breaking the existing API - If no filters were passed, we fall back on the good old 'list everything'.
+				// FIXME We should remove this when fabric8io/fabric8-planner#1538 is fixed
+				users, err = appl.Users().List(ctx.Context)
+			}
+
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching users"))
+			}
+			for _, user := range users {
+				identity, err := loadKeyCloakIdentity(appl, user)
+				if err != nil {
+					return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error fetching keycloak identities"))
+				}
+				appIdentity := ConvertUser(ctx.RequestData, identity, user)
+				appIdentities = append(appIdentities, appIdentity.Data)
+				lastUpdates[*appIdentity.Data.ID] = latest(identity.UpdatedAt, user.UpdatedAt)
+			}
+		}
+
+		/*** Sort and page the filtered users ****/
+
+		var additionalQuery []string
+		if ctx.FilterUsername != nil {
+			additionalQuery = append(additionalQuery, "filter[username]="+url.QueryEscape(*ctx.FilterUsername))
+		}
+		if ctx.FilterUsernameContains != nil {
+			additionalQuery = append(additionalQuery, "filter[username][contains]="+url.QueryEscape(*ctx.FilterUsernameContains))
+		}
+		if ctx.FilterEmail != nil {
+			additionalQuery = append(additionalQuery, "filter[email]="+url.QueryEscape(*ctx.FilterEmail))
+		}
+		if ctx.FilterEmailContains != nil {
+			additionalQuery = append(additionalQuery, "filter[email][contains]="+url.QueryEscape(*ctx.FilterEmailContains))
+		}
+		if ctx.FilterCompany != nil {
+			additionalQuery = append(additionalQuery, "filter[company]="+url.QueryEscape(*ctx.FilterCompany))
+		}
+		if ctx.FilterQ != nil {
+			additionalQuery = append(additionalQuery, "filter[q]="+url.QueryEscape(*ctx.FilterQ))
+		}
+		if ctx.FilterID != nil {
+			additionalQuery = append(additionalQuery, "filter[id]="+url.QueryEscape(*ctx.FilterID))
+		}
+		if ctx.FilterRegistrationCompleted != nil {
+			additionalQuery = append(additionalQuery, "filter[registrationCompleted]="+strconv.FormatBool(*ctx.FilterRegistrationCompleted))
+		}
+		if ctx.FilterState != nil {
+			additionalQuery = append(additionalQuery, "filter[state]="+*ctx.FilterState)
+		}
+		if ctx.Sort != nil {
+			sortUsers(appIdentities, *ctx.Sort)
+			additionalQuery = append(additionalQuery, "sort="+*ctx.Sort)
+		}
+		if ctx.FieldsIdentities != nil {
+			additionalQuery = append(additionalQuery, "fields[identities]="+url.QueryEscape(*ctx.FieldsIdentities))
+		}
+
+		count := len(appIdentities)
+		offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
+		if offset > count {
+			offset = count
+		}
+		end := offset + limit
+		if end > count {
+			end = count
+		}
+		page := appIdentities[offset:end]
+		if page == nil {
+			page = make([]*app.IdentityData, 0)
+		}
+
+		// the ETag and the last modification time change whenever a user of the page is updated, or the total count changes
+		entity := httpsupport.ConditionalEntity{ETagData: []interface{}{count}}
+		for _, identity := range page {
+			entity.ETagData = append(entity.ETagData, *identity.ID, lastUpdates[*identity.ID])
+			entity.LastModified = latest(entity.LastModified, lastUpdates[*identity.ID])
+		}
+		return httpsupport.ConditionalRequest(ctx, ctx.RequestData, ctx.ResponseData, entity, func() error {
+			// the attributes are only omitted once the users are sorted, since they may be sorted by an omitted attribute
+			for _, identity := range page {
+				fieldset.Apply(identity.Attributes)
+			}
+			response := app.UserList{
+				Links: &app.PagingLinks{},
+				Meta:  &app.UserListMeta{TotalCount: count},
+				Data:  page,
+			}
+			setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(page), offset, limit, count, additionalQuery...)
+			return ctx.OK(&response)
+		})
+	})
+}
+
+// ListCompanies runs the list-companies action: it lists the distinct companies of the users starting with the given prefix
+func (c *UsersController) ListCompanies(ctx *app.ListCompaniesUsersContext) error {
+	prefix := ""
+	if ctx.StartsWith != nil {
+		prefix = strings.TrimSpace(*ctx.StartsWith)
+	}
+	_, limit := computePagingLimts(nil, ctx.PageLimit)
+	var companies []string
+	err := application.Transactional(c.db, func(appl application.Application) error {
+		var err error
+		companies, err = appl.Users().ListCompanies(ctx, prefix, limit)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	data := make([]*app.Company, len(companies))
+	for i, company := range companies {
+		data[i] = &app.Company{
+			Type:       "companies",
+			Attributes: &app.CompanyAttributes{Name: company},
+		}
+	}
+	return ctx.OK(&app.CompanyList{Data: data})
+}
+
+// parseIdentityIDs parses the given comma-separated identity IDs
+func parseIdentityIDs(value string) ([]uuid.UUID, error) {
+	var identityIDs []uuid.UUID
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		identityID, err := uuid.FromString(s)
+		if err != nil {
+			return nil, errs.NewBadParameterError("filter[id]", value).Expected("comma-separated identity IDs")
+		}
+		identityIDs = append(identityIDs, identityID)
+	}
+	if len(identityIDs) == 0 {
+		return nil, errs.NewBadParameterError("filter[id]", value).Expected("at least one identity ID")
+	}
+	return identityIDs, nil
+}
+
+// Search runs the search action.
+func (c *UsersController) Search(ctx *app.SearchUsersContext) error {
+	q := strings.TrimSpace(ctx.Q)
+	if q == "" {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("q", ctx.Q).Expected("a non-empty search term"))
+	}
+	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
+	additionalQuery := []string{"q=" + url.QueryEscape(q)}
+	var among []uuid.UUID
+	if ctx.SpaceID != nil {
+		var err error
+		among, err = c.loadSpaceCollaborators(ctx, ctx.RequestData, *ctx.SpaceID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		additionalQuery = append(additionalQuery, "spaceID="+url.QueryEscape(*ctx.SpaceID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		identities, count, err := appl.Identities().Search(ctx, q, among, offset, limit)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.Wrap(err, "error searching users"))
+		}
+		data := make([]*app.IdentityData, len(identities))
+		for i, identity := range identities {
+			data[i] = ConvertUser(ctx.RequestData, identity, &identity.User).Data
+		}
+		response := app.UserList{
+			Links: &app.PagingLinks{},
+			Meta:  &app.UserListMeta{TotalCount: count},
+			Data:  data,
+		}
+		setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(data), offset, limit, count, additionalQuery...)
+		return ctx.OK(&response)
+	})
+}
+
+// loadSpaceCollaborators returns the IDs of the identities collaborating on the given space
+func (c *UsersController) loadSpaceCollaborators(ctx context.Context, request *goa.RequestData, spaceID string) ([]uuid.UUID, error) {
+	spaceUUID, err := uuid.FromString(spaceID)
+	if err != nil {
+		return nil, errs.NewBadParameterError("spaceID", spaceID).Expected("a space ID")
+	}
+	var resource *space.Resource
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		resource, err = appl.SpaceResources().LoadBySpace(ctx, &spaceUUID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	policy, _, err := c.policyManager.GetPolicy(ctx, request, resource.PolicyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the collaborators of the space")
+	}
+	userIDs, err := policy.UserIDs()
+	if err != nil {
+		return nil, err
+	}
+	collaborators := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		id, err := uuid.FromString(userID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid collaborator in the space policy: %s", userID)
+		}
+		collaborators = append(collaborators, id)
+	}
+	return collaborators, nil
+}
+
+// usersByAttribute sorts users by the value of one of their attributes, then by ID
+type usersByAttribute struct {
+	data      []*app.IdentityData
+	attribute func(*app.IdentityDataAttributes) *string
+}
+
+func (u usersByAttribute) Len() int      { return len(u.data) }
+func (u usersByAttribute) Swap(i, j int) { u.data[i], u.data[j] = u.data[j], u.data[i] }
+func (u usersByAttribute) Less(i, j int) bool {
+	vi, vj := u.attribute(u.data[i].Attributes), u.attribute(u.data[j].Attributes)
+	switch {
+	case vi != nil && vj != nil && *vi != *vj:
+		return *vi < *vj
+	case vi == nil && vj != nil:
+		return true
+	case vi != nil && vj == nil:
+		return false
+	}
+	return *u.data[i].ID < *u.data[j].ID
+}
+
+// sortUsers sorts the given users by `username`, `email` or `full_name`,
+// in descending order when the attribute name is prefixed with `-`
+func sortUsers(data []*app.IdentityData, sortParam string) {
+	var attribute func(*app.IdentityDataAttributes) *string
+	switch strings.TrimPrefix(sortParam, "-") {
+	case "username":
+		attribute = func(a *app.IdentityDataAttributes) *string { return a.Username }
+	case "email":
+		attribute = func(a *app.IdentityDataAttributes) *string { return a.Email }
+	case "full_name":
+		attribute = func(a *app.IdentityDataAttributes) *string { return a.FullName }
+	default:
+		return
+	}
+	var byAttribute sort.Interface = usersByAttribute{data: data, attribute: attribute}
+	if strings.HasPrefix(sortParam, "-") {
+		byAttribute = sort.Reverse(byAttribute)
+	}
+	sort.Sort(byAttribute)
+}
+
+func loadKeyCloakIdentity(appl application.Application, user *account.User) (*account.Identity, error) {
+	identities, err := appl.Identities().Query(account.IdentityFilterByUserID(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.ProviderType == account.KeycloakIDP {
+			return identity, nil
+		}
+	}
+	return nil, fmt.Errorf("Can't find Keycloak Identity for user %s", user.Email)
+}
+
+// ConvertUser converts a complete Identity object into REST representation
+func ConvertUser(request *goa.RequestData, identity *account.Identity, user *account.User) *app.Identity {
+	uuid := identity.ID
+	id := uuid.String()
 	fullName := identity.Username
 	userName := identity.Username
 	registrationCompleted := identity.RegistrationCompleted
+	deactivated := identity.DeactivatedAt != nil
 	providerType := identity.ProviderType
 	var imageURL string
 	var bio string
 	var userURL string
 	var email string
+	var pendingEmail *string
+	var state *string
 	var company string
 	var contextInformation workitem.Fields
+	displayName := account.DisplayName(*identity, user)
 
 	if user != nil {
 		fullName = user.FullName
@@ -434,6 +1945,10 @@ func ConvertUser(request *goa.RequestData, identity *account.Identity, user *acc
 		bio = user.Bio
 		userURL = user.URL
 		email = user.Email
+		pendingEmail = user.PendingEmail
+		if user.State != "" {
+			state = &user.State
+		}
 		company = user.Company
 		contextInformation = user.ContextInformation
 	}
@@ -454,14 +1969,18 @@ func ConvertUser(request *goa.RequestData, identity *account.Identity, user *acc
 			Attributes: &app.IdentityDataAttributes{
 				Username:              &userName,
 				FullName:              &fullName,
+				DisplayName:           &displayName,
 				ImageURL:              &imageURL,
 				Bio:                   &bio,
 				URL:                   &userURL,
 				ProviderType:          &providerType,
 				Email:                 &email,
+				PendingEmail:          pendingEmail,
 				Company:               &company,
 				ContextInformation:    workitem.Fields{},
 				RegistrationCompleted: &registrationCompleted,
+				Deactivated:           &deactivated,
+				State:                 state,
 			},
 			Links: createUserLinks(request, uuid),
 		},
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/avatar"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/rest"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// multipartFormOverhead is the size allowed in an avatar upload request on top of the image itself,
+// for the boundaries and headers of the multipart form
+const multipartFormOverhead = 64 * 1024
+
+// UsersAvatarControllerConfiguration the configuration for the UsersAvatarController
+type UsersAvatarControllerConfiguration interface {
+	GetAvatarMaxSize() int64
+}
+
+// UsersAvatarController implements the users_avatar resource.
+type UsersAvatarController struct {
+	*goa.Controller
+	db      application.DB
+	config  UsersAvatarControllerConfiguration
+	storage avatar.Storage
+}
+
+// NewUsersAvatarController creates a users_avatar controller.
+func NewUsersAvatarController(service *goa.Service, db application.DB, config UsersAvatarControllerConfiguration, storage avatar.Storage) *UsersAvatarController {
+	return &UsersAvatarController{Controller: service.NewController("UsersAvatarController"), db: db, config: config, storage: storage}
+}
+
+// Show runs the show action: it serves the variant of the requested size of the avatar of the given user
+func (c *UsersAvatarController) Show(ctx *app.ShowUsersAvatarContext) error {
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	size := avatar.DefaultSize
+	if ctx.Size != nil {
+		size = *ctx.Size
+	}
+	content, err := c.storage.Load(ctx, avatar.VariantName(identityID, size))
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	ctx.ResponseData.Header().Set("Content-Type", avatar.ContentType)
+	return ctx.OK(content)
+}
+
+// Upload runs the upload action: it stores the variants of the uploaded image as the avatar of the authenticated user
+// and points the image URL of the user to the uploaded avatar.
+func (c *UsersAvatarController) Upload(ctx *app.UploadUsersAvatarContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to upload the avatar of identity %s", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	maxSize := c.config.GetAvatarMaxSize()
+	ctx.Request.Body = http.MaxBytesReader(ctx.ResponseData, ctx.Request.Body, maxSize+multipartFormOverhead)
+	file, _, err := ctx.Request.FormFile("file")
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("file", nil).Expected(fmt.Sprintf("an image of at most %d bytes in the 'file' field of a multipart form", maxSize)))
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+	}
+	if int64(len(content)) > maxSize {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("file", nil).Expected(fmt.Sprintf("an image of at most %d bytes", maxSize)))
+	}
+	var identity *account.Identity
+	var user *account.User
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err = appl.Identities().Load(ctx, identityID)
+		if err != nil || !identity.UserID.Valid {
+			return errors.NewNotFoundError("identity", ctx.ID)
+		}
+		user, err = appl.Users().Load(ctx, identity.UserID.UUID)
+		if err != nil {
+			return errors.NewNotFoundError("user", identity.UserID.UUID.String())
+		}
+		if err := avatar.Save(ctx, c.storage, identityID, content); err != nil {
+			return err
+		}
+		user.ImageURL = rest.AbsoluteURL(ctx.RequestData, app.UsersHref(identityID)+"/avatar")
+		return appl.Users().Save(ctx, user)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"image_url":   user.ImageURL,
+	}, "avatar uploaded")
+	return ctx.OK(ConvertUser(ctx.RequestData, identity, user))
+}
@@ -0,0 +1,185 @@
+package controller_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	"github.com/almighty/almighty-core/avatar"
+	config "github.com/almighty/almighty-core/configuration"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestUsersAvatar(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestUsersAvatarSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestUsersAvatarSuite struct {
+	gormtestsupport.DBTestSuite
+	db            *gormapplication.GormDB
+	clean         func()
+	configuration *config.ConfigurationData
+	storageDir    string
+	storage       avatar.Storage
+}
+
+func (s *TestUsersAvatarSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+	configuration, err := config.GetConfigurationData()
+	require.Nil(s.T(), err)
+	s.configuration = configuration
+}
+
+func (s *TestUsersAvatarSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	dir, err := ioutil.TempDir("", "avatars")
+	require.Nil(s.T(), err)
+	s.storageDir = dir
+	s.storage = avatar.NewFileSystemStorage(dir)
+}
+
+func (s *TestUsersAvatarSuite) TearDownTest() {
+	s.clean()
+	os.RemoveAll(s.storageDir)
+}
+
+// avatarMaxSizeConfiguration overrides the maximum size of the uploaded avatars
+type avatarMaxSizeConfiguration struct {
+	*config.ConfigurationData
+	maxSize int64
+}
+
+func (c avatarMaxSizeConfiguration) GetAvatarMaxSize() int64 {
+	return c.maxSize
+}
+
+// upload posts the given content as the 'file' field of a multipart form to the upload action,
+// on behalf of the given identity
+func (s *TestUsersAvatarSuite) upload(config UsersAvatarControllerConfiguration, identity account.Identity, id string, content []byte) *httptest.ResponseRecorder {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("UsersAvatar-Service", almtoken.NewManager(pub), identity)
+	ctrl := NewUsersAvatarController(svc, s.db, config, s.storage)
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "avatar.png")
+	require.Nil(s.T(), err)
+	_, err = part.Write(content)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), writer.Close())
+	req, err := http.NewRequest("POST", app.UsersHref(id)+"/avatar", &body)
+	require.Nil(s.T(), err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rw := httptest.NewRecorder()
+	goaCtx := goa.NewContext(goa.WithAction(svc.Context, "UsersAvatarTest"), rw, req, url.Values{"id": []string{id}})
+	uploadCtx, err := app.NewUploadUsersAvatarContext(goaCtx, req, svc)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), ctrl.Upload(uploadCtx))
+	return rw
+}
+
+func (s *TestUsersAvatarSuite) showAvatar(id string, size *int) image.Image {
+	svc := goa.New("UsersAvatar-Service")
+	ctrl := NewUsersAvatarController(svc, s.db, s.configuration, s.storage)
+	res := test.ShowUsersAvatarOK(s.T(), svc.Context, svc, ctrl, id, size)
+	assert.Equal(s.T(), avatar.ContentType, res.Header().Get("Content-Type"))
+	img, err := png.Decode(res.(*httptest.ResponseRecorder).Body)
+	require.Nil(s.T(), err)
+	return img
+}
+
+func encodeAvatar(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func (s *TestUsersAvatarSuite) TestUploadAvatarOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUploadAvatarOK"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	// when
+	rw := s.upload(s.configuration, identity, identity.ID.String(), encodeAvatar(s.T(), 300, 200))
+	// then
+	require.Equal(s.T(), http.StatusOK, rw.Code)
+	loaded, err := s.db.Users().Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), strings.HasSuffix(loaded.ImageURL, app.UsersHref(identity.ID)+"/avatar"), loaded.ImageURL)
+	assert.Equal(s.T(), image.Rect(0, 0, avatar.DefaultSize, avatar.DefaultSize), s.showAvatar(identity.ID.String(), nil).Bounds())
+	size := 32
+	assert.Equal(s.T(), image.Rect(0, 0, 32, 32), s.showAvatar(identity.ID.String(), &size).Bounds())
+}
+
+func (s *TestUsersAvatarSuite) TestUploadAvatarOfOtherUserForbidden() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUploadAvatarOfOtherUser"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUploadAvatarOfOtherUserOther"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	// when
+	rw := s.upload(s.configuration, identity, other.ID.String(), encodeAvatar(s.T(), 32, 32))
+	// then
+	assert.Equal(s.T(), http.StatusForbidden, rw.Code)
+	loaded, err := s.db.Users().Load(context.Background(), other.UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), other.User.ImageURL, loaded.ImageURL)
+}
+
+func (s *TestUsersAvatarSuite) TestUploadAvatarNotAnImageBadRequest() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUploadAvatarNotAnImage"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	// when
+	rw := s.upload(s.configuration, identity, identity.ID.String(), []byte("not an image"))
+	// then
+	assert.Equal(s.T(), http.StatusBadRequest, rw.Code)
+}
+
+func (s *TestUsersAvatarSuite) TestUploadAvatarTooLargeBadRequest() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUploadAvatarTooLarge"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	content := encodeAvatar(s.T(), 300, 200)
+	// when
+	rw := s.upload(avatarMaxSizeConfiguration{s.configuration, int64(len(content) - 1)}, identity, identity.ID.String(), content)
+	// then
+	assert.Equal(s.T(), http.StatusBadRequest, rw.Code)
+	loaded, err := s.db.Users().Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), identity.User.ImageURL, loaded.ImageURL)
+}
+
+func (s *TestUsersAvatarSuite) TestShowAvatarNotUploadedNotFound() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestShowAvatarNotUploaded"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc := goa.New("UsersAvatar-Service")
+	ctrl := NewUsersAvatarController(svc, s.db, s.configuration, s.storage)
+	// when/then
+	test.ShowUsersAvatarNotFound(s.T(), svc.Context, svc, ctrl, identity.ID.String(), nil)
+}
@@ -2,12 +2,18 @@ package controller_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/almighty/almighty-core/account"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/app/test"
+	"github.com/almighty/almighty-core/auth"
 
 	config "github.com/almighty/almighty-core/configuration"
 	. "github.com/almighty/almighty-core/controller"
@@ -18,9 +24,11 @@ import (
 
 	"github.com/almighty/almighty-core/gormtestsupport"
 	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
 	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,6 +51,10 @@ type TestUsersSuite struct {
 	identityRepo   account.IdentityRepository
 	configuration  *config.ConfigurationData
 	profileService login.UserProfileService
+	policyManager  *testUsersPolicyManager
+	sessionManager *testUserSessionManager
+	mailer         *testMailer
+	emailManager   *testUserEmailManager
 }
 
 func (s *TestUsersSuite) SetupSuite() {
@@ -58,7 +70,7 @@ func (s *TestUsersSuite) SetupSuite() {
 	dummyProfileResponse := createDummyUserProfileResponse(&testAttributeValue, &testAttributeValue, &testAttributeValue)
 	keycloakUserProfileService := newDummyUserProfileService(dummyProfileResponse)
 	s.profileService = keycloakUserProfileService
-	s.controller = NewUsersController(s.svc, s.db, s.configuration, s.profileService)
+	s.controller = NewUsersController(s.svc, s.db, s.configuration, s.profileService, &testUsersPolicyManager{}, &testUserSessionManager{}, &testMailer{}, &testUserEmailManager{})
 	s.userRepo = s.db.Users()
 	s.identityRepo = s.db.Identities()
 
@@ -66,6 +78,10 @@ func (s *TestUsersSuite) SetupSuite() {
 
 func (s *TestUsersSuite) SetupTest() {
 	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	s.policyManager = &testUsersPolicyManager{}
+	s.sessionManager = &testUserSessionManager{}
+	s.mailer = &testMailer{}
+	s.emailManager = &testUserEmailManager{}
 }
 
 func (s *TestUsersSuite) TearDownTest() {
@@ -76,13 +92,13 @@ func (s *TestUsersSuite) SecuredController(identity account.Identity) (*goa.Serv
 	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
 
 	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
-	return svc, NewUsersController(svc, s.db, s.configuration, s.profileService)
+	return svc, NewUsersController(svc, s.db, s.configuration, s.profileService, s.policyManager, s.sessionManager, s.mailer, s.emailManager)
 }
 func (s *TestUsersSuite) TestUpdateUserOK() {
 	// given
 	user := s.createRandomUser("TestUpdateUserOK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
 	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
@@ -107,12 +123,12 @@ func (s *TestUsersSuite) TestUpdateUserOK() {
 	}
 	//secureController, secureService := createSecureController(t, identity)
 	updateUsersPayload := createUpdateUsersPayload(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL, &newCompany, nil, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 
 	// then
 	require.NotNil(s.T(), result)
 	// let's fetch it and validate
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), newFullName, *result.Data.Attributes.FullName)
@@ -134,7 +150,7 @@ func (s *TestUsersSuite) TestUpdateUserNameMulitpleTimesForbidden() {
 
 	user := s.createRandomUser("OK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 
 	newUserName := identity.Username + uuid.NewV4().String()
@@ -145,19 +161,19 @@ func (s *TestUsersSuite) TestUpdateUserNameMulitpleTimesForbidden() {
 	}
 
 	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 
 	// next attempt should fail.
 	newUserName = identity.Username + uuid.NewV4().String()
 	updateUsersPayload = createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, contextInformation)
-	test.UpdateUsersForbidden(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	test.UpdateUsersForbidden(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 }
 
 func (s *TestUsersSuite) TestUpdateUserNameMulitpleTimesOK() {
 
 	user := s.createRandomUser("OK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 
 	newUserName := identity.Username // new username = old userame
@@ -168,25 +184,261 @@ func (s *TestUsersSuite) TestUpdateUserNameMulitpleTimesOK() {
 	}
 
 	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	require.False(s.T(), *result.Data.Attributes.RegistrationCompleted)
 
 	// next attempt should PASS.
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	require.False(s.T(), *result.Data.Attributes.RegistrationCompleted)
 
 }
 
+func (s *TestUsersSuite) TestForceUserNameChangeByAdminOK() {
+	// given an administrator who already changed their username once
+	identity := s.createRandomIdentity(s.createRandomUser("TestForceUserNameChange"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithAdmins(identity, identity)
+	firstUserName := identity.Username + uuid.NewV4().String()
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &firstUserName, nil))
+	secondUserName := identity.Username + uuid.NewV4().String()
+	force := true
+	// when
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, &force, createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &secondUserName, nil))
+	// then
+	assert.Equal(s.T(), secondUserName, *result.Data.Attributes.Username)
+	_, history := test.ListUsernameHistoryUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String())
+	require.Len(s.T(), history.Data, 2)
+	assert.Equal(s.T(), identity.Username, history.Data[0].Attributes.OldUsername)
+	assert.Equal(s.T(), firstUserName, history.Data[0].Attributes.NewUsername)
+	assert.Equal(s.T(), firstUserName, history.Data[1].Attributes.OldUsername)
+	assert.Equal(s.T(), secondUserName, history.Data[1].Attributes.NewUsername)
+	assert.Equal(s.T(), identity.ID, history.Data[1].Attributes.ChangedBy)
+}
+
+func (s *TestUsersSuite) TestForceUserNameChangeNotAdminForbidden() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestForceUserNameChangeNotAdmin"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	newUserName := identity.Username + uuid.NewV4().String()
+	force := true
+	// when/then
+	test.UpdateUsersForbidden(s.T(), secureService.Context, secureService, secureController, &force, createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, nil))
+}
+
+func (s *TestUsersSuite) TestForceUserNameChangeByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	identity := s.createRandomIdentity(s.createRandomUser("TestForceUserNameChangeImpersonated"), account.KeycloakIDP)
+	impersonator := s.createRandomIdentity(s.createRandomUser("TestForceUserNameChangeImpersonator"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithAdmins(identity, identity)
+	impersonatedBy(secureService, impersonator)
+	newUserName := identity.Username + uuid.NewV4().String()
+	force := true
+	// when/then
+	test.UpdateUsersForbidden(s.T(), secureService.Context, secureService, secureController, &force, createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, nil))
+}
+
+func (s *TestUsersSuite) TestListUsernameHistoryOfOtherUserByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	admin := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistoryAdmin"), account.KeycloakIDP)
+	impersonator := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistoryImpersonator"), account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistoryOther"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithAdmins(admin, admin)
+	impersonatedBy(secureService, impersonator)
+	// when/then
+	test.ListUsernameHistoryUsersForbidden(s.T(), secureService.Context, secureService, secureController, other.ID.String())
+}
+
+func (s *TestUsersSuite) TestListUsernameHistoryOfOtherUserForbidden() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistory"), account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistoryOther"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when/then
+	test.ListUsernameHistoryUsersForbidden(s.T(), secureService.Context, secureService, secureController, other.ID.String())
+}
+
+func (s *TestUsersSuite) TestListUsernameHistoryWithoutChangeOK() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestListUsernameHistoryWithoutChange"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when
+	_, history := test.ListUsernameHistoryUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String())
+	// then
+	assert.Empty(s.T(), history.Data)
+}
+
+func (s *TestUsersSuite) TestUpdateUserRecordsProfileEvents() {
+	// given
+	user := s.createRandomUser("TestUpdateUserRecordsProfileEvents")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	newFullName := "TestUpdateUserRecordsProfileEvents Updated"
+	newBio := "not recorded"
+	// when
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, createUpdateUsersPayload(nil, &newFullName, &newBio, nil, nil, &user.Company, nil, map[string]interface{}{
+		"last_visited": "yesterday",
+		"count":        3,
+	}))
+	// then
+	_, events := test.ListEventsUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String(), nil, nil)
+	require.Len(s.T(), events.Data, 3)
+	assert.Equal(s.T(), 3, events.Meta.TotalCount)
+	changes := map[string]*app.ProfileEventAttributes{}
+	for _, e := range events.Data {
+		changes[e.Attributes.Field] = e.Attributes
+		assert.Equal(s.T(), identity.ID, e.Attributes.ChangedBy)
+	}
+	require.Contains(s.T(), changes, "fullName")
+	assert.Equal(s.T(), user.FullName, *changes["fullName"].OldValue)
+	assert.Equal(s.T(), newFullName, *changes["fullName"].NewValue)
+	require.Contains(s.T(), changes, "contextInformation.last_visited")
+	assert.Nil(s.T(), changes["contextInformation.last_visited"].OldValue)
+	assert.Equal(s.T(), "yesterday", *changes["contextInformation.last_visited"].NewValue)
+	require.Contains(s.T(), changes, "contextInformation.count")
+	assert.Equal(s.T(), "3", *changes["contextInformation.count"].NewValue)
+}
+
+func (s *TestUsersSuite) TestListEventsPaginated() {
+	// given 3 changes of the full name
+	user := s.createRandomUser("TestListEventsPaginated")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	for i := 1; i <= 3; i++ {
+		fullName := fmt.Sprintf("TestListEventsPaginated %d", i)
+		test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, createUpdateUsersPayload(nil, &fullName, nil, nil, nil, nil, nil, nil))
+	}
+	limit := 2
+	offset := "0"
+	// when
+	_, events := test.ListEventsUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String(), &limit, &offset)
+	// then the most recent change comes first
+	require.Len(s.T(), events.Data, 2)
+	assert.Equal(s.T(), 3, events.Meta.TotalCount)
+	assert.Equal(s.T(), "TestListEventsPaginated 3", *events.Data[0].Attributes.NewValue)
+	assert.Equal(s.T(), "TestListEventsPaginated 2", *events.Data[1].Attributes.NewValue)
+	require.NotNil(s.T(), events.Links.Next)
+	// when
+	offset = "2"
+	_, events = test.ListEventsUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String(), &limit, &offset)
+	// then
+	require.Len(s.T(), events.Data, 1)
+	assert.Equal(s.T(), user.FullName, *events.Data[0].Attributes.OldValue)
+}
+
+func (s *TestUsersSuite) TestListEventsOfOtherUserForbidden() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestListEvents"), account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestListEventsOther"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when/then
+	test.ListEventsUsersForbidden(s.T(), secureService.Context, secureService, secureController, other.ID.String(), nil, nil)
+}
+
+func (s *TestUsersSuite) TestListEventsOfOtherUserByAdminOK() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestListEventsAdmin"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestListEvents"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithAdmins(admin, admin)
+	// when
+	_, events := test.ListEventsUsersOK(s.T(), secureService.Context, secureService, secureController, identity.ID.String(), nil, nil)
+	// then
+	assert.Empty(s.T(), events.Data)
+}
+
+func (s *TestUsersSuite) TestListEventsOfOtherUserByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	admin := s.createRandomIdentity(s.createRandomUser("TestListEventsAdmin"), account.KeycloakIDP)
+	impersonator := s.createRandomIdentity(s.createRandomUser("TestListEventsImpersonator"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestListEvents"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithAdmins(admin, admin)
+	impersonatedBy(secureService, impersonator)
+	// when/then
+	test.ListEventsUsersForbidden(s.T(), secureService.Context, secureService, secureController, identity.ID.String(), nil, nil)
+}
+
+// companyRequiredConfiguration overrides the configuration to require a company on registration
+type companyRequiredConfiguration struct {
+	*config.ConfigurationData
+}
+
+func (c companyRequiredConfiguration) IsUserCompanyRequired() bool {
+	return true
+}
+
+func (s *TestUsersSuite) SecuredControllerWithCompanyRequired(identity account.Identity) (*goa.Service, *UsersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+
+	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
+	return svc, NewUsersController(svc, s.db, companyRequiredConfiguration{s.configuration}, s.profileService, s.policyManager, s.sessionManager, s.mailer, s.emailManager)
+}
+
+func (s *TestUsersSuite) TestUpdateUserNameWithoutCompanyBadRequestWhenCompanyRequired() {
+	// given
+	user := s.createRandomUser("TestUpdateUserNameWithoutCompany")
+	user.Company = ""
+	err := s.userRepo.Save(context.Background(), &user)
+	require.Nil(s.T(), err)
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithCompanyRequired(identity)
+	newUserName := identity.Username + uuid.NewV4().String()
+	// when/then
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, nil)
+	test.UpdateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	assert.False(s.T(), *result.Data.Attributes.RegistrationCompleted)
+}
+
+func (s *TestUsersSuite) TestUpdateUserNameWithCompanyOKWhenCompanyRequired() {
+	// given
+	user := s.createRandomUser("TestUpdateUserNameWithCompany")
+	user.Company = ""
+	err := s.userRepo.Save(context.Background(), &user)
+	require.Nil(s.T(), err)
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithCompanyRequired(identity)
+	newUserName := identity.Username + uuid.NewV4().String()
+	newCompany := "company " + uuid.NewV4().String()
+	// when
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, &newCompany, &newUserName, nil)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	assert.True(s.T(), *result.Data.Attributes.RegistrationCompleted)
+	assert.Equal(s.T(), newCompany, *result.Data.Attributes.Company)
+}
+
+func (s *TestUsersSuite) TestUpdateUserEmptyCompanyBadRequestWhenCompanyRequired() {
+	// given
+	user := s.createRandomUser("TestUpdateUserEmptyCompany")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithCompanyRequired(identity)
+	emptyCompany := " "
+	// when/then
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, &emptyCompany, nil, nil)
+	test.UpdateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) TestUpdateUserEmptyCompanyOKWhenCompanyNotRequired() {
+	// given
+	user := s.createRandomUser("TestUpdateUserEmptyCompanyNotRequired")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	emptyCompany := ""
+	// when
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, &emptyCompany, nil, nil)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	assert.Equal(s.T(), "", *result.Data.Attributes.Company)
+}
+
 func (s *TestUsersSuite) TestUpdateExistingUsernameForbidden() {
 	// create 2 users.
 	user := s.createRandomUser("OK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 
 	user2 := s.createRandomUser("OK2")
 	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
-	_, result2 := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity2.ID.String())
+	_, result2 := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity2.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity2.ID.String(), *result2.Data.ID)
 
 	// try updating using the username of an existing ( just created ) user.
@@ -198,19 +450,57 @@ func (s *TestUsersSuite) TestUpdateExistingUsernameForbidden() {
 
 	newUserName := identity.Username
 	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, contextInformation)
-	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) deactivateIdentity(identity account.Identity, deactivatedAt time.Time) {
+	identity.DeactivatedAt = &deactivatedAt
+	err := s.identityRepo.Save(context.Background(), &identity)
+	require.Nil(s.T(), err)
+}
+
+func (s *TestUsersSuite) TestUpdateUsernameOfDeactivatedUserWithinGracePeriodConflict() {
+	// given
+	deactivatedUser := s.createRandomUser("Deactivated")
+	deactivatedIdentity := s.createRandomIdentity(deactivatedUser, account.KeycloakIDP)
+	gracePeriod := s.configuration.GetUsernameReuseGracePeriod()
+	s.deactivateIdentity(deactivatedIdentity, time.Now().Add(-gracePeriod/2))
+	user := s.createRandomUser("OK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when/then
+	newUserName := deactivatedIdentity.Username
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, nil)
+	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) TestUpdateUsernameOfDeactivatedUserAfterGracePeriodOK() {
+	// given
+	deactivatedUser := s.createRandomUser("Deactivated")
+	deactivatedIdentity := s.createRandomIdentity(deactivatedUser, account.KeycloakIDP)
+	gracePeriod := s.configuration.GetUsernameReuseGracePeriod()
+	s.deactivateIdentity(deactivatedIdentity, time.Now().Add(-gracePeriod-time.Hour))
+	user := s.createRandomUser("OK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when
+	newUserName := deactivatedIdentity.Username
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, &newUserName, nil)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	assert.Equal(s.T(), newUserName, *result.Data.Attributes.Username)
 }
 
 func (s *TestUsersSuite) TestUpdateExistingEmailForbidden() {
 	// create 2 users.
 	user := s.createRandomUser("OK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 
 	user2 := s.createRandomUser("OK2")
 	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
-	_, result2 := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity2.ID.String())
+	_, result2 := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity2.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity2.ID.String(), *result2.Data.ID)
 
 	// try updating using the email of an existing ( just created ) user.
@@ -222,7 +512,161 @@ func (s *TestUsersSuite) TestUpdateExistingEmailForbidden() {
 
 	newEmail := user.Email
 	updateUsersPayload := createUpdateUsersPayload(&newEmail, nil, nil, nil, nil, nil, nil, contextInformation)
-	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) TestUpdateExistingEmailWithDifferentCaseForbidden() {
+	// given
+	user := s.createRandomUser("OK")
+	s.createRandomIdentity(user, account.KeycloakIDP)
+	user2 := s.createRandomUser("OK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity2)
+	// when
+	newEmail := " " + strings.ToUpper(user.Email) + " "
+	updateUsersPayload := createUpdateUsersPayload(&newEmail, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	test.UpdateUsersConflict(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) TestUpdateInvalidEmailBadRequest() {
+	// given
+	user := s.createRandomUser("OK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	for _, newEmail := range []string{"", "not an email", "missing-domain@", "@missing-local-part.com", "John Doe <john@example.com>"} {
+		// when
+		updateUsersPayload := createUpdateUsersPayload(&newEmail, nil, nil, nil, nil, nil, nil, nil)
+		// then
+		test.UpdateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	}
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	assert.Equal(s.T(), user.Email, *result.Data.Attributes.Email)
+}
+
+func (s *TestUsersSuite) TestUpdateEmailNormalizedOK() {
+	// given
+	user := s.createRandomUser("OK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when
+	newEmail := "  Updated-" + uuid.NewV4().String() + "@Email.COM "
+	updateUsersPayload := createUpdateUsersPayload(&newEmail, nil, nil, nil, nil, nil, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then the normalized email is pending until it is verified
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	assert.Equal(s.T(), user.Email, *result.Data.Attributes.Email)
+	require.NotNil(s.T(), result.Data.Attributes.PendingEmail)
+	assert.Equal(s.T(), strings.ToLower(strings.TrimSpace(newEmail)), *result.Data.Attributes.PendingEmail)
+}
+
+// requestEmailChange updates the email of the given identity and returns the verification token sent to the new email
+func (s *TestUsersSuite) requestEmailChange(identity account.Identity, newEmail string) string {
+	secureService, secureController := s.SecuredController(identity)
+	updateUsersPayload := createUpdateUsersPayload(&newEmail, nil, nil, nil, nil, nil, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	user, err := s.userRepo.Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), user.EmailVerificationToken)
+	return *user.EmailVerificationToken
+}
+
+func (s *TestUsersSuite) TestUpdateEmailSendsVerification() {
+	// given
+	user := s.createRandomUser("TestUpdateEmailSendsVerification")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	newEmail := "updated-" + uuid.NewV4().String() + "@email.com"
+	// when
+	token := s.requestEmailChange(identity, newEmail)
+	// then
+	require.Len(s.T(), s.mailer.sent, 1)
+	assert.Equal(s.T(), newEmail, s.mailer.sent[0].to)
+	assert.Contains(s.T(), s.mailer.sent[0].body, "/api/users/verifyemail?token="+token)
+	assert.Empty(s.T(), s.emailManager.updatedEmails)
+}
+
+func (s *TestUsersSuite) TestUpdateSameEmailWithoutVerification() {
+	// given
+	user := s.createRandomUser("TestUpdateSameEmailWithoutVerification")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when
+	sameEmail := user.Email
+	updateUsersPayload := createUpdateUsersPayload(&sameEmail, nil, nil, nil, nil, nil, nil, nil)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	assert.Nil(s.T(), result.Data.Attributes.PendingEmail)
+	assert.Empty(s.T(), s.mailer.sent)
+}
+
+func (s *TestUsersSuite) TestVerifyEmailOK() {
+	// given
+	user := s.createRandomUser("TestVerifyEmailOK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	newEmail := "updated-" + uuid.NewV4().String() + "@email.com"
+	token := s.requestEmailChange(identity, newEmail)
+	svc, ctrl := s.SecuredController(identity)
+	// when
+	_, result := test.VerifyEmailUsersOK(s.T(), svc.Context, svc, ctrl, token)
+	// then
+	assert.Equal(s.T(), newEmail, *result.Data.Attributes.Email)
+	assert.Nil(s.T(), result.Data.Attributes.PendingEmail)
+	assert.Equal(s.T(), map[string]string{identity.ID.String(): newEmail}, s.emailManager.updatedEmails)
+	loaded, err := s.userRepo.Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), newEmail, loaded.Email)
+	assert.Nil(s.T(), loaded.PendingEmail)
+	assert.Nil(s.T(), loaded.EmailVerificationToken)
+	// the token can't be used twice
+	test.VerifyEmailUsersNotFound(s.T(), svc.Context, svc, ctrl, token)
+}
+
+func (s *TestUsersSuite) TestVerifyEmailUnknownTokenNotFound() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestVerifyEmailUnknownToken"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.VerifyEmailUsersNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+func (s *TestUsersSuite) TestVerifyEmailExpiredTokenBadRequest() {
+	// given
+	user := s.createRandomUser("TestVerifyEmailExpiredToken")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	token := s.requestEmailChange(identity, "updated-"+uuid.NewV4().String()+"@email.com")
+	loaded, err := s.userRepo.Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	expiredAt := time.Now().Add(-time.Minute)
+	loaded.EmailVerificationExpiresAt = &expiredAt
+	require.Nil(s.T(), s.userRepo.Save(context.Background(), loaded))
+	svc, ctrl := s.SecuredController(identity)
+	// when
+	test.VerifyEmailUsersBadRequest(s.T(), svc.Context, svc, ctrl, token)
+	// then
+	loaded, err = s.userRepo.Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), user.Email, loaded.Email)
+	assert.Empty(s.T(), s.emailManager.updatedEmails)
+}
+
+func (s *TestUsersSuite) TestVerifyEmailAlreadyInUseConflict() {
+	// given
+	user := s.createRandomUser("TestVerifyEmailAlreadyInUse")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	newEmail := "updated-" + uuid.NewV4().String() + "@email.com"
+	token := s.requestEmailChange(identity, newEmail)
+	// another user takes the email before it is verified
+	other := s.createRandomUser("TestVerifyEmailAlreadyInUseOther")
+	other.Email = newEmail
+	require.Nil(s.T(), s.userRepo.Save(context.Background(), &other))
+	svc, ctrl := s.SecuredController(identity)
+	// when
+	test.VerifyEmailUsersConflict(s.T(), svc.Context, svc, ctrl, token)
+	// then
+	loaded, err := s.userRepo.Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), user.Email, loaded.Email)
+	assert.Empty(s.T(), s.emailManager.updatedEmails)
 }
 
 func (s *TestUsersSuite) TestUpdateUserVariableSpacesInNameOK() {
@@ -230,7 +674,7 @@ func (s *TestUsersSuite) TestUpdateUserVariableSpacesInNameOK() {
 	// given
 	user := s.createRandomUser("OK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
 	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
@@ -263,11 +707,11 @@ func (s *TestUsersSuite) TestUpdateUserVariableSpacesInNameOK() {
 	}
 	//secureController, secureService := createSecureController(t, identity)
 	updateUsersPayload := createUpdateUsersPayload(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL, &newCompany, nil, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	// then
 	require.NotNil(s.T(), result)
 	// let's fetch it and validate
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), newFullName, *result.Data.Attributes.FullName)
@@ -289,13 +733,133 @@ func (s *TestUsersSuite) TestUpdateUserVariableSpacesInNameOK() {
 	Test to unset variable in contextInformation
 */
 
+func (s *TestUsersSuite) TestShowUserNotModifiedUsingIfNoneMatchHeader() {
+	// given
+	user := s.createRandomUser("TestShowUserNotModified")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	res, _ := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	require.NotEmpty(s.T(), eTag)
+	// when/then
+	test.ShowUsersNotModified(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, &eTag)
+}
+
+func (s *TestUsersSuite) TestShowUserETagChangesWhenUserUpdated() {
+	// given
+	user := s.createRandomUser("TestShowUserETagChanges")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	res, _ := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	// when
+	secureService, secureController := s.SecuredController(identity)
+	newBio := "updated bio"
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, createUpdateUsersPayload(nil, nil, &newBio, nil, nil, nil, nil, nil))
+	// then
+	res, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, &eTag)
+	assert.Equal(s.T(), newBio, *result.Data.Attributes.Bio)
+	assert.NotEqual(s.T(), eTag, res.Header().Get(app.ETag))
+}
+
+func (s *TestUsersSuite) TestShowUserNotModifiedUsingIfModifiedSinceHeader() {
+	// given
+	user := s.createRandomUser("TestShowUserNotModified")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	res, _ := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	lastModified := res.Header().Get(app.LastModified)
+	require.NotEmpty(s.T(), lastModified)
+	// when/then
+	test.ShowUsersNotModified(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, &lastModified, nil)
+}
+
+func (s *TestUsersSuite) TestListUsersNotModifiedUsingIfNoneMatchHeader() {
+	// given
+	user := s.createRandomUser("TestListUsersNotModified")
+	s.createRandomIdentity(user, account.KeycloakIDP)
+	res, _ := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &user.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	require.NotEmpty(s.T(), eTag)
+	// when/then
+	test.ListUsersNotModified(s.T(), nil, nil, s.controller, nil, nil, &user.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &eTag)
+}
+
+func (s *TestUsersSuite) TestListUsersETagChangesWhenUserUpdated() {
+	// given
+	user := s.createRandomUser("TestListUsersETagChanges")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	res, _ := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &user.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	eTag := res.Header().Get(app.ETag)
+	// when
+	secureService, secureController := s.SecuredController(identity)
+	newBio := "updated bio"
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, createUpdateUsersPayload(nil, nil, &newBio, nil, nil, nil, nil, nil))
+	// then
+	res, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &user.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &eTag)
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), newBio, *result.Data[0].Attributes.Bio)
+	assert.NotEqual(s.T(), eTag, res.Header().Get(app.ETag))
+}
+
+func (s *TestUsersSuite) TestUpdateUserClearProfileFieldsOK() {
+	// given a user with a bio, a company, an image and a URL
+	user := s.createRandomUser("TestUpdateUserClearProfileFields")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	bio := "some bio"
+	company := "some company"
+	imageURL := "http://some.image.io/imageurl"
+	profileURL := "http://some.profile.url/url"
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, &bio, &imageURL, &profileURL, &company, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// when
+	empty := ""
+	updateUsersPayload = createUpdateUsersPayload(nil, nil, &empty, &empty, &empty, &empty, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), "", *result.Data.Attributes.Bio)
+	assert.Equal(s.T(), "", *result.Data.Attributes.Company)
+	assert.Equal(s.T(), "", *result.Data.Attributes.ImageURL)
+	assert.Equal(s.T(), "", *result.Data.Attributes.URL)
+	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
+	// the attributes are removed from the Keycloak profile as well
+	keycloakUserProfile := s.profileService.(*dummyUserProfileService).updatedProfile
+	require.NotNil(s.T(), keycloakUserProfile)
+	require.NotNil(s.T(), keycloakUserProfile.Attributes)
+	for _, name := range []string{login.BioAttributeName, login.CompanyAttributeName, login.ImageURLAttributeName, login.URLAttributeName} {
+		assert.NotContains(s.T(), *keycloakUserProfile.Attributes, name)
+	}
+}
+
+func (s *TestUsersSuite) TestUpdateUserNilProfileFieldsUnchangedOK() {
+	// given a user with a bio and a company
+	user := s.createRandomUser("TestUpdateUserNilProfileFields")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	bio := "some bio"
+	company := "some company"
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, &bio, nil, nil, &company, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// when only the full name is updated
+	newFullName := "TestUpdateUserNilProfileFields"
+	updateUsersPayload = createUpdateUsersPayload(nil, &newFullName, nil, nil, nil, nil, nil, nil)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), newFullName, *result.Data.Attributes.FullName)
+	assert.Equal(s.T(), bio, *result.Data.Attributes.Bio)
+	assert.Equal(s.T(), company, *result.Data.Attributes.Company)
+	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
+}
+
 func (s *TestUsersSuite) TestUpdateUserUnsetVariableInContextInfo() {
 
 	// given
 	user := s.createRandomUser("TestUpdateUserUnsetVariableInContextInfo")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
 
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
 	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
@@ -318,11 +882,11 @@ func (s *TestUsersSuite) TestUpdateUserUnsetVariableInContextInfo() {
 	}
 	//secureController, secureService := createSecureController(t, identity)
 	updateUsersPayload := createUpdateUsersPayload(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL, nil, nil, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	// then
 	require.NotNil(s.T(), result)
 	// let's fetch it and validate the usual stuff.
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), newFullName, *result.Data.Attributes.FullName)
@@ -341,11 +905,11 @@ func (s *TestUsersSuite) TestUpdateUserUnsetVariableInContextInfo() {
 	}
 
 	updateUsersPayload = createUpdateUsersPayload(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL, nil, nil, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	// then
 	require.NotNil(s.T(), result)
 	// let's fetch it and validate the usual stuff.
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	updatedContextInformation = result.Data.Attributes.ContextInformation
 
@@ -357,40 +921,117 @@ func (s *TestUsersSuite) TestUpdateUserUnsetVariableInContextInfo() {
 	assert.Equal(s.T(), false, ok)
 }
 
-/*
-	Pass no contextInformation and no one complains.
-	This is as per general service behaviour.
-*/
+// contextInformationAllowlistConfiguration overrides the configuration to restrict the keys of the context information
+type contextInformationAllowlistConfiguration struct {
+	*config.ConfigurationData
+}
 
-func (s *TestUsersSuite) TestUpdateUserOKWithoutContextInfo() {
+func (c contextInformationAllowlistConfiguration) GetUserContextInformationAllowedKeys() []string {
+	return []string{"last_visited", "space"}
+}
 
-	// given
-	user := s.createRandomUser("TestUpdateUserOKWithoutContextInfo")
-	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
-	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
-	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
-	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
-	assert.Equal(s.T(), identity.ProviderType, *result.Data.Attributes.ProviderType)
-	assert.Equal(s.T(), identity.Username, *result.Data.Attributes.Username)
-	// when
-	newEmail := "updated-" + uuid.NewV4().String() + "@email.com"
-	newFullName := "TestUpdateUserOKWithoutContextInfo"
-	newImageURL := "http://new.image.io/imageurl"
-	newBio := "new bio"
-	newProfileURL := "http://new.profile.url/url"
-	secureService, secureController := s.SecuredController(identity)
+func (s *TestUsersSuite) SecuredControllerWithContextInformationAllowlist(identity account.Identity) (*goa.Service, *UsersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
 
-	updateUsersPayload := createUpdateUsersPayloadWithoutContextInformation(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL)
-	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
+	return svc, NewUsersController(svc, s.db, contextInformationAllowlistConfiguration{s.configuration}, s.profileService, s.policyManager, s.sessionManager, s.mailer, s.emailManager)
 }
 
-func (s *TestUsersSuite) TestPatchUserContextInformation() {
+func (s *TestUsersSuite) TestUpdateUserContextInfoWithAllowedKeysOK() {
+	// given
+	user := s.createRandomUser("TestUpdateUserContextInfoWithAllowedKeysOK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithContextInformationAllowlist(identity)
+	contextInformation := map[string]interface{}{
+		"last_visited": "yesterday",
+		"space":        "3d6dab8d-f204-42e8-ab29-cdb1c93130ad",
+	}
+	// when
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	require.NotNil(s.T(), result)
+	updatedContextInformation := result.Data.Attributes.ContextInformation
+	assert.Equal(s.T(), contextInformation["last_visited"], updatedContextInformation["last_visited"])
+	assert.Equal(s.T(), contextInformation["space"], updatedContextInformation["space"])
+}
+
+func (s *TestUsersSuite) TestUpdateUserContextInfoUnsetAllowedKeyOK() {
+	// given
+	user := s.createRandomUser("TestUpdateUserContextInfoUnsetAllowedKeyOK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithContextInformationAllowlist(identity)
+	contextInformation := map[string]interface{}{
+		"last_visited": "yesterday",
+		"space":        "3d6dab8d-f204-42e8-ab29-cdb1c93130ad",
+	}
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// when
+	contextInformation = map[string]interface{}{
+		"last_visited": nil,
+	}
+	updateUsersPayload = createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
+	_, result := test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	// then
+	require.NotNil(s.T(), result)
+	updatedContextInformation := result.Data.Attributes.ContextInformation
+	_, ok := updatedContextInformation["last_visited"]
+	assert.False(s.T(), ok)
+	assert.Equal(s.T(), "3d6dab8d-f204-42e8-ab29-cdb1c93130ad", updatedContextInformation["space"])
+}
+
+func (s *TestUsersSuite) TestUpdateUserContextInfoWithDisallowedKeyBadRequest() {
+	// given
+	user := s.createRandomUser("TestUpdateUserContextInfoWithDisallowedKeyBadRequest")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	secureService, secureController := s.SecuredControllerWithContextInformationAllowlist(identity)
+	contextInformation := map[string]interface{}{
+		"last_visited": "yesterday",
+		"rate":         100.00,
+	}
+	// when/then
+	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
+	test.UpdateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	_, ok := result.Data.Attributes.ContextInformation["last_visited"]
+	assert.False(s.T(), ok)
+}
+
+/*
+	Pass no contextInformation and no one complains.
+	This is as per general service behaviour.
+*/
+
+func (s *TestUsersSuite) TestUpdateUserOKWithoutContextInfo() {
+
+	// given
+	user := s.createRandomUser("TestUpdateUserOKWithoutContextInfo")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
+	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
+	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
+	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
+	assert.Equal(s.T(), identity.ProviderType, *result.Data.Attributes.ProviderType)
+	assert.Equal(s.T(), identity.Username, *result.Data.Attributes.Username)
+	// when
+	newEmail := "updated-" + uuid.NewV4().String() + "@email.com"
+	newFullName := "TestUpdateUserOKWithoutContextInfo"
+	newImageURL := "http://new.image.io/imageurl"
+	newBio := "new bio"
+	newProfileURL := "http://new.profile.url/url"
+	secureService, secureController := s.SecuredController(identity)
+
+	updateUsersPayload := createUpdateUsersPayloadWithoutContextInformation(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL)
+	test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+}
+
+func (s *TestUsersSuite) TestPatchUserContextInformation() {
 
 	// given
 	user := s.createRandomUser("TestPatchUserContextInformation")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
 	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
@@ -407,12 +1048,12 @@ func (s *TestUsersSuite) TestPatchUserContextInformation() {
 	}
 	//secureController, secureService := createSecureController(t, identity)
 	updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	// then
 	require.NotNil(s.T(), result)
 
 	// let's fetch it and validate the usual stuff.
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	updatedContextInformation := result.Data.Attributes.ContextInformation
@@ -429,11 +1070,11 @@ func (s *TestUsersSuite) TestPatchUserContextInformation() {
 	}
 
 	updateUsersPayload = createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, patchedContextInformation)
-	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, updateUsersPayload)
+	_, result = test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
 	require.NotNil(s.T(), result)
 
 	// let's fetch it and validate the usual stuff.
-	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result = test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	require.NotNil(s.T(), result)
 	updatedContextInformation = result.Data.Attributes.ContextInformation
 
@@ -451,7 +1092,7 @@ func (s *TestUsersSuite) TestUpdateUserUnauthorized() {
 	// given
 	user := s.createRandomUser("TestUpdateUserUnauthorized")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
 	assert.Equal(s.T(), user.ImageURL, *result.Data.Attributes.ImageURL)
@@ -469,7 +1110,7 @@ func (s *TestUsersSuite) TestUpdateUserUnauthorized() {
 	//secureController, secureService := createSecureController(t, identity)
 	updateUsersPayload := createUpdateUsersPayload(&newEmail, &newFullName, &newBio, &newImageURL, &newProfileURL, nil, nil, contextInformation)
 	// when/then
-	test.UpdateUsersUnauthorized(s.T(), context.Background(), nil, s.controller, updateUsersPayload)
+	test.UpdateUsersUnauthorized(s.T(), context.Background(), nil, s.controller, nil, updateUsersPayload)
 }
 
 func (s *TestUsersSuite) TestShowUserOK() {
@@ -477,7 +1118,7 @@ func (s *TestUsersSuite) TestShowUserOK() {
 	user := s.createRandomUser("TestShowUserOK")
 	identity := s.createRandomIdentity(user, account.KeycloakIDP)
 	// when
-	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String())
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), nil, nil, nil, nil)
 	// then
 	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
 	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
@@ -496,15 +1137,140 @@ func (s *TestUsersSuite) TestListUsersOK() {
 	user2 := s.createRandomUser("TestListUsersOK2")
 	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
 	// when
-	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil)
+	result := s.listAllUsers(nil)
 	// then
 	s.T().Log(fmt.Sprintf("User1 #%s: %s %s", user1.ID.String(), identity11.ID.String(), identity12.ID.String()))
 	s.T().Log(fmt.Sprintf("User2 #%s: %s", user2.ID.String(), identity2.ID.String()))
-	for i, data := range result.Data {
+	for i, data := range result {
 		s.T().Log(fmt.Sprintf("Result #%d: %s %v", i, *data.ID, *data.Attributes.Username))
 	}
-	assertUser(s.T(), findUser(identity11.ID, result.Data), user1, identity11)
-	assertUser(s.T(), findUser(identity2.ID, result.Data), user2, identity2)
+	assertUser(s.T(), findUser(identity11.ID, result), user1, identity11)
+	assertUser(s.T(), findUser(identity2.ID, result), user2, identity2)
+}
+
+func (s *TestUsersSuite) TestShowUserWithSparseFieldsetOK() {
+	// given
+	user := s.createRandomUser("TestShowUserWithSparseFieldsetOK")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	fields := "username,fullName"
+	// when
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, identity.ID.String(), &fields, nil, nil, nil)
+	// then
+	require.NotNil(s.T(), result.Data.Attributes.Username)
+	assert.Equal(s.T(), identity.Username, *result.Data.Attributes.Username)
+	require.NotNil(s.T(), result.Data.Attributes.FullName)
+	assert.Equal(s.T(), user.FullName, *result.Data.Attributes.FullName)
+	assert.Nil(s.T(), result.Data.Attributes.Email)
+	assert.Nil(s.T(), result.Data.Attributes.Company)
+	assert.Nil(s.T(), result.Data.Attributes.ContextInformation)
+	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
+}
+
+func (s *TestUsersSuite) TestShowUserWithUnknownFieldBadRequest() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestShowUserWithUnknownFieldBadRequest"), account.KeycloakIDP)
+	fields := "username,password"
+	// when/then
+	test.ShowUsersBadRequest(s.T(), nil, nil, s.controller, identity.ID.String(), &fields, nil, nil, nil)
+}
+
+func (s *TestUsersSuite) TestListUsersWithSparseFieldsetOK() {
+	// given
+	for i := 0; i < 3; i++ {
+		user := s.createRandomUser(fmt.Sprintf("TestListUsersWithSparseFieldsetOK%d", i))
+		s.createRandomIdentity(user, account.KeycloakIDP)
+	}
+	fields := "username"
+	sort := "full_name"
+	limit := 2
+	// when
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, &fields, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, nil, &sort, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 2)
+	for _, data := range result.Data {
+		assert.NotNil(s.T(), data.Attributes.Username)
+		assert.Nil(s.T(), data.Attributes.FullName)
+		assert.Nil(s.T(), data.Attributes.Email)
+	}
+	require.NotNil(s.T(), result.Links.Next)
+	assert.Contains(s.T(), *result.Links.Next, "fields[identities]=username")
+}
+
+// listAllUsers follows the paging links of the user list until the last page and returns the users of all pages
+func (s *TestUsersSuite) listAllUsers(sort *string) []*app.IdentityData {
+	var users []*app.IdentityData
+	limit := 7
+	for offset := 0; ; offset += limit {
+		pageOffset := strconv.Itoa(offset)
+		_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &pageOffset, sort, nil, nil)
+		require.True(s.T(), len(result.Data) <= limit)
+		users = append(users, result.Data...)
+		if result.Links.Next == nil {
+			require.Len(s.T(), users, result.Meta.TotalCount)
+			return users
+		}
+	}
+}
+
+func (s *TestUsersSuite) TestListUsersPagedOK() {
+	// given
+	for i := 0; i < 3; i++ {
+		user := s.createRandomUser(fmt.Sprintf("TestListUsersPagedOK%d", i))
+		s.createRandomIdentity(user, account.KeycloakIDP)
+	}
+	limit := 2
+	offset := "0"
+	// when
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 2)
+	assert.True(s.T(), result.Meta.TotalCount >= 3)
+	require.NotNil(s.T(), result.Links.Next)
+	assert.Contains(s.T(), *result.Links.Next, "page[offset]=2")
+	assert.Nil(s.T(), result.Links.Prev)
+}
+
+func (s *TestUsersSuite) TestListUsersSortedOK() {
+	// given
+	for i := 0; i < 3; i++ {
+		user := s.createRandomUser(fmt.Sprintf("TestListUsersSortedOK%d", i))
+		s.createRandomIdentity(user, account.KeycloakIDP)
+	}
+	s.T().Run("ascending username", func(t *testing.T) {
+		// when
+		sort := "username"
+		result := s.listAllUsers(&sort)
+		// then
+		for i := 1; i < len(result); i++ {
+			assert.True(t, *result[i-1].Attributes.Username <= *result[i].Attributes.Username)
+		}
+	})
+	s.T().Run("descending email", func(t *testing.T) {
+		// when
+		sort := "-email"
+		result := s.listAllUsers(&sort)
+		// then
+		for i := 1; i < len(result); i++ {
+			assert.True(t, *result[i-1].Attributes.Email >= *result[i].Attributes.Email)
+		}
+	})
+}
+
+func (s *TestUsersSuite) TestListUsersByEmailPagedOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByEmailPagedOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestListUsersByEmailPagedOK2")
+	s.createRandomIdentity(user2, account.KeycloakIDP)
+	limit := 1
+	// when
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &user1.Email, nil, nil, nil, nil, nil, nil, nil, &limit, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assertUser(s.T(), result.Data[0], user1, identity1)
+	// the total count is the one of the filtered users, not the one of all users
+	assert.Equal(s.T(), 1, result.Meta.TotalCount)
+	assert.Nil(s.T(), result.Links.Next)
 }
 
 func (s *TestUsersSuite) TestListUsersByUsernameOK() {
@@ -516,7 +1282,7 @@ func (s *TestUsersSuite) TestListUsersByUsernameOK() {
 	user2 := s.createRandomUser("TestListUsersOK2")
 	s.createRandomIdentity(user2, account.KeycloakIDP)
 	// when
-	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &identity11.Username)
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, nil, nil, &identity11.Username, nil, nil, nil, nil, nil, nil)
 	// then
 	for i, data := range result.Data {
 		s.T().Log(fmt.Sprintf("Result #%d: %s %v", i, *data.ID, *data.Attributes.Username))
@@ -525,6 +1291,420 @@ func (s *TestUsersSuite) TestListUsersByUsernameOK() {
 	assertUser(s.T(), findUser(identity11.ID, result.Data), user1, identity11)
 }
 
+func (s *TestUsersSuite) TestListUsersByIDsOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByIDsOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestListUsersByIDsOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	user3 := s.createRandomUser("TestListUsersByIDsOK3")
+	identity3 := s.createRandomIdentity(user3, account.KeycloakIDP)
+	// when
+	ids := identity1.ID.String() + ", " + identity2.ID.String() + "," + uuid.NewV4().String()
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, &ids, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 2)
+	assert.Equal(s.T(), 2, result.Meta.TotalCount)
+	assertUser(s.T(), findUser(identity1.ID, result.Data), user1, identity1)
+	assertUser(s.T(), findUser(identity2.ID, result.Data), user2, identity2)
+	assert.Nil(s.T(), findUser(identity3.ID, result.Data))
+}
+
+func (s *TestUsersSuite) TestListUsersByInvalidIDsBadRequest() {
+	// given
+	ids := uuid.NewV4().String() + ",not-an-id"
+	// when/then
+	test.ListUsersBadRequest(s.T(), nil, nil, s.controller, nil, nil, nil, nil, &ids, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+}
+
+func (s *TestUsersSuite) TestListUsersByStateOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByStateOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user1.State = account.UserStateBanned
+	require.Nil(s.T(), s.db.Users().Save(context.Background(), &user1))
+	user2 := s.createRandomUser("TestListUsersByStateOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	// when
+	state := account.UserStateBanned
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, nil, &state, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.NotNil(s.T(), findUser(identity1.ID, result.Data))
+	assert.Equal(s.T(), account.UserStateBanned, *findUser(identity1.ID, result.Data).Attributes.State)
+	assert.Nil(s.T(), findUser(identity2.ID, result.Data))
+}
+
+func (s *TestUsersSuite) TestListUsersByUsernameContainingOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByUsernameContainingOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestListUsersByUsernameContainingOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	// when
+	term := strings.ToUpper(identity1.Username[len("TestUpdateUserIntegration123"):])
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &term, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assertUser(s.T(), findUser(identity1.ID, result.Data), user1, identity1)
+	assert.Nil(s.T(), findUser(identity2.ID, result.Data))
+}
+
+func (s *TestUsersSuite) TestListUsersByEmailContainingOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByEmailContainingOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestListUsersByEmailContainingOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	// when
+	term := strings.ToUpper(user1.Email[:8])
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, &term, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assertUser(s.T(), findUser(identity1.ID, result.Data), user1, identity1)
+	assert.Nil(s.T(), findUser(identity2.ID, result.Data))
+}
+
+func (s *TestUsersSuite) TestListUsersByCompanyOK() {
+	// given
+	user1 := s.createRandomUser("TestListUsersByCompanyOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestListUsersByCompanyOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	// when
+	company := strings.ToUpper(user1.Company)
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, &company, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assertUser(s.T(), findUser(identity1.ID, result.Data), user1, identity1)
+	assert.Nil(s.T(), findUser(identity2.ID, result.Data))
+}
+
+func (s *TestUsersSuite) TestListCompaniesOK() {
+	// given 2 users of the same company, and a user of another company with the same prefix
+	prefix := uuid.NewV4().String()
+	company := prefix + " Company"
+	for _, name := range []string{"TestListCompaniesOK1", "TestListCompaniesOK2"} {
+		user := s.createRandomUser(name)
+		user.Company = company
+		require.Nil(s.T(), s.userRepo.Save(context.Background(), &user))
+	}
+	other := s.createRandomUser("TestListCompaniesOK3")
+	other.Company = prefix + " Another Company"
+	require.Nil(s.T(), s.userRepo.Save(context.Background(), &other))
+	s.createRandomUser("TestListCompaniesOK4")
+	startsWith := strings.ToUpper(prefix)
+	// when
+	_, result := test.ListCompaniesUsersOK(s.T(), nil, nil, s.controller, nil, &startsWith)
+	// then the companies are listed once, in alphabetical order
+	require.Len(s.T(), result.Data, 2)
+	assert.Equal(s.T(), other.Company, result.Data[0].Attributes.Name)
+	assert.Equal(s.T(), company, result.Data[1].Attributes.Name)
+	// when
+	limit := 1
+	_, result = test.ListCompaniesUsersOK(s.T(), nil, nil, s.controller, &limit, &startsWith)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), other.Company, result.Data[0].Attributes.Name)
+}
+
+func (s *TestUsersSuite) TestListUsersByQueryOK() {
+	// given
+	term := uuid.NewV4().String()
+	byUsername := s.createRandomIdentity(s.createRandomUser("TestListUsersByQueryOK1"), account.KeycloakIDP)
+	byUsername.Username = "TestListUsersByQuery-" + term
+	require.Nil(s.T(), s.identityRepo.Save(context.Background(), &byUsername))
+	byFullName := s.createRandomIdentity(s.createRandomUser("TestListUsersByQueryOK2 "+strings.ToUpper(term)), account.KeycloakIDP)
+	emailUser := s.createRandomUser("TestListUsersByQueryOK3")
+	emailUser.Email = term + "@example.com"
+	require.Nil(s.T(), s.userRepo.Save(context.Background(), &emailUser))
+	byEmail := s.createRandomIdentity(emailUser, account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestListUsersByQueryOK4"), account.KeycloakIDP)
+	// when
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, &term, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	assert.NotNil(s.T(), findUser(byUsername.ID, result.Data))
+	assert.NotNil(s.T(), findUser(byFullName.ID, result.Data))
+	assert.NotNil(s.T(), findUser(byEmail.ID, result.Data))
+	assert.Nil(s.T(), findUser(other.ID, result.Data))
+	require.NotNil(s.T(), result.Links.First)
+	assert.Contains(s.T(), *result.Links.First, "filter[q]="+term)
+}
+
+func (s *TestUsersSuite) TestListUsersByQueryMatchesWildcardsLiterally() {
+	// given
+	s.createRandomIdentity(s.createRandomUser("TestListUsersByQueryMatchesWildcardsLiterally1"), account.KeycloakIDP)
+	// when
+	q := "TestListUsersByQuery%Literally_"
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, &q, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	assert.Empty(s.T(), result.Data)
+}
+
+func (s *TestUsersSuite) createSearchableUsers(term string) (exactMatch, prefixMatch, substringMatch account.Identity) {
+	// the username of the first user is the search term
+	user := s.createRandomUser("TestSearchUsersOK exact")
+	exactMatch = s.createRandomIdentity(user, account.KeycloakIDP)
+	exactMatch.Username = term
+	require.Nil(s.T(), s.identityRepo.Save(context.Background(), &exactMatch))
+	// the email of the second user starts with the search term
+	user = s.createRandomUser("TestSearchUsersOK prefix")
+	user.Email = term + "-prefix@example.com"
+	require.Nil(s.T(), s.userRepo.Save(context.Background(), &user))
+	prefixMatch = s.createRandomIdentity(user, account.KeycloakIDP)
+	// the full name of the third user contains the search term, in upper case
+	user = s.createRandomUser("TestSearchUsersOK " + strings.ToUpper(term) + " substring")
+	substringMatch = s.createRandomIdentity(user, account.KeycloakIDP)
+	// deactivated and non-Keycloak identities are never found
+	user = s.createRandomUser("TestSearchUsersOK " + term + " deactivated")
+	deactivated := s.createRandomIdentity(user, account.KeycloakIDP)
+	deactivatedAt := time.Now()
+	deactivated.DeactivatedAt = &deactivatedAt
+	require.Nil(s.T(), s.identityRepo.Save(context.Background(), &deactivated))
+	user = s.createRandomUser("TestSearchUsersOK " + term + " github")
+	s.createRandomIdentity(user, "github-test")
+	// unrelated user
+	user = s.createRandomUser("TestSearchUsersOK unrelated")
+	s.createRandomIdentity(user, account.KeycloakIDP)
+	return exactMatch, prefixMatch, substringMatch
+}
+
+func (s *TestUsersSuite) TestSearchUsersOK() {
+	// given
+	term := "search" + uuid.NewV4().String()[:8]
+	exactMatch, prefixMatch, substringMatch := s.createSearchableUsers(term)
+	// when
+	_, result := test.SearchUsersOK(s.T(), nil, nil, s.controller, nil, nil, strings.ToUpper(term), nil)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	assert.Equal(s.T(), 3, result.Meta.TotalCount)
+	assert.Equal(s.T(), exactMatch.ID.String(), *result.Data[0].ID)
+	assert.Equal(s.T(), prefixMatch.ID.String(), *result.Data[1].ID)
+	assert.Equal(s.T(), substringMatch.ID.String(), *result.Data[2].ID)
+}
+
+func (s *TestUsersSuite) TestSearchUsersByPartialTermOK() {
+	// given
+	term := "search" + uuid.NewV4().String()[:8]
+	exactMatch, prefixMatch, substringMatch := s.createSearchableUsers(term)
+	// when
+	_, result := test.SearchUsersOK(s.T(), nil, nil, s.controller, nil, nil, term[3:], nil)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	ids := make([]string, len(result.Data))
+	for i, data := range result.Data {
+		ids[i] = *data.ID
+	}
+	assert.Contains(s.T(), ids, exactMatch.ID.String())
+	assert.Contains(s.T(), ids, prefixMatch.ID.String())
+	assert.Contains(s.T(), ids, substringMatch.ID.String())
+}
+
+func (s *TestUsersSuite) TestSearchUsersPagedOK() {
+	// given
+	term := "search" + uuid.NewV4().String()[:8]
+	exactMatch, prefixMatch, substringMatch := s.createSearchableUsers(term)
+	limit := 2
+	// when
+	_, result := test.SearchUsersOK(s.T(), nil, nil, s.controller, &limit, nil, term, nil)
+	// then
+	require.Len(s.T(), result.Data, 2)
+	assert.Equal(s.T(), 3, result.Meta.TotalCount)
+	assert.Equal(s.T(), exactMatch.ID.String(), *result.Data[0].ID)
+	assert.Equal(s.T(), prefixMatch.ID.String(), *result.Data[1].ID)
+	require.NotNil(s.T(), result.Links.Next)
+	assert.Contains(s.T(), *result.Links.Next, "q="+term)
+	// when
+	offset := "2"
+	_, result = test.SearchUsersOK(s.T(), nil, nil, s.controller, &limit, &offset, term, nil)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), 3, result.Meta.TotalCount)
+	assert.Equal(s.T(), substringMatch.ID.String(), *result.Data[0].ID)
+	assert.Nil(s.T(), result.Links.Next)
+	// when the requested page is after the last match
+	offset = "5"
+	_, result = test.SearchUsersOK(s.T(), nil, nil, s.controller, &limit, &offset, term, nil)
+	// then
+	assert.Empty(s.T(), result.Data)
+	assert.Equal(s.T(), 3, result.Meta.TotalCount)
+}
+
+func (s *TestUsersSuite) TestSearchUsersWildcardsNotInterpreted() {
+	// given
+	term := "search" + uuid.NewV4().String()[:8]
+	s.createSearchableUsers(term)
+	// when
+	_, result := test.SearchUsersOK(s.T(), nil, nil, s.controller, nil, nil, term[:8]+"%_", nil)
+	// then
+	assert.Empty(s.T(), result.Data)
+	assert.Equal(s.T(), 0, result.Meta.TotalCount)
+}
+
+func (s *TestUsersSuite) TestSearchUsersBlankTermBadRequest() {
+	test.SearchUsersBadRequest(s.T(), nil, nil, s.controller, nil, nil, "  ", nil)
+}
+
+func (s *TestUsersSuite) TestSearchUsersInSpaceOK() {
+	// given a space where only the exact and substring matches are collaborators
+	term := "search" + uuid.NewV4().String()[:8]
+	exactMatch, prefixMatch, substringMatch := s.createSearchableUsers(term)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, exactMatch)
+	resource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		resource.PolicyID: {exactMatch, substringMatch},
+	}
+	svc, ctrl := s.SecuredController(prefixMatch)
+	spaceID := sp.ID.String()
+	limit := 1
+	// when
+	_, result := test.SearchUsersOK(s.T(), svc.Context, svc, ctrl, &limit, nil, term, &spaceID)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), 2, result.Meta.TotalCount)
+	assert.Equal(s.T(), exactMatch.ID.String(), *result.Data[0].ID)
+	require.NotNil(s.T(), result.Links.Next)
+	assert.Contains(s.T(), *result.Links.Next, "spaceID="+spaceID)
+	// when
+	offset := "1"
+	_, result = test.SearchUsersOK(s.T(), svc.Context, svc, ctrl, &limit, &offset, term, &spaceID)
+	// then the non-collaborator is not suggested
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), substringMatch.ID.String(), *result.Data[0].ID)
+	assert.Nil(s.T(), result.Links.Next)
+}
+
+func (s *TestUsersSuite) TestSearchUsersInSpaceWithoutMatchingCollaboratorOK() {
+	// given
+	term := "search" + uuid.NewV4().String()[:8]
+	exactMatch, _, _ := s.createSearchableUsers(term)
+	owner := s.createRandomIdentity(s.createRandomUser("TestSearchUsersInSpaceOwner"), account.KeycloakIDP)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	s.policyManager.collaborators = []account.Identity{owner}
+	svc, ctrl := s.SecuredController(exactMatch)
+	spaceID := sp.ID.String()
+	// when
+	_, result := test.SearchUsersOK(s.T(), svc.Context, svc, ctrl, nil, nil, term, &spaceID)
+	// then
+	assert.Empty(s.T(), result.Data)
+	assert.Equal(s.T(), 0, result.Meta.TotalCount)
+}
+
+func (s *TestUsersSuite) TestSearchUsersInInvalidSpaceBadRequest() {
+	spaceID := "not-a-space-id"
+	test.SearchUsersBadRequest(s.T(), nil, nil, s.controller, nil, nil, "search", &spaceID)
+}
+
+func (s *TestUsersSuite) TestSearchUsersInUnknownSpaceNotFound() {
+	spaceID := uuid.NewV4().String()
+	test.SearchUsersNotFound(s.T(), nil, nil, s.controller, nil, nil, "search", &spaceID)
+}
+
+func (s *TestUsersSuite) SecuredUserController(identity account.Identity) (*goa.Service, *UserController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("User-Service", almtoken.NewManager(pub), identity)
+	return svc, NewUserController(svc, s.db, almtoken.NewManager(pub))
+}
+
+func (s *TestUsersSuite) TestListRecentSpacesOK() {
+	// given a user who visited the spaces 1, 2, 3, then 1 again
+	identity := s.createRandomIdentity(s.createRandomUser("TestListRecentSpacesOK"), account.KeycloakIDP)
+	space1 := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	space2 := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	space3 := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	secureService, secureController := s.SecuredController(identity)
+	for _, sp := range []app.Space{space1, space2, space3, space1} {
+		contextInformation := map[string]interface{}{
+			"space":        sp.ID.String(),
+			"last_visited": time.Now().String(),
+		}
+		updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, contextInformation)
+		test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	}
+	userService, userController := s.SecuredUserController(identity)
+	// when
+	_, result := test.ListRecentSpacesUserOK(s.T(), userService.Context, userService, userController)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	assert.Equal(s.T(), 3, result.Meta.TotalCount)
+	assert.Equal(s.T(), *space1.ID, *result.Data[0].ID)
+	assert.Equal(s.T(), *space3.ID, *result.Data[1].ID)
+	assert.Equal(s.T(), *space2.ID, *result.Data[2].ID)
+}
+
+func (s *TestUsersSuite) TestListRecentSpacesSkipsDeletedSpaces() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestListRecentSpacesSkipsDeletedSpaces"), account.KeycloakIDP)
+	space1 := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	space2 := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	secureService, secureController := s.SecuredController(identity)
+	for _, sp := range []app.Space{space1, space2} {
+		updateUsersPayload := createUpdateUsersPayload(nil, nil, nil, nil, nil, nil, nil, map[string]interface{}{"space": sp.ID.String()})
+		test.UpdateUsersOK(s.T(), secureService.Context, secureService, secureController, nil, updateUsersPayload)
+	}
+	require.Nil(s.T(), s.db.Spaces().Delete(context.Background(), *space2.ID))
+	userService, userController := s.SecuredUserController(identity)
+	// when
+	_, result := test.ListRecentSpacesUserOK(s.T(), userService.Context, userService, userController)
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), *space1.ID, *result.Data[0].ID)
+}
+
+func (s *TestUsersSuite) TestListRecentSpacesWithoutVisitOK() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestListRecentSpacesWithoutVisitOK"), account.KeycloakIDP)
+	userService, userController := s.SecuredUserController(identity)
+	// when
+	_, result := test.ListRecentSpacesUserOK(s.T(), userService.Context, userService, userController)
+	// then
+	assert.Empty(s.T(), result.Data)
+	assert.Equal(s.T(), 0, result.Meta.TotalCount)
+}
+
+func (s *TestUsersSuite) TestResolveUsersOK() {
+	// given
+	user1 := s.createRandomUser("TestResolveUsersOK1")
+	identity1 := s.createRandomIdentity(user1, account.KeycloakIDP)
+	user2 := s.createRandomUser("TestResolveUsersOK2")
+	identity2 := s.createRandomIdentity(user2, account.KeycloakIDP)
+	user3 := s.createRandomUser("TestResolveUsersOK3")
+	s.createRandomIdentity(user3, account.KeycloakIDP)
+	unknownUsername := "unknown" + uuid.NewV4().String()
+	// when
+	_, result := test.ResolveUsersOK(s.T(), nil, nil, s.controller, []string{identity2.Username, unknownUsername, identity1.Username, identity2.Username})
+	// then
+	require.Len(s.T(), result.Data, 2)
+	assertUser(s.T(), result.Data[0], user2, identity2)
+	assertUser(s.T(), result.Data[1], user1, identity1)
+}
+
+func (s *TestUsersSuite) TestResolveUsersOnlyUnknownOK() {
+	// when
+	_, result := test.ResolveUsersOK(s.T(), nil, nil, s.controller, []string{"unknown" + uuid.NewV4().String()})
+	// then
+	assert.Empty(s.T(), result.Data)
+}
+
+func (s *TestUsersSuite) TestResolveUsersPrefersActiveIdentityOK() {
+	// given
+	deactivatedUser := s.createRandomUser("TestResolveUsersDeactivated")
+	deactivatedIdentity := s.createRandomIdentity(deactivatedUser, account.KeycloakIDP)
+	s.deactivateIdentity(deactivatedIdentity, time.Now().Add(-s.configuration.GetUsernameReuseGracePeriod()-time.Hour))
+	user := s.createRandomUser("TestResolveUsersActive")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	identity.Username = deactivatedIdentity.Username
+	err := s.identityRepo.Save(context.Background(), &identity)
+	require.Nil(s.T(), err)
+	// when
+	_, result := test.ResolveUsersOK(s.T(), nil, nil, s.controller, []string{identity.Username})
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), identity.ID.String(), *result.Data[0].ID)
+	assertUser(s.T(), result.Data[0], user, identity)
+}
+
 func (s *TestUsersSuite) TestListUsersByEmailOK() {
 	// given user1
 	user1 := s.createRandomUser("TestListUsersOK1")
@@ -535,7 +1715,7 @@ func (s *TestUsersSuite) TestListUsersByEmailOK() {
 	user2 := s.createRandomUser("TestListUsersOK2")
 	s.createRandomIdentity(user2, account.KeycloakIDP)
 	// when
-	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, &user1.Email, nil, nil)
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, &user1.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	// then
 	for i, data := range result.Data {
 		s.T().Log(fmt.Sprintf("Result #%d: %s %v", i, *data.ID, *data.Attributes.Username))
@@ -557,7 +1737,7 @@ func (s *TestUsersSuite) TestListUsersByRegistrationCompletedOK() {
 	s.createRandomIdentity(user2, account.KeycloakIDP)
 	// when
 	boolFalse := false
-	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, &boolFalse, nil)
+	_, result := test.ListUsersOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, nil, &boolFalse, nil, nil, nil, nil, nil, nil, nil, nil)
 	// then
 	for i, data := range result.Data {
 		s.T().Log(fmt.Sprintf("Result #%d: %s %v", i, *data.ID, *data.Attributes.Username))
@@ -565,6 +1745,662 @@ func (s *TestUsersSuite) TestListUsersByRegistrationCompletedOK() {
 	}
 }
 
+// testUsersPolicyManager returns a space policy with the given collaborators, unless other collaborators
+// are given for the policy ID
+type testUsersPolicyManager struct {
+	DummyPolicyManager
+	collaborators         []account.Identity
+	collaboratorsByPolicy map[string][]account.Identity
+	updatedPolicies       []auth.KeycloakPolicy
+	failingUpdates        int
+}
+
+func (m *testUsersPolicyManager) GetPolicy(ctx context.Context, request *goa.RequestData, policyID string) (*auth.KeycloakPolicy, *string, error) {
+	collaborators, ok := m.collaboratorsByPolicy[policyID]
+	if !ok {
+		collaborators = m.collaborators
+	}
+	policy := &auth.KeycloakPolicy{ID: &policyID}
+	for _, c := range collaborators {
+		policy.AddUserToPolicy(c.ID.String())
+	}
+	pat := ""
+	return policy, &pat, nil
+}
+
+func (m *testUsersPolicyManager) GetPolicies(ctx context.Context, request *goa.RequestData, policyIDs []string) ([]*auth.KeycloakPolicy, error) {
+	policies := make([]*auth.KeycloakPolicy, len(policyIDs))
+	for i, policyID := range policyIDs {
+		policies[i], _, _ = m.GetPolicy(ctx, request, policyID)
+	}
+	return policies, nil
+}
+
+func (m *testUsersPolicyManager) UpdatePolicy(ctx context.Context, request *goa.RequestData, policy auth.KeycloakPolicy, pat string) error {
+	if m.failingUpdates > 0 {
+		m.failingUpdates--
+		return errs.New("keycloak unavailable")
+	}
+	m.updatedPolicies = append(m.updatedPolicies, policy)
+	return nil
+}
+
+// testUserSessionManager records the users whose sessions were revoked, or fails with the given error
+type testUserSessionManager struct {
+	revokedUserIDs []string
+	err            error
+}
+
+func (m *testUserSessionManager) RevokeSessions(ctx context.Context, request *goa.RequestData, userID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.revokedUserIDs = append(m.revokedUserIDs, userID)
+	return nil
+}
+
+// testMailer records the sent emails
+type testMailer struct {
+	sent []testEmail
+}
+
+type testEmail struct {
+	to      string
+	subject string
+	body    string
+}
+
+func (m *testMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.sent = append(m.sent, testEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// testUserEmailManager records the emails updated in the identity provider, indexed by user ID
+type testUserEmailManager struct {
+	updatedEmails map[string]string
+}
+
+func (m *testUserEmailManager) UpdateEmail(ctx context.Context, request *goa.RequestData, userID string, email string) error {
+	if m.updatedEmails == nil {
+		m.updatedEmails = map[string]string{}
+	}
+	m.updatedEmails[userID] = email
+	return nil
+}
+
+// SecuredControllerWithAdmins returns a controller called with a token of the given identity, granted the admin scope
+// if the identity is among the given administrators
+func (s *TestUsersSuite) SecuredControllerWithAdmins(identity account.Identity, admins ...account.Identity) (*goa.Service, *UsersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
+	for _, admin := range admins {
+		if uuid.Equal(admin.ID, identity.ID) {
+			svc = testsupport.ServiceAsUserWithScopes("Users-Service", almtoken.NewManager(pub), identity, almtoken.ScopeAdminUsers)
+		}
+	}
+	return svc, NewUsersController(svc, s.db, s.configuration, s.profileService, s.policyManager, s.sessionManager, s.mailer, s.emailManager)
+}
+
+func (s *TestUsersSuite) TestListMembershipsOK() {
+	// given an identity owning a space and collaborating on another one
+	admin := s.createRandomIdentity(s.createRandomUser("TestListMembershipsAdmin"), account.KeycloakIDP)
+	member := s.createRandomIdentity(s.createRandomUser("TestListMembershipsMember"), account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestListMembershipsOther"), account.KeycloakIDP)
+	ownedSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, member)
+	collaboratedSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, other)
+	otherSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, other)
+	collaboratedResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), collaboratedSpace.ID)
+	require.Nil(s.T(), err)
+	otherResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), otherSpace.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		collaboratedResource.PolicyID: {other, member},
+		otherResource.PolicyID:        {other},
+	}
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	// when
+	_, result := test.ListMembershipsUsersOK(s.T(), svc.Context, svc, ctrl, member.ID.String())
+	// then
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), member.ID, result.IdentityID)
+	roles := map[uuid.UUID]string{}
+	for _, m := range result.Data {
+		roles[m.SpaceID] = m.Role
+	}
+	assert.Len(s.T(), roles, 2)
+	assert.Equal(s.T(), SpaceRoleOwner, roles[*ownedSpace.ID])
+	assert.Equal(s.T(), SpaceRoleContributor, roles[*collaboratedSpace.ID])
+	assert.NotContains(s.T(), roles, *otherSpace.ID)
+}
+
+func (s *TestUsersSuite) TestListMembershipsNotAdminForbidden() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestListMembershipsAdmin"), account.KeycloakIDP)
+	member := s.createRandomIdentity(s.createRandomUser("TestListMembershipsMember"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(member, admin)
+	// when/then
+	test.ListMembershipsUsersForbidden(s.T(), svc.Context, svc, ctrl, member.ID.String())
+}
+
+func (s *TestUsersSuite) TestListMembershipsByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	admin := s.createRandomIdentity(s.createRandomUser("TestListMembershipsAdmin"), account.KeycloakIDP)
+	impersonator := s.createRandomIdentity(s.createRandomUser("TestListMembershipsImpersonator"), account.KeycloakIDP)
+	member := s.createRandomIdentity(s.createRandomUser("TestListMembershipsMember"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	impersonatedBy(svc, impersonator)
+	// when/then
+	test.ListMembershipsUsersForbidden(s.T(), svc.Context, svc, ctrl, member.ID.String())
+}
+
+func (s *TestUsersSuite) TestListMembershipsUnknownIdentityNotFound() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestListMembershipsAdmin"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	// when/then
+	test.ListMembershipsUsersNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+func (s *TestUsersSuite) TestUpdateStateOK() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestUpdateStateAdmin"), account.KeycloakIDP)
+	user := s.createRandomUser("TestUpdateStateUser")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	// when
+	_, result := test.UpdateStateUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.UpdateUserState{State: account.UserStateBanned})
+	// then
+	assert.Equal(s.T(), account.UserStateBanned, *result.Data.Attributes.State)
+	loaded, err := s.db.Users().Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), account.UserStateBanned, loaded.State)
+}
+
+func (s *TestUsersSuite) TestUpdateStateActiveReactivatesIdentities() {
+	// given a deactivated user whose identity was deactivated on its own
+	admin := s.createRandomIdentity(s.createRandomUser("TestUpdateStateAdmin"), account.KeycloakIDP)
+	user := s.createRandomUser("TestUpdateStateUser")
+	user.State = account.UserStateDeactivated
+	require.Nil(s.T(), s.db.Users().Save(context.Background(), &user))
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	deactivatedAt := time.Now().Add(-time.Hour)
+	identity.DeactivatedAt = &deactivatedAt
+	require.Nil(s.T(), s.db.Identities().Save(context.Background(), &identity))
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	// when
+	test.UpdateStateUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.UpdateUserState{State: account.UserStateActive})
+	// then
+	loaded, err := s.identityRepo.Load(context.Background(), identity.ID)
+	require.Nil(s.T(), err)
+	assert.Nil(s.T(), loaded.DeactivatedAt)
+}
+
+func (s *TestUsersSuite) TestUpdateStateNotAdminForbidden() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestUpdateStateAdmin"), account.KeycloakIDP)
+	user := s.createRandomUser("TestUpdateStateUser")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(identity, admin)
+	// when/then
+	test.UpdateStateUsersForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.UpdateUserState{State: account.UserStateActive})
+}
+
+func (s *TestUsersSuite) TestUpdateStateByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	admin := s.createRandomIdentity(s.createRandomUser("TestUpdateStateAdmin"), account.KeycloakIDP)
+	impersonator := s.createRandomIdentity(s.createRandomUser("TestUpdateStateImpersonator"), account.KeycloakIDP)
+	user := s.createRandomUser("TestUpdateStateUser")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	impersonatedBy(svc, impersonator)
+	// when
+	test.UpdateStateUsersForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.UpdateUserState{State: account.UserStateBanned})
+	// then
+	loaded, err := s.db.Users().Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), account.UserStateActive, loaded.State)
+}
+
+func (s *TestUsersSuite) TestUpdateStateUnknownIdentityNotFound() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestUpdateStateAdmin"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithAdmins(admin, admin)
+	// when/then
+	test.UpdateStateUsersNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4().String(), &app.UpdateUserState{State: account.UserStateBanned})
+}
+
+func (s *TestUsersSuite) TestDeactivateUserTransfersSpaceOwnership() {
+	// given
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeactivateOwner"), account.KeycloakIDP)
+	newOwner := s.createRandomIdentity(s.createRandomUser("TestDeactivateNewOwner"), account.KeycloakIDP)
+	s.policyManager.collaborators = []account.Identity{owner, newOwner}
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	secureService, secureController := s.SecuredController(owner)
+	// when
+	_, result := test.DeactivateUsersOK(s.T(), secureService.Context, secureService, secureController, &app.DeactivateUsersPayload{NewOwner: &newOwner.ID})
+	// then
+	assert.True(s.T(), *result.Data.Attributes.Deactivated)
+	loaded, err := s.db.Spaces().Load(context.Background(), *sp.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), newOwner.ID, loaded.OwnerId)
+}
+
+func (s *TestUsersSuite) TestDeactivateUserWithoutSpaceOK() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestDeactivateWithoutSpace"), account.KeycloakIDP)
+	secureService, secureController := s.SecuredController(identity)
+	// when
+	_, result := test.DeactivateUsersOK(s.T(), secureService.Context, secureService, secureController, &app.DeactivateUsersPayload{})
+	// then the user is deactivated along with the identity, and the token of the identity is rejected
+	assert.True(s.T(), *result.Data.Attributes.Deactivated)
+	loaded, err := s.userRepo.Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), account.UserStateDeactivated, loaded.State)
+	handled := false
+	handler := login.RejectInactiveUsers(s.db)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		handled = true
+		return nil
+	})
+	err = handler(secureService.Context, httptest.NewRecorder(), nil)
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+	serviceError, ok := err.(goa.ServiceError)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), http.StatusForbidden, serviceError.ResponseStatus())
+}
+
+func (s *TestUsersSuite) TestDeactivateSpaceOwnerWithoutNewOwnerBadRequest() {
+	// given
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeactivateOwner"), account.KeycloakIDP)
+	s.policyManager.collaborators = []account.Identity{owner}
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	secureService, secureController := s.SecuredController(owner)
+	// when
+	test.DeactivateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, &app.DeactivateUsersPayload{})
+	// then
+	loaded, err := s.db.Spaces().Load(context.Background(), *sp.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), owner.ID, loaded.OwnerId)
+	_, result := test.ShowUsersOK(s.T(), nil, nil, s.controller, owner.ID.String(), nil, nil, nil, nil)
+	assert.False(s.T(), *result.Data.Attributes.Deactivated)
+}
+
+func (s *TestUsersSuite) TestDeactivateSpaceOwnerWithNonCollaboratorNewOwnerBadRequest() {
+	// given
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeactivateOwner"), account.KeycloakIDP)
+	newOwner := s.createRandomIdentity(s.createRandomUser("TestDeactivateNewOwner"), account.KeycloakIDP)
+	s.policyManager.collaborators = []account.Identity{owner}
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	secureService, secureController := s.SecuredController(owner)
+	// when
+	test.DeactivateUsersBadRequest(s.T(), secureService.Context, secureService, secureController, &app.DeactivateUsersPayload{NewOwner: &newOwner.ID})
+	// then
+	loaded, err := s.db.Spaces().Load(context.Background(), *sp.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), owner.ID, loaded.OwnerId)
+}
+
+func (s *TestUsersSuite) SecuredControllerWithScopes(identity account.Identity, scopes ...string) (*goa.Service, *UsersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUserWithScopes("Users-Service", almtoken.NewManager(pub), identity, scopes...)
+	return svc, NewUsersController(svc, s.db, s.configuration, s.profileService, s.policyManager, s.sessionManager, s.mailer, s.emailManager)
+}
+
+func (s *TestUsersSuite) TestDeleteUserOK() {
+	// given a user with 2 identities, an active session and collaborating on a space
+	admin := s.createRandomIdentity(s.createRandomUser("TestDeleteUserAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeleteUserOwner"), account.KeycloakIDP)
+	user := s.createRandomUser("TestDeleteUser")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	otherIdentity := s.createRandomIdentity(user, "github")
+	session, err := s.db.Sessions().Create(context.Background(), &auth.Session{IdentityID: identity.ID, SessionState: uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	spaceResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		spaceResource.PolicyID: {owner, identity},
+	}
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.DeleteUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	_, err = s.identityRepo.Load(context.Background(), identity.ID)
+	assert.NotNil(s.T(), err)
+	_, err = s.identityRepo.Load(context.Background(), otherIdentity.ID)
+	assert.NotNil(s.T(), err)
+	_, err = s.userRepo.Load(context.Background(), user.ID)
+	assert.NotNil(s.T(), err)
+	require.Len(s.T(), s.policyManager.updatedPolicies, 1)
+	updated := s.policyManager.updatedPolicies[0]
+	assert.Equal(s.T(), spaceResource.PolicyID, *updated.ID)
+	assert.True(s.T(), updated.HasUser(owner.ID.String()))
+	assert.False(s.T(), updated.HasUser(identity.ID.String()))
+	assert.Equal(s.T(), []string{identity.ID.String()}, s.sessionManager.revokedUserIDs)
+	loaded, err := s.db.Sessions().LoadBySessionState(context.Background(), session.SessionState)
+	require.Nil(s.T(), err)
+	assert.NotNil(s.T(), loaded.RevokedAt)
+}
+
+func (s *TestUsersSuite) TestDeleteUserRestoredWhenSessionsCannotBeRevoked() {
+	// given a viewer of a space whose Keycloak sessions can't be revoked
+	admin := s.createRandomIdentity(s.createRandomUser("TestDeleteUserAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeleteUserOwner"), account.KeycloakIDP)
+	user := s.createRandomUser("TestDeleteUser")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	spaceResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		spaceResource.PolicyID: {owner, identity},
+	}
+	s.sessionManager.err = errs.New("keycloak unavailable")
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.DeleteUsersInternalServerError(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then the user is not deleted and is added back to the policy
+	_, err = s.identityRepo.Load(context.Background(), identity.ID)
+	assert.Nil(s.T(), err)
+	_, err = s.userRepo.Load(context.Background(), user.ID)
+	assert.Nil(s.T(), err)
+	require.Len(s.T(), s.policyManager.updatedPolicies, 2)
+	assert.False(s.T(), s.policyManager.updatedPolicies[0].HasUser(identity.ID.String()))
+	restored := s.policyManager.updatedPolicies[1]
+	assert.Equal(s.T(), spaceResource.PolicyID, *restored.ID)
+	assert.True(s.T(), restored.HasUser(identity.ID.String()))
+	assert.Equal(s.T(), auth.PolicyRoleContributor, restored.UserRole(identity.ID.String()))
+}
+
+func (s *TestUsersSuite) TestDeleteUserWithoutScopeForbidden() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestDeleteUserAdmin"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestDeleteUser"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredController(admin)
+	// when
+	test.DeleteUsersForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	_, err := s.identityRepo.Load(context.Background(), identity.ID)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.sessionManager.revokedUserIDs)
+}
+
+func (s *TestUsersSuite) TestDeleteSpaceOwnerBadRequest() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestDeleteUserAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestDeleteUserOwner"), account.KeycloakIDP)
+	CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.DeleteUsersBadRequest(s.T(), svc.Context, svc, ctrl, owner.ID.String())
+	// then
+	_, err := s.identityRepo.Load(context.Background(), owner.ID)
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.policyManager.updatedPolicies)
+	assert.Empty(s.T(), s.sessionManager.revokedUserIDs)
+}
+
+func (s *TestUsersSuite) TestDeleteUnknownUserNotFound() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestDeleteUserAdmin"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when/then
+	test.DeleteUsersNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+func (s *TestUsersSuite) TestMergeUsersOK() {
+	// given a duplicate user with 2 identities, one of them collaborating on a space
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestMergeUsersOwner"), account.KeycloakIDP)
+	user := s.createRandomUser("TestMergeUsers")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	duplicate := s.createRandomUser("TestMergeUsersDuplicate")
+	source := s.createRandomIdentity(duplicate, account.KeycloakIDP)
+	otherSource := s.createRandomIdentity(duplicate, "github")
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	spaceResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		spaceResource.PolicyID: {owner, source},
+	}
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	_, result := test.MergeUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: source.ID})
+	// then
+	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
+	for _, i := range []account.Identity{source, otherSource} {
+		loaded, err := s.identityRepo.Load(context.Background(), i.ID)
+		require.Nil(s.T(), err)
+		assert.Equal(s.T(), user.ID, loaded.UserID.UUID)
+	}
+	_, err = s.userRepo.Load(context.Background(), duplicate.ID)
+	assert.NotNil(s.T(), err)
+	require.Len(s.T(), s.policyManager.updatedPolicies, 1)
+	updated := s.policyManager.updatedPolicies[0]
+	assert.Equal(s.T(), spaceResource.PolicyID, *updated.ID)
+	assert.True(s.T(), updated.HasUser(owner.ID.String()))
+	assert.True(s.T(), updated.HasUser(identity.ID.String()))
+	assert.False(s.T(), updated.HasUser(source.ID.String()))
+}
+
+func (s *TestUsersSuite) TestMergeUsersRetriesPolicyUpdateOK() {
+	// given a duplicate user collaborating on a space whose policy can't be updated at once
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestMergeUsersOwner"), account.KeycloakIDP)
+	user := s.createRandomUser("TestMergeUsers")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	duplicate := s.createRandomUser("TestMergeUsersDuplicate")
+	source := s.createRandomIdentity(duplicate, account.KeycloakIDP)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	spaceResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		spaceResource.PolicyID: {owner, source},
+	}
+	s.policyManager.failingUpdates = 2
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.MergeUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: source.ID})
+	// then
+	require.Len(s.T(), s.policyManager.updatedPolicies, 1)
+	assert.True(s.T(), s.policyManager.updatedPolicies[0].HasUser(identity.ID.String()))
+}
+
+func (s *TestUsersSuite) TestMergeUsersPolicyNotUpdatedInternalServerError() {
+	// given a duplicate user collaborating on a space whose policy can't be updated
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	owner := s.createRandomIdentity(s.createRandomUser("TestMergeUsersOwner"), account.KeycloakIDP)
+	user := s.createRandomUser("TestMergeUsers")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	duplicate := s.createRandomUser("TestMergeUsersDuplicate")
+	source := s.createRandomIdentity(duplicate, account.KeycloakIDP)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	spaceResource, err := s.db.SpaceResources().LoadBySpace(context.Background(), sp.ID)
+	require.Nil(s.T(), err)
+	s.policyManager.collaboratorsByPolicy = map[string][]account.Identity{
+		spaceResource.PolicyID: {owner, source},
+	}
+	s.policyManager.failingUpdates = 3
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.MergeUsersInternalServerError(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: source.ID})
+	// then the merge is committed nonetheless
+	loaded, err := s.identityRepo.Load(context.Background(), source.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), user.ID, loaded.UserID.UUID)
+	_, err = s.userRepo.Load(context.Background(), duplicate.ID)
+	assert.NotNil(s.T(), err)
+	assert.Empty(s.T(), s.policyManager.updatedPolicies)
+}
+
+func (s *TestUsersSuite) TestMergeUsersWithoutScopeForbidden() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestMergeUsers"), account.KeycloakIDP)
+	duplicate := s.createRandomUser("TestMergeUsersDuplicate")
+	source := s.createRandomIdentity(duplicate, account.KeycloakIDP)
+	svc, ctrl := s.SecuredController(admin)
+	// when
+	test.MergeUsersForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: source.ID})
+	// then
+	_, err := s.userRepo.Load(context.Background(), duplicate.ID)
+	assert.Nil(s.T(), err)
+}
+
+func (s *TestUsersSuite) TestMergeSameUserBadRequest() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	user := s.createRandomUser("TestMergeUsers")
+	identity := s.createRandomIdentity(user, account.KeycloakIDP)
+	source := s.createRandomIdentity(user, "github")
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.MergeUsersBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: source.ID})
+	// then
+	_, err := s.userRepo.Load(context.Background(), user.ID)
+	assert.Nil(s.T(), err)
+}
+
+func (s *TestUsersSuite) TestMergeUnknownSourceNotFound() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestMergeUsersAdmin"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestMergeUsers"), account.KeycloakIDP)
+	svc, ctrl := s.SecuredControllerWithScopes(admin, almtoken.ScopeAdminUsers)
+	// when/then
+	test.MergeUsersNotFound(s.T(), svc.Context, svc, ctrl, identity.ID.String(), &app.MergeUsers{Source: uuid.NewV4()})
+}
+
+// completeRegistrationController returns a controller for the given identity, whose email is verified or not in
+// Keycloak, which reports the tenant initializations to the returned channel
+func (s *TestUsersSuite) completeRegistrationController(identity account.Identity, emailVerified bool) (*goa.Service, *UsersController, chan struct{}) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
+	testAttributeValue := "a"
+	profile := createDummyUserProfileResponse(&testAttributeValue, &testAttributeValue, &testAttributeValue)
+	profile.EmailVerified = &emailVerified
+	ctrl := NewUsersController(svc, s.db, s.configuration, newDummyUserProfileService(profile), s.policyManager, s.sessionManager, s.mailer, s.emailManager)
+	tenantInitialized := make(chan struct{}, 1)
+	ctrl.InitTenant = func(ctx context.Context) error {
+		tenantInitialized <- struct{}{}
+		return nil
+	}
+	return svc, ctrl, tenantInitialized
+}
+
+func (s *TestUsersSuite) TestCompleteRegistrationOK() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestCompleteRegistration"), account.KeycloakIDP)
+	svc, ctrl, tenantInitialized := s.completeRegistrationController(identity, true)
+	// when
+	_, result := test.CompleteRegistrationUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	assert.True(s.T(), *result.Data.Attributes.RegistrationCompleted)
+	loaded, err := s.identityRepo.Load(context.Background(), identity.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), loaded.RegistrationCompleted)
+	select {
+	case <-tenantInitialized:
+	case <-time.After(5 * time.Second):
+		assert.Fail(s.T(), "the tenant was not initialized")
+	}
+}
+
+func (s *TestUsersSuite) TestCompleteRegistrationTwiceConflict() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestCompleteRegistrationTwice"), account.KeycloakIDP)
+	svc, ctrl, tenantInitialized := s.completeRegistrationController(identity, true)
+	test.CompleteRegistrationUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	<-tenantInitialized
+	// when/then
+	test.CompleteRegistrationUsersConflict(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	assert.Len(s.T(), tenantInitialized, 0)
+}
+
+func (s *TestUsersSuite) TestCompleteRegistrationWithUnverifiedEmailBadRequest() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestCompleteRegistrationUnverified"), account.KeycloakIDP)
+	svc, ctrl, tenantInitialized := s.completeRegistrationController(identity, false)
+	// when
+	test.CompleteRegistrationUsersBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	loaded, err := s.identityRepo.Load(context.Background(), identity.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), loaded.RegistrationCompleted)
+	assert.Len(s.T(), tenantInitialized, 0)
+}
+
+func (s *TestUsersSuite) TestCompleteRegistrationOfOtherUserForbidden() {
+	// given
+	identity := s.createRandomIdentity(s.createRandomUser("TestCompleteRegistrationOfOtherUser"), account.KeycloakIDP)
+	other := s.createRandomIdentity(s.createRandomUser("TestCompleteRegistrationOfOtherUserOther"), account.KeycloakIDP)
+	svc, ctrl, _ := s.completeRegistrationController(identity, true)
+	// when/then
+	test.CompleteRegistrationUsersForbidden(s.T(), svc.Context, svc, ctrl, other.ID.String())
+}
+
+func (s *TestUsersSuite) TestShowUserOwnedSpaces() {
+	// given
+	owner := s.createRandomIdentity(s.createRandomUser("TestShowUserOwnedSpaces"), account.KeycloakIDP)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	svc, ctrl := s.SecuredController(owner)
+	// when
+	_, result := test.ShowUsersOK(s.T(), svc.Context, svc, ctrl, owner.ID.String(), nil, nil, nil, nil)
+	// then
+	require.NotNil(s.T(), result.Data.Relationships)
+	require.NotNil(s.T(), result.Data.Relationships.OwnedSpaces)
+	require.Len(s.T(), result.Data.Relationships.OwnedSpaces.Data, 1)
+	assert.Equal(s.T(), sp.ID.String(), *result.Data.Relationships.OwnedSpaces.Data[0].ID)
+	assert.Equal(s.T(), APIStringTypeSpace, *result.Data.Relationships.OwnedSpaces.Data[0].Type)
+	// the collaborations are only given along with the included spaces
+	assert.Nil(s.T(), result.Data.Relationships.Spaces)
+}
+
+func (s *TestUsersSuite) TestShowUserIncludeSpaces() {
+	// given
+	owner := s.createRandomIdentity(s.createRandomUser("TestShowUserIncludeSpacesOwner"), account.KeycloakIDP)
+	identity := s.createRandomIdentity(s.createRandomUser("TestShowUserIncludeSpaces"), account.KeycloakIDP)
+	ownedSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, identity)
+	sp := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	otherSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	// the collaborators are read from their cache
+	err := s.db.SpaceCollaborators().Replace(context.Background(), *sp.ID, []space.Collaborator{
+		{IdentityID: owner.ID, Role: SpaceRoleContributor},
+		{IdentityID: identity.ID, Role: SpaceRoleContributor},
+	})
+	require.Nil(s.T(), err)
+	err = s.db.SpaceCollaborators().Replace(context.Background(), *otherSpace.ID, []space.Collaborator{
+		{IdentityID: owner.ID, Role: SpaceRoleContributor},
+	})
+	require.Nil(s.T(), err)
+	// the identity collaborates on another space through a team
+	teamSpace := CreateSecuredSpace(s.T(), s.db, s.configuration, owner)
+	team := account.Team{Name: "TestShowUserIncludeSpaces-" + uuid.NewV4().String()}
+	require.Nil(s.T(), s.db.Teams().Create(context.Background(), &team))
+	require.Nil(s.T(), s.db.Teams().AddMember(context.Background(), team.ID, identity.ID))
+	require.Nil(s.T(), s.db.Teams().AddToSpace(context.Background(), *teamSpace.ID, team.ID))
+	svc, ctrl := s.SecuredController(identity)
+	include := "spaces"
+	// when
+	_, result := test.ShowUsersOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), nil, &include, nil, nil)
+	// then
+	require.NotNil(s.T(), result.Data.Relationships)
+	require.Len(s.T(), result.Data.Relationships.OwnedSpaces.Data, 1)
+	assert.Equal(s.T(), ownedSpace.ID.String(), *result.Data.Relationships.OwnedSpaces.Data[0].ID)
+	require.NotNil(s.T(), result.Data.Relationships.Spaces)
+	require.Len(s.T(), result.Data.Relationships.Spaces.Data, 2)
+	spaceIDs := []string{*result.Data.Relationships.Spaces.Data[0].ID, *result.Data.Relationships.Spaces.Data[1].ID}
+	assert.Contains(s.T(), spaceIDs, sp.ID.String())
+	assert.Contains(s.T(), spaceIDs, teamSpace.ID.String())
+	includedSpaceIDs := []uuid.UUID{}
+	for _, i := range result.Included {
+		if included, ok := i.(*app.Space); ok {
+			includedSpaceIDs = append(includedSpaceIDs, *included.ID)
+		}
+	}
+	assert.Contains(s.T(), includedSpaceIDs, *ownedSpace.ID)
+	assert.Contains(s.T(), includedSpaceIDs, *sp.ID)
+	assert.NotContains(s.T(), includedSpaceIDs, *otherSpace.ID)
+}
+
 func (s *TestUsersSuite) createRandomUser(fullname string) account.User {
 	user := account.User{
 		Email:    uuid.NewV4().String() + "primaryForUpdat7e@example.com",
@@ -646,6 +2482,7 @@ func createUpdateUsersPayloadWithoutContextInformation(email, fullName, bio, ima
 
 type dummyUserProfileService struct {
 	dummyGetResponse *login.KeycloakUserProfileResponse
+	updatedProfile   *login.KeycloakUserProfile
 }
 
 func newDummyUserProfileService(dummyGetResponse *login.KeycloakUserProfileResponse) *dummyUserProfileService {
@@ -655,6 +2492,7 @@ func newDummyUserProfileService(dummyGetResponse *login.KeycloakUserProfileRespo
 }
 
 func (d *dummyUserProfileService) Update(keycloakUserProfile *login.KeycloakUserProfile, accessToken string, keycloakProfileURL string) error {
+	d.updatedProfile = keycloakUserProfile
 	return nil
 }
 
@@ -662,6 +2500,15 @@ func (d *dummyUserProfileService) Get(accessToken string, keycloakProfileURL str
 	return d.dummyGetResponse, nil
 }
 
+func (d *dummyUserProfileService) Create(keycloakUserProfile *login.KeycloakUserProfile, protectionAPIToken string, keycloakAdminUserAPIURL string) (*string, error) {
+	id := uuid.NewV4().String()
+	return &id, nil
+}
+
+func (d *dummyUserProfileService) Delete(keycloakUserID string, protectionAPIToken string, keycloakAdminUserAPIURL string) error {
+	return nil
+}
+
 func (d *dummyUserProfileService) SetDummyGetResponse(dummyGetResponse *login.KeycloakUserProfileResponse) {
 	d.dummyGetResponse = dummyGetResponse
 }
@@ -0,0 +1,339 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/mailer"
+	"github.com/almighty/almighty-core/rest"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// UsersEmailsControllerConfiguration the configuration for the UsersEmailsController
+type UsersEmailsControllerConfiguration interface {
+	GetEmailVerificationExpiry() time.Duration
+}
+
+// UsersEmailsController implements the users_emails resource.
+type UsersEmailsController struct {
+	*goa.Controller
+	db           application.DB
+	config       UsersEmailsControllerConfiguration
+	mailer       mailer.Mailer
+	emailManager auth.UserEmailManager
+}
+
+// NewUsersEmailsController creates a users_emails controller.
+func NewUsersEmailsController(service *goa.Service, db application.DB, config UsersEmailsControllerConfiguration, mailer mailer.Mailer, emailManager auth.UserEmailManager) *UsersEmailsController {
+	return &UsersEmailsController{Controller: service.NewController("UsersEmailsController"), db: db, config: config, mailer: mailer, emailManager: emailManager}
+}
+
+// List runs the list action: it lists the emails of the authenticated user, the primary email first
+func (c *UsersEmailsController) List(ctx *app.ListUsersEmailsContext) error {
+	identityID, err := authorizeUserEmails(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var emails []account.UserEmail
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		_, user, err := loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		if err := syncPrimaryUserEmail(ctx, appl, user); err != nil {
+			return err
+		}
+		emails, err = appl.UserEmails().List(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	data := make([]*app.UserEmail, len(emails))
+	for i := range emails {
+		data[i] = convertUserEmail(ctx.RequestData, identityID, emails[i])
+	}
+	return ctx.OK(&app.UserEmailList{Data: data})
+}
+
+// Create runs the create action: it adds a secondary email to the authenticated user and sends the link to verify it
+func (c *UsersEmailsController) Create(ctx *app.CreateUsersEmailsContext) error {
+	identityID, err := authorizeUserEmails(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if ctx.Payload.Data.Attributes.Email == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("email", nil).Expected("an email address"))
+	}
+	email, err := normalizeEmail(*ctx.Payload.Data.Attributes.Email)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("email", *ctx.Payload.Data.Attributes.Email).Expected("a bare email address such as john@example.com"))
+	}
+	var user *account.User
+	var userEmail *account.UserEmail
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		_, user, err = loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		if err := syncPrimaryUserEmail(ctx, appl, user); err != nil {
+			return err
+		}
+		isUnique, err := isEmailUnique(appl, email, account.User{})
+		if err != nil {
+			return err
+		}
+		if !isUnique {
+			return errors.NewVersionConflictError(fmt.Sprintf("email address: %s is already in use", email))
+		}
+		token, err := generateEmailVerificationToken()
+		if err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(c.config.GetEmailVerificationExpiry())
+		userEmail = &account.UserEmail{
+			UserID:                user.ID,
+			Email:                 email,
+			VerificationToken:     &token,
+			VerificationExpiresAt: &expiresAt,
+		}
+		return appl.UserEmails().Create(ctx, userEmail)
+	})
+	if err != nil {
+		if _, conflict := err.(errors.VersionConflictError); conflict {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(err.Error()))
+			return ctx.Conflict(jerrors)
+		}
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if err := sendEmailVerification(ctx, c.mailer, ctx.RequestData, user.FullName, email, *userEmail.VerificationToken, c.config.GetEmailVerificationExpiry()); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"email_id":    userEmail.ID,
+	}, "secondary email added")
+	result := &app.UserEmailSingle{Data: convertUserEmail(ctx.RequestData, identityID, *userEmail)}
+	ctx.ResponseData.Header().Set("Location", *result.Data.Links.Self)
+	return ctx.Created(result)
+}
+
+// Update runs the update action: it makes the given verified email the primary email of the authenticated user,
+// in Keycloak first and then in the platform db.
+func (c *UsersEmailsController) Update(ctx *app.UpdateUsersEmailsContext) error {
+	identityID, err := authorizeUserEmails(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if ctx.Payload.Data.Attributes.Primary == nil || !*ctx.Payload.Data.Attributes.Primary {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("primary", ctx.Payload.Data.Attributes.Primary).Expected("true: only the primary email can be changed"))
+	}
+	var userEmail *account.UserEmail
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		_, user, err := loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		if err := syncPrimaryUserEmail(ctx, appl, user); err != nil {
+			return err
+		}
+		userEmail, err = loadUserEmail(ctx, appl, user.ID, ctx.EmailID)
+		if err != nil {
+			return err
+		}
+		if userEmail.Primary {
+			return nil
+		}
+		if !userEmail.Verified {
+			return errors.NewBadParameterError("emailID", ctx.EmailID).Expected("a verified email")
+		}
+		// the email may have been taken by another user since it was added
+		isUnique, err := isEmailUnique(appl, userEmail.Email, *user)
+		if err != nil {
+			return err
+		}
+		if !isUnique {
+			return errors.NewVersionConflictError(fmt.Sprintf("email address: %s is already in use", userEmail.Email))
+		}
+		// the email must be updated in Keycloak as well, otherwise it would be restored at the next login of the user
+		if err := c.emailManager.UpdateEmail(ctx, ctx.RequestData, identityID.String(), userEmail.Email); err != nil {
+			return err
+		}
+		emails, err := appl.UserEmails().List(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		for _, e := range emails {
+			if e.Primary {
+				e.Primary = false
+				if err := appl.UserEmails().Save(ctx, &e); err != nil {
+					return err
+				}
+			}
+		}
+		userEmail.Primary = true
+		if err := appl.UserEmails().Save(ctx, userEmail); err != nil {
+			return err
+		}
+		profileChanges := newProfileChanges(identityID, identityID, *user)
+		user.Email = userEmail.Email
+		if err := appl.Users().Save(ctx, user); err != nil {
+			return err
+		}
+		return profileChanges.record(ctx, appl, *user)
+	})
+	if err != nil {
+		if _, conflict := err.(errors.VersionConflictError); conflict {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(err.Error()))
+			return ctx.Conflict(jerrors)
+		}
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"email_id":    userEmail.ID,
+	}, "primary email changed")
+	return ctx.OK(&app.UserEmailSingle{Data: convertUserEmail(ctx.RequestData, identityID, *userEmail)})
+}
+
+// Delete runs the delete action: it removes the given secondary email of the authenticated user
+func (c *UsersEmailsController) Delete(ctx *app.DeleteUsersEmailsContext) error {
+	identityID, err := authorizeUserEmails(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		_, user, err := loadIdentityAndUser(ctx, appl, identityID)
+		if err != nil {
+			return err
+		}
+		userEmail, err := loadUserEmail(ctx, appl, user.ID, ctx.EmailID)
+		if err != nil {
+			return err
+		}
+		if userEmail.Primary {
+			return errors.NewBadParameterError("emailID", ctx.EmailID).Expected("a secondary email: the primary email cannot be removed")
+		}
+		return appl.UserEmails().Delete(ctx, userEmail.ID)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"email_id":    ctx.EmailID,
+	}, "secondary email removed")
+	return ctx.OK([]byte{})
+}
+
+// authorizeUserEmails returns the ID of the given identity if it is the authenticated identity, which is the only one
+// allowed to manage its emails
+func authorizeUserEmails(ctx context.Context, id string) (uuid.UUID, error) {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return uuid.Nil, goa.ErrUnauthorized(err.Error())
+	}
+	identityID, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, errors.NewBadParameterError("id", id).Expected("identity ID")
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) {
+		return uuid.Nil, goa.NewErrorClass("forbidden", 403)(fmt.Sprintf("identity %s is not allowed to manage the emails of identity %s", *currentIdentityID, identityID))
+	}
+	return identityID, nil
+}
+
+// loadIdentityAndUser loads the given identity and its user
+// returns NotFoundError if the identity is unknown or has no user
+func loadIdentityAndUser(ctx context.Context, appl application.Application, identityID uuid.UUID) (*account.Identity, *account.User, error) {
+	identity, err := appl.Identities().Load(ctx, identityID)
+	if err != nil || !identity.UserID.Valid {
+		return nil, nil, errors.NewNotFoundError("identity", identityID.String())
+	}
+	user, err := appl.Users().Load(ctx, identity.UserID.UUID)
+	if err != nil || user == nil {
+		return nil, nil, errors.NewNotFoundError("user", identity.UserID.UUID.String())
+	}
+	return identity, user, nil
+}
+
+// loadUserEmail loads the given email of the given user
+// returns NotFoundError if the email is unknown or belongs to another user
+func loadUserEmail(ctx context.Context, appl application.Application, userID uuid.UUID, emailID uuid.UUID) (*account.UserEmail, error) {
+	userEmail, err := appl.UserEmails().Load(ctx, emailID)
+	if err != nil {
+		return nil, err
+	}
+	if !uuid.Equal(userEmail.UserID, userID) {
+		return nil, errors.NewNotFoundError("email", emailID.String())
+	}
+	return userEmail, nil
+}
+
+// syncPrimaryUserEmail makes sure that the email of the given user is recorded as its primary email, since the email of
+// the user is also updated at login with the email from Keycloak and when the user verifies a new email
+func syncPrimaryUserEmail(ctx context.Context, appl application.Application, user *account.User) error {
+	if user.Email == "" {
+		return nil
+	}
+	emails, err := appl.UserEmails().List(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	var primary *account.UserEmail
+	for i, e := range emails {
+		if e.Email == user.Email {
+			if e.Primary && e.Verified {
+				return nil
+			}
+			primary = &emails[i]
+		} else if e.Primary {
+			e.Primary = false
+			if err := appl.UserEmails().Save(ctx, &e); err != nil {
+				return err
+			}
+		}
+	}
+	if primary == nil {
+		return appl.UserEmails().Create(ctx, &account.UserEmail{
+			UserID:   user.ID,
+			Email:    user.Email,
+			Primary:  true,
+			Verified: true,
+		})
+	}
+	primary.Primary = true
+	primary.Verified = true
+	primary.VerificationToken = nil
+	primary.VerificationExpiresAt = nil
+	return appl.UserEmails().Save(ctx, primary)
+}
+
+func convertUserEmail(request *goa.RequestData, identityID uuid.UUID, email account.UserEmail) *app.UserEmail {
+	id := email.ID
+	selfURL := rest.AbsoluteURL(request, fmt.Sprintf("%s/emails/%s", app.UsersHref(identityID), email.ID))
+	return &app.UserEmail{
+		Type: "useremails",
+		ID:   &id,
+		Attributes: &app.UserEmailAttributes{
+			Email:     &email.Email,
+			Primary:   &email.Primary,
+			Verified:  &email.Verified,
+			CreatedAt: &email.CreatedAt,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+}
@@ -0,0 +1,265 @@
+package controller_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestUsersEmails(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestUsersEmailsSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestUsersEmailsSuite struct {
+	gormtestsupport.DBTestSuite
+	db           *gormapplication.GormDB
+	clean        func()
+	mailer       *testMailer
+	emailManager *testUserEmailManager
+}
+
+func (s *TestUsersEmailsSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *TestUsersEmailsSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	s.mailer = &testMailer{}
+	s.emailManager = &testUserEmailManager{}
+}
+
+func (s *TestUsersEmailsSuite) TearDownTest() {
+	s.clean()
+}
+
+func (s *TestUsersEmailsSuite) createIdentity(fullname string) (account.Identity, account.User) {
+	user := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: fullname,
+	}
+	require.Nil(s.T(), s.db.Users().Create(context.Background(), &user))
+	identity := account.Identity{
+		Username:     fullname + uuid.NewV4().String(),
+		ProviderType: account.KeycloakIDP,
+		UserID:       account.NullUUID{UUID: user.ID, Valid: true},
+	}
+	require.Nil(s.T(), s.db.Identities().Create(context.Background(), &identity))
+	return identity, user
+}
+
+func (s *TestUsersEmailsSuite) SecuredController(identity account.Identity) (*goa.Service, *UsersEmailsController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("UsersEmails-Service", almtoken.NewManager(pub), identity)
+	return svc, NewUsersEmailsController(svc, s.db, s.Configuration, s.mailer, s.emailManager)
+}
+
+func (s *TestUsersEmailsSuite) SecuredUsersController(identity account.Identity) (*goa.Service, *UsersController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Users-Service", almtoken.NewManager(pub), identity)
+	testAttributeValue := "a"
+	profileService := newDummyUserProfileService(createDummyUserProfileResponse(&testAttributeValue, &testAttributeValue, &testAttributeValue))
+	return svc, NewUsersController(svc, s.db, s.Configuration, profileService, nil, nil, s.mailer, s.emailManager)
+}
+
+func newUserEmailPayload(email *string, primary *bool) *app.UserEmailSingle {
+	return &app.UserEmailSingle{
+		Data: &app.UserEmail{
+			Type: "useremails",
+			Attributes: &app.UserEmailAttributes{
+				Email:   email,
+				Primary: primary,
+			},
+		},
+	}
+}
+
+// addVerifiedEmail adds a secondary email to the given identity and verifies it
+func (s *TestUsersEmailsSuite) addVerifiedEmail(identity account.Identity) *app.UserEmail {
+	svc, ctrl := s.SecuredController(identity)
+	email := uuid.NewV4().String() + "@example.com"
+	_, created := test.CreateUsersEmailsCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&email, nil))
+	loaded, err := s.db.UserEmails().Load(context.Background(), *created.Data.ID)
+	require.Nil(s.T(), err)
+	usersSvc, usersCtrl := s.SecuredUsersController(identity)
+	test.VerifyEmailUsersOK(s.T(), usersSvc.Context, usersSvc, usersCtrl, *loaded.VerificationToken)
+	return created.Data
+}
+
+func (s *TestUsersEmailsSuite) TestListIncludesPrimaryEmail() {
+	// given
+	identity, user := s.createIdentity("TestListIncludesPrimaryEmail")
+	svc, ctrl := s.SecuredController(identity)
+	// when
+	_, result := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	require.Len(s.T(), result.Data, 1)
+	assert.Equal(s.T(), user.Email, *result.Data[0].Attributes.Email)
+	assert.True(s.T(), *result.Data[0].Attributes.Primary)
+	assert.True(s.T(), *result.Data[0].Attributes.Verified)
+}
+
+func (s *TestUsersEmailsSuite) TestListOfOtherUserForbidden() {
+	// given
+	identity, _ := s.createIdentity("TestListOfOtherUser")
+	other, _ := s.createIdentity("TestListOfOtherUserOther")
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.ListUsersEmailsForbidden(s.T(), svc.Context, svc, ctrl, other.ID.String())
+}
+
+func (s *TestUsersEmailsSuite) TestCreateSendsVerification() {
+	// given
+	identity, _ := s.createIdentity("TestCreateSendsVerification")
+	svc, ctrl := s.SecuredController(identity)
+	email := "Secondary-" + uuid.NewV4().String() + "@Example.com"
+	// when
+	_, result := test.CreateUsersEmailsCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&email, nil))
+	// then
+	assert.Equal(s.T(), strings.ToLower(email), *result.Data.Attributes.Email)
+	assert.False(s.T(), *result.Data.Attributes.Primary)
+	assert.False(s.T(), *result.Data.Attributes.Verified)
+	require.Len(s.T(), s.mailer.sent, 1)
+	assert.Equal(s.T(), strings.ToLower(email), s.mailer.sent[0].to)
+	_, list := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	assert.Len(s.T(), list.Data, 2)
+}
+
+func (s *TestUsersEmailsSuite) TestCreateEmailOfOtherUserConflict() {
+	// given
+	identity, _ := s.createIdentity("TestCreateEmailOfOtherUser")
+	_, other := s.createIdentity("TestCreateEmailOfOtherUserOther")
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.CreateUsersEmailsConflict(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&other.Email, nil))
+	assert.Empty(s.T(), s.mailer.sent)
+}
+
+func (s *TestUsersEmailsSuite) TestCreateOwnEmailConflict() {
+	// given
+	identity, user := s.createIdentity("TestCreateOwnEmail")
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.CreateUsersEmailsConflict(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&user.Email, nil))
+}
+
+func (s *TestUsersEmailsSuite) TestCreateInvalidEmailBadRequest() {
+	// given
+	identity, _ := s.createIdentity("TestCreateInvalidEmail")
+	svc, ctrl := s.SecuredController(identity)
+	email := "John <john@example.com>"
+	// when/then
+	test.CreateUsersEmailsBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&email, nil))
+}
+
+func (s *TestUsersEmailsSuite) TestVerifySecondaryEmailOK() {
+	// given
+	identity, user := s.createIdentity("TestVerifySecondaryEmail")
+	// when
+	email := s.addVerifiedEmail(identity)
+	// then
+	svc, ctrl := s.SecuredController(identity)
+	_, list := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	require.Len(s.T(), list.Data, 2)
+	assert.Equal(s.T(), *email.ID, *list.Data[1].ID)
+	assert.True(s.T(), *list.Data[1].Attributes.Verified)
+	// the primary email is unchanged
+	loaded, err := s.db.Users().Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), user.Email, loaded.Email)
+	assert.Empty(s.T(), s.emailManager.updatedEmails)
+}
+
+func (s *TestUsersEmailsSuite) TestUpdatePrimaryOK() {
+	// given
+	identity, user := s.createIdentity("TestUpdatePrimary")
+	email := s.addVerifiedEmail(identity)
+	svc, ctrl := s.SecuredController(identity)
+	primary := true
+	// when
+	_, result := test.UpdateUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *email.ID, newUserEmailPayload(nil, &primary))
+	// then
+	assert.True(s.T(), *result.Data.Attributes.Primary)
+	assert.Equal(s.T(), map[string]string{identity.ID.String(): *email.Attributes.Email}, s.emailManager.updatedEmails)
+	loaded, err := s.db.Users().Load(context.Background(), user.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), *email.Attributes.Email, loaded.Email)
+	_, list := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	require.Len(s.T(), list.Data, 2)
+	assert.Equal(s.T(), *email.ID, *list.Data[0].ID)
+	assert.Equal(s.T(), user.Email, *list.Data[1].Attributes.Email)
+	assert.False(s.T(), *list.Data[1].Attributes.Primary)
+}
+
+func (s *TestUsersEmailsSuite) TestUpdatePrimaryUnverifiedBadRequest() {
+	// given
+	identity, _ := s.createIdentity("TestUpdatePrimaryUnverified")
+	svc, ctrl := s.SecuredController(identity)
+	email := uuid.NewV4().String() + "@example.com"
+	_, created := test.CreateUsersEmailsCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserEmailPayload(&email, nil))
+	primary := true
+	// when/then
+	test.UpdateUsersEmailsBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *created.Data.ID, newUserEmailPayload(nil, &primary))
+	assert.Empty(s.T(), s.emailManager.updatedEmails)
+}
+
+func (s *TestUsersEmailsSuite) TestUpdateEmailOfOtherUserNotFound() {
+	// given
+	identity, _ := s.createIdentity("TestUpdateEmailOfOtherUser")
+	other, _ := s.createIdentity("TestUpdateEmailOfOtherUserOther")
+	email := s.addVerifiedEmail(other)
+	svc, ctrl := s.SecuredController(identity)
+	primary := true
+	// when/then
+	test.UpdateUsersEmailsNotFound(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *email.ID, newUserEmailPayload(nil, &primary))
+}
+
+func (s *TestUsersEmailsSuite) TestDeleteSecondaryOK() {
+	// given
+	identity, _ := s.createIdentity("TestDeleteSecondary")
+	email := s.addVerifiedEmail(identity)
+	svc, ctrl := s.SecuredController(identity)
+	// when
+	test.DeleteUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *email.ID)
+	// then
+	_, list := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	assert.Len(s.T(), list.Data, 1)
+}
+
+func (s *TestUsersEmailsSuite) TestDeletePrimaryBadRequest() {
+	// given
+	identity, _ := s.createIdentity("TestDeletePrimary")
+	svc, ctrl := s.SecuredController(identity)
+	_, list := test.ListUsersEmailsOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// when/then
+	test.DeleteUsersEmailsBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *list.Data[0].ID)
+}
+
+func (s *TestUsersEmailsSuite) TestUpdateUserWithSecondaryEmailOfOtherUserConflict() {
+	// given
+	identity, _ := s.createIdentity("TestUpdateUserWithSecondaryEmail")
+	other, _ := s.createIdentity("TestUpdateUserWithSecondaryEmailOther")
+	email := s.addVerifiedEmail(other)
+	svc, ctrl := s.SecuredUsersController(identity)
+	// when/then
+	updateUsersPayload := createUpdateUsersPayload(email.Attributes.Email, nil, nil, nil, nil, nil, nil, nil)
+	test.UpdateUsersConflict(s.T(), svc.Context, svc, ctrl, nil, updateUsersPayload)
+}
@@ -0,0 +1,359 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
+	errs "github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/goadesign/goa"
+	"github.com/pkg/errors"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// maxImportedUsers is the maximum number of users imported at once
+	maxImportedUsers = 1000
+	// maxImportSize is the maximum size of the imported document
+	maxImportSize = 10 << 20
+)
+
+// errImportRejected rolls back the import when some of the users can't be imported
+var errImportRejected = errors.New("the import is rejected")
+
+// importedUser is a user to import along with its identity
+type importedUser struct {
+	IdentityID            string `json:"identityID"`
+	Username              string `json:"username"`
+	Email                 string `json:"email"`
+	FullName              string `json:"fullName"`
+	Company               string `json:"company"`
+	ProviderType          string `json:"providerType"`
+	RegistrationCompleted bool   `json:"registrationCompleted"`
+}
+
+// importedUsers is the JSON document of the users to import
+type importedUsers struct {
+	Data []importedUser `json:"data"`
+}
+
+// Import runs the import action: it creates the given users along with their identity, all or none of them. When
+// provisioning is requested, the users are created in Keycloak as well before being stored, and deleted from Keycloak
+// again if they can't be stored.
+func (c *UsersController) Import(ctx *app.ImportUsersContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if !isAdminIdentity(ctx) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to import users", *currentIdentityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.ResponseData, ctx.Request.Body, maxImportSize)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("body", nil).Expected(fmt.Sprintf("a document of at most %d bytes", maxImportSize)))
+	}
+	users, err := parseImportedUsers(ctx.Request.Header.Get("Content-Type"), body)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if len(users) == 0 || len(users) > maxImportedUsers {
+		return jsonapi.JSONErrorResponse(ctx, errs.NewBadParameterError("data", len(users)).Expected(fmt.Sprintf("between 1 and %d users", maxImportedUsers)))
+	}
+	provision := ctx.Provision != nil && *ctx.Provision
+	var protectionAPIToken, adminUsersEndpoint string
+	if provision {
+		tokenEndpoint, err := c.configuration.GetKeycloakEndpointToken(ctx.RequestData)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		adminEndpoint, err := c.configuration.GetKeycloakEndpointAdmin(ctx.RequestData)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		adminUsersEndpoint = adminEndpoint + "/users"
+		protectionAPIToken, err = auth.GetProtectedAPIToken(tokenEndpoint, c.configuration.GetKeycloakClientID(), c.configuration.GetKeycloakSecret())
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+	}
+	var rowErrors []*app.JSONAPIError
+	rejectRow := func(row int, err error) {
+		jerr, _ := jsonapi.ErrorToJSONAPIError(err)
+		jerr.Meta = map[string]interface{}{"row": row + 1}
+		rowErrors = append(rowErrors, &jerr)
+	}
+	// all the users are validated first, so that no user is provisioned in Keycloak when the import is rejected
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		for i := range users {
+			err := c.validateImportedUser(appl, &users[i], users[:i], provision)
+			if _, ok := errors.Cause(err).(errs.BadParameterError); ok {
+				rejectRow(i, err)
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if len(rowErrors) > 0 {
+		return ctx.BadRequest(&app.JSONAPIErrors{Errors: rowErrors})
+	}
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	// the users are provisioned in Keycloak outside of any transaction, and deleted from Keycloak again
+	// if the import is rejected or can't be stored afterwards
+	var provisioned []string
+	deprovision := func() {
+		for _, keycloakUserID := range provisioned {
+			if err := c.userProfileService.Delete(keycloakUserID, protectionAPIToken, adminUsersEndpoint); err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"keycloak_user_id": keycloakUserID,
+					"err":              err,
+				}, "unable to delete the user provisioned in Keycloak for a rejected import")
+			}
+		}
+	}
+	if provision {
+		for i := range users {
+			keycloakUserID, err := c.userProfileService.Create(newImportedKeycloakUserProfile(users[i]), protectionAPIToken, adminUsersEndpoint)
+			if err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"username": users[i].Username,
+					"err":      err,
+				}, "unable to provision the imported user in Keycloak")
+				deprovision()
+				rejectRow(i, err)
+				return ctx.BadRequest(&app.JSONAPIErrors{Errors: rowErrors})
+			}
+			provisioned = append(provisioned, *keycloakUserID)
+			users[i].IdentityID = *keycloakUserID
+		}
+	}
+	result := make([]*app.IdentityData, 0, len(users))
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		for i, u := range users {
+			// the users are validated again, in case they were concurrently taken while being provisioned
+			err := c.validateImportedUser(appl, &users[i], users[:i], false)
+			if _, ok := errors.Cause(err).(errs.BadParameterError); ok {
+				rejectRow(i, err)
+				continue
+			} else if err != nil {
+				return err
+			}
+			var identityID uuid.UUID
+			if u.IdentityID != "" {
+				identityID, err = uuid.FromString(u.IdentityID)
+				if err != nil {
+					return errs.NewInternalError(fmt.Sprintf("invalid identity ID of the Keycloak user %s: %s", u.Username, u.IdentityID))
+				}
+			}
+			user := account.User{
+				Email:    u.Email,
+				FullName: u.FullName,
+				Company:  u.Company,
+				State:    account.UserStateActive,
+			}
+			if err := appl.Users().Create(ctx, &user); err != nil {
+				return err
+			}
+			identity := account.Identity{
+				ID:                    identityID,
+				Username:              u.Username,
+				ProviderType:          u.ProviderType,
+				UserID:                account.NullUUID{UUID: user.ID, Valid: true},
+				User:                  user,
+				RegistrationCompleted: u.RegistrationCompleted,
+			}
+			if err := appl.Identities().Create(ctx, &identity); err != nil {
+				return err
+			}
+			result = append(result, ConvertUser(ctx.RequestData, &identity, &user).Data)
+		}
+		if len(rowErrors) > 0 {
+			return errImportRejected
+		}
+		return nil
+	})
+	if err != nil {
+		deprovision()
+	}
+	if len(rowErrors) > 0 {
+		return ctx.BadRequest(&app.JSONAPIErrors{Errors: rowErrors})
+	}
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": *currentIdentityID,
+		"users":       len(result),
+		"provisioned": provision,
+	}, "users imported")
+	return ctx.OK(&app.UserArray{Data: result})
+}
+
+// validateImportedUser normalizes the given user and returns a BadParameterError if it can't be imported, given the
+// users imported before it. Any other error means that the validation itself failed.
+func (c *UsersController) validateImportedUser(appl application.Application, u *importedUser, previous []importedUser, provision bool) error {
+	u.Username = strings.TrimSpace(u.Username)
+	u.FullName = strings.TrimSpace(u.FullName)
+	u.Company = strings.TrimSpace(u.Company)
+	u.ProviderType = strings.TrimSpace(u.ProviderType)
+	if u.ProviderType == "" {
+		u.ProviderType = account.KeycloakIDP
+	}
+	if provision && u.ProviderType != account.KeycloakIDP {
+		return errs.NewBadParameterError("providerType", u.ProviderType).Expected(fmt.Sprintf("the '%s' provider type, since the user is provisioned in Keycloak", account.KeycloakIDP))
+	}
+	if u.ProviderType != account.KeycloakIDP && !login.IsLinkedProvider(u.ProviderType) {
+		return errs.NewBadParameterError("providerType", u.ProviderType).Expected("a known identity provider type")
+	}
+	if u.Username == "" {
+		return errs.NewBadParameterError("username", u.Username).Expected("non-empty username")
+	}
+	email, err := normalizeEmail(u.Email)
+	if err != nil {
+		return errs.NewBadParameterError("email", u.Email).Expected("email address")
+	}
+	u.Email = email
+	if u.IdentityID != "" {
+		if provision {
+			return errs.NewBadParameterError("identityID", u.IdentityID).Expected("no identity ID, since it is given by Keycloak")
+		}
+		identityID, err := uuid.FromString(u.IdentityID)
+		if err != nil {
+			return errs.NewBadParameterError("identityID", u.IdentityID).Expected("identity ID")
+		}
+		u.IdentityID = identityID.String()
+		existing, err := appl.Identities().Query(account.IdentityFilterByID(identityID))
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return errs.NewBadParameterError("identityID", u.IdentityID).Expected("identity ID which is not used")
+		}
+	}
+	for _, p := range previous {
+		if p.Username == u.Username {
+			return errs.NewBadParameterError("username", u.Username).Expected("username which is not imported twice")
+		}
+		if p.Email == u.Email {
+			return errs.NewBadParameterError("email", u.Email).Expected("email address which is not imported twice")
+		}
+		if u.IdentityID != "" && p.IdentityID == u.IdentityID {
+			return errs.NewBadParameterError("identityID", u.IdentityID).Expected("identity ID which is not imported twice")
+		}
+	}
+	isUnique, err := isUsernameUnique(appl, u.Username, account.Identity{}, c.configuration.GetUsernameReuseGracePeriod())
+	if err != nil {
+		return err
+	}
+	if !isUnique {
+		return errs.NewBadParameterError("username", u.Username).Expected("username which is not used")
+	}
+	isUnique, err = isEmailUnique(appl, u.Email, account.User{})
+	if err != nil {
+		return err
+	}
+	if !isUnique {
+		return errs.NewBadParameterError("email", u.Email).Expected("email address which is not used")
+	}
+	return nil
+}
+
+// parseImportedUsers parses the users to import from the given document, either a CSV document with a header line
+// naming the columns when the content type is 'text/csv', or a JSON document otherwise
+func parseImportedUsers(contentType string, body []byte) ([]importedUser, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/csv" {
+		var doc importedUsers
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, errs.NewBadParameterError("body", nil).Expected(fmt.Sprintf("a JSON document of users: %s", err.Error()))
+		}
+		return doc.Data, nil
+	}
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errs.NewBadParameterError("body", nil).Expected(fmt.Sprintf("a CSV document of users: %s", err.Error()))
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var users []importedUser
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return users, nil
+		}
+		if err != nil {
+			return nil, errs.NewBadParameterError("body", nil).Expected(fmt.Sprintf("a CSV document of users: %s", err.Error()))
+		}
+		var u importedUser
+		for i, value := range record {
+			switch header[i] {
+			case "identityID":
+				u.IdentityID = value
+			case "username":
+				u.Username = value
+			case "email":
+				u.Email = value
+			case "fullName":
+				u.FullName = value
+			case "company":
+				u.Company = value
+			case "providerType":
+				u.ProviderType = value
+			case "registrationCompleted":
+				if value == "" {
+					continue
+				}
+				u.RegistrationCompleted, err = strconv.ParseBool(value)
+				if err != nil {
+					return nil, errs.NewBadParameterError("registrationCompleted", value).Expected("true or false")
+				}
+			default:
+				return nil, errs.NewBadParameterError("column", header[i]).Expected("identityID, username, email, fullName, company, providerType or registrationCompleted")
+			}
+		}
+		users = append(users, u)
+	}
+}
+
+// newImportedKeycloakUserProfile returns the Keycloak user to provision for the given imported user, whose email is
+// considered verified since it is imported
+func newImportedKeycloakUserProfile(u importedUser) *login.KeycloakUserProfile {
+	nameComponents := strings.Split(u.FullName, " ")
+	firstName := nameComponents[0]
+	lastName := strings.Join(nameComponents[1:], " ")
+	attributes := login.KeycloakUserProfileAttributes{}
+	if u.Company != "" {
+		attributes[login.CompanyAttributeName] = []string{u.Company}
+	}
+	profile := login.NewKeycloakUserProfile(&firstName, &lastName, &u.Email, &attributes)
+	profile.Username = &u.Username
+	enabled := true
+	profile.Enabled = &enabled
+	profile.EmailVerified = &enabled
+	return profile
+}
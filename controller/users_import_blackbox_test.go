@@ -0,0 +1,145 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	almtoken "github.com/almighty/almighty-core/token"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func (s *TestUsersSuite) importUsers(identity account.Identity, contentType string, body string, scopes ...string) *httptest.ResponseRecorder {
+	svc, ctrl := s.SecuredControllerWithScopes(identity, scopes...)
+	req, err := http.NewRequest("POST", "/api/users/import", bytes.NewBufferString(body))
+	require.Nil(s.T(), err)
+	req.Header.Set("Content-Type", contentType)
+	rw := httptest.NewRecorder()
+	goaCtx := goa.NewContext(goa.WithAction(svc.Context, "UsersTest"), rw, req, url.Values{})
+	importCtx, err := app.NewImportUsersContext(goaCtx, req, svc)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), ctrl.Import(importCtx))
+	return rw
+}
+
+func (s *TestUsersSuite) TestImportUsersFromJSONOK() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestImportUsersAdmin"), account.KeycloakIDP)
+	identityID := uuid.NewV4()
+	username := "import-json-" + uuid.NewV4().String()
+	email := username + "@example.com"
+	body := `{"data": [
+		{"identityID": "` + identityID.String() + `", "username": "` + username + `", "email": "` + email + `", "fullName": "Json Import", "company": "Red Hat", "registrationCompleted": true},
+		{"username": "` + username + `-2", "email": "` + username + `-2@example.com"}
+	]}`
+	// when
+	rw := s.importUsers(admin, "application/json", body, almtoken.ScopeAdminUsers)
+	// then
+	require.Equal(s.T(), http.StatusOK, rw.Code, rw.Body.String())
+	var result app.UserArray
+	require.Nil(s.T(), json.Unmarshal(rw.Body.Bytes(), &result))
+	require.Len(s.T(), result.Data, 2)
+	assert.Equal(s.T(), identityID.String(), *result.Data[0].ID)
+	identity, err := s.identityRepo.Load(context.Background(), identityID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), username, identity.Username)
+	assert.Equal(s.T(), account.KeycloakIDP, identity.ProviderType)
+	assert.True(s.T(), identity.RegistrationCompleted)
+	user, err := s.userRepo.Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), email, user.Email)
+	assert.Equal(s.T(), "Json Import", user.FullName)
+	assert.Equal(s.T(), "Red Hat", user.Company)
+	assert.Equal(s.T(), account.UserStateActive, user.State)
+	other, err := s.identityRepo.Query(account.IdentityFilterByUsername(username + "-2"))
+	require.Nil(s.T(), err)
+	require.Len(s.T(), other, 1)
+	assert.False(s.T(), other[0].RegistrationCompleted)
+}
+
+func (s *TestUsersSuite) TestImportUsersFromCSVOK() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestImportUsersAdmin"), account.KeycloakIDP)
+	username := "import-csv-" + uuid.NewV4().String()
+	body := "username,email,fullName,registrationCompleted\n" +
+		username + "," + username + "@Example.com,Csv Import,true\n"
+	// when
+	rw := s.importUsers(admin, "text/csv; charset=utf-8", body, almtoken.ScopeAdminUsers)
+	// then
+	require.Equal(s.T(), http.StatusOK, rw.Code, rw.Body.String())
+	identities, err := s.identityRepo.Query(account.IdentityFilterByUsername(username))
+	require.Nil(s.T(), err)
+	require.Len(s.T(), identities, 1)
+	assert.True(s.T(), identities[0].RegistrationCompleted)
+	user, err := s.userRepo.Load(context.Background(), identities[0].UserID.UUID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), username+"@example.com", user.Email)
+	assert.Equal(s.T(), "Csv Import", user.FullName)
+}
+
+func (s *TestUsersSuite) TestImportUsersWithConflictsBadRequest() {
+	// given a batch whose 2nd user reuses the email of an existing user and whose 3rd user reuses the username of the 1st
+	admin := s.createRandomIdentity(s.createRandomUser("TestImportUsersAdmin"), account.KeycloakIDP)
+	existing := s.createRandomUser("TestImportUsersExisting")
+	username := "import-conflict-" + uuid.NewV4().String()
+	body := `{"data": [
+		{"username": "` + username + `", "email": "` + username + `@example.com"},
+		{"username": "` + username + `-2", "email": "` + existing.Email + `"},
+		{"username": "` + username + `", "email": "` + username + `-3@example.com"}
+	]}`
+	// when
+	rw := s.importUsers(admin, "application/json", body, almtoken.ScopeAdminUsers)
+	// then
+	require.Equal(s.T(), http.StatusBadRequest, rw.Code, rw.Body.String())
+	var jerrors app.JSONAPIErrors
+	require.Nil(s.T(), json.Unmarshal(rw.Body.Bytes(), &jerrors))
+	require.Len(s.T(), jerrors.Errors, 2)
+	assert.Equal(s.T(), float64(2), jerrors.Errors[0].Meta["row"])
+	assert.Equal(s.T(), float64(3), jerrors.Errors[1].Meta["row"])
+	// no user was imported
+	identities, err := s.identityRepo.Query(account.IdentityFilterByUsername(username))
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), identities)
+}
+
+func (s *TestUsersSuite) TestImportUsersWithUnknownProviderTypeBadRequest() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestImportUsersAdmin"), account.KeycloakIDP)
+	username := "import-provider-" + uuid.NewV4().String()
+	body := "username,email,providerType\n" +
+		username + "," + username + "@example.com,github\n" +
+		username + "-2," + username + "-2@example.com,unknown\n"
+	// when
+	rw := s.importUsers(admin, "text/csv", body, almtoken.ScopeAdminUsers)
+	// then
+	require.Equal(s.T(), http.StatusBadRequest, rw.Code, rw.Body.String())
+	var jerrors app.JSONAPIErrors
+	require.Nil(s.T(), json.Unmarshal(rw.Body.Bytes(), &jerrors))
+	require.Len(s.T(), jerrors.Errors, 1)
+	assert.Equal(s.T(), float64(2), jerrors.Errors[0].Meta["row"])
+	identities, err := s.identityRepo.Query(account.IdentityFilterByUsername(username))
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), identities)
+}
+
+func (s *TestUsersSuite) TestImportUsersWithoutScopeForbidden() {
+	// given
+	admin := s.createRandomIdentity(s.createRandomUser("TestImportUsersAdmin"), account.KeycloakIDP)
+	username := "import-forbidden-" + uuid.NewV4().String()
+	body := `{"data": [{"username": "` + username + `", "email": "` + username + `@example.com"}]}`
+	// when
+	rw := s.importUsers(admin, "application/json", body)
+	// then
+	assert.Equal(s.T(), http.StatusForbidden, rw.Code)
+	identities, err := s.identityRepo.Query(account.IdentityFilterByUsername(username))
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), identities)
+}
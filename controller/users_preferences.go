@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/rest"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// UsersPreferencesController implements the users_preferences resource.
+type UsersPreferencesController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewUsersPreferencesController creates a users_preferences controller.
+func NewUsersPreferencesController(service *goa.Service, db application.DB) *UsersPreferencesController {
+	return &UsersPreferencesController{Controller: service.NewController("UsersPreferencesController"), db: db}
+}
+
+// Show runs the show action: it returns the preferences of the given user, or the defaults if the user has not saved any
+func (c *UsersPreferencesController) Show(ctx *app.ShowUsersPreferencesContext) error {
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	var preferences *account.UserPreferences
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err := appl.Identities().Load(ctx, identityID)
+		if err != nil || !identity.UserID.Valid {
+			return errors.NewNotFoundError("identity", ctx.ID)
+		}
+		preferences, err = loadUserPreferences(ctx, appl, identity.UserID.UUID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(ConvertUserPreferences(ctx.RequestData, identityID, preferences))
+}
+
+// Update runs the update action: it replaces the preferences of the authenticated user,
+// resetting the omitted preferences to their default
+func (c *UsersPreferencesController) Update(ctx *app.UpdateUsersPreferencesContext) error {
+	return c.save(ctx, ctx.RequestData, ctx.ID, ctx.Payload, true)
+}
+
+// Patch runs the patch action: it updates the given preferences of the authenticated user,
+// leaving the omitted preferences unchanged
+func (c *UsersPreferencesController) Patch(ctx *app.PatchUsersPreferencesContext) error {
+	return c.save(ctx, ctx.RequestData, ctx.ID, ctx.Payload, false)
+}
+
+type usersPreferencesSaveContext interface {
+	context.Context
+	jsonapi.InternalServerError
+	jsonapi.Forbidden
+	OK(*app.UserPreferencesSingle) error
+}
+
+// save stores the preferences in the given payload for the user with the given identity ID, who must be the authenticated user.
+// The preferences omitted in the payload are reset to their default if 'replace' is true, or else left unchanged.
+func (c *UsersPreferencesController) save(ctx usersPreferencesSaveContext, request *goa.RequestData, id string, payload *app.UserPreferencesSingle, replace bool) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	identityID, err := uuid.FromString(id)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", id).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to update the preferences of identity %s", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	var preferences *account.UserPreferences
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		identity, err := appl.Identities().Load(ctx, identityID)
+		if err != nil || !identity.UserID.Valid {
+			return errors.NewNotFoundError("identity", id)
+		}
+		preferences, err = loadUserPreferences(ctx, appl, identity.UserID.UUID)
+		if err != nil {
+			return err
+		}
+		if replace {
+			lifecycle := preferences.Lifecycle
+			preferences = account.DefaultUserPreferences(identity.UserID.UUID)
+			preferences.Lifecycle = lifecycle
+		}
+		if err := applyUserPreferencesAttributes(preferences, payload.Data.Attributes); err != nil {
+			return err
+		}
+		return appl.UserPreferences().Save(ctx, preferences)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+	}, "user preferences saved")
+	return ctx.OK(ConvertUserPreferences(request, identityID, preferences))
+}
+
+// loadUserPreferences returns the preferences of the given user, or the defaults if the user has not saved any
+func loadUserPreferences(ctx context.Context, appl application.Application, userID uuid.UUID) (*account.UserPreferences, error) {
+	preferences, err := appl.UserPreferences().Load(ctx, userID)
+	if _, notFound := err.(errors.NotFoundError); notFound {
+		return account.DefaultUserPreferences(userID), nil
+	}
+	return preferences, err
+}
+
+// applyUserPreferencesAttributes copies the given attributes to the preferences, leaving the omitted ones unchanged
+// returns BadParameterError if the timezone is unknown
+func applyUserPreferencesAttributes(preferences *account.UserPreferences, attributes *app.UserPreferencesAttributes) error {
+	if attributes == nil {
+		return nil
+	}
+	if attributes.Timezone != nil {
+		if _, err := time.LoadLocation(*attributes.Timezone); err != nil {
+			return errors.NewBadParameterError("timezone", *attributes.Timezone).Expected("an IANA timezone name")
+		}
+		preferences.Timezone = *attributes.Timezone
+	}
+	if attributes.LandingPage != nil {
+		preferences.LandingPage = *attributes.LandingPage
+	}
+	if attributes.EmailNotifyAssigned != nil {
+		preferences.EmailNotifyAssigned = *attributes.EmailNotifyAssigned
+	}
+	if attributes.EmailNotifyCommented != nil {
+		preferences.EmailNotifyCommented = *attributes.EmailNotifyCommented
+	}
+	if attributes.EmailNotifyMentioned != nil {
+		preferences.EmailNotifyMentioned = *attributes.EmailNotifyMentioned
+	}
+	if attributes.Locale != nil {
+		preferences.Locale = *attributes.Locale
+	}
+	return nil
+}
+
+// ConvertUserPreferences converts the preferences of the user with the given identity ID to the app representation
+func ConvertUserPreferences(request *goa.RequestData, identityID uuid.UUID, preferences *account.UserPreferences) *app.UserPreferencesSingle {
+	return &app.UserPreferencesSingle{
+		Data: convertUserPreferencesData(request, identityID, preferences),
+	}
+}
+
+func convertUserPreferencesData(request *goa.RequestData, identityID uuid.UUID, preferences *account.UserPreferences) *app.UserPreferences {
+	id := identityID.String()
+	selfURL := rest.AbsoluteURL(request, app.UsersHref(identityID)+"/preferences")
+	result := &app.UserPreferences{
+		Type: "userpreferences",
+		ID:   &id,
+		Attributes: &app.UserPreferencesAttributes{
+			LandingPage:          &preferences.LandingPage,
+			EmailNotifyAssigned:  &preferences.EmailNotifyAssigned,
+			EmailNotifyCommented: &preferences.EmailNotifyCommented,
+			EmailNotifyMentioned: &preferences.EmailNotifyMentioned,
+			Timezone:             &preferences.Timezone,
+			Locale:               &preferences.Locale,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+	if !preferences.UpdatedAt.IsZero() {
+		result.Attributes.UpdatedAt = &preferences.UpdatedAt
+	}
+	return result
+}
+
+// userPreferencesRelationship returns the relationship from a user to their preferences
+func userPreferencesRelationship(request *goa.RequestData, identityID uuid.UUID) *app.RelationGeneric {
+	t := "userpreferences"
+	id := identityID.String()
+	selfURL := rest.AbsoluteURL(request, app.UsersHref(identityID)+"/preferences")
+	return &app.RelationGeneric{
+		Data: &app.GenericData{
+			Type: &t,
+			ID:   &id,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+}
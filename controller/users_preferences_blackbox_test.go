@@ -0,0 +1,185 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUsersPreferences(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestUsersPreferencesSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestUsersPreferencesSuite struct {
+	gormtestsupport.DBTestSuite
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func (s *TestUsersPreferencesSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *TestUsersPreferencesSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *TestUsersPreferencesSuite) TearDownTest() {
+	s.clean()
+}
+
+func (s *TestUsersPreferencesSuite) SecuredController(identity account.Identity) (*goa.Service, *UsersPreferencesController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("UsersPreferences-Service", almtoken.NewManager(pub), identity)
+	return svc, NewUsersPreferencesController(svc, s.db)
+}
+
+func (s *TestUsersPreferencesSuite) UnsecuredController() (*goa.Service, *UsersPreferencesController) {
+	svc := goa.New("UsersPreferences-Service")
+	return svc, NewUsersPreferencesController(svc, s.db)
+}
+
+func newUserPreferencesPayload(attributes app.UserPreferencesAttributes) *app.UserPreferencesSingle {
+	return &app.UserPreferencesSingle{
+		Data: &app.UserPreferences{
+			Type:       "userpreferences",
+			Attributes: &attributes,
+		},
+	}
+}
+
+func (s *TestUsersPreferencesSuite) TestShowDefaultPreferencesOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestShowDefaultPreferences"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.UnsecuredController()
+	// when
+	_, result := test.ShowUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	// then
+	require.NotNil(s.T(), result.Data)
+	assert.Equal(s.T(), identity.ID.String(), *result.Data.ID)
+	assert.Equal(s.T(), account.DefaultLandingPage, *result.Data.Attributes.LandingPage)
+	assert.Equal(s.T(), account.DefaultTimezone, *result.Data.Attributes.Timezone)
+	assert.Equal(s.T(), account.DefaultLocale, *result.Data.Attributes.Locale)
+	assert.True(s.T(), *result.Data.Attributes.EmailNotifyAssigned)
+	assert.Nil(s.T(), result.Data.Attributes.UpdatedAt)
+}
+
+func (s *TestUsersPreferencesSuite) TestShowUnknownUserNotFound() {
+	svc, ctrl := s.UnsecuredController()
+	test.ShowUsersPreferencesNotFound(s.T(), svc.Context, svc, ctrl, uuid.NewV4().String())
+}
+
+func (s *TestUsersPreferencesSuite) TestUpdatePreferencesOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUpdatePreferences"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	timezone := "Europe/Paris"
+	notify := false
+	// when
+	_, result := test.UpdateUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{
+		Timezone:             &timezone,
+		EmailNotifyCommented: &notify,
+	}))
+	// then
+	assert.Equal(s.T(), timezone, *result.Data.Attributes.Timezone)
+	assert.False(s.T(), *result.Data.Attributes.EmailNotifyCommented)
+	assert.NotNil(s.T(), result.Data.Attributes.UpdatedAt)
+	_, shown := test.ShowUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	assert.Equal(s.T(), timezone, *shown.Data.Attributes.Timezone)
+	assert.False(s.T(), *shown.Data.Attributes.EmailNotifyCommented)
+}
+
+func (s *TestUsersPreferencesSuite) TestUpdateResetsOmittedPreferences() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUpdateResetsOmittedPreferences"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	locale := "pt-BR"
+	test.UpdateUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{Locale: &locale}))
+	landingPage := "/myspace"
+	// when
+	_, result := test.UpdateUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{LandingPage: &landingPage}))
+	// then
+	assert.Equal(s.T(), landingPage, *result.Data.Attributes.LandingPage)
+	assert.Equal(s.T(), account.DefaultLocale, *result.Data.Attributes.Locale)
+}
+
+func (s *TestUsersPreferencesSuite) TestPatchKeepsOmittedPreferences() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestPatchKeepsOmittedPreferences"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	locale := "pt-BR"
+	test.PatchUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{Locale: &locale}))
+	landingPage := "/myspace"
+	// when
+	_, result := test.PatchUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{LandingPage: &landingPage}))
+	// then
+	assert.Equal(s.T(), landingPage, *result.Data.Attributes.LandingPage)
+	assert.Equal(s.T(), locale, *result.Data.Attributes.Locale)
+}
+
+func (s *TestUsersPreferencesSuite) TestUpdateUnknownTimezoneBadRequest() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUpdateUnknownTimezone"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	timezone := "Middle/Earth"
+	// when/then
+	test.PatchUsersPreferencesBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{Timezone: &timezone}))
+}
+
+func (s *TestUsersPreferencesSuite) TestUpdatePreferencesOfOtherUserForbidden() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUpdatePreferencesOfOtherUser"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestUpdatePreferencesOfOtherUserOther"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	locale := "fr"
+	// when/then
+	test.UpdateUsersPreferencesForbidden(s.T(), svc.Context, svc, ctrl, other.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{Locale: &locale}))
+}
+
+func (s *TestUsersPreferencesSuite) TestShowUserIncludesPreferences() {
+	// given
+	identity, err := testsupport.CreateTestIdentityAndUser(s.DB, "TestShowUserIncludesPreferences"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	timezone := "America/New_York"
+	test.PatchUsersPreferencesOK(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newUserPreferencesPayload(app.UserPreferencesAttributes{Timezone: &timezone}))
+	usersSvc := goa.New("Users-Service")
+	usersCtrl := NewUsersController(usersSvc, s.db, s.Configuration, nil, nil, nil, nil, nil)
+	// when
+	_, result := test.ShowUsersOK(s.T(), usersSvc.Context, usersSvc, usersCtrl, identity.ID.String(), nil, nil, nil, nil)
+	// then
+	require.NotNil(s.T(), result.Data.Relationships)
+	require.NotNil(s.T(), result.Data.Relationships.Preferences)
+	assert.Equal(s.T(), identity.ID.String(), *result.Data.Relationships.Preferences.Data.ID)
+	require.Len(s.T(), result.Included, 1)
+	included, ok := result.Included[0].(map[string]interface{})
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), "userpreferences", included["type"])
+	attributes, ok := included["attributes"].(map[string]interface{})
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), timezone, attributes["timezone"])
+}
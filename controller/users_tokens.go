@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/token"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// UsersTokensController implements the users_tokens resource.
+type UsersTokensController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewUsersTokensController creates a users_tokens controller.
+func NewUsersTokensController(service *goa.Service, db application.DB) *UsersTokensController {
+	return &UsersTokensController{Controller: service.NewController("UsersTokensController"), db: db}
+}
+
+// List runs the list action: it lists the personal access tokens of the given identity, the most recent first
+func (c *UsersTokensController) List(ctx *app.ListUsersTokensContext) error {
+	identityID, err := authorizeUserTokens(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var tokens []account.PersonalAccessToken
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Identities().Load(ctx, identityID); err != nil {
+			return errors.NewNotFoundError("identity", ctx.ID)
+		}
+		tokens, err = appl.PersonalAccessTokens().List(ctx, identityID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	data := make([]*app.PersonalAccessToken, len(tokens))
+	for i := range tokens {
+		data[i] = convertPersonalAccessToken(ctx.RequestData, tokens[i])
+	}
+	return ctx.OK(&app.PersonalAccessTokenList{Data: data})
+}
+
+// Create runs the create action: it creates a personal access token for the authenticated identity and returns it,
+// for the only time since only its hash is stored. The personal access tokens and the impersonation tokens are not
+// allowed to create new tokens, which would outlive them.
+func (c *UsersTokensController) Create(ctx *app.CreateUsersTokensContext) error {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	identityID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("id", ctx.ID).Expected("identity ID"))
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) || token.ContextPersonalAccessToken(ctx) != nil || token.ContextImpersonator(ctx) != nil {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to create a personal access token for identity %s with this token", *currentIdentityID, identityID)))
+		return ctx.Forbidden(jerrors)
+	}
+	attributes := ctx.Payload.Data.Attributes
+	if attributes == nil || attributes.Name == nil || strings.TrimSpace(*attributes.Name) == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("name", nil).Expected("the name of the token"))
+	}
+	for _, scope := range attributes.Scopes {
+		if !token.ContextHasScope(ctx, scope) {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(fmt.Sprintf("identity %s is not allowed to grant the scope '%s' to a personal access token", *currentIdentityID, scope)))
+			return ctx.Forbidden(jerrors)
+		}
+	}
+	if attributes.ExpiresAt != nil && !attributes.ExpiresAt.After(time.Now()) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("expiresAt", *attributes.ExpiresAt).Expected("a time in the future"))
+	}
+	rawToken, tokenHash, err := account.GeneratePersonalAccessToken()
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	pat := account.PersonalAccessToken{
+		IdentityID: identityID,
+		Name:       strings.TrimSpace(*attributes.Name),
+		TokenHash:  tokenHash,
+		Scopes:     strings.Join(attributes.Scopes, " "),
+		ExpiresAt:  attributes.ExpiresAt,
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Identities().Load(ctx, identityID); err != nil {
+			return errors.NewNotFoundError("identity", ctx.ID)
+		}
+		return appl.PersonalAccessTokens().Create(ctx, &pat)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"token_id":    pat.ID,
+		"scopes":      pat.Scopes,
+	}, "personal access token created")
+	result := &app.PersonalAccessTokenSingle{Data: convertPersonalAccessToken(ctx.RequestData, pat)}
+	result.Data.Attributes.Token = &rawToken
+	ctx.ResponseData.Header().Set("Location", *result.Data.Links.Self)
+	return ctx.Created(result)
+}
+
+// Delete runs the delete action: it revokes the given personal access token of the given identity. The token is kept
+// so that it is still listed, as revoked.
+func (c *UsersTokensController) Delete(ctx *app.DeleteUsersTokensContext) error {
+	identityID, err := authorizeUserTokens(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		pat, err := appl.PersonalAccessTokens().Load(ctx, ctx.TokenID)
+		if err != nil {
+			return err
+		}
+		if !uuid.Equal(pat.IdentityID, identityID) {
+			return errors.NewNotFoundError("personal access token", ctx.TokenID.String())
+		}
+		if pat.RevokedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		pat.RevokedAt = &now
+		return appl.PersonalAccessTokens().Save(ctx, pat)
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"identity_id": identityID,
+		"token_id":    ctx.TokenID,
+	}, "personal access token revoked")
+	return ctx.OK([]byte{})
+}
+
+// authorizeUserTokens returns the ID of the given identity if it is the authenticated identity or if the authenticated
+// token was granted the admin scope, which are the only ones allowed to list and revoke its personal access tokens
+func authorizeUserTokens(ctx context.Context, id string) (uuid.UUID, error) {
+	currentIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return uuid.Nil, goa.ErrUnauthorized(err.Error())
+	}
+	identityID, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, errors.NewBadParameterError("id", id).Expected("identity ID")
+	}
+	if !uuid.Equal(identityID, *currentIdentityID) && !isAdminIdentity(ctx) {
+		return uuid.Nil, goa.NewErrorClass("forbidden", 403)(fmt.Sprintf("identity %s is not allowed to manage the personal access tokens of identity %s", *currentIdentityID, identityID))
+	}
+	return identityID, nil
+}
+
+func convertPersonalAccessToken(request *goa.RequestData, pat account.PersonalAccessToken) *app.PersonalAccessToken {
+	id := pat.ID
+	name := pat.Name
+	revoked := pat.RevokedAt != nil
+	selfURL := rest.AbsoluteURL(request, fmt.Sprintf("%s/tokens/%s", app.UsersHref(pat.IdentityID), pat.ID))
+	return &app.PersonalAccessToken{
+		Type: "personalaccesstokens",
+		ID:   &id,
+		Attributes: &app.PersonalAccessTokenAttributes{
+			Name:       &name,
+			Scopes:     pat.ScopeList(),
+			ExpiresAt:  pat.ExpiresAt,
+			Revoked:    &revoked,
+			CreatedAt:  &pat.CreatedAt,
+			LastUsedAt: pat.LastUsedAt,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+	}
+}
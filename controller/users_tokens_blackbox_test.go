@@ -0,0 +1,214 @@
+package controller_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestUsersTokens(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestUsersTokensSuite{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestUsersTokensSuite struct {
+	gormtestsupport.DBTestSuite
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func (s *TestUsersTokensSuite) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *TestUsersTokensSuite) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *TestUsersTokensSuite) TearDownTest() {
+	s.clean()
+}
+
+func (s *TestUsersTokensSuite) SecuredController(identity account.Identity, scopes ...string) (*goa.Service, *UsersTokensController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUserWithScopes("UsersTokens-Service", almtoken.NewManager(pub), identity, scopes...)
+	return svc, NewUsersTokensController(svc, s.db)
+}
+
+func newPersonalAccessTokenPayload(name string, scopes ...string) *app.PersonalAccessTokenSingle {
+	return &app.PersonalAccessTokenSingle{
+		Data: &app.PersonalAccessToken{
+			Type: "personalaccesstokens",
+			Attributes: &app.PersonalAccessTokenAttributes{
+				Name:   &name,
+				Scopes: scopes,
+			},
+		},
+	}
+}
+
+func (s *TestUsersTokensSuite) TestCreateAndListTokensOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateAndListTokens"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity, almtoken.ScopeAdminUsers)
+	// when
+	_, created := test.CreateUsersTokensCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci", almtoken.ScopeAdminUsers))
+	// then the token is only returned at its creation, and only its hash is stored
+	require.NotNil(s.T(), created.Data.Attributes.Token)
+	assert.True(s.T(), account.IsPersonalAccessToken(*created.Data.Attributes.Token))
+	loaded, err := s.db.PersonalAccessTokens().Load(context.Background(), *created.Data.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), account.HashPersonalAccessToken(*created.Data.Attributes.Token), loaded.TokenHash)
+	_, list := test.ListUsersTokensOK(s.T(), svc.Context, svc, ctrl, identity.ID.String())
+	require.Len(s.T(), list.Data, 1)
+	assert.Equal(s.T(), *created.Data.ID, *list.Data[0].ID)
+	assert.Equal(s.T(), "ci", *list.Data[0].Attributes.Name)
+	assert.Equal(s.T(), []string{almtoken.ScopeAdminUsers}, list.Data[0].Attributes.Scopes)
+	assert.Nil(s.T(), list.Data[0].Attributes.Token)
+	assert.False(s.T(), *list.Data[0].Attributes.Revoked)
+}
+
+func (s *TestUsersTokensSuite) TestCreateTokenWithScopeNotGrantedForbidden() {
+	// given a token which was not granted the scope
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTokenWithScopeNotGranted"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.CreateUsersTokensForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci", almtoken.ScopeAdminUsers))
+}
+
+func (s *TestUsersTokensSuite) TestCreateTokenWithPersonalAccessTokenForbidden() {
+	// given a call authenticated with a personal access token
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTokenWithPersonalAccessToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	goajwt.ContextJWT(svc.Context).Claims.(jwt.MapClaims)[almtoken.PersonalAccessTokenClaim] = uuid.NewV4().String()
+	// when/then
+	test.CreateUsersTokensForbidden(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci"))
+}
+
+func (s *TestUsersTokensSuite) TestCreateTokenOfOtherUserForbidden() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTokenOfOtherUser"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestCreateTokenOfOtherUserOther"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity, almtoken.ScopeAdminUsers)
+	// when/then even an administrator can't create a token for another user
+	test.CreateUsersTokensForbidden(s.T(), svc.Context, svc, ctrl, other.ID.String(), newPersonalAccessTokenPayload("ci"))
+}
+
+func (s *TestUsersTokensSuite) TestCreateExpiredTokenBadRequest() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestCreateExpiredToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	payload := newPersonalAccessTokenPayload("ci")
+	expiresAt := time.Now().Add(-time.Hour)
+	payload.Data.Attributes.ExpiresAt = &expiresAt
+	// when/then
+	test.CreateUsersTokensBadRequest(s.T(), svc.Context, svc, ctrl, identity.ID.String(), payload)
+}
+
+func (s *TestUsersTokensSuite) TestListTokensOfOtherUserForbidden() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestListTokensOfOtherUser"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestListTokensOfOtherUserOther"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.ListUsersTokensForbidden(s.T(), svc.Context, svc, ctrl, other.ID.String())
+}
+
+func (s *TestUsersTokensSuite) TestDeleteTokenOfOtherUserByAdminOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenByAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	admin, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenByAdminAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	_, created := test.CreateUsersTokensCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci"))
+	adminSvc, adminCtrl := s.SecuredController(admin, almtoken.ScopeAdminUsers)
+	// when
+	test.DeleteUsersTokensOK(s.T(), adminSvc.Context, adminSvc, adminCtrl, identity.ID.String(), *created.Data.ID)
+	// then the token is still listed, as revoked
+	_, list := test.ListUsersTokensOK(s.T(), adminSvc.Context, adminSvc, adminCtrl, identity.ID.String())
+	require.Len(s.T(), list.Data, 1)
+	assert.True(s.T(), *list.Data[0].Attributes.Revoked)
+	loaded, err := s.db.PersonalAccessTokens().Load(context.Background(), *created.Data.ID)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), loaded.IsActive(time.Now()))
+}
+
+func (s *TestUsersTokensSuite) TestListAndDeleteTokenOfOtherUserWithPersonalAccessTokenWithoutScopeForbidden() {
+	// given a personal access token of an administrator, created without the admin scope
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenWithPersonalAccessToken"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	admin, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenWithPersonalAccessTokenAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	_, created := test.CreateUsersTokensCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci"))
+	patSvc, patCtrl := s.SecuredController(admin)
+	goajwt.ContextJWT(patSvc.Context).Claims.(jwt.MapClaims)[almtoken.PersonalAccessTokenClaim] = uuid.NewV4().String()
+	// when/then
+	test.ListUsersTokensForbidden(s.T(), patSvc.Context, patSvc, patCtrl, identity.ID.String())
+	test.DeleteUsersTokensForbidden(s.T(), patSvc.Context, patSvc, patCtrl, identity.ID.String(), *created.Data.ID)
+	loaded, err := s.db.PersonalAccessTokens().Load(context.Background(), *created.Data.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), loaded.IsActive(time.Now()))
+}
+
+func (s *TestUsersTokensSuite) TestListAndDeleteTokenOfOtherUserByImpersonatedAdminForbidden() {
+	// given a token impersonating an administrator
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenByImpersonatedAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	admin, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenByImpersonatedAdminAdmin"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	impersonator, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenByImpersonatedAdminImpersonator"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	svc, ctrl := s.SecuredController(identity)
+	_, created := test.CreateUsersTokensCreated(s.T(), svc.Context, svc, ctrl, identity.ID.String(), newPersonalAccessTokenPayload("ci"))
+	adminSvc, adminCtrl := s.SecuredController(admin, almtoken.ScopeAdminUsers)
+	impersonatedBy(adminSvc, impersonator)
+	// when/then
+	test.ListUsersTokensForbidden(s.T(), adminSvc.Context, adminSvc, adminCtrl, identity.ID.String())
+	test.DeleteUsersTokensForbidden(s.T(), adminSvc.Context, adminSvc, adminCtrl, identity.ID.String(), *created.Data.ID)
+	loaded, err := s.db.PersonalAccessTokens().Load(context.Background(), *created.Data.ID)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), loaded.IsActive(time.Now()))
+}
+
+func (s *TestUsersTokensSuite) TestDeleteTokenOfOtherIdentityNotFound() {
+	// given a token of another identity
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenOfOtherIdentity"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestDeleteTokenOfOtherIdentityOther"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	otherSvc, otherCtrl := s.SecuredController(other)
+	_, created := test.CreateUsersTokensCreated(s.T(), otherSvc.Context, otherSvc, otherCtrl, other.ID.String(), newPersonalAccessTokenPayload("ci"))
+	svc, ctrl := s.SecuredController(identity)
+	// when/then
+	test.DeleteUsersTokensNotFound(s.T(), svc.Context, svc, ctrl, identity.ID.String(), *created.Data.ID)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/workitem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recentSpaceIDs(contextInformation workitem.Fields) []string {
+	var ids []string
+	for _, s := range recentSpaces(contextInformation) {
+		ids = append(ids, s.SpaceID)
+	}
+	return ids
+}
+
+func TestRecordRecentSpace(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	now := time.Now()
+
+	t.Run("most recent first", func(t *testing.T) {
+		contextInformation := workitem.Fields{}
+		recordRecentSpace(contextInformation, "a", now)
+		recordRecentSpace(contextInformation, "b", now.Add(time.Minute))
+		recordRecentSpace(contextInformation, "c", now.Add(2*time.Minute))
+		assert.Equal(t, []string{"c", "b", "a"}, recentSpaceIDs(contextInformation))
+	})
+
+	t.Run("latest visit only", func(t *testing.T) {
+		contextInformation := workitem.Fields{}
+		recordRecentSpace(contextInformation, "a", now)
+		recordRecentSpace(contextInformation, "b", now.Add(time.Minute))
+		recordRecentSpace(contextInformation, "a", now.Add(2*time.Minute))
+		recent := recentSpaces(contextInformation)
+		require.Len(t, recent, 2)
+		assert.Equal(t, "a", recent[0].SpaceID)
+		assert.True(t, now.Add(2*time.Minute).Equal(recent[0].VisitedAt))
+		assert.Equal(t, "b", recent[1].SpaceID)
+	})
+
+	t.Run("at most the max number of spaces", func(t *testing.T) {
+		contextInformation := workitem.Fields{}
+		for i := 0; i < maxRecentSpaces+5; i++ {
+			recordRecentSpace(contextInformation, fmt.Sprint(i), now.Add(time.Duration(i)*time.Minute))
+		}
+		ids := recentSpaceIDs(contextInformation)
+		require.Len(t, ids, maxRecentSpaces)
+		assert.Equal(t, fmt.Sprint(maxRecentSpaces+4), ids[0])
+		assert.Equal(t, "5", ids[maxRecentSpaces-1])
+	})
+
+	t.Run("ordered by visit time when loaded", func(t *testing.T) {
+		// as stored in the database, possibly edited by a client
+		contextInformation := workitem.Fields{
+			contextInformationRecentSpaces: []interface{}{
+				map[string]interface{}{recentSpaceID: "a", recentSpaceVisitedAt: now.Format(time.RFC3339Nano)},
+				map[string]interface{}{recentSpaceID: "b", recentSpaceVisitedAt: now.Add(time.Hour).Format(time.RFC3339Nano)},
+				map[string]interface{}{recentSpaceID: "malformed", recentSpaceVisitedAt: "yesterday"},
+				"malformed",
+			},
+		}
+		assert.Equal(t, []string{"b", "a"}, recentSpaceIDs(contextInformation))
+	})
+}
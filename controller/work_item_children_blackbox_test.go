@@ -102,7 +102,7 @@ func (s *workItemChildSuite) SetupSuite() {
 	svc = testsupport.ServiceAsUser("TestWorkItem-Service", almtoken.NewManagerWithPrivateKey(priv), testIdentity)
 	require.NotNil(s.T(), svc)
 	s.svc = svc
-	s.workItemCtrl = NewWorkitemController(svc, s.db, s.Configuration)
+	s.workItemCtrl = NewWorkitemController(svc, s.db, s.Configuration, nil)
 	require.NotNil(s.T(), s.workItemCtrl)
 
 	svc = testsupport.ServiceAsUser("Space-Service", almtoken.NewManagerWithPrivateKey(priv), testIdentity)
@@ -23,6 +23,7 @@ type WorkItemCommentsController struct {
 
 type WorkItemCommentsControllerConfiguration interface {
 	GetCacheControlComments() string
+	GetCommentMaxLength() int
 }
 
 // NewWorkItemCommentsController creates a work-item-relationships-comments controller.
@@ -48,6 +49,9 @@ func (c *WorkItemCommentsController) Create(ctx *app.CreateWorkItemCommentsConte
 		}
 
 		reqComment := ctx.Payload.Data
+		if err := validateCommentBody(reqComment.Attributes.Body, c.config.GetCommentMaxLength()); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 		markup := rendering.NilSafeGetMarkup(reqComment.Attributes.Markup)
 		newComment := comment.Comment{
 			ParentID:  ctx.WiID,
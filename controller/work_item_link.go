@@ -11,6 +11,8 @@ import (
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/login"
 	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space/authz"
+	"github.com/almighty/almighty-core/workitem"
 	"github.com/almighty/almighty-core/workitem/link"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
@@ -431,6 +433,94 @@ func (c *WorkItemLinkController) Update(ctx *app.UpdateWorkItemLinkContext) erro
 	})
 }
 
+// Dependencies runs the dependencies action: it returns the dependency links between work items of different
+// spaces, one of them being among the given spaces. A link is only returned when the user has access to the
+// spaces of both linked work items.
+func (c *WorkItemLinkController) Dependencies(ctx *app.DependenciesWorkItemLinkContext) error {
+	_, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	graph := app.WorkItemDependencyGraph{
+		Nodes: []*app.WorkItemDependencyNode{},
+		Edges: []*app.WorkItemDependencyEdge{},
+	}
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		links, err := appl.WorkItemLinks().ListCrossSpaceDependencies(ctx, ctx.Space)
+		if err != nil {
+			return err
+		}
+		// the access to each space and the linked work items and link types are only loaded once
+		authorized := map[uuid.UUID]bool{}
+		workItems := map[uint64]*workitem.WorkItem{}
+		linkTypes := map[uuid.UUID]*link.WorkItemLinkType{}
+		loadWorkItem := func(id uint64) (*workitem.WorkItem, bool, error) {
+			wi, ok := workItems[id]
+			if !ok {
+				wi, err = appl.WorkItems().LoadByID(ctx, strconv.FormatUint(id, 10))
+				if err != nil {
+					return nil, false, err
+				}
+				workItems[id] = wi
+			}
+			allowed, ok := authorized[wi.SpaceID]
+			if !ok {
+				allowed, err = authz.Authorize(ctx, wi.SpaceID.String())
+				if err != nil {
+					return nil, false, errors.NewUnauthorizedError(err.Error())
+				}
+				authorized[wi.SpaceID] = allowed
+			}
+			return wi, allowed, nil
+		}
+		nodes := map[string]bool{}
+		for _, l := range links {
+			source, sourceAllowed, err := loadWorkItem(l.SourceID)
+			if err != nil {
+				return err
+			}
+			target, targetAllowed, err := loadWorkItem(l.TargetID)
+			if err != nil {
+				return err
+			}
+			if !sourceAllowed || !targetAllowed {
+				continue
+			}
+			linkType, ok := linkTypes[l.LinkTypeID]
+			if !ok {
+				linkType, err = appl.WorkItemLinkTypes().LoadByID(ctx, l.LinkTypeID)
+				if err != nil {
+					return err
+				}
+				linkTypes[l.LinkTypeID] = linkType
+			}
+			for _, wi := range []*workitem.WorkItem{source, target} {
+				if nodes[wi.ID] {
+					continue
+				}
+				nodes[wi.ID] = true
+				node := &app.WorkItemDependencyNode{ID: wi.ID, SpaceID: wi.SpaceID}
+				if title, ok := wi.Fields[workitem.SystemTitle].(string); ok {
+					node.Title = &title
+				}
+				graph.Nodes = append(graph.Nodes, node)
+			}
+			graph.Edges = append(graph.Edges, &app.WorkItemDependencyEdge{
+				ID:         l.ID,
+				Source:     source.ID,
+				Target:     target.ID,
+				LinkTypeID: l.LinkTypeID,
+				Name:       linkType.ForwardName,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&graph)
+}
+
 // ConvertLinkFromModel converts a work item from model to REST representation
 func ConvertLinkFromModel(t link.WorkItemLink) app.WorkItemLinkSingle {
 	var converted = app.WorkItemLinkSingle{
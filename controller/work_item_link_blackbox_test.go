@@ -2,6 +2,7 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math"
 	"net/http"
@@ -19,6 +20,7 @@ import (
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/space/authz"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
 	"github.com/almighty/almighty-core/workitem"
@@ -27,6 +29,7 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
 	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -79,6 +82,8 @@ func (s *workItemLinkSuite) cleanup() {
 	// rather than ID, unlike the work items or work item links.
 	db = db.Unscoped().Delete(&link.WorkItemLinkType{Name: "test-bug-blocker"})
 	require.Nil(s.T(), db.Error)
+	db = db.Unscoped().Delete(&link.WorkItemLinkType{Name: "test-dependency"})
+	require.Nil(s.T(), db.Error)
 	db = db.Unscoped().Delete(&link.WorkItemLinkCategory{Name: "test-user"})
 	require.Nil(s.T(), db.Error)
 	if s.userSpaceID != uuid.Nil {
@@ -128,7 +133,7 @@ func (s *workItemLinkSuite) SetupTest() {
 	require.Nil(s.T(), err)
 	s.svc = testsupport.ServiceAsUser("TestWorkItem-Service", almtoken.NewManagerWithPrivateKey(priv), testIdentity)
 	require.NotNil(s.T(), s.svc)
-	s.workItemCtrl = NewWorkitemController(svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	s.workItemCtrl = NewWorkitemController(svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 	require.NotNil(s.T(), s.workItemCtrl)
 	// Create a work item link space
 	createSpacePayload := CreateSpacePayload("test-space", "description")
@@ -964,3 +969,92 @@ func TestNewWorkItemRelationshipsLinksControllerDBNull(t *testing.T) {
 		NewWorkItemRelationshipsLinksController(nil, nil, nil)
 	})
 }
+
+// spaceForbiddingAuthzService authorizes the operations in all spaces but the forbidden one
+type spaceForbiddingAuthzService struct {
+	forbidden uuid.UUID
+}
+
+func (s *spaceForbiddingAuthzService) Authorize(ctx context.Context, endpoint string, spaceID string) (bool, error) {
+	return spaceID != s.forbidden.String(), nil
+}
+
+func (s *spaceForbiddingAuthzService) Configuration() authz.AuthzConfiguration {
+	return nil
+}
+
+func (s *spaceForbiddingAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	return false, nil
+}
+
+// createOtherSpaceWorkItem creates a work item with the same type as bug1 in a new space
+func (s *workItemLinkSuite) createOtherSpaceWorkItem(name string, creatorID uuid.UUID) *workitem.WorkItem {
+	bug1, err := workitem.NewWorkItemRepository(s.DB).LoadByID(s.svc.Context, strconv.FormatUint(s.bug1ID, 10))
+	require.Nil(s.T(), err)
+	sp, err := space.NewRepository(s.DB).Create(s.svc.Context, &space.Space{Name: name + "-" + uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+	wi, err := workitem.NewWorkItemRepository(s.DB).Create(s.svc.Context, sp.ID, bug1.Type, map[string]interface{}{
+		workitem.SystemTitle: name,
+		workitem.SystemState: workitem.SystemStateNew,
+	}, creatorID)
+	require.Nil(s.T(), err)
+	return wi
+}
+
+func (s *workItemLinkSuite) TestListCrossSpaceDependenciesOK() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "dependencies user", "test provider")
+	require.Nil(s.T(), err)
+	bug1, err := workitem.NewWorkItemRepository(s.DB).LoadByID(s.svc.Context, strconv.FormatUint(s.bug1ID, 10))
+	require.Nil(s.T(), err)
+	dependencyType, err := link.NewWorkItemLinkTypeRepository(s.DB).Create(s.svc.Context, "test-dependency", nil, bug1.Type, bug1.Type, "blocks", "blocked by", link.TopologyNetwork, s.userLinkCategoryID, s.userSpaceID)
+	require.Nil(s.T(), err)
+	otherWI := s.createOtherSpaceWorkItem("other", identity.ID)
+	otherID, err := strconv.ParseUint(otherWI.ID, 10, 64)
+	require.Nil(s.T(), err)
+	forbiddenWI := s.createOtherSpaceWorkItem("forbidden", identity.ID)
+	forbiddenID, err := strconv.ParseUint(forbiddenWI.ID, 10, 64)
+	require.Nil(s.T(), err)
+	linkRepo := link.NewWorkItemLinkRepository(s.DB)
+	// bug1 blocks a work item of the other space and bug2 blocks a work item of the forbidden space
+	crossLink, err := linkRepo.Create(s.svc.Context, s.bug1ID, otherID, dependencyType.ID, identity.ID)
+	require.Nil(s.T(), err)
+	_, err = linkRepo.Create(s.svc.Context, s.bug2ID, forbiddenID, dependencyType.ID, identity.ID)
+	require.Nil(s.T(), err)
+	// dependencies within the space and non-dependency links are ignored
+	_, err = linkRepo.Create(s.svc.Context, s.bug1ID, s.bug3ID, dependencyType.ID, identity.ID)
+	require.Nil(s.T(), err)
+	_, err = linkRepo.Create(s.svc.Context, s.bug3ID, otherID, s.bugBlockerLinkTypeID, identity.ID)
+	require.Nil(s.T(), err)
+
+	// when the user has access to all spaces
+	_, graph := test.DependenciesWorkItemLinkOK(s.T(), s.svc.Context, s.svc, s.workItemLinkCtrl, []uuid.UUID{s.userSpaceID})
+	// then
+	require.Len(s.T(), graph.Edges, 2)
+	assert.Len(s.T(), graph.Nodes, 4)
+
+	// when the user has no access to the space of one of the work items
+	priv, err := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	require.Nil(s.T(), err)
+	svc := testsupport.ServiceAsSpaceUser("TestWorkItemLink-Service", almtoken.NewManagerWithPrivateKey(priv), identity, &spaceForbiddingAuthzService{forbiddenWI.SpaceID})
+	ctrl := NewWorkItemLinkController(svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	_, graph = test.DependenciesWorkItemLinkOK(s.T(), svc.Context, svc, ctrl, []uuid.UUID{s.userSpaceID})
+	// then only the dependency between the accessible spaces is returned
+	require.Len(s.T(), graph.Edges, 1)
+	assert.Equal(s.T(), crossLink.ID, graph.Edges[0].ID)
+	assert.Equal(s.T(), strconv.FormatUint(s.bug1ID, 10), graph.Edges[0].Source)
+	assert.Equal(s.T(), otherWI.ID, graph.Edges[0].Target)
+	assert.Equal(s.T(), "blocks", graph.Edges[0].Name)
+	require.Len(s.T(), graph.Nodes, 2)
+	spaces := map[string]uuid.UUID{}
+	for _, node := range graph.Nodes {
+		spaces[node.ID] = node.SpaceID
+	}
+	assert.Equal(s.T(), s.userSpaceID, spaces[strconv.FormatUint(s.bug1ID, 10)])
+	assert.Equal(s.T(), otherWI.SpaceID, spaces[otherWI.ID])
+}
+
+func (s *workItemLinkSuite) TestListCrossSpaceDependenciesUnauthorized() {
+	svc := goa.New("TestWorkItemLink-Service")
+	test.DependenciesWorkItemLinkUnauthorized(s.T(), svc.Context, svc, s.workItemLinkCtrl, []uuid.UUID{s.userSpaceID})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/goadesign/goa"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// WorkItemRevisionsController implements the work_item_revisions resource.
+type WorkItemRevisionsController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewWorkItemRevisionsController creates a work_item_revisions controller.
+func NewWorkItemRevisionsController(service *goa.Service, db application.DB) *WorkItemRevisionsController {
+	return &WorkItemRevisionsController{
+		Controller: service.NewController("WorkItemRevisionsController"),
+		db:         db,
+	}
+}
+
+// List runs the list action.
+func (c *WorkItemRevisionsController) List(ctx *app.ListWorkItemRevisionsContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.WorkItems().Load(ctx, spaceID, ctx.WiID); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		revisions, err := appl.WorkItemRevisions().List(ctx, ctx.WiID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.Wrapf(err, "failed to list the revisions of work item %s", ctx.WiID))
+		}
+		changes := map[uuid.UUID]workitem.IterationChange{}
+		for _, change := range workitem.IterationChanges(revisions) {
+			changes[change.RevisionID] = change
+		}
+		data := []*app.WorkItemRevision{}
+		for _, revision := range revisions {
+			change, changed := changes[revision.ID]
+			if ctx.FilterIterationChanges != nil && *ctx.FilterIterationChanges && !changed {
+				continue
+			}
+			var iterationChange *workitem.IterationChange
+			if changed {
+				iterationChange = &change
+			}
+			data = append(data, ConvertWorkItemRevision(ctx.RequestData, revision, iterationChange))
+		}
+		return ctx.OK(&app.WorkItemRevisionList{
+			Data: data,
+		})
+	})
+}
+
+// ConvertWorkItemRevision converts a work item revision model into its REST representation
+func ConvertWorkItemRevision(request *goa.RequestData, revision workitem.Revision, iterationChange *workitem.IterationChange) *app.WorkItemRevision {
+	result := &app.WorkItemRevision{
+		Type: "workitemrevisions",
+		ID:   revision.ID.String(),
+		Attributes: &app.WorkItemRevisionAttributes{
+			RevisionType: revision.Type.String(),
+			RevisionTime: revision.Time,
+			Version:      revision.WorkItemVersion,
+		},
+		Relationships: &app.WorkItemRevisionRelationships{
+			Modifier: &app.RelationGeneric{
+				Data: ConvertUserSimple(request, revision.ModifierIdentity),
+			},
+		},
+	}
+	if iterationChange != nil {
+		from := iterationChange.From
+		to := iterationChange.To
+		result.Attributes.IterationChange = &app.WorkItemRevisionIterationChange{
+			From: &from,
+			To:   &to,
+		}
+	}
+	return result
+}
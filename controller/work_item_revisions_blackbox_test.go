@@ -0,0 +1,128 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/migration"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
+	"github.com/almighty/almighty-core/workitem"
+
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestRunWorkItemRevisionsREST(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &TestWorkItemRevisionsREST{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type TestWorkItemRevisionsREST struct {
+	gormtestsupport.DBTestSuite
+	clean        func()
+	ctx          context.Context
+	testIdentity account.Identity
+}
+
+func (rest *TestWorkItemRevisionsREST) SetupSuite() {
+	rest.DBTestSuite.SetupSuite()
+	rest.ctx = migration.NewMigrationContext(context.Background())
+	rest.DBTestSuite.PopulateDBTestSuite(rest.ctx)
+}
+
+func (rest *TestWorkItemRevisionsREST) SetupTest() {
+	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
+	testIdentity, err := testsupport.CreateTestIdentity(rest.DB, "TestWorkItemRevisionsREST-"+uuid.NewV4().String(), "test provider")
+	require.Nil(rest.T(), err)
+	rest.testIdentity = testIdentity
+}
+
+func (rest *TestWorkItemRevisionsREST) TearDownTest() {
+	rest.clean()
+}
+
+func (rest *TestWorkItemRevisionsREST) UnSecuredController() (*goa.Service, *WorkItemRevisionsController) {
+	svc := goa.New("WorkItemRevisions-Service")
+	return svc, NewWorkItemRevisionsController(svc, gormapplication.NewGormDB(rest.DB))
+}
+
+func (rest *TestWorkItemRevisionsREST) createIteration(name string) *iteration.Iteration {
+	itr := &iteration.Iteration{
+		Name:    name + uuid.NewV4().String(),
+		SpaceID: space.SystemSpace,
+		State:   iteration.IterationStateNew,
+	}
+	require.Nil(rest.T(), iteration.NewIterationRepository(rest.DB).Create(rest.ctx, itr))
+	return itr
+}
+
+// createMovedWorkItem creates a work item in the first given iteration, moves it to the second one, then changes its title,
+// so that it has 3 revisions, of which only the second one records an iteration change
+func (rest *TestWorkItemRevisionsREST) createMovedWorkItem(from, to *iteration.Iteration) *workitem.WorkItem {
+	repo := workitem.NewWorkItemRepository(rest.DB)
+	wi, err := repo.Create(rest.ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle:     "TestWorkItemRevisions",
+		workitem.SystemState:     workitem.SystemStateNew,
+		workitem.SystemIteration: from.ID.String(),
+	}, rest.testIdentity.ID)
+	require.Nil(rest.T(), err)
+	wi.Fields[workitem.SystemIteration] = to.ID.String()
+	wi, err = repo.Save(rest.ctx, space.SystemSpace, *wi, rest.testIdentity.ID)
+	require.Nil(rest.T(), err)
+	wi.Fields[workitem.SystemTitle] = "TestWorkItemRevisions updated"
+	wi, err = repo.Save(rest.ctx, space.SystemSpace, *wi, rest.testIdentity.ID)
+	require.Nil(rest.T(), err)
+	return wi
+}
+
+func (rest *TestWorkItemRevisionsREST) TestListRevisionsWithIterationChangeOK() {
+	// given
+	from := rest.createIteration("TestWorkItemRevisionsFrom")
+	to := rest.createIteration("TestWorkItemRevisionsTo")
+	wi := rest.createMovedWorkItem(from, to)
+	svc, ctrl := rest.UnSecuredController()
+	// when
+	_, revisions := test.ListWorkItemRevisionsOK(rest.T(), svc.Context, svc, ctrl, space.SystemSpace.String(), wi.ID, nil)
+	// then
+	require.Len(rest.T(), revisions.Data, 3)
+	assert.Equal(rest.T(), workitem.RevisionTypeCreate.String(), revisions.Data[0].Attributes.RevisionType)
+	assert.Nil(rest.T(), revisions.Data[0].Attributes.IterationChange)
+	require.NotNil(rest.T(), revisions.Data[1].Attributes.IterationChange)
+	assert.Equal(rest.T(), from.ID.String(), *revisions.Data[1].Attributes.IterationChange.From)
+	assert.Equal(rest.T(), to.ID.String(), *revisions.Data[1].Attributes.IterationChange.To)
+	assert.Nil(rest.T(), revisions.Data[2].Attributes.IterationChange)
+}
+
+func (rest *TestWorkItemRevisionsREST) TestListRevisionsFilteredByIterationChangesOK() {
+	// given
+	from := rest.createIteration("TestWorkItemRevisionsFrom")
+	to := rest.createIteration("TestWorkItemRevisionsTo")
+	wi := rest.createMovedWorkItem(from, to)
+	svc, ctrl := rest.UnSecuredController()
+	iterationChanges := true
+	// when
+	_, revisions := test.ListWorkItemRevisionsOK(rest.T(), svc.Context, svc, ctrl, space.SystemSpace.String(), wi.ID, &iterationChanges)
+	// then only the move to the other iteration is listed
+	require.Len(rest.T(), revisions.Data, 1)
+	assert.Equal(rest.T(), workitem.RevisionTypeUpdate.String(), revisions.Data[0].Attributes.RevisionType)
+	require.NotNil(rest.T(), revisions.Data[0].Attributes.IterationChange)
+	assert.Equal(rest.T(), from.ID.String(), *revisions.Data[0].Attributes.IterationChange.From)
+	assert.Equal(rest.T(), to.ID.String(), *revisions.Data[0].Attributes.IterationChange.To)
+}
+
+func (rest *TestWorkItemRevisionsREST) TestListRevisionsOfUnknownWorkItemNotFound() {
+	svc, ctrl := rest.UnSecuredController()
+	test.ListWorkItemRevisionsNotFound(rest.T(), svc.Context, svc, ctrl, space.SystemSpace.String(), "0", nil)
+}
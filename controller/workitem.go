@@ -3,7 +3,9 @@ package controller
 import (
 	"fmt"
 	"html"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
@@ -11,17 +13,22 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/auth"
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/criteria"
 	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/iteration"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/path"
 	query "github.com/almighty/almighty-core/query/simple"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/space/authz"
 	"github.com/almighty/almighty-core/workitem"
+	"github.com/almighty/almighty-core/workitem/link"
 
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
@@ -38,24 +45,32 @@ const (
 // WorkitemController implements the workitem resource.
 type WorkitemController struct {
 	*goa.Controller
-	db     application.DB
-	config WorkItemControllerConfig
+	db            application.DB
+	config        WorkItemControllerConfig
+	policyManager auth.AuthzPolicyManager
 }
 
 // WorkItemControllerConfig the config interface for the WorkitemController
 type WorkItemControllerConfig interface {
 	GetCacheControlWorkItems() string
+	GetWorkItemRestoreWindow() time.Duration
+	GetWorkItemBulkUpdateBatchSize() int
+	IsWorkItemAssigneeCollaboratorRequired() bool
+	IsWorkItemPayloadStrict() bool
+	GetWorkItemClosedIterationAssignment() string
+	IsWorkItemDuplicateAutoClosed() bool
 }
 
 // NewWorkitemController creates a workitem controller.
-func NewWorkitemController(service *goa.Service, db application.DB, config WorkItemControllerConfig) *WorkitemController {
+func NewWorkitemController(service *goa.Service, db application.DB, config WorkItemControllerConfig, policyManager auth.AuthzPolicyManager) *WorkitemController {
 	if db == nil {
 		panic("db must not be nil")
 	}
 	return &WorkitemController{
-		Controller: service.NewController("WorkitemController"),
-		db:         db,
-		config:     config}
+		Controller:    service.NewController("WorkitemController"),
+		db:            db,
+		config:        config,
+		policyManager: policyManager}
 }
 
 // List runs the list action.
@@ -73,10 +88,13 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
 	}
 	if ctx.FilterAssignee != nil {
-		exp = criteria.And(exp, criteria.Equals(criteria.Field("system.assignees"), criteria.Literal([]string{*ctx.FilterAssignee})))
+		exp = criteria.And(exp, assigneeFilter(*ctx.FilterAssignee))
 		additionalQuery = append(additionalQuery, "filter[assignee]="+*ctx.FilterAssignee)
 	}
-	if ctx.FilterIteration != nil {
+	if ctx.FilterIteration != nil && *ctx.FilterIteration == FilterIterationNone {
+		exp = criteria.And(exp, criteria.IsNull(workitem.SystemIteration))
+		additionalQuery = append(additionalQuery, "filter[iteration]="+*ctx.FilterIteration)
+	} else if ctx.FilterIteration != nil {
 		exp = criteria.And(exp, criteria.Equals(criteria.Field(workitem.SystemIteration), criteria.Literal(string(*ctx.FilterIteration))))
 		additionalQuery = append(additionalQuery, "filter[iteration]="+*ctx.FilterIteration)
 		// Update filter by adding child iterations if any
@@ -101,7 +119,10 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 		exp = criteria.And(exp, criteria.Equals(criteria.Field("Type"), criteria.Literal([]uuid.UUID{*ctx.FilterWorkitemtype})))
 		additionalQuery = append(additionalQuery, "filter[workitemtype]="+ctx.FilterWorkitemtype.String())
 	}
-	if ctx.FilterArea != nil {
+	if ctx.FilterArea != nil && *ctx.FilterArea == FilterAreaNone {
+		exp = criteria.And(exp, criteria.IsNull(workitem.SystemArea))
+		additionalQuery = append(additionalQuery, "filter[area]="+*ctx.FilterArea)
+	} else if ctx.FilterArea != nil {
 		exp = criteria.And(exp, criteria.Equals(criteria.Field(workitem.SystemArea), criteria.Literal(string(*ctx.FilterArea))))
 		additionalQuery = append(additionalQuery, "filter[area]="+*ctx.FilterArea)
 	}
@@ -109,6 +130,43 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 		exp = criteria.And(exp, criteria.Equals(criteria.Field(workitem.SystemState), criteria.Literal(string(*ctx.FilterWorkitemstate))))
 		additionalQuery = append(additionalQuery, "filter[workitemstate]="+*ctx.FilterWorkitemstate)
 	}
+	if ctx.FilterCreatedfrom != nil {
+		exp = criteria.And(exp, criteria.GreaterOrEqual(criteria.Field("created_at"), criteria.Literal(*ctx.FilterCreatedfrom)))
+		additionalQuery = append(additionalQuery, "filter[createdfrom]="+ctx.FilterCreatedfrom.UTC().Format(time.RFC3339Nano))
+	}
+	if ctx.FilterCreatedto != nil {
+		exp = criteria.And(exp, criteria.LessOrEqual(criteria.Field("created_at"), criteria.Literal(*ctx.FilterCreatedto)))
+		additionalQuery = append(additionalQuery, "filter[createdto]="+ctx.FilterCreatedto.UTC().Format(time.RFC3339Nano))
+	}
+	if ctx.FilterExcludearea != nil {
+		areaUUID, errConversion := uuid.FromString(*ctx.FilterExcludearea)
+		if errConversion != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("filter[excludearea]", *ctx.FilterExcludearea).Expected("area ID"))
+		}
+		additionalQuery = append(additionalQuery, "filter[excludearea]="+*ctx.FilterExcludearea)
+		// exclude the given area and all areas below it
+		err = application.Transactional(c.db, func(tx application.Application) error {
+			excludedArea, err := tx.Areas().Load(ctx.Context, areaUUID)
+			if _, ok := errs.Cause(err).(errors.NotFoundError); ok {
+				return errors.NewBadParameterError("filter[excludearea]", *ctx.FilterExcludearea).Expected("existing area ID")
+			}
+			if err != nil {
+				return errs.Wrapf(err, "unable to load area %s", areaUUID)
+			}
+			descendants, err := tx.Areas().ListDescendants(ctx.Context, excludedArea)
+			if err != nil {
+				return errs.Wrapf(err, "unable to fetch descendants of area %s", areaUUID)
+			}
+			exp = criteria.And(exp, criteria.Not(criteria.Field(workitem.SystemArea), criteria.Literal(excludedArea.ID.String())))
+			for _, descendant := range descendants {
+				exp = criteria.And(exp, criteria.Not(criteria.Field(workitem.SystemArea), criteria.Literal(descendant.ID.String())))
+			}
+			return nil
+		})
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+	}
 
 	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
 	return application.Transactional(c.db, func(tx application.Application) error {
@@ -132,8 +190,36 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 	})
 }
 
-// Returns true if the user is the work item creator or space collaborator
+const (
+	// FilterAssigneeNone is the value of the `filter[assignee]` parameter to select the work items without any assignee
+	FilterAssigneeNone = "none"
+	// FilterAssigneeDeactivated is the value of the `filter[assignee]` parameter to select the work items assigned to a deactivated identity
+	FilterAssigneeDeactivated = "deactivated"
+	// FilterIterationNone is the value of the `filter[iteration]` parameter to select the work items without any iteration
+	FilterIterationNone = "none"
+	// FilterAreaNone is the value of the `filter[area]` parameter to select the work items without any area
+	FilterAreaNone = "none"
+)
+
+// assigneeFilter returns the expression selecting the work items assigned to the given identity,
+// the unassigned work items if the given value is FilterAssigneeNone, or the work items assigned
+// to a deactivated identity if the given value is FilterAssigneeDeactivated
+func assigneeFilter(assignee string) criteria.Expression {
+	switch assignee {
+	case FilterAssigneeNone:
+		return criteria.IsNull(workitem.SystemAssignees)
+	case FilterAssigneeDeactivated:
+		return criteria.ReferencesDeactivatedIdentity(workitem.SystemAssignees)
+	default:
+		return criteria.Equals(criteria.Field(workitem.SystemAssignees), criteria.Literal([]string{assignee}))
+	}
+}
+
+// Returns true if the user is the work item creator or space collaborator, unless the user is a viewer of the space
 func authorizeWorkitemEditor(ctx context.Context, db application.DB, spaceID uuid.UUID, creatorID string, editorID string) (bool, error) {
+	if err := authz.RejectViewer(ctx, spaceID.String()); err != nil {
+		return false, err
+	}
 	if editorID == creatorID {
 		return true, nil
 	}
@@ -144,6 +230,163 @@ func authorizeWorkitemEditor(ctx context.Context, db application.DB, spaceID uui
 	return authorized, nil
 }
 
+// authorizeSpaceWriter returns true if the user is a collaborator of the space allowed to modify it: the viewers of the
+// space get an authz.ErrSpaceViewer error
+func authorizeSpaceWriter(ctx context.Context, spaceID string) (bool, error) {
+	authorized, err := authz.Authorize(ctx, spaceID)
+	if err != nil {
+		return false, errors.NewUnauthorizedError(err.Error())
+	}
+	if !authorized {
+		return false, nil
+	}
+	if err := authz.RejectViewer(ctx, spaceID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkAssigneesAreCollaborators returns a BadParameterError if an identity assigned to the given work item is neither
+// a collaborator of the space nor a member of one of its teams, unless it was already among the previous assignees. Nothing is checked unless
+// the assignees are required to be collaborators in the configuration.
+func (c *WorkitemController) checkAssigneesAreCollaborators(ctx context.Context, req *goa.RequestData, appl application.Application, spaceID uuid.UUID, previousAssignees interface{}, wi workitem.WorkItem) error {
+	if !c.config.IsWorkItemAssigneeCollaboratorRequired() {
+		return nil
+	}
+	previous := map[string]bool{}
+	for _, id := range assigneeIDs(previousAssignees) {
+		previous[id] = true
+	}
+	var added []string
+	for _, id := range assigneeIDs(wi.Fields[workitem.SystemAssignees]) {
+		if !previous[id] {
+			added = append(added, id)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	resource, err := appl.SpaceResources().LoadBySpace(ctx, &spaceID)
+	if err != nil {
+		return err
+	}
+	policy, _, err := c.policyManager.GetPolicy(ctx, req, resource.PolicyID)
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	for _, id := range added {
+		if policy.HasUser(id) {
+			continue
+		}
+		// the members of the teams of the space collaborate on it as well
+		identityID, err := uuid.FromString(id)
+		if err != nil {
+			return errors.NewBadParameterError("data.relationships.assignees.data.id", id).Expected("a collaborator of the space")
+		}
+		member, err := appl.Teams().IsSpaceMember(ctx, spaceID, identityID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			return errors.NewBadParameterError("data.relationships.assignees.data.id", id).Expected("a collaborator of the space")
+		}
+	}
+	return nil
+}
+
+// Values of the configuration setting how the assignment of work items to a closed or past iteration is handled
+const (
+	ClosedIterationAssignmentAllow  = "allow"
+	ClosedIterationAssignmentWarn   = "warn"
+	ClosedIterationAssignmentReject = "reject"
+)
+
+// checkIterationAssignment checks the given iteration when it differs from the previous iteration of the work item.
+// Depending on the configuration, the assignment to an iteration which is closed or whose end date has passed is
+// reported with a warning message, or rejected with a BadParameterError. Nothing is checked if it is allowed.
+func (c *WorkitemController) checkIterationAssignment(ctx context.Context, appl application.Application, previousIteration interface{}, newIteration interface{}) (string, error) {
+	mode := c.config.GetWorkItemClosedIterationAssignment()
+	if mode != ClosedIterationAssignmentWarn && mode != ClosedIterationAssignmentReject {
+		return "", nil
+	}
+	id, ok := newIteration.(string)
+	if !ok || id == "" || newIteration == previousIteration {
+		return "", nil
+	}
+	iterationID, err := uuid.FromString(id)
+	if err != nil {
+		return "", errors.NewBadParameterError("data.relationships.iteration.data.id", id).Expected("an iteration ID")
+	}
+	itr, err := appl.Iterations().Load(ctx, iterationID)
+	if err != nil {
+		return "", err
+	}
+	var reason string
+	if itr.State == iteration.IterationStateClose {
+		reason = "closed"
+	} else if itr.EndAt != nil && itr.EndAt.Before(time.Now()) {
+		reason = "past its end date"
+	} else {
+		return "", nil
+	}
+	if mode == ClosedIterationAssignmentReject {
+		return "", errors.NewBadParameterError("data.relationships.iteration.data.id", id).Expected("an iteration which is neither closed nor past its end date")
+	}
+	return fmt.Sprintf("the work item is assigned to the iteration '%s' which is %s", itr.Name, reason), nil
+}
+
+// setWarning adds the given message as a "Warning" header of the response, as defined in RFC 7234.
+// Nothing is added if the message is empty.
+func setWarning(response *goa.ResponseData, message string) {
+	if message != "" {
+		response.Header().Add("Warning", fmt.Sprintf("199 - %q", message))
+	}
+}
+
+// checkUnknownAttributes returns a BadParameterError naming the attributes of the given payload which are neither
+// fields of the work item type nor attributes returned by the API. Nothing is checked unless the configuration
+// requires payloads to be strict.
+func (c *WorkitemController) checkUnknownAttributes(ctx context.Context, appl application.Application, source app.WorkItem, witID uuid.UUID) error {
+	if !c.config.IsWorkItemPayloadStrict() {
+		return nil
+	}
+	wit, err := appl.WorkItemTypes().LoadByID(ctx, witID)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for key := range source.Attributes {
+		if _, ok := wit.Fields[key]; ok {
+			continue
+		}
+		switch key {
+		case "version", "number", workitem.SystemDescriptionMarkup, workitem.SystemDescriptionRendered:
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.NewBadParameterError("data.attributes", strings.Join(unknown, ", ")).Expected(fmt.Sprintf("fields of the work item type '%s'", wit.Name))
+	}
+	return nil
+}
+
+// assigneeIDs returns the IDs of the given assignees, as set by ConvertJSONAPIToWorkItem or loaded from the database
+func assigneeIDs(assignees interface{}) []string {
+	switch a := assignees.(type) {
+	case []string:
+		return a
+	case []interface{}:
+		ids := make([]string, 0, len(a))
+		for _, id := range a {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		return ids
+	}
+	return nil
+}
+
 // Update does PATCH workitem
 func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 	if ctx.Payload == nil || ctx.Payload.Data == nil || ctx.Payload.Data.ID == nil {
@@ -185,11 +428,36 @@ func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 		// Type changes of WI are not allowed which is why we overwrite it the
 		// type with the old one after the WI has been converted.
 		oldType := wi.Type
+		oldTitle := wi.Fields[workitem.SystemTitle]
+		oldIteration := wi.Fields[workitem.SystemIteration]
+		oldAssignees := wi.Fields[workitem.SystemAssignees]
+		err = c.checkUnknownAttributes(ctx, appl, *ctx.Payload.Data, oldType)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 		err = ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data, wi, spaceID)
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, err)
 		}
+		err = c.checkAssigneesAreCollaborators(ctx, ctx.RequestData, appl, spaceID, oldAssignees, *wi)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		warning, err := c.checkIterationAssignment(ctx, appl, oldIteration, wi.Fields[workitem.SystemIteration])
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
 		wi.Type = oldType
+		if wi.Fields[workitem.SystemTitle] != oldTitle || wi.Fields[workitem.SystemIteration] != oldIteration {
+			isUnique, err := isTitleUniqueInIteration(ctx, appl, spaceID, *wi)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			if !isUnique {
+				jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("title: %v is already in use in the iteration", wi.Fields[workitem.SystemTitle])))
+				return ctx.Conflict(jerrors)
+			}
+		}
 		wi, err = appl.WorkItems().Save(ctx, spaceID, *wi, *currentUserIdentityID)
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error updating work item"))
@@ -203,6 +471,97 @@ func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 			},
 		}
 
+		ctx.ResponseData.Header().Set("Last-Modified", lastModified(*wi))
+		setWarning(ctx.ResponseData, warning)
+		return ctx.OK(resp)
+	})
+}
+
+// MarkDuplicate marks the work item as the duplicate of the canonical work item: both work items
+// are linked with a "duplicate of" link and the duplicate is closed if configured so.
+func (c *WorkitemController) MarkDuplicate(ctx *app.MarkDuplicateWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	if ctx.WiID == ctx.CanonicalID {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("canonicalId", ctx.CanonicalID).Expected("a work item other than the duplicate"))
+	}
+	sourceID, err := strconv.ParseUint(ctx.WiID, 10, 64)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("work item", ctx.WiID))
+	}
+	targetID, err := strconv.ParseUint(ctx.CanonicalID, 10, 64)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("work item", ctx.CanonicalID))
+	}
+	var wi *workitem.WorkItem
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		wi, err = appl.WorkItems().Load(ctx, spaceID, ctx.WiID)
+		if err != nil {
+			return errs.Wrap(err, fmt.Sprintf("Failed to load work item with id %v", ctx.WiID))
+		}
+		_, err = appl.WorkItems().Load(ctx, spaceID, ctx.CanonicalID)
+		if err != nil {
+			return errs.Wrap(err, fmt.Sprintf("Failed to load work item with id %v", ctx.CanonicalID))
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	creator := wi.Fields[workitem.SystemCreator]
+	if creator == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError("work item doesn't have creator"))
+	}
+	authorized, err := authorizeWorkitemEditor(ctx, c.db, spaceID, creator.(string), currentUserIdentityID.String())
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		linkCategory, err := appl.WorkItemLinkCategories().LoadCategoryFromDB(ctx, link.SystemWorkItemLinkCategorySystem)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		linkType, err := appl.WorkItemLinkTypes().LoadTypeFromDBByNameAndCategory(ctx, link.SystemWorkItemLinkTypeDuplicateOf, linkCategory.ID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		_, err = appl.WorkItemLinks().Create(ctx, sourceID, targetID, linkType.ID, *currentUserIdentityID)
+		if err != nil {
+			switch errs.Cause(err).(type) {
+			case errors.NotFoundError, errors.BadParameterError:
+				return jsonapi.JSONErrorResponse(ctx, goa.ErrBadRequest(err.Error()))
+			default:
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+		}
+		if c.config.IsWorkItemDuplicateAutoClosed() && wi.Fields[workitem.SystemState] != workitem.SystemStateClosed {
+			wi.Fields[workitem.SystemState] = workitem.SystemStateClosed
+			wi, err = appl.WorkItems().Save(ctx, spaceID, *wi, *currentUserIdentityID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error closing the duplicate work item"))
+			}
+		}
+		log.Info(ctx, map[string]interface{}{
+			"wi_id":        ctx.WiID,
+			"canonical_id": ctx.CanonicalID,
+			"state":        wi.Fields[workitem.SystemState],
+		}, "work item marked as duplicate")
+		hasChildren := workItemIncludeHasChildren(appl, ctx)
+		resp := &app.WorkItemSingle{
+			Data: ConvertWorkItem(ctx.RequestData, *wi, hasChildren),
+			Links: &app.WorkItemLinks{
+				Self: buildAbsoluteURL(ctx.RequestData),
+			},
+		}
 		ctx.ResponseData.Header().Set("Last-Modified", lastModified(*wi))
 		return ctx.OK(resp)
 	})
@@ -219,9 +578,9 @@ func (c *WorkitemController) Reorder(ctx *app.ReorderWorkitemContext) error {
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
 	}
-	authorized, err := authz.Authorize(ctx, ctx.ID)
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
 	if err != nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
 	if !authorized {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
@@ -239,10 +598,25 @@ func (c *WorkitemController) Reorder(ctx *app.ReorderWorkitemContext) error {
 				return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "failed to reorder work item"))
 			}
 
+			oldAssignees := wi.Fields[workitem.SystemAssignees]
+			oldIteration := wi.Fields[workitem.SystemIteration]
+			err = c.checkUnknownAttributes(ctx, appl, *ctx.Payload.Data[i], wi.Type)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
 			err = ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data[i], wi, spaceID)
 			if err != nil {
 				return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "failed to reorder work item"))
 			}
+			err = c.checkAssigneesAreCollaborators(ctx, ctx.RequestData, appl, spaceID, oldAssignees, *wi)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			warning, err := c.checkIterationAssignment(ctx, appl, oldIteration, wi.Fields[workitem.SystemIteration])
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			setWarning(ctx.ResponseData, warning)
 			wi, err = appl.WorkItems().Reorder(ctx, workitem.DirectionType(ctx.Payload.Position.Direction), ctx.Payload.Position.ID, *wi, *currentUserIdentityID)
 			if err != nil {
 				return jsonapi.JSONErrorResponse(ctx, err)
@@ -259,6 +633,565 @@ func (c *WorkitemController) Reorder(ctx *app.ReorderWorkitemContext) error {
 	})
 }
 
+// BulkUpdate does PATCH workitem/bulk: it moves all work items matching the given filter
+// to the given area and/or iteration, updating them in batches of configurable size, and reports
+// the work items which were not updated.
+func (c *WorkitemController) BulkUpdate(ctx *app.BulkUpdateWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
+	}
+	if ctx.Payload.Area == nil && ctx.Payload.Iteration == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("area/iteration", nil).Expected("a target area or iteration"))
+	}
+	if ctx.Payload.Iteration != nil {
+		var warning string
+		err = application.Transactional(c.db, func(appl application.Application) error {
+			warning, err = c.checkIterationAssignment(ctx, appl, nil, ctx.Payload.Iteration.String())
+			return err
+		})
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		setWarning(ctx.ResponseData, warning)
+	}
+	exp, err := query.Parse(&ctx.Payload.Filter)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
+	}
+	var ids []string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if ctx.Payload.Area != nil {
+			a, err := appl.Areas().Load(ctx, *ctx.Payload.Area)
+			if err != nil {
+				return errs.Wrapf(err, "failed to load area %s", ctx.Payload.Area)
+			}
+			if !uuid.Equal(a.SpaceID, spaceID) {
+				return errors.NewBadParameterError("area", *ctx.Payload.Area).Expected("an area of space " + ctx.ID)
+			}
+		}
+		if ctx.Payload.Iteration != nil {
+			i, err := appl.Iterations().Load(ctx, *ctx.Payload.Iteration)
+			if err != nil {
+				return errs.Wrapf(err, "failed to load iteration %s", ctx.Payload.Iteration)
+			}
+			if !uuid.Equal(i.SpaceID, spaceID) {
+				return errors.NewBadParameterError("iteration", *ctx.Payload.Iteration).Expected("an iteration of space " + ctx.ID)
+			}
+		}
+		workitems, _, err := appl.WorkItems().List(ctx, spaceID, exp, nil, nil, nil)
+		if err != nil {
+			return errs.Wrap(err, "error listing work items to update")
+		}
+		for _, wi := range workitems {
+			ids = append(ids, wi.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	result := c.updateInBatches(ctx, spaceID, ids, *currentUserIdentityID, func(appl application.Application, wi *workitem.WorkItem) (string, error) {
+		if ctx.Payload.Area != nil {
+			wi.Fields[workitem.SystemArea] = ctx.Payload.Area.String()
+		}
+		if ctx.Payload.Iteration != nil {
+			wi.Fields[workitem.SystemIteration] = ctx.Payload.Iteration.String()
+			isUnique, err := isTitleUniqueInIteration(ctx, appl, spaceID, *wi)
+			if err != nil {
+				return "", err
+			}
+			if !isUnique {
+				return fmt.Sprintf("title %v is already in use in iteration %s", wi.Fields[workitem.SystemTitle], ctx.Payload.Iteration), nil
+			}
+		}
+		return "", nil
+	})
+	return ctx.OK(&result)
+}
+
+// updateInBatches applies the given update on the work items with the given IDs and saves them, within transactions
+// of at most the configured batch size. The update returns a non-empty reason when a work item must not be saved.
+// All the work items which were not updated are reported as failed along with the reason, including the ones
+// whose batch was rolled back, so that the result lists exactly the work items left untouched.
+func (c *WorkitemController) updateInBatches(ctx context.Context, spaceID uuid.UUID, ids []string, modifierID uuid.UUID, update func(appl application.Application, wi *workitem.WorkItem) (string, error)) app.WorkItemBulkUpdateResult {
+	result := app.WorkItemBulkUpdateResult{
+		Failed: []*app.WorkItemBulkUpdateFailure{},
+	}
+	batchSize := c.config.GetWorkItemBulkUpdateBatchSize()
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var updated int
+		var failed []*app.WorkItemBulkUpdateFailure
+		err := application.Transactional(c.db, func(appl application.Application) error {
+			updated, failed = 0, nil
+			for _, id := range ids[start:end] {
+				reason, err := updateWorkItem(ctx, appl, spaceID, id, modifierID, update)
+				if err != nil {
+					switch errs.Cause(err).(type) {
+					case errors.NotFoundError, errors.BadParameterError, errors.VersionConflictError, errors.ConversionError:
+						// a failed statement aborts the whole transaction, which then fails to commit
+						reason = err.Error()
+					default:
+						return errs.Wrapf(err, "failed to update work item %s", id)
+					}
+				}
+				if reason != "" {
+					failed = append(failed, &app.WorkItemBulkUpdateFailure{ID: id, Reason: reason})
+					continue
+				}
+				updated++
+			}
+			return nil
+		})
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"space_id": spaceID,
+				"wi_ids":   ids[start:end],
+				"err":      err,
+			}, "bulk update of a batch of work items rolled back")
+			for _, id := range ids[start:end] {
+				result.Failed = append(result.Failed, &app.WorkItemBulkUpdateFailure{ID: id, Reason: "the update of the batch of the work item was rolled back"})
+			}
+			continue
+		}
+		result.Updated += updated
+		result.Failed = append(result.Failed, failed...)
+	}
+	return result
+}
+
+// updateWorkItem loads the work item with the given ID, applies the given update on it and saves it,
+// unless the update returns a reason not to.
+func updateWorkItem(ctx context.Context, appl application.Application, spaceID uuid.UUID, id string, modifierID uuid.UUID, update func(appl application.Application, wi *workitem.WorkItem) (string, error)) (string, error) {
+	wi, err := appl.WorkItems().Load(ctx, spaceID, id)
+	if err != nil {
+		return "", err
+	}
+	reason, err := update(appl, wi)
+	if err != nil || reason != "" {
+		return reason, err
+	}
+	_, err = appl.WorkItems().Save(ctx, spaceID, *wi, modifierID)
+	return "", err
+}
+
+// BulkSetDueDate does PATCH workitem/bulk/duedate: it sets the due date of all work items matching
+// the given filter, updating them in batches of configurable size, and reports the work items which
+// were not updated. The work items whose type has no due date are skipped and not counted as updated.
+func (c *WorkitemController) BulkSetDueDate(ctx *app.BulkSetDueDateWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
+	}
+	exp, err := query.Parse(&ctx.Payload.Filter)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
+	}
+	var ids []string
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		workitems, _, err := appl.WorkItems().List(ctx, spaceID, exp, nil, nil, nil)
+		if err != nil {
+			return errs.Wrap(err, "error listing work items to update")
+		}
+		// only keep the work items whose type has a due date
+		hasDueDate := map[uuid.UUID]bool{}
+		for _, wi := range workitems {
+			if _, ok := hasDueDate[wi.Type]; !ok {
+				wit, err := appl.WorkItemTypes().LoadByID(ctx, wi.Type)
+				if err != nil {
+					return errs.Wrapf(err, "failed to load type of work item %s", wi.ID)
+				}
+				_, hasDueDate[wi.Type] = wit.Fields[workitem.SystemDueDate]
+			}
+			if hasDueDate[wi.Type] {
+				ids = append(ids, wi.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	result := c.updateInBatches(ctx, spaceID, ids, *currentUserIdentityID, func(appl application.Application, wi *workitem.WorkItem) (string, error) {
+		wi.Fields[workitem.SystemDueDate] = ctx.Payload.DueDate
+		return "", nil
+	})
+	return ctx.OK(&result)
+}
+
+// BulkSetState does PATCH workitem/bulk/state: it sets all work items matching the given filter
+// to the given state and reports the work items which were skipped, either because they already are
+// in that state or because the state is not one of the states allowed by their type.
+// All the work items are updated within a single transaction: none of them is updated if an error occurs.
+func (c *WorkitemController) BulkSetState(ctx *app.BulkSetStateWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
+	}
+	exp, err := query.Parse(&ctx.Payload.Filter)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
+	}
+	var result app.WorkItemBulkStateUpdateResult
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		result = app.WorkItemBulkStateUpdateResult{
+			Updated: []string{},
+			Skipped: []*app.WorkItemBulkStateUpdateSkip{},
+		}
+		workitems, _, err := appl.WorkItems().List(ctx, spaceID, exp, nil, nil, nil)
+		if err != nil {
+			return errs.Wrap(err, "error listing work items to update")
+		}
+		for _, wi := range workitems {
+			reason, err := setWorkItemState(ctx, appl, spaceID, wi, ctx.Payload.State, *currentUserIdentityID)
+			if err != nil {
+				switch errs.Cause(err).(type) {
+				case errors.NotFoundError, errors.BadParameterError, errors.VersionConflictError, errors.ConversionError:
+					// a failed statement aborts the whole transaction, which then fails to commit
+					reason = err.Error()
+				default:
+					return errs.Wrapf(err, "failed to set the state of work item %s", wi.ID)
+				}
+			}
+			if reason != "" {
+				result.Skipped = append(result.Skipped, &app.WorkItemBulkStateUpdateSkip{ID: wi.ID, Reason: reason})
+				continue
+			}
+			result.Updated = append(result.Updated, wi.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id": spaceID,
+			"state":    ctx.Payload.State,
+			"err":      err,
+		}, "bulk state update of work items rolled back")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&result)
+}
+
+// setWorkItemState sets the given work item to the given state and saves it, unless it already is in that state
+// or the state is not allowed by its type, in which case the reason to skip the work item is returned.
+func setWorkItemState(ctx context.Context, appl application.Application, spaceID uuid.UUID, wi workitem.WorkItem, state string, modifierID uuid.UUID) (string, error) {
+	if wi.Fields[workitem.SystemState] == state {
+		return fmt.Sprintf("work item is already in state '%s'", state), nil
+	}
+	wit, err := appl.WorkItemTypes().LoadByID(ctx, wi.Type)
+	if err != nil {
+		return "", errs.Wrapf(err, "failed to load type of work item %s", wi.ID)
+	}
+	stateDef, ok := wit.Fields[workitem.SystemState]
+	if !ok {
+		return fmt.Sprintf("work item type %s has no state", wi.Type), nil
+	}
+	if _, err := stateDef.ConvertToModel(workitem.SystemState, state); err != nil {
+		return fmt.Sprintf("state '%s' is not allowed by work item type %s", state, wi.Type), nil
+	}
+	wi.Fields[workitem.SystemState] = state
+	_, err = appl.WorkItems().Save(ctx, spaceID, wi, modifierID)
+	return "", err
+}
+
+// Transfer runs the transfer action.
+func (c *WorkitemController) Transfer(ctx *app.TransferWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	targetSpaceID := ctx.Payload.Space
+	if uuid.Equal(spaceID, targetSpaceID) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("space", targetSpaceID).Expected("a space other than "+ctx.ID))
+	}
+	for _, sID := range []string{ctx.ID, targetSpaceID.String()} {
+		authorized, err := authorizeSpaceWriter(ctx, sID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		if !authorized {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space "+sID))
+		}
+	}
+	var mapping *workItemTransferMapping
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Spaces().Load(ctx, targetSpaceID); err != nil {
+			return err
+		}
+		mapping, err = newWorkItemTransferMapping(ctx, appl, spaceID, targetSpaceID)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	result := app.WorkItemTransferResult{
+		Moved:     []string{},
+		Failed:    []*app.WorkItemTransferFailure{},
+		Fallbacks: []*app.WorkItemTransferFallback{},
+	}
+	for _, id := range ctx.Payload.Workitems {
+		var reason string
+		var fallbacks []string
+		err := application.Transactional(c.db, func(appl application.Application) error {
+			wi, err := appl.WorkItems().Load(ctx, spaceID, id)
+			if err != nil {
+				return err
+			}
+			if reason, fallbacks = mapping.apply(wi); reason != "" {
+				return nil
+			}
+			isUnique, err := isTitleUniqueInIteration(ctx, appl, targetSpaceID, *wi)
+			if err != nil {
+				return err
+			}
+			if !isUnique {
+				reason = fmt.Sprintf("title %v is already in use in the iteration of space %s", wi.Fields[workitem.SystemTitle], targetSpaceID)
+				return nil
+			}
+			_, err = appl.WorkItems().Move(ctx, spaceID, *wi, targetSpaceID, *currentUserIdentityID)
+			return err
+		})
+		if err != nil {
+			switch errs.Cause(err).(type) {
+			case errors.NotFoundError, errors.BadParameterError, errors.VersionConflictError, errors.ConversionError:
+				reason = err.Error()
+			default:
+				log.Error(ctx, map[string]interface{}{
+					"space_id":        spaceID,
+					"target_space_id": targetSpaceID,
+					"wi_id":           id,
+					"err":             err,
+				}, "transfer of work items aborted")
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+		}
+		if reason != "" {
+			result.Failed = append(result.Failed, &app.WorkItemTransferFailure{ID: id, Reason: reason})
+			continue
+		}
+		result.Moved = append(result.Moved, id)
+		for _, fallback := range fallbacks {
+			result.Fallbacks = append(result.Fallbacks, &app.WorkItemTransferFallback{ID: id, Reason: fallback})
+		}
+	}
+	return ctx.OK(&result)
+}
+
+// workItemTransferMapping maps the area, iteration and type of the work items of a space
+// to the ones of another space. Areas and iterations are mapped by their path of names below the root
+// area (resp. iteration), and types by name. Work items in an area (resp. iteration) which has no
+// counterpart in the target space are moved to the root area (resp. iteration) of the target space.
+type workItemTransferMapping struct {
+	targetSpaceID  uuid.UUID
+	areas          map[string]string
+	areaPaths      map[string]string
+	rootArea       string
+	iterations     map[string]string
+	iterationPaths map[string]string
+	rootIteration  string
+	types          map[uuid.UUID]uuid.UUID
+}
+
+func newWorkItemTransferMapping(ctx context.Context, appl application.Application, spaceID, targetSpaceID uuid.UUID) (*workItemTransferMapping, error) {
+	m := workItemTransferMapping{
+		targetSpaceID:  targetSpaceID,
+		areas:          map[string]string{},
+		areaPaths:      map[string]string{},
+		iterations:     map[string]string{},
+		iterationPaths: map[string]string{},
+		types:          map[uuid.UUID]uuid.UUID{},
+	}
+	// areas
+	sourceAreas, err := appl.Areas().List(ctx, spaceID)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list areas of space %s", spaceID)
+	}
+	targetAreas, err := appl.Areas().List(ctx, targetSpaceID)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list areas of space %s", targetSpaceID)
+	}
+	targetAreaIDs := map[string]string{}
+	targetAreaNames := map[uuid.UUID]string{}
+	for _, a := range targetAreas {
+		targetAreaNames[a.ID] = a.Name
+	}
+	for _, a := range targetAreas {
+		targetAreaIDs[relativeNamePath(a.Path, a.Name, targetAreaNames)] = a.ID.String()
+	}
+	sourceAreaNames := map[uuid.UUID]string{}
+	for _, a := range sourceAreas {
+		sourceAreaNames[a.ID] = a.Name
+	}
+	for _, a := range sourceAreas {
+		areaPath := relativeNamePath(a.Path, a.Name, sourceAreaNames)
+		m.areaPaths[a.ID.String()] = areaPath
+		if targetID, ok := targetAreaIDs[areaPath]; ok {
+			m.areas[a.ID.String()] = targetID
+		}
+	}
+	if rootArea, err := appl.Areas().Root(ctx, targetSpaceID); err == nil {
+		m.rootArea = rootArea.ID.String()
+	}
+	// iterations
+	sourceIterations, err := appl.Iterations().List(ctx, spaceID)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list iterations of space %s", spaceID)
+	}
+	targetIterations, err := appl.Iterations().List(ctx, targetSpaceID)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list iterations of space %s", targetSpaceID)
+	}
+	targetIterationIDs := map[string]string{}
+	targetIterationNames := map[uuid.UUID]string{}
+	for _, i := range targetIterations {
+		targetIterationNames[i.ID] = i.Name
+	}
+	for _, i := range targetIterations {
+		targetIterationIDs[relativeNamePath(i.Path, i.Name, targetIterationNames)] = i.ID.String()
+	}
+	sourceIterationNames := map[uuid.UUID]string{}
+	for _, i := range sourceIterations {
+		sourceIterationNames[i.ID] = i.Name
+	}
+	for _, i := range sourceIterations {
+		iterationPath := relativeNamePath(i.Path, i.Name, sourceIterationNames)
+		m.iterationPaths[i.ID.String()] = iterationPath
+		if targetID, ok := targetIterationIDs[iterationPath]; ok {
+			m.iterations[i.ID.String()] = targetID
+		}
+	}
+	if rootIteration, err := appl.Iterations().Root(ctx, targetSpaceID); err == nil {
+		m.rootIteration = rootIteration.ID.String()
+	}
+	// types: the ones of the system space and of the target space can be kept as-is,
+	// the ones specific to the source space are replaced by the type with the same name in the target space.
+	targetTypeIDs := map[string]uuid.UUID{}
+	for _, sID := range []uuid.UUID{space.SystemSpace, targetSpaceID} {
+		types, err := appl.WorkItemTypes().List(ctx, sID, nil, nil)
+		if err != nil {
+			return nil, errs.Wrapf(err, "failed to list work item types of space %s", sID)
+		}
+		for _, t := range types {
+			m.types[t.ID] = t.ID
+			targetTypeIDs[t.Name] = t.ID
+		}
+	}
+	sourceTypes, err := appl.WorkItemTypes().List(ctx, spaceID, nil, nil)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list work item types of space %s", spaceID)
+	}
+	for _, t := range sourceTypes {
+		if _, ok := m.types[t.ID]; ok {
+			continue
+		}
+		if targetID, ok := targetTypeIDs[t.Name]; ok {
+			m.types[t.ID] = targetID
+		}
+	}
+	return &m, nil
+}
+
+// relativeNamePath returns the path of names of the area (resp. iteration) with the given path and name
+// below the root area (resp. iteration), in the form of /area1/area2, the root one having the / path.
+// The names of the ancestors are looked up in the given names by ID.
+func relativeNamePath(p path.Path, name string, names map[uuid.UUID]string) string {
+	if p.IsEmpty() {
+		return path.SepInService
+	}
+	resolved := ""
+	for _, id := range p[1:] {
+		resolved += path.SepInService + names[id]
+	}
+	return resolved + path.SepInService + name
+}
+
+// apply updates the given work item with the area, iteration and type of the target space.
+// Returns the reason why the work item cannot be moved, or an empty string if it can, along with
+// the notes about the area and iteration replaced by the root ones of the target space.
+func (m workItemTransferMapping) apply(wi *workitem.WorkItem) (string, []string) {
+	targetType, ok := m.types[wi.Type]
+	if !ok {
+		return fmt.Sprintf("work item type %s has no equivalent in space %s", wi.Type, m.targetSpaceID), nil
+	}
+	wi.Type = targetType
+	var fallbacks []string
+	if areaID, ok := wi.Fields[workitem.SystemArea].(string); ok && areaID != "" {
+		targetAreaID, ok := m.areas[areaID]
+		if !ok {
+			if m.rootArea == "" {
+				return fmt.Sprintf("area %s has no equivalent in space %s", areaID, m.targetSpaceID), nil
+			}
+			targetAreaID = m.rootArea
+			fallbacks = append(fallbacks, fmt.Sprintf("area %s has no equivalent in space %s, the work item is moved to its root area", m.pathOf(m.areaPaths, areaID), m.targetSpaceID))
+		}
+		wi.Fields[workitem.SystemArea] = targetAreaID
+	}
+	if iterationID, ok := wi.Fields[workitem.SystemIteration].(string); ok && iterationID != "" {
+		targetIterationID, ok := m.iterations[iterationID]
+		if !ok {
+			if m.rootIteration == "" {
+				return fmt.Sprintf("iteration %s has no equivalent in space %s", iterationID, m.targetSpaceID), nil
+			}
+			targetIterationID = m.rootIteration
+			fallbacks = append(fallbacks, fmt.Sprintf("iteration %s has no equivalent in space %s, the work item is moved to its root iteration", m.pathOf(m.iterationPaths, iterationID), m.targetSpaceID))
+		}
+		wi.Fields[workitem.SystemIteration] = targetIterationID
+	}
+	return "", fallbacks
+}
+
+// pathOf returns the path of names of the area or iteration with the given ID, or the ID itself if it is unknown.
+func (m workItemTransferMapping) pathOf(paths map[string]string, id string) string {
+	if p, ok := paths[id]; ok {
+		return p
+	}
+	return id
+}
+
 // Create does POST workitem
 func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 	spaceID, err := uuid.FromString(ctx.ID)
@@ -285,6 +1218,9 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 		spaceSelfURL := rest.AbsoluteURL(goa.ContextRequest(ctx), app.SpaceHref(spaceID.String()))
 		ctx.Payload.Data.Relationships.Space = app.NewSpaceRelation(spaceID, spaceSelfURL)
 	}
+	if err := authz.RejectViewer(ctx, spaceID.String()); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 	wi := workitem.WorkItem{
 		Fields: make(map[string]interface{}),
 	}
@@ -305,7 +1241,11 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 			wi.Fields[workitem.SystemArea] = rootArea.ID.String()
 		}
 
-		err := ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data, &wi, spaceID)
+		err := c.checkUnknownAttributes(ctx, appl, *ctx.Payload.Data, *wit)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		err = ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data, &wi, spaceID)
 		// fetch root iteration for this space and assign it to WI if not present already
 		if _, ok := wi.Fields[workitem.SystemIteration]; ok == false {
 			// no iteration set hence set to root iteration of its space
@@ -317,6 +1257,22 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, fmt.Sprintf("Error creating work item")))
 		}
+		err = c.checkAssigneesAreCollaborators(ctx, ctx.RequestData, appl, spaceID, nil, wi)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		warning, err := c.checkIterationAssignment(ctx, appl, nil, wi.Fields[workitem.SystemIteration])
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		isUnique, err := isTitleUniqueInIteration(ctx, appl, spaceID, wi)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		if !isUnique {
+			jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrInvalidRequest(fmt.Sprintf("title: %v is already in use in the iteration", wi.Fields[workitem.SystemTitle])))
+			return ctx.Conflict(jerrors)
+		}
 
 		wi, err := appl.WorkItems().Create(ctx, spaceID, *wit, wi.Fields, *currentUserIdentityID)
 		if err != nil {
@@ -332,10 +1288,50 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 		}
 		ctx.ResponseData.Header().Set("Last-Modified", lastModified(*wi))
 		ctx.ResponseData.Header().Set("Location", app.WorkitemHref(wi2.Relationships.Space.Data.ID.String(), wi2.ID))
+		setWarning(ctx.ResponseData, warning)
 		return ctx.Created(resp)
 	})
 }
 
+// isTitleUniqueInIteration returns false if the space of the given work item
+// requires distinct titles per iteration and another work item of the same
+// iteration already has the title of the given work item.
+func isTitleUniqueInIteration(ctx context.Context, appl application.Application, spaceID uuid.UUID, wi workitem.WorkItem) (bool, error) {
+	s, err := appl.Spaces().Load(ctx, spaceID)
+	if err != nil {
+		return false, errs.Wrapf(err, "failed to load space %s", spaceID)
+	}
+	if !s.UniqueTitlesPerIteration {
+		return true, nil
+	}
+	title, ok := wi.Fields[workitem.SystemTitle].(string)
+	if !ok {
+		return true, nil
+	}
+	iterationID, ok := wi.Fields[workitem.SystemIteration].(string)
+	if !ok || iterationID == "" {
+		return true, nil
+	}
+	exp := criteria.And(
+		criteria.Equals(criteria.Field(workitem.SystemTitle), criteria.Literal(title)),
+		criteria.Equals(criteria.Field(workitem.SystemIteration), criteria.Literal(iterationID)))
+	wisWithSameTitle, _, err := appl.WorkItems().List(ctx, spaceID, exp, nil, nil, nil)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id":     spaceID,
+			"iteration_id": iterationID,
+			"err":          err,
+		}, "error fetching work items with the same title in the iteration")
+		return false, errs.Wrapf(err, "failed to list work items of iteration %s", iterationID)
+	}
+	for _, other := range wisWithSameTitle {
+		if other.ID != wi.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // Show does GET workitem
 func (c *WorkitemController) Show(ctx *app.ShowWorkitemContext) error {
 	spaceID, err := uuid.FromString(ctx.ID)
@@ -361,6 +1357,62 @@ func (c *WorkitemController) Show(ctx *app.ShowWorkitemContext) error {
 	})
 }
 
+// ShowByNumber does GET workitem/number/:number
+func (c *WorkitemController) ShowByNumber(ctx *app.ShowByNumberWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		wi, err := appl.WorkItems().LoadByNumber(ctx, spaceID, ctx.Number)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, fmt.Sprintf("Fail to load work item with number %d", ctx.Number)))
+		}
+		comments := workItemIncludeCommentsAndTotal(ctx, c.db, wi.ID)
+		hasChildren := workItemIncludeHasChildren(appl, ctx)
+		return ctx.ConditionalEntity(*wi, c.config.GetCacheControlWorkItems, func() error {
+			wi2 := ConvertWorkItem(ctx.RequestData, *wi, comments, hasChildren)
+			resp := &app.WorkItemSingle{
+				Data: wi2,
+			}
+			return ctx.OK(resp)
+		})
+	})
+}
+
+// Restore does POST workitem/restore
+func (c *WorkitemController) Restore(ctx *app.RestoreWorkitemContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+	}
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !authorized {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		wi, err := appl.WorkItems().Restore(ctx, spaceID, ctx.WiID, *currentUserIdentityID, c.config.GetWorkItemRestoreWindow())
+		if err != nil {
+			if _, ok := errs.Cause(err).(errors.NotFoundError); ok {
+				return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(fmt.Sprintf("work item %s cannot be restored: it does not exist or has been purged", ctx.WiID)))
+			}
+			return jsonapi.JSONErrorResponse(ctx, errs.Wrapf(err, "error restoring work item %s", ctx.WiID))
+		}
+		hasChildren := workItemIncludeHasChildren(appl, ctx)
+		resp := &app.WorkItemSingle{
+			Data: ConvertWorkItem(ctx.RequestData, *wi, hasChildren),
+		}
+		return ctx.OK(resp)
+	})
+}
+
 // Delete does DELETE workitem
 func (c *WorkitemController) Delete(ctx *app.DeleteWorkitemContext) error {
 
@@ -376,9 +1428,9 @@ func (c *WorkitemController) Delete(ctx *app.DeleteWorkitemContext) error {
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
 	}
-	authorized, err := authz.Authorize(ctx, ctx.ID)
+	authorized, err := authorizeSpaceWriter(ctx, ctx.ID)
 	if err != nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError(err.Error()))
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
 	if !authorized {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("user is not authorized to access the space"))
@@ -525,6 +1577,17 @@ func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem,
 			} else {
 				return err
 			}
+		} else if key == workitem.SystemDueDate && val != nil {
+			// the due date is received as an RFC 3339 string but stored as an instant
+			s, ok := val.(string)
+			if !ok {
+				return errors.NewBadParameterError("data.attributes[system.due_date]", val).Expected("an RFC 3339 date")
+			}
+			dueDate, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return errors.NewBadParameterError("data.attributes[system.due_date]", val).Expected("an RFC 3339 date")
+			}
+			target.Fields[key] = dueDate
 		} else {
 			target.Fields[key] = val
 		}
@@ -578,6 +1641,7 @@ func ConvertWorkItem(request *goa.RequestData, wi workitem.WorkItem, additional
 		Type: APIStringTypeWorkItem,
 		Attributes: map[string]interface{}{
 			"version": wi.Version,
+			"number":  wi.Number,
 		},
 		Relationships: &app.WorkItemRelationships{
 			BaseType: &app.RelationBaseType{
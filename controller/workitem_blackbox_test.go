@@ -19,6 +19,7 @@ import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/app/test"
 	"github.com/almighty/almighty-core/area"
+	"github.com/almighty/almighty-core/auth"
 	"github.com/almighty/almighty-core/codebase"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/gormapplication"
@@ -26,19 +27,23 @@ import (
 	"github.com/almighty/almighty-core/gormtestsupport"
 	"github.com/almighty/almighty-core/iteration"
 	"github.com/almighty/almighty-core/jsonapi"
+	tokencontext "github.com/almighty/almighty-core/login/tokencontext"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/path"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/space/authz"
 	testsupport "github.com/almighty/almighty-core/test"
 	almtoken "github.com/almighty/almighty-core/token"
 	"github.com/almighty/almighty-core/workitem"
+	"github.com/almighty/almighty-core/workitem/link"
 
 	"github.com/almighty/almighty-core/configuration"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
@@ -89,7 +94,7 @@ func (s *WorkItemSuite) SetupTest() {
 	s.testIdentity = testIdentity
 
 	s.svc = testsupport.ServiceAsUser("TestUpdateWI-Service", almtoken.NewManagerWithPrivateKey(s.priKey), s.testIdentity)
-	s.controller = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	s.controller = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 	payload := minimumRequiredCreateWithType(workitem.SystemBug)
 	payload.Data.Attributes[workitem.SystemTitle] = "Test WI"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
@@ -352,14 +357,14 @@ func (s *WorkItemSuite) TestListByFields() {
 	filter := "{\"system.title\":\"run integration test\"}"
 	offset := "0"
 	limit := 1
-	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, payload.Data.Relationships.Space.Data.ID.String(), &filter, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, payload.Data.Relationships.Space.Data.ID.String(), &filter, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	// then
 	require.NotNil(s.T(), result)
 	require.Equal(s.T(), 1, len(result.Data))
 	// when
 	filter = fmt.Sprintf("{\"system.creator\":\"%s\"}", s.testIdentity.ID.String())
 	// then
-	_, result = test.ListWorkitemOK(s.T(), nil, nil, s.controller, payload.Data.Relationships.Space.Data.ID.String(), &filter, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), nil, nil, s.controller, payload.Data.Relationships.Space.Data.ID.String(), &filter, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	require.NotNil(s.T(), result)
 	require.Equal(s.T(), 1, len(result.Data))
 }
@@ -497,7 +502,7 @@ func (s *WorkItemSuite) TestUnauthorizeWorkItemCUD() {
 	UnauthorizeCreateUpdateDeleteTest(s.T(), getWorkItemTestDataFunc(*s.Configuration), func() *goa.Service {
 		return goa.New("TestUnauthorizedCreateWI-Service")
 	}, func(service *goa.Service) error {
-		controller := NewWorkitemController(service, gormapplication.NewGormDB(s.DB), s.Configuration)
+		controller := NewWorkitemController(service, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 		app.MountWorkitemController(service, controller)
 		return nil
 	})
@@ -509,7 +514,7 @@ func createPagingTest(t *testing.T, ctx context.Context, controller *WorkitemCon
 		repo.ListReturns(makeWorkItems(count), uint64(totalCount), nil)
 		offset := strconv.Itoa(start)
 
-		_, response := test.ListWorkitemOK(t, ctx, nil, controller, spaceID, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+		_, response := test.ListWorkitemOK(t, ctx, nil, controller, spaceID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 		assertLink(t, "first", first, response.Links.First)
 		assertLink(t, "last", last, response.Links.Last)
 		assertLink(t, "prev", prev, response.Links.Prev)
@@ -716,6 +721,23 @@ func newChildIteration(ctx context.Context, db *gorm.DB, parentIteration *iterat
 	return &itr
 }
 
+func newChildArea(ctx context.Context, db *gorm.DB, parentArea *area.Area) *area.Area {
+	areaRepo := area.NewAreaRepository(db)
+
+	parentPath := append(parentArea.Path, parentArea.ID)
+	ar := area.Area{
+		Name:    "Area 52",
+		SpaceID: parentArea.SpaceID,
+		Path:    parentPath,
+	}
+	err := areaRepo.Create(ctx, &ar)
+	if err != nil {
+		fmt.Println("Failed to create area.")
+		return nil
+	}
+	return &ar
+}
+
 // ========== WorkItem2Suite struct that implements SetupSuite, TearDownSuite, SetupTest, TearDownTest ==========
 // a normal test function that will kick off WorkItem2Suite
 func TestSuiteWorkItem2(t *testing.T) {
@@ -760,8 +782,8 @@ func (s *WorkItem2Suite) SetupTest() {
 	require.Nil(s.T(), err)
 	s.priKey, _ = almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	s.svc = testsupport.ServiceAsUser("TestUpdateWI2-Service", almtoken.NewManagerWithPrivateKey(s.priKey), testIdentity)
-	s.wiCtrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
-	s.wi2Ctrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	s.wiCtrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
+	s.wi2Ctrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 	s.linkCatCtrl = NewWorkItemLinkCategoryController(s.svc, gormapplication.NewGormDB(s.DB))
 	s.linkTypeCtrl = NewWorkItemLinkTypeController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
 	s.linkCtrl = NewWorkItemLinkController(s.svc, gormapplication.NewGormDB(s.DB), s.Configuration)
@@ -1201,6 +1223,455 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWithAssigneesRelation() {
 	assert.Equal(s.T(), newUser3.ID.String(), *wiu.Data.Relationships.Assignees.Data[1].ID)
 }
 
+// assigneeCollaboratorConfiguration requires the work items to be assigned to the collaborators of their space only
+type assigneeCollaboratorConfiguration struct {
+	*configuration.ConfigurationData
+}
+
+func (c *assigneeCollaboratorConfiguration) IsWorkItemAssigneeCollaboratorRequired() bool {
+	return true
+}
+
+// createAssigneeCollaboratorSpace creates a space with the given collaborators and a controller which requires
+// the work items to be assigned to these collaborators, then returns the payload to create a work item in this space.
+func (s *WorkItem2Suite) createAssigneeCollaboratorSpace(collaborators ...account.Identity) (*WorkitemController, app.CreateWorkitemPayload) {
+	name := testsupport.CreateRandomValidTestName("TestAssigneeCollaborator-")
+	p := minimumRequiredCreateSpace()
+	p.Data.Attributes.Name = &name
+	_, sp := test.CreateSpaceCreated(s.T(), s.svc.Context, s.svc, s.spaceCtrl, p)
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &assigneeCollaboratorConfiguration{s.Configuration}, &testUsersPolicyManager{collaborators: collaborators})
+	c := minimumRequiredCreatePayload()
+	c.Data.Attributes[workitem.SystemTitle] = "Title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	c.Data.Relationships.BaseType = newRelationBaseType(*sp.Data.ID, workitem.SystemBug)
+	c.Data.Relationships.Space = app.NewSpaceRelation(*sp.Data.ID, rest.AbsoluteURL(&goa.RequestData{
+		Request: &http.Request{Host: "api.service.domain.org"},
+	}, app.SpaceHref(sp.Data.ID.String())))
+	return ctrl, c
+}
+
+func (s *WorkItem2Suite) TestWI2CreateWithCollaboratorAssigneeOK() {
+	// given
+	collaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace(*collaborator)
+	c.Data.Relationships.Assignees = &app.RelationGenericList{
+		Data: []*app.GenericData{
+			ident(collaborator.ID),
+		},
+	}
+	// when
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.Len(s.T(), wi.Data.Relationships.Assignees.Data, 1)
+	assert.Equal(s.T(), collaborator.ID.String(), *wi.Data.Relationships.Assignees.Data[0].ID)
+}
+
+func (s *WorkItem2Suite) TestWI2CreateWithTeamMemberAssigneeOK() {
+	// given an identity which collaborates on the space through a team only
+	member := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace()
+	teams := account.NewTeamRepository(s.DB)
+	team := account.Team{Name: testsupport.CreateRandomValidTestName("TestAssigneeCollaborator-")}
+	require.Nil(s.T(), teams.Create(s.svc.Context, &team))
+	require.Nil(s.T(), teams.AddMember(s.svc.Context, team.ID, member.ID))
+	require.Nil(s.T(), teams.AddToSpace(s.svc.Context, *c.Data.Relationships.Space.Data.ID, team.ID))
+	c.Data.Relationships.Assignees = &app.RelationGenericList{
+		Data: []*app.GenericData{
+			ident(member.ID),
+		},
+	}
+	// when
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.Len(s.T(), wi.Data.Relationships.Assignees.Data, 1)
+	assert.Equal(s.T(), member.ID.String(), *wi.Data.Relationships.Assignees.Data[0].ID)
+}
+
+// personalAccessTokenController returns a controller authenticated with a personal access token of the given identity,
+// whose space authorizations are checked by the Keycloak authz service
+func (s *WorkItem2Suite) personalAccessTokenController(identity account.Identity) (*goa.Service, *WorkitemController) {
+	db := gormapplication.NewGormDB(s.DB)
+	svc := goa.New("TestPersonalAccessToken-Service")
+	claims := jwt.MapClaims{
+		"sub":                             identity.ID.String(),
+		almtoken.PersonalAccessTokenClaim: uuid.NewV4().String(),
+	}
+	svc.Context = goajwt.WithJWT(svc.Context, &jwt.Token{Raw: account.PersonalAccessTokenPrefix + "test", Claims: claims, Valid: true})
+	svc.Context = tokencontext.ContextWithTokenManager(svc.Context, almtoken.NewManagerWithPrivateKey(s.priKey))
+	svc.Context = tokencontext.ContextWithSpaceAuthzService(svc.Context, &authz.KeycloakAuthzServiceManager{Service: authz.NewAuthzService(nil, db)})
+	return svc, NewWorkitemController(svc, db, s.Configuration, nil)
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateWithPersonalAccessTokenOK() {
+	// given a work item of a space whose cached collaborators include the owner of the token
+	collaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace()
+	spaceID := *c.Data.Relationships.Space.Data.ID
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, spaceID.String(), &c)
+	err := gormapplication.NewGormDB(s.DB).SpaceCollaborators().Replace(s.svc.Context, spaceID, []space.Collaborator{{IdentityID: collaborator.ID}})
+	require.Nil(s.T(), err)
+	svc, patCtrl := s.personalAccessTokenController(*collaborator)
+	payload := getMinimumRequiredUpdatePayload(wi.Data)
+	payload.Data.Attributes[workitem.SystemTitle] = "Updated with a personal access token"
+	// when
+	_, updated := test.UpdateWorkitemOK(s.T(), svc.Context, svc, patCtrl, spaceID.String(), *wi.Data.ID, payload)
+	// then
+	assert.Equal(s.T(), "Updated with a personal access token", updated.Data.Attributes[workitem.SystemTitle])
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateWithPersonalAccessTokenOfNonCollaboratorUnauthorized() {
+	// given a work item of a space whose cached collaborators don't include the owner of the token
+	other := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace()
+	spaceID := *c.Data.Relationships.Space.Data.ID
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, spaceID.String(), &c)
+	err := gormapplication.NewGormDB(s.DB).SpaceCollaborators().Replace(s.svc.Context, spaceID, []space.Collaborator{})
+	require.Nil(s.T(), err)
+	svc, patCtrl := s.personalAccessTokenController(*other)
+	payload := getMinimumRequiredUpdatePayload(wi.Data)
+	payload.Data.Attributes[workitem.SystemTitle] = "Updated with a personal access token"
+	// when/then
+	test.UpdateWorkitemUnauthorized(s.T(), svc.Context, svc, patCtrl, spaceID.String(), *wi.Data.ID, payload)
+}
+
+func (s *WorkItem2Suite) TestWI2CreateByViewerForbidden() {
+	// given a space whose cached collaborators list the owner of the token as a viewer
+	viewer := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	_, c := s.createAssigneeCollaboratorSpace()
+	spaceID := *c.Data.Relationships.Space.Data.ID
+	err := gormapplication.NewGormDB(s.DB).SpaceCollaborators().Replace(s.svc.Context, spaceID, []space.Collaborator{{IdentityID: viewer.ID, Role: auth.PolicyRoleViewer}})
+	require.Nil(s.T(), err)
+	svc, patCtrl := s.personalAccessTokenController(*viewer)
+	// when/then
+	test.CreateWorkitemForbidden(s.T(), svc.Context, svc, patCtrl, spaceID.String(), &c)
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateByViewerForbidden() {
+	// given a work item of a space whose cached collaborators list the owner of the token as a viewer
+	viewer := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace()
+	spaceID := *c.Data.Relationships.Space.Data.ID
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, spaceID.String(), &c)
+	err := gormapplication.NewGormDB(s.DB).SpaceCollaborators().Replace(s.svc.Context, spaceID, []space.Collaborator{{IdentityID: viewer.ID, Role: auth.PolicyRoleViewer}})
+	require.Nil(s.T(), err)
+	svc, patCtrl := s.personalAccessTokenController(*viewer)
+	payload := getMinimumRequiredUpdatePayload(wi.Data)
+	payload.Data.Attributes[workitem.SystemTitle] = "Updated by a viewer"
+	// when/then
+	test.UpdateWorkitemForbidden(s.T(), svc.Context, svc, patCtrl, spaceID.String(), *wi.Data.ID, payload)
+}
+
+func (s *WorkItem2Suite) TestWI2CreateWithNonCollaboratorAssigneeBadRequest() {
+	// given
+	collaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	nonCollaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace(*collaborator)
+	c.Data.Relationships.Assignees = &app.RelationGenericList{
+		Data: []*app.GenericData{
+			ident(collaborator.ID),
+			ident(nonCollaborator.ID),
+		},
+	}
+	// when/then
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateWithNonCollaboratorAssigneeBadRequest() {
+	// given a work item assigned to a collaborator
+	collaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	nonCollaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace(*collaborator)
+	c.Data.Relationships.Assignees = &app.RelationGenericList{
+		Data: []*app.GenericData{
+			ident(collaborator.ID),
+		},
+	}
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	update := minimumRequiredUpdatePayloadWithSpace(*c.Data.Relationships.Space.Data.ID)
+	update.Data.ID = wi.Data.ID
+	update.Data.Type = wi.Data.Type
+	update.Data.Attributes["version"] = wi.Data.Attributes["version"]
+	update.Data.Relationships = &app.WorkItemRelationships{
+		Assignees: &app.RelationGenericList{
+			Data: []*app.GenericData{
+				ident(collaborator.ID),
+				ident(nonCollaborator.ID),
+			},
+		},
+		Space: c.Data.Relationships.Space,
+	}
+	// when/then
+	test.UpdateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), *wi.Data.ID, &update)
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateKeepingNonCollaboratorAssigneeOK() {
+	// given a work item assigned to an identity which is no collaborator anymore
+	formerCollaborator := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	ctrl, c := s.createAssigneeCollaboratorSpace()
+	c.Data.Relationships.Assignees = &app.RelationGenericList{
+		Data: []*app.GenericData{
+			ident(formerCollaborator.ID),
+		},
+	}
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	update := minimumRequiredUpdatePayloadWithSpace(*c.Data.Relationships.Space.Data.ID)
+	update.Data.ID = wi.Data.ID
+	update.Data.Type = wi.Data.Type
+	update.Data.Attributes[workitem.SystemTitle] = "Updated title"
+	update.Data.Attributes["version"] = wi.Data.Attributes["version"]
+	// when
+	_, wiu := test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), *wi.Data.ID, &update)
+	// then
+	require.Len(s.T(), wiu.Data.Relationships.Assignees.Data, 1)
+	assert.Equal(s.T(), formerCollaborator.ID.String(), *wiu.Data.Relationships.Assignees.Data[0].ID)
+}
+
+// strictPayloadConfiguration rejects the work item payloads containing unknown attributes
+type strictPayloadConfiguration struct {
+	*configuration.ConfigurationData
+}
+
+func (c *strictPayloadConfiguration) IsWorkItemPayloadStrict() bool {
+	return true
+}
+
+func (s *WorkItem2Suite) TestWI2CreateWithUnknownAttributeStrictBadRequest() {
+	// given
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &strictPayloadConfiguration{s.Configuration}, nil)
+	c := minimumRequiredCreatePayload()
+	c.Data.Attributes[workitem.SystemTitle] = "Title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	c.Data.Attributes["system.titel"] = "Typo"
+	c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+	// when
+	_, jerrs := test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.NotNil(s.T(), jerrs)
+	require.Len(s.T(), jerrs.Errors, 1)
+	assert.Contains(s.T(), jerrs.Errors[0].Detail, "system.titel")
+}
+
+func (s *WorkItem2Suite) TestWI2CreateWithUnknownAttributeLenientOK() {
+	// given
+	c := minimumRequiredCreatePayload()
+	c.Data.Attributes[workitem.SystemTitle] = "Title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	c.Data.Attributes["system.titel"] = "Typo"
+	c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+	// when
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.NotNil(s.T(), wi.Data)
+	assert.Equal(s.T(), "Title", wi.Data.Attributes[workitem.SystemTitle])
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateWithReturnedAttributesStrictOK() {
+	// given a work item payload sent back as returned by the API
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &strictPayloadConfiguration{s.Configuration}, nil)
+	c := minimumRequiredCreatePayload()
+	c.Data.Attributes[workitem.SystemTitle] = "Title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	c.Data.Attributes[workitem.SystemDescription] = "Description"
+	c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	update := minimumRequiredUpdatePayload()
+	update.Data.ID = wi.Data.ID
+	update.Data.Type = wi.Data.Type
+	update.Data.Attributes = wi.Data.Attributes
+	update.Data.Attributes[workitem.SystemTitle] = "Updated title"
+	// when
+	_, wiu := test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), *wi.Data.ID, &update)
+	// then
+	assert.Equal(s.T(), "Updated title", wiu.Data.Attributes[workitem.SystemTitle])
+}
+
+// closedIterationConfiguration handles the assignments to closed or past iterations with the given mode
+type closedIterationConfiguration struct {
+	*configuration.ConfigurationData
+	mode string
+}
+
+func (c *closedIterationConfiguration) GetWorkItemClosedIterationAssignment() string {
+	return c.mode
+}
+
+// createPastIteration creates an iteration whose end date has passed
+func (s *WorkItem2Suite) createPastIteration() *iteration.Iteration {
+	itr := createOneRandomIteration(s.svc.Context, s.DB)
+	require.NotNil(s.T(), itr)
+	endAt := time.Now().Add(-24 * time.Hour)
+	itr.EndAt = &endAt
+	itr, err := iteration.NewIterationRepository(s.DB).Save(s.svc.Context, *itr)
+	require.Nil(s.T(), err)
+	return itr
+}
+
+func newIterationCreatePayload(iterationID string) app.CreateWorkitemPayload {
+	c := minimumRequiredCreatePayload()
+	c.Data.Attributes[workitem.SystemTitle] = "Title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+	c.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			ID: &iterationID,
+		},
+	}
+	return c
+}
+
+func (s *WorkItem2Suite) TestWI2CreateInPastIterationAllowedByDefault() {
+	// given
+	c := newIterationCreatePayload(s.createPastIteration().ID.String())
+	// when
+	res, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.NotNil(s.T(), wi.Data)
+	assert.Empty(s.T(), res.Header().Get("Warning"))
+}
+
+func (s *WorkItem2Suite) TestWI2CreateInPastIterationWarning() {
+	// given
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &closedIterationConfiguration{s.Configuration, ClosedIterationAssignmentWarn}, nil)
+	itr := s.createPastIteration()
+	c := newIterationCreatePayload(itr.ID.String())
+	// when
+	res, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	// then
+	require.NotNil(s.T(), wi.Data)
+	assert.Equal(s.T(), itr.ID.String(), *wi.Data.Relationships.Iteration.Data.ID)
+	assert.Contains(s.T(), res.Header().Get("Warning"), "past its end date")
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateToClosedIterationWarning() {
+	// given
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &closedIterationConfiguration{s.Configuration, ClosedIterationAssignmentWarn}, nil)
+	c := newIterationCreatePayload(createOneRandomIteration(s.svc.Context, s.DB).ID.String())
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	closed := createOneRandomIteration(s.svc.Context, s.DB)
+	closed.State = iteration.IterationStateClose
+	closed, err := iteration.NewIterationRepository(s.DB).Save(s.svc.Context, *closed)
+	require.Nil(s.T(), err)
+	closedID := closed.ID.String()
+	u := minimumRequiredUpdatePayload()
+	u.Data.ID = wi.Data.ID
+	u.Data.Attributes["version"] = wi.Data.Attributes["version"]
+	u.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			ID: &closedID,
+		},
+	}
+	// when
+	res, _ := test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), *wi.Data.ID, &u)
+	// then
+	assert.Contains(s.T(), res.Header().Get("Warning"), "closed")
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateToPastIterationStrictBadRequest() {
+	// given
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &closedIterationConfiguration{s.Configuration, ClosedIterationAssignmentReject}, nil)
+	c := newIterationCreatePayload(createOneRandomIteration(s.svc.Context, s.DB).ID.String())
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+	pastID := s.createPastIteration().ID.String()
+	u := minimumRequiredUpdatePayload()
+	u.Data.ID = wi.Data.ID
+	u.Data.Attributes["version"] = wi.Data.Attributes["version"]
+	u.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			ID: &pastID,
+		},
+	}
+	// when
+	_, jerrs := test.UpdateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, ctrl, c.Data.Relationships.Space.Data.ID.String(), *wi.Data.ID, &u)
+	// then
+	require.NotNil(s.T(), jerrs)
+	require.Len(s.T(), jerrs.Errors, 1)
+	assert.Contains(s.T(), jerrs.Errors[0].Detail, pastID)
+}
+
+// duplicateAutoCloseConfiguration closes the work items marked as duplicate
+type duplicateAutoCloseConfiguration struct {
+	*configuration.ConfigurationData
+}
+
+func (c *duplicateAutoCloseConfiguration) IsWorkItemDuplicateAutoClosed() bool {
+	return true
+}
+
+// createDuplicateCandidates bootstraps the system link types and creates two bugs:
+// the canonical work item and its duplicate
+func (s *WorkItem2Suite) createDuplicateCandidates() (app.WorkItemSingle, app.WorkItemSingle) {
+	err := migration.BootstrapWorkItemLinking(s.svc.Context, link.NewWorkItemLinkCategoryRepository(s.DB), space.NewRepository(s.DB), link.NewWorkItemLinkTypeRepository(s.DB))
+	require.Nil(s.T(), err)
+	var wis []app.WorkItemSingle
+	for _, title := range []string{"Canonical", "Duplicate"} {
+		c := minimumRequiredCreateWithType(workitem.SystemBug)
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), &c)
+		wis = append(wis, *wi)
+	}
+	return wis[0], wis[1]
+}
+
+// assertDuplicateOfLink asserts that the duplicate and the canonical work items are linked with a "duplicate of" link
+func (s *WorkItem2Suite) assertDuplicateOfLink(duplicateID, canonicalID string) {
+	links, err := link.NewWorkItemLinkRepository(s.DB).ListByWorkItemID(s.svc.Context, duplicateID)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), links, 1)
+	linkType, err := link.NewWorkItemLinkTypeRepository(s.DB).LoadByID(s.svc.Context, links[0].LinkTypeID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), link.SystemWorkItemLinkTypeDuplicateOf, linkType.Name)
+	assert.Equal(s.T(), duplicateID, strconv.FormatUint(links[0].SourceID, 10))
+	assert.Equal(s.T(), canonicalID, strconv.FormatUint(links[0].TargetID, 10))
+}
+
+func (s *WorkItem2Suite) TestWI2MarkDuplicateAutoCloseOK() {
+	// given
+	ctrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), &duplicateAutoCloseConfiguration{s.Configuration}, nil)
+	canonical, duplicate := s.createDuplicateCandidates()
+	// when
+	_, wi := test.MarkDuplicateWorkitemOK(s.T(), s.svc.Context, s.svc, ctrl, space.SystemSpace.String(), *duplicate.Data.ID, *canonical.Data.ID)
+	// then
+	require.NotNil(s.T(), wi.Data)
+	assert.Equal(s.T(), workitem.SystemStateClosed, wi.Data.Attributes[workitem.SystemState])
+	s.assertDuplicateOfLink(*duplicate.Data.ID, *canonical.Data.ID)
+	// the canonical work item is left untouched
+	_, canonicalAfter := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, ctrl, space.SystemSpace.String(), *canonical.Data.ID, nil, nil)
+	assert.Equal(s.T(), workitem.SystemStateNew, canonicalAfter.Data.Attributes[workitem.SystemState])
+}
+
+func (s *WorkItem2Suite) TestWI2MarkDuplicateWithoutAutoCloseOK() {
+	// given
+	canonical, duplicate := s.createDuplicateCandidates()
+	// when
+	_, wi := test.MarkDuplicateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), *duplicate.Data.ID, *canonical.Data.ID)
+	// then
+	require.NotNil(s.T(), wi.Data)
+	assert.Equal(s.T(), workitem.SystemStateNew, wi.Data.Attributes[workitem.SystemState])
+	s.assertDuplicateOfLink(*duplicate.Data.ID, *canonical.Data.ID)
+}
+
+func (s *WorkItem2Suite) TestWI2MarkDuplicateOfItselfBadRequest() {
+	// given
+	_, duplicate := s.createDuplicateCandidates()
+	// when
+	_, jerrs := test.MarkDuplicateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), *duplicate.Data.ID, *duplicate.Data.ID)
+	// then
+	require.NotNil(s.T(), jerrs)
+	links, err := link.NewWorkItemLinkRepository(s.DB).ListByWorkItemID(s.svc.Context, *duplicate.Data.ID)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), links)
+}
+
+func (s *WorkItem2Suite) TestWI2MarkDuplicateUnknownCanonicalNotFound() {
+	// given
+	_, duplicate := s.createDuplicateCandidates()
+	// when
+	_, jerrs := test.MarkDuplicateWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), *duplicate.Data.ID, "2147483647")
+	// then
+	require.NotNil(s.T(), jerrs)
+}
+
 func (s *WorkItem2Suite) TestWI2ListByAssigneeFilter() {
 	// given
 	newUser := createOneRandomUserIdentity(s.svc.Context, s.DB)
@@ -1223,12 +1694,158 @@ func (s *WorkItem2Suite) TestWI2ListByAssigneeFilter() {
 	assert.Len(s.T(), wi.Data.Relationships.Assignees.Data, 1)
 	assert.Equal(s.T(), newUser.ID.String(), *wi.Data.Relationships.Assignees.Data[0].ID)
 	newUserID := newUser.ID.String()
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, &newUserID, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, &newUserID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Len(s.T(), list.Data, 1)
 	assert.Equal(s.T(), newUser.ID.String(), *list.Data[0].Relationships.Assignees.Data[0].ID)
 	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[assignee]"))
 }
 
+func (s *WorkItem2Suite) TestWI2ListByUnassignedFilter() {
+	// given
+	newUser := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	title := "Unassigned " + uuid.NewV4().String()
+	for _, assignees := range [][]*app.GenericData{{ident(newUser.ID)}, nil, nil} {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		if assignees != nil {
+			c.Data.Relationships.Assignees = &app.RelationGenericList{Data: assignees}
+		}
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+	}
+	filter := fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title)
+	unassigned := FilterAssigneeNone
+	// when
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &filter, nil, &unassigned, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), list.Data, 2)
+	for _, wi := range list.Data {
+		if wi.Relationships.Assignees != nil {
+			assert.Empty(s.T(), wi.Relationships.Assignees.Data)
+		}
+	}
+	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[assignee]=none"))
+}
+
+func (s *WorkItem2Suite) TestWI2ListByDeactivatedAssigneeFilter() {
+	// given
+	activeUser := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	deactivatedUser := createOneRandomUserIdentity(s.svc.Context, s.DB)
+	title := "Deactivated assignee " + uuid.NewV4().String()
+	for _, assignees := range [][]*app.GenericData{{ident(activeUser.ID)}, {ident(deactivatedUser.ID)}, {ident(deactivatedUser.ID)}, nil} {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		if assignees != nil {
+			c.Data.Relationships.Assignees = &app.RelationGenericList{Data: assignees}
+		}
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+	}
+	deactivatedAt := time.Now()
+	deactivatedUser.DeactivatedAt = &deactivatedAt
+	err := account.NewIdentityRepository(s.DB).Save(s.svc.Context, deactivatedUser)
+	require.Nil(s.T(), err)
+	filter := fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title)
+	deactivated := FilterAssigneeDeactivated
+	// when
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &filter, nil, &deactivated, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), list.Data, 2)
+	for _, wi := range list.Data {
+		require.Len(s.T(), wi.Relationships.Assignees.Data, 1)
+		assert.Equal(s.T(), deactivatedUser.ID.String(), *wi.Relationships.Assignees.Data[0].ID)
+	}
+	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[assignee]=deactivated"))
+}
+
+// createUnsortedWorkItems creates 3 work items in a new space and removes the iteration of the first one
+// and the area of the second one
+func (s *WorkItem2Suite) createUnsortedWorkItems(name string) (uuid.UUID, []*app.WorkItem) {
+	spaceID := s.createTransferSpace(name)
+	var workItems []*app.WorkItem
+	for i := 0; i < 3; i++ {
+		c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+		c.Data.Attributes[workitem.SystemTitle] = fmt.Sprintf("%s %d", name, i)
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+		workItems = append(workItems, wi.Data)
+	}
+	for i, field := range []string{workitem.SystemIteration, workitem.SystemArea} {
+		err := s.DB.Exec("UPDATE work_items SET fields = fields - ? WHERE id = ?", field, *workItems[i].ID).Error
+		require.Nil(s.T(), err)
+	}
+	return spaceID, workItems
+}
+
+func (s *WorkItem2Suite) TestWI2ListByNoIterationFilter() {
+	// given
+	spaceID, workItems := s.createUnsortedWorkItems("TestWI2ListByNoIterationFilter")
+	noIteration := FilterIterationNone
+	// when
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), nil, nil, nil, nil, nil, nil, &noIteration, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), list.Data, 1)
+	assert.Equal(s.T(), *workItems[0].ID, *list.Data[0].ID)
+	assert.Nil(s.T(), list.Data[0].Relationships.Iteration)
+	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[iteration]=none"))
+}
+
+func (s *WorkItem2Suite) TestWI2ListByNoAreaFilter() {
+	// given
+	spaceID, workItems := s.createUnsortedWorkItems("TestWI2ListByNoAreaFilter")
+	noArea := FilterAreaNone
+	// when
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), nil, &noArea, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	require.Len(s.T(), list.Data, 1)
+	assert.Equal(s.T(), *workItems[1].ID, *list.Data[0].ID)
+	assert.Nil(s.T(), list.Data[0].Relationships.Area)
+	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[area]=none"))
+}
+
+func (s *WorkItem2Suite) TestWI2ListByCreationDateFilter() {
+	// given
+	title := "Created " + uuid.NewV4().String()
+	createdAt := make([]time.Time, 3)
+	for i := range createdAt {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+		// read the creation time as stored in the DB
+		var storage workitem.WorkItemStorage
+		require.Nil(s.T(), s.DB.Where("id = ?", *wi.Data.ID).First(&storage).Error)
+		createdAt[i] = storage.CreatedAt
+	}
+	filter := fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title)
+
+	s.T().Run("bounds are inclusive", func(t *testing.T) {
+		// when
+		_, list := test.ListWorkitemOK(t, s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &filter, nil, nil, &createdAt[0], &createdAt[1], nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		// then
+		assert.Len(t, list.Data, 2)
+		assert.True(t, strings.Contains(*list.Links.First, "filter[createdfrom]"))
+		assert.True(t, strings.Contains(*list.Links.First, "filter[createdto]"))
+	})
+
+	s.T().Run("single bound", func(t *testing.T) {
+		// when
+		_, list := test.ListWorkitemOK(t, s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &filter, nil, nil, &createdAt[2], nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		// then
+		assert.Len(t, list.Data, 1)
+	})
+
+	s.T().Run("empty range", func(t *testing.T) {
+		// when
+		_, list := test.ListWorkitemOK(t, s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &filter, nil, nil, &createdAt[2], &createdAt[0], nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		// then
+		assert.Empty(t, list.Data)
+	})
+}
+
 func (s *WorkItem2Suite) TestWI2ListByWorkitemtypeFilter() {
 	// given
 	c := minimumRequiredCreatePayload()
@@ -1241,7 +1858,7 @@ func (s *WorkItem2Suite) TestWI2ListByWorkitemtypeFilter() {
 	assert.NotNil(s.T(), expected.Data)
 	require.NotNil(s.T(), expected.Data.ID)
 	require.NotNil(s.T(), expected.Data.Type)
-	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, &workitem.SystemBug, nil, nil, nil, nil)
+	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, &workitem.SystemBug, nil, nil, nil, nil)
 	require.NotNil(s.T(), actual)
 	require.True(s.T(), len(actual.Data) > 1)
 	assert.Contains(s.T(), *actual.Links.First, fmt.Sprintf("filter[workitemtype]=%s", workitem.SystemBug))
@@ -1274,7 +1891,7 @@ func (s *WorkItem2Suite) TestWI2ListByWorkitemstateFilter() {
 	dataArray = append(dataArray, expected)
 	wiNew := workitem.SystemStateNew
 	// var foundExpected bool
-	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, nil, nil, nil, &wiNew, nil, nil, nil, nil, nil)
+	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, nil, nil, nil, nil, nil, nil, &wiNew, nil, nil, nil, nil, nil)
 
 	require.NotNil(s.T(), actual)
 	require.True(s.T(), len(actual.Data) > 1)
@@ -1326,17 +1943,513 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilterOK() {
 	// given
 	spaceID, areaID, _ := s.setupAreaWorkItem(true)
 	// when
-	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	// then
 	assertAreaWorkItems(s.T(), areaID, workitems)
 	assertResponseHeaders(s.T(), res)
 }
 
+func (s *WorkItem2Suite) TestWI2ListByExcludedAreaFilterOK() {
+	// given
+	spaceID, areaID, _ := s.setupAreaWorkItem(true)
+	parentArea, err := area.NewAreaRepository(s.DB).Load(s.svc.Context, uuid.FromStringOrNil(areaID))
+	require.Nil(s.T(), err)
+	childArea := newChildArea(s.svc.Context, s.DB, parentArea)
+	require.NotNil(s.T(), childArea)
+	grandChildArea := newChildArea(s.svc.Context, s.DB, childArea)
+	require.NotNil(s.T(), grandChildArea)
+	otherArea := createOneRandomArea(s.svc.Context, s.DB, "TestWI2ListByExcludedAreaFilter")
+	require.NotNil(s.T(), otherArea)
+	for _, ar := range []*area.Area{childArea, grandChildArea, otherArea} {
+		arID := ar.ID.String()
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = "Title"
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		c.Data.Relationships.Area = &app.RelationGeneric{
+			Data: &app.GenericData{
+				ID: &arID,
+			},
+		}
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, &c)
+	}
+	_, all := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	// when
+	_, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, nil, nil, nil, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil)
+	// then
+	excluded := map[string]bool{
+		areaID:                     true,
+		childArea.ID.String():      true,
+		grandChildArea.ID.String(): true,
+	}
+	require.NotNil(s.T(), workitems.Meta)
+	assert.Equal(s.T(), all.Meta.TotalCount-3, workitems.Meta.TotalCount)
+	for _, wi := range workitems.Data {
+		if wi.Relationships.Area != nil && wi.Relationships.Area.Data != nil {
+			assert.False(s.T(), excluded[*wi.Relationships.Area.Data.ID], "work item %s is in an excluded area", *wi.ID)
+		}
+	}
+	assert.True(s.T(), strings.Contains(*workitems.Links.First, "filter[excludearea]"))
+}
+
+func (s *WorkItem2Suite) TestWI2ListByExcludedAreaFilterBadRequest() {
+	// given
+	spaceID := space.SystemSpace.String()
+	unknownAreaID := uuid.NewV4().String()
+	// when/then
+	test.ListWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, nil, nil, nil, nil, &unknownAreaID, nil, nil, nil, nil, nil, nil, nil, nil)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkUpdateAreaOK() {
+	// given
+	rootArea, err := area.NewAreaRepository(s.DB).Root(s.svc.Context, space.SystemSpace)
+	require.Nil(s.T(), err)
+	targetArea := newChildArea(s.svc.Context, s.DB, rootArea)
+	require.NotNil(s.T(), targetArea)
+	title := "Bulk Update " + uuid.NewV4().String()
+	for _, t := range []string{title, title, title, "Not Moved"} {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = t
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+	}
+	filter := fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title)
+	payload := app.BulkUpdateWorkitemPayload{
+		Filter: filter,
+		Area:   &targetArea.ID,
+	}
+	// when
+	_, result := test.BulkUpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+	// then
+	assert.Equal(s.T(), 3, result.Updated)
+	assert.Empty(s.T(), result.Failed)
+	targetAreaID := targetArea.ID.String()
+	_, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, &targetAreaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	require.Len(s.T(), workitems.Data, 3)
+	for _, wi := range workitems.Data {
+		assert.Equal(s.T(), title, wi.Attributes[workitem.SystemTitle])
+		assert.Equal(s.T(), targetAreaID, *wi.Relationships.Area.Data.ID)
+	}
+}
+
+func (s *WorkItem2Suite) TestWI2BulkUpdateWithoutTargetBadRequest() {
+	// given
+	payload := app.BulkUpdateWorkitemPayload{
+		Filter: fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, "Test WI"),
+	}
+	// when/then
+	test.BulkUpdateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkUpdateAreaOfOtherSpaceBadRequest() {
+	// given
+	otherArea := createOneRandomArea(s.svc.Context, s.DB, "TestWI2BulkUpdateAreaOfOtherSpace")
+	require.NotNil(s.T(), otherArea)
+	payload := app.BulkUpdateWorkitemPayload{
+		Filter: fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, "Test WI"),
+		Area:   &otherArea.ID,
+	}
+	// when/then
+	test.BulkUpdateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkSetDueDateOK() {
+	// given
+	title := "Bulk Due Date " + uuid.NewV4().String()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+		ids = append(ids, *wi.Data.ID)
+	}
+	dueDate := time.Date(2030, time.March, 31, 17, 0, 0, 0, time.UTC)
+	payload := app.BulkSetDueDateWorkitemPayload{
+		Filter:  fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title),
+		DueDate: dueDate,
+	}
+	// when
+	_, result := test.BulkSetDueDateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+	// then
+	assert.Equal(s.T(), 3, result.Updated)
+	assert.Empty(s.T(), result.Failed)
+	for _, id := range ids {
+		_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), id, nil, nil)
+		require.IsType(s.T(), time.Time{}, wi.Data.Attributes[workitem.SystemDueDate])
+		assert.True(s.T(), dueDate.Equal(wi.Data.Attributes[workitem.SystemDueDate].(time.Time)))
+	}
+	// the default work item does not match the filter and is left untouched
+	_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), *s.wi.ID, nil, nil)
+	assert.Nil(s.T(), wi.Data.Attributes[workitem.SystemDueDate])
+}
+
+func (s *WorkItem2Suite) TestWI2BulkSetDueDateInvalidFilterBadRequest() {
+	// given
+	payload := app.BulkSetDueDateWorkitemPayload{
+		Filter:  "not a filter",
+		DueDate: time.Now(),
+	}
+	// when/then
+	test.BulkSetDueDateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkSetStateOK() {
+	// given
+	title := "Bulk State " + uuid.NewV4().String()
+	ids := map[string]string{}
+	for _, st := range []string{workitem.SystemStateNew, workitem.SystemStateOpen, workitem.SystemStateClosed} {
+		c := minimumRequiredCreatePayload()
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = st
+		c.Data.Relationships.BaseType = newRelationBaseType(space.SystemSpace, workitem.SystemBug)
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &c)
+		ids[st] = *wi.Data.ID
+	}
+	payload := app.BulkSetStateWorkitemPayload{
+		Filter: fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, title),
+		State:  workitem.SystemStateClosed,
+	}
+	// when
+	_, result := test.BulkSetStateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+	// then
+	require.Len(s.T(), result.Updated, 2)
+	assert.Contains(s.T(), result.Updated, ids[workitem.SystemStateNew])
+	assert.Contains(s.T(), result.Updated, ids[workitem.SystemStateOpen])
+	require.Len(s.T(), result.Skipped, 1)
+	assert.Equal(s.T(), ids[workitem.SystemStateClosed], result.Skipped[0].ID)
+	for _, id := range ids {
+		_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), id, nil, nil)
+		assert.Equal(s.T(), workitem.SystemStateClosed, wi.Data.Attributes[workitem.SystemState])
+	}
+	// the default work item does not match the filter and is left untouched
+	_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), *s.wi.ID, nil, nil)
+	assert.Equal(s.T(), workitem.SystemStateNew, wi.Data.Attributes[workitem.SystemState])
+}
+
+func (s *WorkItem2Suite) TestWI2BulkSetStateNotAllowedByTypeSkipped() {
+	// given
+	payload := app.BulkSetStateWorkitemPayload{
+		Filter: fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, "Test WI"),
+		State:  "not-a-state",
+	}
+	// when
+	_, result := test.BulkSetStateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+	// then
+	assert.Empty(s.T(), result.Updated)
+	require.Len(s.T(), result.Skipped, 1)
+	assert.Equal(s.T(), *s.wi.ID, result.Skipped[0].ID)
+	assert.Contains(s.T(), result.Skipped[0].Reason, "not-a-state")
+}
+
+func (s *WorkItem2Suite) TestWI2BulkSetStateInvalidFilterBadRequest() {
+	// given
+	payload := app.BulkSetStateWorkitemPayload{
+		Filter: "not a filter",
+		State:  workitem.SystemStateClosed,
+	}
+	// when/then
+	test.BulkSetStateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+}
+
+func (s *WorkItem2Suite) createTransferSpace(name string) uuid.UUID {
+	spacePayload := CreateSpacePayload(name+uuid.NewV4().String(), "description")
+	_, sp := test.CreateSpaceCreated(s.T(), s.svc.Context, s.svc, s.spaceCtrl, spacePayload)
+	require.NotNil(s.T(), sp)
+	return *sp.Data.ID
+}
+
+func (s *WorkItem2Suite) TestWI2TransferOK() {
+	// given
+	sourceSpaceID := s.createTransferSpace("TestWI2TransferOK-source")
+	targetSpaceID := s.createTransferSpace("TestWI2TransferOK-target")
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, sourceSpaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Transferred WI"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &c)
+	require.NotNil(s.T(), wi)
+	payload := app.TransferWorkitemPayload{
+		Space:     targetSpaceID,
+		Workitems: []string{*wi.Data.ID},
+	}
+	// when
+	_, result := test.TransferWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &payload)
+	// then
+	assert.Equal(s.T(), []string{*wi.Data.ID}, result.Moved)
+	assert.Empty(s.T(), result.Failed)
+	assert.Empty(s.T(), result.Fallbacks)
+	_, moved := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, targetSpaceID.String(), *wi.Data.ID, nil, nil)
+	require.NotNil(s.T(), moved)
+	assert.Equal(s.T(), targetSpaceID, *moved.Data.Relationships.Space.Data.ID)
+	rootArea, err := area.NewAreaRepository(s.DB).Root(s.svc.Context, targetSpaceID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), rootArea.ID.String(), *moved.Data.Relationships.Area.Data.ID)
+	rootIteration, err := iteration.NewIterationRepository(s.DB).Root(s.svc.Context, targetSpaceID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), rootIteration.ID.String(), *moved.Data.Relationships.Iteration.Data.ID)
+	test.ShowWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), *wi.Data.ID, nil, nil)
+}
+
+func (s *WorkItem2Suite) TestWI2TransferAreaMappedByPath() {
+	// given an area of the source space whose name, but not path, is found in the target space
+	sourceSpaceID := s.createTransferSpace("TestWI2TransferAreaMappedByPath-source")
+	targetSpaceID := s.createTransferSpace("TestWI2TransferAreaMappedByPath-target")
+	areaRepo := area.NewAreaRepository(s.DB)
+	sourceRootArea, err := areaRepo.Root(s.svc.Context, sourceSpaceID)
+	require.Nil(s.T(), err)
+	sourceArea := newChildArea(s.svc.Context, s.DB, newChildArea(s.svc.Context, s.DB, sourceRootArea))
+	require.NotNil(s.T(), sourceArea)
+	targetRootArea, err := areaRepo.Root(s.svc.Context, targetSpaceID)
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), newChildArea(s.svc.Context, s.DB, targetRootArea))
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, sourceSpaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Transferred WI"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	arType := area.APIStringTypeAreas
+	sourceAreaID := sourceArea.ID.String()
+	c.Data.Relationships.Area = &app.RelationGeneric{
+		Data: &app.GenericData{
+			Type: &arType,
+			ID:   &sourceAreaID,
+		},
+	}
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &c)
+	require.NotNil(s.T(), wi)
+	payload := app.TransferWorkitemPayload{
+		Space:     targetSpaceID,
+		Workitems: []string{*wi.Data.ID},
+	}
+	// when
+	_, result := test.TransferWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &payload)
+	// then the work item is moved to the root area of the target space, and the fallback is reported
+	assert.Equal(s.T(), []string{*wi.Data.ID}, result.Moved)
+	require.Len(s.T(), result.Fallbacks, 1)
+	assert.Equal(s.T(), *wi.Data.ID, result.Fallbacks[0].ID)
+	assert.Contains(s.T(), result.Fallbacks[0].Reason, "/Area 52/Area 52")
+	_, moved := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, targetSpaceID.String(), *wi.Data.ID, nil, nil)
+	require.NotNil(s.T(), moved)
+	assert.Equal(s.T(), targetRootArea.ID.String(), *moved.Data.Relationships.Area.Data.ID)
+}
+
+func (s *WorkItem2Suite) TestWI2TransferIncompatibleType() {
+	// given
+	sourceSpaceID := s.createTransferSpace("TestWI2TransferIncompatibleType-source")
+	targetSpaceID := s.createTransferSpace("TestWI2TransferIncompatibleType-target")
+	wit, err := workitem.NewWorkItemTypeRepository(s.DB).Create(s.svc.Context, sourceSpaceID, nil, &workitem.SystemPlannerItem, "source-only type", nil, "fa-bomb", map[string]workitem.FieldDefinition{})
+	require.Nil(s.T(), err)
+	c := minimumRequiredCreateWithTypeAndSpace(wit.ID, sourceSpaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Not transferred WI"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &c)
+	require.NotNil(s.T(), wi)
+	payload := app.TransferWorkitemPayload{
+		Space:     targetSpaceID,
+		Workitems: []string{*wi.Data.ID},
+	}
+	// when
+	_, result := test.TransferWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &payload)
+	// then
+	assert.Empty(s.T(), result.Moved)
+	require.Len(s.T(), result.Failed, 1)
+	assert.Equal(s.T(), *wi.Data.ID, result.Failed[0].ID)
+	assert.Contains(s.T(), result.Failed[0].Reason, wit.ID.String())
+	test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), *wi.Data.ID, nil, nil)
+}
+
+func (s *WorkItem2Suite) TestWI2TransferToSameSpaceBadRequest() {
+	// given
+	payload := app.TransferWorkitemPayload{
+		Space:     space.SystemSpace,
+		Workitems: []string{*s.wi.ID},
+	}
+	// when/then
+	test.TransferWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), &payload)
+}
+
+func (s *WorkItem2Suite) TestWI2ShowByNumberOK() {
+	// given
+	spaceID := s.createTransferSpace("TestWI2ShowByNumberOK")
+	var created []*app.WorkItemSingle
+	for _, title := range []string{"First", "Second"} {
+		c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+		c.Data.Attributes[workitem.SystemTitle] = title
+		c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+		_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+		created = append(created, wi)
+	}
+	assert.Equal(s.T(), 1, created[0].Data.Attributes["number"])
+	assert.Equal(s.T(), 2, created[1].Data.Attributes["number"])
+	// when
+	_, wi := test.ShowByNumberWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), 2, nil, nil)
+	// then
+	require.NotNil(s.T(), wi)
+	assert.Equal(s.T(), *created[1].Data.ID, *wi.Data.ID)
+	assert.Equal(s.T(), "Second", wi.Data.Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), 2, wi.Data.Attributes["number"])
+}
+
+func (s *WorkItem2Suite) TestWI2ShowByNumberNotFound() {
+	// given
+	spaceID := s.createTransferSpace("TestWI2ShowByNumberNotFound")
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Only"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+	// when/then
+	test.ShowByNumberWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), 2, nil, nil)
+}
+
+func (s *WorkItem2Suite) createUniqueTitlesSpace(name string, uniqueTitles bool) uuid.UUID {
+	spacePayload := CreateSpacePayload(name+uuid.NewV4().String(), "description")
+	spacePayload.Data.Attributes.UniqueTitlesPerIteration = &uniqueTitles
+	_, sp := test.CreateSpaceCreated(s.T(), s.svc.Context, s.svc, s.spaceCtrl, spacePayload)
+	require.NotNil(s.T(), sp)
+	return *sp.Data.ID
+}
+
+func (s *WorkItem2Suite) TestWI2CreateDuplicateTitleInIterationConflict() {
+	// given
+	spaceID := s.createUniqueTitlesSpace("TestWI2CreateDuplicateTitleInIterationConflict", true)
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Same title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+	// when
+	d := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	d.Data.Attributes[workitem.SystemTitle] = "Same title"
+	d.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	// then
+	test.CreateWorkitemConflict(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &d)
+}
+
+func (s *WorkItem2Suite) TestWI2CreateDuplicateTitleInIterationAllowedByDefault() {
+	// given
+	spaceID := s.createUniqueTitlesSpace("TestWI2CreateDuplicateTitleInIterationAllowedByDefault", false)
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Same title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+	// when
+	d := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	d.Data.Attributes[workitem.SystemTitle] = "Same title"
+	d.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &d)
+	// then
+	require.NotNil(s.T(), wi)
+	assert.Equal(s.T(), "Same title", wi.Data.Attributes[workitem.SystemTitle])
+}
+
+func (s *WorkItem2Suite) TestWI2UpdateMoveToIterationWithDuplicateTitleConflict() {
+	// given
+	spaceID := s.createUniqueTitlesSpace("TestWI2UpdateMoveToIterationWithDuplicateTitleConflict", true)
+	rootIteration, err := iteration.NewIterationRepository(s.DB).Root(s.svc.Context, spaceID)
+	require.Nil(s.T(), err)
+	childIteration := newChildIteration(s.svc.Context, s.DB, rootIteration)
+	require.NotNil(s.T(), childIteration)
+	childIterationID := childIteration.ID.String()
+	itType := iteration.APIStringTypeIteration
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Same title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+	// a work item with the same title in another iteration is accepted
+	d := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	d.Data.Attributes[workitem.SystemTitle] = "Same title"
+	d.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	d.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			Type: &itType,
+			ID:   &childIterationID,
+		},
+	}
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &d)
+	require.NotNil(s.T(), wi)
+	// when
+	rootIterationID := rootIteration.ID.String()
+	u := minimumRequiredUpdatePayloadWithSpace(spaceID)
+	u.Data.ID = wi.Data.ID
+	u.Data.Attributes["version"] = wi.Data.Attributes["version"]
+	u.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			Type: &itType,
+			ID:   &rootIterationID,
+		},
+	}
+	// then
+	test.UpdateWorkitemConflict(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), *wi.Data.ID, &u)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkUpdateToIterationWithDuplicateTitleFailed() {
+	// given
+	spaceID := s.createUniqueTitlesSpace("TestWI2BulkUpdateToIterationWithDuplicateTitleFailed", true)
+	rootIteration, err := iteration.NewIterationRepository(s.DB).Root(s.svc.Context, spaceID)
+	require.Nil(s.T(), err)
+	childIteration := newChildIteration(s.svc.Context, s.DB, rootIteration)
+	require.NotNil(s.T(), childIteration)
+	childIterationID := childIteration.ID.String()
+	targetIteration := newChildIteration(s.svc.Context, s.DB, rootIteration)
+	require.NotNil(s.T(), targetIteration)
+	itType := iteration.APIStringTypeIteration
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Same title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &c)
+	d := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, spaceID)
+	d.Data.Attributes[workitem.SystemTitle] = "Same title"
+	d.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	d.Data.Relationships.Iteration = &app.RelationGeneric{
+		Data: &app.GenericData{
+			Type: &itType,
+			ID:   &childIterationID,
+		},
+	}
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &d)
+	payload := app.BulkUpdateWorkitemPayload{
+		Filter:    fmt.Sprintf(`{"%s":"%s"}`, workitem.SystemTitle, "Same title"),
+		Iteration: &targetIteration.ID,
+	}
+	// when
+	_, result := test.BulkUpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID.String(), &payload)
+	// then the first work item is moved, but not the second one
+	assert.Equal(s.T(), 1, result.Updated)
+	require.Len(s.T(), result.Failed, 1)
+	assert.Contains(s.T(), result.Failed[0].Reason, "Same title")
+}
+
+func (s *WorkItem2Suite) TestWI2TransferDuplicateTitleInIterationFailed() {
+	// given
+	sourceSpaceID := s.createTransferSpace("TestWI2TransferDuplicateTitleInIterationFailed-source")
+	targetSpaceID := s.createUniqueTitlesSpace("TestWI2TransferDuplicateTitleInIterationFailed-target", true)
+	c := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, targetSpaceID)
+	c.Data.Attributes[workitem.SystemTitle] = "Same title"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, targetSpaceID.String(), &c)
+	d := minimumRequiredCreateWithTypeAndSpace(workitem.SystemBug, sourceSpaceID)
+	d.Data.Attributes[workitem.SystemTitle] = "Same title"
+	d.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &d)
+	require.NotNil(s.T(), wi)
+	payload := app.TransferWorkitemPayload{
+		Space:     targetSpaceID,
+		Workitems: []string{*wi.Data.ID},
+	}
+	// when
+	_, result := test.TransferWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), &payload)
+	// then
+	assert.Empty(s.T(), result.Moved)
+	require.Len(s.T(), result.Failed, 1)
+	assert.Equal(s.T(), *wi.Data.ID, result.Failed[0].ID)
+	assert.Contains(s.T(), result.Failed[0].Reason, "Same title")
+	test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, sourceSpaceID.String(), *wi.Data.ID, nil, nil)
+}
+
 func (s *WorkItem2Suite) TestWI2ListByAreaFilterOKEmptyList() {
 	// given
 	spaceID, areaID, _ := s.setupAreaWorkItem(false)
 	// when
-	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	// then
 	require.NotNil(s.T(), *workitems)
 	require.Empty(s.T(), workitems.Data)
@@ -1352,7 +2465,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilterOKUsingExpiredIfModifiedSinceHea
 	// when
 	updatedAt := wi.Data.Attributes[workitem.SystemUpdatedAt].(time.Time)
 	ifModifiedSince := app.ToHTTPTime(updatedAt.Add(-1 * time.Hour))
-	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, &ifModifiedSince, nil)
+	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &ifModifiedSince, nil)
 	// then
 	assertAreaWorkItems(s.T(), areaID, workitems)
 	assertResponseHeaders(s.T(), res)
@@ -1363,7 +2476,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilterOKUsingExpiredIfNoneMatchHeader(
 	spaceID, areaID, _ := s.setupAreaWorkItem(true)
 	// when
 	ifNoneMatch := "foo"
-	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, &ifNoneMatch)
+	res, workitems := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &ifNoneMatch)
 	// then
 	assertAreaWorkItems(s.T(), areaID, workitems)
 	assertResponseHeaders(s.T(), res)
@@ -1375,7 +2488,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilterNotModifiedUsingIfModifiedSinceH
 	// when
 	updatedAt := wi.Data.Attributes[workitem.SystemUpdatedAt].(time.Time)
 	ifModifiedSince := app.ToHTTPTime(updatedAt)
-	res := test.ListWorkitemNotModified(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, &ifModifiedSince, nil)
+	res := test.ListWorkitemNotModified(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &ifModifiedSince, nil)
 	// then
 	assertResponseHeaders(s.T(), res)
 }
@@ -1385,7 +2498,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilterNotModifiedUsingIfNoneMatchHeade
 	spaceID, areaID, wi := s.setupAreaWorkItem(true)
 	// when
 	ifNoneMatch := app.GenerateEntityTag(convertWorkItemToConditionalResponseEntity(*wi))
-	res := test.ListWorkitemNotModified(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, &ifNoneMatch)
+	res := test.ListWorkitemNotModified(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, spaceID, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &ifNoneMatch)
 	// then
 	assertResponseHeaders(s.T(), res)
 }
@@ -1411,7 +2524,7 @@ func (s *WorkItem2Suite) TestWI2ListByIterationFilter() {
 	require.NotNil(s.T(), wi.Data.Relationships.Iteration)
 	assert.Equal(s.T(), iterationID, *wi.Data.Relationships.Iteration.Data.ID)
 
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, nil, &iterationID, nil, nil, nil, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, c.Data.Relationships.Space.Data.ID.String(), nil, nil, nil, nil, nil, nil, &iterationID, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 1)
 	assert.Equal(s.T(), iterationID, *list.Data[0].Relationships.Iteration.Data.ID)
 	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[iteration]"))
@@ -2215,15 +3328,15 @@ func (s *WorkItem2Suite) TestWI2ListForChildIteration() {
 	}
 
 	// list workitems for grandParentIteration
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, &grandParentIterationID, nil, nil, nil, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, &grandParentIterationID, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 7)
 
 	// list workitems for parentIteration
-	_, list = test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, &parentIterationID, nil, nil, nil, nil, nil, nil, nil)
+	_, list = test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, &parentIterationID, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 4)
 
 	// list workitems for childIteraiton
-	_, list = test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, &childIteraitonID, nil, nil, nil, nil, nil, nil, nil)
+	_, list = test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, &childIteraitonID, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 2)
 }
 
@@ -2275,10 +3388,10 @@ func (s *WorkItemSuite) TestUpdateWorkitemForSpaceCollaborator() {
 
 	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	svc := testsupport.ServiceAsSpaceUser("Collaborators-Service", almtoken.NewManagerWithPrivateKey(priv), testIdentity, &TestSpaceAuthzService{testIdentity})
-	ctrl := NewWorkitemController(svc, gormapplication.NewGormDB(s.DB), s.Configuration)
+	ctrl := NewWorkitemController(svc, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 	testIdentity2, err := testsupport.CreateTestIdentity(s.DB, "TestUpdateWorkitemForSpaceCollaborator-"+uuid.NewV4().String(), "TestWI")
 	svcNotAuthrized := testsupport.ServiceAsSpaceUser("Collaborators-Service", almtoken.NewManagerWithPrivateKey(priv), testIdentity2, &TestSpaceAuthzService{testIdentity})
-	ctrlNotAuthrize := NewWorkitemController(svcNotAuthrized, gormapplication.NewGormDB(s.DB), s.Configuration)
+	ctrlNotAuthrize := NewWorkitemController(svcNotAuthrized, gormapplication.NewGormDB(s.DB), s.Configuration, nil)
 
 	_, wi := test.CreateWorkitemCreated(s.T(), svc.Context, svc, ctrl, payload.Data.Relationships.Space.Data.ID.String(), &payload)
 	_, wi2 := test.CreateWorkitemCreated(s.T(), svcNotAuthrized.Context, svcNotAuthrized, ctrlNotAuthrize, payload.Data.Relationships.Space.Data.ID.String(), &payload)
@@ -2337,7 +3450,7 @@ func (s *workItemChildSuite) TestWorkItemListFilterByNoParents() {
 		// given
 		var pe *bool
 		// when
-		_, result := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, pe, nil, nil, nil, nil, nil, nil)
+		_, result := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, nil, nil, nil, pe, nil, nil, nil, nil, nil, nil)
 		// then
 		assert.Len(t, result.Data, 3)
 	})
@@ -2346,7 +3459,7 @@ func (s *workItemChildSuite) TestWorkItemListFilterByNoParents() {
 		// given
 		pe := false
 		// when
-		_, result2 := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, &pe, nil, nil, nil, nil, nil, nil)
+		_, result2 := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, nil, nil, nil, &pe, nil, nil, nil, nil, nil, nil)
 		// then
 		assert.Len(t, result2.Data, 1)
 	})
@@ -2355,7 +3468,7 @@ func (s *workItemChildSuite) TestWorkItemListFilterByNoParents() {
 		// given
 		pe := true
 		// when
-		_, result2 := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, &pe, nil, nil, nil, nil, nil, nil)
+		_, result2 := test.ListWorkitemOK(t, nil, nil, s.workItemCtrl, s.userSpaceID.String(), nil, nil, nil, nil, nil, nil, nil, &pe, nil, nil, nil, nil, nil, nil)
 		// then
 		assert.Len(t, result2.Data, 3)
 	})
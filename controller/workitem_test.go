@@ -38,7 +38,7 @@ func (s *TestPagingSuite) SetupSuite() {
 	s.svc = goa.New("TestPaginLinks-Service")
 	assert.NotNil(s.T(), s.svc)
 	db := testsupport.NewMockDB()
-	s.controller = NewWorkitemController(s.svc, db, &s.config)
+	s.controller = NewWorkitemController(s.svc, db, &s.config, nil)
 	s.repo = db.WorkItems().(*testsupport.WorkItemRepository)
 }
 
@@ -64,28 +64,28 @@ func (s *TestPagingSuite) TestPagingErrors() {
 
 	var offset string = "-1"
 	var limit int = 2
-	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	if !strings.Contains(*result.Links.First, "page[offset]=0") {
 		assert.Fail(s.T(), "Offset is negative", "Expected offset to be %d, but was %s", 0, *result.Links.First)
 	}
 
 	offset = "0"
 	limit = 0
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(s.T(), "Limit is 0", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
 
 	offset = "0"
 	limit = -1
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(s.T(), "Limit is negative", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
 
 	offset = "-3"
 	limit = -1
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(s.T(), "Limit is negative", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
@@ -95,7 +95,7 @@ func (s *TestPagingSuite) TestPagingErrors() {
 
 	offset = "ALPHA"
 	limit = 40
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=40") {
 		assert.Fail(s.T(), "Limit is within range", "Expected limit to be size %d, but was %s", 40, *result.Links.First)
 	}
@@ -110,7 +110,7 @@ func (s *TestPagingSuite) TestPagingLinksHasAbsoluteURL() {
 	limit := 10
 	s.repo.ListReturns(makeWorkItems(10), uint64(100), nil)
 	// when
-	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	// then
 	if !strings.HasPrefix(*result.Links.First, "http://") {
 		assert.Fail(s.T(), "Not Absolute URL", "Expected link %s to contain absolute URL but was %s", "First", *result.Links.First)
@@ -132,21 +132,21 @@ func (s *TestPagingSuite) TestPagingDefaultAndMaxSize() {
 	var limit int
 	s.repo.ListReturns(makeWorkItems(10), uint64(100), nil)
 	// when
-	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, &offset, nil, nil)
+	_, result := test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &offset, nil, nil)
 	// then
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(s.T(), "Limit is nil", "Expected limit to be default size %d, got %v", 20, *result.Links.First)
 	}
 	// when
 	limit = 1000
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	// then
 	if !strings.Contains(*result.Links.First, "page[limit]=100") {
 		assert.Fail(s.T(), "Limit is more than max", "Expected limit to be %d, got %v", 100, *result.Links.First)
 	}
 	// when
 	limit = 50
-	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
+	_, result = test.ListWorkitemOK(s.T(), context.Background(), nil, s.controller, space.SystemSpace.String(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil, nil)
 	// then
 	if !strings.Contains(*result.Links.First, "page[limit]=50") {
 		assert.Fail(s.T(), "Limit is within range", "Expected limit to be %d, got %v", 50, *result.Links.First)
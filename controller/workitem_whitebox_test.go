@@ -23,7 +23,7 @@ func TestNewWorkitemController(t *testing.T) {
 	t.Parallel()
 	resource.Require(t, resource.UnitTest)
 	assert.Panics(t, func() {
-		NewWorkitemController(goa.New("Test service"), nil, nil)
+		NewWorkitemController(goa.New("Test service"), nil, nil, nil)
 	})
 }
 
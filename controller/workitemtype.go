@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/almighty/almighty-core/app"
@@ -140,6 +142,163 @@ func (c *WorkitemtypeController) List(ctx *app.ListWorkitemtypeContext) error {
 	})
 }
 
+// ListAvailable runs the list-available action.
+func (c *WorkitemtypeController) ListAvailable(ctx *app.ListAvailableWorkitemtypeContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := appl.Spaces().Load(ctx.Context, spaceID); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		spaceIDs := []uuid.UUID{space.SystemSpace}
+		if !uuid.Equal(spaceID, space.SystemSpace) {
+			spaceIDs = append(spaceIDs, spaceID)
+		}
+		witModels, err := appl.WorkItemTypes().ListInSpaces(ctx.Context, spaceIDs)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error listing work item types"))
+		}
+		result := &app.WorkItemTypeList{}
+		result.Data = make([]*app.WorkItemTypeData, len(witModels))
+		for index, value := range witModels {
+			wit := ConvertWorkItemTypeFromModel(ctx.RequestData, &value)
+			result.Data[index] = &wit
+		}
+		return ctx.OK(result)
+	})
+}
+
+// ShowSchema runs the show-schema action.
+func (c *WorkitemtypeController) ShowSchema(ctx *app.ShowSchemaWorkitemtypeContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("spaceID", ctx.ID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		witModel, err := appl.WorkItemTypes().Load(ctx.Context, spaceID, ctx.WitID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		schema, err := json.Marshal(ConvertWorkItemTypeToCreateSchema(*witModel))
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewInternalError(err.Error()))
+		}
+		ctx.ResponseData.Header().Set("Content-Type", "application/schema+json")
+		return ctx.OK(schema)
+	})
+}
+
+// fields which are set by the server and thus not part of the create payload
+var readOnlyWorkItemFields = map[string]struct{}{
+	workitem.SystemCreator:   {},
+	workitem.SystemCreatedAt: {},
+	workitem.SystemUpdatedAt: {},
+	workitem.SystemOrder:     {},
+}
+
+// ConvertWorkItemTypeToCreateSchema returns the JSON Schema (draft 4) of the payload
+// to create a work item of the given type
+func ConvertWorkItemTypeToCreateSchema(wit workitem.WorkItemType) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for name, def := range wit.Fields {
+		if _, readOnly := readOnlyWorkItemFields[name]; readOnly {
+			continue
+		}
+		property := convertFieldTypeToSchema(def.Type)
+		if def.Label != "" {
+			property["title"] = def.Label
+		}
+		if def.Description != "" {
+			property["description"] = def.Description
+		}
+		properties[name] = property
+		if def.Required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	attributes := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		attributes["required"] = required
+	}
+	return map[string]interface{}{
+		"$schema":  "http://json-schema.org/draft-04/schema#",
+		"title":    fmt.Sprintf("Create a work item of type '%s'", wit.Name),
+		"type":     "object",
+		"required": []string{"data"},
+		"properties": map[string]interface{}{
+			"data": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"type", "attributes", "relationships"},
+				"properties": map[string]interface{}{
+					"type": map[string]interface{}{
+						"enum": []string{APIStringTypeWorkItem},
+					},
+					"attributes": attributes,
+					"relationships": map[string]interface{}{
+						"type":     "object",
+						"required": []string{"baseType"},
+						"properties": map[string]interface{}{
+							"baseType": map[string]interface{}{
+								"type":     "object",
+								"required": []string{"data"},
+								"properties": map[string]interface{}{
+									"data": map[string]interface{}{
+										"type":     "object",
+										"required": []string{"id", "type"},
+										"properties": map[string]interface{}{
+											"id":   map[string]interface{}{"enum": []string{wit.ID.String()}},
+											"type": map[string]interface{}{"enum": []string{APIStringTypeWorkItemType}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// convertFieldTypeToSchema returns the JSON Schema of the values accepted by the given field type
+func convertFieldTypeToSchema(fieldType workitem.FieldType) map[string]interface{} {
+	switch t := fieldType.(type) {
+	case workitem.EnumType:
+		return map[string]interface{}{"enum": t.Values}
+	case *workitem.EnumType:
+		return map[string]interface{}{"enum": t.Values}
+	case workitem.ListType:
+		return map[string]interface{}{"type": "array", "items": convertFieldTypeToSchema(t.ComponentType)}
+	case *workitem.ListType:
+		return map[string]interface{}{"type": "array", "items": convertFieldTypeToSchema(t.ComponentType)}
+	}
+	switch fieldType.GetKind() {
+	case workitem.KindInteger, workitem.KindDuration:
+		return map[string]interface{}{"type": "integer"}
+	case workitem.KindFloat:
+		return map[string]interface{}{"type": "number"}
+	case workitem.KindInstant:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case workitem.KindURL:
+		return map[string]interface{}{"type": "string", "format": "uri"}
+	case workitem.KindMarkup:
+		// markup content is accepted either as a plain string or as a content/markup object
+		return map[string]interface{}{"type": []string{"string", "object"}}
+	case workitem.KindCodebase:
+		return map[string]interface{}{"type": "object"}
+	default:
+		// strings and references to other entities (users, iterations, areas, work items)
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
 // ListSourceLinkTypes runs the list-source-link-types action.
 func (c *WorkitemtypeController) ListSourceLinkTypes(ctx *app.ListSourceLinkTypesWorkitemtypeContext) error {
 	spaceID, err := uuid.FromString(ctx.ID)
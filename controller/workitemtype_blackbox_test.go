@@ -3,8 +3,12 @@ package controller_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/almighty/almighty-core/app"
@@ -393,6 +397,42 @@ func (s *workItemTypeSuite) TestListWorkItemType200OK() {
 	assert.Equal(s.T(), generateWorkItemTypesTag(*witCollection), res.Header()[app.ETag][0])
 }
 
+func (s *workItemTypeSuite) TestListAvailableWorkItemTypesOK() {
+	// given
+	spacePayload := CreateSpacePayload("TestListAvailableWorkItemTypes-"+uuid.NewV4().String(), "description")
+	_, sp := test.CreateSpaceCreated(s.T(), s.svc.Context, s.svc, s.spaceCtrl, spacePayload)
+	require.NotNil(s.T(), sp)
+	payload := CreateWorkItemType(uuid.NewV4(), *sp.Data.ID)
+	_, witPerson := test.CreateWorkitemtypeCreated(s.T(), s.svc.Context, s.svc, s.typeCtrl, sp.Data.ID.String(), &payload)
+	require.NotNil(s.T(), witPerson)
+	// when
+	_, witCollection := test.ListAvailableWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, sp.Data.ID.String())
+	// then
+	require.NotNil(s.T(), witCollection)
+	require.Nil(s.T(), witCollection.Validate())
+	assert.Condition(s.T(), lookupWorkItemTypes(*witCollection, *witPerson), "The work item type of the space was not found.")
+	names := make([]string, len(witCollection.Data))
+	foundSystemBug := false
+	for i, wit := range witCollection.Data {
+		names[i] = wit.Attributes.Name
+		if *wit.ID == workitem.SystemBug {
+			foundSystemBug = true
+		}
+	}
+	assert.True(s.T(), foundSystemBug, "The system work item types were not found.")
+	assert.True(s.T(), sort.StringsAreSorted(names), "The work item types are not ordered by name: %v", names)
+	// the order is stable
+	_, again := test.ListAvailableWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, sp.Data.ID.String())
+	require.Len(s.T(), again.Data, len(witCollection.Data))
+	for i := range again.Data {
+		assert.Equal(s.T(), *witCollection.Data[i].ID, *again.Data[i].ID)
+	}
+}
+
+func (s *workItemTypeSuite) TestListAvailableWorkItemTypesUnknownSpaceNotFound() {
+	test.ListAvailableWorkitemtypeNotFound(s.T(), nil, nil, s.typeCtrl, uuid.NewV4().String())
+}
+
 // TestListWorkItemType200UsingExpiredIfModifiedSinceHeader tests if we can find the work item types
 // "person" and "animal" in the list of work item types with an expired "If-Modified-Since" HTTP request header
 func (s *workItemTypeSuite) TestListWorkItemType200UsingExpiredIfModifiedSinceHeader() {
@@ -714,6 +754,150 @@ func (s *workItemTypeSuite) TestListSourceAndTargetLinkTypesNotFound() {
 	require.NotNil(s.T(), jerrors)
 }
 
+// TestShowWorkItemTypeSchema200OK tests that the schema of the payload to create
+// an "animal" describes its custom fields and validates a sample payload.
+func (s *workItemTypeSuite) TestShowWorkItemTypeSchema200OK() {
+	// given
+	_, wit := s.createWorkItemTypeAnimal()
+	// when
+	res := test.ShowSchemaWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, space.SystemSpace.String(), animalID)
+	// then
+	assert.Equal(s.T(), "application/schema+json", res.Header().Get("Content-Type"))
+	var schema map[string]interface{}
+	require.Nil(s.T(), json.Unmarshal(res.(*httptest.ResponseRecorder).Body.Bytes(), &schema))
+	attributes := schemaProperty(s.T(), schema, "data", "attributes")
+	assert.Equal(s.T(), []interface{}{"animal_type"}, attributes["required"])
+	animalType := schemaProperty(s.T(), attributes, "animal_type")
+	assert.Equal(s.T(), []interface{}{"elephant", "blue whale", "Tyrannosaurus rex"}, animalType["enum"])
+	color := schemaProperty(s.T(), attributes, "color")
+	assert.Equal(s.T(), "string", color["type"])
+	baseTypeID := schemaProperty(s.T(), schema, "data", "relationships", "baseType", "data", "id")
+	assert.Equal(s.T(), []interface{}{wit.Data.ID.String()}, baseTypeID["enum"])
+	// a sample payload which fills the required fields with the allowed values is valid
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": APIStringTypeWorkItem,
+			"attributes": map[string]interface{}{
+				"animal_type": "blue whale",
+				"color":       "blue",
+			},
+			"relationships": map[string]interface{}{
+				"baseType": map[string]interface{}{
+					"data": map[string]interface{}{
+						"id":   animalID.String(),
+						"type": APIStringTypeWorkItemType,
+					},
+				},
+			},
+		},
+	}
+	assert.Nil(s.T(), validateSchema(schema, toJSONValue(s.T(), payload)))
+	// a sample payload with an unknown animal is not
+	payload["data"].(map[string]interface{})["attributes"].(map[string]interface{})["animal_type"] = "unicorn"
+	assert.NotNil(s.T(), validateSchema(schema, toJSONValue(s.T(), payload)))
+	// a sample payload without the required field is not either
+	delete(payload["data"].(map[string]interface{})["attributes"].(map[string]interface{}), "animal_type")
+	assert.NotNil(s.T(), validateSchema(schema, toJSONValue(s.T(), payload)))
+}
+
+// TestShowWorkItemTypeSchemaNotFound tests that a NotFound error is
+// returned when you query the schema of a non existing WIT.
+func (s *workItemTypeSuite) TestShowWorkItemTypeSchemaNotFound() {
+	_, jerrors := test.ShowSchemaWorkitemtypeNotFound(s.T(), nil, nil, s.typeCtrl, space.SystemSpace.String(), uuid.NewV4())
+	require.NotNil(s.T(), jerrors)
+}
+
+// schemaProperty returns the schema of the property at the given path in the given object schema
+func schemaProperty(t *testing.T, schema map[string]interface{}, path ...string) map[string]interface{} {
+	for _, name := range path {
+		properties, ok := schema["properties"].(map[string]interface{})
+		require.True(t, ok, "no properties in schema %v", schema)
+		schema, ok = properties[name].(map[string]interface{})
+		require.True(t, ok, "no property '%s' in schema %v", name, properties)
+	}
+	return schema
+}
+
+// toJSONValue returns the given value as it would be decoded from a JSON document
+func toJSONValue(t *testing.T, value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	require.Nil(t, err)
+	var result interface{}
+	require.Nil(t, json.Unmarshal(b, &result))
+	return result
+}
+
+// validateSchema validates the given value against the subset of JSON Schema
+// returned by the show-schema action (type, enum, required, properties and items).
+func validateSchema(schema map[string]interface{}, value interface{}) error {
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range enum {
+			found = found || reflect.DeepEqual(v, value)
+		}
+		if !found {
+			return fmt.Errorf("value %v is not one of %v", value, enum)
+		}
+	}
+	if t, ok := schema["type"]; ok {
+		types, ok := t.([]interface{})
+		if !ok {
+			types = []interface{}{t}
+		}
+		found := false
+		for _, typ := range types {
+			found = found || jsonSchemaType(value) == typ || (typ == "integer" && jsonSchemaType(value) == "number" && value.(float64) == float64(int64(value.(float64))))
+		}
+		if !found {
+			return fmt.Errorf("value %v is not of type %v", value, t)
+		}
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := object[name.(string)]; !ok {
+					return fmt.Errorf("missing required property '%s'", name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, v := range object {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if err := validateSchema(property, v); err != nil {
+					return fmt.Errorf("invalid property '%s': %s", name, err.Error())
+				}
+			}
+		}
+	}
+	if array, ok := value.([]interface{}); ok {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for _, v := range array {
+				if err := validateSchema(items, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonSchemaType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
 // used for testing purpose only
 func convertWorkItemTypeToModel(data app.WorkItemTypeData) workitem.WorkItemType {
 	return workitem.WorkItemType{
@@ -44,6 +44,10 @@ type ExpressionVisitor interface {
 	Parameter(v *ParameterExpression) interface{}
 	Literal(c *LiteralExpression) interface{}
 	Not(e *NotExpression) interface{}
+	IsNull(e *IsNullExpression) interface{}
+	ReferencesDeactivatedIdentity(e *ReferencesDeactivatedIdentityExpression) interface{}
+	GreaterOrEqual(e *GreaterOrEqualExpression) interface{}
+	LessOrEqual(e *LessOrEqualExpression) interface{}
 }
 
 type expression struct {
@@ -216,3 +220,73 @@ func (t *NotExpression) Accept(visitor ExpressionVisitor) interface{} {
 func Not(left Expression, right Expression) Expression {
 	return reparent(&NotExpression{binaryExpression{expression{}, left, right}})
 }
+
+// IsNull
+
+// IsNullExpression represents the test for a field having no value
+type IsNullExpression struct {
+	expression
+	FieldName string
+}
+
+// Accept implements ExpressionVisitor
+func (t *IsNullExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.IsNull(t)
+}
+
+// IsNull constructs an IsNullExpression
+func IsNull(name string) Expression {
+	return &IsNullExpression{expression{}, name}
+}
+
+// ReferencesDeactivatedIdentity
+
+// ReferencesDeactivatedIdentityExpression represents the test for a field holding the ID of at least one deactivated identity
+type ReferencesDeactivatedIdentityExpression struct {
+	expression
+	FieldName string
+}
+
+// Accept implements ExpressionVisitor
+func (t *ReferencesDeactivatedIdentityExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.ReferencesDeactivatedIdentity(t)
+}
+
+// ReferencesDeactivatedIdentity constructs a ReferencesDeactivatedIdentityExpression
+func ReferencesDeactivatedIdentity(name string) Expression {
+	return &ReferencesDeactivatedIdentityExpression{expression{}, name}
+}
+
+// >=
+
+// GreaterOrEqualExpression represents the greater than or equal operator
+type GreaterOrEqualExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *GreaterOrEqualExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.GreaterOrEqual(t)
+}
+
+// GreaterOrEqual constructs a GreaterOrEqualExpression
+func GreaterOrEqual(left Expression, right Expression) Expression {
+	return reparent(&GreaterOrEqualExpression{binaryExpression{expression{}, left, right}})
+}
+
+// <=
+
+// LessOrEqualExpression represents the less than or equal operator
+type LessOrEqualExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *LessOrEqualExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.LessOrEqual(t)
+}
+
+// LessOrEqual constructs a LessOrEqualExpression
+func LessOrEqual(left Expression, right Expression) Expression {
+	return reparent(&LessOrEqualExpression{binaryExpression{expression{}, left, right}})
+}
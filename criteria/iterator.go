@@ -39,6 +39,22 @@ func (i *postOrderIterator) Not(exp *NotExpression) interface{} {
 	return i.binary(exp)
 }
 
+func (i *postOrderIterator) IsNull(exp *IsNullExpression) interface{} {
+	return i.visit(exp)
+}
+
+func (i *postOrderIterator) ReferencesDeactivatedIdentity(exp *ReferencesDeactivatedIdentityExpression) interface{} {
+	return i.visit(exp)
+}
+
+func (i *postOrderIterator) GreaterOrEqual(exp *GreaterOrEqualExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) LessOrEqual(exp *LessOrEqualExpression) interface{} {
+	return i.binary(exp)
+}
+
 func (i *postOrderIterator) binary(exp BinaryExpression) bool {
 	if exp.Left().Accept(i) == false {
 		return false
@@ -35,11 +35,13 @@ var identity = a.MediaType("application/vnd.identity+json", func() {
 	a.Description("ALM User Identity")
 	a.Attributes(func() {
 		a.Attribute("data", identityData)
+		a.Attribute("included", a.ArrayOf(d.Any), "An array of mixed types")
 		a.Required("data")
 
 	})
 	a.View("default", func() {
 		a.Attribute("data")
+		a.Attribute("included")
 		a.Required("data")
 	})
 })
@@ -77,6 +79,88 @@ var userArray = a.MediaType("application/vnd.user-array+json", func() {
 	})
 })
 
+// usernameChange represents a change of the username of a user
+var usernameChange = a.Type("UsernameChange", func() {
+	a.Attribute("type", d.String, func() {
+		a.Enum("usernamechanges")
+	})
+	a.Attribute("id", d.UUID, "ID of the change")
+	a.Attribute("attributes", usernameChangeAttributes)
+	a.Required("type", "attributes")
+})
+
+var usernameChangeAttributes = a.Type("UsernameChangeAttributes", func() {
+	a.Attribute("oldUsername", d.String, "The username before the change")
+	a.Attribute("newUsername", d.String, "The username after the change")
+	a.Attribute("changedBy", d.UUID, "ID of the identity which changed the username: the user or an administrator")
+	a.Attribute("changedAt", d.DateTime, "When the username was changed", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Required("oldUsername", "newUsername", "changedBy", "changedAt")
+})
+
+var usernameChangeList = JSONList(
+	"UsernameChange", "Holds the changes of the username of a user",
+	usernameChange,
+	nil,
+	nil)
+
+// profileEvent represents a change of a field of the profile of a user
+var profileEvent = a.Type("ProfileEvent", func() {
+	a.Attribute("type", d.String, func() {
+		a.Enum("profileevents")
+	})
+	a.Attribute("id", d.UUID, "ID of the event")
+	a.Attribute("attributes", profileEventAttributes)
+	a.Required("type", "attributes")
+})
+
+var profileEventAttributes = a.Type("ProfileEventAttributes", func() {
+	a.Attribute("field", d.String, "The changed field: fullName, email, company, or contextInformation followed by the changed key, e.g. 'contextInformation.space'", func() {
+		a.Example("fullName")
+	})
+	a.Attribute("oldValue", d.String, "The value before the change, absent if the field was not set")
+	a.Attribute("newValue", d.String, "The value after the change, absent if the field was unset")
+	a.Attribute("changedBy", d.UUID, "ID of the identity which changed the field: the user or an administrator")
+	a.Attribute("changedAt", d.DateTime, "When the field was changed", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Required("field", "changedBy", "changedAt")
+})
+
+var profileEventListMeta = a.Type("ProfileEventListMeta", func() {
+	a.Attribute("totalCount", d.Integer)
+	a.Required("totalCount")
+})
+
+var profileEventList = JSONList(
+	"ProfileEvent", "Holds the paginated changes of the profile of a user",
+	profileEvent,
+	pagingLinks,
+	profileEventListMeta)
+
+// company represents a company of the users, as suggested by the autocompletion
+var company = a.Type("Company", func() {
+	a.Attribute("type", d.String, func() {
+		a.Enum("companies")
+	})
+	a.Attribute("attributes", companyAttributes)
+	a.Required("type", "attributes")
+})
+
+var companyAttributes = a.Type("CompanyAttributes", func() {
+	a.Attribute("name", d.String, "The name of the company", func() {
+		a.Example("Red Hat")
+	})
+	a.Required("name")
+})
+
+var companyList = JSONList(
+	"Company", "Holds the companies of the users matching an autocompletion request",
+	company,
+	nil,
+	nil)
+
 var userListMeta = a.Type("UserListMeta", func() {
 	a.Attribute("totalCount", d.Integer)
 	a.Required("totalCount")
@@ -104,6 +188,18 @@ var _ = a.Resource("user", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
+
+	a.Action("list-recent-spaces", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/spaces/recent"),
+		)
+		a.Description("List the spaces recently visited by the authenticated user, the most recently visited first. A visit is recorded each time the 'space' context information of the user is updated.")
+		a.Response(d.OK, spaceList)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
 })
 
 var _ = a.Resource("identity", func() {
@@ -132,10 +228,16 @@ var _ = a.Resource("users", func() {
 		a.Description("Retrieve user for the given ID.")
 		a.Params(func() {
 			a.Param("id", d.String, "id")
+			a.Param("fields[identities]", d.String, "Comma-separated attributes of the user to return, e.g. 'username,fullName'. All the attributes are returned by default")
+			a.Param("include", d.String, "Include the spaces of the user in the response", func() {
+				a.Enum("spaces")
+			})
 		})
+		a.UseTrait("conditional")
 		a.Response(d.OK, func() {
 			a.Media(identity)
 		})
+		a.Response(d.NotModified)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.BadRequest, JSONAPIErrors)
@@ -147,6 +249,9 @@ var _ = a.Resource("users", func() {
 			a.PATCH(""),
 		)
 		a.Description("update the authenticated user")
+		a.Params(func() {
+			a.Param("forceUsernameChange", d.Boolean, "Allow the username to be changed more than once. Restricted to the administrators")
+		})
 		a.Payload(updateIdentity)
 		a.Response(d.OK, func() {
 			a.Media(identity)
@@ -160,32 +265,550 @@ var _ = a.Resource("users", func() {
 
 	})
 
+	a.Action("deactivate", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/deactivate"),
+		)
+		a.Description("deactivate the authenticated user. The ownership of the spaces of the user is transferred to the given new owner, who must be a collaborator of each of these spaces")
+		a.Payload(deactivateIdentity)
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt", func() {
+			a.Scope("admin:users")
+		})
+		a.Routing(
+			a.DELETE("/:id"),
+		)
+		a.Description(`Deprovision the user with the given identity ID: the user and all their identities are deleted,
+		removed from the collaborators of the spaces and their sessions are revoked. Restricted to the tokens granted the 'admin:users' scope.`)
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("merge", func() {
+		a.Security("jwt", func() {
+			a.Scope("admin:users")
+		})
+		a.Routing(
+			a.POST("/:id/merge"),
+		)
+		a.Description(`Merge the duplicate user of the source identity into the user with the given identity ID: the identities
+		of the duplicate user are moved to the user, the work items, comments and collaborations of the spaces of these identities
+		are transferred to the given identity, then the duplicate user is deleted. Restricted to the tokens granted the 'admin:users' scope.`)
+		a.Params(func() {
+			a.Param("id", d.String, "ID of the identity of the user to merge into")
+		})
+		a.Payload(mergeUsers)
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("import", func() {
+		a.Security("jwt", func() {
+			a.Scope("admin:users")
+		})
+		a.Routing(
+			a.POST("/import"),
+		)
+		a.Description(`Import the users and their identity given either as a JSON document of the form {"data": [{"username": ..., "email": ...}]}
+		or as a CSV document with a header line, when the content type is 'text/csv'. The fields of a user are: identityID, username,
+		email, fullName, company, providerType and registrationCompleted, of which only the username and the email are required.
+		The provider type is 'kc' by default, or the type of a linked identity provider. All the users are imported at once:
+		if a user can't be imported, none is, the users provisioned in Keycloak are deleted again, and the errors are returned
+		with the number of their row in their meta, starting at 1. Restricted to the tokens granted the 'admin:users' scope.`)
+		a.Params(func() {
+			a.Param("provision", d.Boolean, "Whether to create the users in Keycloak as well. The ID of each identity is then the ID of the Keycloak user, and can't be given")
+		})
+		a.Response(d.OK, func() {
+			a.Media(userArray)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("complete-registration", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:id/complete-registration"),
+		)
+		a.Description(`Complete the registration of the authenticated user, which must have a username and a verified email,
+		along with a company when the company is required. The tenant of the user is then initialized.`)
+		a.Params(func() {
+			a.Param("id", d.String, "ID of the identity of the authenticated user")
+		})
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+
+	a.Action("update-state", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PUT("/:id/state"),
+		)
+		a.Description("Set the state of the user with the given ID. The API calls of the deactivated and banned users are rejected. Restricted to the administrators.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Payload(updateUserState)
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("list-memberships", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id/memberships"),
+		)
+		a.Description("List all the spaces which the user with the given ID belongs to, along with the role of the user in each space. Restricted to the administrators.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK, func() {
+			a.Media(spaceMembershipArray)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("list-username-history", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id/username-history"),
+		)
+		a.Description("List the changes of the username of the user with the given ID, the oldest first. Restricted to the user and the administrators.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK, func() {
+			a.Media(usernameChangeList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("list-companies", func() {
+		a.Routing(
+			a.GET("/companies"),
+		)
+		a.Description("List the distinct companies of the users starting with the given prefix, regardless of the case, in alphabetical order. Intended for autocompletion.")
+		a.Params(func() {
+			a.Param("starts_with", d.String, "The prefix of the companies to list")
+			a.Param("page[limit]", d.Integer, "The maximum number of companies to list")
+		})
+		a.Response(d.OK, func() {
+			a.Media(companyList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
+	a.Action("list-events", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id/events"),
+		)
+		a.Description("List the changes of the full name, email, company and context information of the user with the given ID, the most recent first. Restricted to the user and the administrators.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+			a.Param("page[offset]", d.String, "Paging start position")
+			a.Param("page[limit]", d.Integer, "Paging size")
+		})
+		a.Response(d.OK, func() {
+			a.Media(profileEventList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("verify-email", func() {
+		a.Routing(
+			a.GET("/verifyemail"),
+		)
+		a.Description("Verify the new email of a user with the token sent to that email when the user updated it or added it as a secondary email. The email of the user is only replaced once verified.")
+		a.Params(func() {
+			a.Param("token", d.String, "the token sent to the new email of the user")
+			a.Required("token")
+		})
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+
+	a.Action("resolve", func() {
+		a.Routing(
+			a.GET("/resolve"),
+		)
+		a.Description("List the users with the given usernames, in the order of the given usernames. Unknown usernames are skipped.")
+		a.Params(func() {
+			a.Param("username", a.ArrayOf(d.String), "usernames of the users to list")
+			a.Required("username")
+		})
+		a.Response(d.OK, func() {
+			a.Media(userArray)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
+	a.Action("search", func() {
+		a.Routing(
+			a.GET("/search"),
+		)
+		a.Description("Search the users whose username, full name or email contains the given term, regardless of the case. Exact matches are listed first, then the matches starting with the term.")
+		a.Params(func() {
+			a.Param("q", d.String, "term to search in the username, full name and email of the users")
+			a.Param("page[offset]", d.String, "Paging start position")
+			a.Param("page[limit]", d.Integer, "Paging size")
+			a.Param("spaceID", d.String, "The optional ID of a space to restrict the search to its collaborators")
+			a.Required("q")
+		})
+		a.Response(d.OK, func() {
+			a.Media(userList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
 	a.Action("list", func() {
 		a.Routing(
 			a.GET(""),
 		)
 		a.Description("List all users.")
 		a.Response(d.OK, func() {
-			a.Media(userArray)
+			a.Media(userList)
 		})
 		a.Params(func() {
+			a.Param("fields[identities]", d.String, "Comma-separated attributes of the users to return, e.g. 'username,fullName'. All the attributes are returned by default")
 			// This is not filtering - mutliple params do not work as "AND".
 			a.Param("filter[username]", d.String, "username to search users")
+			a.Param("filter[username][contains]", d.String, "part of the username of the users to list, regardless of the case")
 			a.Param("filter[email]", d.String, "email to search users")
+			a.Param("filter[email][contains]", d.String, "part of the email of the users to list, regardless of the case")
+			a.Param("filter[company]", d.String, "company of the users to list, regardless of the case")
+			a.Param("filter[q]", d.String, "part of the username, full name or email of the users to list, regardless of the case")
+			a.Param("filter[id]", d.String, "comma-separated IDs of the identities to list")
 			a.Param("filter[registrationCompleted]", d.Boolean, "users who have not completed registration")
+			a.Param("filter[state]", d.String, "state of the users to list", func() {
+				a.Enum("active", "deactivated", "banned")
+			})
+			a.Param("page[offset]", d.String, "Paging start position")
+			a.Param("page[limit]", d.Integer, "Paging size")
+			a.Param("sort", d.String, "Attribute to sort users by, prefixed with '-' for a descending order", func() {
+				a.Enum("username", "-username", "email", "-email", "full_name", "-full_name")
+			})
+		})
+		a.UseTrait("conditional")
+		a.Response(d.NotModified)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+})
+
+var _ = a.Resource("users_avatar", func() {
+	a.Parent("users")
+	a.BasePath("/avatar")
+
+	a.Action("show", func() {
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("Retrieve the avatar of the user with the given ID, as a square PNG image.")
+		a.Params(func() {
+			a.Param("size", d.Integer, "width and height in pixels of the avatar (256 by default)", func() {
+				a.Enum(32, 64, 128, 256)
+			})
+		})
+		a.Response(d.OK, "image/png")
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+	})
+
+	a.Action("upload", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description(`Upload the avatar of the authenticated user, as the 'file' field of a multipart form. The PNG, JPEG or GIF image
+		is cropped to a square, resized and the image URL of the user is replaced by the URL of the uploaded avatar.`)
+		a.Response(d.OK, func() {
+			a.Media(identity)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
+
+var _ = a.Resource("users_preferences", func() {
+	a.Parent("users")
+	a.BasePath("/preferences")
+
+	a.Action("show", func() {
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("Retrieve the preferences of the user with the given ID. The defaults are returned until the user saves their preferences.")
+		a.Response(d.OK, func() {
+			a.Media(userPreferencesSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PUT(""),
+		)
+		a.Description("Replace the preferences of the authenticated user. The omitted preferences are reset to their default.")
+		a.Payload(userPreferencesSingle)
+		a.Response(d.OK, func() {
+			a.Media(userPreferencesSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("patch", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH(""),
+		)
+		a.Description("Update the given preferences of the authenticated user. The omitted preferences are left unchanged.")
+		a.Payload(userPreferencesSingle)
+		a.Response(d.OK, func() {
+			a.Media(userPreferencesSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
+
+var _ = a.Resource("users_emails", func() {
+	a.Parent("users")
+	a.BasePath("/emails")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the email addresses of the authenticated user, the primary email first.")
+		a.Response(d.OK, func() {
+			a.Media(userEmailList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Add a secondary email address to the authenticated user. A verification link is sent to the email.")
+		a.Payload(userEmailSingle)
+		a.Response(d.Created, "/users/.*/emails/.*", func() {
+			a.Media(userEmailSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:emailID"),
+		)
+		a.Description("Make the given verified email address the primary email of the authenticated user.")
+		a.Params(func() {
+			a.Param("emailID", d.UUID, "ID of the email")
+		})
+		a.Payload(userEmailSingle)
+		a.Response(d.OK, func() {
+			a.Media(userEmailSingle)
 		})
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:emailID"),
+		)
+		a.Description("Remove the given secondary email address of the authenticated user.")
+		a.Params(func() {
+			a.Param("emailID", d.UUID, "ID of the email")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
 	})
 })
 
+var _ = a.Resource("users_tokens", func() {
+	a.Parent("users")
+	a.BasePath("/tokens")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the personal access tokens of the user with the given ID, the most recent first. The tokens themselves are not returned. Restricted to the user and the administrators.")
+		a.Response(d.OK, func() {
+			a.Media(personalAccessTokenList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Create a personal access token for the authenticated user, which authenticates the API calls like a JWT. The token is only returned in the response: it can't be retrieved later. The token can't be granted a scope that the JWT of the request was not granted.")
+		a.Payload(personalAccessTokenSingle)
+		a.Response(d.Created, "/users/.*/tokens/.*", func() {
+			a.Media(personalAccessTokenSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:tokenID"),
+		)
+		a.Description("Revoke the given personal access token of the user with the given ID. Restricted to the user and the administrators.")
+		a.Params(func() {
+			a.Param("tokenID", d.UUID, "ID of the personal access token")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
+
+// updateUserState holds the new state of a user
+var updateUserState = a.Type("UpdateUserState", func() {
+	a.Attribute("state", d.String, "The new state of the user", func() {
+		a.Enum("active", "deactivated", "banned")
+	})
+	a.Required("state")
+})
+
+// mergeUsers holds the duplicate user to merge into another user
+var mergeUsers = a.Type("MergeUsers", func() {
+	a.Attribute("source", d.UUID, "ID of an identity of the duplicate user to merge")
+	a.Required("source")
+})
+
+// deactivateIdentity holds the new owner of the spaces of a deactivated user
+var deactivateIdentity = a.Type("DeactivateIdentity", func() {
+	a.Attribute("newOwner", d.UUID, "ID of the identity which becomes the owner of the spaces of the deactivated user. Required if the user owns spaces")
+})
+
 // identityDataAttributes represents an identified user object attributes
 var identityDataAttributes = a.Type("IdentityDataAttributes", func() {
 	a.Attribute("fullName", d.String, "The users full name")
+	a.Attribute("displayName", d.String, "The name to display for the user: the full name, or else the username, or else the local part of the email")
 	a.Attribute("imageURL", d.String, "The avatar image for the user")
 	a.Attribute("username", d.String, "The username")
 	a.Attribute("registrationCompleted", d.Boolean, "Whether the registration has been completed")
+	a.Attribute("deactivated", d.Boolean, "Whether the user has been deactivated")
+	a.Attribute("state", d.String, "The state of the user, as set by the administrators. Only the active users are allowed to use the API", func() {
+		a.Enum("active", "deactivated", "banned")
+	})
 	a.Attribute("email", d.String, "The email")
+	a.Attribute("pendingEmail", d.String, "The new email of the user, which replaces the email once verified")
 	a.Attribute("bio", d.String, "The bio")
 	a.Attribute("url", d.String, "The url")
 	a.Attribute("company", d.String, "The company")
@@ -200,7 +823,7 @@ var updateIdentityDataAttributes = a.Type("UpdateIdentityDataAttributes", func()
 	a.Attribute("fullName", d.String, "The users full name")
 	a.Attribute("imageURL", d.String, "The avatar image for the user")
 	a.Attribute("username", d.String, "The username")
-	a.Attribute("email", d.String, "The email")
+	a.Attribute("email", d.String, "The new email, which replaces the email once verified with the token sent to it")
 	a.Attribute("bio", d.String, "The bio")
 	a.Attribute("url", d.String, "The url")
 	a.Attribute("company", d.String, "The company")
@@ -215,6 +838,140 @@ var identityData = a.Type("IdentityData", func() {
 	a.Attribute("id", d.String, "unique id for the user identity")
 	a.Attribute("type", d.String, "type of the user identity")
 	a.Attribute("attributes", identityDataAttributes, "Attributes of the user identity")
+	a.Attribute("relationships", identityRelationships)
 	a.Attribute("links", genericLinks)
+	a.Attribute("meta", a.HashOf(d.String, d.Any), "Meta information about the user identity, e.g. the role of a space collaborator")
 	a.Required("type", "attributes")
 })
+
+var identityRelationships = a.Type("IdentityRelationships", func() {
+	a.Attribute("preferences", relationGeneric, "The preferences of the user, included in the response when showing a single user")
+	a.Attribute("ownedSpaces", relationGenericList, "The spaces owned by the user, given when showing a single user")
+	a.Attribute("spaces", relationGenericList, "The spaces the user collaborates on, given when showing a single user with the spaces included")
+})
+
+// userPreferences represents the preferences of a user
+var userPreferences = a.Type("UserPreferences", func() {
+	a.Description(`JSONAPI store for the preferences of a user. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("userpreferences")
+	})
+	a.Attribute("id", d.String, "ID of the identity of the user", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", userPreferencesAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+// userPreferencesAttributes represents the attributes of the preferences of a user
+var userPreferencesAttributes = a.Type("UserPreferencesAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of the preferences of a user. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("landingPage", d.String, "The path of the page the user lands on after login", func() {
+		a.Pattern("^/")
+		a.Example("/_home")
+	})
+	a.Attribute("emailNotifyAssigned", d.Boolean, "Whether the user is notified by email when a work item is assigned to them")
+	a.Attribute("emailNotifyCommented", d.Boolean, "Whether the user is notified by email when a work item they follow is commented")
+	a.Attribute("emailNotifyMentioned", d.Boolean, "Whether the user is notified by email when they are mentioned")
+	a.Attribute("timezone", d.String, "The IANA name of the timezone of the user", func() {
+		a.Example("Europe/Paris")
+	})
+	a.Attribute("locale", d.String, "The language tag of the locale of the user", func() {
+		a.Pattern("^[a-z]{2,3}(-[A-Z]{2})?$")
+		a.Example("pt-BR")
+	})
+	a.Attribute("updatedAt", d.DateTime, "When the preferences were last updated", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+// userEmail represents an email address of a user
+var userEmail = a.Type("UserEmail", func() {
+	a.Description(`JSONAPI store for an email address of a user. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("useremails")
+	})
+	a.Attribute("id", d.UUID, "ID of the email", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", userEmailAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var userEmailAttributes = a.Type("UserEmailAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of an email address of a user. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("email", d.String, "The email address", func() {
+		a.Example("john@example.com")
+	})
+	a.Attribute("primary", d.Boolean, "Whether this is the primary email of the user, i.e. the email of the user resource")
+	a.Attribute("verified", d.Boolean, "Whether the email has been verified with the token sent to it. Read-only")
+	a.Attribute("createdAt", d.DateTime, "When the email was added", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var userEmailSingle = JSONSingle(
+	"UserEmail", "Holds a single email address of a user",
+	userEmail,
+	nil)
+
+var userEmailList = JSONList(
+	"UserEmail", "Holds the list of the email addresses of a user",
+	userEmail,
+	nil,
+	nil)
+
+// personalAccessToken represents a personal access token of a user
+var personalAccessToken = a.Type("PersonalAccessToken", func() {
+	a.Description(`JSONAPI store for a personal access token of a user. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("personalaccesstokens")
+	})
+	a.Attribute("id", d.UUID, "ID of the personal access token", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", personalAccessTokenAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var personalAccessTokenAttributes = a.Type("PersonalAccessTokenAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a personal access token of a user. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("name", d.String, "The name given to the token to remember what it is used for", func() {
+		a.MinLength(1)
+		a.MaxLength(100)
+		a.Example("CI pipeline")
+	})
+	a.Attribute("scopes", a.ArrayOf(d.String), "The scopes granted to the token", func() {
+		a.Example([]string{"admin:users"})
+	})
+	a.Attribute("expiresAt", d.DateTime, "When the token expires. The token never expires if not given", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("token", d.String, "The token to give as a bearer token. Read-only, and only returned when the token is created")
+	a.Attribute("revoked", d.Boolean, "Whether the token has been revoked. Read-only")
+	a.Attribute("createdAt", d.DateTime, "When the token was created. Read-only", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("lastUsedAt", d.DateTime, "When the token was last used to call the API. Read-only", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var personalAccessTokenSingle = JSONSingle(
+	"PersonalAccessToken", "Holds a single personal access token of a user",
+	personalAccessToken,
+	nil)
+
+var personalAccessTokenList = JSONList(
+	"PersonalAccessToken", "Holds the list of the personal access tokens of a user",
+	personalAccessToken,
+	nil,
+	nil)
+
+var userPreferencesSingle = JSONSingle(
+	"UserPreferences", "Holds the preferences of a user",
+	userPreferences,
+	nil)
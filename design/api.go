@@ -47,6 +47,7 @@ var _ = a.API("alm", func() {
 		a.Description("JWT Token Auth")
 		a.TokenURL("/api/login/authorize")
 		a.Header("Authorization")
+		a.Scope("admin:users", "Administrate the user accounts")
 	})
 
 	a.ResponseTemplate(d.OK, func() {
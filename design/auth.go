@@ -113,13 +113,46 @@ var _ = a.Resource("logout", func() {
 		a.Params(func() {
 			a.Param("redirect", d.String, "URL to be redirected to after successful logout. If not set then will redirect to the referrer instead.")
 		})
-		a.Description("Logout user")
+		a.Description("Logout user. The session of the bearer token given in the Authorization header, if any, is revoked.")
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.TemporaryRedirect)
 		a.Response(d.InternalServerError, JSONAPIErrors)
 	})
 })
 
+var _ = a.Resource("admin", func() {
+
+	a.BasePath("/admin")
+
+	a.Action("impersonate", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/impersonate/:identityID"),
+		)
+		a.Params(func() {
+			a.Param("identityID", d.UUID, "ID of the identity to act as")
+		})
+		a.Payload(impersonation)
+		a.Description("Issue a short-lived token acting as the given identity, to reproduce the issues met by a user. Only the tokens granted the admin scope are allowed to impersonate, and each impersonation is recorded.")
+		a.Response(d.OK, func() {
+			a.Media(AuthToken)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+})
+
+// impersonation holds the reason why an administrator acts as another identity
+var impersonation = a.Type("Impersonation", func() {
+	a.Attribute("reason", d.String, "Why the identity is impersonated, e.g. the reference of the support ticket", func() {
+		a.MinLength(1)
+	})
+	a.Required("reason")
+})
+
 var refreshToken = a.Type("RefreshToken", func() {
 	a.Attribute("refresh_token", d.String, "Refresh token")
 })
@@ -13,15 +13,38 @@ var _ = a.Resource("collaborators", func() {
 		a.Routing(
 			a.GET(""),
 		)
-		a.Description("List collaborators for the given space ID.")
+		a.Description("List collaborators for the given space ID. The members of the teams of the space are listed after the other collaborators, with the name of their team in the `via` meta.")
 		a.Params(func() {
+			a.Param("filter[username]", d.String, "part of the username of the collaborators to list, regardless of the case")
+			a.Param("filter[email]", d.String, "part of the email of the collaborators to list, regardless of the case")
+			a.Param("sort", d.String, `Attribute to sort the collaborators by, prefixed with '-' for a descending order. By default, the collaborators
+			are listed in the order they were added to the space, the members of the teams of the space last ('added_at')`, func() {
+				a.Enum("username", "-username", "added_at", "-added_at")
+			})
 			a.Param("page[offset]", d.String, "Paging start position")
 			a.Param("page[limit]", d.Integer, "Paging size")
 		})
+		a.Headers(func() {
+			a.Header("If-None-Match", d.String)
+		})
 		a.Response(d.OK, userList)
+		a.Response(d.NotModified)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
+	a.Action("list-activity", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/activity"),
+		)
+		a.Description("List the collaborators of the given space ID along with their last activity in the space.")
+		a.Response(d.OK, collaboratorActivityList)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
 
 	a.Action("add-many", func() {
@@ -29,8 +52,8 @@ var _ = a.Resource("collaborators", func() {
 		a.Routing(
 			a.POST(""),
 		)
-		a.Description("Add users to the list of space collaborators.")
-		a.Response(d.OK)
+		a.Description("Add users to the list of space collaborators. The response lists whether each user was added, already collaborated on the space, or was not found.")
+		a.Response(d.OK, collaboratorAdditionList)
 		a.Payload(updateUserIDList)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.BadRequest, JSONAPIErrors)
@@ -43,9 +66,10 @@ var _ = a.Resource("collaborators", func() {
 		a.Routing(
 			a.POST("/:identityID"),
 		)
-		a.Description("Add a user to the list of space collaborators.")
-		a.Response(d.OK)
+		a.Description("Add a user to the list of space collaborators. Adding a user who already collaborates on the space is a conflict.")
+		a.Response(d.OK, collaboratorAdditionList)
 		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
@@ -65,6 +89,79 @@ var _ = a.Resource("collaborators", func() {
 		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
 
+	a.Action("role", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/role"),
+		)
+		a.Description("Retrieve the role of the current user in the given space.")
+		a.Response(d.OK, spaceRole)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("permissions", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/permissions"),
+		)
+		a.Description("Retrieve the operations which the current user is allowed to perform in the given space.")
+		a.Response(d.OK, spacePermissions)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:identityID"),
+		)
+		a.Description("Set the role of a collaborator of the given space. Only the owner and the admins of the space are allowed to set the roles, and the role of the owner can't be changed.")
+		a.Payload(collaboratorSingle)
+		a.Response(d.OK, spaceRole)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("add-team", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/teams/:teamID"),
+		)
+		a.Description("Add a team to the space collaborators. The members of the team are contributors of the space for as long as they belong to the team.")
+		a.Params(func() {
+			a.Param("teamID", d.UUID, "ID of the team")
+		})
+		a.Response(d.OK)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("remove-team", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/teams/:teamID"),
+		)
+		a.Description("Remove a team from the space collaborators. Its members remain collaborators if they were added on their own.")
+		a.Params(func() {
+			a.Param("teamID", d.UUID, "ID of the team")
+		})
+		a.Response(d.OK)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
 	a.Action("remove", func() {
 		a.Security("jwt")
 		a.Routing(
@@ -94,3 +191,171 @@ var updateUserID = a.Type("UpdateUserID", func() {
 	})
 	a.Required("type", "id")
 })
+
+var collaboratorAdditionList = JSONList(
+	"CollaboratorAddition", "Holds the result of the addition of each user to the space collaborators",
+	collaboratorAddition,
+	nil,
+	nil,
+)
+
+// collaboratorAddition represents the result of the addition of a user to the space collaborators
+var collaboratorAddition = a.Type("CollaboratorAddition", func() {
+	a.Description(`JSONAPI store for the result of the addition of a space collaborator. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("id", d.String, "user identity ID")
+	a.Attribute("type", d.String, func() {
+		a.Enum("identities")
+	})
+	a.Attribute("attributes", collaboratorAdditionAttributes)
+	a.Required("type", "id", "attributes")
+})
+
+var collaboratorAdditionAttributes = a.Type("CollaboratorAdditionAttributes", func() {
+	a.Attribute("status", d.String, "Whether the user was added, already collaborated on the space, or was not found", func() {
+		a.Enum("added", "already-present", "not-found")
+	})
+	a.Required("status")
+})
+
+var collaboratorSingle = JSONSingle(
+	"Collaborator", "Holds the role of a space collaborator",
+	collaborator,
+	nil,
+)
+
+// collaborator represents a collaborator of a space along with its role
+var collaborator = a.Type("Collaborator", func() {
+	a.Description(`JSONAPI store for the data of a space collaborator. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("id", d.String, "user identity ID")
+	a.Attribute("type", d.String, func() {
+		a.Enum("identities")
+	})
+	a.Attribute("attributes", collaboratorAttributes)
+	a.Required("type", "attributes")
+})
+
+var collaboratorAttributes = a.Type("CollaboratorAttributes", func() {
+	a.Attribute("role", d.String, "Role of the collaborator in the space", func() {
+		a.Enum("admin", "contributor", "viewer")
+	})
+	a.Required("role")
+})
+
+// collaboratorActivity represents the last activity of a collaborator in a space
+var collaboratorActivity = a.Type("CollaboratorActivity", func() {
+	a.Attribute("identityID", d.UUID, "ID of the collaborator identity")
+	a.Attribute("username", d.String, "Username of the collaborator")
+	a.Attribute("lastActivity", d.DateTime, "When the collaborator last created, updated or commented a work item of the space, if ever")
+	a.Required("identityID", "username")
+})
+
+// collaboratorActivityList represents the last activity of the collaborators of a space
+var collaboratorActivityList = a.MediaType("application/vnd.collaborator-activity-list+json", func() {
+	a.TypeName("CollaboratorActivityList")
+	a.Description("Last activity of the collaborators of a space")
+	a.Attribute("data", a.ArrayOf(collaboratorActivity))
+	a.Required("data")
+	a.View("default", func() {
+		a.Attribute("data")
+	})
+})
+
+// spaceRole represents the role of a user in a space
+var spaceRole = a.MediaType("application/vnd.space-role+json", func() {
+	a.TypeName("SpaceRole")
+	a.Description("Role of a user in a space")
+	a.Attribute("spaceID", d.UUID, "ID of the space")
+	a.Attribute("identityID", d.UUID, "ID of the user identity")
+	a.Attribute("role", d.String, "Role of the user in the space", func() {
+		a.Enum("owner", "admin", "contributor", "viewer", "none")
+	})
+	a.Required("spaceID", "identityID", "role")
+	a.View("default", func() {
+		a.Attribute("spaceID")
+		a.Attribute("identityID")
+		a.Attribute("role")
+	})
+})
+
+// spaceMembership represents the role of a user in one of the spaces the user belongs to
+var spaceMembership = a.Type("SpaceMembership", func() {
+	a.Description("Role of a user in a space the user belongs to")
+	a.Attribute("spaceID", d.UUID, "ID of the space")
+	a.Attribute("spaceName", d.String, "Name of the space")
+	a.Attribute("role", d.String, "Role of the user in the space", func() {
+		a.Enum("owner", "admin", "contributor", "viewer")
+	})
+	a.Required("spaceID", "spaceName", "role")
+})
+
+// spaceMembershipArray represents all the space memberships of a user
+var spaceMembershipArray = a.MediaType("application/vnd.space-membership-array+json", func() {
+	a.TypeName("SpaceMembershipArray")
+	a.Description("Space memberships of a user")
+	a.Attribute("identityID", d.UUID, "ID of the user identity")
+	a.Attribute("data", a.ArrayOf(spaceMembership))
+	a.Required("identityID", "data")
+	a.View("default", func() {
+		a.Attribute("identityID")
+		a.Attribute("data")
+	})
+})
+
+// spacePermissions represents the operations which a user is allowed to perform in a space
+var spacePermissions = a.MediaType("application/vnd.space-permissions+json", func() {
+	a.TypeName("SpacePermissions")
+	a.Description("Operations which a user is allowed to perform in a space, as granted by the role of the user")
+	a.Attribute("spaceID", d.UUID, "ID of the space")
+	a.Attribute("identityID", d.UUID, "ID of the user identity")
+	a.Attribute("role", d.String, "Role of the user in the space", func() {
+		a.Enum("owner", "admin", "contributor", "viewer", "none")
+	})
+	a.Attribute("canCreateItem", d.Boolean, "Whether the user can create work items in the space")
+	a.Attribute("canEditItems", d.Boolean, "Whether the user can edit the work items created by other users")
+	a.Attribute("canManageCollaborators", d.Boolean, "Whether the user can add or remove collaborators of the space, except its admins")
+	a.Attribute("canRemoveAdmins", d.Boolean, "Whether the user can remove the admins from the collaborators of the space")
+	a.Attribute("canUpdateSpace", d.Boolean, "Whether the user can update the space")
+	a.Attribute("canDeleteSpace", d.Boolean, "Whether the user can delete the space")
+	a.Required("spaceID", "identityID", "role", "canCreateItem", "canEditItems", "canManageCollaborators", "canRemoveAdmins", "canUpdateSpace", "canDeleteSpace")
+	a.View("default", func() {
+		a.Attribute("spaceID")
+		a.Attribute("identityID")
+		a.Attribute("role")
+		a.Attribute("canCreateItem")
+		a.Attribute("canEditItems")
+		a.Attribute("canManageCollaborators")
+		a.Attribute("canRemoveAdmins")
+		a.Attribute("canUpdateSpace")
+		a.Attribute("canDeleteSpace")
+	})
+})
+
+// collaboratorPoliciesDeduplication reports the outcome of removing the duplicated users from the space policies
+var collaboratorPoliciesDeduplication = a.MediaType("application/vnd.collaborator-policies-deduplication+json", func() {
+	a.TypeName("CollaboratorPoliciesDeduplication")
+	a.Description("Outcome of removing the duplicated users from the space collaborators policies")
+	a.Attribute("deduplicated", d.Integer, "Number of space policies which contained duplicated users")
+	a.Required("deduplicated")
+	a.View("default", func() {
+		a.Attribute("deduplicated")
+	})
+})
+
+var _ = a.Resource("collaborator-policies", func() {
+	a.BasePath("/collaborators")
+
+	a.Action("deduplicate", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/deduplicate"),
+		)
+		a.Description(`Remove the duplicated users from the collaborators policies of all the spaces.
+		Only the administrators are allowed to run it.`)
+		a.Response(d.OK, func() {
+			a.Media(collaboratorPoliciesDeduplication)
+		})
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+})
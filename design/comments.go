@@ -108,6 +108,42 @@ var createSingleComment = JSONSingle(
 	nil,
 )
 
+var bulkCreateCommentsData = a.Type("BulkCreateCommentsData", func() {
+	a.Description("The comment to post on each of the given work items")
+	a.Attribute("attributes", createCommentAttributes)
+	a.Attribute("workItems", a.ArrayOf(d.String), "IDs of the work items to comment", func() {
+		a.MinLength(1)
+	})
+	a.Required("attributes", "workItems")
+})
+
+var bulkCreateComments = a.Type("BulkCreateComments", func() {
+	a.Description("Holds the data to post the same comment on several work items")
+	a.Attribute("data", bulkCreateCommentsData)
+	a.Required("data")
+})
+
+var bulkCommentResult = a.Type("BulkCommentResult", func() {
+	a.Description("Result of posting a comment on a work item")
+	a.Attribute("workItemID", d.String, "ID of the work item")
+	a.Attribute("status", d.String, "Whether the comment was created on the work item", func() {
+		a.Enum("created", "forbidden", "not_found")
+	})
+	a.Attribute("comment", comment, "The created comment")
+	a.Required("workItemID", "status")
+})
+
+// bulkCommentResults represents the results of posting a comment on several work items
+var bulkCommentResults = a.MediaType("application/vnd.bulk-comment-results+json", func() {
+	a.TypeName("BulkCommentResults")
+	a.Description("Results of posting a comment on several work items, in the order of the request")
+	a.Attribute("data", a.ArrayOf(bulkCommentResult))
+	a.Required("data")
+	a.View("default", func() {
+		a.Attribute("data")
+	})
+})
+
 var _ = a.Resource("comments", func() {
 	a.BasePath("/comments")
 
@@ -161,6 +197,23 @@ var _ = a.Resource("comments", func() {
 		a.Response(d.Unauthorized, JSONAPIErrors)
 		a.Response(d.Forbidden, JSONAPIErrors)
 	})
+	a.Action("bulk-create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/bulk"),
+		)
+		a.Description(`Post the same comment on each of the given work items, in a single transaction.
+		The work items which do not exist or which belong to a space the user has no access to are reported as such.
+		Only the administrators are allowed to post bulk comments.`)
+		a.Payload(bulkCreateComments)
+		a.Response(d.OK, func() {
+			a.Media(bulkCommentResults)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
 
 })
 
@@ -0,0 +1,129 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+// invitation represents an invitation to collaborate on a space
+var invitation = a.Type("Invitation", func() {
+	a.Description(`JSONAPI store for the data of an invitation to collaborate on a space. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("invitations")
+	})
+	a.Attribute("id", d.UUID, "ID of the invitation", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", invitationAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var invitationAttributes = a.Type("InvitationAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of an invitation. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("identityID", d.UUID, "ID of the invited user identity. Either the identity or the email of the invitee is required")
+	a.Attribute("email", d.String, "Email address of the invitee, who may not have an account yet", func() {
+		a.Example("john@example.com")
+	})
+	a.Attribute("role", d.String, "Role of the invitee in the space once the invitation is accepted. Contributor if not given", func() {
+		a.Enum("admin", "contributor", "viewer")
+	})
+	a.Attribute("state", d.String, "State of the invitation. Read-only", func() {
+		a.Enum("pending", "accepted", "revoked")
+	})
+	a.Attribute("inviterID", d.UUID, "ID of the user identity who sent the invitation. Read-only")
+	a.Attribute("spaceID", d.UUID, "ID of the space. Read-only")
+	a.Attribute("created-at", d.DateTime, "When the invitation was sent. Read-only", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var invitationSingle = JSONSingle(
+	"Invitation", "Holds a single invitation to collaborate on a space",
+	invitation,
+	nil)
+
+var invitationList = JSONList(
+	"Invitation", "Holds the list of the invitations to collaborate on a space",
+	invitation,
+	nil,
+	nil)
+
+var _ = a.Resource("space_invitations", func() {
+	a.Parent("space")
+	a.BasePath("/invitations")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the pending invitations to collaborate on the given space, the oldest first. Restricted to the owner and the admins of the space.")
+		a.Response(d.OK, func() {
+			a.Media(invitationList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description(`Invite a user, given by identity or by email address, to collaborate on the given space. The invitee is notified,
+		and becomes a collaborator only when accepting the invitation. Restricted to the owner and the admins of the space.`)
+		a.Payload(invitationSingle)
+		a.Response(d.Created, "/invitations/.*", func() {
+			a.Media(invitationSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+
+	a.Action("revoke", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:invitationID"),
+		)
+		a.Description("Revoke the given pending invitation to collaborate on the given space. Restricted to the owner and the admins of the space.")
+		a.Params(func() {
+			a.Param("invitationID", d.UUID, "ID of the invitation")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
+
+var _ = a.Resource("invitations", func() {
+	a.BasePath("/invitations")
+
+	a.Action("accept", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:invitationID/accept"),
+		)
+		a.Description(`Accept the given pending invitation, which adds the authenticated user to the collaborators of the space.
+		Restricted to the invited identity, or to the user whose email address was invited.`)
+		a.Params(func() {
+			a.Param("invitationID", d.UUID, "ID of the invitation")
+		})
+		a.Response(d.OK, spaceRole)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
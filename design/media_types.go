@@ -94,6 +94,32 @@ var TrackerQuery = a.MediaType("application/vnd.trackerquery+json", func() {
 	})
 })
 
+// TrackerQueryImportStatus represents the progress of the latest import job of a tracker query
+var TrackerQueryImportStatus = a.MediaType("application/vnd.trackerquery-import-status+json", func() {
+	a.TypeName("TrackerQueryImportStatus")
+	a.Description("Progress of the latest import job of a tracker query")
+	a.Attribute("id", d.String, "ID of the tracker query")
+	a.Attribute("running", d.Boolean, "Whether the import job is still running")
+	a.Attribute("processed", d.Integer, "Number of remote items processed so far")
+	a.Attribute("failed", d.Integer, "Number of remote items which failed to import")
+	a.Attribute("startedAt", d.DateTime, "When the import job started")
+	a.Attribute("finishedAt", d.DateTime, "When the import job finished")
+
+	a.Required("id")
+	a.Required("running")
+	a.Required("processed")
+	a.Required("failed")
+
+	a.View("default", func() {
+		a.Attribute("id")
+		a.Attribute("running")
+		a.Attribute("processed")
+		a.Attribute("failed")
+		a.Attribute("startedAt")
+		a.Attribute("finishedAt")
+	})
+})
+
 var trackerQueryRelationships = a.Type("TrackerQueryRelationships", func() {
 	a.Attribute("space", relationSpaces, "This defines the owning space of this work item type.")
 })
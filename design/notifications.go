@@ -0,0 +1,51 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+// notificationUnreadCount reports the number of unread notifications of an identity
+var notificationUnreadCount = a.MediaType("application/vnd.notification-unread-count+json", func() {
+	a.TypeName("NotificationUnreadCount")
+	a.Description("Number of unread notifications of the authenticated user")
+	a.Attribute("unread", d.Integer, "Number of notifications which have not been read yet")
+	a.Required("unread")
+	a.View("default", func() {
+		a.Attribute("unread")
+	})
+})
+
+var _ = a.Resource("notification", func() {
+	a.BasePath("/notifications")
+
+	a.Action("unread-count", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/unread-count"),
+		)
+		a.Description("Get the number of unread notifications of the authenticated user")
+		a.Response(d.OK, func() {
+			a.Media(notificationUnreadCount)
+		})
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+	a.Action("mark-read", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:notificationID/read"),
+		)
+		a.Description("Mark a notification of the authenticated user as read and get the remaining number of unread notifications")
+		a.Params(func() {
+			a.Param("notificationID", d.UUID, "Notification Identifier")
+		})
+		a.Response(d.OK, func() {
+			a.Media(notificationUnreadCount)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+})
@@ -166,6 +166,21 @@ var _ = a.Resource("trackerquery", func() {
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
+	a.Action("status", func() {
+		a.Routing(
+			a.GET("/:id/status"),
+		)
+		a.Description("Retrieve the progress of the latest import job of the tracker query with the given id.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK, func() {
+			a.Media(TrackerQueryImportStatus)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
 	a.Action("list", func() {
 		a.Routing(
 			a.GET(""),
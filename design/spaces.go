@@ -74,6 +74,22 @@ var spaceAttributes = a.Type("SpaceAttributes", func() {
 	a.Attribute("updated-at", d.DateTime, "When the space was updated", func() {
 		a.Example("2016-11-29T23:18:14Z")
 	})
+	a.Attribute("theme", spaceTheme, "Optional branding of the space")
+	a.Attribute("unique-titles-per-iteration", d.Boolean, "Whether work items of the same iteration must have distinct titles", func() {
+		a.Example(false)
+	})
+})
+
+var spaceTheme = a.Type("SpaceTheme", func() {
+	a.Attribute("primary-color", d.String, "Primary color of the space, as an hexadecimal RGB value (empty to use the default color)", func() {
+		a.Example("#00aaff")
+	})
+	a.Attribute("accent-color", d.String, "Accent color of the space, as an hexadecimal RGB value (empty to use the default color)", func() {
+		a.Example("#ff5500")
+	})
+	a.Attribute("logo-url", d.String, "Absolute HTTP(S) URL of the space logo (empty to use the default logo)", func() {
+		a.Example("https://example.com/logo.png")
+	})
 })
 
 var spaceListMeta = a.Type("SpaceListMeta", func() {
@@ -92,6 +108,58 @@ var spaceSingle = JSONSingle(
 	space,
 	nil)
 
+// spaceStatistics represents the amount of content stored in a space
+var spaceStatistics = a.MediaType("application/vnd.space-statistics+json", func() {
+	a.TypeName("SpaceStatistics")
+	a.Description("Amount of content stored in a space")
+	a.Attribute("spaceID", d.UUID, "ID of the space")
+	a.Attribute("workItems", d.Integer, "Number of work items in the space")
+	a.Attribute("comments", d.Integer, "Number of comments on the work items of the space")
+	a.Required("spaceID", "workItems", "comments")
+	a.View("default", func() {
+		a.Attribute("spaceID")
+		a.Attribute("workItems")
+		a.Attribute("comments")
+	})
+})
+
+// spaceSettings gathers all the settings which apply to a space in a single document
+var spaceSettings = a.MediaType("application/vnd.space-settings+json", func() {
+	a.TypeName("SpaceSettings")
+	a.Description("Settings which apply to a space, either set on the space itself or inherited from the service configuration")
+	a.Attribute("spaceID", d.UUID, "ID of the space")
+	a.Attribute("theme", spaceTheme, "Branding of the space")
+	a.Attribute("uniqueTitlesPerIteration", d.Boolean, "Whether work items of the same iteration must have distinct titles")
+	a.Attribute("workItems", spaceWorkItemSettings, "Rules applied to the work items of the space")
+	a.Attribute("limits", spaceLimits, "Limits applied to the content of the space")
+	a.Required("spaceID", "theme", "uniqueTitlesPerIteration", "workItems", "limits")
+	a.View("default", func() {
+		a.Attribute("spaceID")
+		a.Attribute("theme")
+		a.Attribute("uniqueTitlesPerIteration")
+		a.Attribute("workItems")
+		a.Attribute("limits")
+	})
+})
+
+var spaceWorkItemSettings = a.Type("SpaceWorkItemSettings", func() {
+	a.Attribute("assigneeCollaboratorRequired", d.Boolean, "Whether work items can only be assigned to the collaborators of the space")
+	a.Attribute("removedCollaboratorAssignments", d.String, `What happens to the work items assigned to a removed collaborator: "keep", "unassign" or "owner"`)
+	a.Attribute("closedIterationAssignment", d.String, `How the assignment of work items to a closed or past iteration is handled: "allow", "warn" or "reject"`)
+	a.Attribute("strictPayload", d.Boolean, "Whether work item payloads containing unknown attributes are rejected")
+	a.Attribute("duplicateAutoClose", d.Boolean, "Whether the work items marked as duplicate are closed")
+	a.Attribute("restoreWindow", d.String, "How long a deleted work item can be restored, as a duration", func() {
+		a.Example("720h0m0s")
+	})
+	a.Required("assigneeCollaboratorRequired", "removedCollaboratorAssignments", "closedIterationAssignment", "strictPayload", "duplicateAutoClose", "restoreWindow")
+})
+
+var spaceLimits = a.Type("SpaceLimits", func() {
+	a.Attribute("commentMaxLength", d.Integer, "Maximum length of a comment body")
+	a.Attribute("bulkUpdateBatchSize", d.Integer, "Maximum number of work items updated within a single transaction by a bulk update")
+	a.Required("commentMaxLength", "bulkUpdateBatchSize")
+})
+
 // relationSpaces is the JSONAPI store for the spaces
 var relationSpaces = a.Type("RelationSpaces", func() {
 	a.Attribute("data", relationSpacesData)
@@ -129,6 +197,37 @@ var _ = a.Resource("space", func() {
 		a.Response(d.NotFound, JSONAPIErrors)
 	})
 
+	a.Action("statistics", func() {
+		a.Routing(
+			a.GET("/:id/statistics"),
+		)
+		a.Description("Retrieve the number of work items and comments stored in the space with the given ID.")
+		a.Params(func() {
+			a.Param("id", d.String, "ID of the space")
+		})
+		a.Response(d.OK, spaceStatistics)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+
+	a.Action("settings", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id/settings"),
+		)
+		a.Description("Retrieve all the settings which apply to the space with the given ID. Restricted to the space owner and collaborators.")
+		a.Params(func() {
+			a.Param("id", d.String, "ID of the space")
+		})
+		a.Response(d.OK, spaceSettings)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
 	a.Action("list", func() {
 		a.Routing(
 			a.GET(""),
@@ -145,6 +244,24 @@ var _ = a.Resource("space", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 	})
 
+	a.Action("list-owned", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/owned"),
+		)
+		a.Description("List the spaces owned by the current user.")
+		a.Params(func() {
+			a.Param("page[offset]", d.String, "Paging start position")
+			a.Param("page[limit]", d.Integer, "Paging size")
+		})
+		a.UseTrait("conditional")
+		a.Response(d.OK, spaceList)
+		a.Response(d.NotModified)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
 	a.Action("create", func() {
 		a.Security("jwt")
 		a.Routing(
@@ -0,0 +1,149 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+// team represents a named group of users, which can be added as a whole to the collaborators of spaces
+var team = a.Type("Team", func() {
+	a.Description(`JSONAPI store for the data of a team. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("teams")
+	})
+	a.Attribute("id", d.UUID, "ID of the team", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", teamAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var teamAttributes = a.Type("TeamAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a team. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("name", d.String, "The unique name of the team", func() {
+		a.MinLength(1)
+		a.MaxLength(62)
+		a.Example("planner-ui")
+	})
+	a.Attribute("description", d.String, "The description of the team", func() {
+		a.Example("The developers of the planner UI")
+	})
+	a.Attribute("created-at", d.DateTime, "When the team was created. Read-only", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("updated-at", d.DateTime, "When the team or its members were last updated. Read-only", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var teamSingle = JSONSingle(
+	"Team", "Holds a single team",
+	team,
+	nil)
+
+var teamList = JSONList(
+	"Team", "Holds the list of teams",
+	team,
+	nil,
+	nil)
+
+var _ = a.Resource("teams", func() {
+	a.BasePath("/teams")
+
+	a.Action("list", func() {
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List all the teams, ordered by name.")
+		a.Response(d.OK, func() {
+			a.Media(teamList)
+		})
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
+	a.Action("show", func() {
+		a.Routing(
+			a.GET("/:teamID"),
+		)
+		a.Description("Retrieve the team with the given ID.")
+		a.Params(func() {
+			a.Param("teamID", d.UUID, "ID of the team")
+		})
+		a.Response(d.OK, func() {
+			a.Media(teamSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Create a team, whose first member is the authenticated user.")
+		a.Payload(teamSingle)
+		a.Response(d.Created, "/teams/.*", func() {
+			a.Media(teamSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+})
+
+var _ = a.Resource("teams_members", func() {
+	a.Parent("teams")
+	a.BasePath("/members")
+
+	a.Action("list", func() {
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the members of the given team, in the order they joined the team.")
+		a.Response(d.OK, func() {
+			a.Media(userArray)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+
+	a.Action("add", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:identityID"),
+		)
+		a.Description(`Add a user to the members of the given team. The user becomes a collaborator of all the spaces of the team.
+		Restricted to the members of the team.`)
+		a.Params(func() {
+			a.Param("identityID", d.UUID, "ID of the user identity")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("remove", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:identityID"),
+		)
+		a.Description(`Remove a user from the members of the given team. The user is no longer a collaborator of the spaces of the team,
+		unless the user is a collaborator on their own. Restricted to the members of the team.`)
+		a.Params(func() {
+			a.Param("identityID", d.UUID, "ID of the user identity")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
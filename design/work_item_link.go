@@ -116,6 +116,41 @@ var workItemLinkList = JSONList(
 	workItemLinkListMeta,
 )
 
+// workItemDependencyNode is a work item of a dependency graph
+var workItemDependencyNode = a.Type("WorkItemDependencyNode", func() {
+	a.Attribute("id", d.String, "ID of the work item", func() {
+		a.Example("1234")
+	})
+	a.Attribute("spaceID", d.UUID, "ID of the space of the work item")
+	a.Attribute("title", d.String, "Title of the work item")
+	a.Required("id", "spaceID")
+})
+
+// workItemDependencyEdge is a work item link of a dependency graph
+var workItemDependencyEdge = a.Type("WorkItemDependencyEdge", func() {
+	a.Attribute("id", d.UUID, "ID of the work item link")
+	a.Attribute("source", d.String, "ID of the work item where the connection starts")
+	a.Attribute("target", d.String, "ID of the work item where the connection ends")
+	a.Attribute("linkTypeID", d.UUID, "ID of the work item link type")
+	a.Attribute("name", d.String, "Forward name of the work item link type", func() {
+		a.Example("blocks")
+	})
+	a.Required("id", "source", "target", "linkTypeID", "name")
+})
+
+// workItemDependencyGraph is the media type for the dependencies between work items of different spaces
+var workItemDependencyGraph = a.MediaType("application/vnd.work-item-dependency-graph+json", func() {
+	a.TypeName("WorkItemDependencyGraph")
+	a.Description("Dependency links between work items of different spaces, with the linked work items")
+	a.Attribute("nodes", a.ArrayOf(workItemDependencyNode))
+	a.Attribute("edges", a.ArrayOf(workItemDependencyEdge))
+	a.Required("nodes", "edges")
+	a.View("default", func() {
+		a.Attribute("nodes")
+		a.Attribute("edges")
+	})
+})
+
 // ############################################################################
 //
 //  Resource Definition
@@ -129,6 +164,7 @@ var _ = a.Resource("work_item_link", func() {
 	a.Action("create", createWorkItemLink)
 	a.Action("delete", deleteWorkItemLink)
 	a.Action("update", updateWorkItemLink)
+	a.Action("dependencies", listCrossSpaceDependencies)
 })
 
 var _ = a.Resource("work_item_relationships_links", func() {
@@ -163,6 +199,25 @@ func listWorkItemLinks() {
 	a.Response(d.InternalServerError, JSONAPIErrors)
 }
 
+// listCrossSpaceDependencies defines the action listing the dependency links
+// which cross the boundaries of the given spaces.
+func listCrossSpaceDependencies() {
+	a.Description(`Retrieve the "blocks" and "depends on" links between work items of different spaces, one of them
+	being among the given spaces, as a graph. Only the links between spaces which the user has access to are returned.`)
+	a.Security("jwt")
+	a.Routing(
+		a.GET("/dependencies"),
+	)
+	a.Params(func() {
+		a.Param("space", a.ArrayOf(d.UUID), "IDs of the spaces whose dependencies to list")
+		a.Required("space")
+	})
+	a.Response(d.OK, workItemDependencyGraph)
+	a.Response(d.BadRequest, JSONAPIErrors)
+	a.Response(d.InternalServerError, JSONAPIErrors)
+	a.Response(d.Unauthorized, JSONAPIErrors)
+}
+
 func showWorkItemLink() {
 	a.Description("Retrieve work item link (as JSONAPI) for the given link ID.")
 	a.Routing(
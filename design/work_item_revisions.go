@@ -0,0 +1,59 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var workItemRevisionIterationChange = a.Type("WorkItemRevisionIterationChange", func() {
+	a.Description(`The iteration change recorded by a work item revision`)
+	a.Attribute("from", d.String, "ID of the iteration the work item was moved from (empty if it had none)", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("to", d.String, "ID of the iteration the work item was moved to (empty if it was removed from its iteration)", func() {
+		a.Example("6c5610be-30b2-4880-9fec-81e4f8e4fd76")
+	})
+})
+
+var workItemRevisionAttributes = a.Type("WorkItemRevisionAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a work item revision. +See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("revision-type", d.String, "The type of operation which created this revision", func() {
+		a.Enum("create", "update", "delete")
+	})
+	a.Attribute("revision-time", d.DateTime, "When the revision was created", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("version", d.Integer, "The version of the work item at this revision")
+	a.Attribute("iteration-change", workItemRevisionIterationChange, "Set when the work item was moved to another iteration in this revision")
+	a.Required("revision-type", "revision-time", "version")
+})
+
+var workItemRevisionRelationships = a.Type("WorkItemRevisionRelationships", func() {
+	a.Attribute("modifier", relationGeneric, "This defines the identity who created this revision")
+})
+
+var workItemRevision = JSONResourceObject("WorkItemRevision", workItemRevisionAttributes, workItemRevisionRelationships)
+
+var workItemRevisionList = JSONList(
+	"WorkItemRevision", "Holds the list of revisions of a work item",
+	workItemRevision,
+	nil,
+	nil)
+
+var _ = a.Resource("work_item_revisions", func() {
+	a.Parent("workitem")
+
+	a.Action("list", func() {
+		a.Routing(
+			a.GET("revisions"),
+		)
+		a.Description("List the revisions of the given work item, oldest first")
+		a.Params(func() {
+			a.Param("filter[iteration-changes]", d.Boolean, "Only return the revisions in which the work item was moved to another iteration")
+		})
+		a.Response(d.OK, workItemRevisionList)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+})
@@ -105,6 +105,106 @@ var workItemReorder = Reorder(
 	workItem,
 	position)
 
+// workItemBulkUpdate selects work items by a filter and moves them to the given area and/or iteration
+var workItemBulkUpdate = a.Type("WorkItemBulkUpdate", func() {
+	a.Attribute("filter", d.String, "a query language expression restricting the set of work items to update")
+	a.Attribute("area", d.UUID, "ID of the area to move the matching work items to")
+	a.Attribute("iteration", d.UUID, "ID of the iteration to move the matching work items to")
+	a.Required("filter")
+})
+
+// workItemBulkUpdateFailure explains why a work item was not updated by a bulk update
+var workItemBulkUpdateFailure = a.Type("WorkItemBulkUpdateFailure", func() {
+	a.Attribute("id", d.String, "ID of the work item which was not updated")
+	a.Attribute("reason", d.String, "Why the work item was not updated")
+	a.Required("id", "reason")
+})
+
+// workItemBulkUpdateResult reports the outcome of a bulk update of work items
+var workItemBulkUpdateResult = a.MediaType("application/vnd.workitem-bulk-update-result+json", func() {
+	a.TypeName("WorkItemBulkUpdateResult")
+	a.Description("Outcome of a bulk update of work items")
+	a.Attribute("updated", d.Integer, "Number of work items which were updated")
+	a.Attribute("failed", a.ArrayOf(workItemBulkUpdateFailure), "Work items which were not updated")
+	a.Required("updated", "failed")
+	a.View("default", func() {
+		a.Attribute("updated")
+		a.Attribute("failed")
+	})
+})
+
+// workItemBulkDueDateUpdate selects work items by a filter and sets their due date
+var workItemBulkDueDateUpdate = a.Type("WorkItemBulkDueDateUpdate", func() {
+	a.Attribute("filter", d.String, "a query language expression restricting the set of work items to update")
+	a.Attribute("dueDate", d.DateTime, "Due date to set on the matching work items")
+	a.Required("filter", "dueDate")
+})
+
+// workItemBulkStateUpdate selects work items by a filter and sets them to the given state
+var workItemBulkStateUpdate = a.Type("WorkItemBulkStateUpdate", func() {
+	a.Attribute("filter", d.String, "a query language expression restricting the set of work items to update")
+	a.Attribute("state", d.String, "State to set on the matching work items", func() {
+		a.Example("closed")
+	})
+	a.Required("filter", "state")
+})
+
+// workItemBulkStateUpdateSkip explains why a work item was not set to the requested state
+var workItemBulkStateUpdateSkip = a.Type("WorkItemBulkStateUpdateSkip", func() {
+	a.Attribute("id", d.String, "ID of the work item which was skipped")
+	a.Attribute("reason", d.String, "Why the work item was skipped")
+	a.Required("id", "reason")
+})
+
+// workItemBulkStateUpdateResult reports the outcome of a bulk state update of work items
+var workItemBulkStateUpdateResult = a.MediaType("application/vnd.workitem-bulk-state-update-result+json", func() {
+	a.TypeName("WorkItemBulkStateUpdateResult")
+	a.Description("Outcome of a bulk state update of work items")
+	a.Attribute("updated", a.ArrayOf(d.String), "IDs of the work items which were set to the requested state")
+	a.Attribute("skipped", a.ArrayOf(workItemBulkStateUpdateSkip), "Work items which were not updated")
+	a.Required("updated", "skipped")
+	a.View("default", func() {
+		a.Attribute("updated")
+		a.Attribute("skipped")
+	})
+})
+
+// workItemTransfer selects work items to move to another space
+var workItemTransfer = a.Type("WorkItemTransfer", func() {
+	a.Attribute("space", d.UUID, "ID of the space to move the work items to")
+	a.Attribute("workitems", a.ArrayOf(d.String), "IDs of the work items to move")
+	a.Required("space", "workitems")
+})
+
+// workItemTransferFailure explains why a work item could not be moved to another space
+var workItemTransferFailure = a.Type("WorkItemTransferFailure", func() {
+	a.Attribute("id", d.String, "ID of the work item which was not moved")
+	a.Attribute("reason", d.String, "Why the work item was not moved")
+	a.Required("id", "reason")
+})
+
+// workItemTransferFallback explains why a moved work item was put in the root area or iteration of the target space
+var workItemTransferFallback = a.Type("WorkItemTransferFallback", func() {
+	a.Attribute("id", d.String, "ID of the work item which was moved")
+	a.Attribute("reason", d.String, "Why the work item was put in the root area or iteration of the target space")
+	a.Required("id", "reason")
+})
+
+// workItemTransferResult reports the outcome of moving work items to another space
+var workItemTransferResult = a.MediaType("application/vnd.workitem-transfer-result+json", func() {
+	a.TypeName("WorkItemTransferResult")
+	a.Description("Outcome of moving work items to another space")
+	a.Attribute("moved", a.ArrayOf(d.String), "IDs of the work items which were moved")
+	a.Attribute("failed", a.ArrayOf(workItemTransferFailure), "Work items which were not moved")
+	a.Attribute("fallbacks", a.ArrayOf(workItemTransferFallback), "Moved work items whose area or iteration has no equivalent in the target space, and which were put in its root area or iteration")
+	a.Required("moved", "failed", "fallbacks")
+	a.View("default", func() {
+		a.Attribute("moved")
+		a.Attribute("failed")
+		a.Attribute("fallbacks")
+	})
+})
+
 // new version of "list" for migration
 var _ = a.Resource("workitem", func() {
 	a.Parent("space")
@@ -124,6 +224,21 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 	})
+	a.Action("show-by-number", func() {
+		a.Routing(
+			a.GET("/number/:number"),
+		)
+		a.Description("Retrieve the work item with the given number in the space.")
+		a.Params(func() {
+			a.Param("number", d.Integer, "number of the work item in the space")
+		})
+		a.UseTrait("conditional")
+		a.Response(d.OK, workItemSingle)
+		a.Response(d.NotModified)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
 	a.Action("list", func() {
 		a.Routing(
 			a.GET(""),
@@ -133,10 +248,13 @@ var _ = a.Resource("workitem", func() {
 			a.Param("filter", d.String, "a query language expression restricting the set of found work items")
 			a.Param("page[offset]", d.String, "Paging start position")
 			a.Param("page[limit]", d.Integer, "Paging size")
-			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user")
-			a.Param("filter[iteration]", d.String, "IterationID to filter work items")
+			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user, not assigned to anyone if set to 'none', or assigned to a deactivated user if set to 'deactivated'")
+			a.Param("filter[iteration]", d.String, "IterationID to filter work items, or work items without any iteration if set to 'none'")
 			a.Param("filter[workitemtype]", d.UUID, "ID of work item type to filter work items by")
-			a.Param("filter[area]", d.String, "AreaID to filter work items")
+			a.Param("filter[area]", d.String, "AreaID to filter work items, or work items without any area if set to 'none'")
+			a.Param("filter[excludearea]", d.String, "AreaID to exclude from work items, along with all its descendant areas")
+			a.Param("filter[createdfrom]", d.DateTime, "Work items created at or after the given time")
+			a.Param("filter[createdto]", d.DateTime, "Work items created at or before the given time")
 			a.Param("filter[workitemstate]", d.String, "work item state to filter work items by")
 			a.Param("filter[parentexists]", d.Boolean, "if false list work items without any parent")
 		})
@@ -175,6 +293,8 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
 	})
 	a.Action("delete", func() {
 		a.Security("jwt")
@@ -191,6 +311,7 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
 	})
 	a.Action("update", func() {
 		a.Security("jwt")
@@ -209,6 +330,110 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+	})
+	a.Action("mark-duplicate", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:wiId/duplicate-of/:canonicalId"),
+		)
+		a.Description(`mark the work item with the given id as the duplicate of the canonical work item,
+		linking them with a "duplicate of" link and closing the duplicate if configured so`)
+		a.Params(func() {
+			a.Param("wiId", d.String, "wiId")
+			a.Param("canonicalId", d.String, "ID of the canonical work item")
+		})
+		a.Response(d.OK, func() {
+			a.Media(workItemSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+	a.Action("bulk-update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/bulk"),
+		)
+		a.Description("move all work items matching the given filter to the given area and/or iteration, reporting the work items which could not be updated")
+		a.Payload(workItemBulkUpdate)
+		a.Response(d.OK, func() {
+			a.Media(workItemBulkUpdateResult)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+	a.Action("bulk-set-due-date", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/bulk/duedate"),
+		)
+		a.Description("set the due date of all work items matching the given filter, skipping the work items whose type has no due date")
+		a.Payload(workItemBulkDueDateUpdate)
+		a.Response(d.OK, func() {
+			a.Media(workItemBulkUpdateResult)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+	a.Action("bulk-set-state", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/bulk/state"),
+		)
+		a.Description("set all work items matching the given filter to the given state, skipping the work items which are already in that state or whose type does not allow it")
+		a.Payload(workItemBulkStateUpdate)
+		a.Response(d.OK, func() {
+			a.Media(workItemBulkStateUpdateResult)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+	a.Action("transfer", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/transfer"),
+		)
+		a.Description("move the given work items to another space, mapping their area, iteration and type to the ones of the target space, and report the work items which could not be moved")
+		a.Payload(workItemTransfer)
+		a.Response(d.OK, func() {
+			a.Media(workItemTransferResult)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+	a.Action("restore", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:wiId/restore"),
+		)
+		a.Description("restore the deleted work item with the given id, as long as it has not been purged yet.")
+		a.Params(func() {
+			a.Param("wiId", d.String, "wiId")
+		})
+		a.Response(d.OK, func() {
+			a.Media(workItemSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
 	})
 	a.Action("reorder", func() {
 		a.Security("jwt")
@@ -224,6 +449,7 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
 	})
 })
 
@@ -248,7 +474,7 @@ var _ = a.Resource("redirect_workitem", func() {
 			a.Param("filter", d.String, "a query language expression restricting the set of found work items")
 			a.Param("page[offset]", d.String, "Paging start position")
 			a.Param("page[limit]", d.Integer, "Paging size")
-			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user")
+			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user, not assigned to anyone if set to 'none', or assigned to a deactivated user if set to 'deactivated'")
 			a.Param("filter[iteration]", d.String, "IterationID to filter work items")
 			a.Param("filter[workitemtype]", d.UUID, "ID of work item type to filter work items by")
 			a.Param("filter[area]", d.String, "AreaID to filter work items")
@@ -303,7 +529,7 @@ var _ = a.Resource("planner_backlog", func() {
 			a.Param("filter", d.String, "a query language expression restricting the set of found work items")
 			a.Param("page[offset]", d.String, "Paging start position")
 			a.Param("page[limit]", d.Integer, "Paging size")
-			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user")
+			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user, not assigned to anyone if set to 'none', or assigned to a deactivated user if set to 'deactivated'")
 			a.Param("filter[workitemtype]", d.UUID, "ID of work item type to filter work items by")
 			a.Param("filter[area]", d.String, "AreaID to filter work items")
 		})
@@ -161,6 +161,31 @@ var _ = a.Resource("workitemtype", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 	})
 
+	a.Action("list-available", func() {
+		a.Routing(
+			a.GET("/available"),
+		)
+		a.Description("List the work item types which can be used in the space: the system types and the types of the space, ordered by name.")
+		a.Response(d.OK, workItemTypeList)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
+	a.Action("show-schema", func() {
+		a.Routing(
+			a.GET("/:witID/schema"),
+		)
+		a.Params(func() {
+			a.Param("witID", d.UUID, "ID of the work item type")
+		})
+		a.Description(`Retrieve the JSON Schema of the payload to create a work item of the given type,
+		including the custom fields of the type and their constraints.`)
+		a.Response(d.OK, "application/schema+json")
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+
 	a.Action("list-source-link-types", func() {
 		a.Routing(
 			a.GET("/:witID/source-link-types"),
@@ -95,6 +95,9 @@ func init() {
 	// structures to ignore during code generation (mostly because they correspond to model structures which were already taken into account)
 	ignoredStructs = []string{
 		"CommentRelationship",
+		// the ETags of the users and collaborators responses are computed in the controllers
+		"Identity",
+		"User",
 	}
 
 }
@@ -0,0 +1,113 @@
+package goasupport
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// ErrRequestTimeout is the class of errors returned when a request could not be processed within the configured timeout
+var ErrRequestTimeout = goa.NewErrorClass("request_timeout", http.StatusServiceUnavailable)
+
+// RequestTimeout cancels the context of the requests which are not processed within the given timeout
+// and returns an ErrRequestTimeout error instead of the handler response. It should be placed in the
+// middleware chain below the error handler middleware, so that the error is turned into a JSONAPI response,
+// and above the recover middleware, since the handler is executed in a separate goroutine.
+// A timeout lower than or equal to zero disables the middleware.
+func RequestTimeout(timeout time.Duration) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		if timeout <= 0 {
+			return h
+		}
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			// the handler writes its response in a buffer, with its own response data, so that nothing
+			// reaches the client if the request times out while the handler is still running
+			tw := &timeoutWriter{header: make(http.Header)}
+			hctx := goa.NewContext(ctx, tw, req, nil)
+			if reqData := goa.ContextRequest(ctx); reqData != nil {
+				*goa.ContextRequest(hctx) = *reqData
+			}
+			if respData := goa.ContextResponse(ctx); respData != nil {
+				goa.ContextResponse(hctx).Service = respData.Service
+			}
+			done := make(chan error, 1)
+			go func() {
+				done <- h(hctx, goa.ContextResponse(hctx), req)
+			}()
+			select {
+			case err := <-done:
+				tw.flush(rw)
+				return err
+			case <-ctx.Done():
+				tw.timeout()
+				return ErrRequestTimeout(fmt.Sprintf("the request could not be processed within %s", timeout))
+			}
+		}
+	}
+}
+
+// timeoutWriter buffers the response of a handler until it is flushed or discarded on timeout
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+// Header implements http.ResponseWriter
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write implements http.ResponseWriter
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// WriteHeader implements http.ResponseWriter
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// timeout discards the response and rejects all subsequent writes
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = true
+	tw.buf.Reset()
+}
+
+// flush copies the buffered response into the given writer
+func (tw *timeoutWriter) flush(rw http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for k, v := range tw.header {
+		rw.Header()[k] = v
+	}
+	if tw.code == 0 {
+		return
+	}
+	rw.WriteHeader(tw.code)
+	rw.Write(tw.buf.Bytes())
+}
@@ -0,0 +1,90 @@
+package goasupport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/goasupport"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func newRequestContext(t *testing.T) (context.Context, *httptest.ResponseRecorder, *http.Request) {
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/workitems", nil)
+	require.Nil(t, err)
+	return goa.NewContext(context.Background(), rw, req, nil), rw, req
+}
+
+func TestRequestTimeoutCutsOffSlowHandler(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	cancelled := make(chan error, 1)
+	handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		select {
+		case <-ctx.Done():
+			cancelled <- ctx.Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("too late"))
+		return nil
+	}
+	ctx, rw, req := newRequestContext(t)
+	// when
+	err := goasupport.RequestTimeout(50*time.Millisecond)(handler)(ctx, goa.ContextResponse(ctx), req)
+	// then
+	require.NotNil(t, err)
+	serviceErr, ok := errs.Cause(err).(goa.ServiceError)
+	require.True(t, ok, "expected a goa.ServiceError but got %T", err)
+	assert.Equal(t, http.StatusServiceUnavailable, serviceErr.ResponseStatus())
+	assert.Equal(t, context.DeadlineExceeded, <-cancelled)
+	// nothing written by the handler reaches the client
+	assert.False(t, goa.ContextResponse(ctx).Written())
+	assert.Empty(t, rw.Body.String())
+}
+
+func TestRequestTimeoutLetsFastHandlerRespond(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("done"))
+		return nil
+	}
+	ctx, rw, req := newRequestContext(t)
+	// when
+	err := goasupport.RequestTimeout(time.Second)(handler)(ctx, goa.ContextResponse(ctx), req)
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, rw.Code)
+	assert.Equal(t, http.StatusCreated, goa.ContextResponse(ctx).Status)
+	assert.Equal(t, "text/plain", rw.Header().Get("Content-Type"))
+	assert.Equal(t, "done", rw.Body.String())
+}
+
+func TestRequestTimeoutDisabled(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.False(t, hasDeadline)
+		return nil
+	}
+	ctx, _, req := newRequestContext(t)
+	// when
+	err := goasupport.RequestTimeout(0)(handler)(ctx, goa.ContextResponse(ctx), req)
+	// then
+	require.Nil(t, err)
+}
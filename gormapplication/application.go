@@ -11,6 +11,7 @@ import (
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/notification"
 	"github.com/almighty/almighty-core/remoteworkitem"
 	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
@@ -74,6 +75,11 @@ func (g *GormBase) WorkItemTypes() workitem.WorkItemTypeRepository {
 	return workitem.NewWorkItemTypeRepository(g.db)
 }
 
+// WorkItemRevisions returns a work item revision repository
+func (g *GormBase) WorkItemRevisions() workitem.RevisionRepository {
+	return workitem.NewRevisionRepository(g.db)
+}
+
 func (g *GormBase) Spaces() space.Repository {
 	return space.NewRepository(g.db)
 }
@@ -103,6 +109,51 @@ func (g *GormBase) Users() account.UserRepository {
 	return account.NewUserRepository(g.db)
 }
 
+// UserPreferences creates new user preferences repository
+func (g *GormBase) UserPreferences() account.UserPreferencesRepository {
+	return account.NewUserPreferencesRepository(g.db)
+}
+
+// UsernameHistory creates new username history repository
+func (g *GormBase) UsernameHistory() account.UsernameHistoryRepository {
+	return account.NewUsernameHistoryRepository(g.db)
+}
+
+// UserEmails creates new user email repository
+func (g *GormBase) UserEmails() account.UserEmailRepository {
+	return account.NewUserEmailRepository(g.db)
+}
+
+// Impersonations creates new impersonation repository
+func (g *GormBase) Impersonations() account.ImpersonationRepository {
+	return account.NewImpersonationRepository(g.db)
+}
+
+// ProfileEvents creates new profile event repository
+func (g *GormBase) ProfileEvents() account.ProfileEventRepository {
+	return account.NewProfileEventRepository(g.db)
+}
+
+// PersonalAccessTokens creates new personal access token repository
+func (g *GormBase) PersonalAccessTokens() account.PersonalAccessTokenRepository {
+	return account.NewPersonalAccessTokenRepository(g.db)
+}
+
+// Teams creates new team repository
+func (g *GormBase) Teams() account.TeamRepository {
+	return account.NewTeamRepository(g.db)
+}
+
+// Invitations creates new invitation repository
+func (g *GormBase) Invitations() space.InvitationRepository {
+	return space.NewInvitationRepository(g.db)
+}
+
+// SpaceCollaborators creates new cached space collaborators repository
+func (g *GormBase) SpaceCollaborators() space.CollaboratorRepository {
+	return space.NewCollaboratorRepository(g.db)
+}
+
 // WorkItemLinkCategories returns a work item link category repository
 func (g *GormBase) WorkItemLinkCategories() link.WorkItemLinkCategoryRepository {
 	return link.NewWorkItemLinkCategoryRepository(g.db)
@@ -138,6 +189,16 @@ func (g *GormBase) OauthStates() auth.OauthStateReferenceRepository {
 	return auth.NewOauthStateReferenceRepository(g.db)
 }
 
+// Sessions returns a session repository
+func (g *GormBase) Sessions() auth.SessionRepository {
+	return auth.NewSessionRepository(g.db)
+}
+
+// Notifications returns a notification repository
+func (g *GormBase) Notifications() notification.Repository {
+	return notification.NewRepository(g.db)
+}
+
 // Codebases returns a codebase repository
 func (g *GormBase) Codebases() codebase.Repository {
 	return codebase.NewCodebaseRepository(g.db)
@@ -0,0 +1,63 @@
+// Package httpsupport provides helpers for the HTTP requests and responses which are not generated for the contexts
+// of the actions.
+package httpsupport
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/almighty/almighty-core/app"
+
+	"github.com/goadesign/goa"
+)
+
+// NotModifiedContext is the context of an action which can respond with "304 Not Modified"
+type NotModifiedContext interface {
+	NotModified() error
+}
+
+// ConditionalEntity holds the values to use to generate the ETag of a response which does not correspond to a
+// single domain entity or to a list of domain entities, along with the last modification time of its content.
+// A zero last modification time means that only the ETag is used.
+type ConditionalEntity struct {
+	ETagData     []interface{}
+	LastModified time.Time
+}
+
+// GetETagData returns the values to use to generate the ETag
+func (e ConditionalEntity) GetETagData() []interface{} {
+	return e.ETagData
+}
+
+// GetLastModified returns the last modification time
+func (e ConditionalEntity) GetLastModified() time.Time {
+	return e.LastModified
+}
+
+// ConditionalRequest sets the "ETag" and "Last-Modified" headers of the response for the given entity, with the
+// same encoding as the conditional requests methods generated for the contexts. It returns a "304 Not Modified"
+// response if the ETag matches the "If-None-Match" request header or, in the absence of this header, if the entity
+// was not modified since the "If-Modified-Since" request header. Otherwise it calls the 'nonConditionalCallback'
+// function to carry on.
+func ConditionalRequest(ctx NotModifiedContext, request *goa.RequestData, response *goa.ResponseData, entity app.ConditionalResponseEntity, nonConditionalCallback func() error) error {
+	eTag := app.GenerateEntityTag(entity)
+	response.Header().Set(app.ETag, eTag)
+	lastModified := entity.GetLastModified()
+	if !lastModified.IsZero() {
+		response.Header().Set(app.LastModified, app.ToHTTPTime(lastModified))
+	}
+	// see https://tools.ietf.org/html/rfc7232#section-6: "If-Modified-Since" is ignored when "If-None-Match" is given
+	if ifNoneMatch := request.Header.Get(app.IfNoneMatch); ifNoneMatch != "" {
+		if ifNoneMatch == eTag {
+			return ctx.NotModified()
+		}
+		return nonConditionalCallback()
+	}
+	if ifModifiedSince := request.Header.Get(app.IfModifiedSince); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err == nil && !since.UTC().Truncate(time.Second).Before(lastModified.UTC().Truncate(time.Second)) {
+			return ctx.NotModified()
+		}
+	}
+	return nonConditionalCallback()
+}
@@ -0,0 +1,116 @@
+package httpsupport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/httpsupport"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/goadesign/goa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNotModifiedContext records whether the "304 Not Modified" response was returned
+type testNotModifiedContext struct {
+	notModified bool
+}
+
+func (ctx *testNotModifiedContext) NotModified() error {
+	ctx.notModified = true
+	return nil
+}
+
+// doConditionalRequest runs a conditional request with the given headers, and returns whether the response was
+// "304 Not Modified" along with the response headers
+func doConditionalRequest(t *testing.T, entity httpsupport.ConditionalEntity, headers map[string]string) (bool, http.Header) {
+	r, err := http.NewRequest("GET", "http://api.service.domain.org/api/users", nil)
+	require.Nil(t, err)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	rw := httptest.NewRecorder()
+	ctx := &testNotModifiedContext{}
+	called := false
+	err = httpsupport.ConditionalRequest(ctx, &goa.RequestData{Request: r}, &goa.ResponseData{ResponseWriter: rw}, entity, func() error {
+		called = true
+		return nil
+	})
+	require.Nil(t, err)
+	assert.NotEqual(t, called, ctx.notModified)
+	return ctx.notModified, rw.Header()
+}
+
+func TestConditionalRequestWithoutHeaders(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	lastModified := time.Now()
+	entity := httpsupport.ConditionalEntity{ETagData: []interface{}{"foo", lastModified}, LastModified: lastModified}
+	// when
+	notModified, headers := doConditionalRequest(t, entity, nil)
+	// then
+	assert.False(t, notModified)
+	assert.Equal(t, app.GenerateEntityTag(entity), headers.Get(app.ETag))
+	assert.Equal(t, app.ToHTTPTime(lastModified), headers.Get(app.LastModified))
+}
+
+func TestConditionalRequestWithoutLastModified(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	entity := httpsupport.ConditionalEntity{ETagData: []interface{}{"foo"}}
+	// when
+	notModified, headers := doConditionalRequest(t, entity, map[string]string{app.IfModifiedSince: app.ToHTTPTime(time.Now())})
+	// then the "If-Modified-Since" header is ignored
+	assert.False(t, notModified)
+	assert.Empty(t, headers.Get(app.LastModified))
+}
+
+func TestConditionalRequestIfNoneMatch(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	entity := httpsupport.ConditionalEntity{ETagData: []interface{}{"foo"}, LastModified: time.Now()}
+	eTag := app.GenerateEntityTag(entity)
+	t.Run("matching", func(t *testing.T) {
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{app.IfNoneMatch: eTag})
+		assert.True(t, notModified)
+	})
+	t.Run("not matching", func(t *testing.T) {
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{app.IfNoneMatch: "bar"})
+		assert.False(t, notModified)
+	})
+	t.Run("not matching with a later if-modified-since", func(t *testing.T) {
+		// the "If-Modified-Since" header is ignored when the "If-None-Match" header is given
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{
+			app.IfNoneMatch:     "bar",
+			app.IfModifiedSince: app.ToHTTPTime(time.Now().Add(time.Hour)),
+		})
+		assert.False(t, notModified)
+	})
+}
+
+func TestConditionalRequestIfModifiedSince(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+	// given
+	lastModified := time.Now()
+	entity := httpsupport.ConditionalEntity{ETagData: []interface{}{"foo"}, LastModified: lastModified}
+	t.Run("not modified since", func(t *testing.T) {
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{app.IfModifiedSince: app.ToHTTPTime(lastModified)})
+		assert.True(t, notModified)
+	})
+	t.Run("modified since", func(t *testing.T) {
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{app.IfModifiedSince: app.ToHTTPTime(lastModified.Add(-time.Hour))})
+		assert.False(t, notModified)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		notModified, _ := doConditionalRequest(t, entity, map[string]string{app.IfModifiedSince: "yesterday"})
+		assert.False(t, notModified)
+	})
+}
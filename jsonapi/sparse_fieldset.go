@@ -0,0 +1,78 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/almighty/almighty-core/errors"
+)
+
+// SparseFieldset is the set of the attributes requested for a type of resources with the 'fields[TYPE]' query
+// parameter, as defined by JSON-API. A nil SparseFieldset requests all the attributes.
+type SparseFieldset map[string]struct{}
+
+// NewSparseFieldset parses the comma-separated names of the attributes given with the 'fields[<resourceType>]' query
+// parameter. The names are checked against the JSON keys of the given attributes, a struct or a pointer to a struct
+// generated by goa. A nil SparseFieldset is returned if the parameter is not given.
+func NewSparseFieldset(resourceType string, fields *string, attributes interface{}) (SparseFieldset, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	param := fmt.Sprintf("fields[%s]", resourceType)
+	known := attributeFields(reflect.TypeOf(attributes))
+	fieldset := SparseFieldset{}
+	for _, name := range strings.Split(*fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			return nil, errors.NewBadParameterError(param, *fields).Expected(fmt.Sprintf("comma-separated attributes of the %s", resourceType))
+		}
+		fieldset[name] = struct{}{}
+	}
+	return fieldset, nil
+}
+
+// Apply clears the fields of the given attributes, a pointer to a struct generated by goa, which are not in the
+// fieldset, so that they are omitted from the response. It does nothing if the fieldset is nil.
+func (s SparseFieldset) Apply(attributes interface{}) {
+	if s == nil {
+		return
+	}
+	v := reflect.ValueOf(attributes)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	v = v.Elem()
+	for name, i := range attributeFields(v.Type()) {
+		if _, ok := s[name]; !ok {
+			f := v.Field(i)
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
+// attributeFields returns the index of the fields of the given struct type, or pointer to a struct type, indexed
+// by their JSON key
+func attributeFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	if t == nil {
+		return fields
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}
@@ -0,0 +1,75 @@
+package jsonapi_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAttributes struct {
+	Username *string                `form:"username,omitempty" json:"username,omitempty" xml:"username,omitempty"`
+	FullName *string                `form:"fullName,omitempty" json:"fullName,omitempty" xml:"fullName,omitempty"`
+	Context  map[string]interface{} `form:"context,omitempty" json:"context,omitempty" xml:"context,omitempty"`
+}
+
+func newTestAttributes() *testAttributes {
+	username, fullName := "john", "John Doe"
+	return &testAttributes{Username: &username, FullName: &fullName, Context: map[string]interface{}{"foo": "bar"}}
+}
+
+func TestSparseFieldset(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("all attributes", func(t *testing.T) {
+		// when
+		fieldset, err := jsonapi.NewSparseFieldset("identities", nil, testAttributes{})
+		// then
+		require.Nil(t, err)
+		assert.Nil(t, fieldset)
+		attributes := newTestAttributes()
+		fieldset.Apply(attributes)
+		assert.Equal(t, newTestAttributes(), attributes)
+	})
+
+	t.Run("some attributes", func(t *testing.T) {
+		// given
+		fields := "username, context"
+		// when
+		fieldset, err := jsonapi.NewSparseFieldset("identities", &fields, testAttributes{})
+		// then
+		require.Nil(t, err)
+		attributes := newTestAttributes()
+		fieldset.Apply(attributes)
+		require.NotNil(t, attributes.Username)
+		assert.Equal(t, "john", *attributes.Username)
+		assert.Nil(t, attributes.FullName)
+		assert.Equal(t, map[string]interface{}{"foo": "bar"}, attributes.Context)
+	})
+
+	t.Run("no attribute", func(t *testing.T) {
+		// given
+		fields := ""
+		// when
+		fieldset, err := jsonapi.NewSparseFieldset("identities", &fields, &testAttributes{})
+		// then
+		require.Nil(t, err)
+		attributes := newTestAttributes()
+		fieldset.Apply(attributes)
+		assert.Equal(t, &testAttributes{}, attributes)
+	})
+
+	t.Run("unknown attribute", func(t *testing.T) {
+		// given
+		fields := "username,password"
+		// when
+		_, err := jsonapi.NewSparseFieldset("identities", &fields, testAttributes{})
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.BadParameterError{}, err)
+	})
+}
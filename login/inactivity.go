@@ -0,0 +1,116 @@
+package login
+
+import (
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/log"
+
+	errs "github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// InactivityConfiguration the configuration of the deactivation of the identities which stopped logging in
+type InactivityConfiguration interface {
+	GetUserInactivityThreshold() time.Duration
+	GetUserInactivityCheckInterval() time.Duration
+	IsUserInactivityDryRunEnabled() bool
+}
+
+// DeactivateInactiveIdentities deactivates the active Keycloak identities which did not log in since longer than the given threshold,
+// along with their users, and returns them. In dry-run mode, the identities are only returned and logged. Identities which own spaces are skipped since
+// the ownership of their spaces must be transferred to another collaborator first.
+func DeactivateInactiveIdentities(ctx context.Context, db application.DB, threshold time.Duration, dryRun bool) ([]account.Identity, error) {
+	var deactivated []account.Identity
+	err := application.Transactional(db, func(appl application.Application) error {
+		now := time.Now()
+		identities, err := appl.Identities().Query(
+			account.IdentityFilterByProviderType(account.KeycloakIDP),
+			account.IdentityFilterByLastLoginBefore(now.Add(-threshold)))
+		if err != nil {
+			return err
+		}
+		for _, identity := range identities {
+			ownedSpaces, _, err := appl.Spaces().LoadByOwner(ctx, &identity.ID, nil, nil)
+			if err != nil {
+				return err
+			}
+			if len(ownedSpaces) > 0 {
+				log.Warn(ctx, map[string]interface{}{
+					"identity_id":   identity.ID,
+					"username":      identity.Username,
+					"last_login_at": identity.LastLoginAt,
+					"owned_spaces":  len(ownedSpaces),
+				}, "inactive identity owns spaces and must be deactivated manually")
+				continue
+			}
+			if !dryRun {
+				identity.DeactivatedAt = &now
+				if err := appl.Identities().Save(ctx, identity); err != nil {
+					return err
+				}
+				if err := deactivateUser(ctx, appl, identity); err != nil {
+					return err
+				}
+			}
+			log.Info(ctx, map[string]interface{}{
+				"identity_id":   identity.ID,
+				"username":      identity.Username,
+				"last_login_at": identity.LastLoginAt,
+				"dry_run":       dryRun,
+			}, "inactive identity deactivated")
+			deactivated = append(deactivated, *identity)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "unable to deactivate the inactive identities")
+	}
+	return deactivated, nil
+}
+
+// deactivateUser deactivates the user of the given identity, so that the identity is rejected by the RejectInactiveUsers
+// middleware and can be reactivated by an administrator. Banned users are left as they are.
+func deactivateUser(ctx context.Context, appl application.Application, identity *account.Identity) error {
+	if !identity.UserID.Valid {
+		return nil
+	}
+	user, err := appl.Users().Load(ctx, identity.UserID.UUID)
+	if err != nil {
+		return errs.Wrapf(err, "unable to load the user of the identity %s", identity.ID)
+	}
+	if user.State == account.UserStateDeactivated || user.State == account.UserStateBanned {
+		return nil
+	}
+	user.State = account.UserStateDeactivated
+	return appl.Users().Save(ctx, user)
+}
+
+// ScheduleInactiveIdentitiesDeactivation deactivates the inactive identities at the configured interval, until the returned
+// function is called. Nothing is scheduled if no inactivity threshold or no check interval is configured.
+func ScheduleInactiveIdentitiesDeactivation(ctx context.Context, db application.DB, config InactivityConfiguration) (stop func()) {
+	threshold := config.GetUserInactivityThreshold()
+	interval := config.GetUserInactivityCheckInterval()
+	if threshold <= 0 || interval <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := DeactivateInactiveIdentities(ctx, db, threshold, config.IsUserInactivityDryRunEnabled()); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"err": err,
+					}, "unable to deactivate the inactive identities")
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,166 @@
+package login_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	. "github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+// the threshold is large enough to only select the identities created by the tests below
+const testInactivityThreshold = 10 * 365 * 24 * time.Hour
+
+type inactivityBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	clean func()
+	db    application.DB
+}
+
+func TestRunInactivityBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &inactivityBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *inactivityBlackBoxTest) SetupSuite() {
+	s.DBTestSuite.SetupSuite()
+	s.db = gormapplication.NewGormDB(s.DB)
+}
+
+func (s *inactivityBlackBoxTest) SetupTest() {
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+}
+
+func (s *inactivityBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+// createIdentity creates a Keycloak identity which last logged in at the given time, or never if nil
+func (s *inactivityBlackBoxTest) createIdentity(lastLoginAt *time.Time) account.Identity {
+	user := account.User{
+		ID:       uuid.NewV4(),
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: "TestInactivity",
+	}
+	err := account.NewUserRepository(s.DB).Create(context.Background(), &user)
+	require.Nil(s.T(), err)
+	identity := account.Identity{
+		Username:     "TestInactivity" + uuid.NewV4().String(),
+		ProviderType: account.KeycloakIDP,
+		UserID:       account.NullUUID{UUID: user.ID, Valid: true},
+		LastLoginAt:  lastLoginAt,
+	}
+	err = account.NewIdentityRepository(s.DB).Create(context.Background(), &identity)
+	require.Nil(s.T(), err)
+	return identity
+}
+
+func (s *inactivityBlackBoxTest) loadIdentity(id uuid.UUID) *account.Identity {
+	identity, err := account.NewIdentityRepository(s.DB).Load(context.Background(), id)
+	require.Nil(s.T(), err)
+	return identity
+}
+
+func (s *inactivityBlackBoxTest) loadUserState(identity account.Identity) string {
+	user, err := account.NewUserRepository(s.DB).Load(context.Background(), identity.UserID.UUID)
+	require.Nil(s.T(), err)
+	return user.State
+}
+
+func ids(identities []account.Identity) []uuid.UUID {
+	result := make([]uuid.UUID, len(identities))
+	for i, identity := range identities {
+		result[i] = identity.ID
+	}
+	return result
+}
+
+func (s *inactivityBlackBoxTest) TestDeactivateInactiveIdentitiesOnlyOldEnough() {
+	// given
+	longAgo := time.Now().Add(-testInactivityThreshold - 24*time.Hour)
+	recently := time.Now().Add(-testInactivityThreshold + 24*time.Hour)
+	inactive := s.createIdentity(&longAgo)
+	active := s.createIdentity(&recently)
+	neverLoggedIn := s.createIdentity(nil)
+	alreadyDeactivated := s.createIdentity(&longAgo)
+	alreadyDeactivated.DeactivatedAt = &longAgo
+	err := account.NewIdentityRepository(s.DB).Save(context.Background(), &alreadyDeactivated)
+	require.Nil(s.T(), err)
+	// when
+	deactivated, err := DeactivateInactiveIdentities(context.Background(), s.db, testInactivityThreshold, false)
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), deactivated, 1)
+	assert.Equal(s.T(), inactive.ID, deactivated[0].ID)
+	assert.NotNil(s.T(), s.loadIdentity(inactive.ID).DeactivatedAt)
+	assert.Equal(s.T(), account.UserStateDeactivated, s.loadUserState(inactive))
+	assert.Nil(s.T(), s.loadIdentity(active.ID).DeactivatedAt)
+	assert.NotEqual(s.T(), account.UserStateDeactivated, s.loadUserState(active))
+	assert.Nil(s.T(), s.loadIdentity(neverLoggedIn.ID).DeactivatedAt)
+	assert.Equal(s.T(), longAgo.Unix(), s.loadIdentity(alreadyDeactivated.ID).DeactivatedAt.Unix())
+}
+
+func (s *inactivityBlackBoxTest) TestDeactivateInactiveIdentitiesDryRun() {
+	// given
+	longAgo := time.Now().Add(-testInactivityThreshold - 24*time.Hour)
+	inactive1 := s.createIdentity(&longAgo)
+	inactive2 := s.createIdentity(&longAgo)
+	// when
+	deactivated, err := DeactivateInactiveIdentities(context.Background(), s.db, testInactivityThreshold, true)
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), deactivated, 2)
+	assert.Contains(s.T(), ids(deactivated), inactive1.ID)
+	assert.Contains(s.T(), ids(deactivated), inactive2.ID)
+	assert.Nil(s.T(), s.loadIdentity(inactive1.ID).DeactivatedAt)
+	assert.Nil(s.T(), s.loadIdentity(inactive2.ID).DeactivatedAt)
+	assert.NotEqual(s.T(), account.UserStateDeactivated, s.loadUserState(inactive1))
+}
+
+func (s *inactivityBlackBoxTest) TestDeactivateInactiveIdentitiesSkipsSpaceOwners() {
+	// given
+	longAgo := time.Now().Add(-testInactivityThreshold - 24*time.Hour)
+	owner := s.createIdentity(&longAgo)
+	_, err := space.NewRepository(s.DB).Create(context.Background(), &space.Space{
+		Name:    "TestInactivity " + uuid.NewV4().String(),
+		OwnerId: owner.ID,
+	})
+	require.Nil(s.T(), err)
+	// when
+	deactivated, err := DeactivateInactiveIdentities(context.Background(), s.db, testInactivityThreshold, false)
+	// then
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), deactivated)
+	assert.Nil(s.T(), s.loadIdentity(owner.ID).DeactivatedAt)
+}
+
+// inactivityConfiguration is an InactivityConfiguration with the given threshold and check interval
+type inactivityConfiguration struct {
+	threshold time.Duration
+	interval  time.Duration
+}
+
+func (c inactivityConfiguration) GetUserInactivityThreshold() time.Duration     { return c.threshold }
+func (c inactivityConfiguration) GetUserInactivityCheckInterval() time.Duration { return c.interval }
+func (c inactivityConfiguration) IsUserInactivityDryRunEnabled() bool           { return true }
+
+func (s *inactivityBlackBoxTest) TestScheduleInactiveIdentitiesDeactivationWithoutInterval() {
+	// given a threshold but no check interval
+	config := inactivityConfiguration{threshold: testInactivityThreshold, interval: 0}
+	// when/then nothing is scheduled
+	stop := ScheduleInactiveIdentitiesDeactivation(context.Background(), s.db, config)
+	require.NotNil(s.T(), stop)
+	stop()
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
 
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/log"
@@ -27,6 +28,9 @@ type KeycloakUserProfile struct {
 	LastName   *string                        `json:"lastName,omitempty"`
 	Email      *string                        `json:"email,omitempty"`
 	Attributes *KeycloakUserProfileAttributes `json:"attributes,omitempty"`
+	// Only used when creating a user
+	Enabled       *bool `json:"enabled,omitempty"`
+	EmailVerified *bool `json:"emailVerified,omitempty"`
 }
 
 // KeycloakUserProfileAttributes represents standard Keycloak profile payload Attributes
@@ -62,6 +66,8 @@ func NewKeycloakUserProfile(firstName *string, lastName *string, email *string,
 type UserProfileService interface {
 	Update(keycloakUserProfile *KeycloakUserProfile, accessToken string, keycloakProfileURL string) error
 	Get(accessToken string, keycloakProfileURL string) (*KeycloakUserProfileResponse, error)
+	Create(keycloakUserProfile *KeycloakUserProfile, protectionAPIToken string, keycloakAdminUserAPIURL string) (*string, error)
+	Delete(keycloakUserID string, protectionAPIToken string, keycloakAdminUserAPIURL string) error
 }
 
 // KeycloakUserProfileClient describes the interface between platform and Keycloak User profile service.
@@ -157,3 +163,93 @@ func (userProfileClient *KeycloakUserProfileClient) Get(accessToken string, keyc
 	err = json.NewDecoder(resp.Body).Decode(&keycloakUserProfileResponse)
 	return &keycloakUserProfileResponse, err
 }
+
+// Create creates a new user in Keycloak with the admin API, and returns the ID of the created user
+func (userProfileClient *KeycloakUserProfileClient) Create(keycloakUserProfile *KeycloakUserProfile, protectionAPIToken string, keycloakAdminUserAPIURL string) (*string, error) {
+	body, err := json.Marshal(keycloakUserProfile)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+
+	req, err := http.NewRequest("POST", keycloakAdminUserAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	req.Header.Add("Authorization", "Bearer "+protectionAPIToken)
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := userProfileClient.client.Do(req)
+	if err != nil {
+		log.Error(context.Background(), map[string]interface{}{
+			"keycloak_user_url": keycloakAdminUserAPIURL,
+			"err":               err,
+		}, "Unable to create Keycloak user")
+		return nil, errors.NewInternalError(err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		// the ID of the created user is only given by the location of the user
+		location := resp.Header.Get("Location")
+		if location == "" {
+			return nil, errors.NewInternalError(fmt.Sprintf("the location of the created Keycloak user is missing in the response of %s", keycloakAdminUserAPIURL))
+		}
+		userID := path.Base(location)
+		log.Debug(context.Background(), map[string]interface{}{
+			"keycloak_user_id": userID,
+		}, "Keycloak user created")
+		return &userID, nil
+	case http.StatusConflict:
+		return nil, errors.NewBadParameterError("username or email", fmt.Sprintf("%s , %s", stringValue(keycloakUserProfile.Username), stringValue(keycloakUserProfile.Email))).Expected("a username and an email which are not used by another Keycloak user")
+	default:
+		log.Error(context.Background(), map[string]interface{}{
+			"response_status":   resp.Status,
+			"response_body":     rest.ReadBody(resp.Body),
+			"keycloak_user_url": keycloakAdminUserAPIURL,
+		}, "Unable to create Keycloak user")
+		return nil, errors.NewInternalError(fmt.Sprintf("Received a non-201 response %s while creating keycloak user %s", resp.Status, keycloakAdminUserAPIURL))
+	}
+}
+
+// Delete deletes the user with the given ID from Keycloak with the admin API
+func (userProfileClient *KeycloakUserProfileClient) Delete(keycloakUserID string, protectionAPIToken string, keycloakAdminUserAPIURL string) error {
+	userURL := keycloakAdminUserAPIURL + "/" + keycloakUserID
+	req, err := http.NewRequest("DELETE", userURL, nil)
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	req.Header.Add("Authorization", "Bearer "+protectionAPIToken)
+
+	resp, err := userProfileClient.client.Do(req)
+	if err != nil {
+		log.Error(context.Background(), map[string]interface{}{
+			"keycloak_user_url": userURL,
+			"err":               err,
+		}, "Unable to delete Keycloak user")
+		return errors.NewInternalError(err.Error())
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotFound:
+		log.Debug(context.Background(), map[string]interface{}{
+			"keycloak_user_id": keycloakUserID,
+		}, "Keycloak user deleted")
+		return nil
+	default:
+		log.Error(context.Background(), map[string]interface{}{
+			"response_status":   resp.Status,
+			"response_body":     rest.ReadBody(resp.Body),
+			"keycloak_user_url": userURL,
+		}, "Unable to delete Keycloak user")
+		return errors.NewInternalError(fmt.Sprintf("Received a non-204 response %s while deleting keycloak user %s", resp.Status, userURL))
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
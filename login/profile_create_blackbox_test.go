@@ -0,0 +1,58 @@
+package login_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateKeycloakUserOK(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	var method, authorization string
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, authorization = r.Method, r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		w.Header().Set("Location", "http://keycloak.example.com/auth/admin/realms/fabric8/users/some-user-id")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	username, email := "john", "john@example.com"
+	enabled := true
+	profile := &login.KeycloakUserProfile{Username: &username, Email: &email, Enabled: &enabled, EmailVerified: &enabled}
+	// when
+	userID, err := login.NewKeycloakUserProfileClient().Create(profile, "some-pat", server.URL+"/users")
+	// then
+	require.Nil(t, err)
+	require.NotNil(t, userID)
+	assert.Equal(t, "some-user-id", *userID)
+	assert.Equal(t, "POST", method)
+	assert.Equal(t, "Bearer some-pat", authorization)
+	assert.Equal(t, map[string]interface{}{"username": "john", "email": "john@example.com", "enabled": true, "emailVerified": true}, payload)
+}
+
+func TestCreateKeycloakUserConflict(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+	username, email := "john", "john@example.com"
+	// when
+	_, err := login.NewKeycloakUserProfileClient().Create(&login.KeycloakUserProfile{Username: &username, Email: &email}, "some-pat", server.URL+"/users")
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, err)
+}
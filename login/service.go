@@ -13,6 +13,8 @@ import (
 	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	errs "github.com/pkg/errors"
 
@@ -35,22 +37,30 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// SessionConfiguration the configuration of the limit on active sessions per identity
+type SessionConfiguration interface {
+	GetSessionMaxActive() int
+	GetSessionLimitPolicy() string
+}
+
 // NewKeycloakOAuthProvider creates a new login.Service capable of using keycloak for authorization
-func NewKeycloakOAuthProvider(identities account.IdentityRepository, users account.UserRepository, tokenManager token.Manager, db application.DB) *KeycloakOAuthProvider {
+func NewKeycloakOAuthProvider(identities account.IdentityRepository, users account.UserRepository, tokenManager token.Manager, db application.DB, sessionConfig SessionConfiguration) *KeycloakOAuthProvider {
 	return &KeycloakOAuthProvider{
-		Identities:   identities,
-		Users:        users,
-		TokenManager: tokenManager,
-		db:           db,
+		Identities:    identities,
+		Users:         users,
+		TokenManager:  tokenManager,
+		db:            db,
+		sessionConfig: sessionConfig,
 	}
 }
 
 // KeycloakOAuthProvider represents a keyclaok IDP
 type KeycloakOAuthProvider struct {
-	Identities   account.IdentityRepository
-	Users        account.UserRepository
-	TokenManager token.Manager
-	db           application.DB
+	Identities    account.IdentityRepository
+	Users         account.UserRepository
+	TokenManager  token.Manager
+	db            application.DB
+	sessionConfig SessionConfiguration
 }
 
 // KeycloakOAuthService represents keycloak OAuth service interface
@@ -60,6 +70,7 @@ type KeycloakOAuthService interface {
 	Link(ctx *app.LinkLoginContext, brokerEndpoint string, clientID string, validRedirectURL string) error
 	LinkSession(ctx *app.LinksessionLoginContext, brokerEndpoint string, clientID string, validRedirectURL string) error
 	LinkCallback(ctx *app.LinkcallbackLoginContext, brokerEndpoint string, clientID string) error
+	RefreshSession(ctx context.Context, token auth.Token) error
 }
 
 type linkInterface interface {
@@ -84,6 +95,16 @@ type keycloakTokenClaims struct {
 
 var allProvidersToLink = []string{"github", "openshift-v3"}
 
+// IsLinkedProvider returns true if the given identity provider is one of the providers linked to the Keycloak accounts
+func IsLinkedProvider(provider string) bool {
+	for _, p := range allProvidersToLink {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	initiateLinkingParam = "initlinking"
 )
@@ -137,7 +158,7 @@ func (keycloak *KeycloakOAuthProvider) Perform(ctx *app.AuthorizeLoginContext, c
 			"known_referrer": knownReferrer,
 		}, "exchanged code to access token")
 
-		_, usr, err := keycloak.CreateOrUpdateKeycloakUser(keycloakToken.AccessToken, ctx, profileEndpoint)
+		identity, usr, err := keycloak.CreateOrUpdateKeycloakUser(keycloakToken.AccessToken, ctx, profileEndpoint)
 		if err != nil {
 			log.Error(ctx, map[string]interface{}{
 				"err": err,
@@ -149,6 +170,20 @@ func (keycloak *KeycloakOAuthProvider) Perform(ctx *app.AuthorizeLoginContext, c
 			return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
 		}
 
+		refreshExpiresIn, _ := strconv.Atoi(fmt.Sprintf("%v", keycloakToken.Extra("refresh_expires_in")))
+		err = keycloak.registerSession(ctx, identity.ID, keycloakToken.AccessToken, refreshExpiresIn)
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"identity_id": identity.ID,
+				"err":         err,
+			}, "failed to register the session")
+			switch err.(type) {
+			case coreerrors.UnauthorizedError:
+				return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+			}
+			return jsonapi.JSONErrorResponse(ctx, goa.ErrInternal(err.Error()))
+		}
+
 		log.Debug(ctx, map[string]interface{}{
 			"code":           code,
 			"state":          state,
@@ -290,6 +325,95 @@ func (keycloak *KeycloakOAuthProvider) Perform(ctx *app.AuthorizeLoginContext, c
 	return ctx.TemporaryRedirect()
 }
 
+// registerSession records the session of the given access token for the given identity,
+// enforcing the configured limit on active sessions
+func (keycloak *KeycloakOAuthProvider) registerSession(ctx context.Context, identityID uuid.UUID, accessToken string, refreshExpiresIn int) error {
+	claims, err := parseToken(accessToken, keycloak.TokenManager.PublicKey())
+	if err != nil {
+		return errs.Wrap(err, "unable to parse the token")
+	}
+	if claims.SessionState == "" {
+		return nil
+	}
+	return application.Transactional(keycloak.db, func(appl application.Application) error {
+		session := &auth.Session{
+			IdentityID:   identityID,
+			SessionState: claims.SessionState,
+			ExpiresAt:    sessionExpiry(claims, refreshExpiresIn),
+		}
+		return auth.RegisterSession(ctx, appl.Sessions(), session, keycloak.sessionConfig.GetSessionMaxActive(), keycloak.sessionConfig.GetSessionLimitPolicy())
+	})
+}
+
+// RefreshSession extends the session of the given refreshed token until its new refresh token expires
+// returns UnauthorizedError if the session was revoked
+func (keycloak *KeycloakOAuthProvider) RefreshSession(ctx context.Context, token auth.Token) error {
+	if token.AccessToken == nil {
+		return nil
+	}
+	claims, err := parseToken(*token.AccessToken, keycloak.TokenManager.PublicKey())
+	if err != nil {
+		return errs.Wrap(err, "unable to parse the token")
+	}
+	if claims.SessionState == "" {
+		return nil
+	}
+	refreshExpiresIn := 0
+	if token.RefreshExpiresIn != nil {
+		refreshExpiresIn = *token.RefreshExpiresIn
+	}
+	expiresAt := sessionExpiry(claims, refreshExpiresIn)
+	return application.Transactional(keycloak.db, func(appl application.Application) error {
+		session, err := appl.Sessions().LoadBySessionState(ctx, claims.SessionState)
+		if err != nil {
+			if _, notFound := err.(coreerrors.NotFoundError); notFound {
+				return nil
+			}
+			return err
+		}
+		if session.RevokedAt != nil {
+			return coreerrors.NewUnauthorizedError("session has been revoked")
+		}
+		if expiresAt == nil {
+			return nil
+		}
+		return appl.Sessions().Extend(ctx, session.ID, *expiresAt)
+	})
+}
+
+// sessionExpiry returns the time at which a session expires: when its refresh token does if it is known,
+// or else when its access token does
+func sessionExpiry(claims *keycloakTokenClaims, refreshExpiresIn int) *time.Time {
+	var expiresAt time.Time
+	if refreshExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(refreshExpiresIn) * time.Second)
+	} else if claims.ExpiresAt > 0 {
+		expiresAt = time.Unix(claims.ExpiresAt, 0)
+	} else {
+		return nil
+	}
+	return &expiresAt
+}
+
+// RevokeSession revokes the session of the given access token, if any
+func RevokeSession(ctx context.Context, db application.DB, publicKey *rsa.PublicKey, accessToken string) error {
+	claims, err := parseToken(accessToken, publicKey)
+	if err != nil {
+		return errs.Wrap(err, "unable to parse the token")
+	}
+	if claims.SessionState == "" {
+		return nil
+	}
+	err = application.Transactional(db, func(appl application.Application) error {
+		return auth.RevokeBySessionState(ctx, appl.Sessions(), claims.SessionState)
+	})
+	if err != nil {
+		return err
+	}
+	revokedSessions.put(claims.SessionState, true)
+	return nil
+}
+
 func (keycloak *KeycloakOAuthProvider) autoLinkProvidersDuringLogin(ctx *app.AuthorizeLoginContext, token string, referrerURL string) error {
 	// Link all available Identity Providers
 	linkURL, err := url.Parse(rest.AbsoluteURL(ctx.RequestData, "/api/login/linksession"))
@@ -629,6 +753,7 @@ func (keycloak *KeycloakOAuthProvider) CreateOrUpdateKeycloakUser(accessToken st
 		}
 		user = new(account.User)
 		fillUser(claims, user)
+		now := time.Now()
 		err = application.Transactional(keycloak.db, func(appl application.Application) error {
 			err := appl.Users().Create(ctx, user)
 			if err != nil {
@@ -639,7 +764,8 @@ func (keycloak *KeycloakOAuthProvider) CreateOrUpdateKeycloakUser(accessToken st
 				Username:     claims.Username,
 				ProviderType: account.KeycloakIDP,
 				UserID:       account.NullUUID{UUID: user.ID, Valid: true},
-				User:         *user}
+				User:         *user,
+				LastLoginAt:  &now}
 			err = appl.Identities().Create(ctx, identity)
 			return err
 		})
@@ -660,6 +786,14 @@ func (keycloak *KeycloakOAuthProvider) CreateOrUpdateKeycloakUser(accessToken st
 			}, "Found Keycloak identity is not linked to any User")
 			return nil, nil, errors.New("found Keycloak identity is not linked to any User")
 		}
+		if identity.DeactivatedAt != nil || (user.State != "" && user.State != account.UserStateActive) {
+			log.Warn(ctx, map[string]interface{}{
+				"identity_id":    identity.ID,
+				"deactivated_at": identity.DeactivatedAt,
+				"state":          user.State,
+			}, "login rejected: the identity or its user is not active")
+			return nil, nil, coreerrors.NewUnauthorizedError(fmt.Sprintf("user '%s' is not active", claims.Username))
+		}
 		// let's update the existing user with the fullname, email and avatar from Keycloak,
 		// in case the user changed them since the last time he/she logged in
 		fillUser(claims, user)
@@ -671,6 +805,16 @@ func (keycloak *KeycloakOAuthProvider) CreateOrUpdateKeycloakUser(accessToken st
 			}, "unable to update user")
 			return nil, nil, errors.New("Cant' update user " + err.Error())
 		}
+		now := time.Now()
+		identity.LastLoginAt = &now
+		err = keycloak.Identities.Save(ctx, identity)
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"identity_id": identity.ID,
+				"err":         err,
+			}, "unable to update the last login of the identity")
+			return nil, nil, errors.New("Cant' update identity " + err.Error())
+		}
 	}
 	return identity, user, nil
 }
@@ -804,6 +948,160 @@ func ContextIdentity(ctx context.Context) (*uuid.UUID, error) {
 	return &uuid, nil
 }
 
+// RejectRevokedSessions is a JWT validation middleware which rejects the tokens
+// belonging to a session that was revoked, e.g. because the max number of active sessions was exceeded.
+// The revocation state of the sessions is cached for sessionCacheTTL.
+func RejectRevokedSessions(db application.DB) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token := goajwt.ContextJWT(ctx)
+			if token == nil {
+				return h(ctx, rw, req)
+			}
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return h(ctx, rw, req)
+			}
+			sessionState, ok := claims["session_state"].(string)
+			if !ok || sessionState == "" {
+				return h(ctx, rw, req)
+			}
+			revoked, ok := revokedSessions.get(sessionState)
+			if !ok {
+				session, err := db.Sessions().LoadBySessionState(ctx, sessionState)
+				if err != nil {
+					if _, notFound := err.(coreerrors.NotFoundError); !notFound {
+						return err
+					}
+				} else {
+					revoked = session.RevokedAt != nil
+				}
+				revokedSessions.put(sessionState, revoked)
+			}
+			if revoked {
+				return goajwt.ErrJWTError("session has been revoked")
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ErrInactiveUser is the class of the errors returned when an inactive user calls the API
+var ErrInactiveUser = goa.NewErrorClass("inactive_user", http.StatusForbidden)
+
+// RejectInactiveUsers is a JWT validation middleware which rejects with a 403 the tokens of the deactivated
+// identities, and of the identities whose user has been deactivated or banned by an administrator.
+func RejectInactiveUsers(db application.DB) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token := goajwt.ContextJWT(ctx)
+			if token == nil {
+				return h(ctx, rw, req)
+			}
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return h(ctx, rw, req)
+			}
+			sub, ok := claims["sub"].(string)
+			if !ok {
+				return h(ctx, rw, req)
+			}
+			identityID, err := uuid.FromString(sub)
+			if err != nil {
+				return h(ctx, rw, req)
+			}
+			identities, err := db.Identities().Query(account.IdentityFilterByID(identityID), account.IdentityWithUser())
+			if err != nil {
+				return err
+			}
+			if len(identities) > 0 && identities[0].DeactivatedAt != nil {
+				log.Warn(ctx, map[string]interface{}{
+					"identity_id":    identityID,
+					"deactivated_at": identities[0].DeactivatedAt,
+				}, "API call rejected: the identity is deactivated")
+				return ErrInactiveUser("the identity is deactivated")
+			}
+			if len(identities) == 0 || !identities[0].UserID.Valid {
+				return h(ctx, rw, req)
+			}
+			if state := identities[0].User.State; state != "" && state != account.UserStateActive {
+				log.Warn(ctx, map[string]interface{}{
+					"identity_id": identityID,
+					"state":       state,
+				}, "API call rejected: the user is not active")
+				return ErrInactiveUser(fmt.Sprintf("the user is %s", state))
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ValidateTokens is the JWT validation middleware which rejects the tokens of the revoked sessions
+// and of the inactive users
+func ValidateTokens(db application.DB) goa.Middleware {
+	rejectRevokedSessions := RejectRevokedSessions(db)
+	rejectInactiveUsers := RejectInactiveUsers(db)
+	return func(h goa.Handler) goa.Handler {
+		return rejectRevokedSessions(rejectInactiveUsers(h))
+	}
+}
+
+// personalAccessTokenLastUseResolution is the precision of the recorded last use of the personal access tokens
+const personalAccessTokenLastUseResolution = time.Minute
+
+// AuthenticatePersonalAccessTokens is the JWT middleware which also accepts the personal access tokens: the bearer
+// tokens with the personal access token prefix are looked up by their hash instead of being handed over to the given
+// JWT middleware. The requests with an active personal access token are given a token with the identity and the
+// scopes of the personal access token, and go through the same validation as the JWTs, so that the tokens of the
+// inactive users are rejected. Since these tokens are not known by Keycloak, they can't be forwarded to it.
+func AuthenticatePersonalAccessTokens(db application.DB, jwtMiddleware goa.Middleware) goa.Middleware {
+	validateTokens := ValidateTokens(db)
+	return func(h goa.Handler) goa.Handler {
+		authenticateJWT := jwtMiddleware(h)
+		authenticatePersonalAccessToken := validateTokens(h)
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			authorization := req.Header.Get("Authorization")
+			if !strings.HasPrefix(authorization, "Bearer ") {
+				return authenticateJWT(ctx, rw, req)
+			}
+			raw := strings.TrimPrefix(authorization, "Bearer ")
+			if !account.IsPersonalAccessToken(raw) {
+				return authenticateJWT(ctx, rw, req)
+			}
+			pat, err := db.PersonalAccessTokens().LoadByTokenHash(ctx, account.HashPersonalAccessToken(raw))
+			if err != nil {
+				if _, notFound := err.(coreerrors.NotFoundError); notFound {
+					return goajwt.ErrJWTError("unknown personal access token")
+				}
+				return err
+			}
+			now := time.Now()
+			if !pat.IsActive(now) {
+				return goajwt.ErrJWTError("personal access token has been revoked or has expired")
+			}
+			// the last use is only recorded once per resolution, so that the tokens used by the scripts don't cost
+			// a write per request, and failing to record it doesn't fail the request
+			if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= personalAccessTokenLastUseResolution {
+				pat.LastUsedAt = &now
+				if err := db.PersonalAccessTokens().Save(ctx, pat); err != nil {
+					log.Warn(ctx, map[string]interface{}{
+						"token_id": pat.ID,
+						"err":      err,
+					}, "unable to record the last use of the personal access token")
+				}
+			}
+			claims := jwt.MapClaims{
+				"jti":                          pat.ID.String(),
+				"sub":                          pat.IdentityID.String(),
+				"scopes":                       pat.ScopeList(),
+				token.PersonalAccessTokenClaim: pat.ID.String(),
+			}
+			ctx = goajwt.WithJWT(ctx, &jwt.Token{Raw: raw, Claims: claims, Valid: true})
+			return authenticatePersonalAccessToken(ctx, rw, req)
+		}
+	}
+}
+
 // InjectTokenManager is a middleware responsible for setting up tokenManager in the context for every request.
 func InjectTokenManager(tokenManager token.Manager) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
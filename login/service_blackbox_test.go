@@ -6,13 +6,16 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 
 	"github.com/almighty/almighty-core/account"
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/auth"
 	config "github.com/almighty/almighty-core/configuration"
+	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormapplication"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/gormtestsupport"
@@ -21,6 +24,7 @@ import (
 	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 
 	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
 	"github.com/almighty/almighty-core/token"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
@@ -87,7 +91,7 @@ func (s *serviceBlackBoxTest) SetupSuite() {
 	userRepository := account.NewUserRepository(s.DB)
 	identityRepository := account.NewIdentityRepository(s.DB)
 	app := gormapplication.NewGormDB(s.DB)
-	s.loginService = NewKeycloakOAuthProvider(identityRepository, userRepository, tokenManager, app)
+	s.loginService = NewKeycloakOAuthProvider(identityRepository, userRepository, tokenManager, app, s.configuration)
 }
 
 func (s *serviceBlackBoxTest) SetupTest() {
@@ -499,3 +503,267 @@ func (s *serviceBlackBoxTest) TestInvalidOAuthAuthorizationCode() {
 	assert.NotContains(s.T(), locationString, refererKeycloakUrl)
 	assert.Contains(s.T(), locationString, refererUrl)
 }
+
+// callRejectInactiveUsers calls the RejectInactiveUsers middleware on behalf of a user in the given state and returns
+// whether the request was handled, along with the error returned by the middleware
+func (s *serviceBlackBoxTest) callRejectInactiveUsers(state string) (bool, error) {
+	return s.callRejectInactiveIdentity(state, nil)
+}
+
+// callRejectInactiveIdentity calls the RejectInactiveUsers middleware on behalf of an identity deactivated at the
+// given time, if any, whose user is in the given state, and returns whether the request was handled, along with
+// the error returned by the middleware
+func (s *serviceBlackBoxTest) callRejectInactiveIdentity(state string, deactivatedAt *time.Time) (bool, error) {
+	user := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: "TestRejectInactiveUsers",
+		State:    state,
+	}
+	require.Nil(s.T(), account.NewUserRepository(s.DB).Create(s.ctx, &user))
+	identity := account.Identity{
+		Username:      "TestRejectInactiveUsers" + uuid.NewV4().String(),
+		ProviderType:  account.KeycloakIDP,
+		UserID:        account.NullUUID{UUID: user.ID, Valid: true},
+		DeactivatedAt: deactivatedAt,
+	}
+	require.Nil(s.T(), account.NewIdentityRepository(s.DB).Create(s.ctx, &identity))
+	ctx := goajwt.WithJWT(context.Background(), &jwt.Token{Raw: "sometoken", Claims: jwt.MapClaims{"sub": identity.ID.String()}})
+	handled := false
+	handler := RejectInactiveUsers(gormapplication.NewGormDB(s.DB))(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		handled = true
+		return nil
+	})
+	err := handler(ctx, httptest.NewRecorder(), nil)
+	return handled, err
+}
+
+func (s *serviceBlackBoxTest) TestRejectInactiveUsersAcceptsActiveUser() {
+	handled, err := s.callRejectInactiveUsers(account.UserStateActive)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), handled)
+}
+
+func (s *serviceBlackBoxTest) TestRejectInactiveUsersRejectsBannedUser() {
+	handled, err := s.callRejectInactiveUsers(account.UserStateBanned)
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+	serviceError, ok := err.(goa.ServiceError)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), http.StatusForbidden, serviceError.ResponseStatus())
+}
+
+func (s *serviceBlackBoxTest) TestRejectInactiveUsersRejectsDeactivatedUser() {
+	handled, err := s.callRejectInactiveUsers(account.UserStateDeactivated)
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+}
+
+func (s *serviceBlackBoxTest) TestRejectInactiveUsersRejectsDeactivatedIdentity() {
+	deactivatedAt := time.Now().Add(-time.Hour)
+	handled, err := s.callRejectInactiveIdentity(account.UserStateActive, &deactivatedAt)
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+	serviceError, ok := err.(goa.ServiceError)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), http.StatusForbidden, serviceError.ResponseStatus())
+}
+
+// callAuthenticatePersonalAccessTokens calls the AuthenticatePersonalAccessTokens middleware with the given bearer
+// token and returns the context given to the handler, or nil if the request was not handled, along with whether the
+// request was handed over to the JWT middleware and the error returned by the middleware
+func (s *serviceBlackBoxTest) callAuthenticatePersonalAccessTokens(bearer string) (context.Context, bool, error) {
+	var handledCtx context.Context
+	delegated := false
+	jwtMiddleware := func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			delegated = true
+			return nil
+		}
+	}
+	handler := AuthenticatePersonalAccessTokens(gormapplication.NewGormDB(s.DB), jwtMiddleware)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		handledCtx = ctx
+		return nil
+	})
+	req, err := http.NewRequest("GET", "http://api.service.domain.org/api/user", nil)
+	require.Nil(s.T(), err)
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	err = handler(context.Background(), httptest.NewRecorder(), req)
+	return handledCtx, delegated, err
+}
+
+// createPersonalAccessToken creates a personal access token granted the given scopes for a new active user
+func (s *serviceBlackBoxTest) createPersonalAccessToken(scopes string) (account.Identity, account.PersonalAccessToken, string) {
+	user := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: "TestAuthenticatePersonalAccessTokens",
+		State:    account.UserStateActive,
+	}
+	require.Nil(s.T(), account.NewUserRepository(s.DB).Create(s.ctx, &user))
+	identity := account.Identity{
+		Username:     "TestAuthenticatePersonalAccessTokens" + uuid.NewV4().String(),
+		ProviderType: account.KeycloakIDP,
+		UserID:       account.NullUUID{UUID: user.ID, Valid: true},
+	}
+	require.Nil(s.T(), account.NewIdentityRepository(s.DB).Create(s.ctx, &identity))
+	rawToken, tokenHash, err := account.GeneratePersonalAccessToken()
+	require.Nil(s.T(), err)
+	pat := account.PersonalAccessToken{IdentityID: identity.ID, Name: "ci", TokenHash: tokenHash, Scopes: scopes}
+	require.Nil(s.T(), account.NewPersonalAccessTokenRepository(s.DB).Create(s.ctx, &pat))
+	return identity, pat, rawToken
+}
+
+func (s *serviceBlackBoxTest) TestAuthenticatePersonalAccessTokensAcceptsActiveToken() {
+	// given
+	identity, pat, rawToken := s.createPersonalAccessToken(token.ScopeAdminUsers)
+	// when
+	ctx, delegated, err := s.callAuthenticatePersonalAccessTokens(rawToken)
+	// then the handler is given a token with the identity and the scopes of the personal access token
+	require.Nil(s.T(), err)
+	assert.False(s.T(), delegated)
+	require.NotNil(s.T(), ctx)
+	assert.Equal(s.T(), identity.ID.String(), goajwt.ContextJWT(ctx).Claims.(jwt.MapClaims)["sub"])
+	assert.True(s.T(), token.ContextHasScope(ctx, token.ScopeAdminUsers))
+	require.NotNil(s.T(), token.ContextPersonalAccessToken(ctx))
+	assert.Equal(s.T(), pat.ID, *token.ContextPersonalAccessToken(ctx))
+	loaded, err := account.NewPersonalAccessTokenRepository(s.DB).Load(s.ctx, pat.ID)
+	require.Nil(s.T(), err)
+	assert.NotNil(s.T(), loaded.LastUsedAt)
+}
+
+func (s *serviceBlackBoxTest) TestAuthenticatePersonalAccessTokensThrottlesLastUse() {
+	// given a token used a few seconds ago
+	_, pat, rawToken := s.createPersonalAccessToken("")
+	lastUsedAt := time.Now().Add(-10 * time.Second)
+	pat.LastUsedAt = &lastUsedAt
+	require.Nil(s.T(), account.NewPersonalAccessTokenRepository(s.DB).Save(s.ctx, &pat))
+	before := time.Now()
+	// when
+	ctx, _, err := s.callAuthenticatePersonalAccessTokens(rawToken)
+	// then the last use is not recorded again
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), ctx)
+	loaded, err := account.NewPersonalAccessTokenRepository(s.DB).Load(s.ctx, pat.ID)
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), loaded.LastUsedAt)
+	assert.True(s.T(), loaded.LastUsedAt.Before(before))
+}
+
+func (s *serviceBlackBoxTest) TestAuthenticatePersonalAccessTokensRejectsRevokedToken() {
+	// given
+	_, pat, rawToken := s.createPersonalAccessToken("")
+	now := time.Now()
+	pat.RevokedAt = &now
+	require.Nil(s.T(), account.NewPersonalAccessTokenRepository(s.DB).Save(s.ctx, &pat))
+	// when
+	ctx, delegated, err := s.callAuthenticatePersonalAccessTokens(rawToken)
+	// then
+	require.NotNil(s.T(), err)
+	assert.Nil(s.T(), ctx)
+	assert.False(s.T(), delegated)
+}
+
+func (s *serviceBlackBoxTest) TestAuthenticatePersonalAccessTokensRejectsUnknownToken() {
+	// when
+	ctx, delegated, err := s.callAuthenticatePersonalAccessTokens(account.PersonalAccessTokenPrefix + "unknown")
+	// then
+	require.NotNil(s.T(), err)
+	assert.Nil(s.T(), ctx)
+	assert.False(s.T(), delegated)
+}
+
+func (s *serviceBlackBoxTest) TestAuthenticatePersonalAccessTokensDelegatesJWT() {
+	// when
+	_, delegated, err := s.callAuthenticatePersonalAccessTokens("eyJhbGciOiJSUzI1NiJ9.e30.c2lnbmF0dXJl")
+	// then
+	require.Nil(s.T(), err)
+	assert.True(s.T(), delegated)
+}
+
+// createSession creates a session of a new identity and returns it along with a signed access token of the session
+func (s *serviceBlackBoxTest) createSession() (*auth.Session, string) {
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestSession"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	session, err := auth.NewSessionRepository(s.DB).Create(s.ctx, &auth.Session{IdentityID: identity.ID, SessionState: uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+	privateKey, err := token.ParsePrivateKey([]byte(token.RSAPrivateKey))
+	require.Nil(s.T(), err)
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub":           identity.ID.String(),
+		"session_state": session.SessionState,
+		"exp":           time.Now().Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	require.Nil(s.T(), err)
+	return session, accessToken
+}
+
+func (s *serviceBlackBoxTest) loadSession(sessionState string) *auth.Session {
+	session, err := auth.NewSessionRepository(s.DB).LoadBySessionState(s.ctx, sessionState)
+	require.Nil(s.T(), err)
+	return session
+}
+
+// callRejectRevokedSessions calls the RejectRevokedSessions middleware with a token of the given session and returns
+// whether the request was handled, along with the error returned by the middleware
+func (s *serviceBlackBoxTest) callRejectRevokedSessions(sessionState string) (bool, error) {
+	ctx := goajwt.WithJWT(context.Background(), &jwt.Token{Raw: "sometoken", Claims: jwt.MapClaims{"session_state": sessionState}})
+	handled := false
+	handler := RejectRevokedSessions(gormapplication.NewGormDB(s.DB))(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		handled = true
+		return nil
+	})
+	err := handler(ctx, httptest.NewRecorder(), nil)
+	return handled, err
+}
+
+func (s *serviceBlackBoxTest) TestRejectRevokedSessionsRejectsRevokedSession() {
+	// given
+	session, _ := s.createSession()
+	require.Nil(s.T(), auth.NewSessionRepository(s.DB).Revoke(s.ctx, session.ID))
+	// when
+	handled, err := s.callRejectRevokedSessions(session.SessionState)
+	// then
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+}
+
+func (s *serviceBlackBoxTest) TestRevokeSessionRejectsItsTokens() {
+	// given
+	session, accessToken := s.createSession()
+	handled, err := s.callRejectRevokedSessions(session.SessionState)
+	require.Nil(s.T(), err)
+	require.True(s.T(), handled)
+	publicKey, err := token.ParsePublicKey([]byte(token.RSAPublicKey))
+	require.Nil(s.T(), err)
+	// when
+	err = RevokeSession(s.ctx, gormapplication.NewGormDB(s.DB), publicKey, accessToken)
+	// then the session is revoked and its tokens are rejected at once, even though its state was cached
+	require.Nil(s.T(), err)
+	assert.NotNil(s.T(), s.loadSession(session.SessionState).RevokedAt)
+	handled, err = s.callRejectRevokedSessions(session.SessionState)
+	require.NotNil(s.T(), err)
+	assert.False(s.T(), handled)
+}
+
+func (s *serviceBlackBoxTest) TestRefreshSessionExtendsSession() {
+	// given
+	session, accessToken := s.createSession()
+	refreshExpiresIn := 1800
+	// when
+	err := s.loginService.RefreshSession(s.ctx, auth.Token{AccessToken: &accessToken, RefreshExpiresIn: &refreshExpiresIn})
+	// then
+	require.Nil(s.T(), err)
+	expiresAt := s.loadSession(session.SessionState).ExpiresAt
+	require.NotNil(s.T(), expiresAt)
+	assert.True(s.T(), expiresAt.After(time.Now().Add(29*time.Minute)))
+}
+
+func (s *serviceBlackBoxTest) TestRefreshSessionRejectsRevokedSession() {
+	// given
+	session, accessToken := s.createSession()
+	require.Nil(s.T(), auth.NewSessionRepository(s.DB).Revoke(s.ctx, session.ID))
+	// when
+	err := s.loginService.RefreshSession(s.ctx, auth.Token{AccessToken: &accessToken})
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.UnauthorizedError{}, err)
+}
@@ -256,3 +256,11 @@ func (d *dummyUserProfileService) Update(keycloakUserProfile *KeycloakUserProfil
 func (d *dummyUserProfileService) Get(accessToken string, keycloakProfileURL string) (*KeycloakUserProfileResponse, error) {
 	return d.profile, nil
 }
+
+func (d *dummyUserProfileService) Create(keycloakUserProfile *KeycloakUserProfile, protectionAPIToken string, keycloakAdminUserAPIURL string) (*string, error) {
+	return nil, nil
+}
+
+func (d *dummyUserProfileService) Delete(keycloakUserID string, protectionAPIToken string, keycloakAdminUserAPIURL string) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package login
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// sessionCacheTTL is how long the JWT middleware trusts the cached revocation state of a session. A session revoked
+	// by another instance of the service is rejected by this instance after at most this duration.
+	sessionCacheTTL = 30 * time.Second
+	// sessionCacheMaxSize is the number of cached sessions above which the outdated ones are evicted
+	sessionCacheMaxSize = 10000
+)
+
+type sessionCacheEntry struct {
+	revoked  bool
+	cachedAt time.Time
+}
+
+// sessionCache caches the revocation state of the sessions by session state, so that the JWT middleware
+// does not look up the session of every request
+type sessionCache struct {
+	entries map[string]sessionCacheEntry
+	lock    sync.RWMutex
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{entries: make(map[string]sessionCacheEntry)}
+}
+
+// revokedSessions is the cache of the sessions checked by the JWT middleware and revoked by this instance of the service
+var revokedSessions = newSessionCache()
+
+// get returns whether the session with the given session state is revoked.
+// The second value (ok) is false if the session is not in the cache or if its entry is outdated.
+func (c *sessionCache) get(sessionState string) (revoked bool, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entry, ok := c.entries[sessionState]
+	if !ok || time.Since(entry.cachedAt) > sessionCacheTTL {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+// put caches the revocation state of the session with the given session state
+func (c *sessionCache) put(sessionState string, revoked bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.entries) >= sessionCacheMaxSize {
+		for s, entry := range c.entries {
+			if time.Since(entry.cachedAt) > sessionCacheTTL {
+				delete(c.entries, s)
+			}
+		}
+	}
+	c.entries[sessionState] = sessionCacheEntry{revoked: revoked, cachedAt: time.Now()}
+}
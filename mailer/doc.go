@@ -0,0 +1,2 @@
+// Package mailer contains the functions used to send emails to the users.
+package mailer
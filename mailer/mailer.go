@@ -0,0 +1,109 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+
+	"golang.org/x/net/context"
+)
+
+// Mailer sends emails
+type Mailer interface {
+	// Send sends a plain text email with the given subject and body to the given address
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// Configuration the configuration of the mailer
+type Configuration interface {
+	GetMailerSMTPHost() string
+	GetMailerSMTPPort() int
+	GetMailerSMTPUser() string
+	GetMailerSMTPPassword() string
+	GetMailerFrom() string
+}
+
+// NewMailer creates a mailer sending the emails through the configured SMTP server.
+// If no SMTP server is configured, the emails are only logged.
+func NewMailer(config Configuration) Mailer {
+	if config.GetMailerSMTPHost() == "" {
+		return &LogMailer{}
+	}
+	var auth smtp.Auth
+	if config.GetMailerSMTPUser() != "" {
+		auth = smtp.PlainAuth("", config.GetMailerSMTPUser(), config.GetMailerSMTPPassword(), config.GetMailerSMTPHost())
+	}
+	return &SMTPMailer{
+		addr: config.GetMailerSMTPHost() + ":" + strconv.Itoa(config.GetMailerSMTPPort()),
+		auth: auth,
+		from: config.GetMailerFrom(),
+	}
+}
+
+// SMTPMailer implements Mailer by sending the emails through an SMTP server
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// Send sends the email through the SMTP server
+// returns BadParameterError if the address is invalid, or InternalError
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	msg, err := Message(m.from, to, subject, body)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, msg); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"smtp_server": m.addr,
+			"to":          to,
+			"err":         err,
+		}, "unable to send the email")
+		return errors.NewInternalError(fmt.Sprintf("unable to send the email: %s", err.Error()))
+	}
+	log.Info(ctx, map[string]interface{}{
+		"to":      to,
+		"subject": subject,
+	}, "email sent")
+	return nil
+}
+
+// LogMailer implements Mailer by logging the emails instead of sending them, for development purposes
+type LogMailer struct{}
+
+// Send logs the email
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	log.Info(ctx, map[string]interface{}{
+		"to":      to,
+		"subject": subject,
+		"body":    body,
+	}, "no SMTP server configured, email not sent")
+	return nil
+}
+
+// Message returns the content of a plain text email with the given headers and body.
+// returns BadParameterError if an address contains a line break, which would allow to inject headers
+func Message(from, to, subject, body string) ([]byte, error) {
+	if strings.ContainsAny(from, "\r\n") {
+		return nil, errors.NewBadParameterError("from", from).Expected("an email address")
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return nil, errors.NewBadParameterError("to", to).Expected("an email address")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,36 @@
+package mailer_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/mailer"
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	msg, err := mailer.Message("noreply@example.com", "john@example.com", "Verify your email", "Hello\nJohn")
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, "From: noreply@example.com\r\n"+
+		"To: john@example.com\r\n"+
+		"Subject: Verify your email\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n"+
+		"\r\n"+
+		"Hello\r\nJohn", string(msg))
+}
+
+func TestMessageWithHeaderInjection(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// when
+	_, err := mailer.Message("noreply@example.com", "john@example.com\r\nBcc: jane@example.com", "Verify your email", "Hello")
+	// then
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, err)
+}
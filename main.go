@@ -16,15 +16,19 @@ import (
 	"github.com/almighty/almighty-core/account"
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/auth"
+	"github.com/almighty/almighty-core/avatar"
 	config "github.com/almighty/almighty-core/configuration"
 	"github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/goasupport"
 	"github.com/almighty/almighty-core/gormapplication"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/mailer"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
 	"github.com/almighty/almighty-core/remoteworkitem"
+	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/space/authz"
 	"github.com/almighty/almighty-core/token"
@@ -79,6 +83,8 @@ func main() {
 
 	// Initialized developer mode flag and log level for the logger
 	log.InitializeLogger(configuration.IsPostgresDeveloperModeEnabled(), configuration.GetLogLevel())
+	// the image policy applies on all the rendered markdown content, including the work item descriptions and the comments
+	rendering.SetImagePolicy(rendering.NewImagePolicy(configuration.GetRenderImageAllowedHosts(), configuration.GetRenderImageAllowedMIMETypes()))
 
 	printUserInfo()
 
@@ -150,12 +156,13 @@ func main() {
 	service.Use(middleware.LogRequest(configuration.IsPostgresDeveloperModeEnabled()))
 	service.Use(gzip.Middleware(9))
 	service.Use(jsonapi.ErrorHandler(service, true))
+	service.Use(goasupport.RequestTimeout(configuration.GetHTTPRequestTimeout()))
 	service.Use(middleware.Recover())
 
 	service.WithLogger(goalogrus.New(log.Logger()))
 
 	// Scheduler to fetch and import remote tracker items
-	scheduler = remoteworkitem.NewScheduler(db)
+	scheduler = remoteworkitem.NewScheduler(db, configuration)
 	defer scheduler.Stop()
 
 	accessTokens := controller.GetAccessTokens(configuration)
@@ -174,17 +181,25 @@ func main() {
 
 	appDB := gormapplication.NewGormDB(db)
 
+	// Deactivation of the identities which stopped logging in
+	stopDeactivation := login.ScheduleInactiveIdentitiesDeactivation(service.Context, appDB, configuration)
+	defer stopDeactivation()
+
+	// Synchronization of the cached space collaborators with Keycloak
+	stopReconciliation := controller.ScheduleSpaceCollaboratorsReconciliation(service.Context, appDB, auth.NewKeycloakPolicyManager(configuration), configuration)
+	defer stopReconciliation()
+
 	tokenManager := token.NewManager(publicKey)
-	app.UseJWTMiddleware(service, jwt.New(publicKey, nil, app.NewJWTSecurity()))
+	app.UseJWTMiddleware(service, login.AuthenticatePersonalAccessTokens(appDB, jwt.New(publicKey, login.ValidateTokens(appDB), app.NewJWTSecurity())))
 	service.Use(login.InjectTokenManager(tokenManager))
 	spaceAuthzService := authz.NewAuthzService(configuration, appDB)
 	service.Use(authz.InjectAuthzService(spaceAuthzService))
 
-	loginService := login.NewKeycloakOAuthProvider(identityRepository, userRepository, tokenManager, appDB)
+	loginService := login.NewKeycloakOAuthProvider(identityRepository, userRepository, tokenManager, appDB, configuration)
 	loginCtrl := controller.NewLoginController(service, loginService, tokenManager, configuration)
 	app.MountLoginController(service, loginCtrl)
 
-	logoutCtrl := controller.NewLogoutController(service, &login.KeycloakLogoutService{}, configuration)
+	logoutCtrl := controller.NewLogoutController(service, &login.KeycloakLogoutService{}, appDB, tokenManager, configuration)
 	app.MountLogoutController(service, logoutCtrl)
 
 	// Mount "status" controller
@@ -192,7 +207,7 @@ func main() {
 	app.MountStatusController(service, statusCtrl)
 
 	// Mount "workitem" controller
-	workitemCtrl := controller.NewWorkitemController(service, appDB, configuration)
+	workitemCtrl := controller.NewWorkitemController(service, appDB, configuration, auth.NewKeycloakPolicyManager(configuration))
 	app.MountWorkitemController(service, workitemCtrl)
 
 	// Mount "workitemtype" controller
@@ -219,6 +234,10 @@ func main() {
 	workItemRelationshipsLinksCtrl := controller.NewWorkItemRelationshipsLinksController(service, appDB, configuration)
 	app.MountWorkItemRelationshipsLinksController(service, workItemRelationshipsLinksCtrl)
 
+	// Mount "work item revisions" controller
+	workItemRevisionsCtrl := controller.NewWorkItemRevisionsController(service, appDB)
+	app.MountWorkItemRevisionsController(service, workItemRevisionsCtrl)
+
 	// Mount "comments" controller
 	commentsCtrl := controller.NewCommentsController(service, appDB, configuration)
 	app.MountCommentsController(service, commentsCtrl)
@@ -237,9 +256,12 @@ func main() {
 
 	// Mount "user" controller
 	userCtrl := controller.NewUserController(service, appDB, tokenManager)
+	// the user and users controllers share the same limit of concurrent tenant initializations
+	var initTenant func(context.Context) error
 	if configuration.GetTenantServiceURL() != "" {
 		log.Logger().Infof("Enabling Init Tenant service %v", configuration.GetTenantServiceURL())
-		userCtrl.InitTenant = account.NewInitTenant(configuration)
+		initTenant = account.NewInitTenant(configuration)
+		userCtrl.InitTenant = initTenant
 	}
 	app.MountUserController(service, userCtrl)
 
@@ -253,9 +275,44 @@ func main() {
 
 	// Mount "users" controller
 	keycloakProfileService := login.NewKeycloakUserProfileClient()
-	usersCtrl := controller.NewUsersController(service, appDB, configuration, keycloakProfileService)
+	usersCtrl := controller.NewUsersController(service, appDB, configuration, keycloakProfileService, auth.NewKeycloakPolicyManager(configuration), auth.NewKeycloakUserSessionManager(configuration), mailer.NewMailer(configuration), auth.NewKeycloakUserEmailManager(configuration))
+	usersCtrl.InitTenant = initTenant
 	app.MountUsersController(service, usersCtrl)
 
+	// Mount "users_avatar" controller
+	avatarStorage, err := avatar.NewStorage(configuration)
+	if err != nil {
+		log.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to setup the avatar storage")
+	}
+	usersAvatarCtrl := controller.NewUsersAvatarController(service, appDB, configuration, avatarStorage)
+	app.MountUsersAvatarController(service, usersAvatarCtrl)
+
+	// Mount "users_preferences" controller
+	usersPreferencesCtrl := controller.NewUsersPreferencesController(service, appDB)
+	app.MountUsersPreferencesController(service, usersPreferencesCtrl)
+
+	// Mount "users_emails" controller
+	usersEmailsCtrl := controller.NewUsersEmailsController(service, appDB, configuration, mailer.NewMailer(configuration), auth.NewKeycloakUserEmailManager(configuration))
+	app.MountUsersEmailsController(service, usersEmailsCtrl)
+
+	// Mount "users_tokens" controller
+	usersTokensCtrl := controller.NewUsersTokensController(service, appDB)
+	app.MountUsersTokensController(service, usersTokensCtrl)
+
+	// Mount "teams" controller
+	teamsCtrl := controller.NewTeamsController(service, appDB)
+	app.MountTeamsController(service, teamsCtrl)
+
+	// Mount "teams_members" controller
+	teamsMembersCtrl := controller.NewTeamsMembersController(service, appDB)
+	app.MountTeamsMembersController(service, teamsMembersCtrl)
+
+	// Mount "admin" controller
+	adminCtrl := controller.NewAdminController(service, appDB, configuration)
+	app.MountAdminController(service, adminCtrl)
+
 	// Mount "iterations" controller
 	iterationCtrl := controller.NewIterationController(service, appDB, configuration)
 	app.MountIterationController(service, iterationCtrl)
@@ -298,10 +355,26 @@ func main() {
 	spaceCodebaseCtrl := controller.NewSpaceCodebasesController(service, appDB)
 	app.MountSpaceCodebasesController(service, spaceCodebaseCtrl)
 
+	// Mount "notification" controller
+	notificationCtrl := controller.NewNotificationController(service, appDB)
+	app.MountNotificationController(service, notificationCtrl)
+
 	// Mount "collaborators" controller
 	collaboratorsCtrl := controller.NewCollaboratorsController(service, appDB, configuration, auth.NewKeycloakPolicyManager(configuration))
 	app.MountCollaboratorsController(service, collaboratorsCtrl)
 
+	// Mount "collaborator-policies" controller
+	collaboratorPoliciesCtrl := controller.NewCollaboratorPoliciesController(service, appDB, auth.NewKeycloakPolicyManager(configuration))
+	app.MountCollaboratorPoliciesController(service, collaboratorPoliciesCtrl)
+
+	// Mount "space_invitations" controller
+	spaceInvitationsCtrl := controller.NewSpaceInvitationsController(service, appDB, auth.NewKeycloakPolicyManager(configuration), mailer.NewMailer(configuration))
+	app.MountSpaceInvitationsController(service, spaceInvitationsCtrl)
+
+	// Mount "invitations" controller
+	invitationsCtrl := controller.NewInvitationsController(service, appDB, auth.NewKeycloakPolicyManager(configuration))
+	app.MountInvitationsController(service, invitationsCtrl)
+
 	if !configuration.IsPostgresDeveloperModeEnabled() {
 		// TEMP MOUNT "redirect" controller
 		redirectWorkItemTypesCtrl := controller.NewRedirectWorkitemtypeController(service)
@@ -285,6 +285,75 @@ func GetMigrations() Migrations {
 	// Version 55
 	m = append(m, steps{ExecuteSQLFile("055-assign-root-area-if-missing.sql")})
 
+	// Version 56
+	m = append(m, steps{ExecuteSQLFile("056-add-theme-to-spaces.sql")})
+
+	// Version 57
+	m = append(m, steps{ExecuteSQLFile("057-sessions.sql")})
+
+	// Version 58
+	m = append(m, steps{ExecuteSQLFile("058-notifications.sql")})
+
+	// Version 59
+	m = append(m, steps{ExecuteSQLFile("059-add-deactivated-at-to-identities.sql")})
+
+	// Version 60
+	m = append(m, steps{ExecuteSQLFile("060-add-unique-titles-per-iteration-to-spaces.sql")})
+
+	// Version 61
+	m = append(m, steps{ExecuteSQLFile("061-work-item-numbers.sql")})
+
+	// Version 62
+	m = append(m, steps{ExecuteSQLFile("062-add-last-login-at-to-identities.sql")})
+
+	// Version 63
+	m = append(m, steps{ExecuteSQLFile("063-add-pending-email-to-users.sql")})
+
+	// Version 64
+	m = append(m, steps{ExecuteSQLFile("064-user-preferences.sql")})
+
+	// Version 65
+	m = append(m, steps{ExecuteSQLFile("065-username-history.sql")})
+
+	// Version 66
+	m = append(m, steps{ExecuteSQLFile("066-add-state-to-users.sql")})
+
+	// Version 67
+	m = append(m, steps{ExecuteSQLFile("067-user-emails.sql")})
+
+	// Version 68
+	m = append(m, steps{ExecuteSQLFile("068-users-search-indexes.sql")})
+
+	// Version 69
+	m = append(m, steps{ExecuteSQLFile("069-impersonations.sql")})
+
+	// Version 70
+	m = append(m, steps{ExecuteSQLFile("070-profile-events.sql")})
+
+	// Version 71
+	m = append(m, steps{ExecuteSQLFile("071-users-company-index.sql")})
+
+	// Version 72
+	m = append(m, steps{ExecuteSQLFile("072-personal-access-tokens.sql")})
+
+	// Version 73
+	m = append(m, steps{ExecuteSQLFile("073-teams.sql")})
+
+	// Version 74
+	m = append(m, steps{ExecuteSQLFile("074-invitations.sql")})
+
+	// Version 75
+	m = append(m, steps{ExecuteSQLFile("075-space-collaborators.sql")})
+
+	// Version 76
+	m = append(m, steps{ExecuteSQLFile("076-space-collaborators-identity-index.sql")})
+
+	// Version 77
+	m = append(m, steps{ExecuteSQLFile("077-sessions-expiry.sql")})
+
+	// Version 78
+	m = append(m, steps{ExecuteSQLFile("078-work-items-title-iteration-unique-index.sql")})
+
 	// Version N
 	//
 	// In order to add an upgrade, simply append an array of MigrationFunc to the
@@ -462,6 +531,9 @@ func BootstrapWorkItemLinking(ctx context.Context, linkCatRepo *link.GormWorkIte
 	if err := createOrUpdateWorkItemLinkType(ctx, linkCatRepo, linkTypeRepo, spaceRepo, link.SystemWorkItemLinkTypeParentChild, "One planner item or a subtype of it which is a parent of another one.", link.TopologyTree, "parent of", "child of", workitem.SystemPlannerItem, workitem.SystemPlannerItem, link.SystemWorkItemLinkCategorySystem, space.SystemSpace); err != nil {
 		return errs.WithStack(err)
 	}
+	if err := createOrUpdateWorkItemLinkType(ctx, linkCatRepo, linkTypeRepo, spaceRepo, link.SystemWorkItemLinkTypeDuplicateOf, "One planner item or a subtype of it duplicates another one.", link.TopologyDirectedNetwork, "duplicate of", "has duplicate", workitem.SystemPlannerItem, workitem.SystemPlannerItem, link.SystemWorkItemLinkCategorySystem, space.SystemSpace); err != nil {
+		return errs.WithStack(err)
+	}
 	return nil
 }
 
@@ -644,6 +716,7 @@ func createOrUpdateSystemPlannerItemType(ctx context.Context, witr *workitem.Gor
 		workitem.SystemIteration:    {Type: workitem.SimpleType{Kind: "iteration"}, Required: false, Label: "Iteration", Description: "The iteration to which the work item belongs"},
 		workitem.SystemArea:         {Type: workitem.SimpleType{Kind: "area"}, Required: false, Label: "Area", Description: "The area to which the work item belongs"},
 		workitem.SystemCodebase:     {Type: workitem.SimpleType{Kind: "codebase"}, Required: false, Label: "Codebase", Description: "Contains codebase attributes to which this WI belongs to"},
+		workitem.SystemDueDate:      {Type: workitem.SimpleType{Kind: "instant"}, Required: false, Label: "Due date", Description: "The date and time by which the work item is expected to be done"},
 		workitem.SystemAssignees: {
 			Type: &workitem.ListType{
 				SimpleType:    workitem.SimpleType{Kind: workitem.KindList},
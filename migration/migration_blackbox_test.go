@@ -112,6 +112,29 @@ func TestMigrations(t *testing.T) {
 	t.Run("TestMigration52", testMigration52)
 	t.Run("testMigration53", testMigration53)
 	t.Run("TestMigration54", testMigration54)
+	t.Run("TestMigration56", testMigration56)
+	t.Run("TestMigration57", testMigration57)
+	t.Run("TestMigration58", testMigration58)
+	t.Run("TestMigration59", testMigration59)
+	t.Run("TestMigration60", testMigration60)
+	t.Run("TestMigration61", testMigration61)
+	t.Run("TestMigration62", testMigration62)
+	t.Run("TestMigration63", testMigration63)
+	t.Run("TestMigration64", testMigration64)
+	t.Run("TestMigration65", testMigration65)
+	t.Run("TestMigration66", testMigration66)
+	t.Run("TestMigration67", testMigration67)
+	t.Run("TestMigration68", testMigration68)
+	t.Run("TestMigration69", testMigration69)
+	t.Run("TestMigration70", testMigration70)
+	t.Run("TestMigration71", testMigration71)
+	t.Run("TestMigration72", testMigration72)
+	t.Run("TestMigration73", testMigration73)
+	t.Run("TestMigration74", testMigration74)
+	t.Run("TestMigration75", testMigration75)
+	t.Run("TestMigration76", testMigration76)
+	t.Run("TestMigration77", testMigration77)
+	t.Run("TestMigration78", testMigration78)
 
 	// Perform the migration
 	if err := migration.Migrate(sqlDB, databaseName); err != nil {
@@ -253,6 +276,192 @@ func testMigration54(t *testing.T) {
 	assert.Nil(t, runSQLscript(sqlDB, "054-add-stackid-to-codebase.sql"))
 }
 
+func testMigration56(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+12)], (initialMigratedVersion + 12))
+
+	assert.True(t, dialect.HasColumn("spaces", "theme_primary_color"))
+	assert.True(t, dialect.HasColumn("spaces", "theme_accent_color"))
+	assert.True(t, dialect.HasColumn("spaces", "theme_logo_url"))
+}
+
+func testMigration57(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+13)], (initialMigratedVersion + 13))
+
+	assert.True(t, dialect.HasTable("sessions"))
+	assert.True(t, dialect.HasIndex("sessions", "sessions_session_state_idx"))
+}
+
+func testMigration58(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+14)], (initialMigratedVersion + 14))
+
+	assert.True(t, dialect.HasTable("notifications"))
+	assert.True(t, dialect.HasIndex("notifications", "notifications_identity_id_idx"))
+}
+
+func testMigration59(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+15)], (initialMigratedVersion + 15))
+
+	assert.True(t, dialect.HasColumn("identities", "deactivated_at"))
+}
+
+func testMigration60(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+16)], (initialMigratedVersion + 16))
+
+	assert.True(t, dialect.HasColumn("spaces", "unique_titles_per_iteration"))
+}
+
+func testMigration61(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+17)], (initialMigratedVersion + 17))
+
+	assert.True(t, dialect.HasTable("work_item_number_sequences"))
+	assert.True(t, dialect.HasColumn("work_items", "number"))
+	assert.True(t, dialect.HasIndex("work_items", "work_items_space_id_number_idx"))
+}
+
+func testMigration62(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+18)], (initialMigratedVersion + 18))
+
+	assert.True(t, dialect.HasColumn("identities", "last_login_at"))
+}
+
+func testMigration63(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+19)], (initialMigratedVersion + 19))
+
+	assert.True(t, dialect.HasColumn("users", "pending_email"))
+	assert.True(t, dialect.HasColumn("users", "email_verification_token"))
+	assert.True(t, dialect.HasColumn("users", "email_verification_expires_at"))
+	assert.True(t, dialect.HasIndex("users", "users_email_verification_token_idx"))
+}
+
+func testMigration64(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+20)], (initialMigratedVersion + 20))
+
+	assert.True(t, gormDB.HasTable("user_preferences"))
+	assert.True(t, dialect.HasColumn("user_preferences", "landing_page"))
+	assert.True(t, dialect.HasColumn("user_preferences", "timezone"))
+	assert.True(t, dialect.HasColumn("user_preferences", "locale"))
+}
+
+func testMigration65(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+21)], (initialMigratedVersion + 21))
+
+	assert.True(t, gormDB.HasTable("username_history"))
+	assert.True(t, dialect.HasColumn("username_history", "old_username"))
+	assert.True(t, dialect.HasColumn("username_history", "new_username"))
+	assert.True(t, dialect.HasColumn("username_history", "changed_by"))
+	assert.True(t, dialect.HasIndex("username_history", "username_history_identity_id_idx"))
+}
+
+func testMigration66(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+22)], (initialMigratedVersion + 22))
+
+	assert.True(t, dialect.HasColumn("users", "state"))
+	assert.True(t, dialect.HasIndex("users", "users_state_idx"))
+}
+
+func testMigration67(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+23)], (initialMigratedVersion + 23))
+
+	assert.True(t, gormDB.HasTable("user_emails"))
+	assert.True(t, dialect.HasColumn("user_emails", "is_primary"))
+	assert.True(t, dialect.HasColumn("user_emails", "verified"))
+	assert.True(t, dialect.HasIndex("user_emails", "user_emails_email_idx"))
+	assert.True(t, dialect.HasIndex("user_emails", "user_emails_verification_token_idx"))
+}
+
+func testMigration68(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+24)], (initialMigratedVersion + 24))
+
+	assert.True(t, dialect.HasIndex("identities", "identities_username_trgm_idx"))
+	assert.True(t, dialect.HasIndex("users", "users_full_name_trgm_idx"))
+	assert.True(t, dialect.HasIndex("users", "users_email_trgm_idx"))
+}
+
+func testMigration69(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+25)], (initialMigratedVersion + 25))
+
+	assert.True(t, gormDB.HasTable("impersonations"))
+	assert.True(t, dialect.HasColumn("impersonations", "impersonator_id"))
+	assert.True(t, dialect.HasColumn("impersonations", "reason"))
+	assert.True(t, dialect.HasColumn("impersonations", "expires_at"))
+	assert.True(t, dialect.HasIndex("impersonations", "impersonations_identity_id_idx"))
+}
+
+func testMigration70(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+26)], (initialMigratedVersion + 26))
+
+	assert.True(t, gormDB.HasTable("profile_events"))
+	assert.True(t, dialect.HasColumn("profile_events", "field"))
+	assert.True(t, dialect.HasColumn("profile_events", "old_value"))
+	assert.True(t, dialect.HasColumn("profile_events", "new_value"))
+	assert.True(t, dialect.HasColumn("profile_events", "changed_by"))
+	assert.True(t, dialect.HasIndex("profile_events", "profile_events_identity_id_created_at_idx"))
+}
+
+func testMigration71(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+27)], (initialMigratedVersion + 27))
+
+	assert.True(t, dialect.HasIndex("users", "users_company_trgm_idx"))
+}
+
+func testMigration72(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+28)], (initialMigratedVersion + 28))
+
+	assert.True(t, gormDB.HasTable("personal_access_tokens"))
+	assert.True(t, dialect.HasColumn("personal_access_tokens", "token_hash"))
+	assert.True(t, dialect.HasColumn("personal_access_tokens", "scopes"))
+	assert.True(t, dialect.HasColumn("personal_access_tokens", "revoked_at"))
+	assert.True(t, dialect.HasIndex("personal_access_tokens", "personal_access_tokens_token_hash_idx"))
+}
+
+func testMigration73(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+29)], (initialMigratedVersion + 29))
+
+	assert.True(t, gormDB.HasTable("teams"))
+	assert.True(t, gormDB.HasTable("team_members"))
+	assert.True(t, gormDB.HasTable("space_teams"))
+	assert.True(t, dialect.HasIndex("teams", "teams_name_idx"))
+	assert.True(t, dialect.HasColumn("team_members", "identity_id"))
+	assert.True(t, dialect.HasColumn("space_teams", "team_id"))
+}
+
+func testMigration74(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+30)], (initialMigratedVersion + 30))
+
+	assert.True(t, gormDB.HasTable("invitations"))
+	assert.True(t, dialect.HasColumn("invitations", "invitee_id"))
+	assert.True(t, dialect.HasColumn("invitations", "invitee_email"))
+	assert.True(t, dialect.HasColumn("invitations", "state"))
+	assert.True(t, dialect.HasIndex("invitations", "invitations_space_id_idx"))
+}
+
+func testMigration75(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+31)], (initialMigratedVersion + 31))
+
+	assert.True(t, gormDB.HasTable("space_collaborators"))
+	assert.True(t, dialect.HasColumn("space_collaborators", "position"))
+	assert.True(t, dialect.HasColumn("space_resources", "collaborators_synced_at"))
+}
+
+func testMigration76(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+32)], (initialMigratedVersion + 32))
+
+	assert.True(t, dialect.HasIndex("space_collaborators", "space_collaborators_identity_id_idx"))
+}
+
+func testMigration77(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+33)], (initialMigratedVersion + 33))
+
+	assert.True(t, dialect.HasColumn("sessions", "expires_at"))
+}
+
+func testMigration78(t *testing.T) {
+	migrateToVersion(sqlDB, migrations[:(initialMigratedVersion+34)], (initialMigratedVersion + 34))
+
+	assert.True(t, dialect.HasColumn("work_items", "unique_title_in_iteration"))
+	assert.True(t, dialect.HasIndex("work_items", "work_items_title_iteration_unique_idx"))
+}
+
 // runSQLscript loads the given filename from the packaged SQL test files and
 // executes it on the given database. Golang text/template module is used
 // to handle all the optional arguments passed to the sql test files
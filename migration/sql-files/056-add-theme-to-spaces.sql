@@ -0,0 +1,4 @@
+-- optional branding of the spaces
+ALTER TABLE spaces ADD COLUMN theme_primary_color TEXT;
+ALTER TABLE spaces ADD COLUMN theme_accent_color TEXT;
+ALTER TABLE spaces ADD COLUMN theme_logo_url TEXT;
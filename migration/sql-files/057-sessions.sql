@@ -0,0 +1,12 @@
+-- Create the table tracking the login sessions of the identities
+CREATE TABLE sessions (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    session_state text NOT NULL,
+    revoked_at timestamp with time zone
+);
+CREATE UNIQUE INDEX sessions_session_state_idx ON sessions (session_state);
+CREATE INDEX sessions_identity_id_idx ON sessions (identity_id) WHERE revoked_at IS NULL;
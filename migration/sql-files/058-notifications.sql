@@ -0,0 +1,11 @@
+-- Create the table holding the notifications sent to the identities
+CREATE TABLE notifications (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    message text NOT NULL,
+    read_at timestamp with time zone
+);
+CREATE INDEX notifications_identity_id_idx ON notifications (identity_id) WHERE read_at IS NULL;
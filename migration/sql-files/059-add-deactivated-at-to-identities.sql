@@ -0,0 +1,2 @@
+-- Record when an identity was deactivated
+ALTER TABLE identities ADD COLUMN deactivated_at timestamp with time zone;
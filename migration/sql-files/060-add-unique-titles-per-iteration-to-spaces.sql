@@ -0,0 +1,2 @@
+-- optional rule rejecting work items with the same title in the same iteration
+ALTER TABLE spaces ADD COLUMN unique_titles_per_iteration BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,17 @@
+-- per-space sequential numbers of the work items
+CREATE TABLE work_item_number_sequences (
+    space_id uuid PRIMARY KEY REFERENCES spaces(id) ON DELETE CASCADE,
+    current_val integer NOT NULL
+);
+
+ALTER TABLE work_items ADD COLUMN number integer;
+
+-- number the existing work items of each space in their order of creation
+UPDATE work_items SET number = seq.row_number FROM (
+    SELECT id, row_number() OVER (PARTITION BY space_id ORDER BY created_at, id) FROM work_items
+) AS seq WHERE work_items.id = seq.id;
+
+INSERT INTO work_item_number_sequences (space_id, current_val)
+    SELECT space_id, max(number) FROM work_items GROUP BY space_id;
+
+CREATE UNIQUE INDEX work_items_space_id_number_idx ON work_items (space_id, number);
@@ -0,0 +1,4 @@
+-- Record when a Keycloak identity last logged in
+ALTER TABLE identities ADD COLUMN last_login_at timestamp with time zone;
+-- existing Keycloak identities start counting their inactivity from now on
+UPDATE identities SET last_login_at = now() WHERE provider_type = 'kc';
@@ -0,0 +1,5 @@
+-- Record the email a user asked to switch to, until it is verified with the token sent to that email
+ALTER TABLE users ADD COLUMN pending_email TEXT;
+ALTER TABLE users ADD COLUMN email_verification_token TEXT;
+ALTER TABLE users ADD COLUMN email_verification_expires_at timestamp with time zone;
+CREATE UNIQUE INDEX users_email_verification_token_idx ON users (email_verification_token);
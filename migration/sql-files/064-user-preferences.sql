@@ -0,0 +1,13 @@
+-- Create the table holding the preferences of the users
+CREATE TABLE user_preferences (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    user_id uuid primary key NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    landing_page text NOT NULL,
+    email_notify_assigned boolean NOT NULL DEFAULT TRUE,
+    email_notify_commented boolean NOT NULL DEFAULT TRUE,
+    email_notify_mentioned boolean NOT NULL DEFAULT TRUE,
+    timezone text NOT NULL DEFAULT 'UTC',
+    locale text NOT NULL DEFAULT 'en'
+);
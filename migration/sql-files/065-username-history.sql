@@ -0,0 +1,12 @@
+-- Create the table recording the changes of the usernames of the identities
+CREATE TABLE username_history (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    old_username text NOT NULL,
+    new_username text NOT NULL,
+    changed_by uuid NOT NULL
+);
+CREATE INDEX username_history_identity_id_idx ON username_history (identity_id);
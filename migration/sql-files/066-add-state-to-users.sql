@@ -0,0 +1,3 @@
+-- Record whether a user is active, deactivated or banned by an administrator
+ALTER TABLE users ADD COLUMN state TEXT NOT NULL DEFAULT 'active' CHECK (state IN ('active', 'deactivated', 'banned'));
+CREATE INDEX users_state_idx ON users (state);
@@ -0,0 +1,22 @@
+-- Create the table holding the email addresses of the users, the primary one being also stored in the users table
+CREATE TABLE user_emails (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email text NOT NULL,
+    is_primary boolean NOT NULL DEFAULT FALSE,
+    verified boolean NOT NULL DEFAULT FALSE,
+    verification_token text,
+    verification_expires_at timestamp with time zone
+);
+CREATE UNIQUE INDEX user_emails_email_idx ON user_emails (lower(email)) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX user_emails_verification_token_idx ON user_emails (verification_token);
+CREATE INDEX user_emails_user_id_idx ON user_emails (user_id);
+
+-- the current email of each user becomes its primary email
+INSERT INTO user_emails (created_at, updated_at, user_id, email, is_primary, verified)
+    SELECT DISTINCT ON (lower(email)) now(), now(), id, email, TRUE, TRUE FROM users
+    WHERE deleted_at IS NULL AND email IS NOT NULL AND email <> ''
+    ORDER BY lower(email), created_at;
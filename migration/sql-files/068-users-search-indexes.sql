@@ -0,0 +1,5 @@
+-- Trigram indexes to support the case-insensitive, partial-match filtering of the users
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX identities_username_trgm_idx ON identities USING GIN (username gin_trgm_ops);
+CREATE INDEX users_full_name_trgm_idx ON users USING GIN (full_name gin_trgm_ops);
+CREATE INDEX users_email_trgm_idx ON users USING GIN (email gin_trgm_ops);
@@ -0,0 +1,13 @@
+-- Audit log of the tokens issued to the administrators to act as another identity
+CREATE TABLE impersonations (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    impersonator_id uuid NOT NULL REFERENCES identities(id),
+    identity_id uuid NOT NULL REFERENCES identities(id),
+    reason text NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+CREATE INDEX impersonations_impersonator_id_idx ON impersonations (impersonator_id);
+CREATE INDEX impersonations_identity_id_idx ON impersonations (identity_id);
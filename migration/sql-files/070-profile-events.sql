@@ -0,0 +1,13 @@
+-- Create the immutable log of the changes of the profiles of the users
+CREATE TABLE profile_events (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    field text NOT NULL,
+    old_value text,
+    new_value text,
+    changed_by uuid NOT NULL
+);
+CREATE INDEX profile_events_identity_id_created_at_idx ON profile_events (identity_id, created_at);
@@ -0,0 +1,2 @@
+-- Trigram index to support the case-insensitive filtering and the autocompletion of the companies of the users
+CREATE INDEX users_company_trgm_idx ON users USING GIN (company gin_trgm_ops);
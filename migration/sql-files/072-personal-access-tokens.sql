@@ -0,0 +1,16 @@
+-- Create the personal access tokens of the identities. Only the SHA-256 hash of each token is stored.
+CREATE TABLE personal_access_tokens (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    token_hash text NOT NULL,
+    scopes text NOT NULL DEFAULT '',
+    expires_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    last_used_at timestamp with time zone
+);
+CREATE UNIQUE INDEX personal_access_tokens_token_hash_idx ON personal_access_tokens (token_hash);
+CREATE INDEX personal_access_tokens_identity_id_idx ON personal_access_tokens (identity_id);
@@ -0,0 +1,27 @@
+-- Create the teams, named groups of identities which can be added as a whole to the collaborators of spaces.
+CREATE TABLE teams (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    name text NOT NULL,
+    description text
+);
+CREATE UNIQUE INDEX teams_name_idx ON teams (name) WHERE deleted_at IS NULL;
+
+CREATE TABLE team_members (
+    created_at timestamp with time zone,
+    team_id uuid NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    identity_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    PRIMARY KEY (team_id, identity_id)
+);
+CREATE INDEX team_members_identity_id_idx ON team_members (identity_id);
+
+-- The teams among the collaborators of the spaces
+CREATE TABLE space_teams (
+    created_at timestamp with time zone,
+    space_id uuid NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    team_id uuid NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    PRIMARY KEY (space_id, team_id)
+);
+CREATE INDEX space_teams_team_id_idx ON space_teams (team_id);
@@ -0,0 +1,15 @@
+-- Create the invitations to collaborate on the spaces. An invitee is either a known identity or an email address.
+CREATE TABLE invitations (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    space_id uuid NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    inviter_id uuid NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    invitee_id uuid REFERENCES identities(id) ON DELETE CASCADE,
+    invitee_email text,
+    role text NOT NULL DEFAULT 'contributor',
+    state text NOT NULL DEFAULT 'pending',
+    CONSTRAINT invitations_invitee_check CHECK (invitee_id IS NOT NULL OR invitee_email IS NOT NULL)
+);
+CREATE INDEX invitations_space_id_idx ON invitations (space_id);
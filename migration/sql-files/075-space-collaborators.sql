@@ -0,0 +1,12 @@
+-- Cache the collaborators of the space policies, so that they can be listed without calling Keycloak.
+-- The position keeps the order of the users in the policy.
+CREATE TABLE space_collaborators (
+    space_id uuid NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    identity_id uuid NOT NULL,
+    role text NOT NULL,
+    position integer NOT NULL,
+    PRIMARY KEY (space_id, identity_id)
+);
+
+-- When the cached collaborators were last synchronized with the space policy. NULL if never, or invalidated.
+ALTER TABLE space_resources ADD COLUMN collaborators_synced_at timestamp with time zone;
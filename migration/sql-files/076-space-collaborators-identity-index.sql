@@ -0,0 +1,2 @@
+-- The spaces which an identity collaborates on are listed from the cached collaborators
+CREATE INDEX space_collaborators_identity_id_idx ON space_collaborators (identity_id);
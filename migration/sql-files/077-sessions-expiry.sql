@@ -0,0 +1,2 @@
+-- The sessions stop counting as active once their tokens cannot be refreshed anymore
+ALTER TABLE sessions ADD COLUMN expires_at timestamp with time zone;
@@ -0,0 +1,39 @@
+-- The work items of the spaces requiring distinct titles per iteration are flagged, so that a unique partial index
+-- rejects the duplicate titles, even when they are created concurrently. The duplicates which already exist are not
+-- flagged, except for the oldest one.
+ALTER TABLE work_items ADD COLUMN unique_title_in_iteration BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE work_items wi SET unique_title_in_iteration = TRUE
+    FROM spaces s
+    WHERE s.id = wi.space_id AND s.unique_titles_per_iteration AND wi.deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM work_items other
+        WHERE other.space_id = wi.space_id AND other.deleted_at IS NULL AND other.id < wi.id
+        AND other.fields->>'system.iteration' = wi.fields->>'system.iteration'
+        AND other.fields->>'system.title' = wi.fields->>'system.title');
+CREATE UNIQUE INDEX work_items_title_iteration_unique_idx
+    ON work_items (space_id, (fields->>'system.iteration'), (fields->>'system.title'))
+    WHERE unique_title_in_iteration AND deleted_at IS NULL AND fields->>'system.iteration' IS NOT NULL;
+
+-- the flag of the work items follows the rule of their space
+CREATE FUNCTION work_items_unique_title_in_iteration() RETURNS trigger AS $$
+BEGIN
+  NEW.unique_title_in_iteration := COALESCE((SELECT unique_titles_per_iteration FROM spaces WHERE id = NEW.space_id), FALSE);
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER work_items_unique_title_in_iteration_create BEFORE INSERT ON work_items
+    FOR EACH ROW EXECUTE PROCEDURE work_items_unique_title_in_iteration();
+CREATE TRIGGER work_items_unique_title_in_iteration_move BEFORE UPDATE OF space_id ON work_items
+    FOR EACH ROW WHEN (OLD.space_id IS DISTINCT FROM NEW.space_id)
+    EXECUTE PROCEDURE work_items_unique_title_in_iteration();
+
+CREATE FUNCTION spaces_unique_titles_per_iteration() RETURNS trigger AS $$
+BEGIN
+  UPDATE work_items SET unique_title_in_iteration = NEW.unique_titles_per_iteration
+      WHERE space_id = NEW.id AND deleted_at IS NULL;
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER spaces_unique_titles_per_iteration AFTER UPDATE OF unique_titles_per_iteration ON spaces
+    FOR EACH ROW WHEN (OLD.unique_titles_per_iteration IS DISTINCT FROM NEW.unique_titles_per_iteration)
+    EXECUTE PROCEDURE spaces_unique_titles_per_iteration();
@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+const notificationTableName = "notifications"
+
+// Notification represents a message sent to an identity, which remains unread until ReadAt is set
+type Notification struct {
+	gormsupport.Lifecycle
+	ID         uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	IdentityID uuid.UUID `sql:"type:uuid"`
+	Message    string
+	ReadAt     *time.Time
+}
+
+// TableName implements gorm.tabler
+func (n Notification) TableName() string {
+	return notificationTableName
+}
+
+// Repository encapsulate storage & retrieval of notifications
+type Repository interface {
+	Create(ctx context.Context, notification *Notification) (*Notification, error)
+	CountUnread(ctx context.Context, identityID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, identityID uuid.UUID, ID uuid.UUID) error
+}
+
+// NewRepository creates a new notification repo
+func NewRepository(db *gorm.DB) *GormRepository {
+	return &GormRepository{db}
+}
+
+// GormRepository implements Repository using gorm
+type GormRepository struct {
+	db *gorm.DB
+}
+
+// Create creates a new notification in the DB
+// returns InternalError
+func (r *GormRepository) Create(ctx context.Context, notification *Notification) (*Notification, error) {
+	if notification.ID == uuid.Nil {
+		notification.ID = uuid.NewV4()
+	}
+	tx := r.db.Create(notification)
+	if err := tx.Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	log.Info(ctx, map[string]interface{}{
+		"notification_id": notification.ID,
+		"identity_id":     notification.IdentityID,
+	}, "Notification created successfully")
+	return notification, nil
+}
+
+// CountUnread returns the number of notifications of the given identity which have not been read yet
+// returns InternalError
+func (r *GormRepository) CountUnread(ctx context.Context, identityID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Model(&Notification{}).Where("identity_id=? AND read_at IS NULL", identityID).Count(&count).Error
+	if err != nil {
+		return 0, errors.NewInternalError(err.Error())
+	}
+	return count, nil
+}
+
+// MarkRead marks the notification with the given id of the given identity as read.
+// Marking a notification which was already read has no effect.
+// returns NotFoundError or InternalError
+func (r *GormRepository) MarkRead(ctx context.Context, identityID uuid.UUID, ID uuid.UUID) error {
+	res := Notification{}
+	tx := r.db.Where("id=? AND identity_id=?", ID, identityID).First(&res)
+	if tx.RecordNotFound() {
+		return errors.NewNotFoundError("notification", ID.String())
+	}
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if res.ReadAt != nil {
+		return nil
+	}
+	if err := r.db.Model(&res).Update("read_at", time.Now()).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	return nil
+}
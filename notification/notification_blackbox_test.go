@@ -0,0 +1,71 @@
+package notification_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/notification"
+	"github.com/almighty/almighty-core/resource"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type notificationBlackBoxTest struct {
+	gormtestsupport.DBTestSuite
+	repo     notification.Repository
+	identity account.Identity
+	clean    func()
+	ctx      context.Context
+}
+
+func TestRunNotificationBlackBoxTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &notificationBlackBoxTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (s *notificationBlackBoxTest) SetupTest() {
+	s.ctx = context.Background()
+	s.repo = notification.NewRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	identity, err := testsupport.CreateTestIdentity(s.DB, "notification-test-"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(s.T(), err)
+	s.identity = identity
+}
+
+func (s *notificationBlackBoxTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *notificationBlackBoxTest) TestCountUnreadAfterMarkRead() {
+	// given
+	n1, err := s.repo.Create(s.ctx, &notification.Notification{IdentityID: s.identity.ID, Message: "first"})
+	require.Nil(s.T(), err)
+	_, err = s.repo.Create(s.ctx, &notification.Notification{IdentityID: s.identity.ID, Message: "second"})
+	require.Nil(s.T(), err)
+	count, err := s.repo.CountUnread(s.ctx, s.identity.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, count)
+	// when
+	err = s.repo.MarkRead(s.ctx, s.identity.ID, n1.ID)
+	// then
+	require.Nil(s.T(), err)
+	count, err = s.repo.CountUnread(s.ctx, s.identity.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *notificationBlackBoxTest) TestMarkReadUnknownNotification() {
+	// when
+	err := s.repo.MarkRead(s.ctx, s.identity.ID, uuid.NewV4())
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.NotFoundError{}, err)
+}
@@ -0,0 +1,51 @@
+package remoteworkitem
+
+import (
+	"sync"
+	"time"
+)
+
+// importPool limits the number of remote tracker items which are imported
+// concurrently, so that a large fetch does not overwhelm the database.
+type importPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// newImportPool creates a new pool which runs at most `concurrency` jobs at the same time.
+// Values lower than 1 are treated as 1.
+func newImportPool(concurrency int) *importPool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &importPool{slots: make(chan struct{}, concurrency)}
+}
+
+// Submit runs the given job as soon as a slot is available. The call blocks
+// until the job has been started.
+func (p *importPool) Submit(job func()) {
+	p.slots <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+		job()
+	}()
+}
+
+// Wait blocks until all submitted jobs have completed.
+func (p *importPool) Wait() {
+	p.wg.Wait()
+}
+
+// ImportStatus holds the progress of the last import job of a tracker query
+type ImportStatus struct {
+	TrackerQueryID uint64
+	Running        bool
+	Processed      int
+	Failed         int
+	StartedAt      time.Time
+	FinishedAt     *time.Time
+}
@@ -0,0 +1,68 @@
+package remoteworkitem
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPoolRespectsConcurrency(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	for _, concurrency := range []int{1, 2, 5} {
+		pool := newImportPool(concurrency)
+		var running, maxRunning, done int32
+		var lock sync.Mutex
+		for i := 0; i < 20; i++ {
+			pool.Submit(func() {
+				current := atomic.AddInt32(&running, 1)
+				lock.Lock()
+				if current > maxRunning {
+					maxRunning = current
+				}
+				lock.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&done, 1)
+			})
+		}
+		pool.Wait()
+		require.Equal(t, int32(20), done)
+		assert.True(t, maxRunning <= int32(concurrency), "expected at most %d concurrent jobs, got %d", concurrency, maxRunning)
+		assert.True(t, maxRunning >= 1)
+	}
+}
+
+func TestImportPoolWithInvalidConcurrency(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	pool := newImportPool(0)
+	require.Equal(t, 1, cap(pool.slots))
+}
+
+func TestSchedulerImportStatus(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	s := Scheduler{status: map[uint64]ImportStatus{}}
+	_, ok := s.ImportStatus(1)
+	assert.False(t, ok)
+
+	s.startImport(1)
+	s.recordImport(1, nil)
+	s.recordImport(1, NotFoundError{"tracker query", "1"})
+	status, ok := s.ImportStatus(1)
+	require.True(t, ok)
+	assert.True(t, status.Running)
+	assert.Equal(t, 2, status.Processed)
+	assert.Equal(t, 1, status.Failed)
+
+	s.finishImport(1)
+	status, _ = s.ImportStatus(1)
+	assert.False(t, status.Running)
+	assert.NotNil(t, status.FinishedAt)
+}
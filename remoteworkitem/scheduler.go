@@ -1,6 +1,9 @@
 package remoteworkitem
 
 import (
+	"sync"
+	"time"
+
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/models"
 
@@ -13,24 +16,36 @@ import (
 
 // TrackerSchedule capture all configuration
 type trackerSchedule struct {
-	TrackerID   int
-	URL         string
-	TrackerType string
-	Query       string
-	Schedule    string
-	SpaceID     uuid.UUID
+	TrackerQueryID uint64
+	TrackerID      int
+	URL            string
+	TrackerType    string
+	Query          string
+	Schedule       string
+	SpaceID        uuid.UUID
+}
+
+type schedulerConfiguration interface {
+	GetRemoteItemImportConcurrency() int
 }
 
 // Scheduler represents scheduler
 type Scheduler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	concurrency int
+	statusLock  sync.RWMutex
+	status      map[uint64]ImportStatus
 }
 
 var cr *cron.Cron
 
 // NewScheduler creates a new Scheduler
-func NewScheduler(db *gorm.DB) *Scheduler {
-	s := Scheduler{db: db}
+func NewScheduler(db *gorm.DB, config schedulerConfiguration) *Scheduler {
+	s := Scheduler{
+		db:          db,
+		concurrency: config.GetRemoteItemImportConcurrency(),
+		status:      map[uint64]ImportStatus{},
+	}
 	return &s
 }
 
@@ -58,26 +73,81 @@ func (s *Scheduler) ScheduleAllQueries(ctx context.Context, accessTokens map[str
 			// In case of Jira, no auth token is needed hence the map wouldnt
 			// return anything. So effectively the authToken is optional.
 
+			s.startImport(tq.TrackerQueryID)
+			pool := newImportPool(s.concurrency)
 			for i := range tr.Fetch(authToken) {
-				models.Transactional(s.db, func(tx *gorm.DB) error {
-					// Save the remote items in a 'temporary' table.
-					err := upload(tx, tq.TrackerID, i)
-					if err != nil {
+				item := i
+				pool.Submit(func() {
+					err := models.Transactional(s.db, func(tx *gorm.DB) error {
+						// Save the remote items in a 'temporary' table.
+						err := upload(tx, tq.TrackerID, item)
+						if err != nil {
+							return errors.WithStack(err)
+						}
+						// Convert the remote item into a local work item and persist in the DB.
+						_, err = convertToWorkItemModel(ctx, tx, tq.TrackerID, item, tq.TrackerType, tq.SpaceID)
 						return errors.WithStack(err)
+					})
+					if err != nil {
+						log.Error(ctx, map[string]interface{}{
+							"tracker_query_id": tq.TrackerQueryID,
+							"remote_item_id":   item.ID,
+							"err":              err,
+						}, "failed to import remote tracker item")
 					}
-					// Convert the remote item into a local work item and persist in the DB.
-					_, err = convertToWorkItemModel(ctx, tx, tq.TrackerID, i, tq.TrackerType, tq.SpaceID)
-					return errors.WithStack(err)
+					s.recordImport(tq.TrackerQueryID, err)
 				})
 			}
+			pool.Wait()
+			s.finishImport(tq.TrackerQueryID)
 		})
 	}
 	cr.Start()
 }
 
+// ImportStatus returns the progress of the latest import job for the given tracker query.
+// The second return value is false if no import was started since the scheduler was created.
+func (s *Scheduler) ImportStatus(trackerQueryID uint64) (ImportStatus, bool) {
+	s.statusLock.RLock()
+	defer s.statusLock.RUnlock()
+	status, ok := s.status[trackerQueryID]
+	return status, ok
+}
+
+func (s *Scheduler) startImport(trackerQueryID uint64) {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+	s.status[trackerQueryID] = ImportStatus{
+		TrackerQueryID: trackerQueryID,
+		Running:        true,
+		StartedAt:      time.Now(),
+	}
+}
+
+func (s *Scheduler) recordImport(trackerQueryID uint64, err error) {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+	status := s.status[trackerQueryID]
+	status.Processed++
+	if err != nil {
+		status.Failed++
+	}
+	s.status[trackerQueryID] = status
+}
+
+func (s *Scheduler) finishImport(trackerQueryID uint64) {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+	status := s.status[trackerQueryID]
+	status.Running = false
+	now := time.Now()
+	status.FinishedAt = &now
+	s.status[trackerQueryID] = status
+}
+
 func fetchTrackerQueries(db *gorm.DB) []trackerSchedule {
 	tsList := []trackerSchedule{}
-	err := db.Table("tracker_queries").Select("trackers.id as tracker_id, trackers.url, trackers.type as tracker_type, tracker_queries.query, tracker_queries.schedule, tracker_queries.space_id").Joins("left join trackers on tracker_queries.tracker_id = trackers.id").Where("trackers.deleted_at is NULL AND tracker_queries.deleted_at is NULL").Scan(&tsList).Error
+	err := db.Table("tracker_queries").Select("tracker_queries.id as tracker_query_id, trackers.id as tracker_id, trackers.url, trackers.type as tracker_type, tracker_queries.query, tracker_queries.schedule, tracker_queries.space_id").Joins("left join trackers on tracker_queries.tracker_id = trackers.id").Where("trackers.deleted_at is NULL AND tracker_queries.deleted_at is NULL").Scan(&tsList).Error
 	if err != nil {
 		log.Error(nil, map[string]interface{}{
 			"err": err,
@@ -0,0 +1,124 @@
+package rendering
+
+import (
+	"bytes"
+	"mime"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ImagePolicy restricts the images which can be embedded in rendered content.
+// An empty list of hosts (resp. MIME types) means that no restriction applies on hosts (resp. MIME types).
+type ImagePolicy struct {
+	AllowedHosts     []string
+	AllowedMIMETypes []string
+}
+
+// NewImagePolicy creates an ImagePolicy from the given allowed hosts and MIME types.
+func NewImagePolicy(allowedHosts, allowedMIMETypes []string) ImagePolicy {
+	return ImagePolicy{AllowedHosts: allowedHosts, AllowedMIMETypes: allowedMIMETypes}
+}
+
+// imagePolicy is the policy applied on the images of all the markdown content rendered in HTML.
+// No restriction applies until SetImagePolicy is called.
+var imagePolicy ImagePolicy
+
+// SetImagePolicy sets the policy applied on the images of all the markdown content rendered in HTML,
+// be it by the render endpoint, in the work item descriptions or in the comments.
+// It is meant to be called once, at startup.
+func SetImagePolicy(policy ImagePolicy) {
+	imagePolicy = policy
+}
+
+// Allows indicates if an image with the given source URL can be embedded.
+// Images with a relative URL are served by the same host and are only subject to the MIME type restriction,
+// but the URLs with a scheme and no host are rejected, since browsers resolve them to a remote host.
+// The MIME type of an image is guessed from the extension in its URL path. Images served by an allowed host
+// without an extension, or with an unknown one, are trusted, since many image hosts do not use extensions.
+func (p ImagePolicy) Allows(src string) bool {
+	// browsers read backslashes as slashes, e.g. in `/\evil.com/x.png`, which Go would parse as a relative path
+	if strings.Contains(src, "\\") {
+		return false
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+	relative := u.Scheme == "" && u.Host == "" && u.Opaque == ""
+	if u.Host == "" && !relative {
+		// e.g. `http:evil.com/x.png`, which browsers resolve to a remote host
+		return false
+	}
+	allowedHost := false
+	if !relative && len(p.AllowedHosts) > 0 {
+		host := u.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !containsFold(p.AllowedHosts, host) {
+			return false
+		}
+		allowedHost = true
+	}
+	if len(p.AllowedMIMETypes) > 0 {
+		mimeType := mime.TypeByExtension(path.Ext(u.Path))
+		if i := strings.Index(mimeType, ";"); i >= 0 {
+			mimeType = mimeType[:i]
+		}
+		if mimeType == "" {
+			return allowedHost
+		}
+		if !containsFold(p.AllowedMIMETypes, mimeType) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterImages strips the `img` elements whose source is not allowed by the given policy from the given HTML content.
+// The rest of the content is left untouched.
+func FilterImages(content string, policy ImagePolicy) string {
+	if len(policy.AllowedHosts) == 0 && len(policy.AllowedMIMETypes) == 0 {
+		return content
+	}
+	var out bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, or invalid content which the sanitizer should have already dealt with
+			return out.String()
+		}
+		// copy the raw bytes, as reading the token may alter the underlying buffer
+		raw := append([]byte(nil), z.Raw()...)
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			if token := z.Token(); token.DataAtom == atom.Img && !policy.Allows(imageSource(token)) {
+				continue
+			}
+		}
+		out.Write(raw)
+	}
+}
+
+func imageSource(token html.Token) string {
+	for _, attr := range token.Attr {
+		if attr.Key == "src" {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package rendering_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/rendering"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePolicyAllows(t *testing.T) {
+	policy := rendering.NewImagePolicy([]string{"images.example.com"}, []string{"image/png", "image/jpeg"})
+	assert.True(t, policy.Allows("https://images.example.com/foo.png"))
+	assert.True(t, policy.Allows("https://IMAGES.example.com:8443/foo.jpg"))
+	assert.True(t, policy.Allows("/relative/foo.png"))
+	assert.False(t, policy.Allows("https://evil.example.com/foo.png"))
+	assert.False(t, policy.Allows("https://images.example.com/foo.svg"))
+	assert.True(t, policy.Allows("https://images.example.com/foo"))
+	assert.True(t, policy.Allows("https://images.example.com/foo.unknownext"))
+	assert.False(t, policy.Allows("https://evil.example.com/foo"))
+	assert.False(t, policy.Allows("/relative/foo"))
+	assert.False(t, policy.Allows("http:evil.example.com/foo.png"))
+	assert.False(t, policy.Allows(`https:\\evil.example.com\foo.png`))
+	assert.False(t, policy.Allows(`/\evil.example.com/foo.png`))
+}
+
+func TestFilterImages(t *testing.T) {
+	policy := rendering.NewImagePolicy([]string{"images.example.com"}, nil)
+
+	t.Run("disallowed host stripped", func(t *testing.T) {
+		content := `<p>before <img src="https://evil.example.com/foo.png" alt="evil"/> after</p>`
+		assert.Equal(t, "<p>before  after</p>", rendering.FilterImages(content, policy))
+	})
+
+	t.Run("allowed host kept", func(t *testing.T) {
+		content := `<p>before <img src="https://images.example.com/foo.png" alt="ok"/> after</p>`
+		assert.Equal(t, content, rendering.FilterImages(content, policy))
+	})
+
+	t.Run("no restriction", func(t *testing.T) {
+		content := `<p><img src="https://evil.example.com/foo.png" alt="evil"/></p>`
+		assert.Equal(t, content, rendering.FilterImages(content, rendering.NewImagePolicy(nil, nil)))
+	})
+}
@@ -16,6 +16,7 @@ func IsMarkupSupported(markup string) bool {
 
 // RenderMarkupToHTML converts the given `content` in HTML using the markup tool corresponding to the given `markup` argument
 // or return nil if no tool for the given `markup` is available, or returns an `error` if the command was not found or failed.
+// The images of the markdown content which are not allowed by the image policy are stripped.
 func RenderMarkupToHTML(content, markup string) string {
 	switch markup {
 	case SystemMarkupPlainText:
@@ -26,7 +27,7 @@ func RenderMarkupToHTML(content, markup string) string {
 		p.AllowAttrs("class").Matching(regexp.MustCompile("^language-[a-zA-Z0-9]+$|prettyprint")).OnElements("code")
 		p.AllowAttrs("class").OnElements("span")
 		html := string(p.SanitizeBytes(unsafe))
-		return html
+		return FilterImages(html, imagePolicy)
 	default:
 		return ""
 	}
@@ -41,3 +41,13 @@ func TestIsMarkupSupported(t *testing.T) {
 	assert.False(t, rendering.IsMarkupSupported(""))
 	assert.False(t, rendering.IsMarkupSupported("foo"))
 }
+
+func TestRenderMarkdownContentWithImagePolicy(t *testing.T) {
+	rendering.SetImagePolicy(rendering.NewImagePolicy([]string{"images.example.com"}, nil))
+	defer rendering.SetImagePolicy(rendering.NewImagePolicy(nil, nil))
+	content := "![allowed](https://images.example.com/foo) ![disallowed](https://evil.example.com/foo.png)"
+	result := rendering.RenderMarkupToHTML(content, rendering.SystemMarkupMarkdown)
+	t.Log(result)
+	assert.True(t, strings.Contains(result, "https://images.example.com/foo"))
+	assert.False(t, strings.Contains(result, "evil.example.com"))
+}
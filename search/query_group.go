@@ -0,0 +1,58 @@
+package search
+
+import (
+	"sync"
+
+	"github.com/almighty/almighty-core/workitem"
+
+	errs "github.com/pkg/errors"
+)
+
+// QueryGroup coalesces identical concurrent search executions: while a search is running,
+// the callers asking for the same search wait for it to complete and share its result
+// instead of running the query again.
+type QueryGroup struct {
+	mu    sync.Mutex
+	calls map[string]*queryCall
+}
+
+// queryCall is a search execution in progress or completed
+type queryCall struct {
+	wg     sync.WaitGroup
+	result []workitem.WorkItem
+	count  uint64
+	err    error
+}
+
+// NewQueryGroup creates a new group of coalesced search executions
+func NewQueryGroup() *QueryGroup {
+	return &QueryGroup{calls: map[string]*queryCall{}}
+}
+
+// Do executes the given search function, making sure that only one execution per key is in-flight
+// at a given time. If a duplicate comes in, the duplicate caller waits for the original to complete
+// and receives the same result.
+func (g *QueryGroup) Do(key string, fn func() ([]workitem.WorkItem, uint64, error)) ([]workitem.WorkItem, uint64, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.result, c.count, c.err
+	}
+	c := &queryCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// the waiters are released and the key is forgotten even if the search panics,
+	// in which case they receive the error below
+	c.err = errs.Errorf("the search '%s' did not complete", key)
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.result, c.count, c.err = fn()
+	return c.result, c.count, c.err
+}
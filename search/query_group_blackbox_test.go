@@ -0,0 +1,115 @@
+package search_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/search"
+	"github.com/almighty/almighty-core/workitem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryGroupCoalescesConcurrentIdenticalSearches(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	g := search.NewQueryGroup()
+	var executions int32
+	release := make(chan struct{})
+	fn := func() ([]workitem.WorkItem, uint64, error) {
+		atomic.AddInt32(&executions, 1)
+		<-release
+		return []workitem.WorkItem{{ID: "1"}}, 1, nil
+	}
+	// when
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([][]workitem.WorkItem, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, count, err := g.Do("same query", fn)
+			assert.Nil(t, err)
+			assert.Equal(t, uint64(1), count)
+			results[i] = result
+		}(i)
+	}
+	// give all callers the time to join the in-flight search before completing it
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	// then
+	assert.Equal(t, int32(1), atomic.LoadInt32(&executions))
+	for _, result := range results {
+		require.Len(t, result, 1)
+		assert.Equal(t, "1", result[0].ID)
+	}
+}
+
+func TestQueryGroupRunsDistinctSearchesSeparately(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	g := search.NewQueryGroup()
+	var executions int32
+	fn := func() ([]workitem.WorkItem, uint64, error) {
+		atomic.AddInt32(&executions, 1)
+		return nil, 0, nil
+	}
+	// when
+	for i := 0; i < 3; i++ {
+		_, _, err := g.Do(fmt.Sprintf("query %d", i), fn)
+		require.Nil(t, err)
+	}
+	// also, a search is executed again once the previous identical one completed
+	_, _, err := g.Do("query 0", fn)
+	require.Nil(t, err)
+	// then
+	assert.Equal(t, int32(4), atomic.LoadInt32(&executions))
+}
+
+func TestQueryGroupReleasesWaitersWhenSearchPanics(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	g := search.NewQueryGroup()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func() ([]workitem.WorkItem, uint64, error) {
+		close(started)
+		<-release
+		panic("search failed")
+	}
+	go func() {
+		defer func() { recover() }()
+		g.Do("same query", fn)
+	}()
+	<-started
+	// when
+	waiterErr := make(chan error)
+	go func() {
+		_, _, err := g.Do("same query", func() ([]workitem.WorkItem, uint64, error) {
+			return nil, 0, nil
+		})
+		waiterErr <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	// then the waiter is released with an error
+	select {
+	case err := <-waiterErr:
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiter was not released")
+	}
+	// and the next identical search is executed again
+	result, _, err := g.Do("same query", func() ([]workitem.WorkItem, uint64, error) {
+		return []workitem.WorkItem{{ID: "1"}}, 1, nil
+	})
+	require.Nil(t, err)
+	require.Len(t, result, 1)
+}
@@ -43,9 +43,14 @@ type Permissions struct {
 // AuthzService represents a space authorization service
 type AuthzService interface {
 	Authorize(ctx context.Context, entitlementEndpoint string, spaceID string) (bool, error)
+	IsViewer(ctx context.Context, spaceID string) (bool, error)
 	Configuration() AuthzConfiguration
 }
 
+// ErrSpaceViewer is the class of the errors returned when a viewer of a space attempts to modify it
+// (there is no native support for 403 in goa)
+var ErrSpaceViewer = goa.NewErrorClass("forbidden", http.StatusForbidden)
+
 // AuthzConfiguration represents a Keycloak entitlement endpoint configuration
 type AuthzConfiguration interface {
 	GetKeycloakEndpointEntitlement(*goa.RequestData) (string, error)
@@ -89,12 +94,29 @@ func (s *KeycloakAuthzService) Configuration() AuthzConfiguration {
 	return s.config
 }
 
-// Authorize returns true and the corresponding Requesting Party Token if the current user is among the space collaborators
+// Authorize returns true if the current user is among the space collaborators, either listed in the space policy
+// or member of one of the teams of the space. The teams are not known to Keycloak, so their members are checked
+// against the database and the changes of the teams apply immediately.
 func (s *KeycloakAuthzService) Authorize(ctx context.Context, entitlementEndpoint string, spaceID string) (bool, error) {
+	authorized, err := s.authorizeByPolicy(ctx, entitlementEndpoint, spaceID)
+	if err != nil || authorized {
+		return authorized, err
+	}
+	return s.isTeamMember(ctx, spaceID)
+}
+
+// authorizeByPolicy returns true if the current user is listed in the space policy, as granted by the Requesting
+// Party Token or by a new entitlement
+func (s *KeycloakAuthzService) authorizeByPolicy(ctx context.Context, entitlementEndpoint string, spaceID string) (bool, error) {
 	jwttoken := goajwt.ContextJWT(ctx)
 	if jwttoken == nil {
 		return false, errs.NewUnauthorizedError("missing token")
 	}
+	if token.ContextPersonalAccessToken(ctx) != nil {
+		// the personal access tokens are neither JWTs nor known by Keycloak, so the policy can only be checked
+		// through the collaborators cached from it
+		return s.isCachedCollaborator(ctx, spaceID)
+	}
 	tm := tokencontext.ReadTokenManagerFromContext(ctx)
 	if tm == nil {
 		log.Error(ctx, map[string]interface{}{
@@ -146,6 +168,97 @@ func (s *KeycloakAuthzService) Authorize(ctx context.Context, entitlementEndpoin
 	return false, nil
 }
 
+// isCachedCollaborator returns true if the current user is among the collaborators cached from the space policy.
+// The spaces whose collaborators were never cached, or were invalidated, authorize nobody until they are cached again.
+func (s *KeycloakAuthzService) isCachedCollaborator(ctx context.Context, spaceID string) (bool, error) {
+	collaborator, synced, err := s.cachedCollaborator(ctx, spaceID)
+	if err != nil {
+		return false, err
+	}
+	if !synced {
+		log.Warn(ctx, map[string]interface{}{
+			"space-id": spaceID,
+		}, "the collaborators of the space are not cached, the personal access token is not authorized")
+		return false, nil
+	}
+	return collaborator != nil, nil
+}
+
+// IsViewer returns true if the current user is a viewer of the space. The roles are not known to Keycloak, so they are
+// read from the collaborators cached from the space policy: nobody is a viewer of the spaces whose collaborators are
+// not cached, until they are cached again.
+func (s *KeycloakAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	collaborator, synced, err := s.cachedCollaborator(ctx, spaceID)
+	if err != nil {
+		return false, err
+	}
+	if !synced {
+		log.Warn(ctx, map[string]interface{}{
+			"space-id": spaceID,
+		}, "the collaborators of the space are not cached, the role of the user is unknown")
+		return false, nil
+	}
+	return collaborator != nil && collaborator.Role == auth.PolicyRoleViewer, nil
+}
+
+// cachedCollaborator returns the current user among the collaborators cached from the space policy, or nil if the
+// user is not listed in the policy, along with whether the collaborators of the space are cached at all
+func (s *KeycloakAuthzService) cachedCollaborator(ctx context.Context, spaceID string) (*space.Collaborator, bool, error) {
+	jwttoken := goajwt.ContextJWT(ctx)
+	if jwttoken == nil {
+		return nil, false, errs.NewUnauthorizedError("missing token")
+	}
+	claims, ok := jwttoken.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, false, nil
+	}
+	identityID, err := uuid.FromString(fmt.Sprint(claims["sub"]))
+	if err != nil {
+		return nil, false, nil
+	}
+	spaceUUID, err := uuid.FromString(spaceID)
+	if err != nil {
+		return nil, false, errs.NewInternalError(err.Error())
+	}
+	var collaborators []space.Collaborator
+	var syncedAt *time.Time
+	err = application.Transactional(s.db, func(appl application.Application) error {
+		collaborators, syncedAt, err = appl.SpaceCollaborators().List(ctx, spaceUUID)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	for i, c := range collaborators {
+		if uuid.Equal(c.IdentityID, identityID) {
+			return &collaborators[i], syncedAt != nil, nil
+		}
+	}
+	return nil, syncedAt != nil, nil
+}
+
+// isTeamMember returns true if the current user is a member of one of the teams of the space
+func (s *KeycloakAuthzService) isTeamMember(ctx context.Context, spaceID string) (bool, error) {
+	claims, ok := goajwt.ContextJWT(ctx).Claims.(jwt.MapClaims)
+	if !ok {
+		return false, nil
+	}
+	identityID, err := uuid.FromString(fmt.Sprint(claims["sub"]))
+	if err != nil {
+		return false, nil
+	}
+	spaceUUID, err := uuid.FromString(spaceID)
+	if err != nil {
+		return false, errs.NewInternalError(err.Error())
+	}
+	var isMember bool
+	err = application.Transactional(s.db, func(appl application.Application) error {
+		isMember, err = appl.Teams().IsSpaceMember(ctx, spaceUUID, identityID)
+		return err
+	})
+	return isMember, err
+}
+
 func (s *KeycloakAuthzService) checkEntitlementForSpace(ctx context.Context, token jwt.Token, entitlementEndpoint string, spaceID string) (bool, error) {
 	resource := auth.EntitlementResource{
 		Permissions: []auth.ResourceSet{{Name: spaceID}},
@@ -212,3 +325,29 @@ func Authorize(ctx context.Context, spaceID string) (bool, error) {
 	manager := srv.(AuthzServiceManager)
 	return manager.AuthzService().Authorize(ctx, manager.EntitlementEndpoint(), spaceID)
 }
+
+// IsViewer returns true if the current user is a viewer of the space
+func IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	srv := tokencontext.ReadSpaceAuthzServiceFromContext(ctx)
+	if srv == nil {
+		log.Error(ctx, map[string]interface{}{
+			"space-id": spaceID,
+		}, "Missing space authz service")
+
+		return false, errors.New("missing space authz service")
+	}
+	return srv.(AuthzServiceManager).AuthzService().IsViewer(ctx, spaceID)
+}
+
+// RejectViewer returns an ErrSpaceViewer error if the current user is a viewer of the space. The viewers are listed in
+// the space policy, so Authorize grants them the space, but they are only allowed to read it.
+func RejectViewer(ctx context.Context, spaceID string) error {
+	viewer, err := IsViewer(ctx, spaceID)
+	if err != nil {
+		return err
+	}
+	if viewer {
+		return ErrSpaceViewer("the viewers of the space are not allowed to modify it")
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/notification"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/space/authz"
@@ -71,9 +72,29 @@ func (s *TestAuthzSuite) TestUserIsNotAmongSpaceCollaboratorsFails() {
 	require.False(s.T(), ok)
 }
 
+func (s *TestAuthzSuite) TestUserAmongSpaceTeamMembersOK() {
+	spaceID1 := uuid.NewV4().String()
+	spaceID2 := uuid.NewV4().String()
+	authzPayload := authz.AuthorizationPayload{Permissions: []authz.Permissions{{ResourceSetName: &spaceID1}}}
+	ok := s.checkTeamPermissions(authzPayload, spaceID2, true)
+	require.True(s.T(), ok)
+}
+
+func (s *TestAuthzSuite) TestUserIsNotAmongSpaceTeamMembersFails() {
+	spaceID1 := uuid.NewV4().String()
+	spaceID2 := uuid.NewV4().String()
+	authzPayload := authz.AuthorizationPayload{Permissions: []authz.Permissions{{ResourceSetName: &spaceID1}}}
+	ok := s.checkTeamPermissions(authzPayload, spaceID2, false)
+	require.False(s.T(), ok)
+}
+
 func (s *TestAuthzSuite) checkPermissions(authzPayload authz.AuthorizationPayload, spaceID string) bool {
+	return s.checkTeamPermissions(authzPayload, spaceID, false)
+}
+
+func (s *TestAuthzSuite) checkTeamPermissions(authzPayload authz.AuthorizationPayload, spaceID string, teamMember bool) bool {
 	resource := &space.Resource{}
-	authzService := authz.NewAuthzService(nil, &db{app{resource: resource}})
+	authzService := authz.NewAuthzService(nil, &db{app{resource: resource, teamMember: teamMember}})
 	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	testIdentity := testsupport.TestIdentity
 	svc := testsupport.ServiceAsUserWithAuthz("SpaceAuthz-Service", almtoken.NewManagerWithPrivateKey(priv), priv, testIdentity, authzPayload)
@@ -85,7 +106,8 @@ func (s *TestAuthzSuite) checkPermissions(authzPayload authz.AuthorizationPayloa
 }
 
 type app struct {
-	resource *space.Resource
+	resource   *space.Resource
+	teamMember bool
 }
 
 type db struct {
@@ -100,6 +122,12 @@ type resourceRepo struct {
 	resource *space.Resource
 }
 
+// teamRepo tells whether the current user is a member of the teams of any space
+type teamRepo struct {
+	account.TeamRepository
+	member bool
+}
+
 func (t *trx) Commit() error {
 	return nil
 }
@@ -109,7 +137,7 @@ func (t *trx) Rollback() error {
 }
 
 func (d *db) BeginTransaction() (application.Transaction, error) {
-	return &trx{}, nil
+	return &trx{d.app}, nil
 }
 
 func (a *app) WorkItems() workitem.WorkItemRepository {
@@ -120,6 +148,18 @@ func (a *app) WorkItemTypes() workitem.WorkItemTypeRepository {
 	return nil
 }
 
+func (a *app) Sessions() auth.SessionRepository {
+	return nil
+}
+
+func (a *app) Notifications() notification.Repository {
+	return nil
+}
+
+func (a *app) WorkItemRevisions() workitem.RevisionRepository {
+	return nil
+}
+
 func (a *app) Trackers() application.TrackerRepository {
 	return nil
 }
@@ -168,6 +208,42 @@ func (a *app) Users() account.UserRepository {
 	return nil
 }
 
+func (a *app) UserPreferences() account.UserPreferencesRepository {
+	return nil
+}
+
+func (a *app) UsernameHistory() account.UsernameHistoryRepository {
+	return nil
+}
+
+func (a *app) UserEmails() account.UserEmailRepository {
+	return nil
+}
+
+func (a *app) Impersonations() account.ImpersonationRepository {
+	return nil
+}
+
+func (a *app) ProfileEvents() account.ProfileEventRepository {
+	return nil
+}
+
+func (a *app) PersonalAccessTokens() account.PersonalAccessTokenRepository {
+	return nil
+}
+
+func (a *app) Teams() account.TeamRepository {
+	return &teamRepo{member: a.teamMember}
+}
+
+func (a *app) Invitations() space.InvitationRepository {
+	return nil
+}
+
+func (a *app) SpaceCollaborators() space.CollaboratorRepository {
+	return nil
+}
+
 func (a *app) Areas() area.Repository {
 	return nil
 }
@@ -196,9 +272,17 @@ func (r *resourceRepo) Delete(ctx netcontext.Context, ID uuid.UUID) error {
 	return nil
 }
 
+func (r *resourceRepo) List(ctx netcontext.Context) ([]space.Resource, error) {
+	return nil, nil
+}
+
 func (r *resourceRepo) LoadBySpace(ctx netcontext.Context, spaceID *uuid.UUID) (*space.Resource, error) {
 	resource := &space.Resource{}
 	past := time.Now().Unix() - 1000
 	resource.UpdatedAt = time.Unix(past, 0)
 	return resource, nil
 }
+
+func (r *teamRepo) IsSpaceMember(ctx netcontext.Context, spaceID uuid.UUID, identityID uuid.UUID) (bool, error) {
+	return r.member, nil
+}
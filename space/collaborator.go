@@ -0,0 +1,140 @@
+package space
+
+import (
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+const (
+	collaboratorTableName = "space_collaborators"
+)
+
+// Collaborator represents a collaborator of a space cached from the space policy, so that the collaborators
+// can be listed without calling Keycloak
+type Collaborator struct {
+	SpaceID    uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	IdentityID uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	// The role of the collaborator in the space policy
+	Role string
+	// The position of the collaborator in the space policy
+	Position int
+}
+
+// TableName implements gorm.tabler
+func (c Collaborator) TableName() string {
+	return collaboratorTableName
+}
+
+// CollaboratorRepository encapsulate storage & retrieval of the cached collaborators of the spaces
+type CollaboratorRepository interface {
+	List(ctx context.Context, spaceID uuid.UUID) ([]Collaborator, *time.Time, error)
+	Replace(ctx context.Context, spaceID uuid.UUID, collaborators []Collaborator) error
+	InvalidateByPolicy(ctx context.Context, policyID string) error
+	ListStale(ctx context.Context, syncedBefore time.Time) ([]uuid.UUID, error)
+	ListSpaces(ctx context.Context, identityID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// NewCollaboratorRepository creates a new cached collaborators repo
+func NewCollaboratorRepository(db *gorm.DB) *GormCollaboratorRepository {
+	return &GormCollaboratorRepository{db}
+}
+
+// GormCollaboratorRepository implements CollaboratorRepository using gorm
+type GormCollaboratorRepository struct {
+	db *gorm.DB
+}
+
+// List returns the cached collaborators of the given space in the order of the space policy, along with the time
+// they were synchronized with the policy. The time is nil if the collaborators of the space were never cached.
+// returns NotFoundError or InternalError
+func (r *GormCollaboratorRepository) List(ctx context.Context, spaceID uuid.UUID) ([]Collaborator, *time.Time, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "space_collaborator", "list"}, time.Now())
+	resource := Resource{}
+	tx := r.db.Where("space_id=?", spaceID).First(&resource)
+	if tx.RecordNotFound() {
+		return nil, nil, errors.NewNotFoundError("space resource", spaceID.String())
+	}
+	if tx.Error != nil {
+		return nil, nil, errors.NewInternalError(tx.Error.Error())
+	}
+	if resource.CollaboratorsSyncedAt == nil {
+		return nil, nil, nil
+	}
+	var res []Collaborator
+	if err := r.db.Where("space_id=?", spaceID).Order("position").Find(&res).Error; err != nil {
+		return nil, nil, errors.NewInternalError(err.Error())
+	}
+	return res, resource.CollaboratorsSyncedAt, nil
+}
+
+// Replace replaces the cached collaborators of the given space, in the given order, and records
+// that they were synchronized with the space policy now
+// returns InternalError
+func (r *GormCollaboratorRepository) Replace(ctx context.Context, spaceID uuid.UUID, collaborators []Collaborator) error {
+	defer goa.MeasureSince([]string{"goa", "db", "space_collaborator", "replace"}, time.Now())
+	if err := r.db.Where("space_id=?", spaceID).Delete(&Collaborator{}).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	for i := range collaborators {
+		collaborator := collaborators[i]
+		collaborator.SpaceID = spaceID
+		collaborator.Position = i
+		if err := r.db.Create(&collaborator).Error; err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"space_id":    spaceID,
+				"identity_id": collaborator.IdentityID,
+				"err":         err,
+			}, "unable to cache the space collaborator")
+			return errors.NewInternalError(err.Error())
+		}
+	}
+	// the update time of the space resource is left unchanged, since the collaborators did not change
+	err := r.db.Model(&Resource{}).Where("space_id=?", spaceID).UpdateColumn("collaborators_synced_at", time.Now()).Error
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// InvalidateByPolicy records that the cached collaborators of the space with the given policy are out of date,
+// so that they are loaded again from the policy
+// returns InternalError
+func (r *GormCollaboratorRepository) InvalidateByPolicy(ctx context.Context, policyID string) error {
+	defer goa.MeasureSince([]string{"goa", "db", "space_collaborator", "invalidate"}, time.Now())
+	err := r.db.Model(&Resource{}).Where("policy_id=?", policyID).UpdateColumn("collaborators_synced_at", nil).Error
+	if err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// ListStale returns the IDs of the spaces whose collaborators were cached before the given time, or never
+// returns InternalError
+func (r *GormCollaboratorRepository) ListStale(ctx context.Context, syncedBefore time.Time) ([]uuid.UUID, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "space_collaborator", "list_stale"}, time.Now())
+	var spaceIDs []uuid.UUID
+	err := r.db.Model(&Resource{}).Where("collaborators_synced_at IS NULL OR collaborators_synced_at < ?", syncedBefore).Pluck("space_id", &spaceIDs).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return spaceIDs, nil
+}
+
+// ListSpaces returns the IDs of the spaces whose cached collaborators include the given identity
+// returns InternalError
+func (r *GormCollaboratorRepository) ListSpaces(ctx context.Context, identityID uuid.UUID) ([]uuid.UUID, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "space_collaborator", "list_spaces"}, time.Now())
+	var spaceIDs []uuid.UUID
+	err := r.db.Model(&Collaborator{}).Where("identity_id=?", identityID).Pluck("space_id", &spaceIDs).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return spaceIDs, nil
+}
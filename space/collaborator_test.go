@@ -0,0 +1,117 @@
+package space_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestRunCollaboratorRepoBBTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &collaboratorRepoBBTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type collaboratorRepoBBTest struct {
+	gormtestsupport.DBTestSuite
+	repo     space.CollaboratorRepository
+	space    *space.Space
+	resource *space.Resource
+	clean    func()
+	ctx      context.Context
+}
+
+func (test *collaboratorRepoBBTest) SetupTest() {
+	test.ctx = context.Background()
+	test.repo = space.NewCollaboratorRepository(test.DB)
+	test.clean = cleaner.DeleteCreatedEntities(test.DB)
+	owner := account.Identity{
+		Username:     "TestCollaborator" + uuid.NewV4().String(),
+		ProviderType: account.KeycloakIDP,
+	}
+	require.Nil(test.T(), account.NewIdentityRepository(test.DB).Create(test.ctx, &owner))
+	s, err := space.NewRepository(test.DB).Create(test.ctx, &space.Space{Name: "TestCollaborator-" + uuid.NewV4().String(), OwnerId: owner.ID})
+	require.Nil(test.T(), err)
+	test.space = s
+	r, err := space.NewResourceRepository(test.DB).Create(test.ctx, &space.Resource{
+		ResourceID:   uuid.NewV4().String(),
+		PolicyID:     uuid.NewV4().String(),
+		PermissionID: uuid.NewV4().String(),
+		SpaceID:      s.ID,
+	})
+	require.Nil(test.T(), err)
+	test.resource = r
+}
+
+func (test *collaboratorRepoBBTest) TearDownTest() {
+	test.clean()
+}
+
+func (test *collaboratorRepoBBTest) TestListNeverCachedOK() {
+	collaborators, syncedAt, err := test.repo.List(test.ctx, test.space.ID)
+	require.Nil(test.T(), err)
+	assert.Nil(test.T(), syncedAt)
+	assert.Empty(test.T(), collaborators)
+}
+
+func (test *collaboratorRepoBBTest) TestListUnknownSpaceNotFound() {
+	_, _, err := test.repo.List(test.ctx, uuid.NewV4())
+	require.NotNil(test.T(), err)
+	assert.IsType(test.T(), errors.NotFoundError{}, err)
+}
+
+func (test *collaboratorRepoBBTest) TestReplaceAndListOK() {
+	// given
+	first := uuid.NewV4()
+	second := uuid.NewV4()
+	require.Nil(test.T(), test.repo.Replace(test.ctx, test.space.ID, []space.Collaborator{
+		{IdentityID: uuid.NewV4(), Role: "contributor"},
+	}))
+	before := time.Now()
+	// when
+	require.Nil(test.T(), test.repo.Replace(test.ctx, test.space.ID, []space.Collaborator{
+		{IdentityID: second, Role: "admin"},
+		{IdentityID: first, Role: "contributor"},
+	}))
+	// then the collaborators are listed in the given order
+	collaborators, syncedAt, err := test.repo.List(test.ctx, test.space.ID)
+	require.Nil(test.T(), err)
+	require.NotNil(test.T(), syncedAt)
+	assert.False(test.T(), syncedAt.Before(before.Add(-time.Second)))
+	require.Len(test.T(), collaborators, 2)
+	assert.Equal(test.T(), second, collaborators[0].IdentityID)
+	assert.Equal(test.T(), "admin", collaborators[0].Role)
+	assert.Equal(test.T(), first, collaborators[1].IdentityID)
+	// and the space resource is not updated
+	loaded, err := space.NewResourceRepository(test.DB).Load(test.ctx, test.resource.ID)
+	require.Nil(test.T(), err)
+	assert.Equal(test.T(), test.resource.UpdatedAt.Unix(), loaded.UpdatedAt.Unix())
+}
+
+func (test *collaboratorRepoBBTest) TestInvalidateAndListStaleOK() {
+	// given
+	require.Nil(test.T(), test.repo.Replace(test.ctx, test.space.ID, []space.Collaborator{}))
+	stale, err := test.repo.ListStale(test.ctx, time.Now().Add(-time.Hour))
+	require.Nil(test.T(), err)
+	assert.NotContains(test.T(), stale, test.space.ID)
+	// when
+	require.Nil(test.T(), test.repo.InvalidateByPolicy(test.ctx, test.resource.PolicyID))
+	// then
+	_, syncedAt, err := test.repo.List(test.ctx, test.space.ID)
+	require.Nil(test.T(), err)
+	assert.Nil(test.T(), syncedAt)
+	stale, err = test.repo.ListStale(test.ctx, time.Now().Add(-time.Hour))
+	require.Nil(test.T(), err)
+	assert.Contains(test.T(), stale, test.space.ID)
+}
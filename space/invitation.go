@@ -0,0 +1,123 @@
+package space
+
+import (
+	"time"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+const (
+	invitationTableName = "invitations"
+)
+
+// States of an invitation to collaborate on a space. The invitee becomes a collaborator of the space only
+// when the invitation is accepted.
+const (
+	InvitationStatePending  = "pending"
+	InvitationStateAccepted = "accepted"
+	InvitationStateRevoked  = "revoked"
+)
+
+// Invitation represents an invitation to collaborate on a space, sent to a known identity or to an email address
+type Invitation struct {
+	gormsupport.Lifecycle
+	ID        uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	SpaceID   uuid.UUID `sql:"type:uuid"` // Belongs to Space
+	InviterID uuid.UUID `sql:"type:uuid"`
+	// The invited identity, if known. It is set to the identity which accepted an invitation sent to an email address.
+	InviteeID    account.NullUUID `sql:"type:uuid"`
+	InviteeEmail *string
+	// The role of the invitee in the space once the invitation is accepted
+	Role  string
+	State string
+}
+
+// TableName implements gorm.tabler
+func (i Invitation) TableName() string {
+	return invitationTableName
+}
+
+// InvitationRepository encapsulate storage & retrieval of the invitations to collaborate on the spaces
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *Invitation) error
+	Save(ctx context.Context, invitation *Invitation) error
+	Load(ctx context.Context, ID uuid.UUID) (*Invitation, error)
+	ListPending(ctx context.Context, spaceID uuid.UUID) ([]Invitation, error)
+}
+
+// NewInvitationRepository creates a new invitation repo
+func NewInvitationRepository(db *gorm.DB) *GormInvitationRepository {
+	return &GormInvitationRepository{db}
+}
+
+// GormInvitationRepository implements InvitationRepository using gorm
+type GormInvitationRepository struct {
+	db *gorm.DB
+}
+
+// Create creates a new pending invitation
+// returns InternalError
+func (r *GormInvitationRepository) Create(ctx context.Context, invitation *Invitation) error {
+	defer goa.MeasureSince([]string{"goa", "db", "invitation", "create"}, time.Now())
+	if invitation.ID == uuid.Nil {
+		invitation.ID = uuid.NewV4()
+	}
+	invitation.State = InvitationStatePending
+	if err := r.db.Create(invitation).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id": invitation.SpaceID,
+			"err":      err,
+		}, "unable to create the invitation")
+		return errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"space_id":      invitation.SpaceID,
+	}, "Invitation created!")
+	return nil
+}
+
+// Save updates the given invitation, typically its state
+// returns InternalError
+func (r *GormInvitationRepository) Save(ctx context.Context, invitation *Invitation) error {
+	defer goa.MeasureSince([]string{"goa", "db", "invitation", "save"}, time.Now())
+	if err := r.db.Save(invitation).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	return nil
+}
+
+// Load returns the invitation with the given id
+// returns NotFoundError or InternalError
+func (r *GormInvitationRepository) Load(ctx context.Context, ID uuid.UUID) (*Invitation, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "invitation", "load"}, time.Now())
+	res := Invitation{}
+	tx := r.db.Where("id=?", ID).First(&res)
+	if tx.RecordNotFound() {
+		return nil, errors.NewNotFoundError("invitation", ID.String())
+	}
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	return &res, nil
+}
+
+// ListPending returns the pending invitations of the given space, the oldest first
+// returns InternalError
+func (r *GormInvitationRepository) ListPending(ctx context.Context, spaceID uuid.UUID) ([]Invitation, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "invitation", "list_pending"}, time.Now())
+	var res []Invitation
+	err := r.db.Where("space_id=? AND state=?", spaceID, InvitationStatePending).Order("created_at").Find(&res).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return res, nil
+}
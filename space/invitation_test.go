@@ -0,0 +1,103 @@
+package space_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/gormtestsupport"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestRunInvitationRepoBBTest(t *testing.T) {
+	resource.Require(t, resource.Database)
+	suite.Run(t, &invitationRepoBBTest{DBTestSuite: gormtestsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type invitationRepoBBTest struct {
+	gormtestsupport.DBTestSuite
+	repo    space.InvitationRepository
+	inviter account.Identity
+	space   *space.Space
+	clean   func()
+	ctx     context.Context
+}
+
+func (test *invitationRepoBBTest) SetupTest() {
+	test.ctx = context.Background()
+	test.repo = space.NewInvitationRepository(test.DB)
+	test.clean = cleaner.DeleteCreatedEntities(test.DB)
+	inviter, err := testsupport.CreateTestIdentity(test.DB, "TestInvitation"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(test.T(), err)
+	test.inviter = inviter
+	s, err := space.NewRepository(test.DB).Create(test.ctx, &space.Space{Name: "TestInvitation-" + uuid.NewV4().String(), OwnerId: test.inviter.ID})
+	require.Nil(test.T(), err)
+	test.space = s
+}
+
+func (test *invitationRepoBBTest) TearDownTest() {
+	test.clean()
+}
+
+func (test *invitationRepoBBTest) TestCreateAndLoadOK() {
+	// given
+	invitee, err := testsupport.CreateTestIdentity(test.DB, "TestInvitation"+uuid.NewV4().String(), account.KeycloakIDP)
+	require.Nil(test.T(), err)
+	invitation := space.Invitation{
+		SpaceID:   test.space.ID,
+		InviterID: test.inviter.ID,
+		InviteeID: account.NullUUID{UUID: invitee.ID, Valid: true},
+		Role:      "admin",
+		State:     space.InvitationStateAccepted,
+	}
+	// when
+	require.Nil(test.T(), test.repo.Create(test.ctx, &invitation))
+	// then the invitation is pending whatever the given state
+	loaded, err := test.repo.Load(test.ctx, invitation.ID)
+	require.Nil(test.T(), err)
+	assert.Equal(test.T(), space.InvitationStatePending, loaded.State)
+	assert.Equal(test.T(), "admin", loaded.Role)
+	assert.Equal(test.T(), invitee.ID, loaded.InviteeID.UUID)
+	assert.True(test.T(), loaded.InviteeID.Valid)
+	assert.Nil(test.T(), loaded.InviteeEmail)
+}
+
+func (test *invitationRepoBBTest) TestLoadUnknownInvitationNotFound() {
+	_, err := test.repo.Load(test.ctx, uuid.NewV4())
+	require.NotNil(test.T(), err)
+	assert.IsType(test.T(), errors.NotFoundError{}, err)
+}
+
+func (test *invitationRepoBBTest) TestListPendingOK() {
+	// given
+	first := "first@example.com"
+	second := "second@example.com"
+	revoked := "revoked@example.com"
+	firstInvitation := space.Invitation{SpaceID: test.space.ID, InviterID: test.inviter.ID, InviteeEmail: &first, Role: "contributor"}
+	require.Nil(test.T(), test.repo.Create(test.ctx, &firstInvitation))
+	secondInvitation := space.Invitation{SpaceID: test.space.ID, InviterID: test.inviter.ID, InviteeEmail: &second, Role: "viewer"}
+	require.Nil(test.T(), test.repo.Create(test.ctx, &secondInvitation))
+	revokedInvitation := space.Invitation{SpaceID: test.space.ID, InviterID: test.inviter.ID, InviteeEmail: &revoked, Role: "contributor"}
+	require.Nil(test.T(), test.repo.Create(test.ctx, &revokedInvitation))
+	// when
+	revokedInvitation.State = space.InvitationStateRevoked
+	require.Nil(test.T(), test.repo.Save(test.ctx, &revokedInvitation))
+	// then
+	pending, err := test.repo.ListPending(test.ctx, test.space.ID)
+	require.Nil(test.T(), err)
+	require.Len(test.T(), pending, 2)
+	assert.Equal(test.T(), firstInvitation.ID, pending[0].ID)
+	assert.Equal(test.T(), secondInvitation.ID, pending[1].ID)
+	pending, err = test.repo.ListPending(test.ctx, uuid.NewV4())
+	require.Nil(test.T(), err)
+	assert.Empty(test.T(), pending)
+}
@@ -28,6 +28,12 @@ type Space struct {
 	Name        string
 	Description string
 	OwnerId     uuid.UUID `sql:"type:uuid"` // Belongs To Identity
+	// optional theme metadata, empty values mean that the default theme applies
+	ThemePrimaryColor string
+	ThemeAccentColor  string
+	ThemeLogoURL      string
+	// when set, work items of the same iteration must have distinct titles
+	UniqueTitlesPerIteration bool
 }
 
 // Ensure Fields implements the Equaler interface
@@ -56,6 +62,18 @@ func (p Space) Equal(u convert.Equaler) bool {
 	if !uuid.Equal(p.OwnerId, other.OwnerId) {
 		return false
 	}
+	if p.ThemePrimaryColor != other.ThemePrimaryColor {
+		return false
+	}
+	if p.ThemeAccentColor != other.ThemeAccentColor {
+		return false
+	}
+	if p.ThemeLogoURL != other.ThemeLogoURL {
+		return false
+	}
+	if p.UniqueTitlesPerIteration != other.UniqueTitlesPerIteration {
+		return false
+	}
 	return true
 }
 
@@ -79,6 +97,13 @@ type Repository interface {
 	LoadByOwnerAndName(ctx context.Context, userID *uuid.UUID, spaceName *string) (*Space, error)
 	List(ctx context.Context, start *int, length *int) ([]Space, uint64, error)
 	Search(ctx context.Context, q *string, start *int, length *int) ([]Space, uint64, error)
+	LoadStatistics(ctx context.Context, ID uuid.UUID) (*Statistics, error)
+}
+
+// Statistics holds the amount of content stored in a space
+type Statistics struct {
+	WorkItems int
+	Comments  int
 }
 
 // NewRepository creates a new space repo
@@ -158,6 +183,10 @@ func (r *GormRepository) Save(ctx context.Context, p *Space) (*Space, error) {
 		if gormsupport.IsUniqueViolation(tx.Error, "spaces_name_idx") {
 			return nil, errors.NewBadParameterError("Name", p.Name).Expected("unique")
 		}
+		// the work items are flagged along with the space, and their titles must then be distinct per iteration
+		if gormsupport.IsUniqueViolation(tx.Error, "work_items_title_iteration_unique_idx") {
+			return nil, errors.NewBadParameterError("UniqueTitlesPerIteration", p.UniqueTitlesPerIteration).Expected("no work items with the same title in an iteration")
+		}
 		return nil, errors.NewInternalError(err.Error())
 	}
 	if tx.RowsAffected == 0 {
@@ -315,3 +344,25 @@ func (r *GormRepository) LoadByOwnerAndName(ctx context.Context, userID *uuid.UU
 	}
 	return &res, nil
 }
+
+// LoadStatistics counts the work items of the space with the given id and the comments on them,
+// ignoring the deleted ones.
+// returns NotFoundError or InternalError
+func (r *GormRepository) LoadStatistics(ctx context.Context, ID uuid.UUID) (*Statistics, error) {
+	if _, err := r.Load(ctx, ID); err != nil {
+		return nil, err
+	}
+	res := Statistics{}
+	row := r.db.Raw(`SELECT
+		(SELECT count(*) FROM work_items wi WHERE wi.space_id = ? AND wi.deleted_at IS NULL),
+		(SELECT count(*) FROM comments c JOIN work_items wi ON c.parent_id = wi.id::text
+			WHERE wi.space_id = ? AND wi.deleted_at IS NULL AND c.deleted_at IS NULL)`, ID, ID).Row()
+	if err := row.Scan(&res.WorkItems, &res.Comments); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_id": ID.String(),
+			"err":      err,
+		}, "unable to count the content of the space")
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return &res, nil
+}
@@ -1,6 +1,8 @@
 package space
 
 import (
+	"time"
+
 	"github.com/almighty/almighty-core/convert"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormsupport"
@@ -23,6 +25,8 @@ type Resource struct {
 	PermissionID string
 	PolicyID     string
 	SpaceID      uuid.UUID `sql:"type:uuid"` // Belongs to Space
+	// When the cached collaborators of the space were last synchronized with the policy. Nil if never, or invalidated.
+	CollaboratorsSyncedAt *time.Time
 }
 
 // TableName implements gorm.tabler
@@ -55,6 +59,7 @@ type ResourceRepository interface {
 	Load(ctx context.Context, ID uuid.UUID) (*Resource, error)
 	Delete(ctx context.Context, ID uuid.UUID) error
 	LoadBySpace(ctx context.Context, spaceID *uuid.UUID) (*Resource, error)
+	List(ctx context.Context) ([]Resource, error)
 }
 
 // NewResourceRepository creates a new space resource repo
@@ -163,6 +168,19 @@ func (r *GormResourceRepository) Create(ctx context.Context, resource *Resource)
 	return resource, nil
 }
 
+// List returns the resources of all the spaces
+// returns InternalError
+func (r *GormResourceRepository) List(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	if err := r.db.Find(&resources).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err,
+		}, "unable to list the space resources")
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return resources, nil
+}
+
 // LoadBySpace loads space resource by space ID
 func (r *GormResourceRepository) LoadBySpace(ctx context.Context, spaceID *uuid.UUID) (*Resource, error) {
 	res := Resource{}
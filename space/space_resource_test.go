@@ -102,6 +102,20 @@ func (test *resourceRepoBBTest) TestLoadBySpace() {
 	assert.True(test.T(), (*res).Equal(*res2))
 }
 
+func (test *resourceRepoBBTest) TestList() {
+	res, _, _ := expectResource(test.create(testResourceID, testPolicyID, testPermissionID), test.requireOk)
+	res2, _, _ := expectResource(test.create(testResource2ID, testPolicyID2, testPermissionID2), test.requireOk)
+
+	resources, err := test.repo.List(context.Background())
+	require.Nil(test.T(), err)
+	policyIDs := map[uuid.UUID]string{}
+	for _, r := range resources {
+		policyIDs[r.ID] = r.PolicyID
+	}
+	assert.Equal(test.T(), testPolicyID, policyIDs[res.ID])
+	assert.Equal(test.T(), testPolicyID2, policyIDs[res2.ID])
+}
+
 func (test *resourceRepoBBTest) TestLoadByDifferentSpaceFails() {
 	test.create(testResourceID, testPolicyID, testPermissionID)
 
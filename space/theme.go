@@ -0,0 +1,30 @@
+package space
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/almighty/almighty-core/errors"
+)
+
+// themeColorPattern matches hexadecimal RGB colors in their short or long form, such as '#0af' or '#00aaff'
+var themeColorPattern = regexp.MustCompile("^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$")
+
+// ValidateTheme checks the theme metadata of the space: colors must be hexadecimal RGB values
+// and the logo must be an absolute HTTP(S) URL. Empty values are valid since the theme is optional.
+// returns BadParameterError if one of the values is invalid
+func (p Space) ValidateTheme() error {
+	if p.ThemePrimaryColor != "" && !themeColorPattern.MatchString(p.ThemePrimaryColor) {
+		return errors.NewBadParameterError("theme.primary-color", p.ThemePrimaryColor).Expected("hexadecimal color such as '#00aaff'")
+	}
+	if p.ThemeAccentColor != "" && !themeColorPattern.MatchString(p.ThemeAccentColor) {
+		return errors.NewBadParameterError("theme.accent-color", p.ThemeAccentColor).Expected("hexadecimal color such as '#00aaff'")
+	}
+	if p.ThemeLogoURL != "" {
+		u, err := url.Parse(p.ThemeLogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.NewBadParameterError("theme.logo-url", p.ThemeLogoURL).Expected("absolute http or https URL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package space_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTheme(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("valid themes", func(t *testing.T) {
+		for _, s := range []space.Space{
+			{},
+			{ThemePrimaryColor: "#0af", ThemeAccentColor: "#00AAFF"},
+			{ThemeLogoURL: "https://example.com/logo.png"},
+			{ThemePrimaryColor: "#123456", ThemeAccentColor: "#abc", ThemeLogoURL: "http://example.com:8080/img/logo.svg"},
+		} {
+			assert.Nil(t, s.ValidateTheme(), "theme %+v", s)
+		}
+	})
+
+	t.Run("invalid themes", func(t *testing.T) {
+		for _, s := range []space.Space{
+			{ThemePrimaryColor: "red"},
+			{ThemePrimaryColor: "#12345"},
+			{ThemeAccentColor: "00aaff"},
+			{ThemeAccentColor: "#00aafg"},
+			{ThemeLogoURL: "/logo.png"},
+			{ThemeLogoURL: "javascript:alert(1)"},
+			{ThemeLogoURL: "ftp://example.com/logo.png"},
+		} {
+			err := s.ValidateTheme()
+			require.NotNil(t, err, "theme %+v", s)
+			_, ok := err.(errors.BadParameterError)
+			assert.True(t, ok, "theme %+v: unexpected error type %T", s, err)
+		}
+	})
+}
@@ -60,3 +60,25 @@ func CreateTestIdentity(db *gorm.DB, username, providerType string) (account.Ide
 	logrus.Info("Created identity with id=", testIdentity.ID.String())
 	return testIdentity, err
 }
+
+// CreateTestIdentityAndUser creates an identity with the given `username` in the database, along with its user. For testing purpose only.
+func CreateTestIdentityAndUser(db *gorm.DB, username, providerType string) (account.Identity, error) {
+	testUser := account.User{
+		Email:    uuid.NewV4().String() + "@example.com",
+		FullName: username,
+	}
+	testIdentity := account.Identity{
+		Username:     username,
+		ProviderType: providerType,
+	}
+	err := models.Transactional(db, func(tx *gorm.DB) error {
+		if err := account.NewUserRepository(tx).Create(context.Background(), &testUser); err != nil {
+			return err
+		}
+		testIdentity.UserID = account.NullUUID{UUID: testUser.ID, Valid: true}
+		return account.NewIdentityRepository(tx).Create(context.Background(), &testIdentity)
+	})
+	testIdentity.User = testUser
+	logrus.Info("Created identity with id=", testIdentity.ID.String(), " and user with id=", testUser.ID.String())
+	return testIdentity, err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/notification"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/workitem"
 	"github.com/almighty/almighty-core/workitem/link"
@@ -28,6 +29,18 @@ func (db *MockDB) WorkItemTypes() workitem.WorkItemTypeRepository {
 	return nil
 }
 
+func (db *MockDB) Sessions() auth.SessionRepository {
+	return nil
+}
+
+func (db *MockDB) Notifications() notification.Repository {
+	return nil
+}
+
+func (db *MockDB) WorkItemRevisions() workitem.RevisionRepository {
+	return nil
+}
+
 func (db *MockDB) Spaces() space.Repository {
 	return nil
 }
@@ -51,6 +64,33 @@ func (db *MockDB) Identities() account.IdentityRepository {
 func (db *MockDB) Users() account.UserRepository {
 	return nil
 }
+func (db *MockDB) UserPreferences() account.UserPreferencesRepository {
+	return nil
+}
+func (db *MockDB) UsernameHistory() account.UsernameHistoryRepository {
+	return nil
+}
+func (db *MockDB) UserEmails() account.UserEmailRepository {
+	return nil
+}
+func (db *MockDB) Impersonations() account.ImpersonationRepository {
+	return nil
+}
+func (db *MockDB) ProfileEvents() account.ProfileEventRepository {
+	return nil
+}
+func (db *MockDB) PersonalAccessTokens() account.PersonalAccessTokenRepository {
+	return nil
+}
+func (db *MockDB) Teams() account.TeamRepository {
+	return nil
+}
+func (db *MockDB) Invitations() space.InvitationRepository {
+	return nil
+}
+func (db *MockDB) SpaceCollaborators() space.CollaboratorRepository {
+	return nil
+}
 func (db *MockDB) WorkItemLinkCategories() link.WorkItemLinkCategoryRepository {
 	return nil
 }
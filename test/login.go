@@ -26,6 +26,10 @@ func (s *dummySpaceAuthzService) Configuration() authz.AuthzConfiguration {
 	return nil
 }
 
+func (s *dummySpaceAuthzService) IsViewer(ctx context.Context, spaceID string) (bool, error) {
+	return false, nil
+}
+
 // WithIdentity fills the context with token
 // Token is filled using input Identity object
 func WithIdentity(ctx context.Context, ident account.Identity) context.Context {
@@ -46,6 +50,14 @@ func WithAuthz(ctx context.Context, key interface{}, ident account.Identity, aut
 	return goajwt.WithJWT(ctx, token)
 }
 
+// WithScopes fills the context with token granted the given scopes
+// Token is filled using input Identity object
+func WithScopes(ctx context.Context, ident account.Identity, scopes ...string) context.Context {
+	token := fillClaimsWithIdentity(ident)
+	token.Claims.(jwt.MapClaims)["scopes"] = scopes
+	return goajwt.WithJWT(ctx, token)
+}
+
 func fillClaimsWithIdentity(ident account.Identity) *jwt.Token {
 	token := jwt.New(jwt.SigningMethodRS256)
 	token.Claims.(jwt.MapClaims)["sub"] = ident.ID.String()
@@ -81,6 +93,15 @@ func ServiceAsUser(serviceName string, tm token.Manager, u account.Identity) *go
 	return svc
 }
 
+// ServiceAsUserWithScopes creates a new service and fill the context with input Identity and a token granted the given scopes
+func ServiceAsUserWithScopes(serviceName string, tm token.Manager, u account.Identity, scopes ...string) *goa.Service {
+	svc := goa.New(serviceName)
+	svc.Context = WithScopes(svc.Context, u, scopes...)
+	svc.Context = tokencontext.ContextWithTokenManager(svc.Context, tm)
+	svc.Context = tokencontext.ContextWithSpaceAuthzService(svc.Context, &authz.KeycloakAuthzServiceManager{Service: &dummySpaceAuthzService{}})
+	return svc
+}
+
 // ServiceAsSpaceUser creates a new service and fill the context with input Identity and space authz service
 func ServiceAsSpaceUser(serviceName string, tm token.Manager, u account.Identity, authzSrv authz.AuthzService) *goa.Service {
 	svc := service(serviceName, tm, nil, u, nil)
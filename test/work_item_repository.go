@@ -3,6 +3,7 @@ package test
 
 import (
 	"sync"
+	"time"
 
 	"github.com/almighty/almighty-core/criteria"
 	"github.com/almighty/almighty-core/workitem"
@@ -137,6 +138,53 @@ type WorkItemRepository struct {
 		result1 map[string]workitem.WICountsPerIteration
 		result2 error
 	}
+	RestoreStub        func(ctx context.Context, spaceID uuid.UUID, ID string, restorerID uuid.UUID, window time.Duration) (*workitem.WorkItem, error)
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		ctx        context.Context
+		spaceID    uuid.UUID
+		ID         string
+		restorerID uuid.UUID
+		window     time.Duration
+	}
+	restoreReturns struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}
+	MoveStub        func(ctx context.Context, spaceID uuid.UUID, wi workitem.WorkItem, targetSpaceID uuid.UUID, modifierID uuid.UUID) (*workitem.WorkItem, error)
+	moveMutex       sync.RWMutex
+	moveArgsForCall []struct {
+		ctx           context.Context
+		spaceID       uuid.UUID
+		wi            workitem.WorkItem
+		targetSpaceID uuid.UUID
+		modifierID    uuid.UUID
+	}
+	moveReturns struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}
+	LoadByNumberStub        func(ctx context.Context, spaceID uuid.UUID, number int) (*workitem.WorkItem, error)
+	loadByNumberMutex       sync.RWMutex
+	loadByNumberArgsForCall []struct {
+		ctx     context.Context
+		spaceID uuid.UUID
+		number  int
+	}
+	loadByNumberReturns struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}
+	ReplaceIdentityStub        func(ctx context.Context, oldIdentityID uuid.UUID, newIdentityID uuid.UUID) error
+	replaceIdentityMutex       sync.RWMutex
+	replaceIdentityArgsForCall []struct {
+		ctx           context.Context
+		oldIdentityID uuid.UUID
+		newIdentityID uuid.UUID
+	}
+	replaceIdentityReturns struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -507,6 +555,149 @@ func (fake *WorkItemRepository) GetCountsForIterationReturns(result1 map[string]
 	}{result1, result2}
 }
 
+func (fake *WorkItemRepository) Restore(ctx context.Context, spaceID uuid.UUID, ID string, restorerID uuid.UUID, window time.Duration) (*workitem.WorkItem, error) {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		ctx        context.Context
+		spaceID    uuid.UUID
+		ID         string
+		restorerID uuid.UUID
+		window     time.Duration
+	}{ctx, spaceID, ID, restorerID, window})
+	fake.recordInvocation("Restore", []interface{}{ctx, spaceID, ID, restorerID, window})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(ctx, spaceID, ID, restorerID, window)
+	}
+	return fake.restoreReturns.result1, fake.restoreReturns.result2
+}
+
+func (fake *WorkItemRepository) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *WorkItemRepository) RestoreArgsForCall(i int) (context.Context, uuid.UUID, string, uuid.UUID, time.Duration) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].ctx, fake.restoreArgsForCall[i].spaceID, fake.restoreArgsForCall[i].ID, fake.restoreArgsForCall[i].restorerID, fake.restoreArgsForCall[i].window
+}
+
+func (fake *WorkItemRepository) RestoreReturns(result1 *workitem.WorkItem, result2 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *WorkItemRepository) Move(ctx context.Context, spaceID uuid.UUID, wi workitem.WorkItem, targetSpaceID uuid.UUID, modifierID uuid.UUID) (*workitem.WorkItem, error) {
+	fake.moveMutex.Lock()
+	fake.moveArgsForCall = append(fake.moveArgsForCall, struct {
+		ctx           context.Context
+		spaceID       uuid.UUID
+		wi            workitem.WorkItem
+		targetSpaceID uuid.UUID
+		modifierID    uuid.UUID
+	}{ctx, spaceID, wi, targetSpaceID, modifierID})
+	fake.recordInvocation("Move", []interface{}{ctx, spaceID, wi, targetSpaceID, modifierID})
+	fake.moveMutex.Unlock()
+	if fake.MoveStub != nil {
+		return fake.MoveStub(ctx, spaceID, wi, targetSpaceID, modifierID)
+	}
+	return fake.moveReturns.result1, fake.moveReturns.result2
+}
+
+func (fake *WorkItemRepository) MoveCallCount() int {
+	fake.moveMutex.RLock()
+	defer fake.moveMutex.RUnlock()
+	return len(fake.moveArgsForCall)
+}
+
+func (fake *WorkItemRepository) MoveArgsForCall(i int) (context.Context, uuid.UUID, workitem.WorkItem, uuid.UUID, uuid.UUID) {
+	fake.moveMutex.RLock()
+	defer fake.moveMutex.RUnlock()
+	return fake.moveArgsForCall[i].ctx, fake.moveArgsForCall[i].spaceID, fake.moveArgsForCall[i].wi, fake.moveArgsForCall[i].targetSpaceID, fake.moveArgsForCall[i].modifierID
+}
+
+func (fake *WorkItemRepository) MoveReturns(result1 *workitem.WorkItem, result2 error) {
+	fake.MoveStub = nil
+	fake.moveReturns = struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *WorkItemRepository) LoadByNumber(ctx context.Context, spaceID uuid.UUID, number int) (*workitem.WorkItem, error) {
+	fake.loadByNumberMutex.Lock()
+	fake.loadByNumberArgsForCall = append(fake.loadByNumberArgsForCall, struct {
+		ctx     context.Context
+		spaceID uuid.UUID
+		number  int
+	}{ctx, spaceID, number})
+	fake.recordInvocation("LoadByNumber", []interface{}{ctx, spaceID, number})
+	fake.loadByNumberMutex.Unlock()
+	if fake.LoadByNumberStub != nil {
+		return fake.LoadByNumberStub(ctx, spaceID, number)
+	}
+	return fake.loadByNumberReturns.result1, fake.loadByNumberReturns.result2
+}
+
+func (fake *WorkItemRepository) LoadByNumberCallCount() int {
+	fake.loadByNumberMutex.RLock()
+	defer fake.loadByNumberMutex.RUnlock()
+	return len(fake.loadByNumberArgsForCall)
+}
+
+func (fake *WorkItemRepository) LoadByNumberArgsForCall(i int) (context.Context, uuid.UUID, int) {
+	fake.loadByNumberMutex.RLock()
+	defer fake.loadByNumberMutex.RUnlock()
+	return fake.loadByNumberArgsForCall[i].ctx, fake.loadByNumberArgsForCall[i].spaceID, fake.loadByNumberArgsForCall[i].number
+}
+
+func (fake *WorkItemRepository) LoadByNumberReturns(result1 *workitem.WorkItem, result2 error) {
+	fake.LoadByNumberStub = nil
+	fake.loadByNumberReturns = struct {
+		result1 *workitem.WorkItem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *WorkItemRepository) ReplaceIdentity(ctx context.Context, oldIdentityID uuid.UUID, newIdentityID uuid.UUID) error {
+	fake.replaceIdentityMutex.Lock()
+	fake.replaceIdentityArgsForCall = append(fake.replaceIdentityArgsForCall, struct {
+		ctx           context.Context
+		oldIdentityID uuid.UUID
+		newIdentityID uuid.UUID
+	}{ctx, oldIdentityID, newIdentityID})
+	fake.recordInvocation("ReplaceIdentity", []interface{}{ctx, oldIdentityID, newIdentityID})
+	fake.replaceIdentityMutex.Unlock()
+	if fake.ReplaceIdentityStub != nil {
+		return fake.ReplaceIdentityStub(ctx, oldIdentityID, newIdentityID)
+	}
+	return fake.replaceIdentityReturns.result1
+}
+
+func (fake *WorkItemRepository) ReplaceIdentityCallCount() int {
+	fake.replaceIdentityMutex.RLock()
+	defer fake.replaceIdentityMutex.RUnlock()
+	return len(fake.replaceIdentityArgsForCall)
+}
+
+func (fake *WorkItemRepository) ReplaceIdentityArgsForCall(i int) (context.Context, uuid.UUID, uuid.UUID) {
+	fake.replaceIdentityMutex.RLock()
+	defer fake.replaceIdentityMutex.RUnlock()
+	return fake.replaceIdentityArgsForCall[i].ctx, fake.replaceIdentityArgsForCall[i].oldIdentityID, fake.replaceIdentityArgsForCall[i].newIdentityID
+}
+
+func (fake *WorkItemRepository) ReplaceIdentityReturns(result1 error) {
+	fake.ReplaceIdentityStub = nil
+	fake.replaceIdentityReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *WorkItemRepository) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -528,6 +719,14 @@ func (fake *WorkItemRepository) Invocations() map[string][][]interface{} {
 	defer fake.getCountsPerIterationMutex.RUnlock()
 	fake.getCountsForIterationMutex.RLock()
 	defer fake.getCountsForIterationMutex.RUnlock()
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	fake.moveMutex.RLock()
+	defer fake.moveMutex.RUnlock()
+	fake.loadByNumberMutex.RLock()
+	defer fake.loadByNumberMutex.RUnlock()
+	fake.replaceIdentityMutex.RLock()
+	defer fake.replaceIdentityMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -2,6 +2,8 @@ package token
 
 import (
 	"crypto/rsa"
+	"strings"
+	"time"
 
 	"github.com/almighty/almighty-core/account"
 	jwt "github.com/dgrijalva/jwt-go"
@@ -11,6 +13,15 @@ import (
 	"golang.org/x/net/context"
 )
 
+// ScopeAdminUsers is the scope of the tokens allowed to administrate the user accounts
+const ScopeAdminUsers = "admin:users"
+
+// ImpersonatorClaim is the claim holding the ID of the administrator to whom a token acting as another identity was issued
+const ImpersonatorClaim = "impersonator"
+
+// PersonalAccessTokenClaim is the claim holding the ID of the personal access token with which the API was called
+const PersonalAccessTokenClaim = "personal_access_token"
+
 // Manager generate and find auth token information
 type Manager interface {
 	Extract(string) (*account.Identity, error)
@@ -139,3 +150,99 @@ G1SZFkN9m2DhL+45us4THzX2eau6s0bISjAUqEGNifPyYYUzKVmXmHS9fiZJR61h
 JXdQ7zylRlpaLopock0FGiZrJhEaAh6BGuaoUWLiMEvqrLuyZnJYEg9f/vyxUJSD
 JwIDAQAB
 -----END PUBLIC KEY-----`
+
+// ContextHasScope returns true if the token in the given context was granted the given scope.
+// The scopes are read from the "scopes" claim, either as a list or as a space separated string.
+func ContextHasScope(ctx context.Context, scope string) bool {
+	token := goajwt.ContextJWT(ctx)
+	if token == nil {
+		return false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	var scopes []string
+	switch s := claims["scopes"].(type) {
+	case string:
+		scopes = strings.Split(s, " ")
+	case []string:
+		scopes = s
+	case []interface{}:
+		for _, v := range s {
+			if str, ok := v.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateImpersonationToken returns a token signed with the given private key which acts as the given identity
+// on behalf of the given administrator and expires after the given duration. The token is granted no scope,
+// so that it can't be used to perform the administrative actions.
+func GenerateImpersonationToken(privateKey *rsa.PrivateKey, identity account.Identity, impersonatorID uuid.UUID, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.New(jwt.SigningMethodRS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["jti"] = uuid.NewV4().String()
+	claims["sub"] = identity.ID.String()
+	claims["preferred_username"] = identity.Username
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(expiresIn).Unix()
+	claims[ImpersonatorClaim] = impersonatorID.String()
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return tokenString, nil
+}
+
+// ContextImpersonator returns the ID of the administrator to whom the token in the given context was issued
+// to act as another identity, or nil if the token was not issued for an impersonation.
+func ContextImpersonator(ctx context.Context) *uuid.UUID {
+	token := goajwt.ContextJWT(ctx)
+	if token == nil {
+		return nil
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	impersonator, ok := claims[ImpersonatorClaim].(string)
+	if !ok {
+		return nil
+	}
+	impersonatorID, err := uuid.FromString(impersonator)
+	if err != nil {
+		return nil
+	}
+	return &impersonatorID
+}
+
+// ContextPersonalAccessToken returns the ID of the personal access token with which the API was called, or nil if
+// the API was called with a JWT.
+func ContextPersonalAccessToken(ctx context.Context) *uuid.UUID {
+	token := goajwt.ContextJWT(ctx)
+	if token == nil {
+		return nil
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	tokenID, ok := claims[PersonalAccessTokenClaim].(string)
+	if !ok {
+		return nil
+	}
+	id, err := uuid.FromString(tokenID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
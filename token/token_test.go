@@ -140,6 +140,80 @@ func TestLocateInvalidUUIDInTokenInContext(t *testing.T) {
 	}
 }
 
+func TestContextHasScope(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	for _, scopes := range []interface{}{
+		"read:space admin:users",
+		[]interface{}{"read:space", "admin:users"},
+		[]string{"admin:users"},
+	} {
+		tk := jwt.New(jwt.SigningMethodRS256)
+		tk.Claims.(jwt.MapClaims)["scopes"] = scopes
+		ctx := goajwt.WithJWT(context.Background(), tk)
+		assert.True(t, token.ContextHasScope(ctx, token.ScopeAdminUsers), "scope not found in %v", scopes)
+	}
+}
+
+func TestContextHasScopeMissing(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// no token
+	assert.False(t, token.ContextHasScope(context.Background(), token.ScopeAdminUsers))
+	// no scopes claim
+	tk := jwt.New(jwt.SigningMethodRS256)
+	assert.False(t, token.ContextHasScope(goajwt.WithJWT(context.Background(), tk), token.ScopeAdminUsers))
+	// other scopes
+	tk.Claims.(jwt.MapClaims)["scopes"] = "read:space admin:userss"
+	assert.False(t, token.ContextHasScope(goajwt.WithJWT(context.Background(), tk), token.ScopeAdminUsers))
+}
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	manager := createManager(t)
+	privateKey, err := token.ParsePrivateKey([]byte(token.RSAPrivateKey))
+	if err != nil {
+		t.Fatal("Could not parse private key", err)
+	}
+	identity := account.Identity{
+		ID:       uuid.NewV4(),
+		Username: "testuser",
+	}
+	impersonatorID := uuid.NewV4()
+	// when
+	tokenString, err := token.GenerateImpersonationToken(privateKey, identity, impersonatorID, 5*time.Minute)
+	// then
+	if err != nil {
+		t.Fatal("Could not generate impersonation token", err)
+	}
+	ident, err := manager.Extract(tokenString)
+	if err != nil {
+		t.Fatal("Could not extract Identity from impersonation token", err)
+	}
+	assert.Equal(t, identity.ID, ident.ID)
+	assert.Equal(t, identity.Username, ident.Username)
+	tk, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return manager.PublicKey(), nil
+	})
+	if err != nil {
+		t.Fatal("Could not parse impersonation token", err)
+	}
+	ctx := goajwt.WithJWT(context.Background(), tk)
+	impersonator := token.ContextImpersonator(ctx)
+	if assert.NotNil(t, impersonator) {
+		assert.Equal(t, impersonatorID, *impersonator)
+	}
+	assert.False(t, token.ContextHasScope(ctx, token.ScopeAdminUsers))
+}
+
+func TestContextImpersonatorMissing(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// no token
+	assert.Nil(t, token.ContextImpersonator(context.Background()))
+	// no impersonator claim
+	tk := jwt.New(jwt.SigningMethodRS256)
+	assert.Nil(t, token.ContextImpersonator(goajwt.WithJWT(context.Background(), tk)))
+}
+
 func createManager(t *testing.T) token.Manager {
 	privateKey, err := token.ParsePrivateKey([]byte(token.RSAPrivateKey))
 	if err != nil {
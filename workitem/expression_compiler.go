@@ -39,6 +39,14 @@ func bubbleUpJSONContext(exp criteria.Expression) bool {
 		if t.Left().Annotation(jsonAnnotation) == true || t.Right().Annotation(jsonAnnotation) == true {
 			t.SetAnnotation(jsonAnnotation, true)
 		}
+	case *criteria.GreaterOrEqualExpression:
+		if t.Left().Annotation(jsonAnnotation) == true || t.Right().Annotation(jsonAnnotation) == true {
+			t.SetAnnotation(jsonAnnotation, true)
+		}
+	case *criteria.LessOrEqualExpression:
+		if t.Left().Annotation(jsonAnnotation) == true || t.Right().Annotation(jsonAnnotation) == true {
+			t.SetAnnotation(jsonAnnotation, true)
+		}
 	}
 	return true
 }
@@ -46,7 +54,7 @@ func bubbleUpJSONContext(exp criteria.Expression) bool {
 // does the field name reference a json field or a column?
 func isJSONField(fieldName string) bool {
 	switch fieldName {
-	case "ID", "Type", "Version":
+	case "ID", "Type", "Version", "created_at":
 		return false
 	}
 	return true
@@ -114,6 +122,56 @@ func (c *expressionCompiler) Not(e *criteria.NotExpression) interface{} {
 	return c.binary(e, "!=")
 }
 
+// IsNull compiles to a test on the column, or on the JSON field, having no value.
+// A JSON field has no value when it is missing, null or an empty list.
+func (c *expressionCompiler) IsNull(e *criteria.IsNullExpression) interface{} {
+	if !isJSONField(e.FieldName) {
+		return "(" + e.FieldName + " IS NULL)"
+	}
+	if strings.Contains(e.FieldName, "'") {
+		// beware of injection, it's a reasonable restriction for field names, make sure it's not allowed when creating wi types
+		c.err = append(c.err, fmt.Errorf("single quote not allowed in field name"))
+		return nil
+	}
+	field := "Fields->'" + e.FieldName + "'"
+	return "(" + field + " IS NULL OR " + field + " = 'null'::jsonb OR " + field + " = '[]'::jsonb)"
+}
+
+// ReferencesDeactivatedIdentity compiles to a subquery on the deactivated identities whose ID is held
+// by the JSON field, be it a single value or a list of values.
+func (c *expressionCompiler) ReferencesDeactivatedIdentity(e *criteria.ReferencesDeactivatedIdentityExpression) interface{} {
+	if !isJSONField(e.FieldName) {
+		c.err = append(c.err, fmt.Errorf("identity references only supported on JSON fields"))
+		return nil
+	}
+	if strings.Contains(e.FieldName, "'") {
+		// beware of injection, it's a reasonable restriction for field names, make sure it's not allowed when creating wi types
+		c.err = append(c.err, fmt.Errorf("single quote not allowed in field name"))
+		return nil
+	}
+	field := "Fields->'" + e.FieldName + "'"
+	return "(EXISTS (SELECT 1 FROM identities WHERE identities.deactivated_at IS NOT NULL AND identities.deleted_at IS NULL" +
+		" AND " + field + " @> to_jsonb(identities.id::text)))"
+}
+
+func (c *expressionCompiler) GreaterOrEqual(e *criteria.GreaterOrEqualExpression) interface{} {
+	return c.comparison(e, ">=")
+}
+
+func (c *expressionCompiler) LessOrEqual(e *criteria.LessOrEqualExpression) interface{} {
+	return c.comparison(e, "<=")
+}
+
+// comparison compiles an ordering operator, which is only supported on columns
+// since the containment operator used on JSON fields cannot express it
+func (c *expressionCompiler) comparison(e criteria.BinaryExpression, op string) interface{} {
+	if isInJSONContext(e.Left()) {
+		c.err = append(c.err, fmt.Errorf("operator %s not supported on JSON fields", op))
+		return nil
+	}
+	return c.binary(e, op)
+}
+
 func (c *expressionCompiler) Parameter(v *criteria.ParameterExpression) interface{} {
 	c.err = append(c.err, fmt.Errorf("Parameter expression not supported"))
 	return nil
@@ -29,6 +29,32 @@ func TestAndOr(t *testing.T) {
 	expect(t, Or(Equals(Field("foo"), Literal("abcd")), Equals(Literal(true), Literal(false))), "((Fields@>'{\"foo\" : \"abcd\"}') or (? = ?))", []interface{}{true, false})
 }
 
+func TestIsNull(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, IsNull("Type"), "(Type IS NULL)", []interface{}{})
+	expect(t, IsNull("system.assignees"), "(Fields->'system.assignees' IS NULL OR Fields->'system.assignees' = 'null'::jsonb OR Fields->'system.assignees' = '[]'::jsonb)", []interface{}{})
+	expect(t, And(IsNull("system.assignees"), Equals(Field("foo"), Literal("abcd"))), "((Fields->'system.assignees' IS NULL OR Fields->'system.assignees' = 'null'::jsonb OR Fields->'system.assignees' = '[]'::jsonb) and (Fields@>'{\"foo\" : \"abcd\"}'))", []interface{}{})
+}
+
+func TestReferencesDeactivatedIdentity(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, ReferencesDeactivatedIdentity("system.assignees"), "(EXISTS (SELECT 1 FROM identities WHERE identities.deactivated_at IS NOT NULL AND identities.deleted_at IS NULL AND Fields->'system.assignees' @> to_jsonb(identities.id::text)))", []interface{}{})
+	_, _, err := Compile(ReferencesDeactivatedIdentity("Type"))
+	assert.NotEmpty(t, err)
+}
+
+func TestComparison(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, GreaterOrEqual(Field("created_at"), Literal(5)), "(created_at >= ?)", []interface{}{5})
+	expect(t, LessOrEqual(Field("created_at"), Literal(5)), "(created_at <= ?)", []interface{}{5})
+	expect(t, And(GreaterOrEqual(Field("created_at"), Literal(1)), Equals(Field("foo"), Literal("abcd"))), "((created_at >= ?) and (Fields@>'{\"foo\" : \"abcd\"}'))", []interface{}{1})
+	_, _, err := Compile(GreaterOrEqual(Field("foo"), Literal(5)))
+	assert.NotEmpty(t, err)
+}
+
 func expect(t *testing.T, expr Expression, expectedClause string, expectedParameters []interface{}) {
 	clause, parameters, err := Compile(expr)
 	if len(err) > 0 {
@@ -13,6 +13,7 @@ import (
 type WorkItemLinkCategoryRepository interface {
 	Create(ctx context.Context, name *string, description *string) (*WorkItemLinkCategory, error)
 	Load(ctx context.Context, ID uuid.UUID) (*WorkItemLinkCategory, error)
+	LoadCategoryFromDB(ctx context.Context, name string) (*WorkItemLinkCategory, error)
 	List(ctx context.Context) ([]WorkItemLinkCategory, error)
 	Delete(ctx context.Context, ID uuid.UUID) error
 	Save(ctx context.Context, linkCat WorkItemLinkCategory) (*WorkItemLinkCategory, error)
@@ -38,6 +38,7 @@ type WorkItemLinkRepository interface {
 	Save(ctx context.Context, linkCat WorkItemLink, modifierID uuid.UUID) (*WorkItemLink, error)
 	ListWorkItemChildren(ctx context.Context, parent string) ([]workitem.WorkItem, error)
 	WorkItemHasChildren(ctx context.Context, parent string) (bool, error)
+	ListCrossSpaceDependencies(ctx context.Context, spaceIDs []uuid.UUID) ([]WorkItemLink, error)
 }
 
 // NewWorkItemLinkRepository creates a work item link repository based on gorm
@@ -347,3 +348,35 @@ func (r *GormWorkItemLinkRepository) WorkItemHasChildren(ctx context.Context, pa
 	}
 	return hasChildren, nil
 }
+
+// DependencyForwardNames are the forward names of the link types which express a dependency between
+// two work items, in addition to the link types with the dependency topology
+var DependencyForwardNames = []string{"blocks", "depends on"}
+
+// ListCrossSpaceDependencies returns the dependency links whose source and target work items belong to
+// different spaces, one of them being among the given spaces
+func (r *GormWorkItemLinkRepository) ListCrossSpaceDependencies(ctx context.Context, spaceIDs []uuid.UUID) ([]WorkItemLink, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "workitemlink", "dependencies", "query"}, time.Now())
+	modelLinks := []WorkItemLink{}
+	if len(spaceIDs) == 0 {
+		return modelLinks, nil
+	}
+	where := fmt.Sprintf(`
+	id IN (
+		SELECT l.id FROM %[1]s l
+		JOIN %[2]s s ON s.id = l.source_id AND s.deleted_at IS NULL
+		JOIN %[2]s t ON t.id = l.target_id AND t.deleted_at IS NULL
+		JOIN %[3]s lt ON lt.id = l.link_type_id
+		WHERE s.space_id <> t.space_id
+		AND (s.space_id IN (?) OR t.space_id IN (?))
+		AND (lt.topology = ? OR lt.forward_name IN (?))
+	)`,
+		WorkItemLink{}.TableName(),
+		workitem.WorkItemStorage{}.TableName(),
+		WorkItemLinkType{}.TableName())
+	db := r.db.Where(where, spaceIDs, spaceIDs, TopologyDependency, DependencyForwardNames).Order("created_at").Find(&modelLinks)
+	if db.Error != nil {
+		return nil, errs.WithStack(db.Error)
+	}
+	return modelLinks, nil
+}
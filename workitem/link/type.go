@@ -23,6 +23,7 @@ const (
 	SystemWorkItemLinkTypeBugBlocker     = "Bug blocker"
 	SystemWorkItemLinkPlannerItemRelated = "Related planner item"
 	SystemWorkItemLinkTypeParentChild    = "Parent child item"
+	SystemWorkItemLinkTypeDuplicateOf    = "Duplicate of"
 )
 
 // returns true if the left hand and right hand side string
@@ -20,6 +20,7 @@ type WorkItemLinkTypeRepository interface {
 	Create(ctx context.Context, name string, description *string, sourceTypeID, targetTypeID uuid.UUID, forwardName, reverseName, topology string, linkCategory, spaceID uuid.UUID) (*WorkItemLinkType, error)
 	Load(ctx context.Context, spaceID uuid.UUID, ID uuid.UUID) (*WorkItemLinkType, error)
 	LoadByID(ctx context.Context, ID uuid.UUID) (*WorkItemLinkType, error)
+	LoadTypeFromDBByNameAndCategory(ctx context.Context, name string, categoryID uuid.UUID) (*WorkItemLinkType, error)
 	List(ctx context.Context, spaceID uuid.UUID) ([]WorkItemLinkType, error)
 	Delete(ctx context.Context, spaceID uuid.UUID, ID uuid.UUID) error
 	Save(ctx context.Context, linkCat WorkItemLinkType) (*WorkItemLinkType, error)
@@ -117,7 +117,15 @@ func (fieldType SimpleType) ConvertFromModel(value interface{}) (interface{}, er
 	case KindString, KindURL, KindUser, KindInteger, KindFloat, KindDuration, KindIteration, KindArea:
 		return value, nil
 	case KindInstant:
-		return time.Unix(0, value.(int64)), nil
+		switch v := value.(type) {
+		case int64:
+			return time.Unix(0, v), nil
+		case float64:
+			// instants read from the database are decoded as JSON numbers
+			return time.Unix(0, int64(v)), nil
+		default:
+			return nil, errs.Errorf("value %v should be %s, but is %s", value, "int64", valueType.Name())
+		}
 	case KindWorkitemReference:
 		if valueType.Kind() != reflect.String {
 			return nil, errs.Errorf("value %v should be %s, but is %s", value, "string", valueType.Name())
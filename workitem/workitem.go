@@ -17,6 +17,8 @@ type WorkItem struct {
 	Version int
 	// ID of the space to which this work item belongs
 	SpaceID uuid.UUID
+	// sequential number of the work item in its space
+	Number int
 	// The field values, according to the field type
 	Fields map[string]interface{}
 }
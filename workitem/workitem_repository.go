@@ -2,6 +2,7 @@ package workitem
 
 import (
 	"strconv"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/almighty/almighty-core/criteria"
 	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
 
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/rendering"
@@ -20,6 +22,10 @@ import (
 
 const orderValue = 1000
 
+// titleIterationUniqueIndex rejects the work items with the title of another work item of their iteration,
+// in the spaces which require distinct titles per iteration
+const titleIterationUniqueIndex = "work_items_title_iteration_unique_idx"
+
 type DirectionType string
 
 const (
@@ -33,15 +39,19 @@ const (
 type WorkItemRepository interface {
 	LoadByID(ctx context.Context, ID string) (*WorkItem, error)
 	Load(ctx context.Context, spaceID uuid.UUID, ID string) (*WorkItem, error)
+	LoadByNumber(ctx context.Context, spaceID uuid.UUID, number int) (*WorkItem, error)
 	Save(ctx context.Context, spaceID uuid.UUID, wi WorkItem, modifierID uuid.UUID) (*WorkItem, error)
+	Move(ctx context.Context, spaceID uuid.UUID, wi WorkItem, targetSpaceID uuid.UUID, modifierID uuid.UUID) (*WorkItem, error)
 	Reorder(ctx context.Context, direction DirectionType, targetID *string, wi WorkItem, modifierID uuid.UUID) (*WorkItem, error)
 	Delete(ctx context.Context, spaceID uuid.UUID, ID string, suppressorID uuid.UUID) error
+	Restore(ctx context.Context, spaceID uuid.UUID, ID string, restorerID uuid.UUID, window time.Duration) (*WorkItem, error)
 	Create(ctx context.Context, spaceID uuid.UUID, typeID uuid.UUID, fields map[string]interface{}, creatorID uuid.UUID) (*WorkItem, error)
 	List(ctx context.Context, spaceID uuid.UUID, criteria criteria.Expression, parentExists *bool, start *int, length *int) ([]WorkItem, uint64, error)
 	Fetch(ctx context.Context, spaceID uuid.UUID, criteria criteria.Expression) (*WorkItem, error)
 	GetCountsPerIteration(ctx context.Context, spaceID uuid.UUID) (map[string]WICountsPerIteration, error)
 	GetCountsForIteration(ctx context.Context, iterationID uuid.UUID) (map[string]WICountsPerIteration, error)
 	Count(ctx context.Context, spaceID uuid.UUID, criteria criteria.Expression) (int, error)
+	ReplaceIdentity(ctx context.Context, oldIdentityID uuid.UUID, newIdentityID uuid.UUID) error
 }
 
 // NewWorkItemRepository creates a GormWorkItemRepository
@@ -139,6 +149,28 @@ func (r *GormWorkItemRepository) Load(ctx context.Context, spaceID uuid.UUID, wo
 	return ConvertWorkItemStorageToModel(wiType, &res)
 }
 
+// LoadByNumber returns the work item with the given number in the given space
+// returns NotFoundError, ConversionError or InternalError
+func (r *GormWorkItemRepository) LoadByNumber(ctx context.Context, spaceID uuid.UUID, number int) (*WorkItem, error) {
+	res := WorkItemStorage{}
+	tx := r.db.Model(&res).Where("number=? AND space_id=?", number, spaceID).First(&res)
+	if tx.RecordNotFound() {
+		log.Error(ctx, map[string]interface{}{
+			"wi_number": number,
+			"space_id":  spaceID,
+		}, "work item not found")
+		return nil, errors.NewNotFoundError("work item number", strconv.Itoa(number))
+	}
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	wiType, err := r.witr.LoadTypeFromDB(ctx, res.Type)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return ConvertWorkItemStorageToModel(wiType, &res)
+}
+
 // LoadTopWorkitem returns top most work item of the list. Top most workitem has the Highest order.
 // returns NotFoundError, ConversionError or InternalError
 func (r *GormWorkItemRepository) LoadTopWorkitem(ctx context.Context) (*WorkItem, error) {
@@ -216,6 +248,53 @@ func (r *GormWorkItemRepository) Delete(ctx context.Context, spaceID uuid.UUID,
 	return nil
 }
 
+// Restore brings back a soft-deleted work item, provided it was deleted no
+// longer than the given window ago. Once the window has elapsed the item is
+// considered purged and can no longer be restored.
+// returns NotFoundError, BadParameterError or InternalError
+func (r *GormWorkItemRepository) Restore(ctx context.Context, spaceID uuid.UUID, workitemID string, restorerID uuid.UUID, window time.Duration) (*WorkItem, error) {
+	id, err := strconv.ParseUint(workitemID, 10, 64)
+	if err != nil || id == 0 {
+		// treat as not found: clients don't know it must be a number
+		return nil, errors.NewNotFoundError("work item", workitemID)
+	}
+	res := WorkItemStorage{}
+	tx := r.db.Unscoped().Where("id=? AND space_id=?", id, spaceID).First(&res)
+	if tx.RecordNotFound() {
+		return nil, errors.NewNotFoundError("work item", workitemID)
+	}
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	if res.DeletedAt == nil {
+		return nil, errors.NewBadParameterError("work item", workitemID).Expected("a deleted work item")
+	}
+	if time.Since(*res.DeletedAt) > window {
+		log.Info(ctx, map[string]interface{}{
+			"wi_id":      workitemID,
+			"space_id":   spaceID,
+			"deleted_at": *res.DeletedAt,
+		}, "work item restore window has elapsed")
+		return nil, errors.NewNotFoundError("work item", workitemID)
+	}
+	tx = r.db.Unscoped().Model(&res).UpdateColumn("deleted_at", gorm.Expr("NULL"))
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	res.DeletedAt = nil
+	// store a revision of the restored work item
+	err = r.wirr.Create(context.Background(), restorerID, RevisionTypeUpdate, res)
+	if err != nil {
+		return nil, errs.Wrapf(err, "error while restoring work item")
+	}
+	wiType, err := r.witr.LoadTypeFromDB(ctx, res.Type)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{"wi_id": workitemID, "space_id": spaceID}, "Work item restored successfully!")
+	return ConvertWorkItemStorageToModel(wiType, &res)
+}
+
 // Calculates the order of the reorder workitem
 func (r *GormWorkItemRepository) CalculateOrder(above, below *float64) float64 {
 	return (*above + *below) / 2
@@ -405,8 +484,20 @@ func (r *GormWorkItemRepository) Reorder(ctx context.Context, direction Directio
 }
 
 // Save updates the given work item in storage. Version must be the same as the one int the stored version
-// returns NotFoundError, VersionConflictError, ConversionError or InternalError
+// returns NotFoundError, VersionConflictError, BadParameterError, ConversionError or InternalError
 func (r *GormWorkItemRepository) Save(ctx context.Context, spaceID uuid.UUID, wi WorkItem, modifierID uuid.UUID) (*WorkItem, error) {
+	return r.save(ctx, spaceID, spaceID, wi, modifierID)
+}
+
+// Move updates the given work item in storage, like Save does, and moves it from the given space to the target space.
+// The area, iteration and type of the given work item must be usable in the target space.
+// returns NotFoundError, VersionConflictError, BadParameterError, ConversionError or InternalError
+func (r *GormWorkItemRepository) Move(ctx context.Context, spaceID uuid.UUID, wi WorkItem, targetSpaceID uuid.UUID, modifierID uuid.UUID) (*WorkItem, error) {
+	return r.save(ctx, spaceID, targetSpaceID, wi, modifierID)
+}
+
+// save updates the given work item of the given space and stores it in the target space
+func (r *GormWorkItemRepository) save(ctx context.Context, spaceID uuid.UUID, targetSpaceID uuid.UUID, wi WorkItem, modifierID uuid.UUID) (*WorkItem, error) {
 	res := WorkItemStorage{}
 	id, err := strconv.ParseUint(wi.ID, 10, 64)
 	if err != nil || id == 0 {
@@ -439,6 +530,14 @@ func (r *GormWorkItemRepository) Save(ctx context.Context, spaceID uuid.UUID, wi
 
 	res.Version = res.Version + 1
 	res.Type = wi.Type
+	if !uuid.Equal(res.SpaceID, targetSpaceID) {
+		// the work item gets a new number in the space it is moved to
+		res.Number, err = r.nextNumber(targetSpaceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.SpaceID = targetSpaceID
 	res.Fields = Fields{}
 	res.ExecutionOrder = wi.Fields[SystemOrder].(float64)
 	for fieldName, fieldDef := range wiType.Fields {
@@ -455,6 +554,9 @@ func (r *GormWorkItemRepository) Save(ctx context.Context, spaceID uuid.UUID, wi
 
 	tx = tx.Where("Version = ?", wi.Version).Save(&res)
 	if err := tx.Error; err != nil {
+		if gormsupport.IsUniqueViolation(err, titleIterationUniqueIndex) {
+			return nil, errors.NewBadParameterError(SystemTitle, res.Fields[SystemTitle]).Expected("a title not in use in the iteration")
+		}
 		log.Error(ctx, map[string]interface{}{
 			"wi_id":    wi.ID,
 			"space_id": spaceID,
@@ -491,11 +593,16 @@ func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID,
 		return nil, errors.NewInternalError(err.Error())
 	}
 	pos = pos + orderValue
+	number, err := r.nextNumber(spaceID)
+	if err != nil {
+		return nil, err
+	}
 	wi := WorkItemStorage{
 		Type:           typeID,
 		Fields:         Fields{},
 		ExecutionOrder: pos,
 		SpaceID:        spaceID,
+		Number:         number,
 	}
 	fields[SystemCreator] = creatorID.String()
 	for fieldName, fieldDef := range wiType.Fields {
@@ -517,6 +624,9 @@ func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID,
 	}
 	tx := r.db
 	if err = tx.Create(&wi).Error; err != nil {
+		if gormsupport.IsUniqueViolation(err, titleIterationUniqueIndex) {
+			return nil, errors.NewBadParameterError(SystemTitle, wi.Fields[SystemTitle]).Expected("a title not in use in the iteration")
+		}
 		return nil, errs.Wrapf(err, "failed to create work item")
 	}
 
@@ -533,6 +643,20 @@ func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID,
 	return witem, nil
 }
 
+// nextNumber returns the next number of the work items of the given space. The sequence of the space
+// remains locked until the end of the current transaction, hence concurrent creations get distinct numbers.
+// returns InternalError
+func (r *GormWorkItemRepository) nextNumber(spaceID uuid.UUID) (int, error) {
+	var number int
+	err := r.db.Raw(`INSERT INTO work_item_number_sequences (space_id, current_val) VALUES (?, 1)
+		ON CONFLICT (space_id) DO UPDATE SET current_val = work_item_number_sequences.current_val + 1
+		RETURNING current_val`, spaceID).Row().Scan(&number)
+	if err != nil {
+		return 0, errors.NewInternalError(err.Error())
+	}
+	return number, nil
+}
+
 // ConvertWorkItemStorageToModel convert work item model to app WI
 func ConvertWorkItemStorageToModel(wiType *WorkItemType, wi *WorkItemStorage) (*WorkItem, error) {
 	result, err := wiType.ConvertWorkItemStorageToModel(*wi)
@@ -674,6 +798,36 @@ func (r *GormWorkItemRepository) Count(ctx context.Context, spaceID uuid.UUID, c
 	return count, nil
 }
 
+// ReplaceIdentity replaces the given identity by the new one as the creator and as an assignee of all the work items,
+// including the deleted ones. No revision is recorded, since the work items are not modified by a user.
+// returns InternalError
+func (r *GormWorkItemRepository) ReplaceIdentity(ctx context.Context, oldIdentityID uuid.UUID, newIdentityID uuid.UUID) error {
+	tableName := WorkItemStorage{}.TableName()
+	tx := r.db.Exec(fmt.Sprintf(`UPDATE %s SET fields = jsonb_set(fields, '{%s}', to_jsonb(?::text))
+		WHERE fields->>'%s' = ?`, tableName, SystemCreator, SystemCreator), newIdentityID.String(), oldIdentityID.String())
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	creatorCount := tx.RowsAffected
+	// the assignees keep their order, the new identity taking the place of the first one of them it is replacing or already is
+	tx = r.db.Exec(fmt.Sprintf(`UPDATE %[1]s SET fields = jsonb_set(fields, '{%[2]s}', (
+			SELECT jsonb_agg(r.a ORDER BY r.n) FROM (
+				SELECT CASE WHEN e.a = ? THEN ? ELSE e.a END AS a, MIN(e.n) AS n
+				FROM jsonb_array_elements_text(fields->'%[2]s') WITH ORDINALITY e(a, n) GROUP BY 1) r))
+		WHERE jsonb_typeof(fields->'%[2]s') = 'array' AND fields->'%[2]s' @> jsonb_build_array(?::text)`, tableName, SystemAssignees),
+		oldIdentityID.String(), newIdentityID.String(), oldIdentityID.String())
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{
+		"old_identity_id": oldIdentityID,
+		"new_identity_id": newIdentityID,
+		"created":         creatorCount,
+		"assigned":        tx.RowsAffected,
+	}, "identity replaced in the work items")
+	return nil
+}
+
 // Fetch fetches the (first) work item matching by the given criteria.Expression.
 func (r *GormWorkItemRepository) Fetch(ctx context.Context, spaceID uuid.UUID, criteria criteria.Expression) (*WorkItem, error) {
 	limit := 1
@@ -2,7 +2,9 @@ package workitem_test
 
 import (
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/errors"
@@ -109,6 +111,78 @@ func (s *workItemRepoBlackBoxTest) TestFaiLoadZeroID() {
 	assert.IsType(s.T(), errors.NotFoundError{}, errs.Cause(err))
 }
 
+func (s *workItemRepoBlackBoxTest) TestRestore() {
+	// given
+	createDeletedWorkItem := func() *workitem.WorkItem {
+		wi, err := s.repo.Create(
+			s.ctx, s.spaceID, workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: "Title",
+				workitem.SystemState: workitem.SystemStateNew,
+			}, s.creatorID)
+		require.Nil(s.T(), err, "Could not create work item")
+		err = s.repo.Delete(s.ctx, s.spaceID, wi.ID, s.creatorID)
+		require.Nil(s.T(), err, "Could not delete work item")
+		return wi
+	}
+
+	s.T().Run("ok - within window", func(t *testing.T) {
+		// given
+		wi := createDeletedWorkItem()
+		// when
+		restored, err := s.repo.Restore(s.ctx, s.spaceID, wi.ID, s.creatorID, time.Hour)
+		// then
+		require.Nil(t, err)
+		assert.Equal(t, wi.ID, restored.ID)
+		loaded, err := s.repo.Load(s.ctx, s.spaceID, wi.ID)
+		require.Nil(t, err)
+		assert.Equal(t, "Title", loaded.Fields[workitem.SystemTitle])
+	})
+
+	s.T().Run("fail - window elapsed", func(t *testing.T) {
+		// given
+		wi := createDeletedWorkItem()
+		err := s.DB.Unscoped().Model(&workitem.WorkItemStorage{}).Where("id = ?", wi.ID).
+			UpdateColumn("deleted_at", time.Now().Add(-2*time.Hour)).Error
+		require.Nil(t, err)
+		// when
+		_, err = s.repo.Restore(s.ctx, s.spaceID, wi.ID, s.creatorID, time.Hour)
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.NotFoundError{}, errs.Cause(err))
+		_, err = s.repo.Load(s.ctx, s.spaceID, wi.ID)
+		assert.IsType(t, errors.NotFoundError{}, errs.Cause(err))
+	})
+
+	s.T().Run("fail - purged", func(t *testing.T) {
+		// given
+		wi := createDeletedWorkItem()
+		err := s.DB.Unscoped().Where("id = ?", wi.ID).Delete(&workitem.WorkItemStorage{}).Error
+		require.Nil(t, err)
+		// when
+		_, err = s.repo.Restore(s.ctx, s.spaceID, wi.ID, s.creatorID, time.Hour)
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.NotFoundError{}, errs.Cause(err))
+	})
+
+	s.T().Run("fail - not deleted", func(t *testing.T) {
+		// given
+		wi, err := s.repo.Create(
+			s.ctx, s.spaceID, workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: "Title",
+				workitem.SystemState: workitem.SystemStateNew,
+			}, s.creatorID)
+		require.Nil(t, err)
+		// when
+		_, err = s.repo.Restore(s.ctx, s.spaceID, wi.ID, s.creatorID, time.Hour)
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+	})
+}
+
 func (s *workItemRepoBlackBoxTest) TestSaveAssignees() {
 	// given
 	wi, err := s.repo.Create(
@@ -126,6 +200,26 @@ func (s *workItemRepoBlackBoxTest) TestSaveAssignees() {
 	assert.Equal(s.T(), "A", wi.Fields[workitem.SystemAssignees].([]interface{})[0])
 }
 
+func (s *workItemRepoBlackBoxTest) TestReplaceIdentityKeepsAssigneesOrder() {
+	// given
+	oldID, newID := uuid.NewV4().String(), uuid.NewV4().String()
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:     "Title",
+			workitem.SystemState:     workitem.SystemStateNew,
+			workitem.SystemAssignees: []string{"C", oldID, "A", newID, "B"},
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	// when
+	err = s.repo.ReplaceIdentity(s.ctx, uuid.FromStringOrNil(oldID), uuid.FromStringOrNil(newID))
+	// then
+	require.Nil(s.T(), err)
+	wi, err = s.repo.LoadByID(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []interface{}{"C", newID, "A", "B"}, wi.Fields[workitem.SystemAssignees])
+}
+
 func (s *workItemRepoBlackBoxTest) TestSaveForUnchangedCreatedDate() {
 	// given
 	wi, err := s.repo.Create(
@@ -301,3 +395,123 @@ func (s *workItemRepoBlackBoxTest) TestCodebaseAttributes() {
 	assert.Equal(s.T(), file, cb.FileName)
 	assert.Equal(s.T(), line, cb.LineNumber)
 }
+
+func (s *workItemRepoBlackBoxTest) TestCreateAssignsIncreasingNumbersPerSpace() {
+	// given
+	sp, err := space.NewRepository(s.DB).Create(s.ctx, &space.Space{
+		Name:    "TestCreateAssignsIncreasingNumbersPerSpace-" + uuid.NewV4().String(),
+		OwnerId: s.creatorID,
+	})
+	require.Nil(s.T(), err)
+	// when
+	var workitems []*workitem.WorkItem
+	for i := 0; i < 3; i++ {
+		wi, err := s.repo.Create(
+			s.ctx, sp.ID, workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: fmt.Sprintf("Numbered %d", i),
+				workitem.SystemState: workitem.SystemStateNew,
+			}, s.creatorID)
+		require.Nil(s.T(), err)
+		workitems = append(workitems, wi)
+	}
+	// then
+	for i, wi := range workitems {
+		assert.Equal(s.T(), i+1, wi.Number)
+	}
+	loaded, err := s.repo.Load(s.ctx, sp.ID, workitems[2].ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 3, loaded.Number)
+}
+
+func (s *workItemRepoBlackBoxTest) TestConcurrentCreateAssignsUniqueNumbers() {
+	// given
+	sp, err := space.NewRepository(s.DB).Create(s.ctx, &space.Space{
+		Name:    "TestConcurrentCreateAssignsUniqueNumbers-" + uuid.NewV4().String(),
+		OwnerId: s.creatorID,
+	})
+	require.Nil(s.T(), err)
+	count := 10
+	numbers := make(chan int, count)
+	failures := make(chan error, count)
+	var wg sync.WaitGroup
+	// when
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx := s.DB.Begin()
+			wi, err := workitem.NewWorkItemRepository(tx).Create(
+				s.ctx, sp.ID, workitem.SystemBug,
+				map[string]interface{}{
+					workitem.SystemTitle: fmt.Sprintf("Concurrent %d", i),
+					workitem.SystemState: workitem.SystemStateNew,
+				}, s.creatorID)
+			if err != nil {
+				tx.Rollback()
+				failures <- err
+				return
+			}
+			if err := tx.Commit().Error; err != nil {
+				failures <- err
+				return
+			}
+			numbers <- wi.Number
+		}(i)
+	}
+	wg.Wait()
+	close(numbers)
+	close(failures)
+	// then
+	for err := range failures {
+		require.Nil(s.T(), err)
+	}
+	seen := map[int]bool{}
+	for n := range numbers {
+		assert.False(s.T(), seen[n], "number %d assigned twice", n)
+		seen[n] = true
+	}
+	require.Len(s.T(), seen, count)
+	for n := 1; n <= count; n++ {
+		assert.True(s.T(), seen[n], "number %d not assigned", n)
+	}
+	// the next work item gets the next number
+	wi, err := s.repo.Create(
+		s.ctx, sp.ID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "After concurrent creations",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), count+1, wi.Number)
+}
+
+func (s *workItemRepoBlackBoxTest) TestLoadByNumber() {
+	// given
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "Title",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err)
+	s.T().Run("found", func(t *testing.T) {
+		// when
+		loaded, err := s.repo.LoadByNumber(s.ctx, s.spaceID, wi.Number)
+		// then
+		require.Nil(t, err)
+		assert.Equal(t, wi.ID, loaded.ID)
+	})
+	s.T().Run("not found in other space", func(t *testing.T) {
+		// when
+		_, err := s.repo.LoadByNumber(s.ctx, uuid.NewV4(), wi.Number)
+		// then
+		require.IsType(t, errors.NotFoundError{}, errs.Cause(err))
+	})
+	s.T().Run("unknown number", func(t *testing.T) {
+		// when
+		_, err := s.repo.LoadByNumber(s.ctx, s.spaceID, wi.Number+1)
+		// then
+		require.IsType(t, errors.NotFoundError{}, errs.Cause(err))
+	})
+}
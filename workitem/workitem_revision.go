@@ -47,3 +47,56 @@ const (
 func (w Revision) TableName() string {
 	return revisionTableName
 }
+
+// String returns the name of the revision type, as exposed in the API
+func (t RevisionType) String() string {
+	switch t {
+	case RevisionTypeCreate:
+		return "create"
+	case RevisionTypeDelete:
+		return "delete"
+	case RevisionTypeUpdate:
+		return "update"
+	}
+	return ""
+}
+
+// IterationChange represents the move of a work item from an iteration to another,
+// as recorded in a revision
+type IterationChange struct {
+	// the id of the revision in which the change was recorded
+	RevisionID uuid.UUID
+	// the timestamp of the change
+	Time time.Time
+	// the identity who moved the work item
+	ModifierIdentity uuid.UUID
+	// the iteration the work item was moved from (empty if none)
+	From string
+	// the iteration the work item was moved to (empty if none)
+	To string
+}
+
+// IterationChanges returns the iteration changes recorded in the given revisions
+// of a single work item. The revisions must be sorted from the oldest to the newest.
+func IterationChanges(revisions []Revision) []IterationChange {
+	changes := []IterationChange{}
+	var previous string
+	for _, revision := range revisions {
+		if revision.Type == RevisionTypeDelete {
+			// deleted work items have no fields stored in their revision
+			continue
+		}
+		current, _ := revision.WorkItemFields[SystemIteration].(string)
+		if revision.Type == RevisionTypeUpdate && current != previous {
+			changes = append(changes, IterationChange{
+				RevisionID:       revision.ID,
+				Time:             revision.Time,
+				ModifierIdentity: revision.ModifierIdentity,
+				From:             previous,
+				To:               current,
+			})
+		}
+		previous = current
+	}
+	return changes
+}
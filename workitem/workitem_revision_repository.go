@@ -19,6 +19,8 @@ type RevisionRepository interface {
 	Create(ctx context.Context, modifierID uuid.UUID, revisionType RevisionType, workitem WorkItemStorage) error
 	// List retrieves all revisions for a given work item
 	List(ctx context.Context, workitemID string) ([]Revision, error)
+	// LastActivities retrieves the last time each of the given identities modified or commented a work item of the given space
+	LastActivities(ctx context.Context, spaceID uuid.UUID, identityIDs []uuid.UUID) (map[uuid.UUID]time.Time, error)
 }
 
 // NewRevisionRepository creates a GormRevisionRepository
@@ -68,3 +70,36 @@ func (r *GormRevisionRepository) List(ctx context.Context, workitemID string) ([
 	}
 	return revisions, nil
 }
+
+// LastActivities retrieves the last time each of the given identities created, updated, deleted or commented
+// a work item of the given space, based on the revisions of the work items and of their comments.
+// The identities without any activity in the space are not part of the result.
+func (r *GormRevisionRepository) LastActivities(ctx context.Context, spaceID uuid.UUID, identityIDs []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	result := make(map[uuid.UUID]time.Time, len(identityIDs))
+	if len(identityIDs) == 0 {
+		return result, nil
+	}
+	query := fmt.Sprintf(`SELECT a.modifier_id, max(a.revision_time) FROM (
+			SELECT r.modifier_id, r.revision_time FROM %[1]s r
+			JOIN %[2]s wi ON wi.id = r.work_item_id
+			WHERE wi.space_id = ? AND r.modifier_id IN (?)
+		UNION ALL
+			SELECT cr.modifier_id, cr.revision_time FROM comment_revisions cr
+			JOIN %[2]s wi ON wi.id::text = cr.comment_parent_id
+			WHERE wi.space_id = ? AND cr.modifier_id IN (?)
+		) a GROUP BY a.modifier_id`, revisionTableName, workitemTableName)
+	rows, err := r.db.Raw(query, spaceID, identityIDs, spaceID, identityIDs).Rows()
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("failed to retrieve the last activities in space %s: %s", spaceID, err.Error()))
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var identityID uuid.UUID
+		var lastActivity time.Time
+		if err := rows.Scan(&identityID, &lastActivity); err != nil {
+			return nil, errors.NewInternalError(fmt.Sprintf("failed to retrieve the last activities in space %s: %s", spaceID, err.Error()))
+		}
+		result[identityID] = lastActivity
+	}
+	return result, nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/almighty/almighty-core/workitem"
 
 	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -140,3 +141,42 @@ func (s *workItemRevisionRepositoryBlackBoxTest) TestStoreRevisions() {
 	assert.Equal(s.T(), s.testIdentity3.ID, revision4.ModifierIdentity)
 	require.Empty(s.T(), revision4.WorkItemFields)
 }
+
+func (s *workItemRevisionRepositoryBlackBoxTest) TestIterationChanges() {
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+
+	// given
+	iteration1 := uuid.NewV4().String()
+	iteration2 := uuid.NewV4().String()
+	// create a workitem in iteration 1
+	workItem, err := s.repository.Create(
+		ctx, space.SystemSpace, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:     "Title",
+			workitem.SystemState:     workitem.SystemStateNew,
+			workitem.SystemIteration: iteration1,
+		}, s.testIdentity1.ID)
+	require.Nil(s.T(), err)
+	// modify the workitem without changing its iteration
+	workItem.Fields[workitem.SystemTitle] = "Updated Title"
+	workItem, err = s.repository.Save(
+		ctx, space.SystemSpace, *workItem, s.testIdentity1.ID)
+	require.Nil(s.T(), err)
+	// move the workitem to iteration 2
+	workItem.Fields[workitem.SystemIteration] = iteration2
+	workItem, err = s.repository.Save(
+		ctx, space.SystemSpace, *workItem, s.testIdentity2.ID)
+	require.Nil(s.T(), err)
+	// when
+	revisions, err := s.revisionRepository.List(ctx, workItem.ID)
+	require.Nil(s.T(), err)
+	changes := workitem.IterationChanges(revisions)
+	// then
+	require.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), iteration1, changes[0].From)
+	assert.Equal(s.T(), iteration2, changes[0].To)
+	assert.Equal(s.T(), s.testIdentity2.ID, changes[0].ModifierIdentity)
+	assert.False(s.T(), changes[0].Time.IsZero())
+}
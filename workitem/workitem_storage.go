@@ -24,6 +24,8 @@ type WorkItemStorage struct {
 	ExecutionOrder float64
 	// Reference to one Space
 	SpaceID uuid.UUID `sql:"type:uuid"`
+	// sequential number of the work item in its space
+	Number int
 }
 
 const (
@@ -64,6 +66,9 @@ func (wi WorkItemStorage) Equal(u convert.Equaler) bool {
 	if wi.SpaceID != other.SpaceID {
 		return false
 	}
+	if wi.Number != other.Number {
+		return false
+	}
 	return wi.Fields.Equal(other.Fields)
 }
 
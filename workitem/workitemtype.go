@@ -31,6 +31,7 @@ const (
 	SystemIteration           = "system.iteration"
 	SystemArea                = "system.area"
 	SystemCodebase            = "system.codebase"
+	SystemDueDate             = "system.due_date"
 
 	SystemStateOpen       = "open"
 	SystemStateNew        = "new"
@@ -168,6 +169,7 @@ func (wit WorkItemType) ConvertWorkItemStorageToModel(workItem WorkItemStorage)
 		Version: workItem.Version,
 		Fields:  map[string]interface{}{},
 		SpaceID: workItem.SpaceID,
+		Number:  workItem.Number,
 	}
 
 	for name, field := range wit.Fields {
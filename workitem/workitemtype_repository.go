@@ -2,6 +2,7 @@ package workitem
 
 import (
 	"fmt"
+	"sort"
 
 	"golang.org/x/net/context"
 
@@ -19,9 +20,11 @@ var cache = NewWorkItemTypeCache()
 // WorkItemTypeRepository encapsulates storage & retrieval of work item types
 type WorkItemTypeRepository interface {
 	Load(ctx context.Context, spaceID uuid.UUID, id uuid.UUID) (*WorkItemType, error)
+	LoadByID(ctx context.Context, id uuid.UUID) (*WorkItemType, error)
 	Create(ctx context.Context, spaceID uuid.UUID, id *uuid.UUID, extendedTypeID *uuid.UUID, name string, description *string, icon string, fields map[string]FieldDefinition) (*WorkItemType, error)
 	List(ctx context.Context, spaceID uuid.UUID, start *int, length *int) ([]WorkItemType, error)
 	ListPlannerItems(ctx context.Context, spaceID uuid.UUID) ([]WorkItemType, error)
+	ListInSpaces(ctx context.Context, spaceIDs []uuid.UUID) ([]WorkItemType, error)
 }
 
 // NewWorkItemTypeRepository creates a wi type repository based on gorm
@@ -191,3 +194,30 @@ func (r *GormWorkItemTypeRepository) List(ctx context.Context, spaceID uuid.UUID
 	}
 	return rows, nil
 }
+
+// ListInSpaces returns all the work item types of the given spaces, ordered by name (and by ID for types with the same name)
+func (r *GormWorkItemTypeRepository) ListInSpaces(ctx context.Context, spaceIDs []uuid.UUID) ([]WorkItemType, error) {
+	var rows []WorkItemType
+	if err := r.db.Where("space_id IN (?)", spaceIDs).Find(&rows).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"space_ids": spaceIDs,
+			"err":       err,
+		}, "unable to list the work item types of the spaces")
+		return nil, errs.WithStack(err)
+	}
+	// sorting here rather than in the query keeps the order independent of the collation of the DB
+	sort.Sort(workItemTypesByName(rows))
+	return rows, nil
+}
+
+// workItemTypesByName sorts work item types by name, then by ID
+type workItemTypesByName []WorkItemType
+
+func (t workItemTypesByName) Len() int      { return len(t) }
+func (t workItemTypesByName) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t workItemTypesByName) Less(i, j int) bool {
+	if t[i].Name != t[j].Name {
+		return t[i].Name < t[j].Name
+	}
+	return t[i].ID.String() < t[j].ID.String()
+}